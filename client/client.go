@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"net"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Zereker/game/protocol"
 	"github.com/Zereker/socket"
@@ -12,19 +17,60 @@ import (
 	"github.com/pkg/errors"
 )
 
+// clientName 握手时上报的客户端名称
+const clientName = "werewolf-terminal"
+
 // ClientState 客户端状态
 type ClientState struct {
 	PlayerID     string
 	Username     string
 	RoomID       string
+	OwnerID      string
 	MyRole       werewolf.RoleType
 	MyCamp       werewolf.Camp
+	Teammates    []string                 // 狼人同伴的玩家ID，不是狼人时为空
+	KnownCamps   map[string]werewolf.Camp // 预言家已得知的查验结果，随对局状态下发
+	Lover        string                   // 被丘比特连接后自己的恋人
+	ExtraRoles   []werewolf.RoleType      // 盗贼还没选的两张底牌
 	GamePhase    werewolf.PhaseType
 	Round        int
 	Players      []protocol.PlayerInfo
 	AlivePlayers []string
+	StateVersion uint64 // 最近一次应用的对局状态版本，增量不连续时请求完整状态
 	Events       []string
+	Chat         []string // 玩家发言，终端界面中单独一栏显示
 	IsInGame     bool
+	Skills       []protocol.SkillInfo // 当前阶段可用技能
+	Preferences  protocol.Preferences
+	Typing       int                  // 白天正在输入发言的人数
+	RTT          time.Duration        // 最近一次心跳测得的往返延迟
+	GuardRules   *protocol.GuardRules // 本局守卫规则，只有守卫会收到
+	Rules        *protocol.GameRules  // 本局对局规则
+
+	SheriffStage   string // 警长竞选阶段，没有开启警长规则时为空
+	SheriffID      string
+	CurrentSpeaker string // 白天按顺序发言时当前应发言的玩家
+
+	MyInfo MyInfo // 自己掌握的私有信息，见 myinfo.go
+
+	StartingAt     time.Time // 开局倒计时结束的时间，没有倒计时时为零值
+	ReadyDeadline  time.Time // 房间移出未准备玩家的时间，没有计时时为零值
+	SkillsDeadline time.Time // 可用技能中有时限的一步（轮到发言、盗贼选牌）的截止时间，不限时为零值
+
+	SkillPrompt *protocol.SkillPromptData // 等待回答的女巫用药或狼王开枪提示，见 witch.go
+
+	Picker *TargetPicker // 正在等待选择的目标菜单，见 picker.go
+
+	Summary *protocol.GameSummaryData // 上一局的复盘，新对局开始或离开房间时清空，见 recap.go
+
+	Revealed int // 结算界面已揭晓身份的玩家数，见 recap.go
+
+	Spectating       bool              // 正在观战，不占座位，见 spectate.go
+	SpectateRequests map[string]string // 等待自己（房主）答复的观战请求：用户名 -> 玩家ID
+
+	Profile protocol.Profile // 自己的玩家资料，登录成功时由服务器下发，见 profile.go
+
+	RoomState string // 所在房间的状态（WAITING、STARTING、PLAYING 等），见 roomstate.go
 }
 
 // Client 客户端
@@ -32,11 +78,34 @@ type Client struct {
 	conn    *socket.Conn
 	state   *ClientState
 	ui      *UI
+	screen  Screen
 	input   *InputHandler
 	logger  *slog.Logger
 	mu      sync.RWMutex
 	ctx     context.Context
 	cancel  context.CancelFunc
+
+	tcpConn   *net.TCPConn
+	outgoing  chan *protocol.Message // 发送队列，由 writeLoop 按顺序写出
+	flushed   chan struct{}          // writeLoop 退出时关闭
+	done      chan struct{}          // 进入关闭流程时关闭
+	closeOnce sync.Once
+	closeErr  error
+
+	inboundMiddlewares  []Middleware
+	outboundMiddlewares []Middleware
+	inbound             MessageHandler // 套上中间件的入站处理，Connect 时生成
+	outbound            MessageHandler // 套上中间件的出站处理，Connect 时生成
+
+	seq      seqTracker     // 服务器消息序号
+	requests requestTracker // 等待响应的请求
+	votes    voteTracker    // 等待结果的投票
+
+	msgTime time.Time // 正在处理的消息的发送时间，用于给事件打时间戳
+
+	handlers map[protocol.MessageType]MessageHandler // 按消息类型注册的处理器，见 handlers.go
+
+	langFixed bool // 用 -lang 指定了界面语言，不跟随账号保存的偏好
 }
 
 // NewClient 创建新客户端
@@ -45,19 +114,38 @@ func NewClient(logger *slog.Logger) *Client {
 
 	client := &Client{
 		state: &ClientState{
-			Events: make([]string, 0),
+			Events:      make([]string, 0),
+			Preferences: protocol.DefaultPreferences(),
 		},
 		ui:     NewUI(),
 		logger: logger,
 		ctx:    ctx,
 		cancel: cancel,
+
+		outgoing: make(chan *protocol.Message, 64),
+		flushed:  make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 
-	client.input = NewInputHandler(client)
+	client.input = NewInputHandler(client, os.Stdin)
+	client.screen = &lineScreen{ui: client.ui, input: client.input}
+	client.registerHandlers()
+	client.Use(LoggingMiddleware(logger, "received message"))
+	client.UseOutbound(LoggingMiddleware(logger, "sent message"))
 
 	return client
 }
 
+// SetLanguage 指定界面语言，之后不再跟随账号保存的语言偏好，需在 Run 之前调用
+func (c *Client) SetLanguage(lang string) error {
+	if err := setLanguage(lang); err != nil {
+		return err
+	}
+
+	c.langFixed = true
+	return nil
+}
+
 // Connect 连接服务器
 func (c *Client) Connect(addr string) error {
 	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
@@ -78,9 +166,12 @@ func (c *Client) Connect(addr string) error {
 		return true // 断开连接
 	})
 
+	c.inbound = chain(c.handleMessage, c.inboundMiddlewares)
+	c.outbound = chain(c.enqueue, c.outboundMiddlewares)
+
 	onMessageOption := socket.OnMessageOption(func(m socket.Message) error {
 		msg := m.(*protocol.Message)
-		return c.handleMessage(msg)
+		return c.inbound(msg)
 	})
 
 	// 创建连接
@@ -90,15 +181,30 @@ func (c *Client) Connect(addr string) error {
 	}
 
 	c.conn = conn
+	c.tcpConn = tcpConn
 
 	c.logger.Info("connected to server", "addr", addr)
 
-	// 在后台运行连接
+	// 在后台运行连接，连接意外断开时结束客户端
 	go func() {
 		if err := c.conn.Run(c.ctx); err != nil {
 			c.logger.Error("connection run error", "error", err)
 		}
+		c.shutdown(ErrConnectionLost)
 	}()
+	go c.writeLoop()
+
+	// 握手，声明本客户端支持的协议版本
+	helloMsg, err := protocol.NewHelloMessage(clientName)
+	if err != nil {
+		return err
+	}
+
+	if err := c.SendMessage(helloMsg); err != nil {
+		return err
+	}
+
+	go c.heartbeat()
 
 	return nil
 }
@@ -109,7 +215,9 @@ func (c *Client) SendMessage(msg *protocol.Message) error {
 		return errors.New("not connected")
 	}
 
-	return c.conn.Write(msg)
+	c.requests.assign(msg)
+
+	return c.outbound(msg)
 }
 
 // handleMessage 处理服务器消息
@@ -117,210 +225,354 @@ func (c *Client) handleMessage(msg *protocol.Message) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.logger.Info("received message", "type", msg.Type)
-
-	switch msg.Type {
-	case protocol.MsgLoginSuccess:
-		return c.handleLoginSuccess(msg)
-	case protocol.MsgRoomCreated:
-		return c.handleRoomCreated(msg)
-	case protocol.MsgRoomJoined:
-		return c.handleRoomJoined(msg)
-	case protocol.MsgPlayerJoined:
-		return c.handlePlayerJoined(msg)
-	case protocol.MsgPlayerLeft:
-		return c.handlePlayerLeft(msg)
-	case protocol.MsgPlayerReady:
-		return c.handlePlayerReady(msg)
-	case protocol.MsgGameStarted:
-		return c.handleGameStarted(msg)
-	case protocol.MsgPhaseChanged:
-		return c.handlePhaseChanged(msg)
-	case protocol.MsgGameState:
-		return c.handleGameState(msg)
-	case protocol.MsgGameEvent:
-		return c.handleGameEvent(msg)
-	case protocol.MsgActionResult:
-		return c.handleActionResult(msg)
-	case protocol.MsgGameEnded:
-		return c.handleGameEnded(msg)
-	case protocol.MsgError:
-		return c.handleError(msg)
-	default:
+	process, gapFrom, gapTo := c.seq.accept(msg.Seq)
+	if gapTo != 0 {
+		c.logger.Warn("missed messages, requesting resend", "from", gapFrom, "to", gapTo)
+		if resendMsg, err := protocol.NewResendMessage(gapFrom, gapTo); err == nil {
+			c.SendMessage(resendMsg)
+		}
+	}
+	if !process {
+		c.logger.Info("duplicate message ignored", "seq", msg.Seq)
+		return nil
+	}
+
+	// 动作排队确认之后还会收到 ACTION_RESULT，请求到那时才算完成
+	if msg.Type == protocol.MsgActionAccepted {
+		return nil
+	}
+
+	defer c.requests.done(msg.CorrelationID)
+
+	c.msgTime = msg.Time()
+
+	handler, ok := c.handlers[msg.Type]
+	if !ok {
 		c.logger.Warn("unknown message type", "type", msg.Type)
+		return nil
 	}
 
-	return nil
+	return handler(msg)
 }
 
 // handleLoginSuccess 处理登录成功
-func (c *Client) handleLoginSuccess(msg *protocol.Message) error {
-	var data protocol.LoginSuccessData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
-	}
-
+func (c *Client) handleLoginSuccess(data *protocol.LoginSuccessData) error {
 	c.state.PlayerID = data.PlayerID
-	c.addEvent("登录成功，玩家ID: " + data.PlayerID)
+	c.state.Username = data.Username
+	c.state.Profile = protocol.Profile{}
+	if data.Profile != nil {
+		c.state.Profile = *data.Profile
+	}
+	if data.IsGuest {
+		c.addEvent(tr("event.login_guest", data.PlayerID))
+	} else {
+		c.addEvent(tr("event.login", data.PlayerID))
+	}
 	c.Render()
 
-	return nil
-}
-
-// handleRoomCreated 处理房间创建
-func (c *Client) handleRoomCreated(msg *protocol.Message) error {
-	var data protocol.RoomCreatedData
-	if err := msg.UnmarshalData(&data); err != nil {
+	// 拉取服务器保存的偏好设置
+	prefsMsg, err := protocol.NewGetPreferencesMessage()
+	if err != nil {
 		return err
 	}
 
+	return c.SendMessage(prefsMsg)
+}
+
+// handleRoomCreated 处理房间创建
+func (c *Client) handleRoomCreated(data *protocol.RoomCreatedData) error {
 	c.state.RoomID = data.RoomID
-	c.addEvent("房间创建成功，房间ID: " + data.RoomID)
+	c.state.RoomState = "WAITING"
+	c.addEvent(tr("event.room_created", data.RoomID))
 
 	return nil
 }
 
 // handleRoomJoined 处理加入房间
-func (c *Client) handleRoomJoined(msg *protocol.Message) error {
-	var data protocol.RoomJoinedData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
-	}
-
+func (c *Client) handleRoomJoined(data *protocol.RoomJoinedData) error {
 	c.state.RoomID = data.RoomID
+	c.state.RoomState = "WAITING" // 重连时随后补发实际状态
+	c.state.OwnerID = data.OwnerID
 	c.state.Players = data.Players
-	c.addEvent("加入房间: " + data.RoomID)
+	c.addEvent(tr("event.room_joined", data.RoomID))
 	c.Render()
 
 	return nil
 }
 
 // handlePlayerJoined 处理玩家加入
-func (c *Client) handlePlayerJoined(msg *protocol.Message) error {
-	var data protocol.PlayerJoinedData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
-	}
-
+func (c *Client) handlePlayerJoined(data *protocol.PlayerJoinedData) error {
 	c.state.Players = append(c.state.Players, data.Player)
-	c.addEvent("玩家加入: " + data.Player.Username)
+	c.addEvent(tr("event.player_joined", data.Player.Username))
+	if c.state.Preferences.Notifications.PlayerJoins {
+		c.ui.Bell()
+	}
 	c.Render()
 
 	return nil
 }
 
 // handlePlayerLeft 处理玩家离开
-func (c *Client) handlePlayerLeft(msg *protocol.Message) error {
-	var data protocol.PlayerLeftData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
+func (c *Client) handlePlayerLeft(data *protocol.PlayerLeftData) error {
+	if data.PlayerID == c.state.PlayerID {
+		c.leaveRoom()
+		c.addEvent(tr("event.you_left"))
+		c.Render()
+		return nil
 	}
 
-	// 从玩家列表中移除
+	name := c.playerName(data.PlayerID)
+
+	// 对局中离开的玩家保留座位，视为出局
+	if data.Abandoned {
+		for i, p := range c.state.Players {
+			if p.ID == data.PlayerID {
+				c.state.Players[i].IsAlive = false
+				break
+			}
+		}
+		c.addEvent(tr("event.player_abandoned", name))
+		c.Render()
+		return nil
+	}
+
+	// 有人离开后房间不再满员，准备计时随之取消
+	c.state.ReadyDeadline = time.Time{}
+
+	// 从玩家列表中移除，其后的玩家座位号前移
 	for i, p := range c.state.Players {
 		if p.ID == data.PlayerID {
 			c.state.Players = append(c.state.Players[:i], c.state.Players[i+1:]...)
 			break
 		}
 	}
+	for i := range c.state.Players {
+		c.state.Players[i].Seat = i + 1
+	}
 
-	c.addEvent("玩家离开: " + data.PlayerID)
+	c.addEvent(tr("event.player_left", name))
 	c.Render()
 
 	return nil
 }
 
 // handlePlayerReady 处理玩家准备
-func (c *Client) handlePlayerReady(msg *protocol.Message) error {
-	var data protocol.PlayerReadyData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
-	}
-
-	// 更新玩家准备状态
+func (c *Client) handlePlayerReady(data *protocol.PlayerReadyData) error {
+	// 更新玩家准备状态，记下未准备的玩家是否本来就没准备
+	wasReady := false
 	for i, p := range c.state.Players {
 		if p.ID == data.PlayerID {
+			wasReady = p.IsReady
 			c.state.Players[i].IsReady = data.IsReady
 			break
 		}
 	}
 
-	status := "准备"
-	if !data.IsReady {
-		status = "取消准备"
+	// 每条准备消息都带着房间当前的准备计时，0 表示没有计时
+	c.state.ReadyDeadline = time.Time{}
+	if data.ReadyTimeout > 0 {
+		c.state.ReadyDeadline = time.Now().Add(time.Duration(data.ReadyTimeout) * time.Second)
 	}
 
-	c.addEvent("玩家" + data.PlayerID + status)
+	switch {
+	case data.IsReady:
+		c.addEvent(tr("event.player_ready", data.PlayerID))
+	case !wasReady && data.ReadyTimeout > 0:
+		// 房间开始为本来就没准备的玩家计时
+		c.addEvent(tr("event.ready_timeout", c.playerName(data.PlayerID), data.ReadyTimeout))
+	default:
+		c.addEvent(tr("event.player_unready", data.PlayerID))
+	}
 	c.Render()
 
 	return nil
 }
 
 // handleGameStarted 处理游戏开始
-func (c *Client) handleGameStarted(msg *protocol.Message) error {
-	var data protocol.GameStartedData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
-	}
-
+func (c *Client) handleGameStarted(data *protocol.GameStartedData) error {
+	c.resetMyInfo()
+	c.state.StartingAt = time.Time{}
+	c.state.ReadyDeadline = time.Time{}
+	c.state.Summary = nil
 	c.state.MyRole = data.RoleType
 	c.state.MyCamp = data.Camp
+	c.state.Teammates = data.Teammates
+	c.state.GuardRules = data.GuardRules
+	c.state.Rules = data.Rules
 	c.state.Players = data.Players
+	c.state.ExtraRoles = data.ExtraRoles
 	c.state.IsInGame = true
 	c.state.Round = 1
-	c.addEvent("游戏开始！")
+	c.addEvent(tr("event.game_started"))
+	if anonymousSeats(data.Players) {
+		c.addEvent(tr("event.anonymous_seats"))
+	}
+	c.addTeammatesEvent()
+	if len(data.ExtraRoles) > 0 {
+		cards := make([]string, 0, len(data.ExtraRoles))
+		for i, role := range data.ExtraRoles {
+			cards = append(cards, fmt.Sprintf("%d. %s", i+1, c.ui.roleName(role)))
+		}
+		c.addEvent(tr("event.extra_roles", strings.Join(cards, tr("list_sep"))))
+	}
+	if c.state.Preferences.Notifications.GameStart {
+		c.ui.Bell()
+	}
 	c.Render()
 
 	return nil
 }
 
-// handlePhaseChanged 处理阶段变化
-func (c *Client) handlePhaseChanged(msg *protocol.Message) error {
-	var data protocol.PhaseChangedData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
+// handleRoleInfo 处理身份变化（盗贼换牌后重新下发的角色信息）
+func (c *Client) handleRoleInfo(data *protocol.RoleInfoData) error {
+	if data.RoleType != c.state.MyRole {
+		c.addEvent(tr("event.role_changed", c.ui.roleName(data.RoleType)))
+	}
+	c.state.MyRole = data.RoleType
+	c.state.MyCamp = data.Camp
+	c.state.Teammates = data.Teammates
+	c.state.GuardRules = data.GuardRules
+	c.state.ExtraRoles = nil
+	c.addTeammatesEvent()
+	c.Render()
+
+	return nil
+}
+
+// addTeammatesEvent 狼人看到同伴名单
+func (c *Client) addTeammatesEvent() {
+	if len(c.state.Teammates) == 0 {
+		return
 	}
 
+	names := make([]string, 0, len(c.state.Teammates))
+	for _, id := range c.state.Teammates {
+		names = append(names, c.playerName(id))
+	}
+	c.addEvent(tr("event.teammates", strings.Join(names, tr("list_sep"))))
+}
+
+// handlePhaseChanged 处理阶段变化
+func (c *Client) handlePhaseChanged(data *protocol.PhaseChangedData) error {
 	c.state.GamePhase = data.Phase
 	c.state.Round = data.Round
+	c.state.Typing = 0
+	// 狼王的开枪提示跨越阶段变化，仍在时限内有效
+	if prompt := c.state.SkillPrompt; prompt != nil && prompt.Step != protocol.SkillStepShoot {
+		c.state.SkillPrompt = nil
+	}
 
-	phaseName := c.ui.phaseName(data.Phase)
-	c.addEvent("阶段变化: " + phaseName)
+	c.addEvent(tr("event.phase_changed", c.ui.phaseName(data.Phase)))
 	c.Render()
 
 	return nil
 }
 
 // handleGameState 处理游戏状态
-func (c *Client) handleGameState(msg *protocol.Message) error {
-	var data protocol.GameStateData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
-	}
-
+func (c *Client) handleGameState(data *protocol.GameStateData) error {
 	c.state.GamePhase = data.Phase
 	c.state.Round = data.Round
 	c.state.Players = data.Players
 	c.state.AlivePlayers = data.AlivePlayers
+	c.state.StateVersion = data.Version
+	c.state.Teammates = data.Teammates
+	c.state.KnownCamps = data.KnownCamps
+	c.state.Lover = data.Lover
 
 	c.Render()
 
 	return nil
 }
 
-// handleGameEvent 处理游戏事件
-func (c *Client) handleGameEvent(msg *protocol.Message) error {
-	var data protocol.GameEventData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
+// handleGameStateDelta 应用对局状态的增量，版本接不上时请求完整状态
+func (c *Client) handleGameStateDelta(data *protocol.GameStateDeltaData) error {
+	if data.BaseVersion != c.state.StateVersion {
+		resync, err := protocol.NewResyncStateMessage()
+		if err != nil {
+			return err
+		}
+		return c.SendMessage(resync)
 	}
 
-	c.addEvent(data.Message)
+	if data.Phase != "" {
+		c.state.GamePhase = data.Phase
+	}
+	if data.Round != 0 {
+		c.state.Round = data.Round
+	}
+	if data.AlivePlayers != nil {
+		c.state.AlivePlayers = data.AlivePlayers
+	}
+	for _, info := range data.Players {
+		for i := range c.state.Players {
+			if c.state.Players[i].ID == info.ID {
+				c.state.Players[i] = info
+			}
+		}
+	}
+	c.state.StateVersion = data.Version
+	c.state.Teammates = data.Teammates
+	c.state.KnownCamps = data.KnownCamps
+	c.state.Lover = data.Lover
+
 	c.Render()
 
 	return nil
 }
 
+// handleGameEvent 处理游戏事件
+func (c *Client) handleGameEvent(data *protocol.GameEventData) error {
+	c.addEvent(c.gameEventText(*data))
+	c.Render()
+
+	return nil
+}
+
+// gameEventText 按当前语言生成游戏事件的文字，不认识的事件显示服务器给出的文字
+func (c *Client) gameEventText(data protocol.GameEventData) string {
+	params := data.Params
+
+	switch data.Event {
+	case protocol.EventKeyPlayerDied:
+		// 首夜只公布死讯时服务器不发送 cause
+		if cause, ok := params["cause"]; ok {
+			return tr("event.player_died", c.seatName(params["victim"]), trOr("cause."+cause, cause))
+		}
+		return tr("event.player_died_hidden", c.seatName(params["victim"]))
+	case protocol.EventKeyIdiotRevealed:
+		return tr("event.idiot_revealed", c.seatName(params["player"]))
+	case protocol.EventKeyKnightDuel:
+		if params["loser"] == params["target"] {
+			return tr("event.duel_wolf", c.seatName(params["knight"]), c.seatName(params["target"]))
+		}
+		return tr("event.duel_good", c.seatName(params["knight"]), c.seatName(params["target"]))
+	case protocol.EventKeyWolfKingShot:
+		return tr("event.wolf_king_shot", c.seatName(params["shooter"]), c.seatName(params["victim"]))
+	case protocol.EventKeyWolfSelfDestruct:
+		return tr("event.wolf_self_destruct", c.seatName(params["player"]))
+	case protocol.EventKeyLoversLinked:
+		return tr("event.lovers_linked", c.seatName(params["partner"]))
+	case protocol.EventKeyLoverSuicide:
+		return tr("event.lover_suicide", c.seatName(params["victim"]), c.seatName(params["partner"]))
+	case protocol.EventKeyDawnPeaceful:
+		return tr("event.dawn_peaceful", params["round"])
+	case protocol.EventKeyDawnDeaths:
+		var names []string
+		for _, id := range strings.Split(params["victims"], ",") {
+			names = append(names, c.seatName(id))
+		}
+		return tr("event.dawn_deaths", params["round"], strings.Join(names, tr("name_sep")))
+	case protocol.EventKeyDawnSilent:
+		return tr("event.dawn_silent", params["round"])
+	case protocol.EventKeyPlayerAFK:
+		return tr("event.player_afk", c.seatName(params["player"]), params["missed"])
+	case protocol.EventKeyPlayerBack:
+		return tr("event.player_back", c.seatName(params["player"]))
+	default:
+		return data.Message
+	}
+}
+
 // handleActionResult 处理动作结果
 func (c *Client) handleActionResult(msg *protocol.Message) error {
 	var data protocol.ActionResultData
@@ -328,10 +580,14 @@ func (c *Client) handleActionResult(msg *protocol.Message) error {
 		return err
 	}
 
+	if vote, ok := c.votes.take(msg.CorrelationID); ok && data.Success {
+		c.state.MyInfo.recordVote(vote)
+	}
+
 	if data.Success {
-		c.addEvent("✓ " + data.Message)
+		c.addEvent(tr("event.action_ok", data.Message))
 	} else {
-		c.addEvent("✗ " + data.Message)
+		c.addEvent(tr("event.action_failed", data.Message))
 	}
 
 	c.Render()
@@ -340,17 +596,324 @@ func (c *Client) handleActionResult(msg *protocol.Message) error {
 }
 
 // handleGameEnded 处理游戏结束
-func (c *Client) handleGameEnded(msg *protocol.Message) error {
-	var data protocol.GameEndedData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
+func (c *Client) handleGameEnded(data *protocol.GameEndedData) error {
+	c.state.IsInGame = false
+	c.state.Players = data.Players
+
+	if data.Reason == protocol.ReasonPlayerLeft {
+		c.addEvent(tr("event.ended_player_left"))
+	}
+
+	if data.Winner == werewolf.CampNone {
+		c.addEvent(tr("event.game_aborted"))
+	} else {
+		c.addEvent(tr("event.game_ended", c.ui.campName(data.Winner)))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleAllowedSkills 处理可用技能
+func (c *Client) handleAllowedSkills(data *protocol.AllowedSkillsData) error {
+	// 发言顺序变化时服务器会重新推送，技能没变就不再提醒
+	changed := !sameActions(c.state.Skills, data.Skills)
+	c.state.Skills = data.Skills
+	c.state.SkillsDeadline = time.Time{}
+	if data.TimeoutSeconds > 0 {
+		c.state.SkillsDeadline = time.Now().Add(time.Duration(data.TimeoutSeconds) * time.Second)
+	}
+	if changed && len(data.Skills) > 0 && c.state.Preferences.Notifications.YourTurn {
+		c.ui.Bell()
+	}
+	c.Render()
+
+	return nil
+}
+
+// sameActions 两组技能的动作是否相同
+func sameActions(a, b []protocol.SkillInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ActionType != b[i].ActionType {
+			return false
+		}
+	}
+	return true
+}
+
+// handleKicked 处理被房主踢出
+func (c *Client) handleKicked(data *protocol.KickedData) error {
+	c.leaveRoom()
+	switch data.Reason {
+	case protocol.ReasonReadyTimeout:
+		c.addEvent(tr("event.kicked_idle", data.RoomID))
+	case protocol.ReasonProfanity:
+		c.addEvent(tr("event.kicked_profanity", data.RoomID))
+	default:
+		c.addEvent(tr("event.kicked", data.RoomID))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleRoomClosed 处理房间关闭
+func (c *Client) handleRoomClosed(data *protocol.RoomClosedData) error {
+	c.leaveRoom()
+	c.addEvent(tr("event.room_closed", data.RoomID))
+	c.Render()
+
+	return nil
+}
+
+// handleOwnerChanged 处理房主变更
+func (c *Client) handleOwnerChanged(data *protocol.OwnerChangedData) error {
+	c.state.OwnerID = data.OwnerID
+	for i := range c.state.Players {
+		c.state.Players[i].IsOwner = c.state.Players[i].ID == data.OwnerID
+	}
+	if data.OwnerID == c.state.PlayerID {
+		c.addEvent(tr("event.became_owner"))
+	} else {
+		c.addEvent(tr("event.owner_changed", c.playerName(data.OwnerID)))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handlePlayerConnection 处理对局中玩家断线或重连
+func (c *Client) handlePlayerConnection(data *protocol.PlayerConnectionData) error {
+	for i := range c.state.Players {
+		if c.state.Players[i].ID == data.PlayerID {
+			c.state.Players[i].IsConnected = data.IsConnected
+		}
+	}
+
+	if data.IsConnected {
+		c.addEvent(tr("event.player_reconnected", c.playerName(data.PlayerID)))
+	} else {
+		c.addEvent(tr("event.player_disconnected", c.playerName(data.PlayerID), data.GraceSeconds))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleHello 处理握手响应
+func (c *Client) handleHello(data *protocol.HelloData) error {
+	c.logger.Info("protocol negotiated", "version", data.ProtocolVersion)
+
+	return nil
+}
+
+// handleActivity 处理正在输入人数
+func (c *Client) handleActivity(data *protocol.ActivityData) error {
+	c.state.Typing = data.Typing
+	c.Render()
+
+	return nil
+}
+
+// handleRoomConfigValidation 处理房间配置预检结果
+func (c *Client) handleRoomConfigValidation(data *protocol.RoomConfigValidationData) error {
+	if data.Valid {
+		c.addEvent(tr("event.config_valid"))
+	} else {
+		c.addEvent(tr("event.config_invalid"))
+	}
+	for _, e := range data.Errors {
+		c.addEvent(tr("event.config_error", e))
+	}
+	for _, w := range data.Warnings {
+		c.addEvent(tr("event.config_warning", w))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleGameStarting 处理开局倒计时及其取消
+func (c *Client) handleGameStarting(data *protocol.GameStartingData) error {
+	if data.Cancelled {
+		c.state.StartingAt = time.Time{}
+		c.addEvent(data.Reason)
+	} else {
+		c.state.StartingAt = time.Now().Add(time.Duration(data.Seconds) * time.Second)
+		c.addEvent(tr("event.starting", data.Seconds))
+	}
+	c.Render()
+
+	return nil
+}
+
+// startCountdown 开局倒计时剩余的秒数，没有倒计时时为 0
+func (c *Client) startCountdown() int {
+	if c.state.StartingAt.IsZero() || c.state.IsInGame {
+		return 0
+	}
+
+	remaining := time.Until(c.state.StartingAt)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(remaining.Round(time.Second).Seconds())
+}
+
+// readyCountdown 距离房间移出未准备玩家的秒数，没有计时时为 0
+func (c *Client) readyCountdown() int {
+	if c.state.ReadyDeadline.IsZero() || c.state.IsInGame {
+		return 0
+	}
+
+	remaining := time.Until(c.state.ReadyDeadline)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(remaining.Round(time.Second).Seconds())
+}
+
+// skillCountdown 当前这一步剩余的秒数，不限时为 0
+func (c *Client) skillCountdown() int {
+	if c.state.SkillsDeadline.IsZero() || len(c.state.Skills) == 0 {
+		return 0
+	}
+
+	remaining := time.Until(c.state.SkillsDeadline)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(remaining.Round(time.Second).Seconds())
+}
+
+// handleRoomList 处理房间列表
+func (c *Client) handleRoomList(data *protocol.RoomListData) error {
+	if len(data.Rooms) == 0 {
+		c.addEvent(tr("event.no_rooms"))
+		c.Render()
+		return nil
 	}
 
+	c.addEvent(tr("event.room_list", len(data.Rooms)))
+	for _, room := range data.Rooms {
+		lock := ""
+		if room.HasPassword {
+			lock = tr("event.room_locked")
+		}
+		if room.Ranked {
+			lock += tr("event.room_ranked")
+		}
+		if room.Anonymous {
+			lock += tr("event.room_anonymous")
+		}
+		if room.Speed != "" && room.Speed != protocol.SpeedNormal {
+			lock += tr("event.room_speed", tr("speed."+room.Speed))
+		}
+		if room.Wolves > 0 {
+			lock += tr("event.room_balance", room.Wolves, room.Specials, room.Villagers)
+		}
+		c.addEvent(fmt.Sprintf("  %s %s %d/%d %s%s", room.RoomID, room.Name, room.Players, room.Capacity, roomStateName(room.State), lock))
+	}
+	c.Render()
+
+	return nil
+}
+
+// roomStateName 房间状态的显示名称
+func roomStateName(state string) string {
+	return trOr("room_state."+state, state)
+}
+
+// leaveRoom 清空房间相关状态，回到大厅
+func (c *Client) leaveRoom() {
+	c.state.StartingAt = time.Time{}
+	c.state.ReadyDeadline = time.Time{}
+	c.state.RoomID = ""
+	c.state.RoomState = ""
+	c.state.OwnerID = ""
+	c.state.Players = nil
+	c.state.AlivePlayers = nil
+	c.state.Skills = nil
+	c.state.SkillsDeadline = time.Time{}
+	c.state.SkillPrompt = nil
+	c.state.Summary = nil
 	c.state.IsInGame = false
-	c.state.Players = data.Players
+	c.state.Spectating = false
+	c.state.SpectateRequests = nil
+}
+
+// playerName 根据玩家ID查找用户名
+func (c *Client) playerName(playerID string) string {
+	for _, p := range c.state.Players {
+		if p.ID == playerID {
+			if p.Username == "" {
+				return tr("seat", p.Seat)
+			}
+			return p.Username
+		}
+	}
+	return playerID
+}
+
+// seatName 带座位号的玩家名称，找不到座位时只显示名称
+func (c *Client) seatName(playerID string) string {
+	for _, p := range c.state.Players {
+		if p.ID == playerID && p.Seat > 0 {
+			return seatPlayerName(p.Seat, p.Username)
+		}
+	}
+	return c.playerName(playerID)
+}
+
+// handlePreferences 处理偏好设置
+func (c *Client) handlePreferences(data *protocol.PreferencesData) error {
+	c.state.Preferences = data.Preferences
+	if !c.langFixed && supportedLanguage(data.Preferences.Language) {
+		setLanguage(data.Preferences.Language)
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleAnnouncement 处理主持人播报
+func (c *Client) handleAnnouncement(data *protocol.AnnouncementData) error {
+	if data.Private {
+		c.addEvent(tr("event.private_notice", data.Message))
+	} else {
+		c.addEvent(tr("event.announcement", data.Message))
+	}
+	c.Render()
+
+	return nil
+}
 
-	winnerName := c.ui.campName(data.Winner)
-	c.addEvent("游戏结束！获胜阵营: " + winnerName)
+// handleServerShutdown 处理服务器关闭通知
+func (c *Client) handleServerShutdown(data *protocol.ServerShutdownData) error {
+	c.addEvent(data.Message)
+	if data.CanResume {
+		c.addEvent(tr("event.room_saved"))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleGamePaused 处理游戏暂停
+func (c *Client) handleGamePaused(data *protocol.GamePausedData) error {
+	switch data.Reason {
+	case protocol.ReasonEngineError:
+		c.addEvent(tr("event.paused_engine"))
+	case protocol.ReasonAwaitingReconnect:
+		c.addEvent(tr("event.paused_reconnect"))
+	case protocol.ReasonPlayerRequest:
+		c.addEvent(tr("event.paused_player", c.seatName(data.PlayerID)))
+	default:
+		c.addEvent(tr("event.paused", data.Reason))
+	}
 	c.Render()
 
 	return nil
@@ -363,63 +926,123 @@ func (c *Client) handleError(msg *protocol.Message) error {
 		return err
 	}
 
-	c.addEvent("错误: " + data.Message)
+	// 容量上限等有错误码的错误显示对应的提示
+	if hint := errorCodeHint(data.Code); hint != "" {
+		data.Message = hint
+	}
+
+	// 能对应到请求时标出是哪个操作失败
+	if msgType, ok := c.requests.lookup(msg.CorrelationID); ok {
+		c.addEvent(tr("event.error_for", msgType, data.Message))
+	} else {
+		c.addEvent(tr("event.error", data.Message))
+	}
+	// 建房被拒时逐条列出不符合规则的地方
+	for _, detail := range data.Details {
+		c.addEvent(tr("event.config_error", detail))
+	}
 	c.Render()
 
 	return nil
 }
 
+// errorCodeHint 错误码对应的提示，未知错误码返回空，沿用服务器的错误信息
+func errorCodeHint(code string) string {
+	switch code {
+	case protocol.ErrorCodeServerFull:
+		return tr("error_code.server_full")
+	case protocol.ErrorCodeTooManyRooms:
+		return tr("error_code.too_many_rooms")
+	case protocol.ErrorCodePlayerRoomLimit:
+		return tr("error_code.player_room_limit")
+	case protocol.ErrorCodeTooManyConnections:
+		return tr("error_code.too_many_connections")
+	case protocol.ErrorCodeInvalidUsername:
+		return tr("error_code.invalid_username")
+	case protocol.ErrorCodeUsernameTaken:
+		return tr("error_code.username_taken")
+	case protocol.ErrorCodeRateLimited:
+		return tr("error_code.rate_limited")
+	case protocol.ErrorCodeInvalidRoomConfig:
+		return tr("error_code.invalid_room_config")
+	case protocol.ErrorCodeSpectatorsFull:
+		return tr("error_code.spectators_full")
+	default:
+		return ""
+	}
+}
+
 // addEvent 添加事件到日志
 func (c *Client) addEvent(event string) {
-	c.state.Events = append(c.state.Events, event)
+	c.state.Events = append(c.state.Events, c.stamp(event))
 }
 
-// Render 渲染UI
-func (c *Client) Render() {
-	c.ui.Clear()
+// addChat 添加一条发言
+func (c *Client) addChat(line string) {
+	c.state.Chat = append(c.state.Chat, c.stamp(line))
+}
 
-	// 打印标题
-	c.ui.PrintHeader(c.state.RoomID, c.state.Round, c.state.GamePhase)
+// stamp 在行首加上消息的发送时间，按玩家设置的时区显示
+func (c *Client) stamp(line string) string {
+	t := c.msgTime
+	if t.IsZero() {
+		t = protocol.Now()
+	}
 
-	// 如果在游戏中，显示玩家列表
-	if len(c.state.Players) > 0 {
-		c.ui.PrintPlayers(c.state.Players, c.state.PlayerID)
+	loc, err := protocol.LoadLocation(c.state.Preferences.TimeZone)
+	if err != nil {
+		loc = time.Local
 	}
 
-	// 显示事件日志
-	c.ui.PrintEvents(c.state.Events)
+	return "[" + protocol.FormatClock(t, loc) + "] " + line
+}
 
-	// 如果在游戏中，显示角色信息
-	if c.state.IsInGame {
-		c.ui.PrintRoleInfo(c.state.MyRole, c.state.MyCamp)
-	}
+// Render 渲染UI
+func (c *Client) Render() {
+	c.screen.Render(c.view())
 }
 
-// Run 运行客户端主循环
-func (c *Client) Run() {
+// Run 运行客户端主循环，直到用户退出、输入结束、客户端被关闭或连接断开
+//
+// 用户退出和输入结束返回 nil，连接断开返回 ErrConnectionLost，输入出错时返回该错误。返回前客户端已关闭。
+func (c *Client) Run() error {
 	// 初始渲染
+	c.screen.Start()
 	c.Render()
 
 	// 主输入循环
 	for {
-		c.ui.PrintPrompt(c.state.GamePhase, c.state.MyRole)
+		c.screen.Prompt(c.view())
 
 		cmd, err := c.input.ReadCommand()
-		if err != nil {
-			c.logger.Error("read command error", "error", err)
-			continue
-		}
-
-		if err := c.input.HandleCommand(cmd); err != nil {
+		switch {
+		case err == nil:
+			err = c.input.HandleCommand(cmd)
+		case !errors.Is(err, io.EOF) && !errors.Is(err, ErrClientClosed):
+			// 输入出错（如脚本等待超时）时结束客户端
+			c.Close()
+			c.screen.Stop()
 			c.ui.PrintError(err.Error())
+			return err
 		}
-	}
-}
 
-// Close 关闭客户端
-func (c *Client) Close() {
-	c.cancel()
-	if c.conn != nil {
-		// socket 包没有提供 Close 方法，通过 cancel context 来关闭
+		switch {
+		case err == nil:
+		case errors.Is(err, errQuit), errors.Is(err, io.EOF):
+			c.Close()
+			c.screen.Stop()
+			c.ui.PrintMessage(tr("bye"))
+			return nil
+		case errors.Is(err, ErrClientClosed):
+			c.Close()
+			c.screen.Stop()
+			if err := c.Err(); err != nil {
+				c.ui.PrintError(tr("err.disconnected"))
+				return err
+			}
+			return nil
+		default:
+			c.screen.Error(err.Error())
+		}
 	}
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// registerHandlers 注册各类服务器消息的处理器
+func (c *Client) registerHandlers() {
+	c.handlers = make(map[protocol.MessageType]MessageHandler)
+
+	RegisterHandler(c, protocol.MsgHello, (*Client).handleHello)
+	RegisterHandler(c, protocol.MsgPong, (*Client).handlePong)
+	RegisterHandler(c, protocol.MsgLoginSuccess, (*Client).handleLoginSuccess)
+	RegisterHandler(c, protocol.MsgRoomCreated, (*Client).handleRoomCreated)
+	RegisterHandler(c, protocol.MsgRoomJoined, (*Client).handleRoomJoined)
+	RegisterHandler(c, protocol.MsgRoomStateChanged, (*Client).handleRoomStateChanged)
+	RegisterHandler(c, protocol.MsgPlayerJoined, (*Client).handlePlayerJoined)
+	RegisterHandler(c, protocol.MsgPlayerLeft, (*Client).handlePlayerLeft)
+	RegisterHandler(c, protocol.MsgPlayerReady, (*Client).handlePlayerReady)
+	RegisterHandler(c, protocol.MsgGameStarted, (*Client).handleGameStarted)
+	RegisterHandler(c, protocol.MsgRoleInfo, (*Client).handleRoleInfo)
+	RegisterHandler(c, protocol.MsgPhaseChanged, (*Client).handlePhaseChanged)
+	RegisterHandler(c, protocol.MsgGameState, (*Client).handleGameState)
+	RegisterHandler(c, protocol.MsgGameStateDelta, (*Client).handleGameStateDelta)
+	RegisterHandler(c, protocol.MsgGameEvent, (*Client).handleGameEvent)
+	c.handle(protocol.MsgActionResult, c.handleActionResult)
+	RegisterHandler(c, protocol.MsgGameEnded, (*Client).handleGameEnded)
+	RegisterHandler(c, protocol.MsgGamePaused, (*Client).handleGamePaused)
+	RegisterHandler(c, protocol.MsgAllowedSkills, (*Client).handleAllowedSkills)
+	RegisterHandler(c, protocol.MsgServerShutdown, (*Client).handleServerShutdown)
+	RegisterHandler(c, protocol.MsgAnnouncement, (*Client).handleAnnouncement)
+	RegisterHandler(c, protocol.MsgPreferences, (*Client).handlePreferences)
+	RegisterHandler(c, protocol.MsgKicked, (*Client).handleKicked)
+	RegisterHandler(c, protocol.MsgRoomClosed, (*Client).handleRoomClosed)
+	RegisterHandler(c, protocol.MsgOwnerChanged, (*Client).handleOwnerChanged)
+	RegisterHandler(c, protocol.MsgPlayerConnection, (*Client).handlePlayerConnection)
+	RegisterHandler(c, protocol.MsgPauseVote, (*Client).handlePauseVote)
+	c.handle(protocol.MsgGameResumed, c.handleGameResumed)
+	RegisterHandler(c, protocol.MsgRoomConfigValidation, (*Client).handleRoomConfigValidation)
+	RegisterHandler(c, protocol.MsgActivity, (*Client).handleActivity)
+	RegisterHandler(c, protocol.MsgSheriffState, (*Client).handleSheriffState)
+	RegisterHandler(c, protocol.MsgSheriffSpeech, (*Client).handleSheriffSpeech)
+	RegisterHandler(c, protocol.MsgSpeakingOrder, (*Client).handleSpeakingOrder)
+	RegisterHandler(c, protocol.MsgYourTurnToSpeak, (*Client).handleYourTurnToSpeak)
+	RegisterHandler(c, protocol.MsgWhisper, (*Client).handleWhisper)
+	RegisterHandler(c, protocol.MsgSpeech, (*Client).handleSpeech)
+	RegisterHandler(c, protocol.MsgNightResult, (*Client).handleNightResult)
+	RegisterHandler(c, protocol.MsgRoomList, (*Client).handleRoomList)
+	RegisterHandler(c, protocol.MsgStats, (*Client).handleStats)
+	RegisterHandler(c, protocol.MsgLeaderboard, (*Client).handleLeaderboard)
+	RegisterHandler(c, protocol.MsgPresetList, (*Client).handlePresetList)
+	RegisterHandler(c, protocol.MsgGameSummary, (*Client).handleGameSummary)
+	RegisterHandler(c, protocol.MsgWolfVoteUpdate, (*Client).handleWolfVoteUpdate)
+	RegisterHandler(c, protocol.MsgVoteCast, (*Client).handleVoteCast)
+	RegisterHandler(c, protocol.MsgVoteResult, (*Client).handleVoteResult)
+	RegisterHandler(c, protocol.MsgActionPending, (*Client).handleActionPending)
+	RegisterHandler(c, protocol.MsgSkillPrompt, (*Client).handleSkillPrompt)
+	RegisterHandler(c, protocol.MsgRematchStatus, (*Client).handleRematchStatus)
+	RegisterHandler(c, protocol.MsgGameStarting, (*Client).handleGameStarting)
+	RegisterHandler(c, protocol.MsgSpectateRequest, (*Client).handleSpectateRequest)
+	RegisterHandler(c, protocol.MsgSpectateResult, (*Client).handleSpectateResult)
+	c.handle(protocol.MsgError, c.handleError)
+}
+
+// RegisterHandler 为一种服务器消息注册处理器，消息数据按 T 解析后交给 fn，解析失败时不调用 fn
+//
+// 处理器在编译期就确定了数据类型，新增消息类型时只需写处理函数并在 registerHandlers 里登记。
+func RegisterHandler[T any](c *Client, msgType protocol.MessageType, fn func(c *Client, data *T) error) {
+	c.handle(msgType, func(msg *protocol.Message) error {
+		var data T
+		if err := msg.UnmarshalData(&data); err != nil {
+			return errors.Wrapf(err, "invalid data for %s", msgType)
+		}
+		return fn(c, &data)
+	})
+}
+
+// handle 为一种服务器消息注册处理器，用于需要消息本身（如 CorrelationID）的处理
+func (c *Client) handle(msgType protocol.MessageType, handler MessageHandler) {
+	c.handlers[msgType] = handler
+}
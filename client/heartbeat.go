@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// pingInterval 心跳间隔，需明显小于服务器的心跳超时
+const pingInterval = 10 * time.Second
+
+// heartbeat 定期发送心跳，直到客户端关闭
+func (c *Client) heartbeat() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			pingMsg, err := protocol.NewPingMessage()
+			if err != nil {
+				continue
+			}
+			// 心跳不需要跟踪响应，不经过请求登记
+			if err := c.outbound(pingMsg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handlePong 根据心跳回复更新往返延迟，下次刷新界面时显示
+func (c *Client) handlePong(data *protocol.PingData) error {
+	c.state.RTT = time.Since(time.Unix(0, data.SentAt))
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// 客户端支持的界面语言，与偏好设置中的 language 取值相同
+const (
+	LangZhCN = "zh-CN"
+	LangEnUS = "en-US"
+)
+
+// catalogs 各语言的文案目录，文案按键查找，可以带 fmt 格式参数；
+// 目录中缺少的键回退到中文，中文也没有时显示键本身
+var catalogs = map[string]map[string]string{
+	LangZhCN: catalogZhCN,
+	LangEnUS: catalogEnUS,
+}
+
+// locale 当前界面语言，界面协程和主循环都会读取
+var locale = struct {
+	sync.RWMutex
+	lang string
+}{lang: LangZhCN}
+
+// supportedLanguage 是否有该语言的文案目录
+func supportedLanguage(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// setLanguage 切换界面语言，之后生成的文字使用新语言，已经记入日志的事件不变
+func setLanguage(lang string) error {
+	if !supportedLanguage(lang) {
+		return errors.Errorf("unsupported language: %s", lang)
+	}
+
+	locale.Lock()
+	locale.lang = lang
+	locale.Unlock()
+
+	return nil
+}
+
+// tr 按当前语言取出文案，带参数时按格式填入
+func tr(key string, args ...interface{}) string {
+	format, ok := lookup(key)
+	if !ok {
+		format = key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// trOr 按当前语言取出文案，两种语言都没有该键时返回 fallback，用于服务器发来的取值
+func trOr(key, fallback string) string {
+	if format, ok := lookup(key); ok {
+		return format
+	}
+	return fallback
+}
+
+// lookup 在当前语言和中文目录中查找文案
+func lookup(key string) (string, bool) {
+	locale.RLock()
+	lang := locale.lang
+	locale.RUnlock()
+
+	if format, ok := catalogs[lang][key]; ok {
+		return format, true
+	}
+	format, ok := catalogZhCN[key]
+	return format, ok
+}
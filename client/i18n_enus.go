@@ -0,0 +1,537 @@
+package main
+
+// catalogEnUS 英文文案
+var catalogEnUS = map[string]string{
+	// 通用
+	"title":       "Werewolf",
+	"seat":        "#%d",
+	"seat_player": "#%d %s",
+	"list_sep":    ", ",
+	"name_sep":    ", ",
+	"on":          "on",
+	"off":         "off",
+	"you":         "You",
+	"error":       "Error: %s",
+	"success":     "OK: %s",
+	"typing":      "%d player(s) typing…",
+	"bye":         "Bye!",
+	"cancelled":   "Cancelled",
+
+	// 标题栏
+	"header.room":            "Room: %s",
+	"header.round":           "Round: %d",
+	"header.phase":           "Phase: %s",
+	"header.countdown":       "Starting in %ds",
+	"header.ready_countdown": "Unready players removed in %ds",
+	"header.rtt":             "Latency: %dms",
+
+	// 行模式各栏的标题
+	"panel.players":     "Players",
+	"panel.events":      "Events",
+	"panel.chat":        "Chat",
+	"panel.role":        "Your role",
+	"panel.role_skills": "Skills",
+	"panel.my_info":     "My notes",
+	"panel.recap":       "Game recap",
+	"panel.rules":       "Rules",
+	"panel.guard_rules": "Guard rules",
+	"panel.allowed":     "Available now",
+	"panel.prompt":      "Enter a command",
+	"panel.hint":        "Hint: %s",
+
+	// 分栏界面
+	"tui.players":     "Players",
+	"tui.info":        "Info",
+	"tui.events":      "Events",
+	"tui.chat":        "Chat",
+	"tui.help":        "Help",
+	"tui.role":        "Role: %s (%s)",
+	"tui.skills":      "Skills: %s",
+	"tui.allowed":     "Available now: %s",
+	"tui.rules":       "Rules: %s",
+	"tui.guard":       "Guard: %s",
+	"tui.lobby":       "login to sign in, create to open a room, join to enter one",
+	"tui.lobby_help":  "Type help for all commands",
+	"tui.placeholder": "Type a command, help for help",
+	"tui.keys":        "↑↓ select player  Tab insert seat  PgUp/PgDn scroll events  Ctrl+C quit",
+	"tui.picker_keys": "↑↓ select target  Enter confirm (or type a number)  Esc cancel",
+	"tui.help_close":  "Press Esc or Enter to go back",
+
+	// 玩家状态
+	"status.alive":   "[alive]",
+	"status.dead":    "[dead]",
+	"status.ready":   "[ready]",
+	"status.bot":     "[bot]",
+	"status.offline": "[offline]",
+	"status.afk":     "[AFK]",
+	"status.owner":   "[host]",
+	"status.sheriff": "[sheriff]",
+	"status.rating":  "[%d pts]",
+
+	// 阶段、角色、阵营
+	"phase.start":    "Start",
+	"phase.night":    "Night",
+	"phase.day":      "Day",
+	"phase.vote":     "Vote",
+	"phase.end":      "End",
+	"role.werewolf":  "Werewolf",
+	"role.seer":      "Seer",
+	"role.witch":     "Witch",
+	"role.guard":     "Guard",
+	"role.hunter":    "Hunter",
+	"role.villager":  "Villager",
+	"role.idiot":     "Idiot",
+	"role.knight":    "Knight",
+	"role.wolf_king": "Wolf King",
+	"role.cupid":     "Cupid",
+	"role.thief":     "Thief",
+	"camp.good":      "Village",
+	"camp.evil":      "Werewolves",
+	"camp.lovers":    "Lovers",
+	"camp.none":      "No team",
+
+	"cause.WOLF_KILL":      "killed by the werewolves",
+	"cause.POISON":         "poisoned by the witch",
+	"cause.EXILE":          "exiled by vote",
+	"cause.KNIGHT_DUEL":    "lost a duel",
+	"cause.WOLF_KING_SHOT": "shot by the Wolf King",
+	"cause.OTHER":          "out of the game",
+
+	"room_state.WAITING":  "waiting",
+	"room_state.STARTING": "starting",
+	"room_state.PLAYING":  "playing",
+	"room_state.PAUSED":   "paused",
+	"room_state.FINISHED": "finished",
+	"room_state.ARCHIVED": "closed",
+
+	// 对局节奏
+	"speed.blitz":   "blitz",
+	"speed.normal":  "normal",
+	"speed.relaxed": "relaxed",
+
+	// 技能名称，键为动作类型
+	"skill.format":        "%s (%s)",
+	"skill.kill":          "Kill",
+	"skill.check":         "Check",
+	"skill.protect":       "Protect",
+	"skill.antidote":      "Antidote",
+	"skill.poison":        "Poison",
+	"skill.vote":          "Vote",
+	"skill.speak":         "Speak",
+	"skill.duel":          "Duel",
+	"skill.link":          "Link lovers",
+	"skill.steal":         "Take a card",
+	"skill.self_destruct": "Self-destruct",
+
+	// 角色技能说明
+	"role_skills.werewolf":  "kill <seat> - kill a player",
+	"role_skills.seer":      "check <seat> - learn a player's team",
+	"role_skills.witch":     "antidote - save the victim | poison <seat> - poison a player",
+	"role_skills.guard":     "protect <seat> - protect a player",
+	"role_skills.hunter":    "Passive: may shoot when killed",
+	"role_skills.villager":  "vote <seat> - vote (day / vote phase)",
+	"role_skills.idiot":     "Passive: survives the first exile by revealing, then can no longer vote",
+	"role_skills.knight":    "duel <seat> - duel a player by day; a werewolf dies, otherwise the knight dies (once per game)",
+	"role_skills.wolf_king": "kill <seat> - kill a player | may shoot a player when out (not when poisoned)",
+	"role_skills.cupid":     "link <seat> <seat> - on the first night, make two players lovers; if one is out, the other follows",
+	"role_skills.thief":     "steal <1|2> - on the first night, take one of the two extra cards and play that role",
+
+	// 当前阶段的行动提示
+	"hint.actions":       "Your move: %s",
+	"hint.separator":     "; ",
+	"hint.deadline":      " (%ds left)",
+	"hint.kill":          "kill <seat> to choose a victim",
+	"hint.check":         "check <seat> to check a player",
+	"hint.antidote":      "antidote to save tonight's victim",
+	"hint.poison":        "poison <seat> to poison a player",
+	"hint.protect":       "protect <seat> to protect a player",
+	"hint.link":          "link <seat> <seat> to make two players lovers",
+	"hint.steal":         "steal <1|2> to take one of the two extra cards",
+	"hint.speak":         "speak <text> to speak",
+	"hint.duel":          "duel <seat> to duel a player",
+	"hint.vote":          "vote <seat> to vote",
+	"hint.self_destruct": "explode to reveal yourself and end the day",
+	"hint.wait":          "Waiting for other players...",
+	"hint.default":       "Type help for available commands",
+
+	// 对局规则
+	"rules.witch_self_save_always":      "witch may save herself",
+	"rules.witch_self_save_never":       "witch may not save herself",
+	"rules.witch_self_save_first_night": "witch may save herself on the first night only",
+	"rules.first_night_death_only":      "first night causes of death hidden",
+	"rules.first_night_none":            "first night deaths not announced",
+	"rules.wolf_kill_majority":          "werewolf kill by majority",
+	"rules.last_words":                  "last words on death",
+	"rules.sheriff":                     "sheriff election on the first day, the sheriff's vote counts 1.5",
+	"rules.hidden_votes":                "exile votes revealed together when voting ends",
+	"guard.self_allowed":                "may protect self",
+	"guard.self_forbidden":              "may not protect self",
+	"guard.repeat_allowed":              "may protect the same player two nights in a row",
+	"guard.repeat_forbidden":            "may not protect the same player two nights in a row",
+
+	// 偏好设置
+	"prefs.local_tz": "local time",
+	"prefs.general":  "Language: %s | Auto ready: %s | Seat color: %s | Time zone: %s",
+	"prefs.notify":   "Notify - game start: %s | my turn: %s | player joins: %s",
+
+	// 玩家资料
+	"profile.show": "Avatar: %s | Name color: %s | Bio: %s",
+	"profile.none": "not set",
+	"player.bio":   "\"%s\"",
+
+	// 帮助
+	"help.title":               "Werewolf - Help",
+	"help.continue":            "Press Enter to continue...",
+	"help.register.cmd":        "register <name> <password>",
+	"help.register.desc":       "Create an account and log in",
+	"help.login.cmd":           "login <name> [password]",
+	"help.login.desc":          "Log in (without a password as a guest)",
+	"help.create.cmd":          "create <room> [preset|roles] [options...]",
+	"help.create.desc":         "Create a room (6 players by default). After the name give a preset name or a comma separated role list for 4-18 players, e.g. werewolf*4,villager*4,seer,witch,hunter,guard. Options: preset=<name> role preset, pw=<password> join password, private hide from the room list, ranked ranked room (registered players only), anonymous random seats and seat numbers only until the game ends, idle=<minutes> remove players who stay unready that long once the room is full and most are ready, speed=blitz|normal|relaxed halve or double every timer, nofilter turn off the profanity filter, nowatch no spectators, watchers=<n> cap the number of spectators, approvewatch spectators need your approval, hiddenvotes keep exile votes secret until voting ends, a webhook URL for the game report",
+	"help.join.cmd":            "join <roomID> [password]",
+	"help.join.desc":           "Join a room; password-protected rooms need the password",
+	"help.watch.cmd":           "watch <roomID> [password]",
+	"help.watch.desc":          "Spectate a room without taking a seat; you see what every spectator sees (the room may need the owner's approval), leave stops watching",
+	"help.rooms.cmd":           "rooms",
+	"help.rooms.desc":          "List rooms (private rooms excluded)",
+	"help.presets.cmd":         "presets",
+	"help.presets.desc":        "List role presets for create",
+	"help.stats.cmd":           "stats [name]",
+	"help.stats.desc":          "Show stats (yours without a name; needs an account)",
+	"help.leaderboard.cmd":     "leaderboard [rating]",
+	"help.leaderboard.desc":    "Show the leaderboard by wins, or by rating",
+	"help.ready.cmd":           "ready",
+	"help.ready.desc":          "Get ready",
+	"help.unready.cmd":         "unready",
+	"help.unready.desc":        "Cancel ready",
+	"help.rematch.cmd":         "rematch [no]",
+	"help.rematch.desc":        "After a game: agree to play again with the same players and roles (no withdraws); the room reopens once everyone agrees",
+	"help.kick.cmd":            "kick <seat>",
+	"help.kick.desc":           "Owner: kick a player",
+	"help.owner.cmd":           "owner <seat>",
+	"help.owner.desc":          "Owner: hand over ownership",
+	"help.close.cmd":           "close",
+	"help.close.desc":          "Owner: close the room",
+	"help.allow.cmd":           "allow <username>",
+	"help.allow.desc":          "Owner: approve a spectate request",
+	"help.deny.cmd":            "deny <username>",
+	"help.deny.desc":           "Owner: deny a spectate request",
+	"help.leave.cmd":           "leave",
+	"help.leave.desc":          "Leave the room (leaving a running game counts as dying)",
+	"help.bot.cmd":             "bot [count]",
+	"help.bot.desc":            "Owner: fill empty seats with bots (all seats without a count)",
+	"help.validate.cmd":        "validate [roles...]",
+	"help.validate.desc":       "Check a role setup without creating a room, e.g. validate werewolf werewolf seer witch villager villager",
+	"help.kill.cmd":            "kill [seat]",
+	"help.kill.desc":           "Werewolf: choose a victim, changeable until dawn",
+	"help.check.cmd":           "check [seat]",
+	"help.check.desc":          "Seer: check a player, changeable until dawn",
+	"help.protect.cmd":         "protect [seat]",
+	"help.protect.desc":        "Guard: protect a player, changeable until dawn",
+	"help.antidote.cmd":        "antidote",
+	"help.antidote.desc":       "Witch: use the antidote",
+	"help.poison.cmd":          "poison [seat]",
+	"help.poison.desc":         "Witch: use the poison, changeable until dawn",
+	"help.witch_antidote.cmd":  "witch yes|no",
+	"help.witch_antidote.desc": "Witch: answer whether to save tonight's victim",
+	"help.witch_poison.cmd":    "witch <seat>|pass",
+	"help.witch_poison.desc":   "Witch: answer with a poison target or pass",
+	"help.duel.cmd":            "duel [seat]",
+	"help.duel.desc":           "Knight: duel a player by day (once per game)",
+	"help.link.cmd":            "link <seat> <seat>",
+	"help.link.desc":           "Cupid: make two players lovers on the first night",
+	"help.steal.cmd":           "steal <1|2>",
+	"help.steal.desc":          "Thief: take one of the two extra cards on the first night",
+	"help.explode.cmd":         "explode",
+	"help.explode.desc":        "Werewolf: self-destruct during the day (if the room allows it), ending the day's speeches and vote",
+	"help.shoot.cmd":           "shoot <seat>|pass",
+	"help.shoot.desc":          "Wolf King: when out, shoot a player or pass",
+	"help.vote.cmd":            "vote [seat]",
+	"help.vote.desc":           "Vote, changeable until the vote phase ends",
+	"help.picker.cmd":          "(commands above without a seat)",
+	"help.picker.desc":         "List valid targets to pick with arrow keys or a number",
+	"help.speak.cmd":           "speak [text]",
+	"help.speak.desc":          "Speak (without text, enter typing mode; others see that someone is typing)",
+	"help.pass.cmd":            "pass",
+	"help.pass.desc":           "Skip your action this phase (no check, protect or kill, abstain, or skip your speech); rejected if the server's rules engine does not support it",
+	"help.sheriff_run.cmd":     "sheriff run|quit",
+	"help.sheriff_run.desc":    "Run for sheriff / withdraw (with the sheriff rule)",
+	"help.sheriff_say.cmd":     "sheriff say <text>",
+	"help.sheriff_say.desc":    "Campaign speech",
+	"help.sheriff_vote.cmd":    "sheriff vote <seat>",
+	"help.sheriff_vote.desc":   "Vote for sheriff (non-candidates)",
+	"help.sheriff_pass.cmd":    "sheriff pass [seat]",
+	"help.sheriff_pass.desc":   "Pass the badge when out (tear it up without a seat)",
+	"help.sheriff_order.cmd":   "sheriff order cw|ccw",
+	"help.sheriff_order.desc":  "Sheriff: choose today's speaking direction (seats ascending/descending)",
+	"help.skills.cmd":          "skills",
+	"help.skills.desc":         "Show currently available skills",
+	"help.pause.cmd":           "pause",
+	"help.pause.desc":          "In a game: ask to pause (e.g. while someone reconnects); takes effect once a majority of online players agree",
+	"help.resume.cmd":          "resume",
+	"help.resume.desc":         "Ask to resume a paused game; the player who asked for the pause can resume alone, others need a majority",
+	"help.prefs.cmd":           "prefs",
+	"help.prefs.desc":          "Show preferences",
+	"help.set.cmd":             "set <item> <value>",
+	"help.set.desc":            "Change preferences: lang/autoready/color/tz/notify <kind> (set lang zh-CN switches the UI language at once)",
+	"help.profile.cmd":         "profile [item] [value]",
+	"help.profile.desc":        "Show or change your profile: avatar <emoji or ID>, color <name color>, bio <text> (up to 80 characters); no value clears it. Others see it in the player list and recap",
+	"help.whisper.cmd":         "/w <seat> <message>",
+	"help.whisper.desc":        "whisper to a player in the room lobby (not during a game)",
+	"help.mute.cmd":            "mute|unmute <seat>",
+	"help.mute.desc":           "hide or show a player's speeches, only for you",
+	"help.report.cmd":          "report <seat> <reason>",
+	"help.report.desc":         "report a player to the admins, with the recent chat attached",
+	"help.help.cmd":            "help",
+	"help.help.desc":           "Show this help",
+	"help.quit.cmd":            "quit",
+	"help.quit.desc":           "Quit",
+
+	// 命令用法和输入错误
+	"usage.login":         "usage: login <name> [password]",
+	"usage.register":      "usage: register <name> <password>",
+	"usage.join":          "usage: join <roomID> [password]",
+	"usage.seat":          "usage: %s <seat>",
+	"usage.report":        "usage: report <seat> <reason>",
+	"usage.rematch":       "usage: rematch [yes|no]",
+	"usage.profile":       "usage: profile [avatar|color|bio] [value]",
+	"usage.watch":         "usage: watch <roomID> [password]",
+	"usage.spectator":     "usage: %s <username>",
+	"usage.watchers":      "watchers= takes the spectator limit, e.g. watchers=10",
+	"usage.create_idle":   "idle= takes a number of minutes, e.g. idle=3",
+	"usage.create_roles":  "Role list: comma separated roles, *<count> for several, e.g. werewolf*3,villager*3,seer,witch,hunter",
+	"usage.whisper":       "usage: /w <seat> <message>",
+	"usage.bot":           "usage: bot [count]",
+	"usage.set":           "usage: set lang <zh-CN|en-US> | set autoready <on|off> | set color <color> | set tz <time zone, e.g. Asia/Shanghai> | set notify <gamestart|turn|join> <on|off>",
+	"usage.witch":         "usage: witch yes|no (antidote) or witch <seat>|pass (poison)",
+	"usage.witch_yes_no":  "usage: witch yes|no",
+	"usage.shoot":         "usage: shoot <seat>|pass",
+	"usage.link":          "usage: link <seat> <seat>",
+	"usage.steal":         "usage: steal <1|2>",
+	"usage.sheriff":       "usage: sheriff run|quit|say <text>|vote <seat>|pass [seat]|order cw|ccw",
+	"usage.sheriff_say":   "usage: sheriff say <text>",
+	"usage.sheriff_vote":  "usage: sheriff vote <seat>",
+	"usage.sheriff_order": "usage: sheriff order cw|ccw (cw seats ascending, ccw seats descending)",
+	"usage.wait":          "usage: wait <message type> [timeout]",
+	"usage.sleep":         "usage: sleep <duration>",
+	"err.unknown_command": "unknown command: %s, type help for help",
+	"err.seat_not_number": "the seat must be a number",
+	"err.invalid_seat":    "invalid seat: %d",
+	"err.not_in_room":     "you are not in a room",
+	"err.not_logged_in":   "log in first",
+	"err.unknown_tz":      "unknown time zone: %s",
+	"err.unknown_lang":    "unsupported language: %s (zh-CN or en-US)",
+	"err.no_witch_prompt": "there is no potion prompt to answer",
+	"err.no_shot_prompt":  "there is no shot prompt to answer",
+	"err.no_request":      "%s has not asked to spectate",
+	"err.open_script":     "open script: %v",
+	"err.whisper_in_game": "whispers are disabled during the game",
+	"err.connect":         "connect to server: %v",
+	"err.tutorial_server": "start tutorial server: %v",
+	"err.disconnected":    "disconnected from the server",
+	"default_room_name":   "Game room",
+	"speak.prompt":        "Type your speech (empty line to cancel):",
+
+	// 目标菜单
+	"picker.title":          "Choose a target (%s)",
+	"picker.footer":         "Type a number to choose, Enter to cancel",
+	"picker.unavailable":    "%s is not available now, usage: %s <seat>",
+	"picker.no_targets":     "%s has no valid targets",
+	"picker.invalid_target": "Seat %d %s is not a valid target for %s right now",
+	"picker.out_of_range":   "enter a number from 1 to %d",
+
+	// 脚本模式
+	"script.line":         "script line %d",
+	"script.bad_timeout":  "invalid timeout: %s",
+	"script.bad_duration": "invalid duration: %s",
+	"script.wait_timeout": "timed out waiting for %s (%s)",
+
+	// 教学模式
+	"tutorial.player_name":    "Trainee",
+	"tutorial.room_name":      "Tutorial",
+	"tutorial.welcome":        "[Tutorial] Welcome to Werewolf! This practice game runs on your own machine against 5 bots. Logging you in and setting up a 6-player room: 2 werewolves, 2 villagers, a seer and a witch.",
+	"tutorial.ready":          "[Tutorial] The bots have taken their seats and are always ready. Type ready to start the game.",
+	"tutorial.started":        "[Tutorial] The game has started and you are the %s. Werewolves win by killing the villagers or the special roles; the village wins by voting out every werewolf. Type skills to see what your role can do at any time.",
+	"tutorial.night_wolf":     "[Tutorial] Night falls. Werewolves wake up together: use kill <seat> to choose tonight's victim. Your teammates are shown in the player list.",
+	"tutorial.night_seer":     "[Tutorial] Night falls. As the seer, use check <seat> to learn whether a player is a werewolf. Only you will see the result.",
+	"tutorial.night_witch":    "[Tutorial] Night falls. As the witch you have two one-time potions: antidote saves tonight's victim, poison <seat> kills a player. You may also do nothing.",
+	"tutorial.night_villager": "[Tutorial] Night falls. Villagers have no night action, so close your eyes and wait for dawn while the others act.",
+	"tutorial.day":            "[Tutorial] Day breaks and last night's deaths are announced. Players speak one at a time; when it is your turn, use speak <text> to share what you know or suspect.",
+	"tutorial.vote":           "[Tutorial] Time to vote. Use vote <seat> on the player you think is a werewolf; whoever gets the most votes is eliminated.",
+	"tutorial.ended":          "[Tutorial] The game is over and every role has been revealed above. You know the basics now; play with friends by running the client without -tutorial, or type quit to leave.",
+
+	// 事件日志
+	"event.login_guest":         "Logged in as a guest, player ID: %s",
+	"event.login":               "Logged in, player ID: %s",
+	"event.room_created":        "Room created, room ID: %s",
+	"event.room_joined":         "Joined room: %s",
+	"event.player_joined":       "Player joined: %s",
+	"event.you_left":            "You left the room",
+	"event.player_abandoned":    "%s left mid-game and is out",
+	"event.player_left":         "Player left: %s",
+	"event.player_ready":        "Player %s is ready",
+	"event.player_unready":      "Player %s is no longer ready",
+	"event.game_started":        "The game has started!",
+	"event.anonymous_seats":     "Anonymous seating: seats were drawn at random and players go by seat number until the game ends",
+	"event.teammates":           "Your fellow werewolves: %s",
+	"event.extra_roles":         "Extra cards: %s; take one with steal <1|2>",
+	"event.role_changed":        "Your role is now: %s",
+	"event.phase_changed":       "Phase: %s",
+	"event.action_ok":           "✓ %s",
+	"event.action_failed":       "✗ %s",
+	"event.ended_player_left":   "A player left mid-game, the game was settled early",
+	"event.game_aborted":        "The game was aborted and counts as a draw",
+	"event.game_ended":          "Game over! Winner: %s",
+	"event.rematch_votes":       "Rematch: %d/%d players agreed, type rematch to join in",
+	"event.rematch_reset":       "Everyone agreed to a rematch, the room is open again with reshuffled roles",
+	"event.kicked":              "You were removed from the room by the owner: %s",
+	"event.kicked_idle":         "You were removed from room %s for not getting ready in time",
+	"event.kicked_profanity":    "You were removed from room %s for repeated offensive speech",
+	"event.ready_timeout":       "%s is not ready and will be removed in %d seconds",
+	"event.room_closed":         "The owner closed the room: %s",
+	"event.room_archived":       "The room was closed: %s",
+	"event.became_owner":        "You are now the room owner",
+	"event.player_disconnected": "%s disconnected; their seat is held for %d seconds",
+	"event.player_reconnected":  "%s reconnected",
+	"event.owner_changed":       "New room owner: %s",
+	"event.config_valid":        "✓ The room setup is valid",
+	"event.config_invalid":      "✗ The room setup is not valid",
+	"event.config_error":        "  Error: %s",
+	"event.config_warning":      "  Note: %s",
+	"event.starting":            "Everyone is ready, starting in %d seconds (type unready to cancel)",
+	"event.no_rooms":            "No public rooms yet, use create to open one",
+	"event.room_list":           "%d public room(s):",
+	"event.room_locked":         " [password]",
+	"event.room_ranked":         " [ranked]",
+	"event.room_anonymous":      " [anonymous]",
+	"event.room_speed":          " [%s]",
+	"event.room_balance":        " [%d wolves/%d specials/%d villagers]",
+	"event.private_notice":      "[Notice] %s",
+	"event.announcement":        "[Host] %s",
+	"event.room_saved":          "The room was saved; log in with the same account after the server restarts to continue",
+	"event.paused_engine":       "The game is paused by an internal server error, please wait for an operator",
+	"event.paused":              "Game paused: %s",
+	"event.paused_reconnect":    "The server restarted, the game resumes once everyone has reconnected",
+	"event.paused_player":       "%s paused the game; timers are stopped until they or a majority type resume",
+	"event.pause_votes":         "Pause requested: %d/%d players agreed, type pause to agree",
+	"event.resume_votes":        "Resume requested: %d/%d players agreed, type resume to agree",
+	"event.resumed":             "The game resumes, timers continue where they stopped",
+	"event.error_for":           "Error (%s): %s",
+	"event.error":               "Error: %s",
+	"event.player_died":         "%s died: %s",
+	"event.player_died_hidden":  "%s died",
+	"event.idiot_revealed":      "%s reveals the Idiot and survives the exile, but can no longer vote",
+	"event.duel_wolf":           "%s reveals the Knight and duels %s: a werewolf, who is out",
+	"event.duel_good":           "%s reveals the Knight and duels %s: a villager, so the Knight is out",
+	"event.wolf_king_shot":      "%s reveals the Wolf King and shoots %s",
+	"event.wolf_self_destruct":  "%s is a werewolf and self-destructs! The day's speeches and vote are over; night falls",
+	"event.lovers_linked":       "Cupid chose you as a lover; your lover is %s",
+	"event.lover_suicide":       "%s follows their lover %s out of the game",
+	"event.dawn_peaceful":       "Day %s dawns: nobody died last night",
+	"event.dawn_deaths":         "Day %s dawns: %s died last night",
+	"event.dawn_silent":         "Day %s dawns: last night's deaths are not announced",
+	"event.player_afk":          "%s missed %s timed actions in a row and is marked AFK; their turns will be passed",
+	"event.player_back":         "%s is back",
+	"event.spectate_request":    "%s wants to spectate, type allow %[1]s or deny %[1]s",
+	"event.spectate_pending":    "Asked the owner of room %s to let you spectate, waiting for an answer",
+	"event.spectate_denied":     "The owner denied your request to spectate room %s",
+	"event.spectate_started":    "Now spectating: %s (%s), type leave to stop",
+
+	// 服务器的错误码
+	"error_code.server_full":          "The server is full, please try again later",
+	"error_code.too_many_rooms":       "The server has no room for new rooms, try later or join one (rooms lists them)",
+	"error_code.player_room_limit":    "You own too many rooms, finish or close one first",
+	"error_code.too_many_connections": "Too many connections from your address, close other clients and retry",
+	"error_code.invalid_username":     "Names are 2-16 letters, digits, underscores or dashes, pick another: login <name>",
+	"error_code.username_taken":       "That name is taken, pick another: login <name>",
+	"error_code.rate_limited":         "You are sending too fast, try again in a moment",
+	"error_code.invalid_room_config":  "The room setup breaks the server rules:",
+	"error_code.spectators_full":      "This room has no spectator seats left, try again later",
+
+	// 夜间行动结果和我的信息
+	"night.check":       "Night %d check: %s is %s",
+	"night.saved":       "Night %d: saved %s with the antidote",
+	"night.poisoned":    "Night %d: poisoned %s",
+	"night.protected":   "Night %d: protected %s",
+	"night.kill_ok":     "killed",
+	"night.kill_failed": "the target survived",
+	"night.kill":        "Night %d kill on %s: %s",
+	"night.potions":     "Potions: antidote %s, poison %s",
+	"night.potion_left": "left",
+	"night.potion_used": "used",
+	"my_info.vote":      "Day %d: voted for %s",
+
+	// 登记的选择和狼人刀口
+	"pending.kill":     "kill",
+	"pending.check":    "check",
+	"pending.protect":  "protect",
+	"pending.poison":   "poison",
+	"pending.vote":     "vote for",
+	"pending.changed":  "Changed: %s %s (was %s), still changeable until the phase ends",
+	"pending.recorded": "Recorded: %s %s, changeable until the phase ends",
+	"wolf.rule_last":   "last choice wins",
+	"wolf.rule_vote":   "majority",
+	"wolf.choice":      "%s chose to kill %s, current target (%s): %s",
+	"vote.cast":        "%s voted for %s",
+	"vote.changed":     "%s changed their vote to %s",
+	"vote.abstain":     "%s abstained",
+	"vote.hidden":      "%s has voted",
+	"vote.result":      "Round %d exile votes:",
+	"skill_prompt":     "%s (within %d seconds)",
+
+	// 角色预设
+	"presets.none":   "The server offers no role presets",
+	"presets.header": "Role presets (use with create <room> preset=<name>):",
+	"presets.item":   "  %s  %s (%d players): %s",
+
+	// 复盘
+	"recap.draw":      "draw",
+	"recap.won":       "%s won",
+	"recap.rounds":    "%d rounds, %s",
+	"recap.role":      "#%d %s: %s, %s",
+	"recap.hidden":    "#%d %s: ???",
+	"recap.at_night":  "out on night %d",
+	"recap.by_vote":   "exiled on day %d",
+	"recap.survived":  "survived to the end",
+	"recap.killed":    "werewolves killed %s",
+	"recap.protected": "guard protected %s",
+	"recap.saved":     "witch saved %s",
+	"recap.poisoned":  "witch poisoned %s",
+	"recap.checked":   "seer checked %s (%s)",
+	"recap.peaceful":  "no deaths",
+	"recap.deaths":    "%s out",
+	"recap.night":     "Night %d: %s",
+	"recap.no_exile":  "nobody exiled",
+	"recap.exiled":    "%s exiled",
+	"recap.vote":      "Day %d vote: %s, %s",
+	"recap.out":       "out",
+	"recap.alive":     "alive",
+	"recap.score":     "%s: %d pts (accurate votes %d, effective night actions %d, %s)%s",
+	"recap.ratings":   "Rating: %s",
+	"recap.mvp":       "MVP: %s",
+
+	// 警长和发言
+	"sheriff.notice":     "[Sheriff] %s",
+	"sheriff.votes":      "%s %s votes",
+	"sheriff.tally":      "[Sheriff] Tally: %s",
+	"sheriff.speech":     "[Campaign] %s: %s",
+	"whisper.from":       "[Whisper] %s → you: %s",
+	"whisper.to":         "[Whisper] you → %s: %s",
+	"speaking.done":      "Everyone has spoken",
+	"speaking.order":     "Speaking order: %s",
+	"speaking.next":      "%s, please speak",
+	"speaking.your_turn": "Your turn to speak, use speak <text>",
+	"speaking.timed":     "Your turn to speak, use speak <text> within %d seconds",
+
+	// 战绩和排行榜
+	"stats.none":         "%s has not finished a game yet",
+	"stats.summary":      "%s: %d games, %d wins, win rate %s, survival rate %s",
+	"stats.camps":        "  village wins %d, werewolf wins %d",
+	"stats.rating":       "  rating %d (%d ranked games)",
+	"stats.roles":        "  roles played: %s",
+	"leaderboard.empty":  "The leaderboard is empty",
+	"leaderboard.rating": "Leaderboard (by rating):",
+	"leaderboard.rated":  "  %d. %s  %d pts  %d ranked games",
+	"leaderboard.wins":   "Leaderboard (by wins):",
+	"leaderboard.winner": "  %d. %s  %d wins / %d games  win rate %s",
+}
@@ -0,0 +1,537 @@
+package main
+
+// catalogZhCN 中文文案，也是其他语言缺少某个键时的回退
+var catalogZhCN = map[string]string{
+	// 通用
+	"title":       "狼人杀游戏",
+	"seat":        "%d号",
+	"seat_player": "%d号 %s",
+	"list_sep":    "，",
+	"name_sep":    "、",
+	"on":          "开",
+	"off":         "关",
+	"you":         "你",
+	"error":       "错误: %s",
+	"success":     "成功: %s",
+	"typing":      "%d 名玩家正在输入…",
+	"bye":         "再见！",
+	"cancelled":   "已取消",
+
+	// 标题栏
+	"header.room":            "房间: %s",
+	"header.round":           "回合: %d",
+	"header.phase":           "阶段: %s",
+	"header.countdown":       "%d 秒后开局",
+	"header.ready_countdown": "%d 秒后移出未准备的玩家",
+	"header.rtt":             "延迟: %dms",
+
+	// 行模式各栏的标题
+	"panel.players":     "玩家列表",
+	"panel.events":      "事件日志",
+	"panel.chat":        "发言",
+	"panel.role":        "你的角色",
+	"panel.role_skills": "可用技能",
+	"panel.my_info":     "我的信息",
+	"panel.recap":       "对局复盘",
+	"panel.rules":       "本局规则",
+	"panel.guard_rules": "守护规则",
+	"panel.allowed":     "当前可用",
+	"panel.prompt":      "请输入命令",
+	"panel.hint":        "提示: %s",
+
+	// 分栏界面
+	"tui.players":     "玩家",
+	"tui.info":        "信息",
+	"tui.events":      "事件",
+	"tui.chat":        "发言",
+	"tui.help":        "帮助",
+	"tui.role":        "角色: %s (%s)",
+	"tui.skills":      "技能: %s",
+	"tui.allowed":     "当前可用: %s",
+	"tui.rules":       "规则: %s",
+	"tui.guard":       "守护: %s",
+	"tui.lobby":       "login 登录，create 创建房间，join 加入房间",
+	"tui.lobby_help":  "输入 help 查看全部命令",
+	"tui.placeholder": "输入命令，help 查看帮助",
+	"tui.keys":        "↑↓ 选择玩家  Tab 填入座位号  PgUp/PgDn 翻看事件  Ctrl+C 退出",
+	"tui.picker_keys": "↑↓ 选择目标  回车确认（也可输入编号）  Esc 取消",
+	"tui.help_close":  "按 Esc 或回车返回",
+
+	// 玩家状态
+	"status.alive":   "[存活]",
+	"status.dead":    "[死亡]",
+	"status.ready":   "[准备]",
+	"status.bot":     "[机器人]",
+	"status.offline": "[断线]",
+	"status.afk":     "[挂机]",
+	"status.owner":   "[房主]",
+	"status.sheriff": "[警长]",
+	"status.rating":  "[%d分]",
+
+	// 阶段、角色、阵营
+	"phase.start":    "开始",
+	"phase.night":    "夜晚",
+	"phase.day":      "白天",
+	"phase.vote":     "投票",
+	"phase.end":      "结束",
+	"role.werewolf":  "狼人",
+	"role.seer":      "预言家",
+	"role.witch":     "女巫",
+	"role.guard":     "守卫",
+	"role.hunter":    "猎人",
+	"role.villager":  "平民",
+	"role.idiot":     "白痴",
+	"role.knight":    "骑士",
+	"role.wolf_king": "狼王",
+	"role.cupid":     "丘比特",
+	"role.thief":     "盗贼",
+	"camp.good":      "好人阵营",
+	"camp.evil":      "狼人阵营",
+	"camp.lovers":    "恋人阵营",
+	"camp.none":      "无阵营",
+
+	"cause.WOLF_KILL":      "被狼人杀害",
+	"cause.POISON":         "被女巫毒杀",
+	"cause.EXILE":          "被放逐",
+	"cause.KNIGHT_DUEL":    "决斗落败",
+	"cause.WOLF_KING_SHOT": "被狼王带走",
+	"cause.OTHER":          "出局",
+
+	"room_state.WAITING":  "等待中",
+	"room_state.STARTING": "即将开局",
+	"room_state.PLAYING":  "游戏中",
+	"room_state.PAUSED":   "已暂停",
+	"room_state.FINISHED": "已结束",
+	"room_state.ARCHIVED": "已关闭",
+
+	// 对局节奏
+	"speed.blitz":   "快速",
+	"speed.normal":  "正常",
+	"speed.relaxed": "慢速",
+
+	// 技能名称，键为动作类型
+	"skill.format":        "%s(%s)",
+	"skill.kill":          "击杀",
+	"skill.check":         "查验",
+	"skill.protect":       "守护",
+	"skill.antidote":      "解药",
+	"skill.poison":        "毒药",
+	"skill.vote":          "投票",
+	"skill.speak":         "发言",
+	"skill.duel":          "决斗",
+	"skill.link":          "连接恋人",
+	"skill.steal":         "选底牌",
+	"skill.self_destruct": "自爆",
+
+	// 角色技能说明
+	"role_skills.werewolf":  "kill <座位号> - 击杀玩家",
+	"role_skills.seer":      "check <座位号> - 查验玩家身份",
+	"role_skills.witch":     "antidote - 解救被杀玩家 | poison <座位号> - 毒杀玩家",
+	"role_skills.guard":     "protect <座位号> - 保护玩家",
+	"role_skills.hunter":    "被动技能：死亡时可开枪",
+	"role_skills.villager":  "vote <座位号> - 投票（白天/投票阶段）",
+	"role_skills.idiot":     "被动技能：被放逐时翻牌免死一次，之后不能投票",
+	"role_skills.knight":    "duel <座位号> - 白天与一名玩家决斗，对方是狼人则对方出局，否则自己出局（每局一次）",
+	"role_skills.wolf_king": "kill <座位号> - 击杀玩家 | 出局时可开枪带走一名玩家（被毒杀除外）",
+	"role_skills.cupid":     "link <座位号> <座位号> - 第一夜连接两名玩家成为恋人，一方出局另一方殉情",
+	"role_skills.thief":     "steal <1|2> - 第一夜从两张底牌中拿一张，换成该身份",
+
+	// 当前阶段的行动提示
+	"hint.actions":       "轮到你行动: %s",
+	"hint.separator":     "；",
+	"hint.deadline":      "（剩余 %d 秒）",
+	"hint.kill":          "kill <座位号> 选择击杀目标",
+	"hint.check":         "check <座位号> 查验一名玩家",
+	"hint.antidote":      "antidote 解救今晚被杀的玩家",
+	"hint.poison":        "poison <座位号> 毒杀一名玩家",
+	"hint.protect":       "protect <座位号> 守护一名玩家",
+	"hint.link":          "link <座位号> <座位号> 连接两名玩家成为恋人",
+	"hint.steal":         "steal <1|2> 从两张底牌中拿一张",
+	"hint.speak":         "speak <内容> 发言",
+	"hint.duel":          "duel <座位号> 与一名玩家决斗",
+	"hint.vote":          "vote <座位号> 投票",
+	"hint.self_destruct": "explode 自爆并结束当天的发言和投票",
+	"hint.wait":          "等待其他玩家行动...",
+	"hint.default":       "输入 help 查看可用命令",
+
+	// 对局规则
+	"rules.witch_self_save_always":      "女巫可以自救",
+	"rules.witch_self_save_never":       "女巫不能自救",
+	"rules.witch_self_save_first_night": "女巫仅首夜可以自救",
+	"rules.first_night_death_only":      "首夜不公布死因",
+	"rules.first_night_none":            "首夜不公布死讯",
+	"rules.wolf_kill_majority":          "狼人刀口多数决",
+	"rules.last_words":                  "出局有遗言",
+	"rules.sheriff":                     "首个白天竞选警长，警长一票计 1.5 票",
+	"rules.hidden_votes":                "放逐投票结束时同时公布每一票",
+	"guard.self_allowed":                "可以守护自己",
+	"guard.self_forbidden":              "不能守护自己",
+	"guard.repeat_allowed":              "可以连续两晚守护同一人",
+	"guard.repeat_forbidden":            "不能连续两晚守护同一人",
+
+	// 偏好设置
+	"prefs.local_tz": "本机时区",
+	"prefs.general":  "语言: %s | 自动准备: %s | 座位颜色: %s | 时区: %s",
+	"prefs.notify":   "通知 - 游戏开始: %s | 轮到我: %s | 玩家加入: %s",
+
+	// 玩家资料
+	"profile.show": "头像: %s | 名字颜色: %s | 简介: %s",
+	"profile.none": "未设置",
+	"player.bio":   "「%s」",
+
+	// 帮助
+	"help.title":               "狼人杀游戏 - 帮助信息",
+	"help.continue":            "按回车键继续...",
+	"help.register.cmd":        "register <用户名> <密码>",
+	"help.register.desc":       "注册账号并登录",
+	"help.login.cmd":           "login <用户名> [密码]",
+	"help.login.desc":          "登录游戏（不带密码为游客）",
+	"help.create.cmd":          "create <房间名> [预设名|角色列表] [选项...]",
+	"help.create.desc":         "创建房间（默认6人局），房间名之后可以写预设名或逗号分隔的角色列表（4 到 18 人），如 werewolf*4,villager*4,seer,witch,hunter,guard；选项: preset=<预设名> 使用角色预设、pw=<密码> 设置加入密码、private 不出现在房间列表、ranked 排位房间（仅限注册玩家）、anonymous 匿名座位（开局随机排座，对局中只显示座位号）、idle=<分钟> 满员且多数人已准备后移出超时未准备的玩家、speed=blitz|normal|relaxed 各步时限减半或加倍、nofilter 关闭不当发言过滤、nowatch 不允许观战、watchers=<人数> 限制观战人数、approvewatch 观战需要房主同意、hiddenvotes 放逐投票结束前不公布各人投给谁、战报投递的 webhook 地址",
+	"help.join.cmd":            "join <房间ID> [密码]",
+	"help.join.desc":           "加入房间，有密码的房间需要带上密码",
+	"help.watch.cmd":           "watch <房间ID> [密码]",
+	"help.watch.desc":          "观战房间，不占座位，看到的与其他观众相同（房间可能要求房主同意），leave 停止观战",
+	"help.rooms.cmd":           "rooms",
+	"help.rooms.desc":          "查看房间列表（不含私密房间）",
+	"help.presets.cmd":         "presets",
+	"help.presets.desc":        "查看建房可选的角色预设",
+	"help.stats.cmd":           "stats [用户名]",
+	"help.stats.desc":          "查看战绩（不带用户名时查看自己，需注册账号）",
+	"help.leaderboard.cmd":     "leaderboard [rating]",
+	"help.leaderboard.desc":    "查看胜场排行榜，带 rating 时查看排位积分排行榜",
+	"help.ready.cmd":           "ready",
+	"help.ready.desc":          "准备",
+	"help.unready.cmd":         "unready",
+	"help.unready.desc":        "取消准备",
+	"help.rematch.cmd":         "rematch [no]",
+	"help.rematch.desc":        "对局结束后：同意原班人马、原角色配置再来一局（no 撤回），全员同意后房间重新开放",
+	"help.kick.cmd":            "kick <座位号>",
+	"help.kick.desc":           "房主：踢出玩家",
+	"help.owner.cmd":           "owner <座位号>",
+	"help.owner.desc":          "房主：转让房主",
+	"help.close.cmd":           "close",
+	"help.close.desc":          "房主：关闭房间",
+	"help.allow.cmd":           "allow <用户名>",
+	"help.allow.desc":          "房主：同意观战请求",
+	"help.deny.cmd":            "deny <用户名>",
+	"help.deny.desc":           "房主：拒绝观战请求",
+	"help.leave.cmd":           "leave",
+	"help.leave.desc":          "离开房间（对局中离开视为出局）",
+	"help.bot.cmd":             "bot [数量]",
+	"help.bot.desc":            "房主：添加机器人填补空位（不填数量则填满）",
+	"help.validate.cmd":        "validate [角色...]",
+	"help.validate.desc":       "检查角色配置是否可用（不创建房间），如 validate werewolf werewolf seer witch villager villager",
+	"help.kill.cmd":            "kill [座位号]",
+	"help.kill.desc":           "狼人选择击杀目标，天亮前可以改选",
+	"help.check.cmd":           "check [座位号]",
+	"help.check.desc":          "预言家查验目标，天亮前可以改选",
+	"help.protect.cmd":         "protect [座位号]",
+	"help.protect.desc":        "守卫保护目标，天亮前可以改选",
+	"help.antidote.cmd":        "antidote",
+	"help.antidote.desc":       "女巫使用解药",
+	"help.poison.cmd":          "poison [座位号]",
+	"help.poison.desc":         "女巫使用毒药，天亮前可以改选",
+	"help.witch_antidote.cmd":  "witch yes|no",
+	"help.witch_antidote.desc": "女巫按提示决定是否对刀口使用解药",
+	"help.witch_poison.cmd":    "witch <座位号>|pass",
+	"help.witch_poison.desc":   "女巫按提示选择毒药目标或不用毒药",
+	"help.duel.cmd":            "duel [座位号]",
+	"help.duel.desc":           "骑士白天与一名玩家决斗（每局一次）",
+	"help.link.cmd":            "link <座位号> <座位号>",
+	"help.link.desc":           "丘比特第一夜连接两名玩家成为恋人",
+	"help.steal.cmd":           "steal <1|2>",
+	"help.steal.desc":          "盗贼第一夜从两张底牌中拿一张",
+	"help.explode.cmd":         "explode",
+	"help.explode.desc":        "狼人白天自爆（需房间规则允许），结束当天发言和投票",
+	"help.shoot.cmd":           "shoot <座位号>|pass",
+	"help.shoot.desc":          "狼王出局后按提示开枪带走一名玩家或放弃",
+	"help.vote.cmd":            "vote [座位号]",
+	"help.vote.desc":           "投票，投票阶段结束前可以改投",
+	"help.picker.cmd":          "（以上命令不带座位号）",
+	"help.picker.desc":         "列出可选的目标，用方向键或编号选择",
+	"help.speak.cmd":           "speak [内容]",
+	"help.speak.desc":          "发言（不带内容时进入输入模式，其他玩家会看到有人正在输入）",
+	"help.pass.cmd":            "pass",
+	"help.pass.desc":           "放弃本阶段的行动（不查验、不守护、不选刀口、弃权或跳过发言），服务器的规则引擎不支持时被拒绝",
+	"help.sheriff_run.cmd":     "sheriff run|quit",
+	"help.sheriff_run.desc":    "上警 / 放弃上警或退水（开启警长规则时）",
+	"help.sheriff_say.cmd":     "sheriff say <内容>",
+	"help.sheriff_say.desc":    "竞选发言",
+	"help.sheriff_vote.cmd":    "sheriff vote <座位号>",
+	"help.sheriff_vote.desc":   "警下玩家投票选警长",
+	"help.sheriff_pass.cmd":    "sheriff pass [座位号]",
+	"help.sheriff_pass.desc":   "警长出局时移交警徽（不带座位号为撕毁）",
+	"help.sheriff_order.cmd":   "sheriff order cw|ccw",
+	"help.sheriff_order.desc":  "警长：选择当天发言方向（座位号递增/递减）",
+	"help.skills.cmd":          "skills",
+	"help.skills.desc":         "查询当前可用技能",
+	"help.pause.cmd":           "pause",
+	"help.pause.desc":          "对局中：请求暂停（比如等人重连），过半在线玩家同意后生效",
+	"help.resume.cmd":          "resume",
+	"help.resume.desc":         "请求继续暂停的对局，暂停的发起人一人即可，其他人需要过半同意",
+	"help.prefs.cmd":           "prefs",
+	"help.prefs.desc":          "查看偏好设置",
+	"help.set.cmd":             "set <项> <值>",
+	"help.set.desc":            "修改偏好: lang/autoready/color/tz/notify <类型>（set lang en-US 立即切换界面语言）",
+	"help.profile.cmd":         "profile [项] [值]",
+	"help.profile.desc":        "查看或修改玩家资料: avatar <表情或编号>、color <名字颜色>、bio <简介>（最多 80 字），不带值时清除；其他玩家在玩家列表和复盘中看到",
+	"help.whisper.cmd":         "/w <座位号> <内容>",
+	"help.whisper.desc":        "在房间大厅里私聊一名玩家（对局中不可用）",
+	"help.mute.cmd":            "mute|unmute <座位号>",
+	"help.mute.desc":           "屏蔽/取消屏蔽该玩家的发言，只对自己生效",
+	"help.report.cmd":          "report <座位号> <理由>",
+	"help.report.desc":         "举报玩家，附带最近的发言提交给管理员",
+	"help.help.cmd":            "help",
+	"help.help.desc":           "显示此帮助信息",
+	"help.quit.cmd":            "quit",
+	"help.quit.desc":           "退出游戏",
+
+	// 命令用法和输入错误
+	"usage.login":         "用法: login <用户名> [密码]",
+	"usage.register":      "用法: register <用户名> <密码>",
+	"usage.join":          "用法: join <房间ID> [密码]",
+	"usage.seat":          "用法: %s <座位号>",
+	"usage.report":        "用法: report <座位号> <理由>",
+	"usage.rematch":       "用法: rematch [yes|no]",
+	"usage.profile":       "用法: profile [avatar|color|bio] [值]",
+	"usage.watch":         "用法: watch <房间ID> [密码]",
+	"usage.spectator":     "用法: %s <用户名>",
+	"usage.watchers":      "watchers= 后面是观战人数上限，如 watchers=10",
+	"usage.create_idle":   "idle= 后面是分钟数，如 idle=3",
+	"usage.create_roles":  "角色列表用逗号分隔，多张同一角色写 *<数量>，如 werewolf*3,villager*3,seer,witch,hunter",
+	"usage.whisper":       "用法: /w <座位号> <内容>",
+	"usage.bot":           "用法: bot [数量]",
+	"usage.set":           "用法: set lang <zh-CN|en-US> | set autoready <on|off> | set color <颜色> | set tz <时区，如 Asia/Shanghai> | set notify <gamestart|turn|join> <on|off>",
+	"usage.witch":         "用法: witch yes|no（解药） 或 witch <座位号>|pass（毒药）",
+	"usage.witch_yes_no":  "用法: witch yes|no",
+	"usage.shoot":         "用法: shoot <座位号>|pass",
+	"usage.link":          "用法: link <座位号> <座位号>",
+	"usage.steal":         "用法: steal <1|2>",
+	"usage.sheriff":       "用法: sheriff run|quit|say <内容>|vote <座位号>|pass [座位号]|order cw|ccw",
+	"usage.sheriff_say":   "用法: sheriff say <内容>",
+	"usage.sheriff_vote":  "用法: sheriff vote <座位号>",
+	"usage.sheriff_order": "用法: sheriff order cw|ccw（cw 按座位号递增，ccw 按座位号递减）",
+	"usage.wait":          "用法: wait <消息类型> [超时]",
+	"usage.sleep":         "用法: sleep <时长>",
+	"err.unknown_command": "未知命令: %s，输入 help 查看帮助",
+	"err.seat_not_number": "座位号必须是数字",
+	"err.invalid_seat":    "无效的座位号: %d",
+	"err.not_in_room":     "你不在任何房间中",
+	"err.not_logged_in":   "请先登录",
+	"err.unknown_tz":      "未知时区: %s",
+	"err.unknown_lang":    "不支持的语言: %s（可选 zh-CN、en-US）",
+	"err.no_witch_prompt": "现在没有需要回答的用药提示",
+	"err.no_shot_prompt":  "现在没有需要回答的开枪提示",
+	"err.no_request":      "%s 没有请求观战",
+	"err.open_script":     "打开脚本失败: %v",
+	"err.whisper_in_game": "对局进行中不能私聊",
+	"err.connect":         "连接服务器失败: %v",
+	"err.tutorial_server": "启动教学服务器失败: %v",
+	"err.disconnected":    "与服务器的连接已断开",
+	"default_room_name":   "游戏房间",
+	"speak.prompt":        "请输入发言内容（空行取消）:",
+
+	// 目标菜单
+	"picker.title":          "选择目标（%s）",
+	"picker.footer":         "输入编号选择，直接回车取消",
+	"picker.unavailable":    "现在不能使用 %s，用法: %s <座位号>",
+	"picker.no_targets":     "%s 没有可选的目标",
+	"picker.invalid_target": "%d号 %s 现在不能作为 %s 的目标",
+	"picker.out_of_range":   "请输入 1-%d 之间的编号",
+
+	// 脚本模式
+	"script.line":         "脚本第 %d 行",
+	"script.bad_timeout":  "无效的超时: %s",
+	"script.bad_duration": "无效的时长: %s",
+	"script.wait_timeout": "等待 %s 超时（%s）",
+
+	// 教学模式
+	"tutorial.player_name":    "新手",
+	"tutorial.room_name":      "新手教学",
+	"tutorial.welcome":        "[教学] 欢迎来到狼人杀！这局练习在你的电脑上进行，其他 5 个座位由机器人扮演。正在为你登录并创建6人房间：2 狼人、2 村民、预言家和女巫。",
+	"tutorial.ready":          "[教学] 机器人已经入座并且总是准备好的。输入 ready 开始游戏。",
+	"tutorial.started":        "[教学] 游戏开始，你的身份是 %s。狼人杀光村民或神职就获胜，好人投票放逐所有狼人就获胜。随时输入 skills 查看你的技能。",
+	"tutorial.night_wolf":     "[教学] 天黑了。狼人一起睁眼：用 kill <座位号> 选择今晚的目标，你的队友在玩家列表中标出。",
+	"tutorial.night_seer":     "[教学] 天黑了。作为预言家，用 check <座位号> 查验一名玩家是不是狼人，结果只有你能看到。",
+	"tutorial.night_witch":    "[教学] 天黑了。女巫有两瓶各只能用一次的药：antidote 救下今晚被刀的人，poison <座位号> 毒死一名玩家，也可以什么都不做。",
+	"tutorial.night_villager": "[教学] 天黑了。村民夜里没有技能，请闭眼等待天亮，其他人正在行动。",
+	"tutorial.day":            "[教学] 天亮了，公布昨晚的死讯。玩家依次发言，轮到你时用 speak <内容> 说出你的信息和怀疑。",
+	"tutorial.vote":           "[教学] 开始投票。用 vote <座位号> 投给你认为是狼人的玩家，得票最多的人出局。",
+	"tutorial.ended":          "[教学] 游戏结束，上面公布了所有人的身份。你已经掌握了基本玩法；去掉 -tutorial 运行客户端就可以和朋友一起玩，输入 quit 退出。",
+
+	// 事件日志
+	"event.login_guest":         "以游客身份登录，玩家ID: %s",
+	"event.login":               "登录成功，玩家ID: %s",
+	"event.room_created":        "房间创建成功，房间ID: %s",
+	"event.room_joined":         "加入房间: %s",
+	"event.player_joined":       "玩家加入: %s",
+	"event.you_left":            "你已离开房间",
+	"event.player_abandoned":    "%s 中途离开，视为出局",
+	"event.player_left":         "玩家离开: %s",
+	"event.player_ready":        "玩家%s准备",
+	"event.player_unready":      "玩家%s取消准备",
+	"event.game_started":        "游戏开始！",
+	"event.anonymous_seats":     "本局为匿名座位，座位已随机排定，对局中只以座位号称呼，结束后公布名字",
+	"event.teammates":           "你的狼人同伴: %s",
+	"event.extra_roles":         "两张底牌: %s，用 steal <1|2> 拿一张",
+	"event.role_changed":        "你的身份变为: %s",
+	"event.phase_changed":       "阶段变化: %s",
+	"event.action_ok":           "✓ %s",
+	"event.action_failed":       "✗ %s",
+	"event.ended_player_left":   "有玩家中途离开，对局提前结算",
+	"event.game_aborted":        "游戏异常中止，本局判为平局",
+	"event.game_ended":          "游戏结束！获胜阵营: %s",
+	"event.rematch_votes":       "再来一局：%d/%d 人同意，输入 rematch 表示同意",
+	"event.rematch_reset":       "全员同意再来一局，房间重新开放，角色重新洗牌",
+	"event.kicked":              "你已被房主请出房间: %s",
+	"event.kicked_idle":         "你超时未准备，已被移出房间 %s",
+	"event.kicked_profanity":    "你多次不当发言，已被移出房间 %s",
+	"event.ready_timeout":       "%s 还没有准备，%d 秒后将被移出房间",
+	"event.room_closed":         "房主已关闭房间: %s",
+	"event.room_archived":       "房间已关闭: %s",
+	"event.became_owner":        "你成为了房主",
+	"event.player_disconnected": "%s 断线，座位保留 %d 秒等待重连",
+	"event.player_reconnected":  "%s 已重连",
+	"event.owner_changed":       "房主变更为: %s",
+	"event.config_valid":        "✓ 房间配置可用",
+	"event.config_invalid":      "✗ 房间配置不可用",
+	"event.config_error":        "  错误: %s",
+	"event.config_warning":      "  提示: %s",
+	"event.starting":            "所有人已准备，%d 秒后开局（输入 unready 可取消准备）",
+	"event.no_rooms":            "当前没有公开的房间，可以用 create 创建一个",
+	"event.room_list":           "公开房间 %d 个:",
+	"event.room_locked":         " [需密码]",
+	"event.room_ranked":         " [排位]",
+	"event.room_anonymous":      " [匿名]",
+	"event.room_speed":          " [%s]",
+	"event.room_balance":        " [%d狼/%d神/%d民]",
+	"event.private_notice":      "【提示】%s",
+	"event.announcement":        "【主持人】%s",
+	"event.room_saved":          "房间已保存，服务器重启后使用同一账号登录即可继续",
+	"event.paused_engine":       "游戏因服务器内部错误暂停，请等待管理员处理",
+	"event.paused":              "游戏暂停: %s",
+	"event.paused_reconnect":    "服务器已重启，所有玩家重新连接后对局继续",
+	"event.paused_player":       "%s 发起的暂停已生效，计时全部停下，发起人或过半玩家输入 resume 后继续",
+	"event.pause_votes":         "请求暂停：%d/%d 人同意，输入 pause 表示同意",
+	"event.resume_votes":        "请求继续：%d/%d 人同意，输入 resume 表示同意",
+	"event.resumed":             "对局继续，计时从暂停处接着走",
+	"event.error_for":           "错误 (%s): %s",
+	"event.error":               "错误: %s",
+	"event.player_died":         "%s 死亡: %s",
+	"event.player_died_hidden":  "%s 死亡",
+	"event.idiot_revealed":      "%s 翻牌白痴，免于放逐，之后不能再投票",
+	"event.duel_wolf":           "%s 翻牌骑士，与 %s 决斗：对方是狼人，出局",
+	"event.duel_good":           "%s 翻牌骑士，与 %s 决斗：对方是好人，骑士出局",
+	"event.wolf_king_shot":      "%s 翻牌狼王，开枪带走了 %s",
+	"event.wolf_self_destruct":  "%s 是狼人，当场自爆！今天的发言和投票到此结束，直接进入黑夜",
+	"event.lovers_linked":       "你被丘比特选为恋人，你的恋人是 %s",
+	"event.lover_suicide":       "%s 的恋人 %s 出局，随之殉情",
+	"event.dawn_peaceful":       "第%s天天亮了，昨晚是平安夜",
+	"event.dawn_deaths":         "第%s天天亮了，昨晚 %s 出局",
+	"event.dawn_silent":         "第%s天天亮了，昨晚的死讯不公布",
+	"event.player_afk":          "%s 连续 %s 次没有行动，视为挂机，之后的回合自动跳过",
+	"event.player_back":         "%s 回来了",
+	"event.spectate_request":    "%s 请求观战，输入 allow %[1]s 同意或 deny %[1]s 拒绝",
+	"event.spectate_pending":    "已向房间 %s 的房主请求观战，等待答复",
+	"event.spectate_denied":     "房主拒绝了你观战房间 %s 的请求",
+	"event.spectate_started":    "开始观战: %s（%s），输入 leave 停止观战",
+
+	// 服务器的错误码
+	"error_code.server_full":          "服务器在线人数已满，请稍后再试",
+	"error_code.too_many_rooms":       "服务器房间数已满，请稍后再试或加入已有房间（rooms 查看房间列表）",
+	"error_code.player_room_limit":    "你创建的房间数已达上限，请先结束或关闭已有房间",
+	"error_code.too_many_connections": "来自你的网络地址的连接过多，请关闭其他客户端后重试",
+	"error_code.invalid_username":     "用户名需为2-16个字符，只能包含中英文、数字、下划线和短横线，请换一个名字: login <用户名>",
+	"error_code.username_taken":       "该用户名已有人在使用，请换一个名字: login <用户名>",
+	"error_code.rate_limited":         "发送过于频繁，请稍后再试",
+	"error_code.invalid_room_config":  "房间配置不符合规则:",
+	"error_code.spectators_full":      "该房间的观战人数已满，请稍后再试",
+
+	// 夜间行动结果和我的信息
+	"night.check":       "第%d夜查验: %s 是%s",
+	"night.saved":       "第%d夜用解药救了 %s",
+	"night.poisoned":    "第%d夜用毒药毒了 %s",
+	"night.protected":   "第%d夜守护了 %s",
+	"night.kill_ok":     "击杀成功",
+	"night.kill_failed": "击杀失败，目标存活",
+	"night.kill":        "第%d夜刀 %s: %s",
+	"night.potions":     "剩余药水: 解药%s，毒药%s",
+	"night.potion_left": "可用",
+	"night.potion_used": "已用",
+	"my_info.vote":      "第%d天投票给 %s",
+
+	// 登记的选择和狼人刀口
+	"pending.kill":     "刀",
+	"pending.check":    "查验",
+	"pending.protect":  "守护",
+	"pending.poison":   "毒",
+	"pending.vote":     "投票给",
+	"pending.changed":  "已改选: %s %s（原为 %s），阶段结束前仍可改选",
+	"pending.recorded": "已登记: %s %s，阶段结束前可以改选",
+	"wolf.rule_last":   "以最后提交为准",
+	"wolf.rule_vote":   "多数决",
+	"wolf.choice":      "%s 选择刀 %s，当前刀口（%s）: %s",
+	"vote.cast":        "%s 投票给 %s",
+	"vote.changed":     "%s 改投 %s",
+	"vote.abstain":     "%s 弃权",
+	"vote.hidden":      "%s 已投票",
+	"vote.result":      "第%d轮放逐投票:",
+	"skill_prompt":     "%s（%d 秒内）",
+
+	// 角色预设
+	"presets.none":   "服务器没有提供角色预设",
+	"presets.header": "角色预设（create <房间名> preset=<名称> 使用）:",
+	"presets.item":   "  %s  %s（%d人）: %s",
+
+	// 复盘
+	"recap.draw":      "平局",
+	"recap.won":       "%s获胜",
+	"recap.rounds":    "共%d轮，%s",
+	"recap.role":      "%d号 %s: %s，%s",
+	"recap.hidden":    "%d号 %s: ？？？",
+	"recap.at_night":  "第%d夜出局",
+	"recap.by_vote":   "第%d天被放逐",
+	"recap.survived":  "存活到最后",
+	"recap.killed":    "狼人刀 %s",
+	"recap.protected": "守卫守 %s",
+	"recap.saved":     "女巫救 %s",
+	"recap.poisoned":  "女巫毒 %s",
+	"recap.checked":   "预言家验 %s（%s）",
+	"recap.peaceful":  "平安夜",
+	"recap.deaths":    "%s 出局",
+	"recap.night":     "第%d夜: %s",
+	"recap.no_exile":  "无人出局",
+	"recap.exiled":    "%s 被放逐",
+	"recap.vote":      "第%d天投票: %s，%s",
+	"recap.out":       "出局",
+	"recap.alive":     "存活",
+	"recap.score":     "%s: %d 分（准确投票 %d，夜间有效行动 %d，%s）%s",
+	"recap.ratings":   "排位积分: %s",
+	"recap.mvp":       "MVP: %s",
+
+	// 警长和发言
+	"sheriff.notice":     "【警长】%s",
+	"sheriff.votes":      "%s %s 票",
+	"sheriff.tally":      "【警长】竞选计票: %s",
+	"sheriff.speech":     "【竞选】%s: %s",
+	"whisper.from":       "[私聊] %s → 你: %s",
+	"whisper.to":         "[私聊] 你 → %s: %s",
+	"speaking.done":      "所有人已按顺序发言",
+	"speaking.order":     "发言顺序: %s",
+	"speaking.next":      "请 %s 发言",
+	"speaking.your_turn": "轮到你发言，使用 speak <内容> 发言",
+	"speaking.timed":     "轮到你发言，%d 秒内使用 speak <内容> 发言",
+
+	// 战绩和排行榜
+	"stats.none":         "%s 还没有完成过对局",
+	"stats.summary":      "%s 的战绩: %d 局 %d 胜，胜率 %s，存活率 %s",
+	"stats.camps":        "  好人阵营胜 %d 局，狼人阵营胜 %d 局",
+	"stats.rating":       "  排位积分 %d（%d 局排位）",
+	"stats.roles":        "  玩过的角色: %s",
+	"leaderboard.empty":  "排行榜还是空的",
+	"leaderboard.rating": "排行榜（按排位积分）:",
+	"leaderboard.rated":  "  %d. %s  %d 分  %d 局排位",
+	"leaderboard.wins":   "排行榜（按胜场）:",
+	"leaderboard.winner": "  %d. %s  %d 胜 / %d 局  胜率 %s",
+}
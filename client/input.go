@@ -2,35 +2,98 @@ package main
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
 	"github.com/pkg/errors"
 )
 
+// activityHintInterval 输入提示的最小发送间隔
+const activityHintInterval = 3 * time.Second
+
 // InputHandler 输入处理器
 type InputHandler struct {
-	scanner *bufio.Scanner
+	reader  io.Reader
+	lines   chan string   // 待处理的输入行，见 submit
+	ended   chan struct{} // 输入结束时关闭，见 end
+	endOnce sync.Once
+	readErr error // 输入出错的原因，ended 关闭前写入
 	client  *Client
+
+	lastHint time.Time // 上次发送输入提示的时间
 }
 
-// NewInputHandler 创建输入处理器
-func NewInputHandler(client *Client) *InputHandler {
+// NewInputHandler 创建从 reader 逐行读取命令的输入处理器
+func NewInputHandler(client *Client, reader io.Reader) *InputHandler {
 	return &InputHandler{
-		scanner: bufio.NewScanner(os.Stdin),
-		client:  client,
+		reader: reader,
+		lines:  make(chan string),
+		ended:  make(chan struct{}),
+		client: client,
+	}
+}
+
+// start 在后台读取输入，读取阻塞时主循环仍能响应客户端关闭
+//
+// 终端界面自己读取键盘，不调用 start，而是把输入行交给 submit。
+func (h *InputHandler) start() {
+	go func() {
+		scanner := bufio.NewScanner(h.reader)
+		for scanner.Scan() {
+			if !h.submit(scanner.Text()) {
+				return
+			}
+		}
+		h.readErr = scanner.Err()
+		h.end()
+	}()
+}
+
+// submit 把一行输入交给主循环，阻塞到主循环取走为止；客户端关闭或输入已结束时返回 false
+func (h *InputHandler) submit(line string) bool {
+	select {
+	case h.lines <- strings.TrimSpace(line):
+		return true
+	case <-h.client.done:
+		return false
+	case <-h.ended:
+		return false
 	}
 }
 
-// ReadCommand 读取命令
+// end 输入结束，之后 ReadCommand 返回 io.EOF
+func (h *InputHandler) end() {
+	h.endOnce.Do(func() {
+		close(h.ended)
+	})
+}
+
+// ReadCommand 读取一行命令；输入结束时返回 io.EOF，客户端关闭时返回 ErrClientClosed
 func (h *InputHandler) ReadCommand() (string, error) {
-	if !h.scanner.Scan() {
-		return "", errors.New("failed to read input")
+	select {
+	case line := <-h.lines:
+		return line, nil
+	case <-h.ended:
+		if h.readErr != nil {
+			return "", errors.Wrap(h.readErr, "read input")
+		}
+		return "", io.EOF
+	case <-h.client.done:
+		return "", ErrClientClosed
 	}
+}
 
-	return strings.TrimSpace(h.scanner.Text()), nil
+// Close 关闭输入源，让后台读取退出；标准输入无法打断，读取协程随进程结束
+func (h *InputHandler) Close() {
+	if closer, ok := h.reader.(io.Closer); ok && h.reader != os.Stdin {
+		closer.Close()
+	}
 }
 
 // HandleCommand 处理命令
@@ -51,12 +114,32 @@ func (h *InputHandler) HandleCommand(cmd string) error {
 		return h.handleHelp()
 	case "login":
 		return h.handleLogin(parts)
+	case "register":
+		return h.handleRegister(parts)
 	case "create":
 		return h.handleCreate(parts)
 	case "join":
 		return h.handleJoin(parts)
+	case "watch":
+		return h.handleWatch(parts)
+	case "rooms":
+		return h.handleListRooms()
+	case "presets":
+		return h.handleListPresets()
+	case "stats":
+		return h.handleStatsCommand(parts)
+	case "leaderboard", "top":
+		return h.handleLeaderboardCommand(parts)
 	case "ready":
-		return h.handleReady()
+		return h.handleReady(true)
+	case "unready":
+		return h.handleReady(false)
+	case "rematch":
+		return h.handleRematch(parts)
+	case "pause":
+		return h.handlePause(false)
+	case "resume":
+		return h.handlePause(true)
 	case "kill":
 		return h.handleAction("kill", parts)
 	case "check":
@@ -67,21 +150,78 @@ func (h *InputHandler) HandleCommand(cmd string) error {
 		return h.handleAction("antidote", parts)
 	case "poison":
 		return h.handleAction("poison", parts)
+	case "witch":
+		return h.handleWitch(parts)
+	case "duel":
+		return h.handleAction("duel", parts)
+	case "link":
+		return h.handleLink(parts)
+	case "steal":
+		return h.handleSteal(parts)
+	case "explode":
+		return h.handleAction(string(protocol.ActionSelfDestruct), parts)
+	case "shoot":
+		return h.handleShoot(parts)
 	case "vote":
 		return h.handleAction("vote", parts)
 	case "speak":
 		return h.handleSpeak(parts)
+	case "pass":
+		return h.handlePass()
+	case "sheriff":
+		return h.handleSheriff(parts)
+	case "skills":
+		return h.handleSkills()
+	case "prefs":
+		for _, line := range preferenceLines(h.client.state.Preferences) {
+			h.client.screen.Message(line)
+		}
+		return nil
+	case "set":
+		return h.handleSet(parts)
+	case "profile":
+		return h.handleProfile(parts)
+	case "kick":
+		return h.handleOwnerAction("kick", parts, protocol.NewKickPlayerMessage)
+	case "owner":
+		return h.handleOwnerAction("owner", parts, protocol.NewTransferOwnerMessage)
+	case "close":
+		return h.handleClose()
+	case "allow":
+		return h.handleSpectateDecision(parts, true)
+	case "deny":
+		return h.handleSpectateDecision(parts, false)
+	case "leave":
+		return h.handleLeave()
+	case "validate":
+		return h.handleValidate(parts)
+	case "bot":
+		return h.handleAddBot(parts)
+	case "mute":
+		return h.handleMute(parts, true)
+	case "unmute":
+		return h.handleMute(parts, false)
+	case "report":
+		return h.handleReport(parts)
+	case "/w", "whisper":
+		return h.handleWhisper(parts)
 	case "quit", "exit":
 		return h.handleQuit()
 	default:
-		return errors.Errorf("未知命令: %s，输入 help 查看帮助", command)
+		return errors.New(tr("err.unknown_command", command))
 	}
 }
 
 // handleHelp 处理帮助命令
 func (h *InputHandler) handleHelp() error {
-	h.client.ui.PrintHelp()
-	h.scanner.Scan() // 等待用户按回车
+	if !h.client.screen.ShowHelp() {
+		return nil
+	}
+
+	// 等待用户按回车
+	if _, err := h.ReadCommand(); err != nil {
+		return err
+	}
 	h.client.Render()
 	return nil
 }
@@ -89,11 +229,32 @@ func (h *InputHandler) handleHelp() error {
 // handleLogin 处理登录命令
 func (h *InputHandler) handleLogin(parts []string) error {
 	if len(parts) < 2 {
-		return errors.New("用法: login <用户名>")
+		return errors.New(tr("usage.login"))
 	}
 
 	username := parts[1]
-	msg, err := protocol.NewLoginMessage(username)
+
+	var msg *protocol.Message
+	var err error
+	if len(parts) >= 3 {
+		msg, err = protocol.NewAccountLoginMessage(username, parts[2])
+	} else {
+		msg, err = protocol.NewLoginMessage(username)
+	}
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleRegister 处理注册命令
+func (h *InputHandler) handleRegister(parts []string) error {
+	if len(parts) < 3 {
+		return errors.New(tr("usage.register"))
+	}
+
+	msg, err := protocol.NewRegisterMessage(parts[1], parts[2])
 	if err != nil {
 		return err
 	}
@@ -102,18 +263,145 @@ func (h *InputHandler) handleLogin(parts []string) error {
 }
 
 // handleCreate 处理创建房间命令
+//
+// 房间名之后可以紧跟预设名或逗号分隔的角色列表（如 werewolf*4,villager*4,seer,witch,hunter,guard），
+// 决定房间人数（4 到 18 人）；其余参数可以是 preset=<预设名>、pw=<密码>、private、ranked、anonymous、idle=<分钟>、
+// speed=<节奏>、nofilter、nowatch、watchers=<人数>、approvewatch、hiddenvotes 或战报投递地址，顺序不限。都不指定时使用默认6人局。
 func (h *InputHandler) handleCreate(parts []string) error {
-	roomName := "游戏房间"
+	roomName := tr("default_room_name")
 	if len(parts) >= 2 {
 		roomName = parts[1]
 	}
 
-	// 使用默认6人局配置
-	msg, err := protocol.NewCreateRoomMessage(roomName, []interface{}{
+	// 使用默认6人局配置，指定预设时由服务器按预设分配角色
+	roles := defaultRoles()
+
+	var opts protocol.RoomOptions
+	var spectate protocol.SpectateSettings
+	hiddenVotes := false
+	if len(parts) >= 3 && isRoleSetup(parts[2]) {
+		if strings.ContainsAny(parts[2], ",*") {
+			list, err := parseRoleList(parts[2])
+			if err != nil {
+				return err
+			}
+			roles = list
+		} else {
+			opts.Preset = parts[2]
+		}
+		parts = append(parts[:2:2], parts[3:]...)
+	}
+	if len(parts) >= 3 {
+		for _, arg := range parts[2:] {
+			switch {
+			case strings.HasPrefix(arg, "preset="):
+				opts.Preset = strings.TrimPrefix(arg, "preset=")
+			case strings.HasPrefix(arg, "pw="):
+				opts.Password = strings.TrimPrefix(arg, "pw=")
+			case arg == "private":
+				opts.Private = true
+			case arg == "ranked":
+				opts.Ranked = true
+			case arg == "anonymous":
+				opts.Anonymous = true
+			case arg == "nofilter":
+				opts.NoChatFilter = true
+			case arg == "nowatch":
+				spectate.Disabled = true
+			case arg == "approvewatch":
+				spectate.Approval = true
+			case arg == "hiddenvotes":
+				hiddenVotes = true
+			case strings.HasPrefix(arg, "watchers="):
+				limit, err := strconv.Atoi(strings.TrimPrefix(arg, "watchers="))
+				if err != nil || limit <= 0 {
+					return errors.New(tr("usage.watchers"))
+				}
+				spectate.Max = limit
+			case strings.HasPrefix(arg, "idle="):
+				minutes, err := strconv.Atoi(strings.TrimPrefix(arg, "idle="))
+				if err != nil || minutes <= 0 {
+					return errors.New(tr("usage.create_idle"))
+				}
+				opts.ReadyTimeout = minutes * 60
+			case strings.HasPrefix(arg, "speed="):
+				opts.Speed = strings.TrimPrefix(arg, "speed=")
+			default:
+				opts.SummaryWebhook = arg
+			}
+		}
+	}
+	if spectate != (protocol.SpectateSettings{}) {
+		opts.Spectate = &spectate
+	}
+	if hiddenVotes {
+		// 其余规则留空，由服务器按默认值补齐
+		opts.Rules = &protocol.GameRules{HiddenVotes: true}
+	}
+
+	msg, err := protocol.NewCreateRoomWithOptionsMessage(roomName, roles, opts)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// isRoleSetup 房间名之后的参数是否是预设名或角色列表，而不是选项或战报地址
+func isRoleSetup(arg string) bool {
+	switch {
+	case strings.Contains(arg, "="), strings.Contains(arg, "://"):
+		return false
+	case arg == "private", arg == "ranked", arg == "anonymous", arg == "nofilter", arg == "nowatch", arg == "approvewatch", arg == "hiddenvotes":
+		return false
+	default:
+		return true
+	}
+}
+
+// parseRoleList 解析逗号分隔的角色列表，角色后面可以用 *<数量> 表示多张，如 werewolf*3,villager*3,seer
+func parseRoleList(list string) ([]interface{}, error) {
+	var roles []interface{}
+	for _, item := range strings.Split(list, ",") {
+		role, count := strings.ToLower(strings.TrimSpace(item)), 1
+		if name, n, ok := strings.Cut(role, "*"); ok {
+			parsed, err := strconv.Atoi(n)
+			if err != nil || parsed <= 0 {
+				return nil, errors.New(tr("usage.create_roles"))
+			}
+			role, count = name, parsed
+		}
+		if role == "" {
+			return nil, errors.New(tr("usage.create_roles"))
+		}
+
+		for i := 0; i < count; i++ {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// defaultRoles 默认6人局配置
+func defaultRoles() []interface{} {
+	return []interface{}{
 		"werewolf", "werewolf",
 		"villager", "villager",
 		"seer", "witch",
-	})
+	}
+}
+
+// handleValidate 预检角色配置，不创建房间
+func (h *InputHandler) handleValidate(parts []string) error {
+	roles := defaultRoles()
+	if len(parts) >= 2 {
+		roles = make([]interface{}, 0, len(parts)-1)
+		for _, role := range parts[1:] {
+			roles = append(roles, strings.ToLower(role))
+		}
+	}
+
+	msg, err := protocol.NewValidateRoomConfigMessage("", roles)
 	if err != nil {
 		return err
 	}
@@ -124,11 +412,27 @@ func (h *InputHandler) handleCreate(parts []string) error {
 // handleJoin 处理加入房间命令
 func (h *InputHandler) handleJoin(parts []string) error {
 	if len(parts) < 2 {
-		return errors.New("用法: join <房间ID>")
+		return errors.New(tr("usage.join"))
 	}
 
 	roomID := parts[1]
-	msg, err := protocol.NewJoinRoomMessage(roomID)
+	var msg *protocol.Message
+	var err error
+	if len(parts) >= 3 {
+		msg, err = protocol.NewJoinProtectedRoomMessage(roomID, parts[2])
+	} else {
+		msg, err = protocol.NewJoinRoomMessage(roomID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleListRooms 处理查询房间列表命令
+func (h *InputHandler) handleListRooms() error {
+	msg, err := protocol.NewListRoomsMessage()
 	if err != nil {
 		return err
 	}
@@ -136,9 +440,9 @@ func (h *InputHandler) handleJoin(parts []string) error {
 	return h.client.SendMessage(msg)
 }
 
-// handleReady 处理准备命令
-func (h *InputHandler) handleReady() error {
-	msg, err := protocol.NewReadyMessage()
+// handleReady 处理准备和取消准备命令，发送期望的状态，重复输入不会来回切换
+func (h *InputHandler) handleReady(ready bool) error {
+	msg, err := protocol.NewReadyMessage(ready)
 	if err != nil {
 		return err
 	}
@@ -148,32 +452,118 @@ func (h *InputHandler) handleReady() error {
 
 // handleAction 处理游戏动作命令
 func (h *InputHandler) handleAction(actionType string, parts []string) error {
-	targetID := ""
-
 	// 某些动作需要目标
-	needsTarget := actionType != "antidote"
+	if actionType == "antidote" || actionType == string(protocol.ActionSelfDestruct) {
+		msg, err := protocol.NewPerformActionMessage(actionType, "", nil)
+		if err != nil {
+			return err
+		}
+		return h.client.SendMessage(msg)
+	}
 
-	if needsTarget {
-		if len(parts) < 2 {
-			return errors.Errorf("用法: %s <玩家编号>", actionType)
+	// 目标以座位号发送，由服务器按座位表解析；没有带座位号时从菜单中选择
+	var (
+		target protocol.PlayerInfo
+		err    error
+	)
+	if len(parts) < 2 {
+		var ok bool
+		if target, ok, err = h.pickTarget(actionType); err != nil || !ok {
+			return err
 		}
+	} else if target, err = h.playerBySeat(parts[1]); err != nil {
+		return err
+	} else if err = h.client.checkTarget(werewolf.ActionType(actionType), target); err != nil {
+		return err
+	}
 
-		// 解析玩家编号
-		playerNum, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return errors.New("玩家编号必须是数字")
+	msg, err := protocol.NewSeatActionMessage(actionType, target.Seat, nil)
+	if err != nil {
+		return err
+	}
+
+	if actionType == string(protocol.ActionVote) {
+		return h.client.sendVote(msg, target.ID)
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleLink 丘比特连接两名恋人
+func (h *InputHandler) handleLink(parts []string) error {
+	if len(parts) != 3 {
+		return errors.New(tr("usage.link"))
+	}
+
+	first, err := h.playerBySeat(parts[1])
+	if err != nil {
+		return err
+	}
+	second, err := h.playerBySeat(parts[2])
+	if err != nil {
+		return err
+	}
+	for _, p := range []protocol.PlayerInfo{first, second} {
+		if err := h.client.checkTarget(protocol.ActionLink, p); err != nil {
+			return err
 		}
+	}
+
+	msg, err := protocol.NewLinkMessage(first.Seat, second.Seat)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleSteal 盗贼拿第 1 或第 2 张底牌
+func (h *InputHandler) handleSteal(parts []string) error {
+	if len(parts) != 2 {
+		return errors.New(tr("usage.steal"))
+	}
+
+	card, err := strconv.Atoi(parts[1])
+	if err != nil || card < 1 || card > 2 {
+		return errors.New(tr("usage.steal"))
+	}
+
+	msg, err := protocol.NewStealMessage(card)
+	if err != nil {
+		return err
+	}
 
-		// 将编号转换为玩家ID
-		players := h.client.state.Players
-		if playerNum < 1 || playerNum > len(players) {
-			return errors.Errorf("无效的玩家编号: %d", playerNum)
+	return h.client.SendMessage(msg)
+}
+
+// playerBySeat 根据座位号查找玩家
+func (h *InputHandler) playerBySeat(arg string) (protocol.PlayerInfo, error) {
+	seat, err := strconv.Atoi(arg)
+	if err != nil {
+		return protocol.PlayerInfo{}, errors.New(tr("err.seat_not_number"))
+	}
+
+	for _, p := range h.client.state.Players {
+		if p.Seat == seat {
+			return p, nil
 		}
+	}
 
-		targetID = players[playerNum-1].ID
+	return protocol.PlayerInfo{}, errors.New(tr("err.invalid_seat", seat))
+}
+
+// handleOwnerAction 处理针对玩家的房主命令（踢人、转让房主）
+func (h *InputHandler) handleOwnerAction(command string, parts []string, build func(playerID string) (*protocol.Message, error)) error {
+	if len(parts) < 2 {
+		return errors.New(tr("usage.seat", command))
+	}
+
+	target, err := h.playerBySeat(parts[1])
+	if err != nil {
+		return err
 	}
 
-	msg, err := protocol.NewPerformActionMessage(actionType, targetID, nil)
+	msg, err := build(target.ID)
 	if err != nil {
 		return err
 	}
@@ -181,13 +571,130 @@ func (h *InputHandler) handleAction(actionType string, parts []string) error {
 	return h.client.SendMessage(msg)
 }
 
-// handleSpeak 处理发言命令
-func (h *InputHandler) handleSpeak(parts []string) error {
+// handleMute 处理屏蔽/取消屏蔽命令，由服务器停止转发对方的发言
+func (h *InputHandler) handleMute(parts []string, muted bool) error {
 	if len(parts) < 2 {
-		return errors.New("用法: speak <内容>")
+		return errors.New(tr("usage.seat", parts[0]))
+	}
+
+	target, err := h.playerBySeat(parts[1])
+	if err != nil {
+		return err
+	}
+
+	msg, err := protocol.NewMutePlayerMessage(target.ID, muted)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleReport 处理举报命令
+func (h *InputHandler) handleReport(parts []string) error {
+	if len(parts) < 3 {
+		return errors.New(tr("usage.report"))
+	}
+
+	target, err := h.playerBySeat(parts[1])
+	if err != nil {
+		return err
+	}
+
+	msg, err := protocol.NewReportPlayerMessage(target.ID, strings.Join(parts[2:], " "))
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleWhisper 处理私聊命令，只能在房间大厅里使用
+func (h *InputHandler) handleWhisper(parts []string) error {
+	if len(parts) < 3 {
+		return errors.New(tr("usage.whisper"))
+	}
+	if h.client.state.IsInGame {
+		return errors.New(tr("err.whisper_in_game"))
+	}
+
+	target, err := h.playerBySeat(parts[1])
+	if err != nil {
+		return err
+	}
+
+	msg, err := protocol.NewWhisperMessage(target.ID, strings.Join(parts[2:], " "))
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleAddBot 处理房主添加机器人命令
+func (h *InputHandler) handleAddBot(parts []string) error {
+	count := 0
+	if len(parts) >= 2 {
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n <= 0 {
+			return errors.New(tr("usage.bot"))
+		}
+		count = n
+	}
+
+	msg, err := protocol.NewAddBotMessage(count)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleClose 处理关闭房间命令
+func (h *InputHandler) handleClose() error {
+	msg, err := protocol.NewCloseRoomMessage()
+	if err != nil {
+		return err
 	}
 
-	content := strings.Join(parts[1:], " ")
+	return h.client.SendMessage(msg)
+}
+
+// handleLeave 处理离开房间命令
+func (h *InputHandler) handleLeave() error {
+	if h.client.state.RoomID == "" {
+		return errors.New(tr("err.not_in_room"))
+	}
+
+	msg, err := protocol.NewLeaveRoomMessage()
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleSpeak 处理发言命令
+//
+// 只输入 speak 时进入编辑模式：先告诉服务器正在输入，下一行作为发言内容。
+func (h *InputHandler) handleSpeak(parts []string) error {
+	var content string
+	if len(parts) >= 2 {
+		content = strings.Join(parts[1:], " ")
+	} else {
+		h.sendActivityHint(true)
+		h.client.screen.Message(tr("speak.prompt"))
+
+		line, err := h.ReadCommand()
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			h.sendActivityHint(false)
+			return nil
+		}
+		content = line
+	}
 
 	data := map[string]interface{}{
 		"content": content,
@@ -201,9 +708,110 @@ func (h *InputHandler) handleSpeak(parts []string) error {
 	return h.client.SendMessage(msg)
 }
 
-// handleQuit 处理退出命令
+// sendActivityHint 发送输入提示，开始输入的提示按 activityHintInterval 限流
+func (h *InputHandler) sendActivityHint(typing bool) {
+	if typing {
+		if time.Since(h.lastHint) < activityHintInterval {
+			return
+		}
+		h.lastHint = time.Now()
+	}
+
+	msg, err := protocol.NewActivityHintMessage(typing)
+	if err != nil {
+		return
+	}
+	h.client.SendMessage(msg)
+}
+
+// handleSkills 处理查询可用技能命令
+func (h *InputHandler) handleSkills() error {
+	msg, err := protocol.NewGetAllowedSkillsMessage()
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleSet 处理修改偏好命令
+func (h *InputHandler) handleSet(parts []string) error {
+	usage := errors.New(tr("usage.set"))
+	if len(parts) < 3 {
+		return usage
+	}
+
+	prefs := h.client.state.Preferences
+
+	parseSwitch := func(v string) (bool, error) {
+		switch strings.ToLower(v) {
+		case "on":
+			return true, nil
+		case "off":
+			return false, nil
+		default:
+			return false, usage
+		}
+	}
+
+	switch strings.ToLower(parts[1]) {
+	case "lang":
+		if !supportedLanguage(parts[2]) {
+			return errors.New(tr("err.unknown_lang", parts[2]))
+		}
+
+		// 立即切换界面语言，登录后同时保存到账号的偏好
+		setLanguage(parts[2])
+		h.client.langFixed = false
+		h.client.Render()
+		if h.client.state.PlayerID == "" {
+			return nil
+		}
+		prefs.Language = parts[2]
+	case "color":
+		prefs.SeatColor = strings.ToLower(parts[2])
+	case "tz":
+		if _, err := protocol.LoadLocation(parts[2]); err != nil {
+			return errors.New(tr("err.unknown_tz", parts[2]))
+		}
+		prefs.TimeZone = parts[2]
+	case "autoready":
+		on, err := parseSwitch(parts[2])
+		if err != nil {
+			return err
+		}
+		prefs.AutoReady = on
+	case "notify":
+		if len(parts) < 4 {
+			return usage
+		}
+		on, err := parseSwitch(parts[3])
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(parts[2]) {
+		case "gamestart":
+			prefs.Notifications.GameStart = on
+		case "turn":
+			prefs.Notifications.YourTurn = on
+		case "join":
+			prefs.Notifications.PlayerJoins = on
+		default:
+			return usage
+		}
+	default:
+		return usage
+	}
+
+	msg, err := protocol.NewSetPreferencesMessage(prefs)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleQuit 处理退出命令，由 Run 负责关闭客户端
 func (h *InputHandler) handleQuit() error {
-	h.client.ui.PrintMessage("再见！")
-	os.Exit(0)
-	return nil
+	return errQuit
 }
@@ -10,6 +10,11 @@ import (
 func main() {
 	// 解析命令行参数
 	addr := flag.String("addr", "127.0.0.1:8888", "server address")
+	plain := flag.Bool("plain", false, "use the line-based UI instead of the full-screen terminal UI")
+	script := flag.String("script", "", "read commands from a script file (- for stdin) instead of the keyboard")
+	lang := flag.String("lang", "", "UI language (zh-CN|en-US), overrides the saved preference")
+	tutorial := flag.Bool("tutorial", false, "play a guided game against bots on a local server started for the tutorial")
+	serverBin := flag.String("server-bin", "", "server binary for -tutorial (defaults to server next to the client, then PATH)")
 	flag.Parse()
 
 	// 创建日志
@@ -17,15 +22,64 @@ func main() {
 		Level: slog.LevelError, // 客户端只显示错误日志，避免干扰UI
 	}))
 
-	// 创建客户端
+	// 创建客户端，标准输入是终端时使用分栏界面，管道输入和脚本仍按行读取
 	client := NewClient(logger)
-	defer client.Close()
+	if *lang != "" {
+		if err := client.SetLanguage(*lang); err != nil {
+			log.Fatal(err)
+		}
+	}
+	switch {
+	case *script == "-":
+		client.UseScript(os.Stdin)
+	case *script != "":
+		f, err := os.Open(*script)
+		if err != nil {
+			log.Fatal(tr("err.open_script", err))
+		}
+		defer f.Close()
+		client.UseScript(f)
+	case !*plain && isTerminal(os.Stdin):
+		client.EnableTUI()
+	}
+
+	// 教学模式连接本机启动的服务器，由引导自动登录开房
+	var tutorialSrv *tutorialServer
+	if *tutorial {
+		srv, err := startTutorialServer(*serverBin)
+		if err != nil {
+			log.Fatal(tr("err.tutorial_server", err))
+		}
+		tutorialSrv = srv
+
+		*addr = srv.addr
+		client.UseTutorial()
+	}
+
+	// 连接服务器并运行客户端，退出码：0 正常退出，1 连接失败、连接断开或其他错误
+	os.Exit(run(client, *addr, logger, tutorialSrv))
+}
+
+// run 连接并运行客户端，返回退出码；os.Exit 不执行 defer，教学服务器在这里停止
+func run(client *Client, addr string, logger *slog.Logger, tutorialSrv *tutorialServer) int {
+	if tutorialSrv != nil {
+		defer tutorialSrv.Close()
+	}
 
-	// 连接服务器
-	if err := client.Connect(*addr); err != nil {
-		log.Fatalf("连接服务器失败: %v", err)
+	if err := client.Connect(addr); err != nil {
+		log.Print(tr("err.connect", err))
+		return 1
 	}
 
-	// 运行客户端
-	client.Run()
+	if err := client.Run(); err != nil {
+		logger.Error("client exited", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// isTerminal 文件是否是终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// MessageHandler 处理一条入站或出站消息
+type MessageHandler func(msg *protocol.Message) error
+
+// Middleware 包装消息处理，用于日志、统计或在测试中截获消息
+//
+// 中间件可以在调用 next 前后做处理，不调用 next 即丢弃该消息。
+type Middleware func(next MessageHandler) MessageHandler
+
+// Use 添加入站中间件，先添加的在外层，需在 Connect 之前调用
+func (c *Client) Use(middlewares ...Middleware) {
+	c.inboundMiddlewares = append(c.inboundMiddlewares, middlewares...)
+}
+
+// UseOutbound 添加出站中间件，先添加的在外层，需在 Connect 之前调用
+func (c *Client) UseOutbound(middlewares ...Middleware) {
+	c.outboundMiddlewares = append(c.outboundMiddlewares, middlewares...)
+}
+
+// chain 把中间件按添加顺序套在 handler 外面
+func chain(handler MessageHandler, middlewares []Middleware) MessageHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// LoggingMiddleware 记录每条消息的类型、序号和请求ID，处理出错时记录错误
+func LoggingMiddleware(logger *slog.Logger, event string) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(msg *protocol.Message) error {
+			logger.Info(event, "type", msg.Type, "seq", msg.Seq, "id", msg.ID, "correlationID", msg.CorrelationID)
+
+			err := next(msg)
+			if err != nil {
+				logger.Warn(event+" failed", "type", msg.Type, "error", err)
+			}
+			return err
+		}
+	}
+}
+
+// MessageStats 按消息类型统计消息数
+type MessageStats struct {
+	mu     sync.Mutex
+	counts map[protocol.MessageType]int
+}
+
+// Middleware 返回计数中间件
+func (s *MessageStats) Middleware() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(msg *protocol.Message) error {
+			s.mu.Lock()
+			if s.counts == nil {
+				s.counts = make(map[protocol.MessageType]int)
+			}
+			s.counts[msg.Type]++
+			s.mu.Unlock()
+
+			return next(msg)
+		}
+	}
+}
+
+// Counts 返回各类型消息数的副本
+func (s *MessageStats) Counts() map[protocol.MessageType]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[protocol.MessageType]int, len(s.counts))
+	for msgType, n := range s.counts {
+		counts[msgType] = n
+	}
+	return counts
+}
+
+// MessageRecorder 按顺序记录经过的消息，供测试断言
+type MessageRecorder struct {
+	mu       sync.Mutex
+	messages []*protocol.Message
+}
+
+// Middleware 返回记录中间件
+func (r *MessageRecorder) Middleware() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(msg *protocol.Message) error {
+			r.mu.Lock()
+			r.messages = append(r.messages, msg)
+			r.mu.Unlock()
+
+			return next(msg)
+		}
+	}
+}
+
+// Messages 返回已记录消息的副本
+func (r *MessageRecorder) Messages() []*protocol.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*protocol.Message(nil), r.messages...)
+}
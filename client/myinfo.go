@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// MyInfo 玩家自己掌握的私有信息，跨回合累积，与滚动的事件日志分开显示
+type MyInfo struct {
+	Nights []protocol.NightResultData // 每晚的行动结果，按回合顺序
+	Votes  []VoteRecord               // 被服务器接受的放逐投票
+}
+
+// VoteRecord 一次放逐投票
+type VoteRecord struct {
+	Round    int
+	TargetID string
+}
+
+// recordVote 记入一次被接受的投票，同一轮改票时替换之前的选择
+func (m *MyInfo) recordVote(vote VoteRecord) {
+	for i := range m.Votes {
+		if m.Votes[i].Round == vote.Round {
+			m.Votes[i] = vote
+			return
+		}
+	}
+	m.Votes = append(m.Votes, vote)
+}
+
+// voteTracker 记录已发出、尚未收到结果的投票，收到成功的 ACTION_RESULT 后记入投票历史
+type voteTracker struct {
+	mu      sync.Mutex
+	pending map[string]VoteRecord // 请求ID -> 投票
+}
+
+// track 登记一次投票，需在发送前调用以免结果先于登记到达
+func (t *voteTracker) track(requestID string, vote VoteRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == nil {
+		t.pending = make(map[string]VoteRecord)
+	}
+	t.pending[requestID] = vote
+}
+
+// take 取出请求对应的投票
+func (t *voteTracker) take(correlationID string) (VoteRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	vote, ok := t.pending[correlationID]
+	delete(t.pending, correlationID)
+	return vote, ok
+}
+
+// sendVote 发送投票并登记，结果成功后出现在我的信息中
+func (c *Client) sendVote(msg *protocol.Message, targetID string) error {
+	c.requests.assign(msg)
+	c.votes.track(msg.ID, VoteRecord{Round: c.state.Round, TargetID: targetID})
+
+	return c.SendMessage(msg)
+}
+
+// resetMyInfo 新的一局开始时清空上一局的信息，断线重连补发的开局消息不清空
+func (c *Client) resetMyInfo() {
+	if !c.state.IsInGame {
+		c.state.MyInfo = MyInfo{}
+	}
+}
+
+// myInfoLines 把累积的私有信息转成面板中的文字
+func (c *Client) myInfoLines() []string {
+	var lines []string
+
+	var potions *protocol.WitchPotionResult
+	for _, night := range c.state.MyInfo.Nights {
+		lines = append(lines, c.nightResultLines(night)...)
+		if night.Potions != nil {
+			potions = night.Potions
+		}
+	}
+	if potions != nil {
+		lines = append(lines, potionsLine(*potions))
+	}
+
+	for _, vote := range c.state.MyInfo.Votes {
+		lines = append(lines, tr("my_info.vote", vote.Round, c.playerName(vote.TargetID)))
+	}
+
+	return lines
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+)
+
+// handleNightResult 处理天亮时私下收到的前一晚行动结果
+func (c *Client) handleNightResult(data *protocol.NightResultData) error {
+	c.state.MyInfo.Nights = append(c.state.MyInfo.Nights, *data)
+
+	for _, line := range c.nightResultLines(*data) {
+		c.addEvent(line)
+	}
+	if data.Potions != nil {
+		c.addEvent(potionsLine(*data.Potions))
+	}
+	c.Render()
+
+	return nil
+}
+
+// nightResultLines 把行动结果转成可读的文字，每项一行，不含剩余药水
+func (c *Client) nightResultLines(data protocol.NightResultData) []string {
+	var lines []string
+
+	if data.Check != nil {
+		lines = append(lines, tr("night.check", data.Round, c.playerName(data.Check.TargetID), c.ui.campName(data.Check.Camp)))
+	}
+
+	if data.Potions != nil {
+		if data.Potions.SavedID != "" {
+			lines = append(lines, tr("night.saved", data.Round, c.playerName(data.Potions.SavedID)))
+		}
+		if data.Potions.PoisonedID != "" {
+			lines = append(lines, tr("night.poisoned", data.Round, c.playerName(data.Potions.PoisonedID)))
+		}
+	}
+
+	if data.Protect != "" {
+		lines = append(lines, tr("night.protected", data.Round, c.playerName(data.Protect)))
+	}
+
+	if data.Kill != nil {
+		outcome := tr("night.kill_ok")
+		if !data.Kill.Succeeded {
+			outcome = tr("night.kill_failed")
+		}
+		lines = append(lines, tr("night.kill", data.Round, c.playerName(data.Kill.TargetID), outcome))
+	}
+
+	return lines
+}
+
+// potionsLine 女巫剩余药水
+func potionsLine(potions protocol.WitchPotionResult) string {
+	return tr("night.potions", potionLeft(potions.AntidoteLeft), potionLeft(potions.PoisonLeft))
+}
+
+func potionLeft(left bool) string {
+	if left {
+		return tr("night.potion_left")
+	}
+	return tr("night.potion_used")
+}
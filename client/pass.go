@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// handlePass 放弃本阶段的行动，结果以 ACTION_RESULT 送达
+func (h *InputHandler) handlePass() error {
+	if h.client.state.RoomID == "" {
+		return errors.New(tr("err.not_in_room"))
+	}
+
+	msg, err := protocol.NewPassMessage()
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
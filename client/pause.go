@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// handlePause 对局中表态暂停，resume 为 true 时表态继续
+func (h *InputHandler) handlePause(resume bool) error {
+	if h.client.state.RoomID == "" {
+		return errors.New(tr("err.not_in_room"))
+	}
+
+	msg, err := protocol.NewRequestPauseMessage(resume)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handlePauseVote 处理暂停或继续的表态情况
+func (c *Client) handlePauseVote(data *protocol.PauseVoteData) error {
+	key := "event.pause_votes"
+	if data.Resume {
+		key = "event.resume_votes"
+	}
+	c.addEvent(tr(key, len(data.Agreed), data.Needed))
+	c.Render()
+
+	return nil
+}
+
+// handleGameResumed 处理暂停的对局继续，可用技能随后由服务器推送
+func (c *Client) handleGameResumed(msg *protocol.Message) error {
+	c.addEvent(tr("event.resumed"))
+	c.Render()
+
+	return nil
+}
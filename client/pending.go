@@ -0,0 +1,23 @@
+package main
+
+import "github.com/Zereker/game/protocol"
+
+// handleActionPending 处理服务器登记的选择，提示阶段结束前还可以改选
+func (c *Client) handleActionPending(data *protocol.ActionPendingData) error {
+	// 可改选动作的显示名称，见目录中的 pending.<动作>
+	verb := trOr("pending."+string(data.ActionType), string(data.ActionType))
+
+	target := c.playerName(data.TargetID)
+	if data.TargetSeat > 0 {
+		target = tr("seat_player", data.TargetSeat, target)
+	}
+
+	if data.PreviousTargetID != "" {
+		c.addEvent(tr("pending.changed", verb, target, c.playerName(data.PreviousTargetID)))
+	} else {
+		c.addEvent(tr("pending.recorded", verb, target))
+	}
+	c.Render()
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// TargetPicker 等待玩家选择的目标菜单，选项按编号从 1 开始
+type TargetPicker struct {
+	Title   string
+	Options []string
+}
+
+// targetCandidates 技能的可选目标：服务器给出的合法目标中存活的玩家，按座位排列
+//
+// 狼人刀人时菜单里不列出自己和同伴，确实要自刀或刀同伴时仍可以直接输入座位号。
+func (c *Client) targetCandidates(actionType werewolf.ActionType) ([]protocol.PlayerInfo, bool) {
+	var skill *protocol.SkillInfo
+	for i := range c.state.Skills {
+		if c.state.Skills[i].ActionType == actionType && c.state.Skills[i].NeedsTarget {
+			skill = &c.state.Skills[i]
+			break
+		}
+	}
+	if skill == nil {
+		return nil, false
+	}
+
+	var candidates []protocol.PlayerInfo
+	for _, p := range c.state.Players {
+		if !p.IsAlive || !slices.Contains(skill.Targets, p.ID) {
+			continue
+		}
+		if actionType == protocol.ActionKill && (p.ID == c.state.PlayerID || slices.Contains(c.state.Teammates, p.ID)) {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+
+	return candidates, true
+}
+
+// checkTarget 直接输入座位号时按服务器给出的合法目标检查，不合法的选择不发给服务器
+//
+// 还没收到这个技能（阶段刚切换、技能表尚未推送）时交给服务器判断。
+func (c *Client) checkTarget(actionType werewolf.ActionType, target protocol.PlayerInfo) error {
+	for _, skill := range c.state.Skills {
+		if skill.ActionType != actionType || !skill.NeedsTarget {
+			continue
+		}
+		if !slices.Contains(skill.Targets, target.ID) {
+			return errors.New(tr("picker.invalid_target", target.Seat, target.Username, actionType))
+		}
+		return nil
+	}
+	return nil
+}
+
+// pickTarget 技能命令没有带座位号时列出可选目标，读取下一行输入作为选择；返回 false 表示取消
+func (h *InputHandler) pickTarget(actionType string) (protocol.PlayerInfo, bool, error) {
+	candidates, ok := h.client.targetCandidates(werewolf.ActionType(actionType))
+	if !ok {
+		return protocol.PlayerInfo{}, false, errors.New(tr("picker.unavailable", actionType, actionType))
+	}
+	if len(candidates) == 0 {
+		return protocol.PlayerInfo{}, false, errors.New(tr("picker.no_targets", actionType))
+	}
+
+	options := make([]string, 0, len(candidates))
+	for _, p := range candidates {
+		options = append(options, seatPlayerName(p.Seat, p.Username))
+	}
+
+	h.client.state.Picker = &TargetPicker{
+		Title:   tr("picker.title", actionType),
+		Options: options,
+	}
+	h.client.Render()
+
+	line, err := h.ReadCommand()
+
+	h.client.state.Picker = nil
+	h.client.Render()
+
+	if err != nil {
+		return protocol.PlayerInfo{}, false, err
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		h.client.screen.Message(tr("cancelled"))
+		return protocol.PlayerInfo{}, false, nil
+	}
+
+	n, err := strconv.Atoi(line)
+	if err != nil || n < 1 || n > len(candidates) {
+		return protocol.PlayerInfo{}, false, errors.New(tr("picker.out_of_range", len(candidates)))
+	}
+
+	return candidates[n-1], true, nil
+}
+
+// pickerLines 行模式下目标菜单的文字
+func pickerLines(picker *TargetPicker) []string {
+	lines := make([]string, 0, len(picker.Options)+2)
+	lines = append(lines, picker.Title)
+	for i, option := range picker.Options {
+		lines = append(lines, fmt.Sprintf("  %d) %s", i+1, option))
+	}
+	lines = append(lines, tr("picker.footer"))
+	return lines
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+)
+
+// handlePresetList 处理角色预设列表
+func (c *Client) handlePresetList(data *protocol.PresetListData) error {
+	if len(data.Presets) == 0 {
+		c.addEvent(tr("presets.none"))
+		c.Render()
+		return nil
+	}
+
+	c.addEvent(tr("presets.header"))
+	for _, preset := range data.Presets {
+		c.addEvent(tr("presets.item", preset.Name, preset.Title, len(preset.Roles), preset.Description))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleListPresets 处理 presets 命令
+func (h *InputHandler) handleListPresets() error {
+	msg, err := protocol.NewListPresetsMessage()
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// handleProfile 查看或修改自己的玩家资料，不带值时清除该项
+//
+//	profile                 查看当前资料
+//	profile avatar [头像]   一个表情符号或头像编号
+//	profile color [颜色]    名字在其他人玩家列表中的颜色
+//	profile bio [简介]      一句话简介，可以带空格
+func (h *InputHandler) handleProfile(parts []string) error {
+	c := h.client
+	if c.state.PlayerID == "" {
+		return errors.New(tr("err.not_logged_in"))
+	}
+
+	profile := c.state.Profile
+	if len(parts) < 2 {
+		c.screen.Message(tr("profile.show", orNone(profile.Avatar), orNone(profile.Color), orNone(profile.Bio)))
+		return nil
+	}
+
+	value := strings.Join(parts[2:], " ")
+	switch strings.ToLower(parts[1]) {
+	case "avatar":
+		profile.Avatar = value
+	case "color":
+		profile.Color = strings.ToLower(value)
+	case "bio":
+		profile.Bio = value
+	default:
+		return errors.New(tr("usage.profile"))
+	}
+
+	msg, err := protocol.NewUpdateProfileMessage(c.state.Username, profile)
+	if err != nil {
+		return err
+	}
+
+	return c.SendMessage(msg)
+}
+
+// orNone 空值显示为“未设置”
+func orNone(value string) string {
+	if value == "" {
+		return tr("profile.none")
+	}
+	return value
+}
+
+// displayName 带头像和名字颜色的玩家名，用于玩家列表和复盘
+func (ui *UI) displayName(player protocol.PlayerInfo) string {
+	if player.Profile == nil {
+		return player.Username
+	}
+
+	name := player.Username
+	if player.Profile.Color != "" {
+		name = ui.colorCode(player.Profile.Color) + name + ColorReset
+	}
+	if player.Profile.Avatar != "" {
+		name = player.Profile.Avatar + " " + name
+	}
+	return name
+}
+
+// seatPlayerName 座位号加玩家名，匿名座位的对局中没有名字时只显示座位号
+func seatPlayerName(seat int, name string) string {
+	if name == "" {
+		return tr("seat", seat)
+	}
+	return tr("seat_player", seat, name)
+}
+
+// anonymousSeats 玩家列表是否来自匿名座位的对局，此时服务器不发送名字
+func anonymousSeats(players []protocol.PlayerInfo) bool {
+	for _, p := range players {
+		if p.Username == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// playerBio 玩家的简介，没有设置时为空
+func playerBio(player protocol.PlayerInfo) string {
+	if player.Profile == nil {
+		return ""
+	}
+	return player.Profile.Bio
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// revealInterval 结算界面逐个揭晓身份的间隔
+const revealInterval = 700 * time.Millisecond
+
+// handleGameSummary 处理对局结束后的复盘，保存下来在结算界面按座位逐个揭晓身份
+func (c *Client) handleGameSummary(data *protocol.GameSummaryData) error {
+	c.state.Summary = data
+	c.state.Revealed = 0
+	c.state.Players = data.Players
+	c.Render()
+
+	go c.revealSummary(data)
+
+	return nil
+}
+
+// revealSummary 每隔 revealInterval 多揭晓一名玩家，全部揭晓或复盘被替换、清空时停止
+func (c *Client) revealSummary(summary *protocol.GameSummaryData) {
+	ticker := time.NewTicker(revealInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.Lock()
+		if c.state.Summary != summary {
+			c.mu.Unlock()
+			return
+		}
+		c.state.Revealed++
+		finished := c.state.Revealed >= len(summary.Players)
+		c.Render()
+		c.mu.Unlock()
+
+		if finished {
+			return
+		}
+	}
+}
+
+// recapLines 把复盘整理成结算界面的文字：先逐个揭晓身份和结局，全部揭晓后再列出每晚行动、每轮投票和玩家表现
+func (c *Client) recapLines() []string {
+	summary := c.state.Summary
+	if summary == nil {
+		return nil
+	}
+
+	outcome := tr("recap.draw")
+	if summary.Winner != werewolf.CampNone {
+		outcome = tr("recap.won", c.ui.campName(summary.Winner))
+	}
+	lines := []string{tr("recap.rounds", summary.Rounds, outcome)}
+
+	fates := c.recapFates(summary)
+	for i, p := range summary.Players {
+		if i >= c.state.Revealed {
+			lines = append(lines, tr("recap.hidden", p.Seat, c.ui.displayName(p)))
+			continue
+		}
+		lines = append(lines, tr("recap.role", p.Seat, c.ui.displayName(p), c.ui.roleName(p.RoleType), fates[p.ID]))
+	}
+	if c.state.Revealed < len(summary.Players) {
+		return lines
+	}
+
+	for _, n := range summary.Nights {
+		var parts []string
+		if n.Killed != "" {
+			parts = append(parts, tr("recap.killed", c.playerName(n.Killed)))
+		}
+		if n.Protected != "" {
+			parts = append(parts, tr("recap.protected", c.playerName(n.Protected)))
+		}
+		if n.Saved != "" {
+			parts = append(parts, tr("recap.saved", c.playerName(n.Saved)))
+		}
+		if n.Poisoned != "" {
+			parts = append(parts, tr("recap.poisoned", c.playerName(n.Poisoned)))
+		}
+		if n.Checked != "" {
+			parts = append(parts, tr("recap.checked", c.playerName(n.Checked), c.ui.campName(n.CheckedCamp)))
+		}
+
+		deaths := tr("recap.peaceful")
+		if len(n.Deaths) > 0 {
+			names := make([]string, 0, len(n.Deaths))
+			for _, id := range n.Deaths {
+				names = append(names, c.playerName(id))
+			}
+			deaths = tr("recap.deaths", strings.Join(names, tr("name_sep")))
+		}
+		parts = append(parts, deaths)
+
+		lines = append(lines, tr("recap.night", n.Round, strings.Join(parts, tr("list_sep"))))
+	}
+
+	for _, v := range summary.Votes {
+		voters := make([]string, 0, len(v.Ballots))
+		for voter := range v.Ballots {
+			voters = append(voters, voter)
+		}
+		sort.Slice(voters, func(i, j int) bool { return c.seatOf(voters[i]) < c.seatOf(voters[j]) })
+
+		ballots := make([]string, 0, len(voters))
+		for _, voter := range voters {
+			ballots = append(ballots, fmt.Sprintf("%s→%s", c.playerName(voter), c.playerName(v.Ballots[voter])))
+		}
+
+		exiled := tr("recap.no_exile")
+		if v.Exiled != "" {
+			exiled = tr("recap.exiled", c.playerName(v.Exiled))
+		}
+		lines = append(lines, tr("recap.vote", v.Round, strings.Join(ballots, " "), exiled))
+	}
+
+	for _, s := range summary.Stats {
+		mark := ""
+		if s.PlayerID == summary.MVP {
+			mark = " MVP"
+		}
+		alive := tr("recap.out")
+		if s.Survived {
+			alive = tr("recap.alive")
+		}
+		lines = append(lines, tr("recap.score",
+			c.playerName(s.PlayerID), s.Score, s.AccurateVotes, s.NightHits, alive, mark))
+	}
+
+	if summary.MVP != "" {
+		lines = append(lines, tr("recap.mvp", c.playerName(summary.MVP)))
+	}
+
+	if len(summary.Ratings) > 0 {
+		changes := make([]string, 0, len(summary.Ratings))
+		for _, p := range summary.Players {
+			if delta, ok := summary.Ratings[p.ID]; ok {
+				changes = append(changes, fmt.Sprintf("%s %+d", p.Username, delta))
+			}
+		}
+		lines = append(lines, tr("recap.ratings", strings.Join(changes, tr("list_sep"))))
+	}
+
+	return lines
+}
+
+// recapFates 每名玩家的结局：哪一夜出局、哪一天被放逐或存活到最后，其他原因出局的只标出局
+func (c *Client) recapFates(summary *protocol.GameSummaryData) map[string]string {
+	fates := make(map[string]string, len(summary.Players))
+	for _, p := range summary.Players {
+		fates[p.ID] = tr("recap.out")
+	}
+	for _, s := range summary.Stats {
+		if s.Survived {
+			fates[s.PlayerID] = tr("recap.survived")
+		} else {
+			fates[s.PlayerID] = tr("recap.out")
+		}
+	}
+	for _, n := range summary.Nights {
+		for _, id := range n.Deaths {
+			fates[id] = tr("recap.at_night", n.Round)
+		}
+	}
+	for _, v := range summary.Votes {
+		if v.Exiled != "" {
+			fates[v.Exiled] = tr("recap.by_vote", v.Round)
+		}
+	}
+	return fates
+}
+
+// seatOf 玩家的座位号，找不到时返回 0
+func (c *Client) seatOf(playerID string) int {
+	for _, p := range c.state.Players {
+		if p.ID == playerID {
+			return p.Seat
+		}
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// handleRematch 对局结束后表态再来一局，rematch no 撤回同意
+func (h *InputHandler) handleRematch(parts []string) error {
+	if h.client.state.RoomID == "" {
+		return errors.New(tr("err.not_in_room"))
+	}
+
+	optIn := true
+	if len(parts) >= 2 {
+		switch strings.ToLower(parts[1]) {
+		case "yes":
+		case "no":
+			optIn = false
+		default:
+			return errors.New(tr("usage.rematch"))
+		}
+	}
+
+	msg, err := protocol.NewRematchMessage(optIn)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleRematchStatus 处理再来一局的表态情况，全员同意后回到房间大厅
+func (c *Client) handleRematchStatus(data *protocol.RematchStatusData) error {
+	if !data.Reset {
+		c.addEvent(tr("event.rematch_votes", len(data.OptedIn), data.Needed))
+		c.Render()
+		return nil
+	}
+
+	c.state.Players = data.Players
+	c.state.AlivePlayers = nil
+	c.state.GamePhase = ""
+	c.state.Round = 0
+	c.state.MyRole = ""
+	c.state.MyCamp = ""
+	c.state.Teammates = nil
+	c.state.Skills = nil
+	c.state.SheriffStage = ""
+	c.state.SheriffID = ""
+	c.state.CurrentSpeaker = ""
+	c.addEvent(tr("event.rematch_reset"))
+	c.Render()
+
+	return nil
+}
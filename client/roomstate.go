@@ -0,0 +1,20 @@
+package main
+
+import "github.com/Zereker/game/protocol"
+
+// handleRoomStateChanged 记下所在房间的状态，显示在标题栏；房间被移除时回到大厅
+func (c *Client) handleRoomStateChanged(data *protocol.RoomStateChangedData) error {
+	if data.RoomID != c.state.RoomID {
+		return nil
+	}
+
+	if data.State == "ARCHIVED" {
+		c.leaveRoom()
+		c.addEvent(tr("event.room_archived", data.RoomID))
+	} else {
+		c.state.RoomState = data.State
+	}
+	c.Render()
+
+	return nil
+}
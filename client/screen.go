@@ -0,0 +1,188 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// viewEventLimit 每次渲染带上的最近事件条数，界面只显示其中能放下的部分
+const viewEventLimit = 200
+
+// Screen 客户端的显示方式：整屏重绘的行模式或分栏的终端界面（见 tui.go）
+type Screen interface {
+	// Start 开始接收输入，输入交给 InputHandler
+	Start()
+	// Stop 结束显示，之后可以直接向终端打印
+	Stop()
+	// Render 按状态快照刷新界面
+	Render(view View)
+	// Prompt 等待输入前调用
+	Prompt(view View)
+	// Message 显示一条提示
+	Message(msg string)
+	// Error 显示一条错误
+	Error(msg string)
+	// ShowHelp 显示帮助，返回 true 表示要等玩家按回车才回到主界面
+	ShowHelp() bool
+}
+
+// View 一次渲染所需的客户端状态快照
+//
+// 终端界面在自己的协程中渲染，不能直接读 ClientState，所以切片都是复制出来的。
+type View struct {
+	RoomID    string
+	RoomState string
+	Round     int
+	Phase     werewolf.PhaseType
+	RTT       time.Duration
+	Countdown int // 开局倒计时秒数，0 表示没有倒计时
+	Idle      int // 房间移出未准备玩家的倒计时秒数，0 表示没有计时
+
+	MyID      string
+	SeatColor string
+	Players   []protocol.PlayerInfo
+	Events    []string
+	Chat      []string
+	Typing    int
+
+	InGame     bool
+	Role       werewolf.RoleType
+	Camp       werewolf.Camp
+	MyInfo     []string
+	Rules      *protocol.GameRules
+	GuardRules *protocol.GuardRules
+	Skills     []protocol.SkillInfo
+	SkillTime  int // 当前这一步剩余的秒数，0 表示不限时
+	Recap      []string
+
+	Picker *TargetPicker // 正在选择目标时不为空，菜单内容不会再被修改
+}
+
+// view 生成当前状态的快照
+func (c *Client) view() View {
+	view := View{
+		RoomID:    c.state.RoomID,
+		RoomState: c.state.RoomState,
+		Round:     c.state.Round,
+		Phase:     c.state.GamePhase,
+		RTT:       c.state.RTT,
+		Countdown: c.startCountdown(),
+		Idle:      c.readyCountdown(),
+
+		MyID:      c.state.PlayerID,
+		SeatColor: c.state.Preferences.SeatColor,
+		Players:   append([]protocol.PlayerInfo(nil), c.state.Players...),
+		Events:    tail(c.state.Events, viewEventLimit),
+		Chat:      tail(c.state.Chat, viewEventLimit),
+		Typing:    c.state.Typing,
+
+		InGame: c.state.IsInGame,
+		Role:   c.state.MyRole,
+		Camp:   c.state.MyCamp,
+		Skills: append([]protocol.SkillInfo(nil), c.state.Skills...),
+		Picker: c.state.Picker,
+
+		SkillTime: c.skillCountdown(),
+	}
+
+	if view.InGame {
+		view.MyInfo = c.myInfoLines()
+		view.Rules = c.state.Rules
+		view.GuardRules = c.state.GuardRules
+	} else {
+		view.Recap = c.recapLines()
+	}
+
+	return view
+}
+
+// tail 复制最后 n 条
+func tail(lines []string, n int) []string {
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return append([]string(nil), lines...)
+}
+
+// lineScreen 行模式：每次刷新清屏后重新打印，从 InputHandler 的输入源逐行读取命令
+//
+// 标准输入不是终端（管道、脚本）或指定 -plain 时使用。
+type lineScreen struct {
+	ui    *UI
+	input *InputHandler
+}
+
+// Start 实现 Screen
+func (s *lineScreen) Start() {
+	s.input.start()
+}
+
+// Stop 实现 Screen
+func (s *lineScreen) Stop() {}
+
+// Render 实现 Screen
+func (s *lineScreen) Render(view View) {
+	ui := s.ui
+	ui.Clear()
+
+	// 打印标题
+	ui.PrintHeader(view.RoomID, view.RoomState, view.Round, view.Phase, view.RTT, view.Countdown, view.Idle)
+
+	// 如果在游戏中，显示玩家列表
+	if len(view.Players) > 0 {
+		ui.PrintPlayers(view.Players, view.MyID, view.SeatColor)
+	}
+
+	// 显示事件日志和发言
+	ui.PrintEvents(view.Events)
+	ui.PrintChat(view.Chat)
+
+	if view.Typing > 0 && view.Phase == werewolf.PhaseDay {
+		ui.PrintMessage(tr("typing", view.Typing))
+	}
+
+	// 如果在游戏中，显示角色信息
+	if view.InGame {
+		ui.PrintRoleInfo(view.Role, view.Camp)
+		ui.PrintMyInfo(view.MyInfo)
+		if view.Rules != nil {
+			ui.PrintGameRules(*view.Rules)
+		}
+		if view.GuardRules != nil {
+			ui.PrintGuardRules(*view.GuardRules)
+		}
+		ui.PrintAllowedSkills(view.Skills)
+	} else {
+		ui.PrintRecap(view.Recap)
+	}
+
+	// 正在选择目标时把菜单放在最后，紧挨着输入
+	if view.Picker != nil {
+		for _, line := range pickerLines(view.Picker) {
+			ui.PrintMessage(line)
+		}
+	}
+}
+
+// Prompt 实现 Screen
+func (s *lineScreen) Prompt(view View) {
+	s.ui.PrintPrompt(view.Phase, view.Skills, view.SkillTime)
+}
+
+// Message 实现 Screen
+func (s *lineScreen) Message(msg string) {
+	s.ui.PrintMessage(msg)
+}
+
+// Error 实现 Screen
+func (s *lineScreen) Error(msg string) {
+	s.ui.PrintError(msg)
+}
+
+// ShowHelp 实现 Screen
+func (s *lineScreen) ShowHelp() bool {
+	s.ui.PrintHelp()
+	return true
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// defaultScriptWait wait 指令没有写超时时的等待时间
+const defaultScriptWait = 30 * time.Second
+
+// scriptRunner 按脚本驱动客户端，用于自动化场景测试和演示录制
+//
+// 脚本每行一条命令，与手动输入相同；另有两条指令：
+//
+//	wait <消息类型> [超时]  等待收到下一条该类型的消息，如 wait GAME_STARTED 10s，超时默认 30 秒
+//	sleep <时长>           暂停一段时间，如 sleep 500ms
+//
+// 空行和 # 开头的行被忽略。wait 从上一次 wait 等到的消息之后找起，
+// 所以命令发出后才开始 wait 也不会漏掉已经到达的回复。等待超时时客户端以错误退出。
+type scriptRunner struct {
+	client *Client
+	script io.Reader
+	out    *io.PipeWriter // 命令写入管道，由 InputHandler 逐行读取
+
+	mu       sync.Mutex
+	received []protocol.MessageType // 上一次 wait 等到的消息之后收到的消息类型
+	arrived  chan struct{}          // 收到新消息时关闭并换新
+}
+
+// UseScript 改为从脚本读取命令，需在 Connect 之前调用
+func (c *Client) UseScript(script io.Reader) {
+	pr, pw := io.Pipe()
+
+	runner := &scriptRunner{
+		client:  c,
+		script:  script,
+		out:     pw,
+		arrived: make(chan struct{}),
+	}
+
+	c.input.reader = pr
+	c.Use(runner.middleware())
+
+	go runner.run()
+}
+
+// middleware 记录收到的消息类型，供 wait 指令匹配
+func (s *scriptRunner) middleware() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(msg *protocol.Message) error {
+			s.mu.Lock()
+			s.received = append(s.received, msg.Type)
+			close(s.arrived)
+			s.arrived = make(chan struct{})
+			s.mu.Unlock()
+
+			return next(msg)
+		}
+	}
+}
+
+// run 逐行执行脚本，脚本结束时输入随之结束，出错时把错误交给输入处理器
+func (s *scriptRunner) run() {
+	scanner := bufio.NewScanner(s.script)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+
+		if err := s.exec(strings.TrimSpace(scanner.Text())); err != nil {
+			s.out.CloseWithError(errors.Wrap(err, tr("script.line", lineNo)))
+			return
+		}
+	}
+
+	s.out.CloseWithError(scanner.Err())
+}
+
+// exec 执行一行脚本：指令自己处理，其余的作为命令交给主循环
+func (s *scriptRunner) exec(line string) error {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	fields := strings.Fields(line)
+
+	switch strings.ToLower(fields[0]) {
+	case "wait":
+		if len(fields) < 2 || len(fields) > 3 {
+			return errors.New(tr("usage.wait"))
+		}
+
+		timeout := defaultScriptWait
+		if len(fields) == 3 {
+			d, err := time.ParseDuration(fields[2])
+			if err != nil {
+				return errors.New(tr("script.bad_timeout", fields[2]))
+			}
+			timeout = d
+		}
+
+		return s.wait(protocol.MessageType(strings.ToUpper(fields[1])), timeout)
+	case "sleep":
+		if len(fields) != 2 {
+			return errors.New(tr("usage.sleep"))
+		}
+
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return errors.New(tr("script.bad_duration", fields[1]))
+		}
+
+		select {
+		case <-time.After(d):
+			return nil
+		case <-s.client.done:
+			return ErrClientClosed
+		}
+	}
+
+	// 输入处理器已关闭（客户端退出）时写入失败，脚本随之结束
+	_, err := fmt.Fprintln(s.out, line)
+	return err
+}
+
+// wait 等待收到下一条指定类型的消息
+func (s *scriptRunner) wait(msgType protocol.MessageType, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		for i, received := range s.received {
+			if received == msgType {
+				s.received = s.received[i+1:]
+				s.mu.Unlock()
+				return nil
+			}
+		}
+		arrived := s.arrived
+		s.mu.Unlock()
+
+		select {
+		case <-arrived:
+		case <-deadline.C:
+			return errors.New(tr("script.wait_timeout", msgType, timeout))
+		case <-s.client.done:
+			return ErrClientClosed
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// seqTracker 跟踪服务器消息序号，发现漏收和重复
+type seqTracker struct {
+	last    uint64
+	missing map[uint64]bool // 已请求重发、尚未收到的序号
+}
+
+// accept 判断消息是否需要处理；发现跳号时返回需要重发的区间
+func (t *seqTracker) accept(seq uint64) (process bool, gapFrom, gapTo uint64) {
+	// 没有序号的消息（旧服务器或登录前的错误）总是处理
+	if seq == 0 {
+		return true, 0, 0
+	}
+
+	if seq <= t.last {
+		if t.missing[seq] {
+			delete(t.missing, seq)
+			return true, 0, 0
+		}
+		return false, 0, 0 // 重复消息
+	}
+
+	if seq > t.last+1 {
+		if t.missing == nil {
+			t.missing = make(map[uint64]bool)
+		}
+		for s := t.last + 1; s < seq; s++ {
+			t.missing[s] = true
+		}
+		gapFrom, gapTo = t.last+1, seq-1
+	}
+
+	t.last = seq
+	return true, gapFrom, gapTo
+}
+
+// requestTracker 为请求分配ID，并记录尚未收到响应的请求
+type requestTracker struct {
+	mu      sync.Mutex
+	next    uint64
+	pending map[string]protocol.MessageType
+}
+
+// assign 为请求分配ID并登记
+func (t *requestTracker) assign(msg *protocol.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if msg.ID == "" {
+		t.next++
+		msg.ID = fmt.Sprintf("c%d", t.next)
+	}
+
+	if t.pending == nil {
+		t.pending = make(map[string]protocol.MessageType)
+	}
+	t.pending[msg.ID] = msg.Type
+}
+
+// lookup 查询响应对应的请求类型
+func (t *requestTracker) lookup(correlationID string) (protocol.MessageType, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msgType, ok := t.pending[correlationID]
+	return msgType, ok
+}
+
+// done 收到响应后移除请求
+func (t *requestTracker) done(correlationID string) {
+	if correlationID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.pending, correlationID)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// handleSheriffState 处理警长竞选进度和警徽变化
+func (c *Client) handleSheriffState(data *protocol.SheriffStateData) error {
+	c.state.SheriffStage = data.Stage
+	c.state.SheriffID = data.SheriffID
+
+	if data.Message != "" {
+		c.addEvent(tr("sheriff.notice", data.Message))
+	}
+	if len(data.Tally) > 0 {
+		parts := make([]string, 0, len(data.Tally))
+		for _, id := range data.Candidates {
+			parts = append(parts, tr("sheriff.votes", c.playerName(id), strconv.FormatFloat(data.Tally[id], 'g', -1, 64)))
+		}
+		c.addEvent(tr("sheriff.tally", strings.Join(parts, tr("list_sep"))))
+	}
+
+	// 轮到自己竞选发言，或自己作为出局的警长需要移交警徽
+	yourTurn := (data.Stage == protocol.SheriffStageSpeech && data.Speaker == c.state.PlayerID) ||
+		(data.Stage == protocol.SheriffStageBadge && data.SheriffID == c.state.PlayerID)
+	if yourTurn && c.state.Preferences.Notifications.YourTurn {
+		c.ui.Bell()
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleSheriffSpeech 处理候选人的竞选发言
+func (c *Client) handleSheriffSpeech(data *protocol.SheriffSpeechData) error {
+	c.addChat(tr("sheriff.speech", c.playerName(data.PlayerID), data.Content))
+	c.Render()
+
+	return nil
+}
+
+// handleSheriff 处理警长相关命令
+func (h *InputHandler) handleSheriff(parts []string) error {
+	if len(parts) < 2 {
+		return errors.New(tr("usage.sheriff"))
+	}
+
+	var msg *protocol.Message
+	var err error
+
+	switch strings.ToLower(parts[1]) {
+	case "run":
+		msg, err = protocol.NewSheriffRunMessage(true)
+	case "quit":
+		msg, err = protocol.NewSheriffRunMessage(false)
+	case "say":
+		if len(parts) < 3 {
+			return errors.New(tr("usage.sheriff_say"))
+		}
+		msg, err = protocol.NewSheriffSpeechMessage(strings.Join(parts[2:], " "))
+	case "vote":
+		if len(parts) < 3 {
+			return errors.New(tr("usage.sheriff_vote"))
+		}
+		target, seatErr := h.playerBySeat(parts[2])
+		if seatErr != nil {
+			return seatErr
+		}
+		msg, err = protocol.NewSheriffVoteMessage(target.Seat)
+	case "pass":
+		// 不带座位号时撕毁警徽
+		seat := 0
+		if len(parts) >= 3 {
+			target, seatErr := h.playerBySeat(parts[2])
+			if seatErr != nil {
+				return seatErr
+			}
+			seat = target.Seat
+		}
+		msg, err = protocol.NewSheriffPassMessage(seat)
+	case "order":
+		if len(parts) < 3 || (parts[2] != "cw" && parts[2] != "ccw") {
+			return errors.New(tr("usage.sheriff_order"))
+		}
+		msg, err = protocol.NewSpeakingOrderMessage(parts[2] == "cw")
+	default:
+		return errors.New(tr("usage.sheriff"))
+	}
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
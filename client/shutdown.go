@@ -0,0 +1,103 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// flushTimeout 关闭时等待发送队列写完的最长时间
+const flushTimeout = 2 * time.Second
+
+var (
+	// ErrClientClosed 客户端已关闭
+	ErrClientClosed = errors.New("client closed")
+	// ErrConnectionLost 与服务器的连接意外断开
+	ErrConnectionLost = errors.New("connection lost")
+
+	// errQuit 用户输入了退出命令
+	errQuit = errors.New("quit")
+)
+
+// enqueue 把消息放入发送队列，客户端关闭后返回 ErrClientClosed
+func (c *Client) enqueue(msg *protocol.Message) error {
+	select {
+	case <-c.done:
+		return ErrClientClosed
+	default:
+	}
+
+	select {
+	case c.outgoing <- msg:
+		return nil
+	case <-c.done:
+		return ErrClientClosed
+	}
+}
+
+// writeLoop 按顺序写出发送队列；关闭后把已入队的消息写完再退出
+func (c *Client) writeLoop() {
+	defer close(c.flushed)
+
+	for {
+		select {
+		case msg := <-c.outgoing:
+			c.write(msg)
+		case <-c.done:
+			for {
+				select {
+				case msg := <-c.outgoing:
+					c.write(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *Client) write(msg *protocol.Message) {
+	if err := c.conn.WriteDirect(msg); err != nil {
+		c.logger.Warn("send message failed", "type", msg.Type, "error", err)
+	}
+}
+
+// shutdown 标记客户端进入关闭流程，只有第一次调用的原因会被记录
+func (c *Client) shutdown(reason error) {
+	c.closeOnce.Do(func() {
+		c.closeErr = reason
+		close(c.done)
+	})
+}
+
+// Err 返回客户端关闭的原因，主动关闭或尚未关闭时为 nil
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.closeErr
+	default:
+		return nil
+	}
+}
+
+// Close 关闭客户端：停止接受新消息，写完发送队列，关闭输入和连接
+//
+// 可重复调用，也可在 Run 之外调用，Run 会随之返回。
+func (c *Client) Close() {
+	c.shutdown(nil)
+
+	if c.conn != nil {
+		select {
+		case <-c.flushed:
+		case <-time.After(flushTimeout):
+			c.logger.Warn("timed out flushing pending messages")
+		}
+	}
+
+	c.input.Close()
+	c.cancel()
+	if c.tcpConn != nil {
+		c.tcpConn.Close()
+	}
+}
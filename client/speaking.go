@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// handleSpeakingOrder 处理白天的发言顺序
+func (c *Client) handleSpeakingOrder(data *protocol.SpeakingOrderData) error {
+	c.state.CurrentSpeaker = data.Current
+
+	if data.Current == "" {
+		c.addEvent(tr("speaking.done"))
+		c.Render()
+		return nil
+	}
+
+	if len(data.Order) > 0 && data.Current == data.Order[0] {
+		names := make([]string, 0, len(data.Order))
+		for _, id := range data.Order {
+			names = append(names, c.playerName(id))
+		}
+		c.addEvent(tr("speaking.order", strings.Join(names, " → ")))
+	}
+
+	// 轮到自己时另有 YOUR_TURN_TO_SPEAK 提醒
+	if data.Current != c.state.PlayerID {
+		c.addEvent(tr("speaking.next", c.playerName(data.Current)))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleYourTurnToSpeak 处理轮到自己发言的提醒
+func (c *Client) handleYourTurnToSpeak(data *protocol.YourTurnToSpeakData) error {
+	if data.TimeoutSeconds > 0 {
+		c.addEvent(tr("speaking.timed", data.TimeoutSeconds))
+	} else {
+		c.addEvent(tr("speaking.your_turn"))
+	}
+	if c.state.Preferences.Notifications.YourTurn {
+		c.ui.Bell()
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleWhisper 处理私聊：别人发来的，或服务器回给自己的发送确认
+func (c *Client) handleWhisper(data *protocol.WhisperData) error {
+	if data.FromID == c.state.PlayerID {
+		c.addChat(tr("whisper.to", c.playerName(data.PlayerID), data.Content))
+	} else {
+		c.addChat(tr("whisper.from", c.playerName(data.FromID), data.Content))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleSpeech 处理其他玩家（以及自己）的发言
+func (c *Client) handleSpeech(data *protocol.SpeechData) error {
+	c.addChat(c.playerName(data.PlayerID) + ": " + data.Content)
+	c.Render()
+
+	return nil
+}
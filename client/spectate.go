@@ -0,0 +1,88 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// handleWatch 请求观战一个房间，有密码的房间需要带上密码；房间要求房主同意时等房主答复
+func (h *InputHandler) handleWatch(parts []string) error {
+	if len(parts) < 2 {
+		return errors.New(tr("usage.watch"))
+	}
+
+	password := ""
+	if len(parts) >= 3 {
+		password = parts[2]
+	}
+
+	msg, err := protocol.NewSpectateMessage(parts[1], password)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleSpectateDecision 房主按用户名同意或拒绝观战请求
+func (h *InputHandler) handleSpectateDecision(parts []string, approve bool) error {
+	if len(parts) < 2 {
+		return errors.New(tr("usage.spectator", parts[0]))
+	}
+
+	c := h.client
+	c.mu.Lock()
+	playerID, ok := c.state.SpectateRequests[parts[1]]
+	if ok {
+		delete(c.state.SpectateRequests, parts[1])
+	}
+	c.mu.Unlock()
+	if !ok {
+		return errors.New(tr("err.no_request", parts[1]))
+	}
+
+	msg, err := protocol.NewSpectateApproveMessage(playerID, approve)
+	if err != nil {
+		return err
+	}
+
+	return c.SendMessage(msg)
+}
+
+// handleSpectateRequest 房主收到观战请求，记下来等房主用 allow 或 deny 答复
+func (c *Client) handleSpectateRequest(data *protocol.SpectateRequestData) error {
+	if c.state.SpectateRequests == nil {
+		c.state.SpectateRequests = make(map[string]string)
+	}
+	c.state.SpectateRequests[data.Username] = data.PlayerID
+
+	c.addEvent(tr("event.spectate_request", data.Username))
+	if c.state.Preferences.Notifications.PlayerJoins {
+		c.ui.Bell()
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleSpectateResult 处理观战请求的结果，开始观战时按快照显示房间
+func (c *Client) handleSpectateResult(data *protocol.SpectateResultData) error {
+	switch data.Status {
+	case protocol.SpectatePending:
+		c.addEvent(tr("event.spectate_pending", data.RoomID))
+	case protocol.SpectateDenied:
+		c.addEvent(tr("event.spectate_denied", data.RoomID))
+	case protocol.SpectateWatching:
+		c.leaveRoom()
+		c.state.RoomID = data.RoomID
+		c.state.Players = data.Players
+		c.state.GamePhase = data.Phase
+		c.state.Round = data.Round
+		c.state.AlivePlayers = data.AlivePlayers
+		c.state.Spectating = true
+		c.addEvent(tr("event.spectate_started", data.Name, data.RoomID))
+	}
+	c.Render()
+
+	return nil
+}
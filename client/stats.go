@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// leaderboardSize 排行榜条数，加上标题正好占满事件日志显示的最近10条
+const leaderboardSize = 9
+
+// handleStats 处理战绩查询结果
+func (c *Client) handleStats(data *protocol.StatsData) error {
+	s := data.Stats
+	if s.GamesPlayed == 0 {
+		c.addEvent(tr("stats.none", s.Username))
+		c.Render()
+		return nil
+	}
+
+	c.addEvent(tr("stats.summary",
+		s.Username, s.GamesPlayed, s.Wins, percent(s.Wins, s.GamesPlayed), percent(s.Survived, s.GamesPlayed)))
+	c.addEvent(tr("stats.camps",
+		s.WinsByCamp[werewolf.CampGood], s.WinsByCamp[werewolf.CampEvil]))
+	if s.RatedGames > 0 {
+		c.addEvent(tr("stats.rating", s.Rating, s.RatedGames))
+	}
+
+	roles := make([]werewolf.RoleType, 0, len(s.Roles))
+	for role := range s.Roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return s.Roles[roles[i]] > s.Roles[roles[j]] })
+
+	parts := make([]string, 0, len(roles))
+	for _, role := range roles {
+		parts = append(parts, fmt.Sprintf("%s %d", c.ui.roleName(role), s.Roles[role]))
+	}
+	c.addEvent(tr("stats.roles", strings.Join(parts, tr("list_sep"))))
+	c.Render()
+
+	return nil
+}
+
+// handleLeaderboard 处理排行榜查询结果
+func (c *Client) handleLeaderboard(data *protocol.LeaderboardData) error {
+	if len(data.Entries) == 0 {
+		c.addEvent(tr("leaderboard.empty"))
+		c.Render()
+		return nil
+	}
+
+	if data.By == protocol.LeaderboardByRating {
+		c.addEvent(tr("leaderboard.rating"))
+		for i, s := range data.Entries {
+			c.addEvent(tr("leaderboard.rated", i+1, s.Username, s.Rating, s.RatedGames))
+		}
+		c.Render()
+		return nil
+	}
+
+	c.addEvent(tr("leaderboard.wins"))
+	for i, s := range data.Entries {
+		c.addEvent(tr("leaderboard.winner",
+			i+1, s.Username, s.Wins, s.GamesPlayed, percent(s.Wins, s.GamesPlayed)))
+	}
+	c.Render()
+
+	return nil
+}
+
+// percent 把比例格式化为百分数，分母为 0 时显示 -
+func percent(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)*100/float64(total))
+}
+
+// handleStatsCommand 处理 stats 命令，不带用户名时查询自己
+func (h *InputHandler) handleStatsCommand(parts []string) error {
+	username := ""
+	if len(parts) >= 2 {
+		username = parts[1]
+	}
+
+	msg, err := protocol.NewGetStatsMessage(username)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
+
+// handleLeaderboardCommand 处理 leaderboard 命令，带 rating 时按排位积分排列
+func (h *InputHandler) handleLeaderboardCommand(parts []string) error {
+	by := protocol.LeaderboardByWins
+	if len(parts) >= 2 && parts[1] == "rating" {
+		by = protocol.LeaderboardByRating
+	}
+
+	msg, err := protocol.NewGetLeaderboardMessage(by, leaderboardSize)
+	if err != nil {
+		return err
+	}
+
+	return h.client.SendMessage(msg)
+}
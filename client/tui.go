@@ -0,0 +1,496 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// 终端界面各栏的尺寸
+const (
+	tuiMaxLeftWidth = 40 // 玩家栏和信息栏的最大宽度
+	tuiFooterHeight = 3  // 提示行、操作说明和输入行
+)
+
+// 终端界面的样式
+var (
+	tuiHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")).Background(lipgloss.Color("4")).Padding(0, 1)
+	tuiBoxStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8"))
+	tuiTitleStyle  = lipgloss.NewStyle().Bold(true)
+	tuiCursorStyle = lipgloss.NewStyle().Reverse(true)
+	tuiNoticeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
+	tuiErrorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	tuiHintStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+)
+
+// tuiScreen 分栏的终端界面
+//
+// 玩家、信息、事件、发言和输入各占一栏。消息到达时只重绘各栏，输入行保留正在输入的内容；
+// 方向键在玩家栏中选择玩家，Tab 把选中玩家的座位号填入命令。选择技能目标时信息栏换成目标菜单，
+// 方向键改为在菜单中移动。
+type tuiScreen struct {
+	input   *InputHandler
+	program *tea.Program
+	done    chan struct{} // 界面退出、终端恢复后关闭
+}
+
+// EnableTUI 改用分栏的终端界面，需在 Run 之前调用
+func (c *Client) EnableTUI() {
+	c.screen = &tuiScreen{
+		input:   c.input,
+		program: tea.NewProgram(newTUIModel(c.ui, c.input.submit), tea.WithAltScreen()),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start 实现 Screen，界面退出（Ctrl+C）等同于输入结束
+func (s *tuiScreen) Start() {
+	go func() {
+		defer close(s.done)
+		defer s.input.end()
+
+		s.program.Run()
+	}()
+}
+
+// Stop 实现 Screen，等终端恢复后再返回
+func (s *tuiScreen) Stop() {
+	s.program.Quit()
+	<-s.done
+}
+
+// Render 实现 Screen
+func (s *tuiScreen) Render(view View) {
+	s.program.Send(view)
+}
+
+// Prompt 实现 Screen，输入行一直显示，不需要单独提示
+func (s *tuiScreen) Prompt(View) {}
+
+// Message 实现 Screen
+func (s *tuiScreen) Message(msg string) {
+	s.program.Send(tuiNotice{text: msg})
+}
+
+// Error 实现 Screen
+func (s *tuiScreen) Error(msg string) {
+	s.program.Send(tuiNotice{text: tr("error", msg), err: true})
+}
+
+// ShowHelp 实现 Screen，帮助覆盖在主界面上，按 Esc 或回车关闭
+func (s *tuiScreen) ShowHelp() bool {
+	s.program.Send(tuiHelp{})
+	return false
+}
+
+// tuiNotice 显示在输入行上方的提示
+type tuiNotice struct {
+	text string
+	err  bool
+}
+
+// tuiHelp 打开帮助
+type tuiHelp struct{}
+
+// tuiModel 终端界面的状态，只在界面协程中读写
+type tuiModel struct {
+	ui     *UI
+	submit func(string) bool // 把输入行交给主循环
+
+	input  textinput.Model
+	view   View
+	notice tuiNotice
+	help   bool
+
+	width, height int
+	scroll        int // 事件栏向上翻过的行数，0 表示跟随最新事件
+	cursor        int // 玩家栏中选中的玩家下标，-1 表示没有选中
+	pick          int // 目标菜单中选中的选项下标
+}
+
+// newTUIModel 创建终端界面
+func newTUIModel(ui *UI, submit func(string) bool) tuiModel {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	input.Placeholder = tr("tui.placeholder")
+	input.Focus()
+
+	return tuiModel{
+		ui:     ui,
+		submit: submit,
+		input:  input,
+		cursor: -1,
+	}
+}
+
+// Init 实现 tea.Model
+func (m tuiModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update 实现 tea.Model
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = msg.Width - len(m.input.Prompt) - 1
+		return m, nil
+	case View:
+		if msg.Picker != m.view.Picker {
+			m.pick = 0
+		}
+		m.view = msg
+		if m.cursor >= len(msg.Players) {
+			m.cursor = len(msg.Players) - 1
+		}
+		return m, nil
+	case tuiNotice:
+		m.notice = msg
+		return m, nil
+	case tuiHelp:
+		m.help = true
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// handleKey 处理按键，界面自己的快捷键之外的按键交给输入行
+func (m tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+
+	if m.help {
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyEnter {
+			m.help = false
+		}
+		return m, nil
+	}
+
+	if m.view.Picker != nil {
+		if model, cmd, ok := m.handlePickerKey(msg); ok {
+			return model, cmd
+		}
+	}
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		line := m.input.Value()
+		m.input.Reset()
+		m.notice = tuiNotice{}
+
+		return m, m.submitLine(line)
+	case tea.KeyUp:
+		m.moveCursor(-1)
+		return m, nil
+	case tea.KeyDown:
+		m.moveCursor(1)
+		return m, nil
+	case tea.KeyTab:
+		m.insertSelectedSeat()
+		return m, nil
+	case tea.KeyEsc:
+		m.cursor = -1
+		return m, nil
+	case tea.KeyPgUp:
+		m.scroll = min(m.scroll+m.eventsPage(), max(len(m.view.Events)-m.eventsPage(), 0))
+		return m, nil
+	case tea.KeyPgDown:
+		m.scroll = max(m.scroll-m.eventsPage(), 0)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// handlePickerKey 目标菜单打开时的按键：方向键移动，回车提交选中项的编号，Esc 提交空行取消；
+// 输入行中已经输入了编号时回车按输入的编号提交。返回 false 表示按键交给输入行
+func (m tuiModel) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	n := len(m.view.Picker.Options)
+
+	switch msg.Type {
+	case tea.KeyUp:
+		m.pick = (m.pick - 1 + n) % n
+	case tea.KeyDown:
+		m.pick = (m.pick + 1) % n
+	case tea.KeyEnter:
+		line := strings.TrimSpace(m.input.Value())
+		if line == "" {
+			line = strconv.Itoa(m.pick + 1)
+		}
+		m.input.Reset()
+		m.notice = tuiNotice{}
+		return m, m.submitLine(line), true
+	case tea.KeyEsc:
+		m.input.Reset()
+		m.notice = tuiNotice{}
+		return m, m.submitLine(""), true
+	default:
+		return m, nil, false
+	}
+
+	return m, nil, true
+}
+
+// submitLine 把输入行交给主循环；主循环处理命令时可能要刷新界面，所以在界面协程之外提交
+func (m tuiModel) submitLine(line string) tea.Cmd {
+	submit := m.submit
+	return func() tea.Msg {
+		submit(line)
+		return nil
+	}
+}
+
+// moveCursor 在玩家栏中上下移动选择，到头后从另一端开始
+func (m *tuiModel) moveCursor(delta int) {
+	n := len(m.view.Players)
+	if n == 0 {
+		m.cursor = -1
+		return
+	}
+
+	if m.cursor < 0 {
+		if delta > 0 {
+			m.cursor = 0
+		} else {
+			m.cursor = n - 1
+		}
+		return
+	}
+
+	m.cursor = (m.cursor + delta + n) % n
+}
+
+// insertSelectedSeat 把选中玩家的座位号填入命令，命令末尾已经是座位号时替换它
+func (m *tuiModel) insertSelectedSeat() {
+	if m.cursor < 0 || m.cursor >= len(m.view.Players) {
+		return
+	}
+
+	fields := strings.Fields(m.input.Value())
+	if n := len(fields); n > 1 {
+		if _, err := strconv.Atoi(fields[n-1]); err == nil {
+			fields = fields[:n-1]
+		}
+	}
+	fields = append(fields, strconv.Itoa(m.view.Players[m.cursor].Seat))
+
+	m.input.SetValue(strings.Join(fields, " "))
+	m.input.CursorEnd()
+}
+
+// View 实现 tea.Model
+func (m tuiModel) View() string {
+	// 还没有拿到终端尺寸
+	if m.width == 0 {
+		return ""
+	}
+
+	if m.help {
+		return m.helpView()
+	}
+
+	bodyHeight := max(m.height-1-tuiFooterHeight, 8)
+	leftWidth := min(tuiMaxLeftWidth, m.width/3)
+	rightWidth := m.width - leftWidth
+
+	playersHeight := min(max(len(m.view.Players)+3, 4), bodyHeight/2)
+	eventsHeight := bodyHeight * 2 / 3
+
+	info := box(tr("tui.info"), m.infoLines(), leftWidth, bodyHeight-playersHeight)
+	if m.view.Picker != nil {
+		info = box(m.view.Picker.Title, m.pickerLines(), leftWidth, bodyHeight-playersHeight)
+	}
+
+	left := lipgloss.JoinVertical(lipgloss.Left,
+		box(tr("tui.players"), m.playerLines(), leftWidth, playersHeight),
+		info)
+
+	right := lipgloss.JoinVertical(lipgloss.Left,
+		box(tr("tui.events"), scrolled(m.view.Events, m.scroll, eventsHeight-3), rightWidth, eventsHeight),
+		box(tr("tui.chat"), m.view.Chat, rightWidth, bodyHeight-eventsHeight))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.headerView(),
+		lipgloss.JoinHorizontal(lipgloss.Top, left, right),
+		m.noticeView(),
+		tuiHintStyle.MaxWidth(m.width).Render(m.hint()),
+		m.input.View())
+}
+
+// headerView 标题栏：房间及其状态、回合、阶段、开局倒计时、准备计时和延迟
+func (m tuiModel) headerView() string {
+	info := []string{tr("title")}
+	if m.view.RoomID != "" {
+		info = append(info, tr("header.room", m.view.RoomID))
+		if m.view.RoomState != "" {
+			info = append(info, roomStateName(m.view.RoomState))
+		}
+		info = append(info, tr("header.round", m.view.Round), tr("header.phase", m.ui.phaseName(m.view.Phase)))
+	}
+	if m.view.Countdown > 0 {
+		info = append(info, tr("header.countdown", m.view.Countdown))
+	}
+	if m.view.Idle > 0 {
+		info = append(info, tr("header.ready_countdown", m.view.Idle))
+	}
+	if m.view.RTT > 0 {
+		info = append(info, tr("header.rtt", m.view.RTT.Milliseconds()))
+	}
+
+	return tuiHeaderStyle.Width(m.width).MaxWidth(m.width).Render(strings.Join(info, " | "))
+}
+
+// playerLines 玩家栏：自己的座位带标记，选中的玩家反色显示
+func (m tuiModel) playerLines() []string {
+	lines := make([]string, 0, len(m.view.Players))
+	for i, player := range m.view.Players {
+		marker := "  "
+		if player.ID == m.view.MyID {
+			marker = m.ui.colorCode(m.view.SeatColor) + "➤ " + ColorReset
+		}
+
+		name := seatPlayerName(player.Seat, m.ui.displayName(player))
+		if i == m.cursor {
+			name = tuiCursorStyle.Render(name)
+		}
+
+		line := marker + name + " " + m.ui.formatPlayerStatus(player)
+		// 选中的玩家附上简介，栏宽有限，其他人的简介不显示
+		if bio := playerBio(player); bio != "" && i == m.cursor {
+			line += " " + tr("player.bio", bio)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// infoLines 信息栏：对局中显示角色、技能、私有信息和规则，对局结束后显示复盘
+func (m tuiModel) infoLines() []string {
+	if !m.view.InGame {
+		if len(m.view.Recap) > 0 {
+			return append([]string{tuiTitleStyle.Render(tr("panel.recap"))}, m.view.Recap...)
+		}
+		return []string{tr("tui.lobby"), tr("tui.lobby_help")}
+	}
+
+	lines := []string{tr("tui.role", m.ui.roleName(m.view.Role), m.ui.campName(m.view.Camp))}
+	if skills := m.ui.roleSkills(m.view.Role); skills != "" {
+		lines = append(lines, tr("tui.skills", skills))
+	}
+	if len(m.view.Skills) > 0 {
+		lines = append(lines, tr("tui.allowed", skillNames(m.view.Skills)))
+	}
+	if len(m.view.MyInfo) > 0 {
+		lines = append(lines, tuiTitleStyle.Render(tr("panel.my_info")))
+		lines = append(lines, m.view.MyInfo...)
+	}
+	if m.view.Rules != nil {
+		lines = append(lines, tr("tui.rules", gameRulesText(*m.view.Rules)))
+	}
+	if m.view.GuardRules != nil {
+		lines = append(lines, tr("tui.guard", guardRulesText(*m.view.GuardRules)))
+	}
+	return lines
+}
+
+// pickerLines 目标菜单：选中的选项反色显示
+func (m tuiModel) pickerLines() []string {
+	lines := make([]string, 0, len(m.view.Picker.Options))
+	for i, option := range m.view.Picker.Options {
+		line := fmt.Sprintf("%d) %s", i+1, option)
+		if i == m.pick {
+			line = tuiCursorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// noticeView 提示行：最近一条提示或错误，没有时显示正在输入的人数
+func (m tuiModel) noticeView() string {
+	switch {
+	case m.notice.err:
+		return tuiErrorStyle.MaxWidth(m.width).Render(m.notice.text)
+	case m.notice.text != "":
+		return tuiNoticeStyle.MaxWidth(m.width).Render(m.notice.text)
+	case m.view.Typing > 0:
+		return tuiNoticeStyle.Render(tr("typing", m.view.Typing))
+	default:
+		return ""
+	}
+}
+
+// hint 操作说明：当前阶段的行动提示和界面快捷键
+func (m tuiModel) hint() string {
+	if m.view.Picker != nil {
+		return tr("tui.picker_keys")
+	}
+
+	keys := tr("tui.keys")
+	if m.view.InGame {
+		return m.ui.getActionHints(m.view.Phase, m.view.Skills, m.view.SkillTime) + "  |  " + keys
+	}
+	return keys
+}
+
+// helpView 帮助页
+func (m tuiModel) helpView() string {
+	lines := make([]string, 0, len(helpCommands))
+	for _, cmd := range helpCommands {
+		if cmd == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s%-25s%s %s", ColorCyan, tr("help."+cmd+".cmd"), ColorReset, tr("help."+cmd+".desc")))
+	}
+	lines = append(lines, "", tr("tui.help_close"))
+
+	return box(tr("tui.help"), lines, m.width, m.height)
+}
+
+// eventsPage 事件栏一页的行数
+func (m tuiModel) eventsPage() int {
+	return max((m.height-1-tuiFooterHeight)*2/3-3, 1)
+}
+
+// scrolled 从末尾往前翻 offset 行后能放下的 n 行
+func scrolled(lines []string, offset, n int) []string {
+	end := max(len(lines)-offset, min(n, len(lines)))
+	start := max(end-n, 0)
+	return lines[start:end]
+}
+
+// box 带边框和标题的一栏，每行截断到栏宽，行数超出时只保留最后几行
+func box(title string, lines []string, width, height int) string {
+	innerWidth, innerHeight := max(width-2, 1), max(height-2, 1)
+
+	visible := innerHeight - 1
+	if len(lines) > visible {
+		lines = lines[len(lines)-visible:]
+	}
+
+	rows := make([]string, 0, len(lines)+1)
+	rows = append(rows, tuiTitleStyle.Render(title))
+	for _, line := range lines {
+		rows = append(rows, lipgloss.NewStyle().MaxWidth(innerWidth).Render(line))
+	}
+
+	return tuiBoxStyle.
+		Width(innerWidth).
+		Height(innerHeight).
+		MaxHeight(height).
+		Render(strings.Join(rows, "\n"))
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// tutorialStartTimeout 等待教学服务器开始接受连接的超时
+const tutorialStartTimeout = 10 * time.Second
+
+// tutorialServer 教学模式在本机启动的服务器进程
+//
+// 服务器是 main 包，无法嵌入客户端，因此作为子进程运行，监听 127.0.0.1 上的随机端口；
+// 账号写到临时目录，不保存快照，客户端退出时随之停止。
+type tutorialServer struct {
+	addr   string
+	cmd    *exec.Cmd
+	dir    string
+	exited chan struct{} // 进程退出时关闭
+}
+
+// startTutorialServer 启动教学服务器，binary 为空时在客户端所在目录和 PATH 中查找 server
+func startTutorialServer(binary string) (*tutorialServer, error) {
+	binary, err := findServerBinary(binary)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "werewolf-tutorial-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temp dir")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "pick free port")
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	// 关闭发言计时和开局倒计时，新玩家可以慢慢读提示
+	s := &tutorialServer{
+		addr: addr,
+		cmd: exec.Command(binary, "serve",
+			"-addr", addr,
+			"-account-path", filepath.Join(dir, "accounts.json"),
+			"-state-dir", "",
+			"-speak-timeout", "0",
+			"-start-countdown", "0",
+			"-log-level", "error",
+		),
+		dir:    dir,
+		exited: make(chan struct{}),
+	}
+
+	if err := s.cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "start tutorial server")
+	}
+	go func() {
+		s.cmd.Wait()
+		close(s.exited)
+	}()
+
+	if err := s.waitReady(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// findServerBinary 依次使用指定路径、客户端旁边的 server 和 PATH 中的 server
+func findServerBinary(binary string) (string, error) {
+	if binary != "" {
+		return binary, nil
+	}
+
+	if self, err := os.Executable(); err == nil {
+		sibling := filepath.Join(filepath.Dir(self), "server")
+		if info, err := os.Stat(sibling); err == nil && !info.IsDir() {
+			return sibling, nil
+		}
+	}
+
+	path, err := exec.LookPath("server")
+	if err != nil {
+		return "", errors.New("server binary not found, build it next to the client or pass -server-bin")
+	}
+	return path, nil
+}
+
+// waitReady 轮询直到服务器开始监听或进程退出
+func (s *tutorialServer) waitReady() error {
+	deadline := time.Now().Add(tutorialStartTimeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", s.addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case <-s.exited:
+			return errors.New("tutorial server exited before accepting connections")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return errors.New("tutorial server did not start in time")
+}
+
+// Close 停止服务器并删除临时目录
+func (s *tutorialServer) Close() {
+	defer os.RemoveAll(s.dir)
+
+	s.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-s.exited:
+	case <-time.After(5 * time.Second):
+		s.cmd.Process.Kill()
+	}
+}
+
+// tutorialGuide 教学模式的引导：自动登录、开房、补满机器人，并在对局的每个阶段第一次出现时讲解规则和命令
+//
+// 引导只在真实协议之上加提示，玩家的命令和界面与正常对局完全相同。
+type tutorialGuide struct {
+	client *Client
+
+	mu    sync.Mutex
+	shown map[string]bool // 已经显示过的提示
+}
+
+// UseTutorial 开启教学引导，需在 Connect 之前调用
+func (c *Client) UseTutorial() {
+	guide := &tutorialGuide{
+		client: c,
+		shown:  make(map[string]bool),
+	}
+
+	c.Use(guide.middleware())
+}
+
+// middleware 消息处理完之后推进引导：客户端状态已经更新，可以按当前角色和阶段给出提示
+func (g *tutorialGuide) middleware() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(msg *protocol.Message) error {
+			if err := next(msg); err != nil {
+				return err
+			}
+			return g.advance(msg)
+		}
+	}
+}
+
+// advance 根据收到的消息发出引导请求或显示提示
+func (g *tutorialGuide) advance(msg *protocol.Message) error {
+	switch msg.Type {
+	case protocol.MsgHello:
+		g.tell("welcome", "tutorial.welcome")
+		return g.send(protocol.NewLoginMessage(tr("tutorial.player_name")))
+	case protocol.MsgLoginSuccess:
+		return g.send(protocol.NewCreateRoomMessage(tr("tutorial.room_name"), defaultRoles()))
+	case protocol.MsgRoomCreated:
+		// 默认6人局，除了玩家自己都由机器人补满
+		return g.send(protocol.NewAddBotMessage(len(defaultRoles()) - 1))
+	case protocol.MsgPlayerJoined:
+		g.tell("ready", "tutorial.ready")
+	case protocol.MsgGameStarted:
+		g.tell("started", "tutorial.started", g.client.ui.roleName(g.role()))
+	case protocol.MsgPhaseChanged:
+		var data protocol.PhaseChangedData
+		if err := msg.UnmarshalData(&data); err != nil {
+			return err
+		}
+		g.explainPhase(data.Phase)
+	case protocol.MsgGameEnded:
+		g.tell("ended", "tutorial.ended")
+	}
+
+	return nil
+}
+
+// explainPhase 每个阶段第一次出现时讲解该阶段能做什么
+func (g *tutorialGuide) explainPhase(phase werewolf.PhaseType) {
+	switch phase {
+	case werewolf.PhaseNight:
+		switch g.role() {
+		case werewolf.RoleTypeWerewolf:
+			g.tell("night", "tutorial.night_wolf")
+		case werewolf.RoleTypeSeer:
+			g.tell("night", "tutorial.night_seer")
+		case werewolf.RoleTypeWitch:
+			g.tell("night", "tutorial.night_witch")
+		default:
+			g.tell("night", "tutorial.night_villager")
+		}
+	case werewolf.PhaseDay:
+		g.tell("day", "tutorial.day")
+	case werewolf.PhaseVote:
+		g.tell("vote", "tutorial.vote")
+	}
+}
+
+// role 当前的身份
+func (g *tutorialGuide) role() werewolf.RoleType {
+	g.client.mu.Lock()
+	defer g.client.mu.Unlock()
+
+	return g.client.state.MyRole
+}
+
+// tell 显示一条提示，同一个 step 只显示一次
+func (g *tutorialGuide) tell(step, key string, args ...interface{}) {
+	g.mu.Lock()
+	if g.shown[step] {
+		g.mu.Unlock()
+		return
+	}
+	g.shown[step] = true
+	g.mu.Unlock()
+
+	g.client.mu.Lock()
+	g.client.addEvent(tr(key, args...))
+	g.client.Render()
+	g.client.mu.Unlock()
+}
+
+// send 发送引导请求
+func (g *tutorialGuide) send(msg *protocol.Message, err error) error {
+	if err != nil {
+		return err
+	}
+	return g.client.SendMessage(msg)
+}
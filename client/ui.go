@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Zereker/game/protocol"
 	"github.com/Zereker/werewolf"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // ANSI 颜色代码
@@ -38,34 +40,59 @@ func (ui *UI) Clear() {
 	fmt.Print("\033[2J\033[H")
 }
 
-// PrintHeader 打印标题
-func (ui *UI) PrintHeader(roomID string, round int, phase werewolf.PhaseType) {
+// PrintHeader 打印标题，rtt 为 0 时不显示延迟，countdown、idle 为 0 时不显示开局倒计时和准备计时
+func (ui *UI) PrintHeader(roomID, roomState string, round int, phase werewolf.PhaseType, rtt time.Duration, countdown, idle int) {
 	ui.printSeparator()
-	title := "狼人杀游戏"
+	title := tr("title")
 	padding := (ui.width - len(title)) / 2
 	fmt.Printf("%s%s%s%s\n", ColorBold, strings.Repeat(" ", padding), title, ColorReset)
 
+	var info []string
 	if roomID != "" {
-		info := fmt.Sprintf("房间: %s | 回合: %d | 阶段: %s", roomID, round, ui.phaseName(phase))
-		fmt.Printf("%s%s%s\n", ColorCyan, info, ColorReset)
+		info = append(info, tr("header.room", roomID))
+		if roomState != "" {
+			info = append(info, roomStateName(roomState))
+		}
+		info = append(info, tr("header.round", round), tr("header.phase", ui.phaseName(phase)))
+	}
+	if countdown > 0 {
+		info = append(info, ColorYellow+tr("header.countdown", countdown)+ColorReset+ColorCyan)
+	}
+	if idle > 0 {
+		info = append(info, ColorYellow+tr("header.ready_countdown", idle)+ColorReset+ColorCyan)
+	}
+	if rtt > 0 {
+		info = append(info, tr("header.rtt", rtt.Milliseconds()))
+	}
+	if len(info) > 0 {
+		fmt.Printf("%s%s%s\n", ColorCyan, strings.Join(info, " | "), ColorReset)
 	}
 
 	ui.printSeparator()
 	fmt.Println()
 }
 
-// PrintPlayers 打印玩家列表
-func (ui *UI) PrintPlayers(players []protocol.PlayerInfo, myID string) {
-	fmt.Printf("%s玩家列表:%s\n", ColorBold, ColorReset)
+// PrintPlayers 打印玩家列表，seatColor 为自己座位标记的颜色
+func (ui *UI) PrintPlayers(players []protocol.PlayerInfo, myID, seatColor string) {
+	fmt.Printf("%s%s:%s\n", ColorBold, tr("panel.players"), ColorReset)
 
-	for i, player := range players {
+	for _, player := range players {
 		status := ui.formatPlayerStatus(player)
 		marker := "  "
 		if player.ID == myID {
-			marker = ColorYellow + "➤ " + ColorReset
+			marker = ui.colorCode(seatColor) + "➤ " + ColorReset
+		}
+
+		// 头像和名字颜色不占或多占显示宽度，按实际宽度补齐
+		name := ui.displayName(player)
+		if pad := 20 - lipgloss.Width(name); pad > 0 {
+			name += strings.Repeat(" ", pad)
+		}
+		if bio := playerBio(player); bio != "" {
+			status += " " + tr("player.bio", bio)
 		}
 
-		fmt.Printf("%s%d. %-20s %s\n", marker, i+1, player.Username, status)
+		fmt.Printf("%s%s %s %s\n", marker, tr("seat", player.Seat), name, status)
 	}
 
 	fmt.Println()
@@ -77,7 +104,7 @@ func (ui *UI) PrintEvents(events []string) {
 		return
 	}
 
-	fmt.Printf("%s事件日志:%s\n", ColorBold, ColorReset)
+	fmt.Printf("%s%s:%s\n", ColorBold, tr("panel.events"), ColorReset)
 
 	// 只显示最近10条事件
 	start := 0
@@ -92,9 +119,30 @@ func (ui *UI) PrintEvents(events []string) {
 	fmt.Println()
 }
 
+// PrintChat 打印最近的发言
+func (ui *UI) PrintChat(chat []string) {
+	if len(chat) == 0 {
+		return
+	}
+
+	fmt.Printf("%s%s:%s\n", ColorBold, tr("panel.chat"), ColorReset)
+
+	// 只显示最近5条发言
+	start := 0
+	if len(chat) > 5 {
+		start = len(chat) - 5
+	}
+
+	for _, line := range chat[start:] {
+		fmt.Printf("  %s\n", line)
+	}
+
+	fmt.Println()
+}
+
 // PrintRoleInfo 打印角色信息
 func (ui *UI) PrintRoleInfo(roleType werewolf.RoleType, camp werewolf.Camp) {
-	fmt.Printf("%s你的角色:%s ", ColorBold, ColorReset)
+	fmt.Printf("%s%s:%s ", ColorBold, tr("panel.role"), ColorReset)
 
 	roleName := ui.roleName(roleType)
 	campName := ui.campName(camp)
@@ -109,20 +157,131 @@ func (ui *UI) PrintRoleInfo(roleType werewolf.RoleType, camp werewolf.Camp) {
 	// 显示角色技能
 	skills := ui.roleSkills(roleType)
 	if skills != "" {
-		fmt.Printf("%s可用技能:%s %s\n", ColorBold, ColorReset, skills)
+		fmt.Printf("%s%s:%s %s\n", ColorBold, tr("panel.role_skills"), ColorReset, skills)
+	}
+
+	fmt.Println()
+}
+
+// PrintMyInfo 打印我的信息面板：查验、用药、守护、投票等只有自己知道的历史
+func (ui *UI) PrintMyInfo(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Printf("%s%s:%s\n", ColorBold, tr("panel.my_info"), ColorReset)
+	for _, line := range lines {
+		fmt.Printf("  %s%s%s\n", ColorYellow, line, ColorReset)
+	}
+
+	fmt.Println()
+}
+
+// PrintRecap 打印对局结束后的复盘
+func (ui *UI) PrintRecap(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Printf("%s%s:%s\n", ColorBold, tr("panel.recap"), ColorReset)
+	for _, line := range lines {
+		fmt.Printf("  %s\n", line)
 	}
 
 	fmt.Println()
 }
 
+// PrintGameRules 打印本局对局规则
+func (ui *UI) PrintGameRules(rules protocol.GameRules) {
+	fmt.Printf("%s%s:%s %s\n", ColorBold, tr("panel.rules"), ColorReset, gameRulesText(rules))
+}
+
+// PrintGuardRules 打印本局守卫规则
+func (ui *UI) PrintGuardRules(rules protocol.GuardRules) {
+	fmt.Printf("%s%s:%s %s\n\n", ColorBold, tr("panel.guard_rules"), ColorReset, guardRulesText(rules))
+}
+
+// gameRulesText 本局对局规则的说明
+func gameRulesText(rules protocol.GameRules) string {
+	var items []string
+
+	switch rules.WitchSelfSave {
+	case protocol.WitchSelfSaveAlways:
+		items = append(items, tr("rules.witch_self_save_always"))
+	case protocol.WitchSelfSaveNever:
+		items = append(items, tr("rules.witch_self_save_never"))
+	default:
+		items = append(items, tr("rules.witch_self_save_first_night"))
+	}
+
+	switch rules.FirstNightReveal {
+	case protocol.RevealDeathOnly:
+		items = append(items, tr("rules.first_night_death_only"))
+	case protocol.RevealNone:
+		items = append(items, tr("rules.first_night_none"))
+	}
+
+	if rules.WolfKill == protocol.WolfKillMajority {
+		items = append(items, tr("rules.wolf_kill_majority"))
+	}
+
+	if rules.LastWords {
+		items = append(items, tr("rules.last_words"))
+	}
+
+	if rules.Sheriff {
+		items = append(items, tr("rules.sheriff"))
+	}
+
+	if rules.HiddenVotes {
+		items = append(items, tr("rules.hidden_votes"))
+	}
+
+	return strings.Join(items, tr("list_sep"))
+}
+
+// guardRulesText 本局守卫规则的说明
+func guardRulesText(rules protocol.GuardRules) string {
+	self := tr("guard.self_allowed")
+	if !rules.AllowSelf {
+		self = tr("guard.self_forbidden")
+	}
+
+	repeat := tr("guard.repeat_allowed")
+	if !rules.AllowRepeat {
+		repeat = tr("guard.repeat_forbidden")
+	}
+
+	return self + tr("list_sep") + repeat
+}
+
+// PrintAllowedSkills 打印当前阶段可用技能
+func (ui *UI) PrintAllowedSkills(skills []protocol.SkillInfo) {
+	if len(skills) == 0 {
+		return
+	}
+
+	fmt.Printf("%s%s:%s %s\n\n", ColorBold, tr("panel.allowed"), ColorReset, skillNames(skills))
+}
+
+// skillNames 可用技能的名称列表，名称后带上命令
+func skillNames(skills []protocol.SkillInfo) string {
+	names := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		action := string(skill.ActionType)
+		names = append(names, tr("skill.format", trOr("skill."+action, action), action))
+	}
+	return strings.Join(names, ", ")
+}
+
 // PrintPrompt 打印输入提示
-func (ui *UI) PrintPrompt(phase werewolf.PhaseType, roleType werewolf.RoleType) {
-	fmt.Printf("%s请输入命令:%s\n", ColorBold, ColorReset)
+func (ui *UI) PrintPrompt(phase werewolf.PhaseType, skills []protocol.SkillInfo, timeout int) {
+	fmt.Printf("%s%s:%s\n", ColorBold, tr("panel.prompt"), ColorReset)
 
-	// 根据阶段和角色提示可用操作
-	hints := ui.getActionHints(phase, roleType)
+	// 根据服务器推送的可用技能提示可用操作
+	hints := ui.getActionHints(phase, skills, timeout)
 	if hints != "" {
-		fmt.Printf("%s提示: %s%s\n", ColorYellow, hints, ColorReset)
+		fmt.Printf("%s%s%s\n", ColorYellow, tr("panel.hint", hints), ColorReset)
 	}
 
 	fmt.Print(ColorGreen + "> " + ColorReset)
@@ -135,58 +294,97 @@ func (ui *UI) PrintMessage(msg string) {
 
 // PrintError 打印错误消息
 func (ui *UI) PrintError(msg string) {
-	fmt.Printf("%s错误: %s%s\n", ColorRed, msg, ColorReset)
+	fmt.Printf("%s%s%s\n", ColorRed, tr("error", msg), ColorReset)
 }
 
 // PrintSuccess 打印成功消息
 func (ui *UI) PrintSuccess(msg string) {
-	fmt.Printf("%s成功: %s%s\n", ColorGreen, msg, ColorReset)
+	fmt.Printf("%s%s%s\n", ColorGreen, tr("success", msg), ColorReset)
+}
+
+// preferenceLines 偏好设置的说明，每项设置一行
+func preferenceLines(prefs protocol.Preferences) []string {
+	onOff := func(b bool) string {
+		if b {
+			return tr("on")
+		}
+		return tr("off")
+	}
+
+	timeZone := prefs.TimeZone
+	if timeZone == "" {
+		timeZone = tr("prefs.local_tz")
+	}
+
+	return []string{
+		tr("prefs.general", prefs.Language, onOff(prefs.AutoReady), prefs.SeatColor, timeZone),
+		tr("prefs.notify",
+			onOff(prefs.Notifications.GameStart),
+			onOff(prefs.Notifications.YourTurn),
+			onOff(prefs.Notifications.PlayerJoins)),
+	}
+}
+
+// Bell 终端响铃提醒
+func (ui *UI) Bell() {
+	fmt.Print("\a")
 }
 
 // PrintHelp 打印帮助信息
 func (ui *UI) PrintHelp() {
 	ui.Clear()
 	ui.printSeparator()
-	fmt.Printf("%s狼人杀游戏 - 帮助信息%s\n", ColorBold, ColorReset)
+	fmt.Printf("%s%s%s\n", ColorBold, tr("help.title"), ColorReset)
 	ui.printSeparator()
 	fmt.Println()
 
-	commands := []struct {
-		cmd  string
-		desc string
-	}{
-		{"login <用户名>", "登录游戏"},
-		{"create <房间名>", "创建房间（默认6人局）"},
-		{"join <房间ID>", "加入房间"},
-		{"ready", "准备/取消准备"},
-		{"", ""},
-		{"kill <玩家编号>", "狼人击杀目标"},
-		{"check <玩家编号>", "预言家查验目标"},
-		{"protect <玩家编号>", "守卫保护目标"},
-		{"antidote", "女巫使用解药"},
-		{"poison <玩家编号>", "女巫使用毒药"},
-		{"vote <玩家编号>", "投票"},
-		{"speak <内容>", "发言"},
-		{"", ""},
-		{"help", "显示此帮助信息"},
-		{"quit", "退出游戏"},
-	}
-
-	for _, cmd := range commands {
-		if cmd.cmd == "" {
+	for _, cmd := range helpCommands {
+		if cmd == "" {
 			fmt.Println()
 			continue
 		}
-		fmt.Printf("  %s%-25s%s %s\n", ColorCyan, cmd.cmd, ColorReset, cmd.desc)
+		fmt.Printf("  %s%-25s%s %s\n", ColorCyan, tr("help."+cmd+".cmd"), ColorReset, tr("help."+cmd+".desc"))
 	}
 
 	fmt.Println()
 	ui.printSeparator()
-	fmt.Printf("\n按回车键继续...")
+	fmt.Printf("\n%s", tr("help.continue"))
+}
+
+// helpCommands 帮助信息中的命令，文案见目录中的 help.<命令>.cmd 和 help.<命令>.desc，空串用于分组
+var helpCommands = []string{
+	"register", "login", "create", "join", "watch", "rooms", "presets", "stats", "leaderboard",
+	"ready", "unready", "rematch", "kick", "owner", "close", "allow", "deny", "leave", "bot", "validate",
+	"",
+	"kill", "check", "protect", "antidote", "poison", "witch_antidote", "witch_poison",
+	"duel", "explode", "link", "steal", "shoot", "vote", "picker", "speak", "pass",
+	"sheriff_run", "sheriff_say", "sheriff_vote", "sheriff_pass", "sheriff_order",
+	"skills", "pause", "resume", "prefs", "set", "profile", "whisper", "mute", "report",
+	"",
+	"help", "quit",
 }
 
 // 辅助函数
 
+func (ui *UI) colorCode(name string) string {
+	switch name {
+	case "red":
+		return ColorRed
+	case "green":
+		return ColorGreen
+	case "blue":
+		return ColorBlue
+	case "purple":
+		return ColorPurple
+	case "cyan":
+		return ColorCyan
+	case "white":
+		return ColorWhite
+	default:
+		return ColorYellow
+	}
+}
+
 func (ui *UI) printSeparator() {
 	fmt.Println(strings.Repeat("=", ui.width))
 }
@@ -195,13 +393,37 @@ func (ui *UI) formatPlayerStatus(player protocol.PlayerInfo) string {
 	status := ""
 
 	if player.IsAlive {
-		status += ColorGreen + "[存活]" + ColorReset
+		status += ColorGreen + tr("status.alive") + ColorReset
 	} else {
-		status += ColorRed + "[死亡]" + ColorReset
+		status += ColorRed + tr("status.dead") + ColorReset
 	}
 
 	if player.IsReady {
-		status += " " + ColorYellow + "[准备]" + ColorReset
+		status += " " + ColorYellow + tr("status.ready") + ColorReset
+	}
+
+	if player.IsBot {
+		status += " " + ColorCyan + tr("status.bot") + ColorReset
+	}
+
+	if player.IsAlive && !player.IsConnected {
+		status += " " + ColorRed + tr("status.offline") + ColorReset
+	}
+
+	if player.IsAFK {
+		status += " " + ColorYellow + tr("status.afk") + ColorReset
+	}
+
+	if player.IsOwner {
+		status += " " + ColorBlue + tr("status.owner") + ColorReset
+	}
+
+	if player.IsSheriff {
+		status += " " + ColorPurple + tr("status.sheriff") + ColorReset
+	}
+
+	if player.Rating > 0 {
+		status += " " + tr("status.rating", player.Rating)
 	}
 
 	return status
@@ -210,15 +432,15 @@ func (ui *UI) formatPlayerStatus(player protocol.PlayerInfo) string {
 func (ui *UI) phaseName(phase werewolf.PhaseType) string {
 	switch phase {
 	case werewolf.PhaseStart:
-		return "开始"
+		return tr("phase.start")
 	case werewolf.PhaseNight:
-		return "夜晚"
+		return tr("phase.night")
 	case werewolf.PhaseDay:
-		return "白天"
+		return tr("phase.day")
 	case werewolf.PhaseVote:
-		return "投票"
+		return tr("phase.vote")
 	case werewolf.PhaseEnd:
-		return "结束"
+		return tr("phase.end")
 	default:
 		return string(phase)
 	}
@@ -227,17 +449,27 @@ func (ui *UI) phaseName(phase werewolf.PhaseType) string {
 func (ui *UI) roleName(roleType werewolf.RoleType) string {
 	switch roleType {
 	case werewolf.RoleTypeWerewolf:
-		return "狼人"
+		return tr("role.werewolf")
 	case werewolf.RoleTypeSeer:
-		return "预言家"
+		return tr("role.seer")
 	case werewolf.RoleTypeWitch:
-		return "女巫"
+		return tr("role.witch")
 	case werewolf.RoleTypeGuard:
-		return "守卫"
+		return tr("role.guard")
 	case werewolf.RoleTypeHunter:
-		return "猎人"
+		return tr("role.hunter")
 	case werewolf.RoleTypeVillager:
-		return "平民"
+		return tr("role.villager")
+	case protocol.RoleTypeIdiot:
+		return tr("role.idiot")
+	case protocol.RoleTypeKnight:
+		return tr("role.knight")
+	case protocol.RoleTypeWolfKing:
+		return tr("role.wolf_king")
+	case protocol.RoleTypeCupid:
+		return tr("role.cupid")
+	case protocol.RoleTypeThief:
+		return tr("role.thief")
 	default:
 		return string(roleType)
 	}
@@ -246,53 +478,67 @@ func (ui *UI) roleName(roleType werewolf.RoleType) string {
 func (ui *UI) campName(camp werewolf.Camp) string {
 	switch camp {
 	case werewolf.CampGood:
-		return "好人阵营"
+		return tr("camp.good")
 	case werewolf.CampEvil:
-		return "狼人阵营"
+		return tr("camp.evil")
+	case protocol.CampLovers:
+		return tr("camp.lovers")
 	default:
-		return "无阵营"
+		return tr("camp.none")
 	}
 }
 
 func (ui *UI) roleSkills(roleType werewolf.RoleType) string {
 	switch roleType {
 	case werewolf.RoleTypeWerewolf:
-		return "kill <编号> - 击杀玩家"
+		return tr("role_skills.werewolf")
 	case werewolf.RoleTypeSeer:
-		return "check <编号> - 查验玩家身份"
+		return tr("role_skills.seer")
 	case werewolf.RoleTypeWitch:
-		return "antidote - 解救被杀玩家 | poison <编号> - 毒杀玩家"
+		return tr("role_skills.witch")
 	case werewolf.RoleTypeGuard:
-		return "protect <编号> - 保护玩家"
+		return tr("role_skills.guard")
 	case werewolf.RoleTypeHunter:
-		return "被动技能：死亡时可开枪"
+		return tr("role_skills.hunter")
 	case werewolf.RoleTypeVillager:
-		return "vote <编号> - 投票（白天/投票阶段）"
+		return tr("role_skills.villager")
+	case protocol.RoleTypeIdiot:
+		return tr("role_skills.idiot")
+	case protocol.RoleTypeKnight:
+		return tr("role_skills.knight")
+	case protocol.RoleTypeWolfKing:
+		return tr("role_skills.wolf_king")
+	case protocol.RoleTypeCupid:
+		return tr("role_skills.cupid")
+	case protocol.RoleTypeThief:
+		return tr("role_skills.thief")
 	default:
 		return ""
 	}
 }
 
-func (ui *UI) getActionHints(phase werewolf.PhaseType, roleType werewolf.RoleType) string {
-	switch phase {
-	case werewolf.PhaseNight:
-		switch roleType {
-		case werewolf.RoleTypeWerewolf:
-			return "轮到你行动了，使用 kill <编号> 选择击杀目标"
-		case werewolf.RoleTypeSeer:
-			return "使用 check <编号> 查验一名玩家"
-		case werewolf.RoleTypeWitch:
-			return "使用 antidote 解救被杀玩家，或 poison <编号> 毒杀玩家"
-		case werewolf.RoleTypeGuard:
-			return "使用 protect <编号> 保护一名玩家"
+// getActionHints 按服务器推送的可用技能给出操作提示，timeout 为这一步剩余的秒数
+//
+// 提示只看服务器说此刻能做什么，不按阶段和角色自行推测。
+func (ui *UI) getActionHints(phase werewolf.PhaseType, skills []protocol.SkillInfo, timeout int) string {
+	if len(skills) == 0 {
+		switch phase {
+		case werewolf.PhaseNight, werewolf.PhaseDay, werewolf.PhaseVote:
+			return tr("hint.wait")
 		default:
-			return "等待其他玩家行动..."
+			return tr("hint.default")
 		}
-	case werewolf.PhaseDay:
-		return "白天讨论阶段，使用 speak <内容> 发言"
-	case werewolf.PhaseVote:
-		return "投票阶段，使用 vote <编号> 投票"
-	default:
-		return "输入 help 查看可用命令"
 	}
+
+	hints := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		action := string(skill.ActionType)
+		hints = append(hints, trOr("hint."+action, action))
+	}
+
+	hint := tr("hint.actions", strings.Join(hints, tr("hint.separator")))
+	if timeout > 0 {
+		hint += tr("hint.deadline", timeout)
+	}
+	return hint
 }
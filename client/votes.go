@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+)
+
+// handleVoteCast 处理放逐投票中有人投票或改票；隐藏投票的房间只知道谁已投票
+func (c *Client) handleVoteCast(data *protocol.VoteCastData) error {
+	who := c.seatName(data.VoterID)
+
+	switch {
+	case data.Hidden:
+		c.addEvent(tr("vote.hidden", who))
+	case data.TargetID == "":
+		c.addEvent(tr("vote.abstain", who))
+	case data.Changed:
+		c.addEvent(tr("vote.changed", who, c.seatName(data.TargetID)))
+	default:
+		c.addEvent(tr("vote.cast", who, c.seatName(data.TargetID)))
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleVoteResult 处理投票结束时同时公布的每一票，按座位顺序显示
+func (c *Client) handleVoteResult(data *protocol.VoteResultData) error {
+	c.addEvent(tr("vote.result", data.Round))
+	for _, p := range c.state.Players {
+		target, ok := data.Ballots[p.ID]
+		if !ok {
+			continue
+		}
+		if target == "" {
+			c.addEvent(tr("vote.abstain", c.seatName(p.ID)))
+		} else {
+			c.addEvent(tr("vote.cast", c.seatName(p.ID), c.seatName(target)))
+		}
+	}
+	c.Render()
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// handleSkillPrompt 处理女巫分步用药和狼王开枪的提示，保存下来等待 witch 或 shoot 命令回答
+func (c *Client) handleSkillPrompt(data *protocol.SkillPromptData) error {
+	c.state.SkillPrompt = data
+
+	c.addEvent(tr("skill_prompt", data.Message, data.TimeoutSeconds))
+	if c.state.Preferences.Notifications.YourTurn {
+		c.ui.Bell()
+	}
+	c.Render()
+
+	return nil
+}
+
+// handleWitch 处理 witch 命令：回答当前的解药或毒药提示
+func (h *InputHandler) handleWitch(parts []string) error {
+	if len(parts) < 2 {
+		return errors.New(tr("usage.witch"))
+	}
+
+	prompt := h.client.state.SkillPrompt
+	if prompt == nil || prompt.Step == protocol.SkillStepShoot {
+		return errors.New(tr("err.no_witch_prompt"))
+	}
+
+	accept, seat := false, 0
+	answer := strings.ToLower(parts[1])
+
+	switch prompt.Step {
+	case protocol.SkillStepAntidote:
+		switch answer {
+		case "yes", "y":
+			accept = true
+		case "no", "n":
+		default:
+			return errors.New(tr("usage.witch_yes_no"))
+		}
+	case protocol.SkillStepPoison:
+		if answer != "pass" && answer != "no" {
+			target, err := h.playerBySeat(parts[1])
+			if err != nil {
+				return err
+			}
+			accept, seat = true, target.Seat
+		}
+	default:
+		return errors.New(tr("usage.witch"))
+	}
+
+	msg, err := protocol.NewSkillResponseMessage(prompt.PromptID, accept, seat)
+	if err != nil {
+		return err
+	}
+
+	h.client.state.SkillPrompt = nil
+	return h.client.SendMessage(msg)
+}
+
+// handleShoot 处理 shoot 命令：狼王出局后回答开枪提示
+func (h *InputHandler) handleShoot(parts []string) error {
+	if len(parts) < 2 {
+		return errors.New(tr("usage.shoot"))
+	}
+
+	prompt := h.client.state.SkillPrompt
+	if prompt == nil || prompt.Step != protocol.SkillStepShoot {
+		return errors.New(tr("err.no_shot_prompt"))
+	}
+
+	accept, seat := false, 0
+	if answer := strings.ToLower(parts[1]); answer != "pass" && answer != "no" {
+		target, err := h.playerBySeat(parts[1])
+		if err != nil {
+			return err
+		}
+		accept, seat = true, target.Seat
+	}
+
+	msg, err := protocol.NewSkillResponseMessage(prompt.PromptID, accept, seat)
+	if err != nil {
+		return err
+	}
+
+	h.client.state.SkillPrompt = nil
+	return h.client.SendMessage(msg)
+}
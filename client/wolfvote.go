@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+)
+
+// handleWolfVoteUpdate 处理狼队友选择或改选刀口，显示当前会被击杀的目标
+func (c *Client) handleWolfVoteUpdate(data *protocol.WolfVoteUpdateData) error {
+	who := c.playerName(data.PlayerID)
+	if data.PlayerID == c.state.PlayerID {
+		who = tr("you")
+	}
+
+	rule := tr("wolf.rule_last")
+	if data.Rule == protocol.WolfKillMajority {
+		rule = tr("wolf.rule_vote")
+	}
+
+	c.addEvent(tr("wolf.choice",
+		who, c.playerName(data.Votes[data.PlayerID]), rule, c.playerName(data.Target)))
+	c.Render()
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package engine 定义房间使用的规则引擎接口，房间只依赖接口，
+// 可以换用其他规则实现或测试用的模拟引擎。
+package engine
+
+import (
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// GameEngine 规则引擎
+//
+// 接口只包含房间实际用到的引擎能力。阶段、角色、事件等类型仍沿用 werewolf 包的定义，
+// 它们也是客户端协议的一部分；可用技能由服务器按状态计算，引擎不提供结束阶段的接口。
+type GameEngine interface {
+	// AddPlayer 开局前按座位顺序加入玩家
+	AddPlayer(playerID string) error
+	// Start 开始对局，之后通过订阅的事件推进
+	Start() error
+	// PerformAction 提交玩家的技能或投票，不合法时返回错误，引擎自身故障时返回包装了 ErrInternal 的错误
+	PerformAction(playerID string, actionType werewolf.ActionType, targetID string, data map[string]interface{}) error
+	// GetState 当前对局状态
+	GetState() *werewolf.GameState
+	// Subscribe 订阅引擎事件，需在 Start 之前调用
+	Subscribe(eventType werewolf.EventType, handler func(werewolf.Event))
+}
+
+// ErrInternal 引擎自身的故障，例如状态不一致，区别于玩家动作不合法被规则拒绝
+//
+// 实现遇到内部故障时用 errors.Wrap 包装它返回，房间据此按错误策略处理，而不是当作动作被拒绝。
+var ErrInternal = errors.New("engine internal error")
+
+// Flusher 异步发出事件的引擎实现它，房间重放快照后等重放产生的事件发完再继续
+type Flusher interface {
+	// Flush 阻塞到已产生的事件全部交给订阅者处理完
+	Flush()
+}
+
+// Abstainer 接受空目标表示放弃的引擎实现它：狼人不刀、预言家不查验、守卫不守护、放逐投票弃权
+//
+// 玩家的 PASS、替挂机玩家交上的空动作和警长加权计票的弃权票都要交空目标，
+// 引擎没有实现这个接口时房间不交空目标：拒绝 PASS，放逐投票不按警长加权计票。
+type Abstainer interface {
+	// AcceptsAbstain 引擎是否接受空目标表示放弃
+	AcceptsAbstain() bool
+}
+
+// CanAbstain 引擎是否接受空目标表示放弃，见 Abstainer
+func CanAbstain(eng GameEngine) bool {
+	abstainer, ok := eng.(Abstainer)
+	return ok && abstainer.AcceptsAbstain()
+}
+
+// Factory 按对局配置创建引擎
+type Factory func(config werewolf.Config) GameEngine
+
+// NewWerewolf 创建默认的 werewolf 引擎
+func NewWerewolf(config werewolf.Config) GameEngine {
+	return werewolf.NewEngine(config)
+}
+
+// ByName 按名称选择引擎：werewolf 为真实规则引擎，mock 为确定性的模拟引擎
+func ByName(name string) (Factory, error) {
+	switch name {
+	case "werewolf":
+		return NewWerewolf, nil
+	case "mock":
+		return NewMock, nil
+	default:
+		return nil, errors.Errorf("unknown engine: %s", name)
+	}
+}
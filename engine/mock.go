@@ -0,0 +1,433 @@
+package engine
+
+import (
+	"sync"
+
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// 模拟引擎认识的动作，与客户端协议中的标准动作名称一致
+const (
+	mockKill     werewolf.ActionType = "kill"
+	mockCheck    werewolf.ActionType = "check"
+	mockProtect  werewolf.ActionType = "protect"
+	mockAntidote werewolf.ActionType = "antidote"
+	mockPoison   werewolf.ActionType = "poison"
+	mockVote     werewolf.ActionType = "vote"
+	mockSpeak    werewolf.ActionType = "speak"
+)
+
+// Mock 确定性的模拟引擎，用于压测和开发时不依赖真实规则引擎
+//
+// 角色按加入顺序依次分配，不洗牌；阶段在所有人提交后立即推进：
+// 夜里所有存活狼人提交刀口、存活的预言家和守卫都行动后天亮，刀口以最后一次提交为准，
+// 女巫的行动可选，需赶在夜晚结束之前提交；
+// 被守护或被解药救下的不死，被毒的出局；白天所有存活玩家都发言后进入投票；
+// 投票所有存活玩家都投完后票数最多者出局，平票无人出局；除毒药外空目标表示放弃，弃权票不计。
+// 每次有人出局后判定胜负：狼人全部出局好人胜，狼人不少于其他存活玩家时狼人胜。
+// 事件按产生顺序在单独的协程中发出，不阻塞调用方（房间持有自己的锁调用 Start 和重新发牌），
+// 发出时不持有引擎的锁。
+type Mock struct {
+	mu       sync.Mutex
+	roles    []werewolf.RoleType
+	state    werewolf.GameState
+	handlers map[werewolf.EventType][]func(werewolf.Event)
+	pending  []werewolf.Event // 等待发出的事件
+	emitting bool             // 是否有协程正在发出事件
+	idle     *sync.Cond       // 发完事件时通知 Flush
+
+	actions map[string]map[werewolf.ActionType]string // 本阶段每名玩家每种动作最后一次的目标
+	kill    string                                    // 本夜最后一次提交的刀口
+	used    map[werewolf.ActionType]bool              // 女巫已用过的药
+}
+
+// NewMock 创建模拟引擎
+func NewMock(config werewolf.Config) GameEngine {
+	m := &Mock{
+		roles:    append([]werewolf.RoleType(nil), config.Roles...),
+		state:    werewolf.GameState{Phase: werewolf.PhaseStart},
+		handlers: make(map[werewolf.EventType][]func(werewolf.Event)),
+		used:     make(map[werewolf.ActionType]bool),
+	}
+	m.idle = sync.NewCond(&m.mu)
+	return m
+}
+
+// AddPlayer 实现 GameEngine 接口
+func (m *Mock) AddPlayer(playerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state.Phase != werewolf.PhaseStart {
+		return errors.New("game already started")
+	}
+	if len(m.state.Players) >= len(m.roles) {
+		return errors.New("all roles are taken")
+	}
+
+	m.state.Players = append(m.state.Players, werewolf.PlayerState{
+		ID:      playerID,
+		Role:    m.roles[len(m.state.Players)],
+		IsAlive: true,
+	})
+	return nil
+}
+
+// Subscribe 实现 GameEngine 接口
+func (m *Mock) Subscribe(eventType werewolf.EventType, handler func(werewolf.Event)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers[eventType] = append(m.handlers[eventType], handler)
+}
+
+// Start 实现 GameEngine 接口，从第一夜开始
+func (m *Mock) Start() error {
+	m.mu.Lock()
+	if m.state.Phase != werewolf.PhaseStart {
+		m.mu.Unlock()
+		return errors.New("game already started")
+	}
+	if len(m.state.Players) != len(m.roles) {
+		m.mu.Unlock()
+		return errors.Errorf("need %d players, got %d", len(m.roles), len(m.state.Players))
+	}
+
+	events := m.enter(werewolf.PhaseNight)
+	m.mu.Unlock()
+
+	m.emit(events)
+	return nil
+}
+
+// GetState 实现 GameEngine 接口，返回状态的副本
+func (m *Mock) GetState() *werewolf.GameState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.state
+	state.Players = append([]werewolf.PlayerState(nil), m.state.Players...)
+	state.AlivePlayers = m.alive()
+	return &state
+}
+
+// PerformAction 实现 GameEngine 接口
+func (m *Mock) PerformAction(playerID string, actionType werewolf.ActionType, targetID string, _ map[string]interface{}) error {
+	m.mu.Lock()
+	if err := m.check(playerID, actionType, targetID); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	if m.actions[playerID] == nil {
+		m.actions[playerID] = make(map[werewolf.ActionType]string)
+	}
+	m.actions[playerID][actionType] = targetID
+	switch actionType {
+	case mockKill:
+		if targetID != "" {
+			m.kill = targetID
+		}
+	case mockAntidote, mockPoison:
+		m.used[actionType] = true
+	}
+
+	var events []werewolf.Event
+	if m.phaseDone() {
+		events = m.finishPhase()
+	}
+	m.mu.Unlock()
+
+	m.emit(events)
+	return nil
+}
+
+// AcceptsAbstain 实现 Abstainer 接口，空目标表示放弃
+func (m *Mock) AcceptsAbstain() bool {
+	return true
+}
+
+// check 检查动作是否合法（需持有锁）
+func (m *Mock) check(playerID string, actionType werewolf.ActionType, targetID string) error {
+	if m.state.Phase == werewolf.PhaseStart || m.state.IsEnded {
+		return errors.New("game is not running")
+	}
+
+	player, ok := m.player(playerID)
+	if !ok || !player.IsAlive {
+		return errors.New("player is not alive")
+	}
+
+	var role werewolf.RoleType
+	switch actionType {
+	case mockKill:
+		role = werewolf.RoleTypeWerewolf
+	case mockCheck:
+		role = werewolf.RoleTypeSeer
+	case mockProtect:
+		role = werewolf.RoleTypeGuard
+	case mockAntidote, mockPoison:
+		role = werewolf.RoleTypeWitch
+		if m.used[actionType] {
+			return errors.Errorf("%s already used", actionType)
+		}
+	}
+
+	switch {
+	case role != "":
+		if m.state.Phase != werewolf.PhaseNight || player.Role != role {
+			return errors.Errorf("cannot %s now", actionType)
+		}
+	case actionType == mockSpeak:
+		if m.state.Phase != werewolf.PhaseDay {
+			return errors.New("cannot speak now")
+		}
+	case actionType == mockVote:
+		if m.state.Phase != werewolf.PhaseVote {
+			return errors.New("cannot vote now")
+		}
+	default:
+		return errors.Errorf("unknown action: %s", actionType)
+	}
+
+	if actionType == mockAntidote && m.kill == "" {
+		return errors.New("nobody to save")
+	}
+	// 空目标表示放弃：不刀、不查验、不守护或弃权，毒药必须有目标
+	if targetID == "" && actionType != mockPoison {
+		return nil
+	}
+	if actionType != mockSpeak && actionType != mockAntidote {
+		if target, ok := m.player(targetID); !ok || !target.IsAlive {
+			return errors.New("target is not alive")
+		}
+	}
+
+	return nil
+}
+
+// phaseDone 本阶段需要行动的玩家是否都已提交（需持有锁）
+func (m *Mock) phaseDone() bool {
+	for _, p := range m.state.Players {
+		if !p.IsAlive {
+			continue
+		}
+
+		var need werewolf.ActionType
+		switch m.state.Phase {
+		case werewolf.PhaseNight:
+			switch {
+			case p.Role == werewolf.RoleTypeWerewolf:
+				need = mockKill
+			case p.Role == werewolf.RoleTypeSeer:
+				need = mockCheck
+			case p.Role == werewolf.RoleTypeGuard:
+				need = mockProtect
+			}
+		case werewolf.PhaseDay:
+			need = mockSpeak
+		case werewolf.PhaseVote:
+			need = mockVote
+		}
+
+		if need == "" {
+			continue
+		}
+		if _, done := m.actions[p.ID][need]; !done {
+			return false
+		}
+	}
+	return true
+}
+
+// finishPhase 结算当前阶段并进入下一阶段，返回要发出的事件（需持有锁）
+func (m *Mock) finishPhase() []werewolf.Event {
+	var events []werewolf.Event
+
+	switch m.state.Phase {
+	case werewolf.PhaseNight:
+		saved := false
+		for _, acts := range m.actions {
+			if _, ok := acts[mockAntidote]; ok {
+				saved = true
+			}
+			if acts[mockProtect] == m.kill {
+				saved = true
+			}
+		}
+		if m.kill != "" && !saved {
+			events = append(events, m.die(m.kill, "killed"))
+		}
+		for _, acts := range m.actions {
+			if target, ok := acts[mockPoison]; ok {
+				events = append(events, m.die(target, "poisoned"))
+			}
+		}
+	case werewolf.PhaseVote:
+		if exiled := m.tally(); exiled != "" {
+			events = append(events, m.die(exiled, "exiled"))
+		}
+	}
+
+	if winner, ended := m.winner(); ended {
+		m.state.IsEnded = true
+		m.state.Phase = werewolf.PhaseEnd
+		return append(events, werewolf.Event{
+			Type: werewolf.EventGameEnded,
+			Data: map[string]interface{}{"winner": winner},
+		})
+	}
+
+	next := map[werewolf.PhaseType]werewolf.PhaseType{
+		werewolf.PhaseNight: werewolf.PhaseDay,
+		werewolf.PhaseDay:   werewolf.PhaseVote,
+		werewolf.PhaseVote:  werewolf.PhaseNight,
+	}[m.state.Phase]
+
+	return append(events, m.enter(next)...)
+}
+
+// enter 进入新阶段，夜晚开始新的一轮（需持有锁）
+func (m *Mock) enter(phase werewolf.PhaseType) []werewolf.Event {
+	if phase == werewolf.PhaseNight {
+		m.state.Round++
+		m.kill = ""
+	}
+	m.state.Phase = phase
+	m.actions = make(map[string]map[werewolf.ActionType]string)
+
+	return []werewolf.Event{{
+		Type: werewolf.EventPhaseStarted,
+		Data: map[string]interface{}{"phase": phase, "round": m.state.Round},
+	}}
+}
+
+// tally 放逐投票的结果，平票时返回空（需持有锁）
+func (m *Mock) tally() string {
+	counts := make(map[string]int)
+	for _, acts := range m.actions {
+		counts[acts[mockVote]]++
+	}
+
+	exiled, top, tie := "", 0, false
+	// 按座位顺序遍历，结果与 map 的遍历顺序无关
+	for _, p := range m.state.Players {
+		switch n := counts[p.ID]; {
+		case n > top:
+			exiled, top, tie = p.ID, n, false
+		case n == top && n > 0:
+			tie = true
+		}
+	}
+
+	if tie {
+		return ""
+	}
+	return exiled
+}
+
+// die 玩家出局（需持有锁）
+func (m *Mock) die(playerID, reason string) werewolf.Event {
+	for i := range m.state.Players {
+		if m.state.Players[i].ID == playerID {
+			m.state.Players[i].IsAlive = false
+		}
+	}
+
+	return werewolf.Event{
+		Type: werewolf.EventPlayerDied,
+		Data: map[string]interface{}{"playerID": playerID, "reason": reason},
+	}
+}
+
+// winner 判定胜负（需持有锁）
+func (m *Mock) winner() (werewolf.Camp, bool) {
+	wolves, others := 0, 0
+	for _, p := range m.state.Players {
+		switch {
+		case !p.IsAlive:
+		case p.Role == werewolf.RoleTypeWerewolf:
+			wolves++
+		default:
+			others++
+		}
+	}
+
+	switch {
+	case wolves == 0:
+		return werewolf.CampGood, true
+	case wolves >= others:
+		return werewolf.CampEvil, true
+	default:
+		return "", false
+	}
+}
+
+// player 按ID查找玩家（需持有锁）
+func (m *Mock) player(playerID string) (werewolf.PlayerState, bool) {
+	for _, p := range m.state.Players {
+		if p.ID == playerID {
+			return p, true
+		}
+	}
+	return werewolf.PlayerState{}, false
+}
+
+// alive 存活玩家，按加入顺序（需持有锁）
+func (m *Mock) alive() []string {
+	var ids []string
+	for _, p := range m.state.Players {
+		if p.IsAlive {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+// emit 把事件排进发送队列，没有协程在发送时启动一个
+func (m *Mock) emit(events []werewolf.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending = append(m.pending, events...)
+	if !m.emitting {
+		m.emitting = true
+		go m.drain()
+	}
+}
+
+// drain 依次发出队列中的事件（不能持有锁，订阅者会回调 GetState 和 PerformAction）
+func (m *Mock) drain() {
+	for {
+		m.mu.Lock()
+		if len(m.pending) == 0 {
+			m.emitting = false
+			m.idle.Broadcast()
+			m.mu.Unlock()
+			return
+		}
+		event := m.pending[0]
+		m.pending = m.pending[1:]
+		handlers := append([]func(werewolf.Event){}, m.handlers[event.Type]...)
+		m.mu.Unlock()
+
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+}
+
+// Flush 实现 Flusher 接口，等待发出事件的协程把队列发完
+func (m *Mock) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.emitting {
+		m.idle.Wait()
+	}
+}
@@ -0,0 +1,265 @@
+// Package gametest 端到端测试工具：用真实的 TCP 连接驱动服务器，按场景校验完整流程
+package gametest
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeout 等待单条消息的默认超时
+const DefaultTimeout = 10 * time.Second
+
+// Client 测试用客户端，收到的消息先缓存，由 Expect 按类型取出；按序号去重并补齐漏收的消息
+type Client struct {
+	Name         string
+	PlayerID     string
+	SessionToken string
+
+	tcpConn  *net.TCPConn
+	conn     *socket.Conn
+	messages chan *protocol.Message
+	pending  []*protocol.Message // 等待时跳过的消息，留给之后的 Expect
+
+	lastSeq uint64          // 已收到的最大序号
+	missing map[uint64]bool // 已请求重发、尚未收到的序号
+}
+
+// Dial 连接服务器并完成握手
+func Dial(addr, name string) (*Client, error) {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve address")
+	}
+
+	tcpConn, err := net.DialTCP("tcp", nil, tcpAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial")
+	}
+
+	c := &Client{
+		Name:     name,
+		tcpConn:  tcpConn,
+		messages: make(chan *protocol.Message, 256),
+	}
+
+	c.conn, err = socket.NewConn(tcpConn,
+		socket.CustomCodecOption(protocol.NewCodec()),
+		socket.OnErrorOption(func(err error) bool { return true }),
+		socket.OnMessageOption(func(m socket.Message) error {
+			c.messages <- m.(*protocol.Message)
+			return nil
+		}))
+	if err != nil {
+		tcpConn.Close()
+		return nil, errors.Wrap(err, "create connection")
+	}
+
+	go c.conn.Run(context.Background())
+
+	hello, _ := protocol.NewHelloMessage("gametest")
+	if err := c.Send(hello); err != nil {
+		return nil, err
+	}
+	if _, err := c.Expect(protocol.MsgHello, DefaultTimeout); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Send 发送消息
+func (c *Client) Send(msg *protocol.Message) error {
+	return errors.Wrapf(c.conn.Write(msg), "%s: send %s", c.Name, msg.Type)
+}
+
+// Close 直接断开 TCP 连接，模拟掉线
+func (c *Client) Close() error {
+	return c.tcpConn.Close()
+}
+
+// Login 以游客身份登录
+func (c *Client) Login() error {
+	msg, _ := protocol.NewLoginMessage(c.Name)
+	return c.login(msg)
+}
+
+// Resume 凭会话令牌重连
+func (c *Client) Resume(sessionToken string) error {
+	msg, _ := protocol.NewResumeSessionMessage(sessionToken)
+	return c.login(msg)
+}
+
+func (c *Client) login(msg *protocol.Message) error {
+	if err := c.Send(msg); err != nil {
+		return err
+	}
+
+	var data protocol.LoginSuccessData
+	if err := c.ExpectData(protocol.MsgLoginSuccess, &data); err != nil {
+		return err
+	}
+
+	c.PlayerID = data.PlayerID
+	c.SessionToken = data.SessionToken
+
+	return nil
+}
+
+// CreateRoom 创建房间，返回房间ID
+func (c *Client) CreateRoom(name string, roles []interface{}) (string, error) {
+	msg, _ := protocol.NewCreateRoomMessage(name, roles)
+	if err := c.Send(msg); err != nil {
+		return "", err
+	}
+
+	var data protocol.RoomCreatedData
+	if err := c.ExpectData(protocol.MsgRoomCreated, &data); err != nil {
+		return "", err
+	}
+
+	return data.RoomID, nil
+}
+
+// JoinRoom 加入房间
+func (c *Client) JoinRoom(roomID string) error {
+	msg, _ := protocol.NewJoinRoomMessage(roomID)
+	if err := c.Send(msg); err != nil {
+		return err
+	}
+
+	_, err := c.Expect(protocol.MsgRoomJoined, DefaultTimeout)
+	return err
+}
+
+// Ready 准备
+func (c *Client) Ready() error {
+	msg, _ := protocol.NewReadyMessage(true)
+	return c.Send(msg)
+}
+
+// Action 执行动作并返回动作结果
+func (c *Client) Action(actionType, targetID string) (protocol.ActionResultData, error) {
+	var data protocol.ActionResultData
+
+	msg, _ := protocol.NewPerformActionMessage(actionType, targetID, nil)
+	if err := c.Send(msg); err != nil {
+		return data, err
+	}
+
+	err := c.ExpectData(protocol.MsgActionResult, &data)
+	return data, err
+}
+
+// Submit 提交夜间动作，等到服务器确认收下；夜里成功的结果要到天亮才随 NIGHT_RESULT 发出，被拒绝时立即返回错误
+func (c *Client) Submit(actionType, targetID string) error {
+	msg, _ := protocol.NewPerformActionMessage(actionType, targetID, nil)
+	if err := c.Send(msg); err != nil {
+		return err
+	}
+
+	reply, err := c.ExpectFunc(func(m *protocol.Message) bool {
+		return m.Type == protocol.MsgActionAccepted || m.Type == protocol.MsgActionResult
+	}, "ACTION_ACCEPTED", DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	if reply.Type == protocol.MsgActionAccepted {
+		return nil
+	}
+
+	var data protocol.ActionResultData
+	if err := reply.UnmarshalData(&data); err != nil {
+		return errors.Wrapf(err, "%s: decode %s", c.Name, reply.Type)
+	}
+	if !data.Success {
+		return errors.Errorf("%s: %s rejected: %s", c.Name, actionType, data.Message)
+	}
+	return nil
+}
+
+// Expect 等待指定类型的消息；期间收到服务器错误时直接失败
+func (c *Client) Expect(msgType protocol.MessageType, timeout time.Duration) (*protocol.Message, error) {
+	return c.ExpectFunc(func(msg *protocol.Message) bool {
+		return msg.Type == msgType
+	}, string(msgType), timeout)
+}
+
+// WaitForType 以默认超时等待指定类型的消息
+func (c *Client) WaitForType(msgType protocol.MessageType) (*protocol.Message, error) {
+	return c.Expect(msgType, DefaultTimeout)
+}
+
+// ExpectData 等待指定类型的消息并解析数据
+func (c *Client) ExpectData(msgType protocol.MessageType, v interface{}) error {
+	msg, err := c.Expect(msgType, DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(msg.UnmarshalData(v), "%s: decode %s", c.Name, msgType)
+}
+
+// ExpectFunc 等待满足条件的消息，desc 用于超时时的错误描述
+func (c *Client) ExpectFunc(match func(*protocol.Message) bool, desc string, timeout time.Duration) (*protocol.Message, error) {
+	for i, msg := range c.pending {
+		if match(msg) {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return msg, nil
+		}
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-c.messages:
+			if !c.accept(msg) {
+				continue
+			}
+			if match(msg) {
+				return msg, nil
+			}
+			if msg.Type == protocol.MsgError {
+				var data protocol.ErrorData
+				msg.UnmarshalData(&data)
+				return nil, errors.Errorf("%s: server error while waiting for %s: %s", c.Name, desc, data.Message)
+			}
+			c.pending = append(c.pending, msg)
+		case <-deadline:
+			return nil, errors.Errorf("%s: timeout waiting for %s", c.Name, desc)
+		}
+	}
+}
+
+// accept 按序号去掉重复消息；发现跳号时请求服务器重发缺失的区间，补发的消息随后到达
+func (c *Client) accept(msg *protocol.Message) bool {
+	seq := msg.Seq
+	if seq == 0 {
+		return true
+	}
+
+	if seq <= c.lastSeq {
+		if c.missing[seq] {
+			delete(c.missing, seq)
+			return true
+		}
+		return false
+	}
+
+	if seq > c.lastSeq+1 {
+		if c.missing == nil {
+			c.missing = make(map[uint64]bool)
+		}
+		for s := c.lastSeq + 1; s < seq; s++ {
+			c.missing[s] = true
+		}
+		resend, _ := protocol.NewResendMessage(c.lastSeq+1, seq-1)
+		c.Send(resend)
+	}
+
+	c.lastSeq = seq
+	return true
+}
@@ -0,0 +1,145 @@
+package gametest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// gameStartTimeout 全员准备后等待开局的超时
+const gameStartTimeout = 30 * time.Second
+
+// SixPlayerRoles 标准6人局配置
+var SixPlayerRoles = []interface{}{
+	"werewolf", "werewolf", "villager", "villager", "seer", "witch",
+}
+
+// Logf 场景输出进度的日志函数
+type Logf func(format string, args ...interface{})
+
+// Scenario 一个端到端测试场景
+type Scenario struct {
+	Name string
+	Run  func(addr string, logf Logf) error
+}
+
+// Scenarios 所有已注册的场景
+var Scenarios = []Scenario{
+	{Name: "reconnect-during-night", Run: ReconnectDuringNight},
+	{Name: "six-player-night", Run: SixPlayerNight},
+}
+
+// Game 已开局的一桌玩家
+type Game struct {
+	RoomID  string
+	Clients []*Client
+	Started map[string]protocol.GameStartedData // playerID -> 开局时收到的角色信息
+}
+
+// StartGame 连接与角色数相同的玩家，由第一名玩家建房，全员准备并等待开局
+func StartGame(addr, prefix string, roles []interface{}) (*Game, error) {
+	game := &Game{
+		Started: make(map[string]protocol.GameStartedData),
+	}
+
+	for i := range roles {
+		client, err := Dial(addr, fmt.Sprintf("%s-%d", prefix, i+1))
+		if err != nil {
+			game.Close()
+			return nil, err
+		}
+		game.Clients = append(game.Clients, client)
+
+		if err := client.Login(); err != nil {
+			game.Close()
+			return nil, err
+		}
+	}
+
+	host := game.Clients[0]
+	roomID, err := host.CreateRoom(prefix, roles)
+	if err != nil {
+		game.Close()
+		return nil, err
+	}
+	game.RoomID = roomID
+
+	for _, client := range game.Clients[1:] {
+		if err := client.JoinRoom(roomID); err != nil {
+			game.Close()
+			return nil, err
+		}
+	}
+
+	for _, client := range game.Clients {
+		if err := client.Ready(); err != nil {
+			game.Close()
+			return nil, err
+		}
+	}
+
+	for _, client := range game.Clients {
+		msg, err := client.Expect(protocol.MsgGameStarted, gameStartTimeout)
+		if err != nil {
+			game.Close()
+			return nil, err
+		}
+
+		var data protocol.GameStartedData
+		if err := msg.UnmarshalData(&data); err != nil {
+			game.Close()
+			return nil, errors.Wrap(err, "decode game started")
+		}
+		game.Started[client.PlayerID] = data
+	}
+
+	return game, nil
+}
+
+// ByRole 返回担任指定角色的玩家
+func (g *Game) ByRole(role werewolf.RoleType) []*Client {
+	var clients []*Client
+	for _, client := range g.Clients {
+		if g.Started[client.PlayerID].RoleType == role {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// Close 断开所有玩家
+func (g *Game) Close() {
+	for _, client := range g.Clients {
+		client.Close()
+	}
+}
+
+// findSkill 在可用技能中查找指定动作
+func findSkill(data protocol.AllowedSkillsData, actionType werewolf.ActionType) (protocol.SkillInfo, bool) {
+	for _, skill := range data.Skills {
+		if skill.ActionType == actionType {
+			return skill, true
+		}
+	}
+	return protocol.SkillInfo{}, false
+}
+
+// hasSkill 匹配包含指定动作的可用技能消息
+func hasSkill(actionType werewolf.ActionType) func(*protocol.Message) bool {
+	return func(msg *protocol.Message) bool {
+		if msg.Type != protocol.MsgAllowedSkills {
+			return false
+		}
+
+		var data protocol.AllowedSkillsData
+		if err := msg.UnmarshalData(&data); err != nil {
+			return false
+		}
+
+		_, ok := findSkill(data, actionType)
+		return ok
+	}
+}
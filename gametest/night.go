@@ -0,0 +1,83 @@
+package gametest
+
+import (
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// dawnTimeout 等待天亮的超时（夜晚播报完整走完需要十几秒）
+const dawnTimeout = 60 * time.Second
+
+// SixPlayerNight 标准6人局的第一夜：狼人都刀同一名平民，预言家查验一名狼人
+//
+// 天亮后预言家应收到查验目标属于狼人阵营，狼人应收到击杀成功，被刀的平民出局。
+// 覆盖建房、加入、准备开局、夜间技能推送、动作结算和私密的夜晚结果。
+func SixPlayerNight(addr string, logf Logf) error {
+	game, err := StartGame(addr, "night", SixPlayerRoles)
+	if err != nil {
+		return errors.Wrap(err, "start game")
+	}
+	defer game.Close()
+	logf("game started in room %s", game.RoomID)
+
+	wolves := game.ByRole(werewolf.RoleTypeWerewolf)
+	seers := game.ByRole(werewolf.RoleTypeSeer)
+	villagers := game.ByRole(werewolf.RoleTypeVillager)
+	if len(wolves) != 2 || len(seers) != 1 || len(villagers) != 2 {
+		return errors.Errorf("unexpected role assignment: %d wolves, %d seers, %d villagers", len(wolves), len(seers), len(villagers))
+	}
+	wolf, seer, victim := wolves[0], seers[0], villagers[0]
+
+	// 模拟引擎要等所有存活狼人都选了刀口才结束夜晚
+	for _, w := range wolves {
+		if _, err := w.ExpectFunc(hasSkill(protocol.ActionKill), "kill skill", nightTimeout); err != nil {
+			return err
+		}
+		if err := w.Submit(string(protocol.ActionKill), victim.PlayerID); err != nil {
+			return err
+		}
+		logf("%s chose to kill %s", w.Name, victim.Name)
+	}
+
+	if _, err := seer.ExpectFunc(hasSkill(protocol.ActionCheck), "check skill", nightTimeout); err != nil {
+		return err
+	}
+	if err := seer.Submit(string(protocol.ActionCheck), wolf.PlayerID); err != nil {
+		return err
+	}
+	logf("%s checked %s", seer.Name, wolf.Name)
+
+	checked, err := nightResult(seer)
+	if err != nil {
+		return err
+	}
+	if checked.Check == nil || checked.Check.TargetID != wolf.PlayerID || checked.Check.Camp != werewolf.CampEvil {
+		return errors.Errorf("seer night result: got %+v, want %s in the evil camp", checked.Check, wolf.PlayerID)
+	}
+
+	killed, err := nightResult(wolf)
+	if err != nil {
+		return err
+	}
+	if killed.Kill == nil || killed.Kill.TargetID != victim.PlayerID || !killed.Kill.Succeeded {
+		return errors.Errorf("wolf night result: got %+v, want a successful kill on %s", killed.Kill, victim.PlayerID)
+	}
+	logf("dawn: seer saw an evil player, the kill succeeded")
+
+	return nil
+}
+
+// nightResult 等待天亮时的私密夜晚结果
+func nightResult(c *Client) (protocol.NightResultData, error) {
+	var data protocol.NightResultData
+
+	msg, err := c.Expect(protocol.MsgNightResult, dawnTimeout)
+	if err != nil {
+		return data, err
+	}
+
+	return data, errors.Wrapf(msg.UnmarshalData(&data), "%s: decode night result", c.Name)
+}
@@ -0,0 +1,142 @@
+package gametest
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// nightTimeout 等待狼人进入可击杀阶段的超时（夜晚播报每步间隔数秒）
+const nightTimeout = 30 * time.Second
+
+// ReconnectDuringNight 狼人在夜晚断线，宽限期内凭会话令牌重连
+//
+// 重连后应收到包含同伴的角色信息、夜晚的游戏状态和可用技能，并能正常提交击杀。
+// 覆盖会话令牌、断线保留座位、重连补发状态和狼人同伴可见性。
+func ReconnectDuringNight(addr string, logf Logf) error {
+	game, err := StartGame(addr, "reconnect", SixPlayerRoles)
+	if err != nil {
+		return errors.Wrap(err, "start game")
+	}
+	defer game.Close()
+	logf("game started in room %s", game.RoomID)
+
+	wolves := game.ByRole(werewolf.RoleTypeWerewolf)
+	if len(wolves) != 2 {
+		return errors.Errorf("expected 2 werewolves, got %d", len(wolves))
+	}
+	wolf, observer := wolves[0], game.ByRole(werewolf.RoleTypeVillager)[0]
+
+	teammates := game.Started[wolf.PlayerID].Teammates
+	if len(teammates) != 1 || teammates[0] != wolves[1].PlayerID {
+		return errors.Errorf("werewolf teammates at game start: got %v, want [%s]", teammates, wolves[1].PlayerID)
+	}
+
+	if _, err := wolf.ExpectFunc(hasSkill(protocol.ActionKill), "kill skill", nightTimeout); err != nil {
+		return err
+	}
+	logf("%s can kill, disconnecting", wolf.Name)
+
+	if err := wolf.Close(); err != nil {
+		return errors.Wrap(err, "disconnect")
+	}
+
+	if _, err := observer.ExpectFunc(func(msg *protocol.Message) bool {
+		var data protocol.PlayerConnectionData
+		return msg.Type == protocol.MsgPlayerConnection &&
+			msg.UnmarshalData(&data) == nil &&
+			data.PlayerID == wolf.PlayerID && !data.IsConnected
+	}, "disconnect notice", DefaultTimeout); err != nil {
+		return err
+	}
+
+	resumed, err := Dial(addr, wolf.Name)
+	if err != nil {
+		return errors.Wrap(err, "reconnect")
+	}
+	game.Clients = append(game.Clients, resumed)
+
+	if err := resumed.Resume(wolf.SessionToken); err != nil {
+		return errors.Wrap(err, "resume session")
+	}
+	if resumed.PlayerID != wolf.PlayerID {
+		return errors.Errorf("resumed as %s, want %s", resumed.PlayerID, wolf.PlayerID)
+	}
+	logf("%s reconnected", wolf.Name)
+
+	var joined protocol.RoomJoinedData
+	if err := resumed.ExpectData(protocol.MsgRoomJoined, &joined); err != nil {
+		return err
+	}
+	if joined.RoomID != game.RoomID {
+		return errors.Errorf("resumed into room %s, want %s", joined.RoomID, game.RoomID)
+	}
+
+	var started protocol.GameStartedData
+	if err := resumed.ExpectData(protocol.MsgGameStarted, &started); err != nil {
+		return err
+	}
+	if started.RoleType != werewolf.RoleTypeWerewolf {
+		return errors.Errorf("resumed role %s, want werewolf", started.RoleType)
+	}
+	if !equalIDs(started.Teammates, teammates) {
+		return errors.Errorf("resumed teammates %v, want %v", started.Teammates, teammates)
+	}
+
+	var state protocol.GameStateData
+	if err := resumed.ExpectData(protocol.MsgGameState, &state); err != nil {
+		return err
+	}
+	if state.Phase != werewolf.PhaseNight {
+		return errors.Errorf("resumed in phase %s, want night", state.Phase)
+	}
+
+	var skills protocol.AllowedSkillsData
+	if err := resumed.ExpectData(protocol.MsgAllowedSkills, &skills); err != nil {
+		return err
+	}
+	kill, ok := findSkill(skills, protocol.ActionKill)
+	if !ok {
+		return errors.Errorf("kill not in allowed skills after resume: %+v", skills.Skills)
+	}
+
+	target := ""
+	for _, id := range kill.Targets {
+		if id != wolf.PlayerID && id != teammates[0] {
+			target = id
+			break
+		}
+	}
+	if target == "" {
+		return errors.Errorf("no non-wolf kill target in %v", kill.Targets)
+	}
+
+	if err := resumed.Submit(string(protocol.ActionKill), target); err != nil {
+		return errors.Wrap(err, "kill after resume")
+	}
+	logf("%s killed %s after reconnecting", wolf.Name, target)
+
+	return nil
+}
+
+// equalIDs 比较两组玩家ID，忽略顺序
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
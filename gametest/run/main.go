@@ -0,0 +1,63 @@
+// 端到端场景测试：先启动服务器，再运行 go run ./gametest/run -scenario <名称>；
+// 加 -embedded 时自动编译并在随机端口启动一个临时服务器，无需另外启动
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Zereker/game/gametest"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run 运行选中的场景并返回退出码，内嵌服务器在返回前关闭
+func run() int {
+	addr := flag.String("addr", "127.0.0.1:8888", "server address")
+	name := flag.String("scenario", "all", "scenario to run (all to run every scenario)")
+	embedded := flag.Bool("embedded", false, "build and start a throwaway server on a free port instead of using -addr")
+	flag.Parse()
+
+	if *embedded {
+		server, err := gametest.StartServer()
+		if err != nil {
+			fmt.Printf("start embedded server: %v\n", err)
+			return 2
+		}
+		defer server.Close()
+		*addr = server.Addr
+	}
+
+	failed := 0
+	ran := 0
+	for _, scenario := range gametest.Scenarios {
+		if *name != "all" && *name != scenario.Name {
+			continue
+		}
+		ran++
+
+		fmt.Printf("=== RUN   %s\n", scenario.Name)
+		logf := func(format string, args ...interface{}) {
+			fmt.Printf("    "+format+"\n", args...)
+		}
+
+		if err := scenario.Run(*addr, logf); err != nil {
+			fmt.Printf("--- FAIL: %s: %v\n", scenario.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("--- PASS: %s\n", scenario.Name)
+	}
+
+	if ran == 0 {
+		fmt.Printf("unknown scenario: %s\n", *name)
+		return 2
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
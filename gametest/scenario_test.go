@@ -0,0 +1,48 @@
+package gametest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// addr 所有场景共用的内嵌服务器地址
+var addr string
+
+// TestMain 编译并启动使用模拟引擎的内嵌服务器，角色按座位顺序发放，结果不依赖随机性；-short 时跳过
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	server, err := StartServer("-engine", "mock")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "start embedded server: %v\n", err)
+		os.Exit(2)
+	}
+
+	addr = server.Addr
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
+// scenario 把场景作为测试运行，场景的日志写进测试输出
+func scenario(t *testing.T, run func(addr string, logf Logf) error) {
+	if testing.Short() {
+		t.Skip("end-to-end scenario needs an embedded server")
+	}
+	if err := run(addr, t.Logf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSixPlayerNight(t *testing.T) {
+	scenario(t, SixPlayerNight)
+}
+
+func TestReconnectDuringNight(t *testing.T) {
+	scenario(t, ReconnectDuringNight)
+}
@@ -0,0 +1,150 @@
+package gametest
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// serverStartTimeout 等待内嵌服务器开始接受连接的超时
+const serverStartTimeout = 60 * time.Second
+
+// Server 为测试启动的服务器进程，监听 127.0.0.1 上的随机端口
+//
+// 服务器是 main 包，无法在进程内引用，因此先编译再作为子进程运行；
+// 账号、快照都写到临时目录，不影响开发环境。
+type Server struct {
+	Addr string
+
+	cmd    *exec.Cmd
+	dir    string
+	exited chan struct{} // 进程退出时关闭
+}
+
+// StartServer 编译并启动服务器，返回时服务器已可以接受连接；extraArgs 追加到 serve 的参数之后
+func StartServer(extraArgs ...string) (*Server, error) {
+	root, err := moduleRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "gametest-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temp dir")
+	}
+
+	binary := filepath.Join(dir, "server")
+	build := exec.Command("go", "build", "-o", binary, "./server")
+	build.Dir = root
+	if out, err := build.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrapf(err, "build server: %s", out)
+	}
+
+	addr, err := freeAddr()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	args := append([]string{"serve",
+		"-addr", addr,
+		"-account-path", filepath.Join(dir, "accounts.json"),
+		"-state-dir", "",
+		"-log-level", "warn",
+	}, extraArgs...)
+
+	s := &Server{
+		Addr:   addr,
+		cmd:    exec.Command(binary, args...),
+		dir:    dir,
+		exited: make(chan struct{}),
+	}
+	s.cmd.Stdout = os.Stderr
+	s.cmd.Stderr = os.Stderr
+
+	if err := s.cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "start server")
+	}
+	go func() {
+		s.cmd.Wait()
+		close(s.exited)
+	}()
+
+	if err := s.waitReady(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Close 停止服务器并删除临时目录
+func (s *Server) Close() error {
+	defer os.RemoveAll(s.dir)
+
+	if err := s.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		return errors.Wrap(err, "stop server")
+	}
+
+	select {
+	case <-s.exited:
+		return nil
+	case <-time.After(DefaultTimeout):
+		s.cmd.Process.Kill()
+		return errors.New("server did not stop in time, killed")
+	}
+}
+
+// waitReady 轮询直到服务器开始监听或进程退出
+func (s *Server) waitReady() error {
+	deadline := time.Now().Add(serverStartTimeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", s.Addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case <-s.exited:
+			return errors.New("server exited before accepting connections")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	return errors.Errorf("server did not listen on %s within %s", s.Addr, serverStartTimeout)
+}
+
+// freeAddr 向系统要一个空闲的本地端口
+func freeAddr() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.Wrap(err, "pick a free port")
+	}
+	defer ln.Close()
+
+	return ln.Addr().String(), nil
+}
+
+// moduleRoot 当前模块的根目录，服务器源码在其下的 server 目录
+func moduleRoot() (string, error) {
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		return "", errors.Wrap(err, "locate module")
+	}
+
+	gomod := strings.TrimSpace(string(out))
+	if gomod == "" || gomod == os.DevNull {
+		return "", errors.New("not inside the game module")
+	}
+
+	return filepath.Dir(gomod), nil
+}
@@ -0,0 +1,119 @@
+package protocol
+
+import (
+	"strings"
+
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// SkillType 动作的数字编号，供只支持枚举的客户端使用，与 ActionType 一一对应
+type SkillType int
+
+const (
+	SkillTypeNone         SkillType = iota // 未指定，使用 actionType
+	SkillTypeKill                          // kill
+	SkillTypeCheck                         // check
+	SkillTypeProtect                       // protect
+	SkillTypeAntidote                      // antidote
+	SkillTypePoison                        // poison
+	SkillTypeVote                          // vote
+	SkillTypeSpeak                         // speak
+	SkillTypeDuel                          // duel
+	SkillTypeLink                          // link
+	SkillTypeSteal                         // steal
+	SkillTypeSelfDestruct                  // self_destruct
+)
+
+// skillActions 数字编号对应的动作类型
+var skillActions = map[SkillType]werewolf.ActionType{
+	SkillTypeKill:         ActionKill,
+	SkillTypeCheck:        ActionCheck,
+	SkillTypeProtect:      ActionProtect,
+	SkillTypeAntidote:     ActionAntidote,
+	SkillTypePoison:       ActionPoison,
+	SkillTypeVote:         ActionVote,
+	SkillTypeSpeak:        ActionSpeak,
+	SkillTypeDuel:         ActionDuel,
+	SkillTypeLink:         ActionLink,
+	SkillTypeSteal:        ActionSteal,
+	SkillTypeSelfDestruct: ActionSelfDestruct,
+}
+
+// actionAliases 动作类型的别名，不区分大小写；标准名称本身也在其中
+var actionAliases = map[string]werewolf.ActionType{
+	"kill":          ActionKill,
+	"wolf_kill":     ActionKill,
+	"check":         ActionCheck,
+	"inspect":       ActionCheck,
+	"protect":       ActionProtect,
+	"guard":         ActionProtect,
+	"antidote":      ActionAntidote,
+	"save":          ActionAntidote,
+	"heal":          ActionAntidote,
+	"poison":        ActionPoison,
+	"vote":          ActionVote,
+	"exile":         ActionVote,
+	"speak":         ActionSpeak,
+	"speech":        ActionSpeak,
+	"duel":          ActionDuel,
+	"link":          ActionLink,
+	"couple":        ActionLink,
+	"steal":         ActionSteal,
+	"thief":         ActionSteal,
+	"self_destruct": ActionSelfDestruct,
+	"explode":       ActionSelfDestruct,
+}
+
+// ParseActionType 解析动作类型，接受标准名称和别名
+func ParseActionType(s string) (werewolf.ActionType, error) {
+	actionType, ok := actionAliases[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return "", errors.Errorf("unknown action type: %s", s)
+	}
+	return actionType, nil
+}
+
+// Normalize 把动作统一成标准的 actionType，并检查字段是否合法
+//
+// actionType 和 skillType 至少填一个；都填时必须指向同一个动作。
+func (d *PerformActionData) Normalize() error {
+	var fromSkill werewolf.ActionType
+	if d.SkillType != SkillTypeNone {
+		actionType, ok := skillActions[d.SkillType]
+		if !ok {
+			return errors.Errorf("unknown skill type: %d", d.SkillType)
+		}
+		fromSkill = actionType
+	}
+
+	switch {
+	case d.ActionType != "":
+		actionType, err := ParseActionType(string(d.ActionType))
+		if err != nil {
+			return err
+		}
+		if fromSkill != "" && fromSkill != actionType {
+			return errors.Errorf("skill type %d does not match action type %s", d.SkillType, d.ActionType)
+		}
+		d.ActionType = actionType
+	case fromSkill != "":
+		d.ActionType = fromSkill
+	default:
+		return errors.New("action type is required")
+	}
+
+	if d.TargetSeat < 0 || d.SecondTargetSeat < 0 {
+		return errors.Errorf("invalid seat: %d", min(d.TargetSeat, d.SecondTargetSeat))
+	}
+
+	if d.Card < 0 {
+		return errors.Errorf("invalid card: %d", d.Card)
+	}
+
+	if d.Data == nil {
+		d.Data = make(map[string]interface{})
+	}
+
+	return nil
+}
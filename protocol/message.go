@@ -2,17 +2,22 @@ package protocol
 
 import (
 	"encoding/json"
-	"time"
 
 	"github.com/Zereker/socket"
 	"github.com/pkg/errors"
 )
 
 // Message 游戏消息
+//
+// Seq 由服务器按玩家分配，严格递增，客户端据此发现漏收并请求重发；
+// ID 由客户端为请求分配，服务器用它去重，并在响应的 CorrelationID 中回填。
 type Message struct {
-	Type      MessageType     `json:"type"`
-	Data      json.RawMessage `json:"data"`
-	Timestamp int64           `json:"timestamp"`
+	Type          MessageType     `json:"type"`
+	Data          json.RawMessage `json:"data"`
+	Timestamp     int64           `json:"timestamp"`
+	Seq           uint64          `json:"seq,omitempty"`
+	ID            string          `json:"id,omitempty"`
+	CorrelationID string          `json:"correlationID,omitempty"`
 }
 
 // NewMessage 创建新消息
@@ -25,12 +30,21 @@ func NewMessage(msgType MessageType, data interface{}) (*Message, error) {
 	return &Message{
 		Type:      msgType,
 		Data:      dataBytes,
-		Timestamp: time.Now().Unix(),
+		Timestamp: Now().Unix(),
 	}, nil
 }
 
-// UnmarshalData 解析消息数据
+// ReplyTo 把消息标记为对请求 req 的响应
+func (m *Message) ReplyTo(req *Message) *Message {
+	m.CorrelationID = req.ID
+	return m
+}
+
+// UnmarshalData 解析消息数据，没有数据时按 null 处理，v 保持零值
 func (m *Message) UnmarshalData(v interface{}) error {
+	if len(m.Data) == 0 {
+		return nil
+	}
 	if err := json.Unmarshal(m.Data, v); err != nil {
 		return errors.Wrap(err, "unmarshal message data")
 	}
@@ -58,12 +72,21 @@ func NewCodec() *Codec {
 }
 
 // Decode 实现 socket.Codec 接口
+//
+// 帧内容来自不可信的连接：不是 JSON 对象（包括 null）或缺少消息类型的帧都返回错误，
+// 不会把零值消息交给处理器。
 func (c *Codec) Decode(data []byte) (socket.Message, error) {
-	var msg Message
+	var msg *Message
 	if err := json.Unmarshal(data, &msg); err != nil {
 		return nil, errors.Wrap(err, "decode message")
 	}
-	return &msg, nil
+	if msg == nil {
+		return nil, errors.New("decode message: empty frame")
+	}
+	if msg.Type == "" {
+		return nil, errors.New("decode message: missing type")
+	}
+	return msg, nil
 }
 
 // Encode 实现 socket.Codec 接口
@@ -73,11 +96,36 @@ func (c *Codec) Encode(message socket.Message) ([]byte, error) {
 
 // 辅助函数：创建各种类型的消息
 
+// NewHelloMessage 创建握手消息
+func NewHelloMessage(client string) (*Message, error) {
+	return NewMessage(MsgHello, HelloData{ProtocolVersion: ProtocolVersion, Client: client})
+}
+
 // NewLoginMessage 创建登录消息
 func NewLoginMessage(username string) (*Message, error) {
 	return NewMessage(MsgLogin, LoginData{Username: username})
 }
 
+// NewAccountLoginMessage 创建账号密码登录消息
+func NewAccountLoginMessage(username, password string) (*Message, error) {
+	return NewMessage(MsgLogin, LoginData{Username: username, Password: password})
+}
+
+// NewUpdateProfileMessage 登录后更新玩家资料，用户名不变；注册玩家的资料随账号保存
+func NewUpdateProfileMessage(username string, profile Profile) (*Message, error) {
+	return NewMessage(MsgLogin, LoginData{Username: username, Profile: &profile})
+}
+
+// NewResumeSessionMessage 创建断线重连的登录消息
+func NewResumeSessionMessage(sessionToken string) (*Message, error) {
+	return NewMessage(MsgLogin, LoginData{SessionToken: sessionToken})
+}
+
+// NewRegisterMessage 创建注册消息
+func NewRegisterMessage(username, password string) (*Message, error) {
+	return NewMessage(MsgRegister, RegisterData{Username: username, Password: password})
+}
+
 // NewCreateRoomMessage 创建房间消息
 func NewCreateRoomMessage(roomName string, roles []interface{}) (*Message, error) {
 	// roles 从 werewolf.RoleType 转换而来
@@ -87,26 +135,308 @@ func NewCreateRoomMessage(roomName string, roles []interface{}) (*Message, error
 	})
 }
 
+// NewCreateRoomWithWebhookMessage 创建房间消息，并指定对局战报投递地址
+func NewCreateRoomWithWebhookMessage(roomName string, roles []interface{}, summaryWebhook string) (*Message, error) {
+	return NewMessage(MsgCreateRoom, map[string]interface{}{
+		"roomName":       roomName,
+		"roles":          roles,
+		"summaryWebhook": summaryWebhook,
+	})
+}
+
+// NewCreateProtectedRoomMessage 创建房间消息，可设置加入密码、私密房间和战报投递地址，空值表示不设置
+func NewCreateProtectedRoomMessage(roomName string, roles []interface{}, password string, private bool, summaryWebhook string) (*Message, error) {
+	return NewCreateRoomWithOptionsMessage(roomName, roles, RoomOptions{
+		Password:       password,
+		Private:        private,
+		SummaryWebhook: summaryWebhook,
+	})
+}
+
+// RoomOptions 创建房间时的可选设置，零值表示不设置
+type RoomOptions struct {
+	Preset         string // 角色预设名，设置时忽略 roles
+	Password       string
+	Private        bool
+	Ranked         bool
+	SummaryWebhook string
+	ReadyTimeout   int    // 准备时限（秒）
+	Speed          string // 对局节奏 blitz / normal / relaxed
+	NoChatFilter   bool   // 关闭不当发言过滤
+	Anonymous      bool   // 匿名座位
+
+	Spectate *SpectateSettings // 观战设置，为空时任何人都可以直接观战
+	Rules    *GameRules        // 对局规则，为空时使用默认规则
+}
+
+// NewCreateRoomWithOptionsMessage 创建房间消息，带上可选设置
+func NewCreateRoomWithOptionsMessage(roomName string, roles []interface{}, opts RoomOptions) (*Message, error) {
+	data := map[string]interface{}{
+		"roomName": roomName,
+	}
+	if opts.Preset != "" {
+		data["preset"] = opts.Preset
+	} else {
+		data["roles"] = roles
+	}
+	if opts.Password != "" {
+		data["password"] = opts.Password
+	}
+	if opts.Private {
+		data["private"] = true
+	}
+	if opts.Ranked {
+		data["ranked"] = true
+	}
+	if opts.Anonymous {
+		data["anonymous"] = true
+	}
+	if opts.SummaryWebhook != "" {
+		data["summaryWebhook"] = opts.SummaryWebhook
+	}
+	if opts.ReadyTimeout > 0 {
+		data["readyTimeout"] = opts.ReadyTimeout
+	}
+	if opts.Speed != "" {
+		data["speed"] = opts.Speed
+	}
+	if opts.NoChatFilter {
+		data["chatFilter"] = false
+	}
+	if opts.Spectate != nil {
+		data["spectate"] = opts.Spectate
+	}
+	if opts.Rules != nil {
+		data["rules"] = opts.Rules
+	}
+
+	return NewMessage(MsgCreateRoom, data)
+}
+
+// NewValidateRoomConfigMessage 预检房间配置消息，不会创建房间
+func NewValidateRoomConfigMessage(roomName string, roles []interface{}) (*Message, error) {
+	return NewMessage(MsgValidateRoomConfig, map[string]interface{}{
+		"roomName": roomName,
+		"roles":    roles,
+	})
+}
+
 // NewJoinRoomMessage 加入房间消息
 func NewJoinRoomMessage(roomID string) (*Message, error) {
 	return NewMessage(MsgJoinRoom, JoinRoomData{RoomID: roomID})
 }
 
-// NewReadyMessage 准备消息
-func NewReadyMessage() (*Message, error) {
-	return NewMessage(MsgReady, map[string]interface{}{})
+// NewJoinProtectedRoomMessage 加入设有密码的房间
+func NewJoinProtectedRoomMessage(roomID, password string) (*Message, error) {
+	return NewMessage(MsgJoinRoom, JoinRoomData{RoomID: roomID, Password: password})
+}
+
+// NewListRoomsMessage 查询房间列表消息
+func NewListRoomsMessage() (*Message, error) {
+	return NewMessage(MsgListRooms, map[string]interface{}{})
 }
 
-// NewPerformActionMessage 执行动作消息
+// NewListPresetsMessage 查询角色预设消息
+func NewListPresetsMessage() (*Message, error) {
+	return NewMessage(MsgListPresets, map[string]interface{}{})
+}
+
+// NewGetStatsMessage 查询战绩消息，username 为空时查询自己
+func NewGetStatsMessage(username string) (*Message, error) {
+	return NewMessage(MsgGetStats, GetStatsData{Username: username})
+}
+
+// NewGetLeaderboardMessage 查询排行榜消息，by 为空时按胜场排列
+func NewGetLeaderboardMessage(by string, limit int) (*Message, error) {
+	return NewMessage(MsgGetLeaderboard, GetLeaderboardData{By: by, Limit: limit})
+}
+
+// NewReadyMessage 准备消息，ready 为 false 时取消准备
+func NewReadyMessage(ready bool) (*Message, error) {
+	return NewMessage(MsgReady, ReadyData{Ready: &ready})
+}
+
+// NewPerformActionMessage 执行动作消息，actionType 可以是别名，发送前统一成标准名称
 func NewPerformActionMessage(actionType, targetID string, data map[string]interface{}) (*Message, error) {
-	return NewMessage(MsgPerformAction, map[string]interface{}{
-		"actionType": actionType,
-		"targetID":   targetID,
-		"data":       data,
+	action, err := ParseActionType(actionType)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMessage(MsgPerformAction, PerformActionData{
+		ActionType: action,
+		TargetID:   targetID,
+		Data:       data,
 	})
 }
 
+// NewGetAllowedSkillsMessage 查询当前可用技能消息
+func NewGetAllowedSkillsMessage() (*Message, error) {
+	return NewMessage(MsgGetAllowedSkills, map[string]interface{}{})
+}
+
+// NewGetPreferencesMessage 查询偏好设置消息
+func NewGetPreferencesMessage() (*Message, error) {
+	return NewMessage(MsgGetPreferences, map[string]interface{}{})
+}
+
+// NewSetPreferencesMessage 保存偏好设置消息
+func NewSetPreferencesMessage(prefs Preferences) (*Message, error) {
+	return NewMessage(MsgSetPreferences, PreferencesData{Preferences: prefs})
+}
+
+// NewKickPlayerMessage 踢出玩家消息（仅房主）
+func NewKickPlayerMessage(playerID string) (*Message, error) {
+	return NewMessage(MsgKickPlayer, TargetPlayerData{PlayerID: playerID})
+}
+
+// NewMutePlayerMessage 屏蔽或取消屏蔽玩家的发言
+func NewMutePlayerMessage(playerID string, muted bool) (*Message, error) {
+	return NewMessage(MsgMutePlayer, MutePlayerData{PlayerID: playerID, Muted: muted})
+}
+
+// NewReportPlayerMessage 举报玩家消息
+func NewReportPlayerMessage(playerID, reason string) (*Message, error) {
+	return NewMessage(MsgReportPlayer, ReportPlayerData{PlayerID: playerID, Reason: reason})
+}
+
+// NewWhisperMessage 私聊消息
+func NewWhisperMessage(playerID, content string) (*Message, error) {
+	return NewMessage(MsgWhisper, WhisperData{PlayerID: playerID, Content: content})
+}
+
+// NewResyncStateMessage 请求完整对局状态消息
+func NewResyncStateMessage() (*Message, error) {
+	return NewMessage(MsgResyncState, map[string]interface{}{})
+}
+
+// NewRematchMessage 再来一局表态消息，optIn 为 false 时撤回同意
+func NewRematchMessage(optIn bool) (*Message, error) {
+	return NewMessage(MsgRematch, RematchData{OptIn: optIn})
+}
+
+// NewRequestPauseMessage 暂停表态消息，resume 为 true 时表态继续
+func NewRequestPauseMessage(resume bool) (*Message, error) {
+	return NewMessage(MsgRequestPause, RequestPauseData{Resume: resume})
+}
+
+// NewSpectateMessage 观战请求消息，房间没有密码时 password 为空
+func NewSpectateMessage(roomID, password string) (*Message, error) {
+	return NewMessage(MsgSpectate, SpectateData{RoomID: roomID, Password: password})
+}
+
+// NewSpectateApproveMessage 房主同意或拒绝观战请求
+func NewSpectateApproveMessage(playerID string, approve bool) (*Message, error) {
+	return NewMessage(MsgSpectateApprove, SpectateApproveData{PlayerID: playerID, Approve: approve})
+}
+
+// NewPassMessage 放弃本阶段行动的消息
+func NewPassMessage() (*Message, error) {
+	return NewMessage(MsgPass, nil)
+}
+
+// NewTransferOwnerMessage 转让房主消息（仅房主）
+func NewTransferOwnerMessage(playerID string) (*Message, error) {
+	return NewMessage(MsgTransferOwner, TargetPlayerData{PlayerID: playerID})
+}
+
+// NewCloseRoomMessage 关闭房间消息（仅房主）
+func NewCloseRoomMessage() (*Message, error) {
+	return NewMessage(MsgCloseRoom, map[string]interface{}{})
+}
+
+// NewAddBotMessage 房主添加机器人消息，count 为 0 时填满空位
+func NewAddBotMessage(count int) (*Message, error) {
+	return NewMessage(MsgAddBot, AddBotData{Count: count})
+}
+
+// NewActivityHintMessage 输入提示消息
+func NewActivityHintMessage(typing bool) (*Message, error) {
+	return NewMessage(MsgActivityHint, ActivityHintData{Typing: typing})
+}
+
+// NewLeaveRoomMessage 离开房间消息
+func NewLeaveRoomMessage() (*Message, error) {
+	return NewMessage(MsgLeaveRoom, map[string]interface{}{})
+}
+
+// NewResendMessage 请求重发序号区间 [fromSeq, toSeq] 内的消息
+func NewResendMessage(fromSeq, toSeq uint64) (*Message, error) {
+	return NewMessage(MsgResend, ResendData{FromSeq: fromSeq, ToSeq: toSeq})
+}
+
+// NewSeatActionMessage 以座位号指定目标的动作消息
+func NewSeatActionMessage(actionType string, targetSeat int, data map[string]interface{}) (*Message, error) {
+	action, err := ParseActionType(actionType)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMessage(MsgPerformAction, PerformActionData{
+		ActionType: action,
+		TargetSeat: targetSeat,
+		Data:       data,
+	})
+}
+
+// NewLinkMessage 丘比特按座位号连接两名恋人的消息
+func NewLinkMessage(firstSeat, secondSeat int) (*Message, error) {
+	return NewMessage(MsgPerformAction, PerformActionData{
+		ActionType:       ActionLink,
+		TargetSeat:       firstSeat,
+		SecondTargetSeat: secondSeat,
+	})
+}
+
+// NewStealMessage 盗贼拿第 card 张底牌（从1开始）的消息
+func NewStealMessage(card int) (*Message, error) {
+	return NewMessage(MsgPerformAction, PerformActionData{
+		ActionType: ActionSteal,
+		Card:       card,
+	})
+}
+
+// NewSheriffRunMessage 上警或退水消息
+func NewSheriffRunMessage(run bool) (*Message, error) {
+	return NewMessage(MsgSheriffRun, SheriffRunData{Run: run})
+}
+
+// NewSheriffSpeechMessage 竞选发言消息
+func NewSheriffSpeechMessage(content string) (*Message, error) {
+	return NewMessage(MsgSheriffSpeech, SheriffSpeechData{Content: content})
+}
+
+// NewSheriffVoteMessage 警长竞选投票消息
+func NewSheriffVoteMessage(targetSeat int) (*Message, error) {
+	return NewMessage(MsgSheriffVote, SheriffTargetData{TargetSeat: targetSeat})
+}
+
+// NewSheriffPassMessage 移交警徽消息，targetSeat 为 0 时撕毁警徽
+func NewSheriffPassMessage(targetSeat int) (*Message, error) {
+	return NewMessage(MsgSheriffPass, SheriffTargetData{TargetSeat: targetSeat})
+}
+
+// NewSkillResponseMessage 回答分步技能的一步，targetSeat 为 0 表示不指定目标
+func NewSkillResponseMessage(promptID string, accept bool, targetSeat int) (*Message, error) {
+	return NewMessage(MsgSkillResponse, SkillResponseData{PromptID: promptID, Accept: accept, TargetSeat: targetSeat})
+}
+
+// NewSpeakingOrderMessage 警长选择发言方向消息
+func NewSpeakingOrderMessage(clockwise bool) (*Message, error) {
+	return NewMessage(MsgSpeakingOrder, SpeakingOrderData{Clockwise: clockwise})
+}
+
+// NewPingMessage 创建心跳消息
+func NewPingMessage() (*Message, error) {
+	return NewMessage(MsgPing, PingData{SentAt: Now().UnixNano()})
+}
+
 // NewErrorMessage 错误消息
 func NewErrorMessage(message string) (*Message, error) {
 	return NewMessage(MsgError, ErrorData{Message: message})
 }
+
+// NewCodedErrorMessage 带错误码的错误消息
+func NewCodedErrorMessage(code, message string) (*Message, error) {
+	return NewMessage(MsgError, ErrorData{Message: message, Code: code})
+}
@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzDecode 任意帧都不能让解码 panic，解码成功的消息一定带类型，重新编码后能原样解回
+//
+// 种子语料在 testdata/fuzz/FuzzDecode，包括曾经让处理器 panic 的 null 帧和没有类型的帧。
+func FuzzDecode(f *testing.F) {
+	codec := NewCodec()
+
+	f.Fuzz(func(t *testing.T, frame []byte) {
+		msg, err := codec.Decode(frame)
+		if err != nil {
+			if msg != nil {
+				t.Fatalf("Decode(%q) returned a message with error %v", frame, err)
+			}
+			return
+		}
+
+		decoded, ok := msg.(*Message)
+		if !ok || decoded == nil {
+			t.Fatalf("Decode(%q) = %#v, want *Message", frame, msg)
+		}
+		if decoded.Type == "" {
+			t.Fatalf("Decode(%q) accepted a frame without type", frame)
+		}
+
+		body, err := codec.Encode(decoded)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		again, err := codec.Decode(body)
+		if err != nil {
+			t.Fatalf("Decode(Encode(%q)): %v", frame, err)
+		}
+		if !bytes.Equal(again.Body(), body) {
+			t.Fatalf("round trip changed %s into %s", body, again.Body())
+		}
+	})
+}
+
+// FuzzUnmarshalData 任意消息数据解析进各类请求数据都不能 panic，没有数据时按 null 处理
+//
+// 种子语料在 testdata/fuzz/FuzzUnmarshalData。
+func FuzzUnmarshalData(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg := &Message{Type: MsgPerformAction, Data: data}
+
+		var action PerformActionData
+		err := msg.UnmarshalData(&action)
+		if len(data) == 0 && err != nil {
+			t.Fatalf("UnmarshalData of empty data: %v", err)
+		}
+		if err == nil {
+			action.Normalize()
+		}
+
+		msg.UnmarshalData(&CreateRoomData{})
+		msg.UnmarshalData(&LoginData{})
+		msg.UnmarshalData(&JoinRoomData{})
+		msg.UnmarshalData(&ReadyData{})
+		msg.UnmarshalData(&map[string]interface{}{})
+	})
+}
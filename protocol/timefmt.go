@@ -0,0 +1,48 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 时间约定：持久化和接口输出一律使用 UTC 并带显式偏移，
+// 展示给玩家时再按其偏好时区转换。
+const (
+	TimeLayout    = time.RFC3339                 // 持久化与接口输出
+	DisplayLayout = "2006-01-02 15:04:05 -07:00" // 历史记录、战报等完整时间
+	ClockLayout   = "15:04:05"                   // 事件日志中的时刻
+)
+
+// Now 返回当前 UTC 时间，需要持久化的时间都从这里取
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Time 返回消息的发送时间（UTC）
+func (m *Message) Time() time.Time {
+	return time.Unix(m.Timestamp, 0).UTC()
+}
+
+// LoadLocation 按 IANA 时区名加载时区，空字符串表示本机时区
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unknown time zone: %s", name)
+	}
+	return loc, nil
+}
+
+// FormatTime 按时区格式化为带偏移的完整时间
+func FormatTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(DisplayLayout)
+}
+
+// FormatClock 按时区格式化为时:分:秒
+func FormatClock(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(ClockLayout)
+}
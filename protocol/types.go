@@ -1,60 +1,487 @@
 package protocol
 
-import "github.com/Zereker/werewolf"
+import (
+	"time"
+
+	"github.com/Zereker/werewolf"
+)
+
+// ProtocolVersion 当前协议版本，客户端在 HELLO 中声明自己支持的版本
+//
+// 版本 2 起 GAME_EVENT 只带事件键和参数，不再附带渲染好的文字，见 GameEventData；
+// 版本 3 起对局状态有变化时发送 GAME_STATE_DELTA，见 GameStateDeltaData。
+const ProtocolVersion = 3
 
 // MessageType 定义所有消息类型
 type MessageType string
 
 const (
 	// 客户端 -> 服务器
-	MsgLogin         MessageType = "LOGIN"
-	MsgCreateRoom    MessageType = "CREATE_ROOM"
-	MsgJoinRoom      MessageType = "JOIN_ROOM"
-	MsgReady         MessageType = "READY"
-	MsgPerformAction MessageType = "PERFORM_ACTION"
+	MsgHello              MessageType = "HELLO" // 握手，双向使用
+	MsgLogin              MessageType = "LOGIN"
+	MsgRegister           MessageType = "REGISTER"
+	MsgCreateRoom         MessageType = "CREATE_ROOM"
+	MsgJoinRoom           MessageType = "JOIN_ROOM"
+	MsgReady              MessageType = "READY"
+	MsgPerformAction      MessageType = "PERFORM_ACTION"
+	MsgGetAllowedSkills   MessageType = "GET_ALLOWED_SKILLS"
+	MsgGetPreferences     MessageType = "GET_PREFERENCES"
+	MsgSetPreferences     MessageType = "SET_PREFERENCES"
+	MsgKickPlayer         MessageType = "KICK_PLAYER"
+	MsgTransferOwner      MessageType = "TRANSFER_OWNER"
+	MsgCloseRoom          MessageType = "CLOSE_ROOM"
+	MsgLeaveRoom          MessageType = "LEAVE_ROOM"
+	MsgResend             MessageType = "RESEND"
+	MsgValidateRoomConfig MessageType = "VALIDATE_ROOM_CONFIG"
+	MsgAddBot             MessageType = "ADD_BOT"
+	MsgActivityHint       MessageType = "ACTIVITY_HINT"
+	MsgPing               MessageType = "PING" // 心跳，登录前后都可发送
+	MsgSheriffRun         MessageType = "SHERIFF_RUN"
+	MsgSheriffSpeech      MessageType = "SHERIFF_SPEECH" // 竞选发言，服务器附上发言人后广播
+	MsgSheriffVote        MessageType = "SHERIFF_VOTE"
+	MsgSheriffPass        MessageType = "SHERIFF_PASS"
+	MsgSpeakingOrder      MessageType = "SPEAKING_ORDER" // 警长选择发言方向，服务器广播当天的发言顺序
+	MsgListRooms          MessageType = "LIST_ROOMS"
+	MsgSkillResponse      MessageType = "SKILL_RESPONSE" // 回答 SKILL_PROMPT
+	MsgGetStats           MessageType = "GET_STATS"      // 查询账号战绩，不填用户名时查自己
+	MsgGetLeaderboard     MessageType = "GET_LEADERBOARD"
+	MsgListPresets        MessageType = "LIST_PRESETS"     // 查询建房可选的角色预设
+	MsgMutePlayer         MessageType = "MUTE_PLAYER"      // 屏蔽或取消屏蔽一名玩家的发言，只对自己生效
+	MsgReportPlayer       MessageType = "REPORT_PLAYER"    // 举报玩家，服务器附上最近的发言供管理员核查
+	MsgWhisper            MessageType = "WHISPER"          // 房间大厅里的私聊，双向使用，对局进行中不可用
+	MsgResyncState        MessageType = "RESYNC_STATE"     // 发现状态版本不连续时请求完整的 GAME_STATE
+	MsgRematch            MessageType = "REMATCH"          // 对局结束后表态是否再来一局
+	MsgRequestPause       MessageType = "REQUEST_PAUSE"    // 对局中表态暂停或继续
+	MsgSpectate           MessageType = "SPECTATE"         // 请求观战一个房间，房间要求房主同意时先转给房主
+	MsgSpectateApprove    MessageType = "SPECTATE_APPROVE" // 房主同意或拒绝观战请求
+	MsgPass               MessageType = "PASS"             // 放弃本阶段的行动，规则引擎不支持空目标时只能跳过发言
 
 	// 服务器 -> 客户端
-	MsgLoginSuccess  MessageType = "LOGIN_SUCCESS"
-	MsgRoomCreated   MessageType = "ROOM_CREATED"
-	MsgRoomJoined    MessageType = "ROOM_JOINED"
-	MsgPlayerJoined  MessageType = "PLAYER_JOINED"
-	MsgPlayerLeft    MessageType = "PLAYER_LEFT"
-	MsgPlayerReady   MessageType = "PLAYER_READY"
-	MsgGameStarted   MessageType = "GAME_STARTED"
-	MsgPhaseChanged  MessageType = "PHASE_CHANGED"
-	MsgGameState     MessageType = "GAME_STATE"
-	MsgGameEvent     MessageType = "GAME_EVENT"
-	MsgActionResult  MessageType = "ACTION_RESULT"
-	MsgGameEnded     MessageType = "GAME_ENDED"
-	MsgError         MessageType = "ERROR"
+	MsgLoginSuccess         MessageType = "LOGIN_SUCCESS"
+	MsgRoomCreated          MessageType = "ROOM_CREATED"
+	MsgRoomJoined           MessageType = "ROOM_JOINED"
+	MsgPlayerJoined         MessageType = "PLAYER_JOINED"
+	MsgPlayerLeft           MessageType = "PLAYER_LEFT"
+	MsgPlayerReady          MessageType = "PLAYER_READY"
+	MsgGameStarted          MessageType = "GAME_STARTED"
+	MsgRoleInfo             MessageType = "ROLE_INFO" // 身份变化后重新下发的角色信息：盗贼换牌后发给盗贼，换成狼人时也发给其他狼人
+	MsgPhaseChanged         MessageType = "PHASE_CHANGED"
+	MsgGameState            MessageType = "GAME_STATE"
+	MsgGameStateDelta       MessageType = "GAME_STATE_DELTA" // 与上一版对局状态相比的变化
+	MsgGameEvent            MessageType = "GAME_EVENT"
+	MsgActionAccepted       MessageType = "ACTION_ACCEPTED" // 动作已排队，结果随后以 ACTION_RESULT 送达
+	MsgActionResult         MessageType = "ACTION_RESULT"
+	MsgGameEnded            MessageType = "GAME_ENDED"
+	MsgGamePaused           MessageType = "GAME_PAUSED"
+	MsgAllowedSkills        MessageType = "ALLOWED_SKILLS"
+	MsgServerShutdown       MessageType = "SERVER_SHUTDOWN"
+	MsgAnnouncement         MessageType = "ANNOUNCEMENT"
+	MsgPreferences          MessageType = "PREFERENCES"
+	MsgKicked               MessageType = "KICKED"
+	MsgRoomClosed           MessageType = "ROOM_CLOSED"
+	MsgRoomStateChanged     MessageType = "ROOM_STATE_CHANGED"
+	MsgOwnerChanged         MessageType = "OWNER_CHANGED"
+	MsgRoomConfigValidation MessageType = "ROOM_CONFIG_VALIDATION"
+	MsgActivity             MessageType = "ACTIVITY"
+	MsgPong                 MessageType = "PONG"
+	MsgSheriffState         MessageType = "SHERIFF_STATE"
+	MsgYourTurnToSpeak      MessageType = "YOUR_TURN_TO_SPEAK" // 只发给轮到发言的玩家
+	MsgSpeech               MessageType = "SPEECH"
+	MsgNightResult          MessageType = "NIGHT_RESULT" // 天亮时私下发给夜里行动过的角色
+	MsgRoomList             MessageType = "ROOM_LIST"
+	MsgGameStarting         MessageType = "GAME_STARTING"    // 全员准备后的开局倒计时，取消时 cancelled 为 true
+	MsgSkillPrompt          MessageType = "SKILL_PROMPT"     // 分步技能的一步：女巫夜里先决定解药再决定毒药，狼王出局后决定是否开枪
+	MsgWolfVoteUpdate       MessageType = "WOLF_VOTE_UPDATE" // 狼人选择或改选刀口后发给所有狼人
+	MsgGameSummary          MessageType = "GAME_SUMMARY"     // 紧跟 GAME_ENDED 的完整复盘
+	MsgStats                MessageType = "STATS"
+	MsgLeaderboard          MessageType = "LEADERBOARD"
+	MsgPresetList           MessageType = "PRESET_LIST"
+	MsgActionPending        MessageType = "ACTION_PENDING"    // 夜间行动或投票已登记，阶段结束前可以改选
+	MsgRematchStatus        MessageType = "REMATCH_STATUS"    // 再来一局的表态情况，全员同意后房间回到等待状态
+	MsgPlayerConnection     MessageType = "PLAYER_CONNECTION" // 对局中有玩家断线或重连
+	MsgPauseVote            MessageType = "PAUSE_VOTE"        // 暂停或继续的表态情况，还没达到生效人数时广播
+	MsgGameResumed          MessageType = "GAME_RESUMED"      // 暂停的对局继续
+	MsgSpectateRequest      MessageType = "SPECTATE_REQUEST"  // 有人请求观战，只发给房主
+	MsgSpectateResult       MessageType = "SPECTATE_RESULT"   // 观战请求的结果，开始观战时附带房间快照
+	MsgVoteCast             MessageType = "VOTE_CAST"         // 放逐投票中有人投票或改票，隐藏投票时不带目标
+	MsgVoteResult           MessageType = "VOTE_RESULT"       // 放逐投票结束时同时公布每一票
+	MsgError                MessageType = "ERROR"
 )
 
+// HelloData 握手消息数据，服务器回复协商后的版本
+type HelloData struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	Client          string `json:"client,omitempty"` // 客户端名称与版本，仅用于日志
+}
+
 // LoginData 登录消息数据
 type LoginData struct {
 	Username string `json:"username"`
+	Password string `json:"password,omitempty"` // 为空时以游客身份登录
+
+	SessionToken string `json:"sessionToken,omitempty"` // 断线重连时携带上次登录的会话令牌，此时忽略用户名和密码
+
+	Profile *Profile `json:"profile,omitempty"` // 同时更新玩家资料，为空时沿用账号保存的资料
+}
+
+// Profile 玩家资料，显示在房间的玩家列表和复盘中，字段都是可选的
+type Profile struct {
+	Avatar string `json:"avatar,omitempty"` // 头像：一个表情符号或头像编号
+	Bio    string `json:"bio,omitempty"`    // 一句话简介
+	Color  string `json:"color,omitempty"`  // 名字的显示颜色，可选值与座位颜色相同
+}
+
+// RegisterData 注册消息数据
+type RegisterData struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 // CreateRoomData 创建房间消息数据
 type CreateRoomData struct {
-	RoomName string           `json:"roomName"`
-	Roles    []werewolf.RoleType `json:"roles"`
+	RoomName       string              `json:"roomName"`
+	Roles          []werewolf.RoleType `json:"roles"`
+	Preset         string              `json:"preset,omitempty"`         // 角色预设名，设置时不能再列出 Roles
+	SummaryWebhook string              `json:"summaryWebhook,omitempty"` // 对局战报投递地址，覆盖服务器默认值
+	GuardRules     *GuardRules         `json:"guardRules,omitempty"`     // 守卫规则，为空时使用默认规则
+	Rules          *GameRules          `json:"rules,omitempty"`          // 对局规则，为空时使用默认规则
+	Password       string              `json:"password,omitempty"`       // 加入密码，为空表示不设密码
+	Private        bool                `json:"private,omitempty"`        // 私密房间不出现在房间列表中，只能凭房间ID加入
+	Ranked         bool                `json:"ranked,omitempty"`         // 排位房间：只允许注册玩家，对局结束后调整积分
+	Anonymous      bool                `json:"anonymous,omitempty"`      // 匿名座位：开局时随机排座，对局中只以座位号称呼，结束后公布名字
+	ReadyTimeout   int                 `json:"readyTimeout,omitempty"`   // 准备时限（秒）：满员后多数人已准备时，未准备的玩家超时被移出，0 表示不限
+	Speed          string              `json:"speed,omitempty"`          // 对局节奏 blitz / normal / relaxed，为空时为 normal
+	ChatFilter     *bool               `json:"chatFilter,omitempty"`     // 是否过滤不当发言，为空时开启（服务器配置了词表才生效）
+	Spectate       *SpectateSettings   `json:"spectate,omitempty"`       // 观战设置，为空时任何人都可以直接观战
+}
+
+// SpectateSettings 房间的观战设置，零值表示允许观战、不限人数、不需要房主同意
+type SpectateSettings struct {
+	Disabled bool `json:"disabled,omitempty"` // 不允许观战
+	Max      int  `json:"max,omitempty"`      // 同时观战的人数上限，0 表示不限
+	Approval bool `json:"approval,omitempty"` // 观战需要房主同意
+}
+
+// 对局节奏，决定发言、用药、竞选等各步时限和开局倒计时的倍率
+const (
+	SpeedBlitz   = "blitz"   // 快速，时限减半
+	SpeedNormal  = "normal"  // 默认时限
+	SpeedRelaxed = "relaxed" // 宽松，时限加倍
+)
+
+// GameRules 对局规则，字符串选项为空时取默认值
+type GameRules struct {
+	WitchSelfSave      string `json:"witchSelfSave,omitempty"`    // 女巫自救：always / first_night / never
+	FirstNightReveal   string `json:"firstNightReveal,omitempty"` // 首夜死讯：cause 公布死因 / death_only 只公布死亡 / none 不公布
+	LastWords          bool   `json:"lastWords"`                  // 出局玩家发表遗言
+	GuardAntidoteKills bool   `json:"guardAntidoteKills"`         // 同守同救则死
+	WolfSelfDestruct   bool   `json:"wolfSelfDestruct"`           // 狼人白天可以自爆
+	Sheriff            bool   `json:"sheriff"`                    // 首个白天前竞选警长，警长一票计 SheriffVoteWeight 票
+	WolfKill           string `json:"wolfKill,omitempty"`         // 狼人意见不一致时的刀口：last 以最后提交为准 / majority 多数决
+	HiddenVotes        bool   `json:"hiddenVotes"`                // 放逐投票期间只公布谁已投票，投票结束时同时公布每一票
+}
+
+// 女巫自救规则
+const (
+	WitchSelfSaveAlways     = "always"
+	WitchSelfSaveFirstNight = "first_night"
+	WitchSelfSaveNever      = "never"
+)
+
+// 首夜死讯公布方式
+const (
+	RevealCause     = "cause"
+	RevealDeathOnly = "death_only"
+	RevealNone      = "none" // 天亮时不公布谁出局，玩家只能从存活名单的变化看出来
+)
+
+// 狼人刀口决定方式
+const (
+	WolfKillLast     = "last"
+	WolfKillMajority = "majority" // 平票时取其中最近被选的目标
+)
+
+// DefaultGameRules 返回默认对局规则：女巫仅首夜可自救，公布死因，没有遗言，刀口以最后提交为准
+func DefaultGameRules() GameRules {
+	return GameRules{
+		WitchSelfSave:    WitchSelfSaveFirstNight,
+		FirstNightReveal: RevealCause,
+		WolfKill:         WolfKillLast,
+	}
+}
+
+// SheriffVoteWeight 警长放逐投票的票数，服务器的规则引擎不接受弃权票时按一票计
+const SheriffVoteWeight = 1.5
+
+// 警长竞选阶段
+const (
+	SheriffStageSignup = "signup" // 报名上警
+	SheriffStageSpeech = "speech" // 候选人依次发言，可以退水
+	SheriffStageVote   = "vote"   // 警下玩家投票
+	SheriffStageDone   = "done"   // 竞选结束或警徽已移交
+	SheriffStageBadge  = "badge"  // 警长出局，等待移交警徽
+)
+
+// SheriffRunData 上警（Run 为 true）或退水
+type SheriffRunData struct {
+	Run bool `json:"run"`
+}
+
+// SheriffSpeechData 竞选发言，PlayerID 由服务器填写
+type SheriffSpeechData struct {
+	PlayerID string `json:"playerID,omitempty"`
+	Content  string `json:"content"`
+}
+
+// SheriffTargetData 警长竞选投票或移交警徽的目标，移交时不带目标表示撕毁警徽
+type SheriffTargetData struct {
+	TargetID   string `json:"targetID,omitempty"`
+	TargetSeat int    `json:"targetSeat,omitempty"`
+}
+
+// SheriffStateData 警长竞选进度和警徽归属
+type SheriffStateData struct {
+	Stage      string             `json:"stage"`
+	Candidates []string           `json:"candidates,omitempty"` // 按发言顺序
+	Speaker    string             `json:"speaker,omitempty"`    // 正在发言的候选人
+	SheriffID  string             `json:"sheriffID,omitempty"`
+	Tally      map[string]float64 `json:"tally,omitempty"` // 竞选投票结果，候选人 -> 票数
+	Message    string             `json:"message,omitempty"`
+}
+
+// SpeakingOrderData 白天发言顺序，客户端只填 Clockwise（警长选择方向）
+type SpeakingOrderData struct {
+	Clockwise bool     `json:"clockwise"` // 按座位号递增方向
+	Order     []string `json:"order,omitempty"`
+	Current   string   `json:"current,omitempty"` // 当前应发言的玩家，全部发言完为空
+}
+
+// YourTurnToSpeakData 轮到发言的提醒，TimeoutSeconds 为 0 表示不限时
+type YourTurnToSpeakData struct {
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+// SpeechData 玩家白天的发言
+type SpeechData struct {
+	PlayerID string `json:"playerID"`
+	Content  string `json:"content"`
+}
+
+// NightResultData 一名玩家前一晚的行动结果，只包含与其角色相关的字段
+type NightResultData struct {
+	Round int               `json:"round"`
+	Role  werewolf.RoleType `json:"role"`
+
+	Check   *SeerCheckResult   `json:"check,omitempty"`   // 预言家查验
+	Potions *WitchPotionResult `json:"potions,omitempty"` // 女巫用药
+	Protect string             `json:"protect,omitempty"` // 守卫守护的玩家
+	Kill    *WolfKillResult    `json:"kill,omitempty"`    // 狼人击杀
+}
+
+// SeerCheckResult 预言家查验结果
+type SeerCheckResult struct {
+	TargetID string        `json:"targetID"`
+	Camp     werewolf.Camp `json:"camp"`
+}
+
+// WitchPotionResult 女巫当晚的用药情况和剩余药水
+type WitchPotionResult struct {
+	SavedID      string `json:"savedID,omitempty"`    // 用解药救下的玩家
+	PoisonedID   string `json:"poisonedID,omitempty"` // 用毒药毒死的玩家
+	AntidoteLeft bool   `json:"antidoteLeft"`
+	PoisonLeft   bool   `json:"poisonLeft"`
+}
+
+// WolfKillResult 狼人当晚的击杀结果
+type WolfKillResult struct {
+	TargetID  string `json:"targetID"`
+	Succeeded bool   `json:"succeeded"` // 目标天亮时已出局
+}
+
+// WolfVoteUpdateData 狼人当晚的刀口选择，Target 为按本局规则当前会被击杀的玩家
+type WolfVoteUpdateData struct {
+	Round    int               `json:"round"`
+	PlayerID string            `json:"playerID"` // 刚选择或改选的狼人
+	Votes    map[string]string `json:"votes"`    // 狼人ID -> 选择的目标
+	Target   string            `json:"target"`
+	Rule     string            `json:"rule"`
+}
+
+// 分步技能的步骤
+const (
+	SkillStepAntidote = "antidote" // 是否对今晚的刀口使用解药
+	SkillStepPoison   = "poison"   // 对谁使用毒药，或不使用
+	SkillStepShoot    = "shoot"    // 狼王出局后带走谁，或不开枪
+)
+
+// SkillPromptData 分步技能的一步，回答时带上 PromptID，过期的回答会被拒绝
+type SkillPromptData struct {
+	PromptID       string   `json:"promptID"`
+	Step           string   `json:"step"`
+	Message        string   `json:"message"`
+	Victim         string   `json:"victim,omitempty"`  // 解药步骤：今晚被刀的玩家
+	Targets        []string `json:"targets,omitempty"` // 毒药和开枪步骤：可以选择的玩家
+	TimeoutSeconds int      `json:"timeoutSeconds"`    // 超时视为不使用
+}
+
+// SkillResponseData 对分步技能一步的回答，Accept 为 false 表示不使用
+type SkillResponseData struct {
+	PromptID   string `json:"promptID"`
+	Accept     bool   `json:"accept"`
+	TargetID   string `json:"targetID,omitempty"`
+	TargetSeat int    `json:"targetSeat,omitempty"`
+}
+
+// GuardRules 守卫规则
+type GuardRules struct {
+	AllowSelf   bool `json:"allowSelf"`   // 可以守护自己
+	AllowRepeat bool `json:"allowRepeat"` // 可以连续两晚守护同一名玩家
+}
+
+// DefaultGuardRules 返回默认守卫规则：可以自守，不能连守
+func DefaultGuardRules() GuardRules {
+	return GuardRules{AllowSelf: true}
+}
+
+// RoomConfigValidationData 房间配置预检结果，Errors 非空时无法用该配置建房
+type RoomConfigValidationData struct {
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // JoinRoomData 加入房间消息数据
 type JoinRoomData struct {
-	RoomID string `json:"roomID"`
+	RoomID   string `json:"roomID"`
+	Password string `json:"password,omitempty"` // 房间设有密码时必填
+}
+
+// RolePreset 建房可选的角色预设
+type RolePreset struct {
+	Name        string              `json:"name"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Roles       []werewolf.RoleType `json:"roles"`
+}
+
+// PresetListData 角色预设列表
+type PresetListData struct {
+	Presets []RolePreset `json:"presets"`
+}
+
+// RoomListData 房间列表，不含私密房间
+type RoomListData struct {
+	Rooms []RoomListing `json:"rooms"`
+}
+
+// RoomListing 房间列表中的一项
+type RoomListing struct {
+	RoomID      string `json:"roomID"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Players     int    `json:"players"`
+	Capacity    int    `json:"capacity"`
+	HasPassword bool   `json:"hasPassword,omitempty"`
+	Ranked      bool   `json:"ranked,omitempty"`
+	Anonymous   bool   `json:"anonymous,omitempty"`
+	Speed       string `json:"speed"` // 对局节奏，玩家据此挑选合适的房间
+
+	// 阵营配比：狼人（含狼王）、神职和平民各有几张牌，有盗贼时包括两张底牌
+	Wolves    int `json:"wolves"`
+	Specials  int `json:"specials"`
+	Villagers int `json:"villagers"`
 }
 
-// PerformActionData 执行动作消息数据
+// PerformActionData 执行动作消息数据，服务器处理前调用 Normalize 统一成标准动作类型
 type PerformActionData struct {
-	ActionType werewolf.ActionType `json:"actionType"`
-	TargetID   string              `json:"targetID,omitempty"`
+	ActionType werewolf.ActionType    `json:"actionType,omitempty"` // 标准名称或别名，见 ParseActionType
+	SkillType  SkillType              `json:"skillType,omitempty"`  // 动作的数字编号，可代替 actionType
+	TargetID   string                 `json:"targetID,omitempty"`
+	TargetSeat int                    `json:"targetSeat,omitempty"` // 目标座位号（从1开始），服务器按座位表解析为玩家ID
 	Data       map[string]interface{} `json:"data,omitempty"`
+
+	// 需要两个目标的动作（丘比特连接恋人）的第二个目标，服务器解析后放进 Data 的 secondTargetID
+	SecondTargetID   string `json:"secondTargetID,omitempty"`
+	SecondTargetSeat int    `json:"secondTargetSeat,omitempty"`
+
+	// 盗贼拿的底牌编号（从1开始，对应 GameStartedData.ExtraRoles 的顺序），服务器放进 Data 的 card
+	Card int `json:"card,omitempty"`
+}
+
+// 客户端发送的动作类型
+const (
+	ActionKill         werewolf.ActionType = "kill"
+	ActionCheck        werewolf.ActionType = "check"
+	ActionProtect      werewolf.ActionType = "protect"
+	ActionAntidote     werewolf.ActionType = "antidote"
+	ActionPoison       werewolf.ActionType = "poison"
+	ActionVote         werewolf.ActionType = "vote"
+	ActionSpeak        werewolf.ActionType = "speak"
+	ActionDuel         werewolf.ActionType = "duel"          // 骑士白天决斗，由房间结算
+	ActionLink         werewolf.ActionType = "link"          // 丘比特第一夜连接两名恋人，由房间结算
+	ActionSteal        werewolf.ActionType = "steal"         // 盗贼第一夜从两张底牌中拿一张，由房间结算
+	ActionSelfDestruct werewolf.ActionType = "self_destruct" // 狼人白天自爆，结束当天发言和投票，由房间结算
+)
+
+// 引擎之外由房间实现的角色，引擎按对应的基础角色（平民或狼人）结算夜晚和投票
+const (
+	RoleTypeIdiot    werewolf.RoleType = "idiot"     // 白痴：被放逐时翻牌免死一次，之后不能投票
+	RoleTypeKnight   werewolf.RoleType = "knight"    // 骑士：白天可以与一名玩家决斗一次
+	RoleTypeWolfKing werewolf.RoleType = "wolf_king" // 狼王：出局时可以开枪带走一名玩家，被毒杀时不能开枪
+	RoleTypeCupid    werewolf.RoleType = "cupid"     // 丘比特：第一夜连接两名玩家成为恋人，一方出局另一方殉情
+	RoleTypeThief    werewolf.RoleType = "thief"     // 盗贼：开局多出两张底牌，第一夜从中拿一张换掉自己的身份
+)
+
+// CampLovers 第三方恋人阵营：恋人分属好人和狼人时，只剩恋人（和丘比特）存活即获胜
+const CampLovers werewolf.Camp = "lovers"
+
+// 房间结算角色技能时产生的事件
+const (
+	EventIdiotRevealed    werewolf.EventType = "idiot_revealed"
+	EventKnightDuel       werewolf.EventType = "knight_duel"
+	EventWolfKingShot     werewolf.EventType = "wolf_king_shot"
+	EventLoversLinked     werewolf.EventType = "lovers_linked" // 只发给两名恋人
+	EventLoverSuicide     werewolf.EventType = "lover_suicide"
+	EventThiefSwapped     werewolf.EventType = "thief_swapped" // 只记入对局日志，不公开
+	EventPlayerAFK        werewolf.EventType = "player_afk"    // 玩家被标为挂机或从挂机中恢复
+	EventWolfSelfDestruct werewolf.EventType = "wolf_self_destruct"
+)
+
+// EventDawn 天亮时房间按死讯公布方式播报昨晚的结果
+const EventDawn werewolf.EventType = "dawn"
+
+// SkillInfo 当前可用的技能及其合法目标
+type SkillInfo struct {
+	ActionType  werewolf.ActionType `json:"actionType"`
+	NeedsTarget bool                `json:"needsTarget"`
+	Targets     []string            `json:"targets,omitempty"` // 合法目标玩家ID，已按规则排除不能选的人（如守卫不能连守、女巫不能自救时没有解药）
+}
+
+// AllowedSkillsData 可用技能消息数据
+type AllowedSkillsData struct {
+	Phase  werewolf.PhaseType `json:"phase"`
+	Round  int                `json:"round"`
+	Skills []SkillInfo        `json:"skills"`
+
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"` // 有时限的一步（轮到发言、盗贼选牌）剩余的秒数，0 表示不限时
 }
 
 // LoginSuccessData 登录成功消息数据
 type LoginSuccessData struct {
 	PlayerID string `json:"playerID"`
+	Username string `json:"username"`
+	IsGuest  bool   `json:"isGuest"`
+
+	SessionToken string `json:"sessionToken,omitempty"` // 会话令牌，断线后在宽限期内凭此重连
+
+	Profile *Profile `json:"profile,omitempty"` // 当前的玩家资料
+}
+
+// GameStartingData 开局倒计时
+type GameStartingData struct {
+	Seconds   int    `json:"seconds,omitempty"` // 距离开局的秒数
+	Cancelled bool   `json:"cancelled,omitempty"`
+	Reason    string `json:"reason,omitempty"` // 取消原因
 }
 
 // RoomCreatedData 房间创建成功消息数据
@@ -65,9 +492,110 @@ type RoomCreatedData struct {
 // RoomJoinedData 加入房间成功消息数据
 type RoomJoinedData struct {
 	RoomID  string       `json:"roomID"`
+	OwnerID string       `json:"ownerID"`
 	Players []PlayerInfo `json:"players"`
 }
 
+// TargetPlayerData 针对某个玩家的房主操作（踢人、转让房主）
+type TargetPlayerData struct {
+	PlayerID string `json:"playerID"`
+}
+
+// MutePlayerData 屏蔽玩家消息数据，座位号和玩家ID任填其一
+type MutePlayerData struct {
+	PlayerID   string `json:"playerID,omitempty"`
+	TargetSeat int    `json:"targetSeat,omitempty"`
+	Muted      bool   `json:"muted"` // false 表示取消屏蔽
+}
+
+// ReportPlayerData 举报玩家消息数据，座位号和玩家ID任填其一
+type ReportPlayerData struct {
+	PlayerID   string `json:"playerID,omitempty"`
+	TargetSeat int    `json:"targetSeat,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// WhisperData 私聊消息数据：发送时填目标（座位号和玩家ID任填其一），
+// 服务器填上 FromID 后转发给目标，并原样回给发送者作为确认
+type WhisperData struct {
+	PlayerID   string `json:"playerID,omitempty"`
+	TargetSeat int    `json:"targetSeat,omitempty"`
+	FromID     string `json:"fromID,omitempty"`
+	Content    string `json:"content"`
+}
+
+// RematchData 再来一局的表态，OptIn 为 false 表示撤回同意
+type RematchData struct {
+	OptIn bool `json:"optIn"`
+}
+
+// RematchStatusData 再来一局的表态情况：Needed 为需要同意的真人玩家数（机器人总是同意）；
+// Reset 为 true 时房间已按原班人马和原角色配置回到等待状态，Players 是新的大厅名单
+type RematchStatusData struct {
+	OptedIn []string     `json:"optedIn"`
+	Needed  int          `json:"needed"`
+	Reset   bool         `json:"reset,omitempty"`
+	Players []PlayerInfo `json:"players,omitempty"`
+}
+
+// KickedData 被踢出房间消息数据
+type KickedData struct {
+	RoomID string `json:"roomID"`
+	Reason string `json:"reason,omitempty"` // 为空表示被房主踢出，ready_timeout 表示超时未准备被移出
+}
+
+// RoomClosedData 房间关闭消息数据
+type RoomClosedData struct {
+	RoomID string `json:"roomID"`
+}
+
+// RoomStateChangedData 房间状态变化消息数据，State 为 WAITING / STARTING / PLAYING / PAUSED / FINISHED / ARCHIVED
+//
+// 重连时补发的当前状态没有 From。
+type RoomStateChangedData struct {
+	RoomID string `json:"roomID"`
+	From   string `json:"from,omitempty"`
+	State  string `json:"state"`
+}
+
+// OwnerChangedData 房主变更消息数据
+type OwnerChangedData struct {
+	OwnerID string `json:"ownerID"`
+}
+
+// PlayerConnectionData 对局中玩家断线或重连
+type PlayerConnectionData struct {
+	PlayerID     string `json:"playerID"`
+	IsConnected  bool   `json:"isConnected"`
+	GraceSeconds int    `json:"graceSeconds,omitempty"` // 断线时等待重连的宽限期，超时按离开处理
+}
+
+// AddBotData 房主添加机器人，Count 为 0 时填满所有空位
+type AddBotData struct {
+	Count int `json:"count,omitempty"`
+}
+
+// ActivityHintData 玩家输入提示，客户端应限流发送（建议每 3 秒最多一次）
+type ActivityHintData struct {
+	Typing bool `json:"typing"`
+}
+
+// ActivityData 白天正在输入发言的人数，不包含是谁
+type ActivityData struct {
+	Typing int `json:"typing"`
+}
+
+// PingData 心跳数据，服务器在 PONG 中原样带回，客户端据此计算往返延迟
+type PingData struct {
+	SentAt int64 `json:"sentAt"` // 客户端发送时间（Unix 纳秒）
+}
+
+// ResendData 重发请求数据，区间两端都包含
+type ResendData struct {
+	FromSeq uint64 `json:"fromSeq"`
+	ToSeq   uint64 `json:"toSeq"`
+}
+
 // PlayerJoinedData 玩家加入消息数据
 type PlayerJoinedData struct {
 	Player PlayerInfo `json:"player"`
@@ -75,13 +603,23 @@ type PlayerJoinedData struct {
 
 // PlayerLeftData 玩家离开消息数据
 type PlayerLeftData struct {
-	PlayerID string `json:"playerID"`
+	PlayerID  string `json:"playerID"`
+	Abandoned bool   `json:"abandoned,omitempty"` // 对局中离开，保留座位并视为出局
+}
+
+// ReadyData 准备消息数据，Ready 为期望的准备状态
+//
+// 发送与当前相同的状态不改变任何东西，重发或重复送达的消息不会把玩家变回未准备；
+// 不带 ready 的旧客户端消息按切换处理。
+type ReadyData struct {
+	Ready *bool `json:"ready,omitempty"`
 }
 
 // PlayerReadyData 玩家准备消息数据
 type PlayerReadyData struct {
-	PlayerID string `json:"playerID"`
-	IsReady  bool   `json:"isReady"`
+	PlayerID     string `json:"playerID"`
+	IsReady      bool   `json:"isReady"`
+	ReadyTimeout int    `json:"readyTimeout,omitempty"` // 房间设置了准备时限且正在计时时，距离移出未准备玩家的秒数
 }
 
 // GameStartedData 游戏开始消息数据
@@ -89,6 +627,20 @@ type GameStartedData struct {
 	RoleType werewolf.RoleType `json:"roleType"`
 	Camp     werewolf.Camp     `json:"camp"`
 	Players  []PlayerInfo      `json:"players"`
+
+	Teammates  []string    `json:"teammates,omitempty"`  // 狼人可见的同伴玩家ID（不含自己）
+	GuardRules *GuardRules `json:"guardRules,omitempty"` // 只发给守卫的本局守护规则
+	Rules      *GameRules  `json:"rules,omitempty"`      // 本局对局规则，所有玩家可见
+
+	ExtraRoles []werewolf.RoleType `json:"extraRoles,omitempty"` // 只发给还没选牌的盗贼的两张底牌
+}
+
+// RoleInfoData 身份变化后的角色信息，字段含义与 GameStartedData 相同
+type RoleInfoData struct {
+	RoleType   werewolf.RoleType `json:"roleType"`
+	Camp       werewolf.Camp     `json:"camp"`
+	Teammates  []string          `json:"teammates,omitempty"`
+	GuardRules *GuardRules       `json:"guardRules,omitempty"`
 }
 
 // PhaseChangedData 阶段变化消息数据
@@ -104,15 +656,86 @@ type GameStateData struct {
 	Players      []PlayerInfo       `json:"players"`
 	AlivePlayers []string           `json:"alivePlayers"`
 	IsEnded      bool               `json:"isEnded"`
+	Version      uint64             `json:"version,omitempty"` // 状态版本，之后的 GAME_STATE_DELTA 以它为基础
+
+	// 只有收件人自己能看到的信息，每名玩家的 GAME_STATE 不同
+	Teammates  []string                 `json:"teammates,omitempty"`  // 狼人可见的同伴玩家ID（不含自己）
+	KnownCamps map[string]werewolf.Camp `json:"knownCamps,omitempty"` // 预言家已在天亮时得知的查验结果
+	Lover      string                   `json:"lover,omitempty"`      // 被丘比特连接后自己的恋人
+}
+
+// GameStateDeltaData 对局状态的增量
+//
+// 只在 BaseVersion 等于客户端当前的状态版本时应用，否则说明漏收了更新，应发送 RESYNC_STATE。
+// Players 是有变化的玩家的完整信息，按玩家ID替换；Died 和 Ready 是其中新出局和准备状态变化的玩家，
+// 方便直接提示。没有变化的字段省略；Teammates、KnownCamps 和 Lover 与 GameStateData 相同，
+// 每次都给出收件人完整的私有信息。
+type GameStateDeltaData struct {
+	Version      uint64             `json:"version"`
+	BaseVersion  uint64             `json:"baseVersion"`
+	Phase        werewolf.PhaseType `json:"phase,omitempty"`
+	Round        int                `json:"round,omitempty"`
+	Players      []PlayerInfo       `json:"players,omitempty"`
+	Died         []string           `json:"died,omitempty"`
+	Ready        map[string]bool    `json:"ready,omitempty"`
+	AlivePlayers []string           `json:"alivePlayers,omitempty"` // 有变化时给出完整列表
+	IsEnded      bool               `json:"isEnded,omitempty"`
+
+	Teammates  []string                 `json:"teammates,omitempty"`
+	KnownCamps map[string]werewolf.Camp `json:"knownCamps,omitempty"`
+	Lover      string                   `json:"lover,omitempty"`
 }
 
 // GameEventData 游戏事件消息数据
+//
+// 客户端按 Event 和 Params 渲染成自己的语言；Message 只发给协议版本 2 之前的客户端。
 type GameEventData struct {
-	EventType werewolf.EventType `json:"eventType"`
-	Message   string             `json:"message"`
+	EventType werewolf.EventType     `json:"eventType"`
+	Event     EventKey               `json:"event,omitempty"`
+	Params    map[string]string      `json:"params,omitempty"`
+	Message   string                 `json:"message,omitempty"`
 	Data      map[string]interface{} `json:"data,omitempty"`
 }
 
+// EventKey 游戏事件的文字键，参数里的玩家都以玩家ID给出
+type EventKey string
+
+// 游戏事件文字键，注释列出各自的参数
+const (
+	EventKeyPlayerDied       EventKey = "PLAYER_DIED"        // victim, cause（首夜只公布死讯时没有 cause）
+	EventKeyIdiotRevealed    EventKey = "IDIOT_REVEALED"     // player
+	EventKeyKnightDuel       EventKey = "KNIGHT_DUEL"        // knight, target, loser
+	EventKeyWolfKingShot     EventKey = "WOLF_KING_SHOT"     // shooter, victim
+	EventKeyLoversLinked     EventKey = "LOVERS_LINKED"      // partner，只发给两名恋人
+	EventKeyLoverSuicide     EventKey = "LOVER_SUICIDE"      // victim, partner（先出局的恋人）
+	EventKeyWolfSelfDestruct EventKey = "WOLF_SELF_DESTRUCT" // player
+	EventKeyDawnPeaceful     EventKey = "DAWN_PEACEFUL"      // round
+	EventKeyDawnDeaths       EventKey = "DAWN_DEATHS"        // round, victims（逗号分隔）
+	EventKeyDawnSilent       EventKey = "DAWN_SILENT"        // round，不公布死讯
+	EventKeyPlayerAFK        EventKey = "PLAYER_AFK"         // player, missed（连续错过的限时行动次数）
+	EventKeyPlayerBack       EventKey = "PLAYER_BACK"        // player
+)
+
+// 玩家出局的原因，PLAYER_DIED 事件的 cause 参数
+const (
+	CauseWolfKill     = "WOLF_KILL"
+	CausePoison       = "POISON"
+	CauseExile        = "EXILE"
+	CauseKnightDuel   = "KNIGHT_DUEL"
+	CauseWolfKingShot = "WOLF_KING_SHOT"
+	CauseOther        = "OTHER" // 引擎结算的其他出局，如猎人开枪
+)
+
+// ActionPendingData 当前登记的选择，阶段结束时按最后一次登记的选择结算
+type ActionPendingData struct {
+	Round            int                 `json:"round"`
+	Phase            werewolf.PhaseType  `json:"phase"`
+	ActionType       werewolf.ActionType `json:"actionType"`
+	TargetID         string              `json:"targetID"`
+	TargetSeat       int                 `json:"targetSeat,omitempty"`
+	PreviousTargetID string              `json:"previousTargetID,omitempty"` // 改选前的目标，首次登记时为空
+}
+
 // ActionResultData 动作结果消息数据
 type ActionResultData struct {
 	Success bool                   `json:"success"`
@@ -124,18 +747,286 @@ type ActionResultData struct {
 type GameEndedData struct {
 	Winner  werewolf.Camp `json:"winner"`
 	Players []PlayerInfo  `json:"players"`
+	Reason  string        `json:"reason,omitempty"` // 非正常结束（如引擎错误判平局）时的原因
+}
+
+// GameEventRecord 房间记录的一条对局事件
+type GameEventRecord struct {
+	Round     int                    `json:"round"`
+	Phase     werewolf.PhaseType     `json:"phase"`
+	EventType werewolf.EventType     `json:"eventType"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Time      time.Time              `json:"time"`
+}
+
+// GameSummaryData 对局结束后的复盘：所有人的身份、每晚的行动、每轮放逐投票和玩家表现
+type GameSummaryData struct {
+	Winner  werewolf.Camp     `json:"winner"`
+	Reason  string            `json:"reason,omitempty"`
+	Rounds  int               `json:"rounds"`
+	Players []PlayerInfo      `json:"players"` // 带身份
+	Nights  []NightTimeline   `json:"nights"`
+	Votes   []VoteRound       `json:"votes"`
+	Stats   []PlayerStats     `json:"stats"`
+	MVP     string            `json:"mvp,omitempty"`     // 胜方得分最高的玩家
+	Ratings map[string]int    `json:"ratings,omitempty"` // 排位房间中各玩家的积分变化
+	Events  []GameEventRecord `json:"events"`
+}
+
+// NightTimeline 一晚的行动，没有发生的项为空
+type NightTimeline struct {
+	Round       int           `json:"round"`
+	Killed      string        `json:"killed,omitempty"` // 狼人最终的刀口
+	Saved       string        `json:"saved,omitempty"`
+	Poisoned    string        `json:"poisoned,omitempty"`
+	Protected   string        `json:"protected,omitempty"`
+	Checked     string        `json:"checked,omitempty"`
+	CheckedCamp werewolf.Camp `json:"checkedCamp,omitempty"`
+	Deaths      []string      `json:"deaths,omitempty"` // 天亮时公布出局的玩家
+}
+
+// VoteRound 一轮放逐投票，同一玩家多次投票时以最后一票为准
+type VoteRound struct {
+	Round   int               `json:"round"`
+	Ballots map[string]string `json:"ballots"` // 投票人 -> 投给谁
+	Exiled  string            `json:"exiled,omitempty"`
 }
 
+// VoteCastData 放逐投票中的一票；隐藏投票时没有 TargetID，也不通知改票
+type VoteCastData struct {
+	Round    int    `json:"round"`
+	VoterID  string `json:"voterID"`
+	TargetID string `json:"targetID,omitempty"` // 为空时是弃权，或隐藏投票不公布目标
+	Changed  bool   `json:"changed,omitempty"`  // 改票
+	Hidden   bool   `json:"hidden,omitempty"`   // 隐藏投票，只公布已投票
+}
+
+// VoteResultData 放逐投票结束时公布的全部投票，每人以最后一票为准
+type VoteResultData struct {
+	Round   int               `json:"round"`
+	Ballots map[string]string `json:"ballots"` // 投票人 -> 投给谁，空字符串表示弃权
+}
+
+// PlayerStats 一名玩家本局的表现，Score 用于评选 MVP
+type PlayerStats struct {
+	PlayerID      string `json:"playerID"`
+	Survived      bool   `json:"survived"`
+	AccurateVotes int    `json:"accurateVotes"` // 投给对方阵营的放逐票数
+	NightHits     int    `json:"nightHits"`     // 查到狼人、毒到狼人、守中刀口或刀人成功的次数
+	Score         int    `json:"score"`
+}
+
+// 游戏暂停/中止原因
+const (
+	ReasonEngineError       = "engine_error"
+	ReasonPlayerLeft        = "player_left"        // 玩家中途离开导致一方阵营无人
+	ReasonRoleSkill         = "role_skill"         // 骑士决斗或狼王开枪导致一方阵营无人
+	ReasonLovers            = "lovers"             // 只剩分属两个阵营的恋人（和丘比特）存活
+	ReasonAwaitingReconnect = "awaiting_reconnect" // 服务器重启后等待玩家重新连接
+	ReasonReadyTimeout      = "ready_timeout"      // 超过房间的准备时限仍未准备，被移出房间
+	ReasonPlayerRequest     = "player_request"     // 过半在线的真人玩家同意暂停或继续
+	ReasonOperator          = "operator"           // 运维通过管理接口继续或中止对局
+	ReasonProfanity         = "profanity"          // 不当发言累计达到上限，被移出房间
+)
+
+// GamePausedData 游戏暂停消息数据，玩家发起的暂停带上发起人
+type GamePausedData struct {
+	Reason   string `json:"reason"`
+	Message  string `json:"message,omitempty"`
+	PlayerID string `json:"playerID,omitempty"`
+}
+
+// GameResumedData 对局继续消息数据，玩家发起的继续带上最后一个同意的玩家
+type GameResumedData struct {
+	Reason   string `json:"reason"`
+	PlayerID string `json:"playerID,omitempty"`
+}
+
+// RequestPauseData 暂停或继续的表态，Resume 为 true 表示同意继续
+type RequestPauseData struct {
+	Resume bool `json:"resume,omitempty"`
+}
+
+// PauseVoteData 暂停或继续的表态情况：Needed 为生效需要的人数（仍在场且在线的真人过半），
+// 继续时暂停的发起人一人同意即可
+type PauseVoteData struct {
+	Resume bool     `json:"resume,omitempty"`
+	Agreed []string `json:"agreed"`
+	Needed int      `json:"needed"`
+}
+
+// SpectateData 观战请求，房间设有密码时需要提供
+type SpectateData struct {
+	RoomID   string `json:"roomID"`
+	Password string `json:"password,omitempty"`
+}
+
+// SpectateRequestData 转给房主的观战请求
+type SpectateRequestData struct {
+	RoomID   string `json:"roomID"`
+	PlayerID string `json:"playerID"`
+	Username string `json:"username"`
+}
+
+// SpectateApproveData 房主对观战请求的答复
+type SpectateApproveData struct {
+	PlayerID string `json:"playerID"`
+	Approve  bool   `json:"approve,omitempty"`
+}
+
+// SpectateResultData 观战请求的结果；Status 为 watching 时附带房间快照，不含任何玩家的角色，
+// 之后与其他观众一样收到房间的公开广播，直到离开房间或房间关闭
+type SpectateResultData struct {
+	RoomID       string             `json:"roomID"`
+	Status       string             `json:"status"`
+	Name         string             `json:"name,omitempty"`
+	Players      []PlayerInfo       `json:"players,omitempty"`
+	Phase        werewolf.PhaseType `json:"phase,omitempty"`
+	Round        int                `json:"round,omitempty"`
+	AlivePlayers []string           `json:"alivePlayers,omitempty"`
+}
+
+// 观战请求的状态
+const (
+	SpectatePending  = "pending"  // 等待房主同意
+	SpectateWatching = "watching" // 已开始观战
+	SpectateDenied   = "denied"   // 房主拒绝
+)
+
 // ErrorData 错误消息数据
 type ErrorData struct {
+	Message string   `json:"message"`
+	Code    string   `json:"code,omitempty"`    // 可供客户端区分处理的错误码，普通错误为空
+	Details []string `json:"details,omitempty"` // 错误码为 invalid_room_config 时逐条列出不符合规则的地方
+}
+
+// 错误码，服务器达到容量上限时使用
+const (
+	ErrorCodeServerFull         = "server_full"          // 在线玩家数已达上限
+	ErrorCodeTooManyRooms       = "too_many_rooms"       // 房间数已达上限
+	ErrorCodePlayerRoomLimit    = "player_room_limit"    // 该玩家创建的房间数已达上限
+	ErrorCodeTooManyConnections = "too_many_connections" // 同一 IP 的连接数已达上限
+)
+
+// ErrorCodeRateLimited 发送过于频繁，稍后再试
+const ErrorCodeRateLimited = "rate_limited"
+
+// ErrorCodeSpectatorsFull 房间的观战人数已达上限
+const ErrorCodeSpectatorsFull = "spectators_full"
+
+// ErrorCodeInvalidRoomConfig 角色配置或房间选项不符合服务器规则，details 逐条列出原因
+const ErrorCodeInvalidRoomConfig = "invalid_room_config"
+
+// 错误码，用户名不可用时使用，客户端据此提示玩家换一个名字
+const (
+	ErrorCodeInvalidUsername = "invalid_username" // 用户名长度或字符不符合要求
+	ErrorCodeUsernameTaken   = "username_taken"   // 用户名已被其他玩家使用
+)
+
+// AnnouncementData 主持人播报消息数据
+type AnnouncementData struct {
 	Message string `json:"message"`
+	Private bool   `json:"private,omitempty"` // 只发给当前行动角色的提示
+}
+
+// ServerShutdownData 服务器关闭消息数据
+type ServerShutdownData struct {
+	Message   string `json:"message"`
+	CanResume bool   `json:"canResume"` // 房间已保存，重启后重新登录即可继续
+}
+
+// Preferences 玩家偏好设置（注册账号的偏好保存在服务器，跨设备生效）
+type Preferences struct {
+	Language      string                  `json:"language,omitempty"` // zh-CN / en-US
+	AutoReady     bool                    `json:"autoReady"`          // 加入房间后自动准备
+	Notifications NotificationPreferences `json:"notifications"`
+	SeatColor     string                  `json:"seatColor,omitempty"` // 自己座位的显示颜色
+	TimeZone      string                  `json:"timeZone,omitempty"`  // 显示时间用的 IANA 时区，为空时用本机时区
+}
+
+// NotificationPreferences 通知订阅
+type NotificationPreferences struct {
+	GameStart   bool `json:"gameStart"`   // 游戏开始
+	YourTurn    bool `json:"yourTurn"`    // 轮到自己行动
+	PlayerJoins bool `json:"playerJoins"` // 有玩家加入房间
+}
+
+// DefaultPreferences 返回默认偏好
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Language: "zh-CN",
+		Notifications: NotificationPreferences{
+			GameStart:   true,
+			YourTurn:    true,
+			PlayerJoins: true,
+		},
+	}
+}
+
+// GetStatsData 查询战绩请求数据
+type GetStatsData struct {
+	Username string `json:"username,omitempty"`
+}
+
+// AccountStats 账号的累计战绩，只统计注册账号正常结束的对局
+type AccountStats struct {
+	Username    string                    `json:"username,omitempty"`
+	GamesPlayed int                       `json:"gamesPlayed"`
+	Wins        int                       `json:"wins"`
+	Survived    int                       `json:"survived"`             // 活到对局结束的局数
+	Rating      int                       `json:"rating,omitempty"`     // 排位积分，没打过排位时为 0，显示为初始积分
+	RatedGames  int                       `json:"ratedGames,omitempty"` // 排位局数
+	WinsByCamp  map[werewolf.Camp]int     `json:"winsByCamp,omitempty"`
+	Roles       map[werewolf.RoleType]int `json:"roles,omitempty"` // 各角色玩过的局数
+}
+
+// StatsData 战绩消息数据
+type StatsData struct {
+	Stats AccountStats `json:"stats"`
+}
+
+// GetLeaderboardData 查询排行榜请求数据，Limit 为 0 时取服务器默认条数
+type GetLeaderboardData struct {
+	By    string `json:"by,omitempty"` // wins 按胜场（默认）/ rating 按排位积分
+	Limit int    `json:"limit,omitempty"`
+}
+
+// 排行榜排序方式
+const (
+	LeaderboardByWins   = "wins"
+	LeaderboardByRating = "rating"
+)
+
+// InitialRating 没打过排位的玩家的积分
+const InitialRating = 1500
+
+// LeaderboardData 排行榜消息数据，按名次排列
+type LeaderboardData struct {
+	By      string         `json:"by"`
+	Entries []AccountStats `json:"entries"`
+}
+
+// PreferencesData 偏好设置消息数据（SET_PREFERENCES 请求与 PREFERENCES 响应共用）
+type PreferencesData struct {
+	Preferences Preferences `json:"preferences"`
 }
 
 // PlayerInfo 玩家信息
+//
+// 匿名座位的房间在对局进行中不发送 Username、Rating 和 Profile，玩家只以座位号称呼，对局结束后恢复。
 type PlayerInfo struct {
-	ID       string            `json:"id"`
-	Username string            `json:"username"`
-	IsAlive  bool              `json:"isAlive"`
-	IsReady  bool              `json:"isReady"`
-	RoleType werewolf.RoleType `json:"roleType,omitempty"` // 只在特定情况下发送
+	ID          string            `json:"id"`
+	Seat        int               `json:"seat"` // 座位号，从1开始
+	Username    string            `json:"username"`
+	IsAlive     bool              `json:"isAlive"`
+	IsReady     bool              `json:"isReady"`
+	IsBot       bool              `json:"isBot,omitempty"`
+	IsOwner     bool              `json:"isOwner,omitempty"` // 房主，房主离开或断线后移交给待得最久的玩家
+	IsConnected bool              `json:"isConnected"`       // 是否连着服务器，机器人总是为 true；对局中断线的玩家在宽限期内保留座位
+	IsAFK       bool              `json:"isAFK,omitempty"`   // 连续错过限时行动被标为挂机，房间替其跳过回合
+	IsSheriff   bool              `json:"isSheriff,omitempty"`
+	Rating      int               `json:"rating,omitempty"`   // 注册玩家的排位积分
+	RoleType    werewolf.RoleType `json:"roleType,omitempty"` // 只在特定情况下发送
+	Profile     *Profile          `json:"profile,omitempty"`  // 玩家资料，没有设置时为空
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrAccountNotFound 账号不存在
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrAccountExists 账号已存在
+	ErrAccountExists = errors.New("account already exists")
+	// ErrInvalidPassword 密码错误
+	ErrInvalidPassword = errors.New("invalid username or password")
+)
+
+const minPasswordLength = 6
+
+// Account 玩家账号
+type Account struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash []byte    `json:"passwordHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	Preferences protocol.Preferences  `json:"preferences"`
+	Stats       protocol.AccountStats `json:"stats"`
+	Profile     protocol.Profile      `json:"profile"`
+}
+
+// AccountStore 账号存储接口
+type AccountStore interface {
+	// GetByUsername 按用户名查询账号，不存在时返回 ErrAccountNotFound
+	GetByUsername(username string) (*Account, error)
+	// Create 保存新账号，用户名重复时返回 ErrAccountExists
+	Create(account *Account) error
+	// SavePreferences 更新账号的偏好设置
+	SavePreferences(accountID string, prefs protocol.Preferences) error
+	// SaveProfile 更新账号的玩家资料
+	SaveProfile(accountID string, profile protocol.Profile) error
+	// RecordGame 把一局结果累加到账号战绩
+	RecordGame(accountID string, game GameRecord) error
+	// ListStats 所有账号的战绩，带用户名
+	ListStats() ([]protocol.AccountStats, error)
+	// Close 释放存储资源
+	Close() error
+}
+
+// OpenAccountStore 根据后端类型打开账号存储
+func OpenAccountStore(backend, path string) (AccountStore, error) {
+	switch backend {
+	case "file":
+		return NewFileAccountStore(path)
+	case "sqlite":
+		return NewSQLiteAccountStore(path)
+	default:
+		return nil, errors.Errorf("unknown account store backend: %s", backend)
+	}
+}
+
+// AccountService 账号注册与认证
+type AccountService struct {
+	store AccountStore
+}
+
+// NewAccountService 创建账号服务
+func NewAccountService(store AccountStore) *AccountService {
+	return &AccountService{store: store}
+}
+
+// Register 注册新账号
+func (s *AccountService) Register(username, password string) (*Account, error) {
+	username, err := validateUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(password) < minPasswordLength {
+		return nil, errors.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "hash password")
+	}
+
+	account := &Account{
+		ID:           uuid.New().String(),
+		Username:     username,
+		PasswordHash: hash,
+		CreatedAt:    protocol.Now(),
+		Preferences:  protocol.DefaultPreferences(),
+	}
+
+	if err := s.store.Create(account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// Authenticate 校验用户名和密码
+func (s *AccountService) Authenticate(username, password string) (*Account, error) {
+	account, err := s.store.GetByUsername(strings.TrimSpace(username))
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			return nil, ErrInvalidPassword
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword(account.PasswordHash, []byte(password)); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	return account, nil
+}
+
+// IsRegistered 检查用户名是否已注册
+func (s *AccountService) IsRegistered(username string) (bool, error) {
+	_, err := s.store.GetByUsername(strings.TrimSpace(username))
+	if errors.Is(err, ErrAccountNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SavePreferences 校验并保存账号偏好
+func (s *AccountService) SavePreferences(accountID string, prefs protocol.Preferences) error {
+	if err := validatePreferences(prefs); err != nil {
+		return err
+	}
+	return s.store.SavePreferences(accountID, prefs)
+}
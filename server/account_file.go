@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// FileAccountStore 基于 JSON 文件的账号存储
+type FileAccountStore struct {
+	path     string
+	accounts map[string]*Account // username -> Account
+	mu       sync.RWMutex
+}
+
+// NewFileAccountStore 打开（或新建）JSON 文件账号存储
+func NewFileAccountStore(path string) (*FileAccountStore, error) {
+	store := &FileAccountStore{
+		path:     path,
+		accounts: make(map[string]*Account),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, errors.Wrap(err, "read account file")
+	}
+
+	var accounts []*Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, errors.Wrap(err, "decode account file")
+	}
+
+	for _, account := range accounts {
+		store.accounts[account.Username] = account
+	}
+
+	return store, nil
+}
+
+// GetByUsername 实现 AccountStore 接口
+func (s *FileAccountStore) GetByUsername(username string) (*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, exists := s.accounts[username]
+	if !exists {
+		return nil, ErrAccountNotFound
+	}
+
+	copied := *account
+	return &copied, nil
+}
+
+// Create 实现 AccountStore 接口
+func (s *FileAccountStore) Create(account *Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.accounts[account.Username]; exists {
+		return ErrAccountExists
+	}
+
+	s.accounts[account.Username] = account
+
+	if err := s.save(); err != nil {
+		delete(s.accounts, account.Username)
+		return err
+	}
+
+	return nil
+}
+
+// SavePreferences 实现 AccountStore 接口
+func (s *FileAccountStore) SavePreferences(accountID string, prefs protocol.Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, account := range s.accounts {
+		if account.ID != accountID {
+			continue
+		}
+
+		previous := account.Preferences
+		account.Preferences = prefs
+
+		if err := s.save(); err != nil {
+			account.Preferences = previous
+			return err
+		}
+		return nil
+	}
+
+	return ErrAccountNotFound
+}
+
+// SaveProfile 实现 AccountStore 接口
+func (s *FileAccountStore) SaveProfile(accountID string, profile protocol.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, account := range s.accounts {
+		if account.ID != accountID {
+			continue
+		}
+
+		previous := account.Profile
+		account.Profile = profile
+
+		if err := s.save(); err != nil {
+			account.Profile = previous
+			return err
+		}
+		return nil
+	}
+
+	return ErrAccountNotFound
+}
+
+// RecordGame 实现 AccountStore 接口
+func (s *FileAccountStore) RecordGame(accountID string, game GameRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, account := range s.accounts {
+		if account.ID != accountID {
+			continue
+		}
+
+		previous := account.Stats
+		account.Stats = game.apply(account.Stats)
+
+		if err := s.save(); err != nil {
+			account.Stats = previous
+			return err
+		}
+		return nil
+	}
+
+	return ErrAccountNotFound
+}
+
+// ListStats 实现 AccountStore 接口
+func (s *FileAccountStore) ListStats() ([]protocol.AccountStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make([]protocol.AccountStats, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		entry := account.Stats
+		entry.Username = account.Username
+		stats = append(stats, entry)
+	}
+	return stats, nil
+}
+
+// Close 实现 AccountStore 接口
+func (s *FileAccountStore) Close() error {
+	return nil
+}
+
+// save 将所有账号写回文件（先写临时文件再重命名，避免写坏）
+func (s *FileAccountStore) save() error {
+	accounts := make([]*Account, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		accounts = append(accounts, account)
+	}
+
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode accounts")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".accounts-*")
+	if err != nil {
+		return errors.Wrap(err, "create temp account file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "write account file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "close account file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), s.path), "replace account file")
+}
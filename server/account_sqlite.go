@@ -0,0 +1,221 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// SQLiteAccountStore 基于 SQLite 的账号存储
+type SQLiteAccountStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteAccountStore 打开 SQLite 账号存储并确保表结构存在
+func NewSQLiteAccountStore(path string) (*SQLiteAccountStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open sqlite")
+	}
+
+	store := &SQLiteAccountStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// sqliteMigrations 按顺序执行的表结构变更，已执行的版本记录在 PRAGMA user_version
+var sqliteMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS accounts (
+		id            TEXT PRIMARY KEY,
+		username      TEXT NOT NULL UNIQUE,
+		password_hash BLOB NOT NULL,
+		created_at    INTEGER NOT NULL
+	)`,
+	`ALTER TABLE accounts ADD COLUMN preferences TEXT NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE accounts ADD COLUMN stats TEXT NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE accounts ADD COLUMN profile TEXT NOT NULL DEFAULT '{}'`,
+}
+
+// migrate 执行尚未应用的表结构变更
+func (s *SQLiteAccountStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return errors.Wrap(err, "read schema version")
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		if _, err := s.db.Exec(sqliteMigrations[i]); err != nil {
+			return errors.Wrapf(err, "apply migration %d", i+1)
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			return errors.Wrapf(err, "record migration %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+// GetByUsername 实现 AccountStore 接口
+func (s *SQLiteAccountStore) GetByUsername(username string) (*Account, error) {
+	var account Account
+	var createdAt int64
+	var prefs, stats, profile string
+
+	err := s.db.QueryRow(
+		`SELECT id, username, password_hash, created_at, preferences, stats, profile FROM accounts WHERE username = ?`,
+		username,
+	).Scan(&account.ID, &account.Username, &account.PasswordHash, &createdAt, &prefs, &stats, &profile)
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "query account")
+	}
+
+	account.CreatedAt = time.Unix(createdAt, 0).UTC()
+	account.Preferences = protocol.DefaultPreferences() // 旧账号没有保存过偏好
+	if err := json.Unmarshal([]byte(prefs), &account.Preferences); err != nil {
+		return nil, errors.Wrap(err, "decode preferences")
+	}
+	if err := json.Unmarshal([]byte(stats), &account.Stats); err != nil {
+		return nil, errors.Wrap(err, "decode stats")
+	}
+	if err := json.Unmarshal([]byte(profile), &account.Profile); err != nil {
+		return nil, errors.Wrap(err, "decode profile")
+	}
+
+	return &account, nil
+}
+
+// Create 实现 AccountStore 接口
+func (s *SQLiteAccountStore) Create(account *Account) error {
+	prefs, err := json.Marshal(account.Preferences)
+	if err != nil {
+		return errors.Wrap(err, "encode preferences")
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO accounts (id, username, password_hash, created_at, preferences) VALUES (?, ?, ?, ?, ?)`,
+		account.ID, account.Username, account.PasswordHash, account.CreatedAt.Unix(), string(prefs),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrAccountExists
+		}
+		return errors.Wrap(err, "insert account")
+	}
+	return nil
+}
+
+// SavePreferences 实现 AccountStore 接口
+func (s *SQLiteAccountStore) SavePreferences(accountID string, prefs protocol.Preferences) error {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return errors.Wrap(err, "encode preferences")
+	}
+
+	result, err := s.db.Exec(`UPDATE accounts SET preferences = ? WHERE id = ?`, string(data), accountID)
+	if err != nil {
+		return errors.Wrap(err, "update preferences")
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// SaveProfile 实现 AccountStore 接口
+func (s *SQLiteAccountStore) SaveProfile(accountID string, profile protocol.Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return errors.Wrap(err, "encode profile")
+	}
+
+	result, err := s.db.Exec(`UPDATE accounts SET profile = ? WHERE id = ?`, string(data), accountID)
+	if err != nil {
+		return errors.Wrap(err, "update profile")
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// RecordGame 实现 AccountStore 接口
+func (s *SQLiteAccountStore) RecordGame(accountID string, game GameRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRow(`SELECT stats FROM accounts WHERE id = ?`, accountID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "query stats")
+	}
+
+	var stats protocol.AccountStats
+	if err := json.Unmarshal([]byte(raw), &stats); err != nil {
+		return errors.Wrap(err, "decode stats")
+	}
+
+	data, err := json.Marshal(game.apply(stats))
+	if err != nil {
+		return errors.Wrap(err, "encode stats")
+	}
+
+	if _, err := tx.Exec(`UPDATE accounts SET stats = ? WHERE id = ?`, string(data), accountID); err != nil {
+		return errors.Wrap(err, "update stats")
+	}
+
+	return errors.Wrap(tx.Commit(), "commit stats")
+}
+
+// ListStats 实现 AccountStore 接口
+func (s *SQLiteAccountStore) ListStats() ([]protocol.AccountStats, error) {
+	rows, err := s.db.Query(`SELECT username, stats FROM accounts`)
+	if err != nil {
+		return nil, errors.Wrap(err, "query stats")
+	}
+	defer rows.Close()
+
+	var all []protocol.AccountStats
+	for rows.Next() {
+		var username, raw string
+		if err := rows.Scan(&username, &raw); err != nil {
+			return nil, errors.Wrap(err, "scan stats")
+		}
+
+		var stats protocol.AccountStats
+		if err := json.Unmarshal([]byte(raw), &stats); err != nil {
+			return nil, errors.Wrap(err, "decode stats")
+		}
+		stats.Username = username
+		all = append(all, stats)
+	}
+
+	return all, errors.Wrap(rows.Err(), "read stats")
+}
+
+// Close 实现 AccountStore 接口
+func (s *SQLiteAccountStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+const (
+	activityTTL         = 5 * time.Second // 超过该时间没有新的提示即视为停止输入
+	activityMinInterval = time.Second     // 同一房间两次广播的最小间隔
+)
+
+// activityTracker 白天正在输入发言的玩家，只对外广播人数
+type activityTracker struct {
+	mu        sync.Mutex
+	typing    map[string]time.Time // playerID -> 过期时间
+	lastCount int
+	lastSent  time.Time
+	timer     *time.Timer // 限流或过期时安排的下一次广播
+}
+
+// update 记录玩家的输入状态
+func (t *activityTracker) update(playerID string, typing bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.typing == nil {
+		t.typing = make(map[string]time.Time)
+	}
+
+	if typing {
+		t.typing[playerID] = time.Now().Add(activityTTL)
+	} else {
+		delete(t.typing, playerID)
+	}
+}
+
+// reset 离开白天时清空
+func (t *activityTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.typing = nil
+	t.lastCount = 0
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// flush 人数变化时返回需要广播的人数；限流或仍有人在输入时安排 retry 再次检查
+func (t *activityTracker) flush(retry func()) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	var next time.Time
+	for playerID, expires := range t.typing {
+		if !expires.After(now) {
+			delete(t.typing, playerID)
+			continue
+		}
+		if next.IsZero() || expires.Before(next) {
+			next = expires
+		}
+	}
+
+	count := len(t.typing)
+	if count == t.lastCount {
+		t.schedule(next, now, retry)
+		return 0, false
+	}
+
+	if wait := t.lastSent.Add(activityMinInterval); now.Before(wait) {
+		t.schedule(wait, now, retry)
+		return 0, false
+	}
+
+	t.lastCount = count
+	t.lastSent = now
+	t.schedule(next, now, retry)
+
+	return count, true
+}
+
+// schedule 在 at 时刻再检查一次（需持有锁）
+func (t *activityTracker) schedule(at, now time.Time, retry func()) {
+	if at.IsZero() {
+		return
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.timer = time.AfterFunc(at.Sub(now), retry)
+}
+
+// broadcastActivity 广播匿名的输入人数，只在白天进行
+func (r *Room) broadcastActivity() {
+	r.mu.RLock()
+	phase, playing := r.lastPhase, r.State == RoomStatePlaying
+	r.mu.RUnlock()
+
+	if !playing || phase != werewolf.PhaseDay {
+		return
+	}
+
+	count, changed := r.activity.flush(r.broadcastActivity)
+	if !changed {
+		return
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgActivity, protocol.ActivityData{Typing: count})
+	r.BroadcastMessage(msg)
+}
+
+// handleActivityHint 处理玩家的输入提示
+//
+// 只接受白天存活玩家的提示；夜晚的提示直接丢弃，不回复错误，避免泄露信息。
+func (h *MessageHandler) handleActivityHint(playerID string, data *protocol.ActivityHintData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil || room.Engine() == nil {
+		return nil
+	}
+
+	room.mu.RLock()
+	phase := room.lastPhase
+	room.mu.RUnlock()
+
+	if phase != werewolf.PhaseDay || !room.isAlive(playerID) {
+		return nil
+	}
+
+	room.activity.update(playerID, data.Typing)
+	room.broadcastActivity()
+
+	return nil
+}
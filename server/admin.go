@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RoomStatus 管理接口中的房间概况
+type RoomStatus struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	State     RoomState     `json:"state"`
+	OwnerID   string        `json:"ownerID"`
+	Resources RoomResources `json:"resources"`
+
+	Rejections []PlayerRejections `json:"rejections,omitempty"` // 本局玩家被拒绝的动作统计
+}
+
+// Status 生成房间概况
+func (r *Room) Status() RoomStatus {
+	r.mu.RLock()
+	status := RoomStatus{
+		ID:      r.ID,
+		Name:    r.Name,
+		State:   r.State,
+		OwnerID: r.OwnerID,
+	}
+	r.mu.RUnlock()
+
+	status.Resources = r.Resources()
+	status.Rejections = r.Rejections()
+
+	return status
+}
+
+// AdminHandler 运维管理 HTTP 接口
+//
+//	GET /admin/rooms       所有房间概况，按资源占用从大到小排列
+//	GET /admin/rooms/{id}  单个房间概况
+//	GET /admin/rooms/{id}/events  房间本局的事件日志
+//	GET /admin/rooms/{id}/audit   房间的动作审计日志，可按 player 和 since 过滤
+//	GET /admin/reports            玩家举报，可按 room 过滤
+//	POST /admin/rooms/{id}/resume 继续暂停的对局（如引擎出错后）
+//	POST /admin/rooms/{id}/abort  中止对局，以平局结束
+type AdminHandler struct {
+	server *Server
+	token  string
+	mux    *http.ServeMux
+}
+
+// NewAdminHandler 创建管理接口，token 非空时要求 Authorization: Bearer <token>
+func NewAdminHandler(server *Server, token string) *AdminHandler {
+	h := &AdminHandler{
+		server: server,
+		token:  token,
+		mux:    http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /admin/rooms", h.listRooms)
+	h.mux.HandleFunc("GET /admin/rooms/{id}", h.getRoom)
+	h.mux.HandleFunc("GET /admin/rooms/{id}/events", h.getRoomEvents)
+	h.mux.HandleFunc("GET /admin/rooms/{id}/audit", h.getRoomAudit)
+	h.mux.HandleFunc("GET /admin/reports", h.listReports)
+	h.mux.HandleFunc("POST /admin/rooms/{id}/resume", h.roomCommand((*Room).operatorResume))
+	h.mux.HandleFunc("POST /admin/rooms/{id}/abort", h.roomCommand((*Room).operatorAbort))
+
+	return h
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if h.token != "" {
+		given := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(h.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	h.mux.ServeHTTP(w, req)
+}
+
+// listRooms 列出所有房间
+func (h *AdminHandler) listRooms(w http.ResponseWriter, _ *http.Request) {
+	statuses := h.server.RoomStatuses()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Resources.Footprint() > statuses[j].Resources.Footprint()
+	})
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// getRoom 查询单个房间
+func (h *AdminHandler) getRoom(w http.ResponseWriter, req *http.Request) {
+	room := h.server.GetRoom(req.PathValue("id"))
+	if room == nil {
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, room.Status())
+}
+
+// roomCommand 对单个房间执行运维操作，成功时返回房间概况，房间状态不允许时返回 409
+func (h *AdminHandler) roomCommand(command func(r *Room) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		room := h.server.GetRoom(req.PathValue("id"))
+		if room == nil {
+			http.Error(w, "room not found", http.StatusNotFound)
+			return
+		}
+
+		if err := command(room); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, room.Status())
+	}
+}
+
+// RoomStatuses 所有房间的概况
+func (s *Server) RoomStatuses() []RoomStatus {
+	s.mu.RLock()
+	rooms := make([]*Room, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.mu.RUnlock()
+
+	statuses := make([]RoomStatus, 0, len(rooms))
+	for _, room := range rooms {
+		statuses = append(statuses, room.Status())
+	}
+	return statuses
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// afkPassDelay 轮到挂机玩家发言时等待多久再跳过，留一点时间给刚回来的玩家
+const afkPassDelay = 3 * time.Second
+
+// afkTracker 对局中真人玩家连续错过限时行动的次数，由 r.mu 保护
+//
+// 限时行动指有计时的那一步：轮到发言、女巫用药、狼王开枪、盗贼选牌，超时即算错过一次。
+// 连续错过 Room.afkMisses 次的玩家被标为挂机，之后由房间替他跳过：轮到发言时很快跳过，
+// 放逐投票弃权，夜里不查验、不守护，刀人跟随同伴的选择。玩家自己提交任何动作即恢复。
+type afkTracker struct {
+	missed  map[string]int
+	flagged map[string]bool
+}
+
+// isAFK 玩家是否被标为挂机
+func (r *Room) isAFK(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.afk.flagged[playerID]
+}
+
+// missedAction 玩家错过了一次限时行动，连续错过的次数达到上限时标为挂机并广播
+func (r *Room) missedAction(playerID string) {
+	r.mu.Lock()
+	if r.afkMisses <= 0 || r.State != RoomStatePlaying || r.bots[playerID] != nil || r.afk.flagged[playerID] {
+		r.mu.Unlock()
+		return
+	}
+
+	if r.afk.missed == nil {
+		r.afk = afkTracker{missed: make(map[string]int), flagged: make(map[string]bool)}
+	}
+	r.afk.missed[playerID]++
+	missed := r.afk.missed[playerID]
+	flagged := missed >= r.afkMisses
+	if flagged {
+		r.afk.flagged[playerID] = true
+	}
+	r.mu.Unlock()
+
+	if !flagged {
+		return
+	}
+
+	r.playerLogger(playerID).Info("player marked afk", "missed", missed)
+	r.publishAFK(protocol.EventKeyPlayerAFK, playerID, map[string]string{
+		"player": playerID,
+		"missed": strconv.Itoa(missed),
+	})
+}
+
+// markActive 玩家自己行动了：清零错过次数，挂机的玩家恢复
+func (r *Room) markActive(playerID string) {
+	r.mu.Lock()
+	back := r.afk.flagged[playerID]
+	delete(r.afk.missed, playerID)
+	delete(r.afk.flagged, playerID)
+	r.mu.Unlock()
+
+	if !back {
+		return
+	}
+
+	r.playerLogger(playerID).Info("player back from afk")
+	r.publishAFK(protocol.EventKeyPlayerBack, playerID, map[string]string{"player": playerID})
+}
+
+// publishAFK 广播挂机状态的变化，玩家列表里的 isAFK 随对局状态一起更新
+func (r *Room) publishAFK(key protocol.EventKey, playerID string, params map[string]string) {
+	event := protocol.GameEventData{
+		EventType: protocol.EventPlayerAFK,
+		Event:     key,
+		Params:    params,
+		Data:      map[string]interface{}{"playerID": playerID},
+	}
+
+	r.logEvent(event.EventType, eventText(event), event.Data)
+	msg, _ := protocol.NewMessage(protocol.MsgGameEvent, event)
+	r.BroadcastMessage(msg)
+
+	r.SendGameState()
+}
+
+// passForAbsent 新阶段开始时替不会自己行动的存活玩家交上空动作：放逐投票弃权，预言家不查验，守卫不守护
+//
+// 引擎要等所有该行动的人提交才结算，挂机或已离开的玩家不能让整桌人一直等下去。
+func (r *Room) passForAbsent(phase werewolf.PhaseType, round int) {
+	for _, ps := range r.Engine().GetState().Players {
+		if ps.IsAlive {
+			r.passFor(ps, phase, round)
+		}
+	}
+}
+
+// passForRemoved 玩家在对局中离开或被房间判出局后，替他交上当前阶段还没交的动作，不等到下一阶段
+func (r *Room) passForRemoved(playerID string) {
+	state := r.Engine().GetState()
+	if state.IsEnded {
+		return
+	}
+
+	for _, ps := range state.Players {
+		if ps.ID == playerID && ps.IsAlive {
+			r.passFor(ps, state.Phase, state.Round)
+		}
+	}
+}
+
+// passFor 玩家不会自己行动时替他交上本阶段的空动作，已经交过的不再覆盖
+//
+// 离开或被房间判出局的玩家不再有发言轮次，白天同样替他跳过发言；狼人没有空刀，跟随同伴已选的刀口，
+// 还没人选时等同伴选定后由 followPack 跟上。
+func (r *Room) passFor(ps werewolf.PlayerState, phase werewolf.PhaseType, round int) {
+	if !r.absent(ps.ID) {
+		return
+	}
+
+	targetID := ""
+	var actionType werewolf.ActionType
+	switch {
+	case phase == werewolf.PhaseVote:
+		actionType = protocol.ActionVote
+	case phase == werewolf.PhaseDay && r.removed(ps.ID):
+		actionType = protocol.ActionSpeak
+	case phase == werewolf.PhaseNight && ps.Role == werewolf.RoleTypeSeer:
+		actionType = protocol.ActionCheck
+	case phase == werewolf.PhaseNight && ps.Role == werewolf.RoleTypeGuard:
+		actionType = protocol.ActionProtect
+	case phase == werewolf.PhaseNight && ps.Role == werewolf.RoleTypeWerewolf:
+		actionType, targetID = protocol.ActionKill, r.packTarget(round)
+		if targetID == "" {
+			return
+		}
+	default:
+		return
+	}
+
+	if r.hasActed(ps.ID, actionType, round) {
+		return
+	}
+
+	// 引擎不接受空目标时交不了空动作，只能等阶段计时
+	if targetID == "" && actionType != protocol.ActionSpeak && !engine.CanAbstain(r.Engine()) {
+		return
+	}
+
+	if r.forceAction(ps.ID, actionType, targetID, nil, round) {
+		r.playerLogger(ps.ID).Info("passed for absent player", "actionType", actionType)
+	}
+}
+
+// absent 引擎里存活但不会自己行动的玩家：离开或被房间判出局的玩家，以及开启挂机检测时被标为挂机的玩家
+func (r *Room) absent(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.abandoned[playerID] || r.special.slain[playerID] || (r.afkMisses > 0 && r.afk.flagged[playerID])
+}
+
+// removed 玩家在对局中离开，或因决斗、开枪、殉情、自爆等由房间结算的出局，引擎并不知道
+func (r *Room) removed(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.abandoned[playerID] || r.special.slain[playerID]
+}
+
+// hasActed 玩家本回合是否已有这种动作被引擎接受
+func (r *Room) hasActed(playerID string, actionType werewolf.ActionType, round int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, action := range r.actions {
+		if action.Round == round && action.PlayerID == playerID && action.ActionType == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// packTarget 狼队本夜当前的刀口，还没人选时为空
+func (r *Room) packTarget(round int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.wolfVotes.round != round || len(r.wolfVotes.choices) == 0 {
+		return ""
+	}
+	return decideWolfKill(r.rules.WolfKill, r.wolfVotes.choices)
+}
+
+// followPack 有狼人选定刀口后，挂机或已离开的狼人跟随同伴，不参与刀口的选择
+func (r *Room) followPack(targetID string, round int) {
+	for _, ps := range r.Engine().GetState().Players {
+		if !ps.IsAlive || ps.Role != werewolf.RoleTypeWerewolf || !r.absent(ps.ID) {
+			continue
+		}
+
+		r.forceAction(ps.ID, protocol.ActionKill, targetID, nil, round)
+	}
+}
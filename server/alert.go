@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Alert 运维告警
+type Alert struct {
+	RoomID  string    `json:"roomID,omitempty"`
+	Kind    string    `json:"kind"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Alerter 运维告警通道
+type Alerter interface {
+	Alert(alert Alert)
+}
+
+// logAlerter 只把告警写入日志
+type logAlerter struct {
+	logger *slog.Logger
+}
+
+// Alert 实现 Alerter 接口
+func (a *logAlerter) Alert(alert Alert) {
+	a.logger.Error("operator alert",
+		"kind", alert.Kind,
+		"roomID", alert.RoomID,
+		"message", alert.Message)
+}
+
+// webhookAlerter 记录日志并推送到 webhook
+type webhookAlerter struct {
+	logAlerter
+	url     string
+	webhook *WebhookClient
+}
+
+// Alert 实现 Alerter 接口
+func (a *webhookAlerter) Alert(alert Alert) {
+	a.logAlerter.Alert(alert)
+	a.webhook.PostAsync(a.url, alert)
+}
+
+// NewAlerter 根据配置创建告警通道
+func NewAlerter(url string, webhook *WebhookClient, logger *slog.Logger) Alerter {
+	if url == "" {
+		return &logAlerter{logger: logger}
+	}
+
+	return &webhookAlerter{
+		logAlerter: logAlerter{logger: logger},
+		url:        url,
+		webhook:    webhook,
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// AuditOutcome 一次动作提交的处理结果
+type AuditOutcome string
+
+const (
+	AuditAccepted AuditOutcome = "accepted" // 房间或引擎接受了动作
+	AuditRejected AuditOutcome = "rejected" // 动作被拒绝，Reason 为原因
+	AuditDeferred AuditOutcome = "deferred" // 夜间发言，缓存到天亮执行
+)
+
+// AuditEntry 审计日志中的一次动作提交
+//
+// 阶段和回合取收到消息时引擎的状态（包括夜晚子阶段），用于核对"幽灵动作"和不合时宜的提交。
+type AuditEntry struct {
+	Seq        int                 `json:"seq"`
+	PlayerID   string              `json:"playerID"`
+	Username   string              `json:"username"`
+	ActionType werewolf.ActionType `json:"actionType"` // 标准名称，无法识别时为玩家提交的原文
+	SkillType  protocol.SkillType  `json:"skillType,omitempty"`
+	TargetID   string              `json:"targetID,omitempty"`
+	TargetSeat int                 `json:"targetSeat,omitempty"`
+	Phase      werewolf.PhaseType  `json:"phase,omitempty"` // 对局未开始时为空
+	Round      int                 `json:"round,omitempty"`
+	MessageID  string              `json:"messageID,omitempty"` // 客户端为请求分配的 ID
+	Outcome    AuditOutcome        `json:"outcome"`
+	Reason     string              `json:"reason,omitempty"`
+	Time       time.Time           `json:"time"`
+}
+
+// auditLog 房间的动作审计日志，只追加不修改，跨对局保留到房间关闭
+type auditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// append 追加一条记录并分配序号
+func (l *auditLog) append(entry AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = len(l.entries) + 1
+	l.entries = append(l.entries, entry)
+}
+
+// query 返回序号大于 since 的记录，playerID 非空时只返回该玩家的
+func (l *auditLog) query(playerID string, since int) []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := []AuditEntry{}
+	for _, entry := range l.entries {
+		if entry.Seq <= since || (playerID != "" && entry.PlayerID != playerID) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// auditEntry 按收到的动作消息生成审计记录，结果由调用方在处理完成后填写
+func (r *Room) auditEntry(player *Player, msg *protocol.Message, data protocol.PerformActionData) AuditEntry {
+	entry := AuditEntry{
+		PlayerID:   player.ID,
+		Username:   player.Username,
+		ActionType: data.ActionType,
+		SkillType:  data.SkillType,
+		TargetID:   data.TargetID,
+		TargetSeat: data.TargetSeat,
+		MessageID:  msg.ID,
+		Time:       protocol.Now(),
+	}
+
+	if r.Engine() != nil {
+		state := r.Engine().GetState()
+		entry.Phase, entry.Round = state.Phase, state.Round
+	}
+
+	return entry
+}
+
+// audit 写入审计日志，err 为 nil 时记为 outcome
+func (r *Room) audit(entry AuditEntry, outcome AuditOutcome, err error) {
+	entry.Outcome = outcome
+	if err != nil {
+		entry.Outcome = AuditRejected
+		entry.Reason = err.Error()
+	}
+
+	r.auditLog.append(entry)
+}
+
+// getRoomAudit 查询房间的动作审计日志
+//
+//	GET /admin/rooms/{id}/audit?player=<玩家ID>&since=<序号>
+func (h *AdminHandler) getRoomAudit(w http.ResponseWriter, req *http.Request) {
+	room := h.server.GetRoom(req.PathValue("id"))
+	if room == nil {
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+
+	since := 0
+	if s := req.URL.Query().Get("since"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+
+	writeJSON(w, http.StatusOK, room.auditLog.query(req.URL.Query().Get("player"), since))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// actionDecision 授权矩阵对一次动作的裁决
+type actionDecision int
+
+const (
+	actionAllow  actionDecision = iota // 交给引擎执行
+	actionReject                       // 直接拒绝
+	actionDefer                        // 缓存到白天再执行
+)
+
+// actionMatrix 阶段 × 动作授权矩阵
+//
+// 只列出需要拦截的组合，未列出的组合交给引擎按规则校验。
+var actionMatrix = map[werewolf.PhaseType]map[werewolf.ActionType]actionDecision{
+	werewolf.PhaseNight: {
+		protocol.ActionSpeak:        actionDefer,
+		protocol.ActionVote:         actionReject,
+		protocol.ActionDuel:         actionReject,
+		protocol.ActionSelfDestruct: actionReject,
+	},
+	werewolf.PhaseDay: {
+		protocol.ActionKill:     actionReject,
+		protocol.ActionCheck:    actionReject,
+		protocol.ActionProtect:  actionReject,
+		protocol.ActionAntidote: actionReject,
+		protocol.ActionPoison:   actionReject,
+		protocol.ActionLink:     actionReject,
+		protocol.ActionSteal:    actionReject,
+	},
+	werewolf.PhaseVote: {
+		protocol.ActionKill:         actionReject,
+		protocol.ActionCheck:        actionReject,
+		protocol.ActionProtect:      actionReject,
+		protocol.ActionAntidote:     actionReject,
+		protocol.ActionPoison:       actionReject,
+		protocol.ActionDuel:         actionReject,
+		protocol.ActionLink:         actionReject,
+		protocol.ActionSteal:        actionReject,
+		protocol.ActionSelfDestruct: actionReject,
+	},
+}
+
+// authorizeAction 查询授权矩阵
+func authorizeAction(phase werewolf.PhaseType, actionType werewolf.ActionType) actionDecision {
+	if decision, ok := actionMatrix[publicPhase(phase)][actionType]; ok {
+		return decision
+	}
+	return actionAllow
+}
+
+// deferAction 缓存夜间提交的动作，天亮后执行
+func (r *Room) deferAction(record ActionRecord) {
+	r.mu.Lock()
+	r.deferred = append(r.deferred, record)
+	r.mu.Unlock()
+
+	r.logger.Info("action deferred until day",
+		"playerID", record.PlayerID,
+		"actionType", record.ActionType)
+}
+
+// releaseHeldSpeech 轮到发言的玩家夜里有缓存的发言时替他发出，同一人的多条合成一条
+//
+// 缓存的发言要等本人的发言轮次才交给引擎，与当面发言一样受发言顺序和警长竞选的约束。
+func (r *Room) releaseHeldSpeech() {
+	r.mu.Lock()
+	o := &r.speaking
+	if !o.active() || r.sheriff.running() || len(r.deferred) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	speaker := o.order[o.turn]
+	var contents []string
+	kept := r.deferred[:0]
+	for _, record := range r.deferred {
+		if record.PlayerID != speaker || record.ActionType != protocol.ActionSpeak {
+			kept = append(kept, record)
+			continue
+		}
+		if content, _ := record.Data["content"].(string); content != "" {
+			contents = append(contents, content)
+		}
+	}
+	r.deferred = kept
+	r.mu.Unlock()
+
+	if len(contents) == 0 {
+		return
+	}
+
+	r.spawn(func() {
+		data := map[string]interface{}{"content": strings.Join(contents, "\n")}
+		if err := r.PerformAction(speaker, protocol.ActionSpeak, "", data); err != nil {
+			r.playerLogger(speaker).Warn("held speech rejected", "error", err)
+		}
+	})
+}
+
+// dropHeldSpeeches 白天发言结束时丢弃没等到发言轮次的缓存发言，如夜里出局的玩家的发言
+func (r *Room) dropHeldSpeeches() {
+	r.mu.Lock()
+	dropped := len(r.deferred)
+	r.deferred = nil
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		r.logger.Info("held speeches dropped", "count", dropped)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/gametest"
+	"github.com/pkg/errors"
+)
+
+// runBench 并发开多桌对局，统计从连接到开局的耗时
+//
+// 未指定 -addr 时在进程内启动一个临时服务器（临时账号存储、不保存房间）。
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	addr := fs.String("addr", "", "server address to test (empty to start a local server)")
+	games := fs.Int("games", 10, "number of games to start")
+	concurrency := fs.Int("concurrency", 5, "games started at the same time")
+	flags := registerConfigFlags(fs, "warn")
+	fs.Parse(args)
+
+	if *games <= 0 || *concurrency <= 0 {
+		return errors.New("games and concurrency must be positive")
+	}
+
+	target := *addr
+	if target == "" {
+		local, stop, err := startBenchServer(flags)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		target = local
+	}
+
+	fmt.Printf("starting %d games against %s (concurrency %d)\n", *games, target, *concurrency)
+
+	var (
+		mu        sync.Mutex
+		durations []time.Duration
+		failures  []error
+		wg        sync.WaitGroup
+	)
+
+	started := time.Now()
+	slots := make(chan struct{}, *concurrency)
+	for i := 0; i < *games; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			begin := time.Now()
+			game, err := gametest.StartGame(target, fmt.Sprintf("bench%d", i), gametest.SixPlayerRoles)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, err)
+				return
+			}
+			game.Close()
+			durations = append(durations, time.Since(begin))
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(started)
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	fmt.Printf("\n%d/%d games started in %s\n", len(durations), *games, elapsed.Round(time.Millisecond))
+	if len(durations) > 0 {
+		fmt.Printf("time to start: p50=%s p95=%s max=%s\n",
+			percentile(durations, 50), percentile(durations, 95), durations[len(durations)-1])
+	}
+	for _, err := range failures {
+		fmt.Printf("failed: %v\n", err)
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("%d games failed to start", len(failures))
+	}
+	return nil
+}
+
+// startBenchServer 在随机端口启动临时服务器，返回地址和停止函数
+func startBenchServer(flags *configFlags) (string, func(), error) {
+	config, err := flags.config()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "invalid flag")
+	}
+	config.StateDir = ""
+	config.SnapshotRedis = ""
+	config.RegistryRedis = ""
+	config.TelemetryURL = ""
+
+	logger, err := flags.logger()
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "werewolf-bench")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "create temp dir")
+	}
+
+	store, err := NewFileAccountStore(filepath.Join(dir, "accounts.json"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		store.Close()
+		os.RemoveAll(dir)
+		return "", nil, errors.Wrap(err, "listen")
+	}
+
+	server := NewServer(config, NewAccountService(store), logger)
+	go server.Serve(listener)
+
+	stop := func() {
+		server.Shutdown()
+		store.Close()
+		os.RemoveAll(dir)
+	}
+
+	return listener.Addr().String(), stop, nil
+}
+
+// percentile 返回已排序耗时的第 p 百分位
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
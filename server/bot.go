@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// 机器人每次行动前的随机思考时间
+const (
+	botMinDelay = 1 * time.Second
+	botMaxDelay = 3 * time.Second
+)
+
+// botSpeech 机器人白天的发言
+const botSpeech = "过。"
+
+// NewBotPlayer 创建机器人玩家，机器人没有连接，始终处于准备状态
+func NewBotPlayer(name string) *Player {
+	return &Player{
+		ID:       "bot-" + uuid.New().String()[:8],
+		Username: name,
+		IsReady:  true,
+		IsGuest:  true,
+		IsBot:    true,
+
+		Preferences: protocol.DefaultPreferences(),
+	}
+}
+
+// bot 机器人的策略状态
+type bot struct {
+	checked map[string]bool // 预言家已查验过的玩家
+	acted   map[string]bool // 已行动过的阶段（引擎阶段/回合），每个阶段只行动一次
+}
+
+// newBot 创建机器人策略状态
+func newBot() *bot {
+	return &bot{
+		checked: make(map[string]bool),
+		acted:   make(map[string]bool),
+	}
+}
+
+// AddBots 用机器人填补空位，返回加入的机器人
+func (r *Room) AddBots(count int) ([]*Player, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.State != RoomStateWaiting {
+		return nil, errors.New("room is not in waiting state")
+	}
+
+	if r.ranked {
+		return nil, ErrRankedBots
+	}
+
+	free := r.seats() - len(r.Players)
+	if free <= 0 {
+		return nil, errors.New("room is full")
+	}
+	if count <= 0 || count > free {
+		count = free
+	}
+
+	added := make([]*Player, 0, count)
+	next := len(r.bots) + 1
+	for i := 0; i < count; i++ {
+		// 跳过已被玩家占用的名字
+		name := fmt.Sprintf("机器人%d", next)
+		for r.usernameTaken(name, "") {
+			next++
+			name = fmt.Sprintf("机器人%d", next)
+		}
+		next++
+
+		player := NewBotPlayer(name)
+		player.RoomID = r.ID
+
+		r.Players[player.ID] = player
+		r.order = append(r.order, player.ID)
+		r.bots[player.ID] = newBot()
+		added = append(added, player)
+	}
+
+	r.logger.Info("bots added", "count", count)
+
+	return added, nil
+}
+
+// botPlayer 返回房间内的机器人玩家，不存在时返回 nil
+func (r *Room) botPlayer(playerID string) *Player {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.bots[playerID]; !ok {
+		return nil
+	}
+	return r.Players[playerID]
+}
+
+// humanCount 仍在房间中的真人玩家数
+func (r *Room) humanCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for playerID, player := range r.Players {
+		if !player.IsBot && !r.abandoned[playerID] {
+			n++
+		}
+	}
+	return n
+}
+
+// driveBots 阶段开始时让每个存活的机器人按角色策略行动
+//
+// phase 是引擎的原始阶段，夜晚各子阶段分别触发，机器人在每个子阶段最多行动一次。
+func (r *Room) driveBots(phase werewolf.PhaseType, round int) {
+	r.mu.RLock()
+	bots := make(map[string]*bot, len(r.bots))
+	for playerID, b := range r.bots {
+		bots[playerID] = b
+	}
+	r.mu.RUnlock()
+
+	key := fmt.Sprintf("%s/%d", phase, round)
+	for playerID, b := range bots {
+		if b.acted[key] {
+			continue
+		}
+
+		r.spawn(func() { r.botAct(playerID, b, key) })
+	}
+}
+
+// botAct 思考片刻后选择并执行一个动作
+func (r *Room) botAct(playerID string, b *bot, key string) {
+	time.Sleep(botMinDelay + time.Duration(rand.Int63n(int64(botMaxDelay-botMinDelay))))
+
+	r.mu.Lock()
+	if r.State != RoomStatePlaying || b.acted[key] {
+		r.mu.Unlock()
+		return
+	}
+	b.acted[key] = true
+	r.mu.Unlock()
+
+	var skills protocol.AllowedSkillsData
+	var myRole werewolf.RoleType
+	wolves := make(map[string]bool)
+	if err := callEngine("bot state", func() error {
+		for _, ps := range r.Engine().GetState().Players {
+			if ps.Role == werewolf.RoleTypeWerewolf {
+				wolves[ps.ID] = true
+			}
+			if ps.ID == playerID {
+				myRole = ps.Role
+			}
+		}
+		skills = r.AllowedSkills(playerID)
+		return nil
+	}); err != nil {
+		r.playerLogger(playerID).Error("bot failed to read state", "error", err)
+		return
+	}
+
+	actionType, targetID, data, ok := b.choose(myRole, wolves, skills.Skills)
+	if !ok {
+		return
+	}
+
+	if err := r.PerformAction(playerID, actionType, targetID, data); err != nil {
+		// 夜晚子阶段的技能表是合并后的，引擎拒绝不属于当前子阶段的动作是正常的
+		r.playerLogger(playerID).Debug("bot action rejected",
+			"actionType", actionType,
+			"error", err)
+		return
+	}
+
+	r.playerLogger(playerID).Info("bot acted",
+		"actionType", actionType,
+		"targetID", targetID)
+
+	r.SendGameState()
+}
+
+// choose 按角色策略从可用技能中选一个动作
+//
+// 狼人随机刀一名非狼人；预言家查验还没验过的玩家；守卫随机守一人；
+// 白天发言"过"；投票时随机投一名存活玩家（狼人不投同伴）；丘比特随机连接两名玩家。
+// 女巫、猎人和骑士不主动用技能，狼王开枪见 startWolfKingShot，盗贼选牌见 armThiefTimer。
+func (b *bot) choose(myRole werewolf.RoleType, wolves map[string]bool, skills []protocol.SkillInfo) (werewolf.ActionType, string, map[string]interface{}, bool) {
+	pick := func(targets []string, keep func(string) bool) (string, bool) {
+		var candidates []string
+		for _, id := range targets {
+			if keep(id) {
+				candidates = append(candidates, id)
+			}
+		}
+		if len(candidates) == 0 {
+			return "", false
+		}
+		return candidates[rand.Intn(len(candidates))], true
+	}
+
+	for _, skill := range skills {
+		switch skill.ActionType {
+		case protocol.ActionKill:
+			if target, ok := pick(skill.Targets, func(id string) bool { return !wolves[id] }); ok {
+				return skill.ActionType, target, nil, true
+			}
+		case protocol.ActionCheck:
+			if target, ok := pick(skill.Targets, func(id string) bool { return !b.checked[id] }); ok {
+				b.checked[target] = true
+				return skill.ActionType, target, nil, true
+			}
+		case protocol.ActionProtect:
+			if target, ok := pick(skill.Targets, func(string) bool { return true }); ok {
+				return skill.ActionType, target, nil, true
+			}
+		case protocol.ActionLink:
+			first, ok := pick(skill.Targets, func(string) bool { return true })
+			if !ok {
+				continue
+			}
+			if second, ok := pick(skill.Targets, func(id string) bool { return id != first }); ok {
+				return skill.ActionType, first, map[string]interface{}{secondTargetKey: second}, true
+			}
+		case protocol.ActionSpeak:
+			return skill.ActionType, "", map[string]interface{}{"content": botSpeech}, true
+		case protocol.ActionVote:
+			isWolf := myRole == werewolf.RoleTypeWerewolf
+			if target, ok := pick(skill.Targets, func(id string) bool { return !isWolf || !wolves[id] }); ok {
+				return skill.ActionType, target, nil, true
+			}
+		}
+	}
+
+	return "", "", nil, false
+}
+
+// handleAddBot 处理房主添加机器人
+func (h *MessageHandler) handleAddBot(playerID string, data *protocol.AddBotData) error {
+	room, err := h.ownedRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	bots, err := room.AddBots(data.Count)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range bots {
+		joinedMsg, _ := protocol.NewMessage(protocol.MsgPlayerJoined, protocol.PlayerJoinedData{
+			Player: room.PlayerInfo(b.ID),
+		})
+		room.BroadcastMessage(joinedMsg)
+	}
+
+	h.logger.Info("bots added by owner", "roomID", room.ID, "count", len(bots), "by", playerID)
+	room.updateReadyTimer()
+
+	// 机器人总是准备好的，补满后可能可以直接开局
+	if _, err := room.tryStart(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/engine"
+	"github.com/pkg/errors"
+)
+
+// command 服务器子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands 所有子命令，不带子命令时执行 serve
+var commands = []command{
+	{"serve", "run the game server (default)", runServe},
+	{"migrate", "apply account storage migrations and exit", runMigrate},
+	{"simulate", "play games between server-side bots and report the results", runSimulate},
+	{"bench", "run a local load test against a server", runBench},
+	{"gateway", "accept client connections and forward them to the game servers hosting their rooms", runGateway},
+}
+
+// dispatch 按第一个参数选择子命令；第一个参数是 flag 时按 serve 处理，兼容旧的启动方式
+func dispatch(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServe(args)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	if args[0] == "help" {
+		printUsage()
+		return nil
+	}
+
+	printUsage()
+	return errors.Errorf("unknown command: %s", args[0])
+}
+
+// printUsage 打印子命令列表
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "usage: server [command] [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nrun 'server <command> -h' for the flags of a command\n")
+}
+
+// configFlags 各子命令共用的配置参数
+type configFlags struct {
+	accountStore     *string
+	accountPath      *string
+	engine           *string
+	errorPolicy      *string
+	sendOverflow     *string
+	alertWebhook     *string
+	stateDir         *string
+	snapshotRedis    *string
+	snapshotEvery    *time.Duration
+	summaryWebhook   *string
+	replayURL        *string
+	adminAddr        *string
+	adminToken       *string
+	metricsAddr      *string
+	traceEndpoint    *string
+	grpcAddr         *string
+	viewerAddr       *string
+	viewerDelay      *time.Duration
+	healthAddr       *string
+	roomMemoryLimit  *int64
+	reconnectGrace   *time.Duration
+	heartbeatTimeout *time.Duration
+	speakTimeout     *time.Duration
+	afkMisses        *int
+	profanityWords   *string
+	profanityPolicy  *string
+	profanityMute    *int
+	profanityKick    *int
+	telemetryURL     *string
+	telemetryEvery   *time.Duration
+	maxRooms         *int
+	maxPlayers       *int
+	maxPlayerRooms   *int
+	maxRoomSize      *int
+	maxConnsPerIP    *int
+	startCountdown   *time.Duration
+	registryRedis    *string
+	workerAddr       *string
+	logLevel         *string
+}
+
+// registerConfigFlags 在子命令的 FlagSet 上注册共用配置参数
+func registerConfigFlags(fs *flag.FlagSet, defaultLogLevel string) *configFlags {
+	defaults := DefaultConfig()
+
+	return &configFlags{
+		accountStore:     fs.String("account-store", "file", "account storage backend (file|sqlite)"),
+		accountPath:      fs.String("account-path", "accounts.json", "account storage path"),
+		engine:           fs.String("engine", "werewolf", "rule engine (werewolf|mock), mock is deterministic and meant for load tests and development"),
+		errorPolicy:      fs.String("engine-error-policy", string(ErrorPolicyPause), "engine internal error policy (restore|pause|abort)"),
+		sendOverflow:     fs.String("send-overflow", string(defaults.SendOverflow), "what to do when a slow client's send queue is full (drop|disconnect)"),
+		alertWebhook:     fs.String("alert-webhook", "", "operator alert webhook URL"),
+		stateDir:         fs.String("state-dir", "state", "directory for room snapshots (empty to disable)"),
+		snapshotRedis:    fs.String("snapshot-redis", "", "store room snapshots in Redis instead of -state-dir, host:port or redis://[:password@]host:port[/db]"),
+		snapshotEvery:    fs.Duration("snapshot-interval", defaults.SnapshotInterval, "how often running rooms are snapshotted for crash recovery (0 to save only on shutdown)"),
+		summaryWebhook:   fs.String("summary-webhook", "", "default game summary webhook URL (rooms may override)"),
+		replayURL:        fs.String("replay-url", "", "base URL for replay links in game summaries"),
+		adminAddr:        fs.String("admin-addr", "", "admin HTTP API address (empty to disable)"),
+		adminToken:       fs.String("admin-token", "", "bearer token required by the admin API"),
+		metricsAddr:      fs.String("metrics-addr", "", "Prometheus /metrics address (empty to disable)"),
+		traceEndpoint:    fs.String("otlp-endpoint", "", "OTLP/HTTP endpoint to export traces to, e.g. http://localhost:4318 (empty to disable)"),
+		grpcAddr:         fs.String("grpc-addr", "", "gRPC API address for programmatic clients (empty to disable)"),
+		viewerAddr:       fs.String("viewer-addr", "", "read-only game viewer (Server-Sent Events) address (empty to disable)"),
+		viewerDelay:      fs.Duration("viewer-delay", 0, "delay game broadcasts to viewers by this long, e.g. 90s, so spectators cannot relay live information to players (0 for live)"),
+		healthAddr:       fs.String("health-addr", "", "HTTP address for the /healthz and /readyz probes (empty to disable)"),
+		roomMemoryLimit:  fs.Int64("room-memory-limit", 0, "heap size in MB above which finished rooms are evicted largest first (0 to disable)"),
+		reconnectGrace:   fs.Duration("reconnect-grace", defaults.ReconnectGrace, "how long a disconnected player keeps their seat in a running game (0 to disable)"),
+		heartbeatTimeout: fs.Duration("heartbeat-timeout", defaults.HeartbeatTimeout, "close connections that stop sending heartbeats for this long (0 to disable)"),
+		speakTimeout:     fs.Duration("speak-timeout", defaults.SpeakTimeout, "skip a player who does not speak within this long on their turn (0 to disable)"),
+		afkMisses:        fs.Int("afk-after", defaults.AFKMisses, "treat a player who misses this many timed actions in a row as AFK and pass their turns (0 to disable)"),
+		profanityWords:   fs.String("profanity-words", "", "word list file (one per line) to filter out of speech and whispers (empty to disable)"),
+		profanityPolicy:  fs.String("profanity-policy", string(defaults.ProfanityPolicy), "what to do with speech that hits the word list (mask|block)"),
+		profanityMute:    fs.Int("profanity-mute-after", defaults.ProfanityMute, "mute a player's speech in a room after this many filtered messages (0 to disable)"),
+		profanityKick:    fs.Int("profanity-kick-after", defaults.ProfanityKick, "remove a player from the room after this many filtered messages (0 to disable)"),
+		telemetryURL:     fs.String("telemetry-url", "", "opt in to anonymous aggregate usage reports sent to this URL (empty to disable)"),
+		telemetryEvery:   fs.Duration("telemetry-interval", defaults.TelemetryInterval, "how often anonymous usage reports are sent"),
+		maxRooms:         fs.Int("max-rooms", 0, "maximum number of open rooms (0 for no limit)"),
+		maxPlayers:       fs.Int("max-players", 0, "maximum number of logged in players (0 for no limit)"),
+		maxPlayerRooms:   fs.Int("max-rooms-per-player", 0, "maximum number of unfinished rooms one player may own (0 for no limit)"),
+		maxRoomSize:      fs.Int("max-room-size", defaults.MaxRoomSize, fmt.Sprintf("maximum number of seats in a room (%d-%d)", minRoomPlayers, maxRoomPlayers)),
+		maxConnsPerIP:    fs.Int("max-conns-per-ip", 0, "maximum number of connections from one IP address (0 for no limit)"),
+		startCountdown:   fs.Duration("start-countdown", defaults.StartCountdown, "countdown between everyone being ready and the game starting (0 to start immediately)"),
+		registryRedis:    fs.String("registry-redis", "", "Redis holding the room routing table shared with the gateway, host:port or redis://... (empty for a standalone server)"),
+		workerAddr:       fs.String("worker-addr", "", "address the gateway uses to reach this server (defaults to -addr)"),
+		logLevel:         fs.String("log-level", defaultLogLevel, "log level (debug|info|warn|error)"),
+	}
+}
+
+// config 根据参数生成服务器配置
+func (f *configFlags) config() (Config, error) {
+	config := DefaultConfig()
+	config.AlertWebhook = *f.alertWebhook
+	config.StateDir = *f.stateDir
+	config.SnapshotRedis = *f.snapshotRedis
+	config.SnapshotInterval = *f.snapshotEvery
+	config.SummaryWebhook = *f.summaryWebhook
+	config.ReplayBaseURL = *f.replayURL
+	config.AdminAddr = *f.adminAddr
+	config.AdminToken = *f.adminToken
+	config.MetricsAddr = *f.metricsAddr
+	config.TraceEndpoint = *f.traceEndpoint
+	config.GRPCAddr = *f.grpcAddr
+	config.ViewerAddr = *f.viewerAddr
+	config.ViewerDelay = *f.viewerDelay
+	config.HealthAddr = *f.healthAddr
+	config.RoomMemoryLimit = *f.roomMemoryLimit << 20
+	config.ReconnectGrace = *f.reconnectGrace
+	config.HeartbeatTimeout = *f.heartbeatTimeout
+	config.SpeakTimeout = *f.speakTimeout
+	config.AFKMisses = *f.afkMisses
+	config.ProfanityMute = *f.profanityMute
+	config.ProfanityKick = *f.profanityKick
+	config.TelemetryURL = *f.telemetryURL
+	config.TelemetryInterval = *f.telemetryEvery
+	config.MaxRooms = *f.maxRooms
+	config.MaxPlayers = *f.maxPlayers
+	config.MaxRoomsPerPlayer = *f.maxPlayerRooms
+	config.MaxRoomSize = *f.maxRoomSize
+	config.MaxConnsPerIP = *f.maxConnsPerIP
+	config.StartCountdown = *f.startCountdown
+	config.RegistryRedis = *f.registryRedis
+	config.WorkerAddr = *f.workerAddr
+
+	factory, err := engine.ByName(*f.engine)
+	if err != nil {
+		return config, err
+	}
+	config.Engine = factory
+
+	policy, err := ParseErrorPolicy(*f.errorPolicy)
+	if err != nil {
+		return config, err
+	}
+	config.EngineErrorPolicy = policy
+
+	overflow, err := ParseOverflowPolicy(*f.sendOverflow)
+	if err != nil {
+		return config, err
+	}
+	config.SendOverflow = overflow
+
+	if config.MaxRoomSize < minRoomPlayers || config.MaxRoomSize > maxRoomPlayers {
+		return config, errors.Errorf("max room size must be between %d and %d", minRoomPlayers, maxRoomPlayers)
+	}
+
+	profanity, err := ParseFilterPolicy(*f.profanityPolicy)
+	if err != nil {
+		return config, err
+	}
+	config.ProfanityPolicy = profanity
+
+	if *f.profanityWords != "" {
+		words, err := loadWordList(*f.profanityWords)
+		if err != nil {
+			return config, err
+		}
+		config.ProfanityWords = words
+	}
+
+	return config, nil
+}
+
+// openStore 打开账号存储，SQLite 存储打开时会执行未应用的迁移
+func (f *configFlags) openStore() (AccountStore, error) {
+	return OpenAccountStore(*f.accountStore, *f.accountPath)
+}
+
+// logger 按日志级别创建日志
+func (f *configFlags) logger() (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*f.logLevel)); err != nil {
+		return nil, errors.Wrap(err, "invalid log level")
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: level,
+	})), nil
+}
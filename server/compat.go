@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+)
+
+// legacyProtocolVersion 握手之前的客户端（不发送 HELLO）
+const legacyProtocolVersion = 0
+
+// eventKeysVersion 开始只发送事件键的协议版本，更早的客户端需要服务器渲染好的事件文字
+const eventKeysVersion = 2
+
+// legacyMessageTypes 旧客户端能解析的服务器消息
+var legacyMessageTypes = map[protocol.MessageType]bool{
+	protocol.MsgLoginSuccess: true,
+	protocol.MsgRoomCreated:  true,
+	protocol.MsgRoomJoined:   true,
+	protocol.MsgPlayerJoined: true,
+	protocol.MsgPlayerLeft:   true,
+	protocol.MsgPlayerReady:  true,
+	protocol.MsgGameStarted:  true,
+	protocol.MsgPhaseChanged: true,
+	protocol.MsgGameState:    true,
+	protocol.MsgGameEvent:    true,
+	protocol.MsgActionResult: true,
+	protocol.MsgGameEnded:    true,
+	protocol.MsgError:        true,
+}
+
+// legacyTranslations 旧客户端无法解析、但内容需要让玩家看到的消息，
+// 转成旧客户端认识的消息；其余新消息直接丢弃。
+var legacyTranslations = map[protocol.MessageType]func(*protocol.Message) *protocol.Message{
+	protocol.MsgAnnouncement: func(m *protocol.Message) *protocol.Message {
+		var data protocol.AnnouncementData
+		if m.UnmarshalData(&data) != nil {
+			return nil
+		}
+		msg, _ := protocol.NewMessage(protocol.MsgGameEvent, protocol.GameEventData{Message: data.Message})
+		return msg
+	},
+	protocol.MsgGamePaused: func(m *protocol.Message) *protocol.Message {
+		var data protocol.GamePausedData
+		if m.UnmarshalData(&data) != nil {
+			return nil
+		}
+		msg, _ := protocol.NewErrorMessage("game paused: " + data.Message)
+		return msg
+	},
+	protocol.MsgGameResumed: func(*protocol.Message) *protocol.Message {
+		msg, _ := protocol.NewMessage(protocol.MsgGameEvent, protocol.GameEventData{Message: "对局继续"})
+		return msg
+	},
+	protocol.MsgServerShutdown: func(m *protocol.Message) *protocol.Message {
+		var data protocol.ServerShutdownData
+		if m.UnmarshalData(&data) != nil {
+			return nil
+		}
+		msg, _ := protocol.NewErrorMessage(data.Message)
+		return msg
+	},
+	protocol.MsgRoleInfo: func(m *protocol.Message) *protocol.Message {
+		var data protocol.RoleInfoData
+		if m.UnmarshalData(&data) != nil {
+			return nil
+		}
+		msg, _ := protocol.NewMessage(protocol.MsgGameEvent, protocol.GameEventData{Message: "你的身份变为 " + string(data.RoleType)})
+		return msg
+	},
+	protocol.MsgKicked: func(*protocol.Message) *protocol.Message {
+		msg, _ := protocol.NewErrorMessage("you were removed from the room by the owner")
+		return msg
+	},
+	protocol.MsgRoomClosed: func(*protocol.Message) *protocol.Message {
+		msg, _ := protocol.NewErrorMessage("the room was closed by the owner")
+		return msg
+	},
+}
+
+// negotiateVersion 取客户端与服务器都支持的协议版本
+func negotiateVersion(clientVersion int) int {
+	if clientVersion < protocol.ProtocolVersion {
+		return clientVersion
+	}
+	return protocol.ProtocolVersion
+}
+
+// downgrade 按玩家的协议版本过滤或转换消息，返回 nil 表示不发送
+func (p *Player) downgrade(msg socket.Message) socket.Message {
+	if p.ProtocolVersion >= protocol.ProtocolVersion {
+		return msg
+	}
+
+	m, ok := msg.(*protocol.Message)
+	if !ok {
+		return msg
+	}
+
+	if p.ProtocolVersion < eventKeysVersion && m.Type == protocol.MsgGameEvent {
+		m = withEventText(m)
+		msg = m
+	}
+
+	if p.ProtocolVersion != legacyProtocolVersion || legacyMessageTypes[m.Type] {
+		return msg
+	}
+
+	if translate, ok := legacyTranslations[m.Type]; ok {
+		if translated := translate(m); translated != nil {
+			return translated
+		}
+	}
+
+	return nil
+}
+
+// withEventText 给只有事件键的 GAME_EVENT 补上渲染好的文字，返回新消息，不修改广播共用的原消息
+func withEventText(m *protocol.Message) *protocol.Message {
+	var data protocol.GameEventData
+	if m.UnmarshalData(&data) != nil || data.Event == "" || data.Message != "" {
+		return m
+	}
+
+	data.Message = eventText(data)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return m
+	}
+
+	translated := *m
+	translated.Data = raw
+	return &translated
+}
+
+// deathCauses 出局原因的文字
+var deathCauses = map[string]string{
+	protocol.CauseWolfKill:     "被狼人杀害",
+	protocol.CausePoison:       "被女巫毒杀",
+	protocol.CauseExile:        "被放逐",
+	protocol.CauseKnightDuel:   "决斗落败",
+	protocol.CauseWolfKingShot: "被狼王带走",
+	protocol.CauseOther:        "出局",
+}
+
+// eventText 事件的中文文字，用于旧版本客户端和对局事件日志
+func eventText(data protocol.GameEventData) string {
+	params := data.Params
+
+	switch data.Event {
+	case protocol.EventKeyPlayerDied:
+		cause, ok := deathCauses[params["cause"]]
+		if !ok {
+			return fmt.Sprintf("玩家 %s 死亡", params["victim"])
+		}
+		return fmt.Sprintf("玩家 %s 死亡: %s", params["victim"], cause)
+	case protocol.EventKeyIdiotRevealed:
+		return fmt.Sprintf("玩家 %s 翻牌白痴，免于放逐，之后不能再投票", params["player"])
+	case protocol.EventKeyKnightDuel:
+		if params["loser"] == params["target"] {
+			return fmt.Sprintf("玩家 %s 翻牌骑士，与玩家 %s 决斗：对方是狼人，出局", params["knight"], params["target"])
+		}
+		return fmt.Sprintf("玩家 %s 翻牌骑士，与玩家 %s 决斗：对方是好人，骑士出局", params["knight"], params["target"])
+	case protocol.EventKeyWolfKingShot:
+		return fmt.Sprintf("玩家 %s 翻牌狼王，开枪带走了玩家 %s", params["shooter"], params["victim"])
+	case protocol.EventKeyLoversLinked:
+		return fmt.Sprintf("你被丘比特选为恋人，你的恋人是玩家 %s", params["partner"])
+	case protocol.EventKeyLoverSuicide:
+		return fmt.Sprintf("玩家 %s 的恋人玩家 %s 出局，随之殉情", params["victim"], params["partner"])
+	case protocol.EventKeyWolfSelfDestruct:
+		return fmt.Sprintf("玩家 %s 是狼人，当场自爆！今天的发言和投票到此结束，直接进入黑夜", params["player"])
+	case protocol.EventKeyDawnPeaceful:
+		return fmt.Sprintf("第%s天天亮了，昨晚是平安夜", params["round"])
+	case protocol.EventKeyDawnDeaths:
+		return fmt.Sprintf("第%s天天亮了，昨晚出局的玩家: %s", params["round"], params["victims"])
+	case protocol.EventKeyDawnSilent:
+		return fmt.Sprintf("第%s天天亮了", params["round"])
+	case protocol.EventKeyPlayerAFK:
+		return fmt.Sprintf("玩家 %s 连续 %s 次没有行动，视为挂机，之后的回合自动跳过", params["player"], params["missed"])
+	case protocol.EventKeyPlayerBack:
+		return fmt.Sprintf("玩家 %s 回来了", params["player"])
+	default:
+		return data.Message
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Zereker/game/engine"
+)
+
+// Config 服务器配置
+type Config struct {
+	Engine            engine.Factory // 房间使用的规则引擎，为 nil 时用 werewolf 引擎
+	EngineErrorPolicy ErrorPolicy    // 引擎内部错误处理策略
+	SendOverflow      OverflowPolicy // 客户端发送队列满时的处理策略
+	AlertWebhook      string         // 运维告警 webhook 地址，为空时只记录日志
+	StateDir          string         // 房间快照目录，为空时不保存
+	SnapshotRedis     string         // 房间快照改存到该 Redis，优先于 StateDir，为空时不使用
+	SnapshotInterval  time.Duration  // 对局进行中定期保存快照的间隔，用于崩溃后恢复，0 表示只在关闭时保存
+	SummaryWebhook    string         // 对局战报默认投递地址，房间可单独覆盖，为空时不投递
+	ReplayBaseURL     string         // 战报中回放链接的前缀，为空时不附带链接
+	AdminAddr         string         // 管理接口监听地址，为空时不启用
+	AdminToken        string         // 管理接口的 Bearer token，为空时不校验
+	MetricsAddr       string         // Prometheus /metrics 监听地址，为空时不启用
+	TraceEndpoint     string         // OTLP/HTTP 链路追踪导出地址，为空时不启用
+	GRPCAddr          string         // gRPC 接口监听地址，为空时不启用
+	ViewerAddr        string         // 只读观看（SSE）接口监听地址，为空时不启用
+	HealthAddr        string         // 健康检查（/healthz、/readyz）监听地址，为空时不启用
+	ViewerDelay       time.Duration  // 观看者收到广播的延迟，防止观众给场上玩家报信，0 表示实时
+	RoomMemoryLimit   int64          // 堆内存超过该值（字节）时优先回收占用大的已结束房间，0 表示不限制
+	ReconnectGrace    time.Duration  // 对局中断线后保留座位等待重连的时长，0 表示断线即弃局
+	HeartbeatTimeout  time.Duration  // 开启心跳的连接超过该时长没有任何消息即断开，0 表示不检查
+	SpeakTimeout      time.Duration  // 白天轮流发言时每人的时限，超时跳过，0 表示不限时
+	AFKMisses         int            // 连续错过多少次限时行动后视为挂机并自动跳过其回合，0 表示不检测
+	ProfanityWords    []string       // 发言过滤的词表，为空时不过滤
+	ProfanityPolicy   FilterPolicy   // 发言命中词表时打码或整条拦下
+	ProfanityMute     int            // 房间里累计多少次不当发言后禁言，0 表示不禁言
+	ProfanityKick     int            // 累计多少次不当发言后移出房间，0 表示不移出
+	TelemetryURL      string         // 匿名使用统计上报地址，为空时不上报（默认关闭）
+	TelemetryInterval time.Duration  // 使用统计的上报周期
+	MaxRooms          int            // 同时存在的房间数上限，0 表示不限制
+	MaxPlayers        int            // 同时在线的玩家数上限，断线重连不受限制，0 表示不限制
+	MaxRoomsPerPlayer int            // 每名玩家同时拥有的未结束房间数上限，0 表示不限制
+	MaxRoomSize       int            // 房间最多的座位数，不能超过 18，0 表示 18
+	MaxConnsPerIP     int            // 同一 IP 的连接数上限，0 表示不限制
+	StartCountdown    time.Duration  // 全员准备后到开局的倒计时，0 表示立即开局
+	RegistryRedis     string         // 多进程部署时房间路由表所在的 Redis，为空时单机运行
+	WorkerAddr        string         // 登记到路由表的本节点地址，网关按此转发，默认为监听地址
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		EngineErrorPolicy: ErrorPolicyPause,
+		SendOverflow:      OverflowDisconnect,
+		ReconnectGrace:    60 * time.Second,
+		HeartbeatTimeout:  30 * time.Second,
+		SpeakTimeout:      90 * time.Second,
+		AFKMisses:         2,
+		ProfanityPolicy:   FilterMask,
+		ProfanityMute:     3,
+		ProfanityKick:     5,
+		TelemetryInterval: 24 * time.Hour,
+		StartCountdown:    5 * time.Second,
+		MaxRoomSize:       maxRoomPlayers,
+		SnapshotInterval:  10 * time.Second,
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// startCountdown 全员准备后的开局倒计时，倒计时期间房间处于 STARTING，由 r.mu 保护
+type startCountdown struct {
+	timer *time.Timer
+	epoch int // 每次开始或取消倒计时加一，过期的计时器据此失效
+}
+
+// active 倒计时是否正在进行
+func (c *startCountdown) active() bool {
+	return c.timer != nil
+}
+
+// startResult 开局请求的结果
+type startResult int
+
+const (
+	startNotReady       startResult = iota // 还有空座或未准备的玩家
+	startCountingDown                      // 开始了倒计时，或倒计时已在进行
+	startStarted                           // 这次请求开了局
+	startAlreadyStarted                    // 房间已经开局，由其他请求开的
+)
+
+// tryStart 全员准备后开局的唯一入口：配置了倒计时时开始倒计时，否则立即开局
+//
+// 准备、补机器人、再来一局都可能同时触发开局，检查和开局在同一次持锁内完成，
+// 只有一个请求能开局，其余按结果区分，不必比较错误信息。
+func (r *Room) tryStart() (startResult, error) {
+	r.mu.Lock()
+	switch {
+	case r.State == RoomStateStarting:
+		r.mu.Unlock()
+		return startCountingDown, nil
+	case r.State != RoomStateWaiting:
+		r.mu.Unlock()
+		return startAlreadyStarted, nil
+	case !r.canStart():
+		r.mu.Unlock()
+		return startNotReady, nil
+	}
+
+	if r.startDelay <= 0 {
+		defer r.mu.Unlock()
+		if err := r.begin(); err != nil {
+			return startNotReady, err
+		}
+		return startStarted, nil
+	}
+
+	if err := r.transition(RoomStateStarting); err != nil {
+		r.mu.Unlock()
+		return startNotReady, err
+	}
+
+	delay := r.scaled(r.startDelay)
+	r.countdown.epoch++
+	epoch := r.countdown.epoch
+	r.countdown.timer = time.AfterFunc(delay, func() { r.finishCountdown(epoch) })
+
+	msg, _ := protocol.NewMessage(protocol.MsgGameStarting, protocol.GameStartingData{
+		Seconds: int(delay.Seconds()),
+	})
+	r.mu.Unlock()
+
+	r.logger.Info("game start countdown", "delay", delay)
+	r.BroadcastMessage(msg)
+
+	return startCountingDown, nil
+}
+
+// finishCountdown 倒计时结束，有人在最后时刻取消准备时不开局
+func (r *Room) finishCountdown(epoch int) {
+	r.mu.Lock()
+	if epoch != r.countdown.epoch || r.State != RoomStateStarting {
+		r.mu.Unlock()
+		return
+	}
+	r.countdown.timer = nil
+
+	if !r.canStart() {
+		r.transition(RoomStateWaiting)
+		r.mu.Unlock()
+		r.BroadcastMessage(countdownCancelledMessage("有玩家未准备"))
+		return
+	}
+
+	err := r.begin()
+	r.mu.Unlock()
+
+	if err != nil {
+		r.logger.Error("failed to start game after countdown", "error", err)
+	}
+}
+
+// cancelCountdown 有玩家取消准备或离开时中止倒计时
+func (r *Room) cancelCountdown(reason string) {
+	r.mu.Lock()
+	msg := r.stopCountdown(reason)
+	r.mu.Unlock()
+
+	if msg != nil {
+		r.BroadcastMessage(msg)
+	}
+}
+
+// stopCountdown 停止倒计时，返回要广播的取消消息，没有倒计时时返回 nil（需持有锁）
+func (r *Room) stopCountdown(reason string) *protocol.Message {
+	if !r.countdown.active() {
+		return nil
+	}
+
+	r.countdown.timer.Stop()
+	r.countdown.timer = nil
+	r.countdown.epoch++
+	r.transition(RoomStateWaiting)
+
+	r.logger.Info("game start countdown cancelled", "reason", reason)
+
+	return countdownCancelledMessage(reason)
+}
+
+// countdownCancelledMessage 倒计时取消消息
+func countdownCancelledMessage(reason string) *protocol.Message {
+	msg, _ := protocol.NewMessage(protocol.MsgGameStarting, protocol.GameStartingData{
+		Cancelled: true,
+		Reason:    fmt.Sprintf("开局倒计时已取消：%s", reason),
+	})
+	return msg
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// secondTargetKey 动作数据中第二个目标的键，见 PerformActionData.SecondTargetID
+const secondTargetKey = "secondTargetID"
+
+// secondTarget 动作数据中的第二个目标
+func secondTarget(data map[string]interface{}) string {
+	id, _ := data[secondTargetKey].(string)
+	return id
+}
+
+// canLink 玩家是否是第一夜还没连接恋人的丘比特
+func (r *Room) canLink(playerID string, phase werewolf.PhaseType, round int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.special.roles[playerID] == protocol.RoleTypeCupid && r.special.lovers[0] == "" &&
+		publicPhase(phase) == werewolf.PhaseNight && round == 1
+}
+
+// linkLovers 丘比特第一夜连接两名玩家成为恋人，恋人私下得知对方
+//
+// 可以连接自己。连接由房间结算，不经过引擎，也不计入重放的动作记录。
+func (r *Room) linkLovers(cupidID, firstID, secondID string) error {
+	if r.specialRole(cupidID) != protocol.RoleTypeCupid {
+		return rejected(RejectOther, errors.New("only cupid can link lovers"))
+	}
+
+	state := r.Engine().GetState()
+	if publicPhase(state.Phase) != werewolf.PhaseNight || state.Round != 1 {
+		return rejected(RejectWrongPhase, errors.New("lovers can only be linked on the first night"))
+	}
+	if firstID == "" || secondID == "" || firstID == secondID {
+		return rejected(RejectInvalidTarget, errors.New("link needs two different players"))
+	}
+	if !r.isAlive(firstID) || !r.isAlive(secondID) {
+		return rejected(RejectInvalidTarget, errors.New("lovers must be alive"))
+	}
+
+	r.mu.Lock()
+	if r.special.lovers[0] != "" {
+		r.mu.Unlock()
+		return rejected(RejectRule, errors.New("lovers are already linked"))
+	}
+	r.special.lovers = [2]string{firstID, secondID}
+	r.mu.Unlock()
+
+	r.logEvent(protocol.EventLoversLinked, "丘比特连接了恋人", map[string]interface{}{
+		"cupidID": cupidID,
+		"lovers":  []string{firstID, secondID},
+	})
+
+	for _, pair := range [][2]string{{firstID, secondID}, {secondID, firstID}} {
+		msg, _ := protocol.NewMessage(protocol.MsgGameEvent, protocol.GameEventData{
+			EventType: protocol.EventLoversLinked,
+			Event:     protocol.EventKeyLoversLinked,
+			Params:    map[string]string{"partner": pair[1]},
+		})
+
+		r.mu.RLock()
+		player := r.Players[pair[0]]
+		r.mu.RUnlock()
+
+		if player != nil {
+			player.SendMessage(msg)
+		}
+	}
+
+	return nil
+}
+
+// loverOf 玩家的恋人，不是恋人时返回空（需持有锁）
+func (r *Room) loverOf(playerID string) string {
+	switch playerID {
+	case "":
+		return ""
+	case r.special.lovers[0]:
+		return r.special.lovers[1]
+	case r.special.lovers[1]:
+		return r.special.lovers[0]
+	default:
+		return ""
+	}
+}
+
+// loverSuicide 恋人一方出局后另一方殉情
+//
+// 殉情通过 slay 结算，对方已经出局时不再重复，连锁到此为止。
+func (r *Room) loverSuicide(playerID string) {
+	r.mu.RLock()
+	partner := r.loverOf(playerID)
+	r.mu.RUnlock()
+
+	if partner == "" || !r.isAlive(partner) {
+		return
+	}
+	if _, finished := r.Result(); finished {
+		return
+	}
+
+	r.slay(partner, protocol.GameEventData{
+		EventType: protocol.EventLoverSuicide,
+		Event:     protocol.EventKeyLoverSuicide,
+		Params:    map[string]string{"victim": partner, "partner": playerID},
+		Data: map[string]interface{}{
+			"playerID":  partner,
+			"partnerID": playerID,
+		},
+	})
+}
+
+// loversWin 分属两个阵营的恋人是否已经获胜：恋人都还存活，其余存活的只有丘比特
+func (r *Room) loversWin() bool {
+	state := r.Engine().GetState()
+	alive := r.activePlayers(state.AlivePlayers)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lovers := r.special.lovers
+	if lovers[0] == "" {
+		return false
+	}
+
+	camps := make(map[string]werewolf.Camp, len(state.Players))
+	for _, ps := range state.Players {
+		camps[ps.ID] = roleCamp(ps.Role)
+	}
+	if camps[lovers[0]] == camps[lovers[1]] {
+		return false
+	}
+
+	found := 0
+	for _, id := range alive {
+		switch {
+		case id == lovers[0] || id == lovers[1]:
+			found++
+		case r.special.roles[id] != protocol.RoleTypeCupid:
+			return false
+		}
+	}
+	return found == 2
+}
+
+// onLoversTeam 恋人阵营获胜时玩家是否算作胜方：两名恋人和丘比特
+func (r *Room) onLoversTeam(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.loverOf(playerID) != "" || r.special.roles[playerID] == protocol.RoleTypeCupid
+}
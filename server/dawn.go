@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// announceDawn 天亮时按死讯公布方式播报昨晚的结果
+//
+// 公布死讯时没人出局播报平安夜，有人出局列出出局的玩家；首夜不公布死讯时只播报天亮。
+func (r *Room) announceDawn(round int) {
+	r.mu.Lock()
+	deaths := r.nightDeaths
+	r.nightDeaths = nil
+	r.mu.Unlock()
+
+	event := protocol.GameEventData{
+		EventType: protocol.EventDawn,
+		Params:    map[string]string{"round": strconv.Itoa(round)},
+	}
+
+	switch {
+	case r.revealPolicy(round) == protocol.RevealNone:
+		event.Event = protocol.EventKeyDawnSilent
+	case len(deaths) == 0:
+		event.Event = protocol.EventKeyDawnPeaceful
+	default:
+		event.Event = protocol.EventKeyDawnDeaths
+		event.Params["victims"] = strings.Join(deaths, ",")
+	}
+
+	// 事件日志总是记下真实的出局名单，供复盘和管理接口查看
+	r.logEvent(event.EventType, eventText(event), map[string]interface{}{"round": round, "deaths": deaths})
+
+	msg, _ := protocol.NewMessage(protocol.MsgGameEvent, event)
+	r.BroadcastMessage(msg)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// ErrorPolicy 引擎内部错误处理策略
+type ErrorPolicy string
+
+const (
+	ErrorPolicyRestore ErrorPolicy = "restore" // 新建引擎重放动作记录，回到出错前的状态，失败则暂停
+	ErrorPolicyPause   ErrorPolicy = "pause"   // 暂停游戏等待运维处理
+	ErrorPolicyAbort   ErrorPolicy = "abort"   // 中止游戏并判平局
+)
+
+// ParseErrorPolicy 解析错误处理策略，旧名称 retry 按 restore 处理
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	switch policy := ErrorPolicy(s); policy {
+	case ErrorPolicyRestore, ErrorPolicyPause, ErrorPolicyAbort:
+		return policy, nil
+	case "retry":
+		return ErrorPolicyRestore, nil
+	default:
+		return "", errors.Errorf("unknown engine error policy: %s", s)
+	}
+}
+
+// EngineError 引擎内部错误，区别于玩家动作被规则拒绝
+type EngineError struct {
+	Op    string
+	Cause error
+}
+
+// Error 实现 error 接口
+func (e *EngineError) Error() string {
+	return fmt.Sprintf("engine %s: %v", e.Op, e.Cause)
+}
+
+// callEngine 调用引擎，把 panic 和引擎报告的内部故障（engine.ErrInternal）转换为 EngineError，
+// 规则拒绝等其他错误原样返回
+func callEngine(op string, fn func() error) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = &EngineError{Op: op, Cause: errors.Errorf("panic: %v", rec)}
+		}
+	}()
+
+	if err := fn(); err != nil {
+		if errors.Is(err, engine.ErrInternal) {
+			return &EngineError{Op: op, Cause: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// handleEngineError 按房间策略处理引擎内部错误，返回给触发者的错误
+//
+// 出错的动作可能已经改了一半引擎状态，重新提交可能被执行两次，因此不重试：restore 策略换用重放
+// 动作记录得到的新引擎，出错的动作不在记录里，由玩家按恢复后的状态重新提交。
+func (r *Room) handleEngineError(engineErr *EngineError) error {
+	r.logger.Error("engine internal error",
+		"op", engineErr.Op,
+		"policy", r.errorPolicy,
+		"error", engineErr.Cause)
+
+	r.alerter.Alert(Alert{
+		RoomID:  r.ID,
+		Kind:    protocol.ReasonEngineError,
+		Message: engineErr.Error(),
+		Time:    protocol.Now(),
+	})
+
+	policy := r.errorPolicy
+	if policy == ErrorPolicyRestore {
+		err := r.restoreEngine()
+		if err == nil {
+			r.logger.Info("engine restored from action log")
+			return &EngineError{Op: engineErr.Op, Cause: errors.New("internal game error, game restored, please submit again")}
+		}
+
+		// 恢复失败，退化为暂停
+		r.logger.Error("engine restore failed", "error", err)
+		policy = ErrorPolicyPause
+	}
+
+	switch policy {
+	case ErrorPolicyAbort:
+		r.abort(protocol.ReasonEngineError)
+	default:
+		r.pause(protocol.ReasonEngineError, engineErr.Error())
+	}
+
+	return &EngineError{Op: engineErr.Op, Cause: errors.New("internal game error")}
+}
+
+// restoreEngine 新建引擎，按当前的角色分配依座位顺序发牌并重放动作记录，替换出错的引擎
+//
+// 与重启后恢复快照相同，只记在房间的警长加权投票不交给引擎；新引擎在替换前发出的事件不会被处理
+// （见 subscribeEvents），房间的阶段、出局和事件日志保持出错前的状态。
+func (r *Room) restoreEngine() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.State != RoomStatePlaying {
+		return errors.Errorf("room is %s", r.State)
+	}
+
+	want := make(map[string]werewolf.RoleType, len(r.order))
+	var phase werewolf.PhaseType
+	var round int
+	if err := callEngine("get state", func() error {
+		state := r.Engine().GetState()
+		for _, ps := range state.Players {
+			want[ps.ID] = ps.Role
+		}
+		phase, round = publicPhase(state.Phase), state.Round
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "read role assignment")
+	}
+
+	roles := make([]werewolf.RoleType, 0, len(r.order))
+	for _, playerID := range r.order {
+		roles = append(roles, want[playerID])
+	}
+
+	eng, err := r.newGameEngine(roles)
+	if err != nil {
+		return err
+	}
+	if err := callEngine("start", eng.Start); err != nil {
+		return errors.Wrap(err, "start engine")
+	}
+
+	for _, ps := range eng.GetState().Players {
+		if want[ps.ID] != ps.Role {
+			return errors.New("engine did not deal roles in seat order")
+		}
+	}
+
+	for _, action := range r.actions {
+		if action.Held {
+			continue
+		}
+		if err := callEngine("replay action", func() error {
+			return eng.PerformAction(action.PlayerID, action.ActionType, action.TargetID, action.Data)
+		}); err != nil {
+			return errors.Wrap(err, "replay action")
+		}
+	}
+	if flusher, ok := eng.(engine.Flusher); ok {
+		flusher.Flush()
+	}
+
+	state := eng.GetState()
+	if publicPhase(state.Phase) != phase || state.Round != round {
+		return errors.Errorf("replay stopped at %s of round %d, want %s of round %d", state.Phase, state.Round, phase, round)
+	}
+
+	r.setEngine(eng)
+	return nil
+}
+
+// pause 暂停游戏并通知所有玩家，各步的计时与玩家请求的暂停一样停下，由运维通过管理接口继续或中止
+func (r *Room) pause(reason, message string) {
+	r.mu.Lock()
+	if r.State != RoomStatePlaying {
+		r.mu.Unlock()
+		return
+	}
+	r.transition(RoomStatePaused)
+	r.suspendTimers()
+	r.mu.Unlock()
+
+	msg, _ := protocol.NewMessage(protocol.MsgGamePaused, protocol.GamePausedData{
+		Reason:  reason,
+		Message: message,
+	})
+
+	r.BroadcastMessage(msg)
+
+	r.logger.Warn("game paused", "reason", reason)
+}
+
+// operatorResume 运维继续暂停的对局，例如引擎出错暂停、处理完毕之后
+//
+// 玩家请求的暂停同样可以继续；服务器重启后等待玩家重连的暂停有自己的时限，不由运维继续。
+func (r *Room) operatorResume() error {
+	r.mu.Lock()
+	switch {
+	case r.State != RoomStatePaused:
+		r.mu.Unlock()
+		return errors.New("game is not paused")
+	case len(r.awaiting) > 0:
+		r.mu.Unlock()
+		return errors.Errorf("still waiting for %d players to reconnect", len(r.awaiting))
+	}
+
+	r.transition(RoomStatePlaying)
+	r.resumeTimers()
+	r.pauses = pauseVote{}
+	r.mu.Unlock()
+
+	msg, _ := protocol.NewMessage(protocol.MsgGameResumed, protocol.GameResumedData{
+		Reason: protocol.ReasonOperator,
+	})
+	r.BroadcastMessage(msg)
+
+	r.logger.Warn("game resumed by operator")
+	r.resumed()
+
+	return nil
+}
+
+// operatorAbort 运维中止进行中或暂停的对局，以平局结束
+func (r *Room) operatorAbort() error {
+	r.mu.RLock()
+	inGame := r.State.inGame()
+	r.mu.RUnlock()
+
+	if !inGame {
+		return errors.New("game is not in progress")
+	}
+
+	r.abort(protocol.ReasonOperator)
+	return nil
+}
+
+// abort 中止游戏，以平局结束
+func (r *Room) abort(reason string) {
+	r.finish(werewolf.CampNone, reason)
+}
+
+// finish 由房间（而非引擎）判定结束对局并广播结果
+func (r *Room) finish(winner werewolf.Camp, reason string) {
+	r.mu.Lock()
+	if r.State.ended() {
+		r.mu.Unlock()
+		return
+	}
+	r.transition(RoomStateFinished)
+	r.winner = winner
+	r.mu.Unlock()
+
+	var players []protocol.PlayerInfo
+	if err := callEngine("get state", func() error {
+		players = r.playersInfo(r.Engine().GetState().Players, true)
+		return nil
+	}); err != nil {
+		players = r.GetPlayerList()
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgGameEnded, protocol.GameEndedData{
+		Winner:  winner,
+		Players: players,
+		Reason:  reason,
+	})
+
+	r.BroadcastMessage(msg)
+	r.logEvent(werewolf.EventGameEnded, "对局由房间判定结束: "+reason, map[string]interface{}{"winner": winner, "reason": reason})
+	ratings := r.recordStats(winner, players)
+	r.broadcastGameSummary(winner, reason, players, ratings)
+	r.sendSummary(winner, reason, players)
+
+	r.logger.Warn("game finished by room", "winner", winner, "reason", reason)
+}
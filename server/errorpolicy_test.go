@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// faultyEngine 第一次收到某种动作时照常交给模拟引擎，再报告内部故障，模拟动作只做了一半
+type faultyEngine struct {
+	engine.GameEngine
+	failOn werewolf.ActionType
+	failed atomic.Bool
+}
+
+// PerformAction 实现 GameEngine 接口
+func (e *faultyEngine) PerformAction(playerID string, actionType werewolf.ActionType, targetID string, data map[string]interface{}) error {
+	if err := e.GameEngine.PerformAction(playerID, actionType, targetID, data); err != nil {
+		return err
+	}
+	if actionType == e.failOn && e.failed.CompareAndSwap(false, true) {
+		return errors.Wrap(engine.ErrInternal, "state out of sync")
+	}
+	return nil
+}
+
+func TestEngineErrorPolicy(t *testing.T) {
+	tests := []struct {
+		policy    ErrorPolicy
+		wantState RoomState
+	}{
+		{policy: ErrorPolicyRestore, wantState: RoomStatePlaying},
+		{policy: ErrorPolicyPause, wantState: RoomStatePaused},
+		{policy: ErrorPolicyAbort, wantState: RoomStateFinished},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			tt.server.config.EngineErrorPolicy = tc.policy
+
+			// 只有开局的引擎会出错，恢复时新建的是正常的模拟引擎
+			var created atomic.Int32
+			tt.server.UseEngine(func(config werewolf.Config) engine.GameEngine {
+				if created.Add(1) == 1 {
+					return &faultyEngine{GameEngine: engine.NewMock(config), failOn: protocol.ActionCheck}
+				}
+				return engine.NewMock(config)
+			})
+
+			room := tt.start(fiveSeats...)
+			seer, wolf := tt.role(room, werewolf.RoleTypeSeer, 0), tt.role(room, werewolf.RoleTypeWerewolf, 0)
+
+			if result := tt.act(room, seer, protocol.ActionCheck, wolf); result.Success {
+				t.Fatal("check accepted despite the engine error")
+			}
+			room.mu.RLock()
+			state, actions := room.State, len(room.actions)
+			room.mu.RUnlock()
+			if state != tc.wantState {
+				t.Fatalf("room state = %s, want %s", state, tc.wantState)
+			}
+			if actions != 0 {
+				t.Errorf("actions recorded = %d after the failed check, want 0", actions)
+			}
+			if tc.policy != ErrorPolicyRestore {
+				return
+			}
+
+			// 出错的查验不在新引擎里，重新提交后第一夜照常结束
+			if result := tt.act(room, seer, protocol.ActionCheck, wolf); !result.Success {
+				t.Fatalf("check after restore rejected: %s", result.Message)
+			}
+			tt.act(room, tt.role(room, werewolf.RoleTypeGuard, 0), protocol.ActionProtect, seer)
+			tt.act(room, wolf, protocol.ActionKill, tt.role(room, werewolf.RoleTypeVillager, 0))
+			tt.waitPhase(room, werewolf.PhaseDay, 1)
+			if created.Load() != 2 {
+				t.Errorf("engines created = %d, want 2", created.Load())
+			}
+		})
+	}
+}
+
+func TestOperatorCommands(t *testing.T) {
+	tests := []struct {
+		command   string
+		wantState RoomState
+	}{
+		{command: "resume", wantState: RoomStatePlaying},
+		{command: "abort", wantState: RoomStateFinished},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.command, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			tt.server.config.EngineErrorPolicy = ErrorPolicyPause
+			tt.server.UseEngine(func(config werewolf.Config) engine.GameEngine {
+				return &faultyEngine{GameEngine: engine.NewMock(config), failOn: protocol.ActionSpeak}
+			})
+			admin := NewAdminHandler(tt.server, "")
+
+			room := tt.start(fiveSeats...)
+			seer := tt.role(room, werewolf.RoleTypeSeer, 0)
+			tt.act(room, seer, protocol.ActionCheck, tt.role(room, werewolf.RoleTypeWerewolf, 0))
+			tt.act(room, tt.role(room, werewolf.RoleTypeGuard, 0), protocol.ActionProtect, seer)
+			tt.act(room, tt.role(room, werewolf.RoleTypeWerewolf, 0), protocol.ActionKill, tt.role(room, werewolf.RoleTypeVillager, 0))
+			tt.waitPhase(room, werewolf.PhaseDay, 1)
+
+			// 第一位发言时引擎出错，对局暂停，发言计时随之停下
+			speaker, _ := room.currentSpeaker()
+			if result := tt.act(room, tt.server.GetPlayer(speaker), protocol.ActionSpeak, nil); result.Success {
+				t.Fatal("speech accepted despite the engine error")
+			}
+			room.mu.RLock()
+			state, suspended := room.State, len(room.pauses.suspended)
+			room.mu.RUnlock()
+			if state != RoomStatePaused || suspended != 1 {
+				t.Fatalf("state = %s with %d suspended timers, want PAUSED with the speaking timer", state, suspended)
+			}
+
+			rec := httptest.NewRecorder()
+			admin.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/rooms/"+room.ID+"/"+tc.command, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: status %d: %s", tc.command, rec.Code, rec.Body)
+			}
+			if status := room.Status(); status.State != tc.wantState {
+				t.Fatalf("room state = %s after %s, want %s", status.State, tc.command, tc.wantState)
+			}
+
+			// 房间已不在暂停中，再次继续被拒绝
+			rec = httptest.NewRecorder()
+			admin.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/rooms/"+room.ID+"/resume", nil))
+			if rec.Code != http.StatusConflict {
+				t.Errorf("second resume: status %d, want %d", rec.Code, http.StatusConflict)
+			}
+
+			if tc.wantState == RoomStatePlaying {
+				tt.speakAll(room)
+				tt.waitPhase(room, werewolf.PhaseVote, 1)
+			}
+		})
+	}
+}
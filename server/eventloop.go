@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+)
+
+// roomQueueSize 房间事件队列长度，队列满时拒绝新的动作而不是阻塞提交者
+const roomQueueSize = 64
+
+var (
+	// ErrRoomBusy 房间事件队列已满
+	ErrRoomBusy = errors.New("room is busy, try again later")
+	// ErrRoomClosed 房间已关闭，不再接受新的任务
+	ErrRoomClosed = errors.New("room is closed")
+)
+
+// submit 把任务放入房间事件循环，按提交顺序逐个执行，不等待执行完成
+//
+// 引擎结算和对全房间的广播都在事件循环里完成，提交动作的玩家连接不会被其他玩家的发送拖慢。
+func (r *Room) submit(task func()) error {
+	r.loopOnce.Do(func() {
+		r.spawn(r.runLoop)
+	})
+
+	select {
+	case <-r.closed:
+		return ErrRoomClosed
+	default:
+	}
+
+	select {
+	case r.tasks <- task:
+		return nil
+	default:
+		r.logger.Warn("room event queue full")
+		return ErrRoomBusy
+	}
+}
+
+// runLoop 房间事件循环，房间关闭时退出，未执行的任务被丢弃
+func (r *Room) runLoop() {
+	for {
+		select {
+		case task := <-r.tasks:
+			task()
+		case <-r.closed:
+			return
+		}
+	}
+}
+
+// Close 停止房间事件循环并把房间归档，可重复调用
+func (r *Room) Close() {
+	r.closeOnce.Do(func() {
+		r.mu.Lock()
+		r.transition(RoomStateArchived)
+		r.mu.Unlock()
+
+		close(r.closed)
+	})
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// logEvent 把一条对局事件记入房间的事件日志，记录当时对外公布的回合和阶段
+//
+// 日志保存完整信息（包括首夜不公开的死因），只在对局结束后随复盘发出。
+func (r *Room) logEvent(eventType werewolf.EventType, message string, data map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, protocol.GameEventRecord{
+		Round:     r.lastRound,
+		Phase:     r.lastPhase,
+		EventType: eventType,
+		Message:   message,
+		Data:      data,
+		Time:      protocol.Now(),
+	})
+}
+
+// EventLog 本局的事件日志
+func (r *Room) EventLog() []protocol.GameEventRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]protocol.GameEventRecord(nil), r.events...)
+}
+
+// broadcastGameSummary 对局结束后向所有玩家发送复盘，players 为带身份的最终玩家列表，ratings 为排位积分变化
+func (r *Room) broadcastGameSummary(winner werewolf.Camp, reason string, players []protocol.PlayerInfo, ratings map[string]int) {
+	summary := r.gameSummary(winner, reason, players)
+	summary.Ratings = ratings
+
+	msg, _ := protocol.NewMessage(protocol.MsgGameSummary, summary)
+	r.BroadcastMessage(msg)
+}
+
+// gameSummary 根据动作记录和事件日志整理复盘
+func (r *Room) gameSummary(winner werewolf.Camp, reason string, players []protocol.PlayerInfo) protocol.GameSummaryData {
+	roles := make(map[string]werewolf.RoleType, len(players))
+	for _, p := range players {
+		roles[p.ID] = p.RoleType
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	summary := protocol.GameSummaryData{
+		Winner:  winner,
+		Reason:  reason,
+		Rounds:  r.lastRound,
+		Players: players,
+		Nights:  []protocol.NightTimeline{},
+		Votes:   []protocol.VoteRound{},
+		Events:  append([]protocol.GameEventRecord{}, r.events...),
+	}
+
+	nights := make(map[int]*protocol.NightTimeline)
+	votes := make(map[int]*protocol.VoteRound)
+	night := func(round int) *protocol.NightTimeline {
+		if nights[round] == nil {
+			nights[round] = &protocol.NightTimeline{Round: round}
+		}
+		return nights[round]
+	}
+	vote := func(round int) *protocol.VoteRound {
+		if votes[round] == nil {
+			votes[round] = &protocol.VoteRound{Round: round, Ballots: make(map[string]string)}
+		}
+		return votes[round]
+	}
+
+	// 每晚的行动；解药救的是当晚最终的刀口，天亮前改刀以最后一次为准
+	saved := make(map[int]bool)
+	actions := finalActions(r.actions)
+	for _, action := range actions {
+		switch action.ActionType {
+		case protocol.ActionKill:
+			night(action.Round).Killed = action.TargetID
+		case protocol.ActionAntidote:
+			saved[action.Round] = true
+		case protocol.ActionPoison:
+			night(action.Round).Poisoned = action.TargetID
+		case protocol.ActionProtect:
+			night(action.Round).Protected = action.TargetID
+		case protocol.ActionCheck:
+			n := night(action.Round)
+			n.Checked = action.TargetID
+			n.CheckedCamp = roleCamp(roles[action.TargetID])
+		case protocol.ActionVote:
+			if action.ballot() {
+				vote(action.Round).Ballots[action.PlayerID] = action.TargetID
+			}
+		}
+	}
+	for round := range saved {
+		night(round).Saved = night(round).Killed
+	}
+
+	// 死亡事件：放逐阶段出局的是被放逐的玩家，其余算作最近一晚的死讯
+	nightRound, voteRound := 0, 0
+	for _, event := range r.events {
+		switch event.EventType {
+		case werewolf.EventPhaseStarted:
+			switch event.Phase {
+			case werewolf.PhaseNight:
+				nightRound = event.Round
+			case werewolf.PhaseVote:
+				voteRound = event.Round
+			}
+		case werewolf.EventPlayerDied:
+			playerID, _ := event.Data["playerID"].(string)
+			if playerID == "" {
+				continue
+			}
+			if event.Phase == werewolf.PhaseVote {
+				vote(voteRound).Exiled = playerID
+			} else if nightRound > 0 {
+				n := night(nightRound)
+				n.Deaths = append(n.Deaths, playerID)
+			}
+		}
+	}
+
+	for _, n := range nights {
+		summary.Nights = append(summary.Nights, *n)
+	}
+	sort.Slice(summary.Nights, func(i, j int) bool { return summary.Nights[i].Round < summary.Nights[j].Round })
+	for _, v := range votes {
+		summary.Votes = append(summary.Votes, *v)
+	}
+	sort.Slice(summary.Votes, func(i, j int) bool { return summary.Votes[i].Round < summary.Votes[j].Round })
+
+	summary.Stats, summary.MVP = r.playerStats(winner, players, roles, actions, summary.Nights, summary.Votes)
+
+	return summary
+}
+
+// playerStats 统计每名玩家的表现并评选 MVP（需持有锁）
+//
+// 投给对方阵营的放逐票各得 1 分；查到狼人、毒到狼人、救下或守中刀口、刀人成功各得 2 分；
+// 存活到最后得 1 分。MVP 是胜方得分最高的玩家，同分时取座位靠前的；平局时在所有玩家中评选。
+// actions 为 finalActions 过滤后的动作，阶段内改选过的只算最终选择。
+func (r *Room) playerStats(winner werewolf.Camp, players []protocol.PlayerInfo, roles map[string]werewolf.RoleType,
+	actions []ActionRecord, nights []protocol.NightTimeline, votes []protocol.VoteRound) ([]protocol.PlayerStats, string) {
+	stats := make(map[string]*protocol.PlayerStats, len(players))
+	for _, p := range players {
+		stats[p.ID] = &protocol.PlayerStats{PlayerID: p.ID, Survived: p.IsAlive}
+	}
+
+	hit := func(playerID string) {
+		if s := stats[playerID]; s != nil {
+			s.NightHits++
+		}
+	}
+
+	for _, round := range votes {
+		for voter, target := range round.Ballots {
+			if s := stats[voter]; s != nil && roleCamp(roles[voter]) != roleCamp(roles[target]) {
+				s.AccurateVotes++
+			}
+		}
+	}
+
+	for _, n := range nights {
+		died := make(map[string]bool, len(n.Deaths))
+		for _, id := range n.Deaths {
+			died[id] = true
+		}
+
+		for _, action := range actions {
+			if action.Round != n.Round {
+				continue
+			}
+			switch action.ActionType {
+			case protocol.ActionCheck:
+				if roleCamp(roles[action.TargetID]) == werewolf.CampEvil {
+					hit(action.PlayerID)
+				}
+			case protocol.ActionPoison:
+				if roleCamp(roles[action.TargetID]) == werewolf.CampEvil {
+					hit(action.PlayerID)
+				}
+			case protocol.ActionAntidote:
+				if n.Killed != "" && !died[n.Killed] {
+					hit(action.PlayerID)
+				}
+			case protocol.ActionProtect:
+				if action.TargetID == n.Killed && !died[n.Killed] {
+					hit(action.PlayerID)
+				}
+			}
+		}
+
+		// 刀口出局时，最后一次选择了这个刀口的狼人都算刀人成功
+		if n.Killed != "" && died[n.Killed] {
+			choices := make(map[string]string)
+			for _, action := range actions {
+				if action.Round == n.Round && action.ActionType == protocol.ActionKill {
+					choices[action.PlayerID] = action.TargetID
+				}
+			}
+			for wolfID, target := range choices {
+				if target == n.Killed {
+					hit(wolfID)
+				}
+			}
+		}
+	}
+
+	result := make([]protocol.PlayerStats, 0, len(players))
+	mvp, best := "", -1
+	for _, playerID := range r.order {
+		s := stats[playerID]
+		if s == nil {
+			continue
+		}
+
+		s.Score = s.AccurateVotes + 2*s.NightHits
+		if s.Survived {
+			s.Score++
+		}
+		result = append(result, *s)
+
+		eligible := winner == werewolf.CampNone || roleCamp(roles[playerID]) == winner
+		if eligible && s.Score > best {
+			mvp, best = playerID, s.Score
+		}
+	}
+
+	return result, mvp
+}
+
+// getRoomEvents 查询房间本局的事件日志
+func (h *AdminHandler) getRoomEvents(w http.ResponseWriter, req *http.Request) {
+	room := h.server.GetRoom(req.PathValue("id"))
+	if room == nil {
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, room.EventLog())
+}
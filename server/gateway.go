@@ -0,0 +1,448 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/pkg/errors"
+)
+
+// gatewayDialTimeout 连接工作节点的时限
+const gatewayDialTimeout = 5 * time.Second
+
+// gatewaySwitchTimeout 换到另一个节点时重放握手和登录的时限
+const gatewaySwitchTimeout = 10 * time.Second
+
+// gatewayHandshakeBuffer 重放登录期间暂存的节点消息数
+const gatewayHandshakeBuffer = 64
+
+// runGateway 启动连接网关，直到收到 SIGINT/SIGTERM
+func runGateway(args []string) error {
+	fs := flag.NewFlagSet("gateway", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8888", "gateway address clients connect to")
+	workers := fs.String("workers", "", "comma separated game server addresses (their -worker-addr)")
+	flags := registerConfigFlags(fs, "info")
+	fs.Parse(args)
+
+	var list []string
+	for _, worker := range strings.Split(*workers, ",") {
+		if worker = strings.TrimSpace(worker); worker != "" {
+			list = append(list, worker)
+		}
+	}
+	if len(list) == 0 {
+		return errors.New("at least one worker is required")
+	}
+
+	registry := newRoomRegistry(*flags.registryRedis)
+	if registry == nil && len(list) > 1 {
+		return errors.New("more than one worker needs -registry-redis to route players to their rooms")
+	}
+
+	logger, err := flags.logger()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+
+	gateway := NewGateway(list, registry, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		gateway.Close()
+	}()
+
+	logger.Info("gateway started", "addr", *addr, "workers", list)
+
+	return gateway.Serve(listener)
+}
+
+// Gateway 连接网关：接受客户端连接，把消息转发到玩家所在房间的工作节点
+//
+// 房间只属于一个工作节点，节点在路由表中登记自己的房间（见 registry.go）。新连接按轮询分配节点，
+// 加入另一个节点上的房间或凭会话令牌重连时，网关在目标节点上重放握手和登录后换过去，
+// 客户端不需要知道有多个节点。
+type Gateway struct {
+	workers  []string
+	registry *roomRegistry // 只有一个节点时可以为 nil
+	next     atomic.Uint64
+	listener net.Listener
+	closing  atomic.Bool
+	logger   *slog.Logger
+}
+
+// NewGateway 创建连接网关
+func NewGateway(workers []string, registry *roomRegistry, logger *slog.Logger) *Gateway {
+	return &Gateway{
+		workers:  workers,
+		registry: registry,
+		logger:   logger.With("component", "gateway"),
+	}
+}
+
+// Serve 接受客户端连接，直到 Close
+func (g *Gateway) Serve(ln net.Listener) error {
+	g.listener = ln
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if g.closing.Load() {
+				return nil
+			}
+			return err
+		}
+
+		tcpConn, ok := conn.(*net.TCPConn)
+		if !ok {
+			g.logger.Error("unsupported connection type, only TCP is supported", "addr", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		go g.handleConnection(tcpConn)
+	}
+}
+
+// Close 停止接受连接
+func (g *Gateway) Close() {
+	g.closing.Store(true)
+	if g.listener != nil {
+		g.listener.Close()
+	}
+}
+
+// pickWorker 按轮询给新连接分配节点
+func (g *Gateway) pickWorker() string {
+	return g.workers[int(g.next.Add(1)-1)%len(g.workers)]
+}
+
+// gatewaySession 一条客户端连接和它当前转发到的节点
+type gatewaySession struct {
+	gateway *Gateway
+	client  *socket.Conn
+	tcp     *net.TCPConn
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	upstream *upstreamConn
+
+	// 换节点时重放的握手和登录，只在客户端消息的处理协程中读写
+	hello *protocol.Message
+	login *protocol.Message
+}
+
+// upstreamConn 网关到一个工作节点的连接
+type upstreamConn struct {
+	worker string
+	tcp    *net.TCPConn
+	conn   *socket.Conn
+
+	mu        sync.Mutex
+	ready     bool                   // 重放完成，之后的消息直接转给客户端
+	handshake chan *protocol.Message // 重放期间节点发来的消息
+}
+
+// handleConnection 服务一条客户端连接，先连上轮询分配的节点
+func (g *Gateway) handleConnection(tcp *net.TCPConn) {
+	s := &gatewaySession{
+		gateway: g,
+		tcp:     tcp,
+		logger:  g.logger.With("addr", tcp.RemoteAddr()),
+	}
+
+	client, err := socket.NewConn(tcp,
+		socket.CustomCodecOption(protocol.NewCodec()),
+		socket.OnErrorOption(func(err error) bool {
+			s.logger.Debug("client connection error", "error", err)
+			return true
+		}),
+		socket.OnMessageOption(func(m socket.Message) error {
+			return s.fromClient(m.(*protocol.Message))
+		}),
+	)
+	if err != nil {
+		s.logger.Error("create connection error", "error", err)
+		tcp.Close()
+		return
+	}
+	s.client = client
+
+	up, err := s.dial(g.pickWorker())
+	if err != nil {
+		s.logger.Error("no worker available", "error", err)
+		tcp.Close()
+		return
+	}
+	up.mu.Lock()
+	up.ready = true
+	up.mu.Unlock()
+	s.mu.Lock()
+	s.upstream = up
+	s.mu.Unlock()
+
+	if err := client.Run(context.Background()); err != nil {
+		s.logger.Debug("client connection closed", "error", err)
+	}
+
+	s.mu.Lock()
+	up = s.upstream
+	s.mu.Unlock()
+	up.tcp.Close()
+}
+
+// dial 连接一个节点，节点发来的消息在重放期间暂存，之后转给客户端
+func (s *gatewaySession) dial(worker string) (*upstreamConn, error) {
+	conn, err := net.DialTimeout("tcp", worker, gatewayDialTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial worker %s", worker)
+	}
+
+	up := &upstreamConn{
+		worker:    worker,
+		tcp:       conn.(*net.TCPConn),
+		handshake: make(chan *protocol.Message, gatewayHandshakeBuffer),
+	}
+
+	up.conn, err = socket.NewConn(up.tcp,
+		socket.CustomCodecOption(protocol.NewCodec()),
+		socket.OnErrorOption(func(err error) bool {
+			s.logger.Debug("worker connection error", "worker", worker, "error", err)
+			return true
+		}),
+		socket.OnMessageOption(func(m socket.Message) error {
+			s.fromWorker(up, m.(*protocol.Message))
+			return nil
+		}),
+	)
+	if err != nil {
+		up.tcp.Close()
+		return nil, errors.Wrap(err, "create worker connection")
+	}
+
+	go func() {
+		up.conn.Run(context.Background())
+
+		// 当前节点断开时断开客户端，客户端按断线重连；换下来的旧节点断开不影响客户端
+		s.mu.Lock()
+		current := s.upstream == up
+		s.mu.Unlock()
+		if current {
+			s.logger.Warn("worker connection lost", "worker", worker)
+			s.tcp.Close()
+		}
+	}()
+
+	return up, nil
+}
+
+// fromWorker 节点发来的消息：重放期间暂存，之后只转发当前节点的消息
+func (s *gatewaySession) fromWorker(up *upstreamConn, msg *protocol.Message) {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	if !up.ready {
+		select {
+		case up.handshake <- msg:
+		default:
+			s.logger.Warn("handshake buffer full, message dropped", "worker", up.worker, "type", msg.Type)
+		}
+		return
+	}
+
+	s.mu.Lock()
+	current := s.upstream == up
+	s.mu.Unlock()
+	if current {
+		s.client.Write(msg)
+	}
+}
+
+// fromClient 客户端发来的消息：记下握手和登录，加入房间或凭令牌重连时先换到对应的节点，再转发
+func (s *gatewaySession) fromClient(msg *protocol.Message) error {
+	switch msg.Type {
+	case protocol.MsgHello:
+		s.hello = msg
+
+	case protocol.MsgLogin, protocol.MsgRegister:
+		var data protocol.LoginData
+		if msg.Type == protocol.MsgLogin && msg.UnmarshalData(&data) == nil && data.SessionToken != "" {
+			if !s.route(msg, s.gateway.sessionWorker(data.SessionToken)) {
+				return nil
+			}
+		}
+		s.login = msg
+
+	case protocol.MsgJoinRoom:
+		var data protocol.JoinRoomData
+		if msg.UnmarshalData(&data) == nil && data.RoomID != "" {
+			if !s.route(msg, s.gateway.roomWorker(data.RoomID)) {
+				return nil
+			}
+		}
+	}
+
+	s.mu.Lock()
+	up := s.upstream
+	s.mu.Unlock()
+
+	return up.conn.Write(msg)
+}
+
+// sessionWorker 会话令牌所在的节点，不知道时返回空
+func (g *Gateway) sessionWorker(token string) string {
+	if g.registry == nil {
+		return ""
+	}
+
+	worker, err := g.registry.sessionWorker(token)
+	if err != nil {
+		g.logger.Warn("session route lookup failed", "error", err)
+	}
+	return worker
+}
+
+// roomWorker 房间所在的节点，不知道时返回空
+func (g *Gateway) roomWorker(roomID string) string {
+	if g.registry == nil {
+		return ""
+	}
+
+	worker, err := g.registry.roomWorker(roomID)
+	if err != nil {
+		g.logger.Warn("room route lookup failed", "roomID", roomID, "error", err)
+	}
+	return worker
+}
+
+// route 需要时换到 worker 节点，返回是否可以继续转发 msg；换不过去时直接回复客户端错误
+func (s *gatewaySession) route(msg *protocol.Message, worker string) bool {
+	s.mu.Lock()
+	current := s.upstream
+	s.mu.Unlock()
+
+	if worker == "" || worker == current.worker {
+		return true
+	}
+	if !slices.Contains(s.gateway.workers, worker) {
+		s.logger.Warn("route points to an unknown worker", "worker", worker)
+		return true
+	}
+
+	if err := s.switchTo(worker); err != nil {
+		s.logger.Warn("switch worker failed", "from", current.worker, "to", worker, "error", err)
+		errMsg, _ := protocol.NewErrorMessage("the server hosting this room is unavailable, please try again")
+		s.client.Write(errMsg.ReplyTo(msg))
+		return false
+	}
+
+	s.logger.Info("player moved to worker", "from", current.worker, "to", worker)
+	return true
+}
+
+// switchTo 在 worker 节点上重放握手和登录，成功后换过去并断开原来的节点
+//
+// 注册在重放时换成登录；游客在新节点上会拿到新的玩家 ID，LOGIN_SUCCESS 照常转给客户端。
+// 凭会话令牌登录的连接没有可重放的凭据，不能换节点。
+func (s *gatewaySession) switchTo(worker string) error {
+	replay, err := s.replayLogin()
+	if err != nil {
+		return err
+	}
+
+	up, err := s.dial(worker)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.After(gatewaySwitchTimeout)
+	await := func(want protocol.MessageType) (*protocol.Message, error) {
+		for {
+			select {
+			case msg := <-up.handshake:
+				switch msg.Type {
+				case want:
+					return msg, nil
+				case protocol.MsgError:
+					return nil, errors.Errorf("worker rejected %s", want)
+				}
+			case <-deadline:
+				return nil, errors.Errorf("timed out waiting for %s", want)
+			}
+		}
+	}
+
+	var forward []*protocol.Message
+	if s.hello != nil {
+		up.conn.Write(s.hello)
+		if _, err := await(protocol.MsgHello); err != nil {
+			up.tcp.Close()
+			return err
+		}
+	}
+	if replay != nil {
+		up.conn.Write(replay)
+		loginMsg, err := await(protocol.MsgLoginSuccess)
+		if err != nil {
+			up.tcp.Close()
+			return err
+		}
+		forward = append(forward, loginMsg)
+	}
+
+	// 换过去之后节点发来的消息直接转给客户端，重放期间暂存的先转
+	s.mu.Lock()
+	old := s.upstream
+	s.upstream = up
+	s.mu.Unlock()
+
+	up.mu.Lock()
+	up.ready = true
+	for _, msg := range forward {
+		s.client.Write(msg)
+	}
+	for len(up.handshake) > 0 {
+		s.client.Write(<-up.handshake)
+	}
+	up.mu.Unlock()
+
+	old.tcp.Close()
+	return nil
+}
+
+// replayLogin 换节点时重放的登录消息，还没登录时为 nil
+func (s *gatewaySession) replayLogin() (*protocol.Message, error) {
+	if s.login == nil {
+		return nil, nil
+	}
+
+	var data protocol.LoginData
+	if err := s.login.UnmarshalData(&data); err != nil {
+		return nil, err
+	}
+	if data.SessionToken != "" {
+		return nil, errors.New("a session resumed by token cannot move to another worker")
+	}
+
+	return protocol.NewMessage(protocol.MsgLogin, protocol.LoginData{
+		Username: data.Username,
+		Password: data.Password,
+	})
+}
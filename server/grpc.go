@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// grpcServiceName gRPC 服务名，与 protocol/werewolf.proto 一致
+const grpcServiceName = "werewolf.v1.Game"
+
+// sessionTokenMetadataKey 登录以外的调用在 metadata 中携带 Login 返回的会话令牌
+const sessionTokenMetadataKey = "session-token"
+
+const (
+	// grpcReplyTimeout 一元调用等待服务器响应的最长时间，动作要经过房间事件循环，留足余量
+	grpcReplyTimeout = 5 * time.Second
+	// grpcStreamTimeout 登录后必须在这段时间内打开 GameEvents，否则按断线处理
+	grpcStreamTimeout = 30 * time.Second
+)
+
+// GRPCService 面向程序化客户端的 gRPC 接口，与 TCP 协议共用同一个 Server
+//
+// 请求和响应都是 google.protobuf.Struct，字段与 TCP 协议的消息数据一致，不需要生成代码。
+// 一元调用返回与请求关联的那条响应消息；其余消息（广播、阶段变化等）都从 GameEvents 流推送，
+// 与 TCP 连接收到的消息完全相同。GameEvents 流结束即视为断线。
+type GRPCService struct {
+	server *Server
+
+	mu       sync.Mutex
+	sessions map[string]*grpcSession // 会话令牌 -> 会话
+}
+
+// NewGRPCServer 创建注册了游戏服务的 gRPC 服务器
+func (s *Server) NewGRPCServer() *grpc.Server {
+	gs := grpc.NewServer()
+	gs.RegisterService(&gameServiceDesc, &GRPCService{
+		server:   s,
+		sessions: make(map[string]*grpcSession),
+	})
+	return gs
+}
+
+// gameServiceDesc 手写的服务描述，对应 protocol/werewolf.proto
+var gameServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Login", (*GRPCService).login),
+		unaryMethod("CreateRoom", (*GRPCService).createRoom),
+		unaryMethod("JoinRoom", (*GRPCService).joinRoom),
+		unaryMethod("PerformAction", (*GRPCService).performAction),
+	},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "GameEvents",
+		ServerStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			if err := stream.RecvMsg(new(structpb.Struct)); err != nil {
+				return err
+			}
+			return srv.(*GRPCService).gameEvents(stream)
+		},
+	}},
+	Metadata: "protocol/werewolf.proto",
+}
+
+// unaryMethod 把 Struct 进 Struct 出的方法包装成 gRPC 一元方法
+func unaryMethod(name string, call func(*GRPCService, context.Context, *structpb.Struct) (*structpb.Struct, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(structpb.Struct)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+
+			handler := func(ctx context.Context, req any) (any, error) {
+				return call(srv.(*GRPCService), ctx, req.(*structpb.Struct))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + grpcServiceName + "/" + name}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+// grpcSession 一个 gRPC 客户端的登录会话，相当于一条 TCP 连接
+type grpcSession struct {
+	service  *GRPCService
+	playerID string
+	token    string
+	queue    *sendQueue
+	events   chan *protocol.Message // 等待 GameEvents 流发出的消息
+	release  func()                 // 归还同一 IP 的连接名额
+
+	mu        sync.Mutex
+	waiters   map[string]chan *protocol.Message // 请求ID -> 等待响应的一元调用
+	streaming bool
+
+	done  chan struct{}
+	close func()
+}
+
+// newSession 创建会话和它的发送队列
+func (g *GRPCService) newSession(release func()) *grpcSession {
+	session := &grpcSession{
+		service: g,
+		events:  make(chan *protocol.Message, sendQueueSize),
+		release: release,
+		waiters: make(map[string]chan *protocol.Message),
+		done:    make(chan struct{}),
+	}
+	session.close = sync.OnceFunc(session.shutdown)
+	session.queue = newSendQueue(session.write, g.server.config.SendOverflow, func() { session.close() },
+		g.server.metrics, g.server.logger.With("transport", "grpc"))
+	return session
+}
+
+// write 发送队列的写函数：先交给等待响应的一元调用，再放入事件流
+func (gs *grpcSession) write(m socket.Message) error {
+	msg, ok := m.(*protocol.Message)
+	if !ok {
+		return nil
+	}
+
+	// 动作排队确认之后还会收到 ACTION_RESULT，一元调用等的是最终结果
+	if msg.CorrelationID != "" && msg.Type != protocol.MsgActionAccepted {
+		gs.mu.Lock()
+		waiter := gs.waiters[msg.CorrelationID]
+		gs.mu.Unlock()
+
+		if waiter != nil {
+			select {
+			case waiter <- msg:
+			default:
+			}
+		}
+	}
+
+	select {
+	case gs.events <- msg:
+		return nil
+	case <-gs.done:
+		return errSendQueueClosed
+	}
+}
+
+// shutdown 结束会话：停止发送队列，玩家仍接在这个会话上时按断线处理
+func (gs *grpcSession) shutdown() {
+	close(gs.done)
+	gs.queue.stop()
+	gs.release()
+
+	g := gs.service
+	g.mu.Lock()
+	if g.sessions[gs.token] == gs {
+		delete(g.sessions, gs.token)
+	}
+	g.mu.Unlock()
+
+	// 同一会话令牌已经在新的会话上重连时，不能把玩家移除
+	if player := g.server.GetPlayer(gs.playerID); player != nil && player.queue() == gs.queue {
+		g.server.RemovePlayer(gs.playerID)
+	}
+
+	g.server.logger.Info("grpc session closed", "playerID", gs.playerID)
+}
+
+// call 交给 handle 处理请求，等待与请求关联的响应
+func (gs *grpcSession) call(ctx context.Context, msg *protocol.Message, handle func() error) (*structpb.Struct, error) {
+	reply := make(chan *protocol.Message, 1)
+
+	gs.mu.Lock()
+	gs.waiters[msg.ID] = reply
+	gs.mu.Unlock()
+
+	defer func() {
+		gs.mu.Lock()
+		delete(gs.waiters, msg.ID)
+		gs.mu.Unlock()
+	}()
+
+	if err := handle(); err != nil {
+		return nil, grpcError(err)
+	}
+
+	timer := time.NewTimer(grpcReplyTimeout)
+	defer timer.Stop()
+
+	select {
+	case resp := <-reply:
+		return messageStruct(resp)
+	case <-timer.C:
+		return nil, status.Error(codes.DeadlineExceeded, "no reply from server")
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-gs.done:
+		return nil, status.Error(codes.Unavailable, "session closed")
+	}
+}
+
+// login 登录或注册，请求字段同 LOGIN（username、password、sessionToken），register 为 true 时按 REGISTER 处理
+//
+// 返回 LOGIN_SUCCESS，之后的调用在 metadata 中携带其中的 sessionToken。
+func (g *GRPCService) login(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	msgType := protocol.MsgLogin
+	if req.GetFields()["register"].GetBoolValue() {
+		msgType = protocol.MsgRegister
+	}
+
+	msg, err := requestMessage(msgType, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与 TCP 连接一样受同一 IP 的连接数限制
+	release := func() {}
+	if p, ok := peer.FromContext(ctx); ok {
+		if release, err = g.server.acquireConn(p.Addr); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+
+	session := g.newSession(release)
+
+	resp, err := session.call(ctx, msg, func() error {
+		player, err := g.server.login(msg, nil, session.queue, protocol.ProtocolVersion)
+		if err != nil {
+			return err
+		}
+
+		session.playerID, session.token = player.ID, player.SessionToken
+		return nil
+	})
+	if err != nil {
+		g.server.logger.Warn("grpc login failed", "type", msgType, "error", err)
+		session.close()
+		return nil, err
+	}
+
+	g.mu.Lock()
+	previous := g.sessions[session.token]
+	g.sessions[session.token] = session
+	g.mu.Unlock()
+
+	// 凭会话令牌重连时旧会话作废
+	if previous != nil {
+		previous.close()
+	}
+
+	// 登录后一直不打开事件流的客户端收不到任何消息，按断线处理
+	time.AfterFunc(grpcStreamTimeout, func() {
+		session.mu.Lock()
+		streaming := session.streaming
+		session.mu.Unlock()
+
+		if !streaming {
+			g.server.logger.Warn("grpc session never opened event stream", "playerID", session.playerID)
+			session.close()
+		}
+	})
+
+	g.server.logger.Info("grpc session opened", "playerID", session.playerID)
+
+	return resp, nil
+}
+
+// createRoom 创建房间，请求字段同 CREATE_ROOM，返回 ROOM_CREATED
+func (g *GRPCService) createRoom(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return g.handle(ctx, protocol.MsgCreateRoom, req)
+}
+
+// joinRoom 加入房间，请求字段同 JOIN_ROOM，返回 ROOM_JOINED
+func (g *GRPCService) joinRoom(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return g.handle(ctx, protocol.MsgJoinRoom, req)
+}
+
+// performAction 执行动作，请求字段同 PERFORM_ACTION，返回 ACTION_RESULT
+func (g *GRPCService) performAction(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	return g.handle(ctx, protocol.MsgPerformAction, req)
+}
+
+// handle 以会话玩家的身份把请求交给消息处理器，与 TCP 连接收到的消息走同一条路径
+func (g *GRPCService) handle(ctx context.Context, msgType protocol.MessageType, req *structpb.Struct) (*structpb.Struct, error) {
+	session, err := g.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := requestMessage(msgType, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.call(ctx, msg, func() error {
+		return g.server.handler.HandleMessage(session.playerID, msg)
+	})
+}
+
+// gameEvents 把发给会话玩家的消息依次推送给客户端，流结束即断线
+func (g *GRPCService) gameEvents(stream grpc.ServerStream) error {
+	session, err := g.session(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	session.mu.Lock()
+	if session.streaming {
+		session.mu.Unlock()
+		return status.Error(codes.AlreadyExists, "event stream already open")
+	}
+	session.streaming = true
+	session.mu.Unlock()
+
+	defer session.close()
+
+	for {
+		select {
+		case msg := <-session.events:
+			event, err := messageStruct(msg)
+			if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		case <-session.done:
+			return status.Error(codes.Unavailable, "session closed")
+		}
+	}
+}
+
+// session 根据 metadata 中的会话令牌找到会话
+func (g *GRPCService) session(ctx context.Context) (*grpcSession, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get(sessionTokenMetadataKey)
+	if len(tokens) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "please login first")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	session, ok := g.sessions[tokens[0]]
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "session expired")
+	}
+	return session, nil
+}
+
+// requestMessage 把请求字段转成 TCP 协议的消息，每个请求分配新的消息ID用于关联响应
+func requestMessage(msgType protocol.MessageType, req *structpb.Struct) (*protocol.Message, error) {
+	msg, err := protocol.NewMessage(msgType, req.AsMap())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	msg.ID = uuid.New().String()
+	return msg, nil
+}
+
+// messageStruct 把消息转成 Struct，字段与 TCP 协议的 JSON 消息一致
+func messageStruct(msg *protocol.Message) (*structpb.Struct, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	event, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return event, nil
+}
+
+// grpcError 把处理请求的错误转成 gRPC 状态，带错误码的错误映射到对应的状态码
+func grpcError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code := codes.FailedPrecondition
+
+	var usernameErr *UsernameError
+	var coded codedError
+	switch {
+	case errors.As(err, &usernameErr) && usernameErr.Code == protocol.ErrorCodeUsernameTaken:
+		code = codes.AlreadyExists
+	case errors.As(err, &usernameErr):
+		code = codes.InvalidArgument
+	case errors.As(err, &coded):
+		code = codes.ResourceExhausted
+	case errors.Is(err, ErrRoomBusy):
+		code = codes.Unavailable
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// checkGuardRules 按本局守卫规则检查守护目标，引擎不区分这些规则，由房间在提交前拦截
+func (r *Room) checkGuardRules(playerID, targetID string, round int) error {
+	r.mu.RLock()
+	rules := r.guardRules
+	r.mu.RUnlock()
+
+	if !rules.AllowSelf && targetID == playerID {
+		return errors.New("guard cannot protect themselves")
+	}
+
+	if !rules.AllowRepeat && targetID != "" && r.lastProtected(playerID, round-1) == targetID {
+		return errors.New("cannot protect the same player two nights in a row")
+	}
+
+	return nil
+}
+
+// lastProtected 守卫在指定回合最后守护的玩家，没有守护时返回空
+//
+// 直接从已接受的动作记录中查找，服务器重启重放动作后同样有效。
+func (r *Room) lastProtected(playerID string, round int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	target := ""
+	for _, action := range r.actions {
+		if action.PlayerID == playerID && action.ActionType == protocol.ActionProtect && action.Round == round {
+			target = action.TargetID
+		}
+	}
+	return target
+}
+
+// guardTargets 按规则从存活玩家中筛出守卫本回合可以守护的目标
+func (r *Room) guardTargets(playerID string, alive []string, round int) []string {
+	targets := make([]string, 0, len(alive))
+	for _, id := range alive {
+		if r.checkGuardRules(playerID, id, round) == nil {
+			targets = append(targets, id)
+		}
+	}
+	return targets
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log/slog"
+	"time"
 
 	"github.com/Zereker/game/protocol"
 	"github.com/Zereker/werewolf"
@@ -12,94 +14,184 @@ import (
 type MessageHandler struct {
 	server *Server
 	logger *slog.Logger
+	ctx    context.Context // 正在处理的消息的链路上下文
+	routes map[protocol.MessageType]handlerFunc
+
+	request *protocol.Message // 正在处理的消息，响应据此 ReplyTo
 }
 
-// NewMessageHandler 创建消息处理器
+// NewMessageHandler 创建消息处理器，注册各类消息的处理链
 func NewMessageHandler(server *Server, logger *slog.Logger) *MessageHandler {
-	return &MessageHandler{
+	h := &MessageHandler{
 		server: server,
 		logger: logger,
+		ctx:    context.Background(),
+		routes: make(map[protocol.MessageType]handlerFunc),
 	}
+
+	RegisterHandler(h, protocol.MsgLogin, (*MessageHandler).handleLogin)
+	RegisterHandler(h, protocol.MsgCreateRoom, (*MessageHandler).handleCreateRoom)
+	RegisterHandler(h, protocol.MsgJoinRoom, (*MessageHandler).handleJoinRoom)
+	RegisterHandler(h, protocol.MsgReady, (*MessageHandler).handleReady)
+	RegisterHandler(h, protocol.MsgPerformAction, (*MessageHandler).handlePerformAction, unmaskSeats)
+	h.handle(protocol.MsgGetAllowedSkills, withoutData((*MessageHandler).handleGetAllowedSkills))
+	h.handle(protocol.MsgGetPreferences, withoutData((*MessageHandler).handleGetPreferences))
+	RegisterHandler(h, protocol.MsgSetPreferences, (*MessageHandler).handleSetPreferences)
+	RegisterHandler(h, protocol.MsgKickPlayer, (*MessageHandler).handleKickPlayer)
+	RegisterHandler(h, protocol.MsgTransferOwner, (*MessageHandler).handleTransferOwner, unmaskSeats)
+	h.handle(protocol.MsgCloseRoom, withoutData((*MessageHandler).handleCloseRoom))
+	h.handle(protocol.MsgLeaveRoom, withoutData((*MessageHandler).handleLeaveRoom))
+	RegisterHandler(h, protocol.MsgResend, (*MessageHandler).handleResend)
+	RegisterHandler(h, protocol.MsgValidateRoomConfig, (*MessageHandler).handleValidateRoomConfig)
+	RegisterHandler(h, protocol.MsgAddBot, (*MessageHandler).handleAddBot)
+	RegisterHandler(h, protocol.MsgActivityHint, (*MessageHandler).handleActivityHint)
+	RegisterHandler(h, protocol.MsgSheriffRun, (*MessageHandler).handleSheriffRun)
+	RegisterHandler(h, protocol.MsgSheriffSpeech, (*MessageHandler).handleSheriffSpeech)
+	RegisterHandler(h, protocol.MsgSheriffVote, (*MessageHandler).handleSheriffVote, unmaskSeats)
+	RegisterHandler(h, protocol.MsgSheriffPass, (*MessageHandler).handleSheriffPass)
+	RegisterHandler(h, protocol.MsgSpeakingOrder, (*MessageHandler).handleSpeakingOrder)
+	h.handle(protocol.MsgListRooms, withoutData((*MessageHandler).handleListRooms))
+	RegisterHandler(h, protocol.MsgSkillResponse, (*MessageHandler).handleSkillResponse, unmaskSeats)
+	RegisterHandler(h, protocol.MsgGetStats, (*MessageHandler).handleGetStats)
+	RegisterHandler(h, protocol.MsgGetLeaderboard, (*MessageHandler).handleGetLeaderboard)
+	h.handle(protocol.MsgListPresets, withoutData((*MessageHandler).handleListPresets))
+	RegisterHandler(h, protocol.MsgMutePlayer, (*MessageHandler).handleMutePlayer, unmaskSeats)
+	RegisterHandler(h, protocol.MsgReportPlayer, (*MessageHandler).handleReportPlayer, unmaskSeats)
+	RegisterHandler(h, protocol.MsgWhisper, (*MessageHandler).handleWhisper)
+	h.handle(protocol.MsgResyncState, withoutData((*MessageHandler).handleResyncState))
+	RegisterHandler(h, protocol.MsgRematch, (*MessageHandler).handleRematch)
+	RegisterHandler(h, protocol.MsgRequestPause, (*MessageHandler).handleRequestPause)
+	RegisterHandler(h, protocol.MsgSpectate, (*MessageHandler).handleSpectate)
+	RegisterHandler(h, protocol.MsgSpectateApprove, (*MessageHandler).handleSpectateApprove)
+	h.handle(protocol.MsgPass, withoutData((*MessageHandler).handlePass))
+
+	return h
 }
 
-// HandleMessage 处理消息
-func (h *MessageHandler) HandleMessage(playerID string, msg *protocol.Message) error {
-	h.logger.Info("handle message",
-		"playerID", playerID,
-		"type", msg.Type)
-
-	switch msg.Type {
-	case protocol.MsgLogin:
-		return h.handleLogin(playerID, msg)
-	case protocol.MsgCreateRoom:
-		return h.handleCreateRoom(playerID, msg)
-	case protocol.MsgJoinRoom:
-		return h.handleJoinRoom(playerID, msg)
-	case protocol.MsgReady:
-		return h.handleReady(playerID, msg)
-	case protocol.MsgPerformAction:
-		return h.handlePerformAction(playerID, msg)
-	default:
-		return errors.Errorf("unknown message type: %s", msg.Type)
+// withoutData 适配不需要消息数据的处理器
+func withoutData(fn func(h *MessageHandler, playerID string) error) handlerFunc {
+	return func(h *MessageHandler, playerID string, _ *protocol.Message) error {
+		return fn(h, playerID)
 	}
 }
 
-// handleLogin 处理登录
-func (h *MessageHandler) handleLogin(playerID string, msg *protocol.Message) error {
-	var data protocol.LoginData
-	if err := msg.UnmarshalData(&data); err != nil {
+// HandleMessage 处理消息，为这条消息生成请求ID和 span，处理过程中的日志都带上请求ID
+func (h *MessageHandler) HandleMessage(playerID string, msg *protocol.Message) error {
+	requestID := newRequestID()
+	ctx, span := startMessageSpan(requestID, playerID, msg)
+	h = h.forRequest(ctx, requestID, playerID, msg)
+
+	handle, ok := h.routes[msg.Type]
+	if !ok {
+		err := errors.Errorf("unknown message type: %s", msg.Type)
+		h.logger.Warn("unknown message type")
+		endSpan(span, err)
 		return err
 	}
 
+	err := handle(h, playerID, msg)
+	endSpan(span, err)
+	return err
+}
+
+// handleLogin 处理登录
+func (h *MessageHandler) handleLogin(playerID string, data *protocol.LoginData) error {
 	player := h.server.GetPlayer(playerID)
 	if player == nil {
 		return errors.New("player not found")
 	}
 
-	player.Username = data.Username
+	// 改名同样要符合用户名规则，且不能与在线玩家或同房间玩家重名，游客不能改用已注册的用户名
+	check := h.server.checkUsername
+	if player.IsGuest {
+		check = h.server.checkGuestUsername
+	}
+	username, err := check(data.Username, playerID)
+	if err != nil {
+		return err
+	}
+	if room := h.server.GetRoom(player.RoomID); room != nil {
+		room.mu.RLock()
+		taken := room.usernameTaken(username, playerID)
+		room.mu.RUnlock()
+		if taken {
+			return errUsernameTaken(username)
+		}
+	}
+
+	if data.Profile != nil {
+		if err := h.server.updateProfile(player, *data.Profile); err != nil {
+			return err
+		}
+	}
+
+	player.Username = username
 
 	// 发送登录成功消息
 	respMsg, _ := protocol.NewMessage(protocol.MsgLoginSuccess, protocol.LoginSuccessData{
 		PlayerID: playerID,
+		Username: username,
+		IsGuest:  player.IsGuest,
+
+		Profile: player.publicProfile(),
 	})
 
 	return player.SendMessage(respMsg)
 }
 
+// configureRoom 按建房请求设置房间，房间还没有登记，不需要持有锁
+func configureRoom(room *Room, data protocol.CreateRoomData) {
+	// 房主可以为本房间指定战报投递地址，覆盖服务器默认值
+	if data.SummaryWebhook != "" {
+		room.summaryWebhook = data.SummaryWebhook
+	}
+	if data.GuardRules != nil {
+		room.guardRules = *data.GuardRules
+	}
+	if data.Rules != nil {
+		room.rules = normalizeRules(*data.Rules)
+	}
+	room.password = data.Password
+	room.private = data.Private
+	room.ranked = data.Ranked
+	room.anonymous = data.Anonymous
+	room.readyTimeout = time.Duration(data.ReadyTimeout) * time.Second
+	room.speed = normalizeSpeed(data.Speed)
+	room.unfilteredChat = data.ChatFilter != nil && !*data.ChatFilter
+	if data.Spectate != nil {
+		room.spectate = *data.Spectate
+	}
+}
+
 // handleCreateRoom 处理创建房间
-func (h *MessageHandler) handleCreateRoom(playerID string, msg *protocol.Message) error {
-	var data map[string]interface{}
-	if err := msg.UnmarshalData(&data); err != nil {
+func (h *MessageHandler) handleCreateRoom(playerID string, data *protocol.CreateRoomData) error {
+	// 与 VALIDATE_ROOM_CONFIG 使用同一套规则
+	if errs, _ := validateRoomConfig(*data, h.server.config.MaxRoomSize); len(errs) > 0 {
+		return &RoomConfigError{Errors: errs}
+	}
+
+	roles, err := configRoles(*data)
+	if err != nil {
 		return err
 	}
 
-	roomName := data["roomName"].(string)
+	if err := h.server.checkCreateRoom(playerID); err != nil {
+		return err
+	}
 
-	// 解析角色配置
-	var roles []werewolf.RoleType
-	if rolesData, ok := data["roles"].([]interface{}); ok && len(rolesData) > 0 {
-		for _, r := range rolesData {
-			roles = append(roles, werewolf.RoleType(r.(string)))
-		}
-	} else {
-		// 默认6人局配置
-		roles = []werewolf.RoleType{
-			werewolf.RoleTypeWerewolf,
-			werewolf.RoleTypeWerewolf,
-			werewolf.RoleTypeVillager,
-			werewolf.RoleTypeVillager,
-			werewolf.RoleTypeSeer,
-			werewolf.RoleTypeWitch,
-		}
+	if data.Ranked && h.server.GetPlayer(playerID).IsGuest {
+		return ErrRankedGuest
 	}
 
-	room, err := h.server.CreateRoom(roomName, roles)
+	// 密码、规则等设置在房间登记前生效，并发的加入请求不会看到没设好的房间
+	room, err := h.server.CreateRoom(data.RoomName, roles, func(r *Room) { configureRoom(r, *data) })
 	if err != nil {
 		return err
 	}
 
-	// 创建者自动加入房间
+	// 创建者自动加入房间，不再观战其他房间
 	player := h.server.GetPlayer(playerID)
+	h.server.stopSpectating(player)
 	if err := room.AddPlayer(player); err != nil {
 		return err
 	}
@@ -110,7 +202,7 @@ func (h *MessageHandler) handleCreateRoom(playerID string, msg *protocol.Message
 	})
 
 	h.logger.Info("sending room created message", "roomID", room.ID)
-	if err := player.SendMessageDirect(respMsg); err != nil {
+	if err := player.SendMessage(respMsg.ReplyTo(h.request)); err != nil {
 		h.logger.Error("failed to send room created message", "error", err)
 		return err
 	}
@@ -119,54 +211,56 @@ func (h *MessageHandler) handleCreateRoom(playerID string, msg *protocol.Message
 	// 发送房间加入成功消息
 	joinedMsg, _ := protocol.NewMessage(protocol.MsgRoomJoined, protocol.RoomJoinedData{
 		RoomID:  room.ID,
+		OwnerID: room.Owner(),
 		Players: room.GetPlayerList(),
 	})
 
 	h.logger.Info("sending room joined message", "roomID", room.ID)
-	err = player.SendMessageDirect(joinedMsg)
+	err = player.SendMessage(joinedMsg)
 	if err != nil {
 		h.logger.Error("failed to send room joined message", "error", err)
-	} else {
-		h.logger.Info("room joined message sent")
+		return err
 	}
-	return err
-}
+	h.logger.Info("room joined message sent")
 
-// handleJoinRoom 处理加入房间
-func (h *MessageHandler) handleJoinRoom(playerID string, msg *protocol.Message) error {
-	var data protocol.JoinRoomData
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
+	if player.Preferences.AutoReady {
+		return h.setReady(player, room, true)
 	}
 
+	return nil
+}
+
+// handleJoinRoom 处理加入房间
+func (h *MessageHandler) handleJoinRoom(playerID string, data *protocol.JoinRoomData) error {
 	room := h.server.GetRoom(data.RoomID)
 	if room == nil {
 		return errors.New("room not found")
 	}
 
+	if err := room.checkPassword(data.Password); err != nil {
+		return err
+	}
+
 	player := h.server.GetPlayer(playerID)
 	if err := room.AddPlayer(player); err != nil {
 		return err
 	}
+	h.server.stopSpectating(player)
 
 	// 发送加入成功消息给该玩家
 	joinedMsg, _ := protocol.NewMessage(protocol.MsgRoomJoined, protocol.RoomJoinedData{
 		RoomID:  room.ID,
+		OwnerID: room.Owner(),
 		Players: room.GetPlayerList(),
 	})
 
-	if err := player.SendMessage(joinedMsg); err != nil {
+	if err := player.SendMessage(joinedMsg.ReplyTo(h.request)); err != nil {
 		return err
 	}
 
 	// 通知房间内其他玩家
 	playerJoinedMsg, _ := protocol.NewMessage(protocol.MsgPlayerJoined, protocol.PlayerJoinedData{
-		Player: protocol.PlayerInfo{
-			ID:       player.ID,
-			Username: player.Username,
-			IsReady:  player.IsReady,
-			IsAlive:  true,
-		},
+		Player: room.PlayerInfo(player.ID),
 	})
 
 	for _, p := range room.Players {
@@ -174,12 +268,18 @@ func (h *MessageHandler) handleJoinRoom(playerID string, msg *protocol.Message)
 			p.SendMessage(playerJoinedMsg)
 		}
 	}
+	room.viewers.publish(playerJoinedMsg)
+
+	if player.Preferences.AutoReady {
+		return h.setReady(player, room, true)
+	}
+	room.updateReadyTimer()
 
 	return nil
 }
 
-// handleReady 处理准备
-func (h *MessageHandler) handleReady(playerID string, msg *protocol.Message) error {
+// handleReady 处理准备，已经是期望的状态时只回复当前状态
+func (h *MessageHandler) handleReady(playerID string, data *protocol.ReadyData) error {
 	player := h.server.GetPlayer(playerID)
 	if player == nil {
 		return errors.New("player not found")
@@ -194,42 +294,70 @@ func (h *MessageHandler) handleReady(playerID string, msg *protocol.Message) err
 		return errors.New("room not found")
 	}
 
-	// 切换准备状态
-	newReadyState := !player.IsReady
-	if err := room.SetPlayerReady(playerID, newReadyState); err != nil {
+	room.mu.RLock()
+	current := player.IsReady
+	countdown := room.readyCountdown()
+	room.mu.RUnlock()
+
+	// 旧客户端不带期望状态，按切换处理
+	isReady := !current
+	if data.Ready != nil {
+		isReady = *data.Ready
+	}
+
+	// 重复的消息不再广播，也不会取消开局倒计时
+	if isReady == current {
+		readyMsg, _ := protocol.NewMessage(protocol.MsgPlayerReady, protocol.PlayerReadyData{
+			PlayerID:     player.ID,
+			IsReady:      current,
+			ReadyTimeout: countdown,
+		})
+		return player.SendMessage(readyMsg.ReplyTo(h.request))
+	}
+
+	return h.setReady(player, room, isReady)
+}
+
+// setReady 设置准备状态、通知房间，所有人准备好后开始游戏
+func (h *MessageHandler) setReady(player *Player, room *Room, isReady bool) error {
+	if err := room.SetPlayerReady(player.ID, isReady); err != nil {
 		return err
 	}
+	room.updateReadyTimer()
+
+	// 通知房间内所有玩家，房间正在为未准备的玩家计时时带上剩余时间
+	room.mu.RLock()
+	countdown := room.readyCountdown()
+	room.mu.RUnlock()
 
-	// 通知房间内所有玩家
 	readyMsg, _ := protocol.NewMessage(protocol.MsgPlayerReady, protocol.PlayerReadyData{
-		PlayerID: playerID,
-		IsReady:  newReadyState,
+		PlayerID:     player.ID,
+		IsReady:      isReady,
+		ReadyTimeout: countdown,
 	})
 
 	room.BroadcastMessage(readyMsg)
 
-	// 如果所有人都准备好了，尝试开始游戏
-	// 由于可能有多个goroutine同时到达这里，Start()内部会检查状态
-	if room.CanStart() {
-		if err := room.Start(); err != nil {
-			// 忽略 "room is not in waiting state" 错误，这表示游戏已经被其他goroutine启动了
-			if err.Error() != "room is not in waiting state" {
-				h.logger.Error("failed to start game", "error", err)
-				return err
-			}
-		}
+	if !isReady {
+		room.cancelCountdown(player.Username + " 取消了准备")
+		return nil
+	}
+
+	// 如果所有人都准备好了，开始开局倒计时；多名玩家同时准备时只有一个请求能开局
+	result, err := room.tryStart()
+	if err != nil {
+		h.logger.Error("failed to start game", "error", err)
+		return err
+	}
+	if result == startAlreadyStarted {
+		h.logger.Debug("game already started by another request", "roomID", room.ID)
 	}
 
 	return nil
 }
 
 // handlePerformAction 处理游戏动作
-func (h *MessageHandler) handlePerformAction(playerID string, msg *protocol.Message) error {
-	var data map[string]interface{}
-	if err := msg.UnmarshalData(&data); err != nil {
-		return err
-	}
-
+func (h *MessageHandler) handlePerformAction(playerID string, data *protocol.PerformActionData) error {
 	player := h.server.GetPlayer(playerID)
 	if player == nil {
 		return errors.New("player not found")
@@ -240,46 +368,144 @@ func (h *MessageHandler) handlePerformAction(playerID string, msg *protocol.Mess
 		return errors.New("room not found")
 	}
 
-	if room.Engine == nil {
-		return errors.New("game not started")
+	// 每次提交都写入审计日志，包括在进入房间队列前就被拒绝的
+	entry := room.auditEntry(player, h.request, *data)
+
+	if room.Engine() == nil {
+		err := errors.New("game not started")
+		room.audit(entry, AuditRejected, err)
+		return err
 	}
 
-	// 解析动作类型
-	actionTypeStr := data["actionType"].(string)
-	actionType := werewolf.ActionType(actionTypeStr)
+	// 统一动作类型：别名和数字编号都转成标准名称
+	if err := data.Normalize(); err != nil {
+		room.rejections.record(playerID, RejectInvalidAction)
+		room.audit(entry, AuditRejected, err)
+		return err
+	}
+	actionType, targetID, actionData := data.ActionType, data.TargetID, data.Data
+	entry.ActionType = actionType
+
+	// 优先使用座位号，由服务器按座位表解析
+	if data.TargetSeat > 0 {
+		resolved, err := room.resolveTarget(targetID, data.TargetSeat)
+		if err != nil {
+			room.rejections.record(playerID, RejectInvalidTarget)
+			room.audit(entry, AuditRejected, err)
+			return err
+		}
+		targetID = resolved
+		entry.TargetID = resolved
+	}
 
-	targetID := ""
-	if tid, ok := data["targetID"].(string); ok {
-		targetID = tid
+	// 第二个目标（丘比特连接恋人）放进动作数据，由房间读取
+	if second := data.SecondTargetID; second != "" || data.SecondTargetSeat > 0 {
+		if data.SecondTargetSeat > 0 {
+			resolved, err := room.resolveTarget(second, data.SecondTargetSeat)
+			if err != nil {
+				room.rejections.record(playerID, RejectInvalidTarget)
+				room.audit(entry, AuditRejected, err)
+				return err
+			}
+			second = resolved
+		}
+		actionData[secondTargetKey] = second
 	}
 
-	actionData := make(map[string]interface{})
-	if ad, ok := data["data"].(map[string]interface{}); ok {
-		actionData = ad
+	// 盗贼拿的底牌编号放进动作数据
+	if data.Card > 0 {
+		actionData[thiefCardKey] = data.Card
 	}
 
+	// 按授权矩阵检查当前阶段是否允许该动作
+	switch authorizeAction(room.Engine().GetState().Phase, actionType) {
+	case actionReject:
+		err := errors.Errorf("action %s is not allowed in current phase", actionType)
+		room.rejections.record(playerID, RejectWrongPhase)
+		room.audit(entry, AuditRejected, err)
+		return err
+	case actionDefer:
+		room.deferAction(ActionRecord{
+			PlayerID:   playerID,
+			ActionType: actionType,
+			TargetID:   targetID,
+			Data:       actionData,
+		})
+		room.audit(entry, AuditDeferred, nil)
+
+		deferredMsg, _ := protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
+			Success: true,
+			Message: "夜间不能发言，内容将在天亮后轮到你发言时发出",
+		})
+		return player.SendMessage(deferredMsg.ReplyTo(h.request))
+	}
+
+	// 动作交给房间事件循环结算，先确认收到，结果异步送达
+	if err := room.submit(func() {
+		h.resolveAction(room, player, h.request, entry, actionType, targetID, actionData)
+	}); err != nil {
+		if errors.Is(err, ErrRoomBusy) {
+			room.rejections.record(playerID, RejectRateLimited)
+		}
+		room.audit(entry, AuditRejected, err)
+		return err
+	}
+
+	acceptedMsg, _ := protocol.NewMessage(protocol.MsgActionAccepted, nil)
+	return player.SendMessage(acceptedMsg.ReplyTo(h.request))
+}
+
+// resolveAction 在房间事件循环中执行动作，向提交者发送结果并广播新状态
+func (h *MessageHandler) resolveAction(room *Room, player *Player, msg *protocol.Message, entry AuditEntry, actionType werewolf.ActionType, targetID string, actionData map[string]interface{}) {
 	// 执行动作
-	err := room.Engine.PerformAction(playerID, actionType, targetID, actionData)
+	err := room.PerformActionContext(h.ctx, player.ID, actionType, targetID, actionData)
+	room.audit(entry, AuditAccepted, err)
 
-	// 发送动作结果
-	var resultMsg *protocol.Message
+	// 发送动作结果；被拒绝时立即告知以便重新提交，成功的结果夜里暂存到天亮
 	if err != nil {
-		resultMsg, _ = protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
+		room.recordRejection(player.ID, err)
+		h.logger.Debug("action rejected", "action", actionType, "error", err)
+		resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
 			Success: false,
 			Message: err.Error(),
 		})
+		player.SendMessage(resultMsg.ReplyTo(msg))
 	} else {
-		resultMsg, _ = protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
+		resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
 			Success: true,
 			Message: "动作执行成功",
 			Data:    actionData,
 		})
+		room.sendPrivateResult(player, resultMsg.ReplyTo(msg))
 	}
 
-	player.SendMessage(resultMsg)
+	// 发言已发出，不再算作正在输入
+	if err == nil && actionType == protocol.ActionSpeak {
+		room.activity.update(player.ID, false)
+		room.broadcastActivity()
+	}
 
 	// 更新游戏状态
 	room.SendGameState()
+}
 
-	return err
+// handleGetAllowedSkills 处理查询可用技能（重连或界面重启后主动拉取）
+func (h *MessageHandler) handleGetAllowedSkills(playerID string) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+
+	if room.Engine() == nil {
+		return errors.New("game not started")
+	}
+
+	room.SendAllowedSkills(playerID)
+
+	return nil
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// 监听状态
+const (
+	listenerStarting  = "starting"  // 还在恢复房间，尚未开始接受连接
+	listenerListening = "listening" // 正在接受连接
+	listenerClosed    = "closed"    // 正在关闭，不再接受连接
+)
+
+// HealthStatus 健康检查的响应
+type HealthStatus struct {
+	Status       string `json:"status"` // ok 或 unavailable
+	Listener     string `json:"listener"`
+	Goroutines   int    `json:"goroutines"`
+	Rooms        int    `json:"rooms"`
+	PlayingRooms int    `json:"playingRooms"`
+	Players      int    `json:"players"`
+}
+
+// listenerState 监听器当前的状态
+func (s *Server) listenerState() string {
+	switch {
+	case s.closing.Load():
+		return listenerClosed
+	case s.serving.Load():
+		return listenerListening
+	default:
+		return listenerStarting
+	}
+}
+
+// HealthHandler 容器部署用的健康检查接口，不需要认证，响应很轻
+//
+//	GET /healthz  进程存活即返回 200，附带监听状态、协程数和房间、玩家数
+//	GET /readyz   正在接受连接时返回 200，启动恢复中或关闭中返回 503，负载均衡据此摘除节点
+type HealthHandler struct {
+	server *Server
+	mux    *http.ServeMux
+}
+
+// NewHealthHandler 创建健康检查接口
+func NewHealthHandler(server *Server) *HealthHandler {
+	h := &HealthHandler{
+		server: server,
+		mux:    http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /healthz", h.healthz)
+	h.mux.HandleFunc("GET /readyz", h.readyz)
+
+	return h
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.mux.ServeHTTP(w, req)
+}
+
+// status 生成当前的健康状态
+func (h *HealthHandler) status() HealthStatus {
+	rooms, playing := h.server.RoomCounts()
+
+	return HealthStatus{
+		Status:       "ok",
+		Listener:     h.server.listenerState(),
+		Goroutines:   runtime.NumGoroutine(),
+		Rooms:        rooms,
+		PlayingRooms: playing,
+		Players:      h.server.PlayerCount(),
+	}
+}
+
+// healthz 存活检查
+func (h *HealthHandler) healthz(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, h.status())
+}
+
+// readyz 就绪检查
+func (h *HealthHandler) readyz(w http.ResponseWriter, req *http.Request) {
+	status := h.status()
+	if status.Listener != listenerListening {
+		status.Status = "unavailable"
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
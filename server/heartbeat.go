@@ -0,0 +1,54 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// heartbeat 连接的最近活跃时间
+//
+// 收到任何消息都算活跃；只有发送过 PING 的客户端才检查超时，
+// 不支持心跳的旧客户端仍依赖 TCP 断开来发现掉线。
+type heartbeat struct {
+	lastSeen atomic.Int64 // UnixNano
+	enabled  atomic.Bool
+}
+
+// touch 记录收到消息
+func (h *heartbeat) touch() {
+	h.lastSeen.Store(time.Now().UnixNano())
+}
+
+// idle 距最近一次收到消息的时长
+func (h *heartbeat) idle() time.Duration {
+	return time.Since(time.Unix(0, h.lastSeen.Load()))
+}
+
+// watch 定期检查连接，开启心跳的连接超过 timeout 没有消息时调用 onTimeout，done 关闭后退出
+func (h *heartbeat) watch(timeout time.Duration, done <-chan struct{}, onTimeout func()) {
+	ticker := time.NewTicker(timeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if h.enabled.Load() && h.idle() > timeout {
+				onTimeout()
+				return
+			}
+		}
+	}
+}
+
+// pong 回复心跳，原样带回客户端的发送时间
+func pong(ping *protocol.Message) *protocol.Message {
+	var data protocol.PingData
+	ping.UnmarshalData(&data)
+
+	msg, _ := protocol.NewMessage(protocol.MsgPong, data)
+	return msg.ReplyTo(ping)
+}
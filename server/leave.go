@@ -0,0 +1,237 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// Leave 玩家离开房间（主动离开或断线）
+//
+// 等待中的房间直接移除玩家；进行中的对局保留座位，玩家被标记为弃局出局，
+// 座位上换成不再关联连接的占位玩家。返回值表示是否按弃局处理。
+func (r *Room) Leave(player *Player) bool {
+	r.mu.Lock()
+	inGame := r.State.inGame()
+	if inGame {
+		player.aliases.Store(nil)
+		r.abandoned[player.ID] = true
+		r.Players[player.ID] = &Player{
+			ID:       player.ID,
+			Username: player.Username,
+			RoomID:   r.ID,
+			IsGuest:  player.IsGuest,
+		}
+	}
+	r.mu.Unlock()
+
+	if !inGame {
+		r.RemovePlayer(player.ID)
+	}
+
+	r.mu.Lock()
+	if r.OwnerID == player.ID {
+		r.OwnerID = r.nextOwner()
+	}
+	r.mu.Unlock()
+
+	return inGame
+}
+
+// nextOwner 选出下一位房主：按加入顺序第一个仍连着的真人玩家，即在房间里待得最久的人；
+// 其余真人都在等待重连时退而选其中最早加入的（需持有锁）
+func (r *Room) nextOwner() string {
+	fallback := ""
+	for _, playerID := range r.order {
+		player, exists := r.Players[playerID]
+		if !exists || player.IsBot || r.abandoned[playerID] {
+			continue
+		}
+		if player.connected() {
+			return playerID
+		}
+		if fallback == "" {
+			fallback = playerID
+		}
+	}
+	return fallback
+}
+
+// isAbandoned 玩家是否已在对局中离开
+func (r *Room) isAbandoned(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.abandoned[playerID]
+}
+
+// isAlive 玩家是否存活且仍在对局中
+func (r *Room) isAlive(playerID string) bool {
+	for _, id := range r.activePlayers(r.Engine().GetState().AlivePlayers) {
+		if id == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// activePlayers 从引擎的存活名单中去掉已离开和被房间结算出局的玩家
+func (r *Room) activePlayers(alive []string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]string, 0, len(alive))
+	for _, playerID := range alive {
+		if !r.abandoned[playerID] && !r.special.slain[playerID] {
+			result = append(result, playerID)
+		}
+	}
+	return result
+}
+
+// checkVictory 有玩家离开或被房间结算出局后重新判定胜负
+//
+// 引擎只在阶段结算时判胜负，且不知道玩家已离开、被决斗或开枪带走；这里按仍在场的存活玩家
+// （包括翻牌的白痴）计算，某一阵营已无人时由房间直接结束对局，reason 为结束原因。
+func (r *Room) checkVictory(reason string) {
+	var ended bool
+	good, evil := 0, 0
+
+	if err := callEngine("get state", func() error {
+		state := r.Engine().GetState()
+		ended = state.IsEnded
+
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		for _, ps := range state.Players {
+			alive := ps.IsAlive || r.special.revealed[ps.ID]
+			if !alive || r.abandoned[ps.ID] || r.special.slain[ps.ID] {
+				continue
+			}
+			switch roleCamp(ps.Role) {
+			case werewolf.CampGood:
+				good++
+			case werewolf.CampEvil:
+				evil++
+			}
+		}
+		return nil
+	}); err != nil {
+		r.logger.Error("victory check failed", "error", err)
+		return
+	}
+
+	if ended {
+		return
+	}
+
+	if r.loversWin() {
+		r.finish(protocol.CampLovers, protocol.ReasonLovers)
+		return
+	}
+
+	switch {
+	case good == 0 && evil == 0:
+		r.finish(werewolf.CampNone, reason)
+	case evil == 0:
+		r.finish(werewolf.CampGood, reason)
+	case good == 0:
+		r.finish(werewolf.CampEvil, reason)
+	}
+}
+
+// leaveRoom 让玩家离开所在房间并通知其余玩家
+func (s *Server) leaveRoom(player *Player) {
+	room := s.GetRoom(player.RoomID)
+	player.RoomID = ""
+	player.IsReady = false
+	if room == nil {
+		return
+	}
+
+	oldOwner := room.Owner()
+	abandoned := room.Leave(player)
+
+	leftMsg, _ := protocol.NewMessage(protocol.MsgPlayerLeft, protocol.PlayerLeftData{
+		PlayerID:  player.ID,
+		Abandoned: abandoned,
+	})
+	room.BroadcastMessage(leftMsg)
+
+	if owner := room.Owner(); owner != oldOwner && owner != "" {
+		ownerMsg, _ := protocol.NewMessage(protocol.MsgOwnerChanged, protocol.OwnerChangedData{
+			OwnerID: owner,
+		})
+		room.BroadcastMessage(ownerMsg)
+	}
+
+	s.logger.Info("player left room",
+		"roomID", room.ID,
+		"playerID", player.ID,
+		"abandoned", abandoned)
+
+	if abandoned {
+		room.SendGameState()
+		if room.humanCount() == 0 {
+			// 只剩机器人时没有继续的意义
+			room.finish(werewolf.CampNone, protocol.ReasonPlayerLeft)
+			return
+		}
+		room.checkVictory(protocol.ReasonPlayerLeft)
+		// 引擎仍把离开的玩家当作存活，替他交上这一阶段的动作，免得整桌人一直等
+		room.spawn(func() { room.passForRemoved(player.ID) })
+		return
+	}
+
+	if room.humanCount() == 0 {
+		s.RemoveRoom(room.ID)
+		return
+	}
+
+	// 正在表态再来一局时，剩下的人可能都已同意
+	if room.rematchPending() {
+		if err := room.progressRematch(); err != nil {
+			s.logger.Error("rematch failed", "roomID", room.ID, "error", err)
+		}
+	}
+}
+
+// handleLeaveRoom 处理玩家主动离开房间
+func (h *MessageHandler) handleLeaveRoom(playerID string) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	// 观战的玩家不在房间里，离开即停止观战
+	if player.RoomID == "" && player.spectating != "" {
+		roomID := player.spectating
+		h.server.stopSpectating(player)
+
+		leftMsg, _ := protocol.NewMessage(protocol.MsgPlayerLeft, protocol.PlayerLeftData{
+			PlayerID: playerID,
+		})
+		player.SendMessage(leftMsg)
+
+		h.logger.Info("spectator left room", "roomID", roomID)
+		return nil
+	}
+
+	if player.RoomID == "" {
+		return errors.New("player not in room")
+	}
+
+	roomID := player.RoomID
+	h.server.leaveRoom(player)
+
+	// 离开者已不在房间广播范围内，单独确认
+	leftMsg, _ := protocol.NewMessage(protocol.MsgPlayerLeft, protocol.PlayerLeftData{
+		PlayerID: playerID,
+	})
+	player.SendMessage(leftMsg)
+
+	h.logger.Info("player left room by request", "roomID", roomID)
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// LimitError 服务器达到容量上限，Code 随错误消息发给客户端
+type LimitError struct {
+	Code string
+	Err  error
+}
+
+// Error 实现 error 接口
+func (e *LimitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode 实现 codedError
+func (e *LimitError) ErrorCode() string {
+	return e.Code
+}
+
+// codedError 带错误码的错误，见 LimitError、UsernameError
+type codedError interface {
+	error
+	ErrorCode() string
+}
+
+// limitError 生成带错误码的容量错误
+func limitError(code, message string) error {
+	return &LimitError{Code: code, Err: errors.New(message)}
+}
+
+// errorMessage 把处理错误转成错误消息，容量错误等带错误码的错误附带错误码，房间配置错误另外逐条附上原因
+func errorMessage(err error) *protocol.Message {
+	var configErr *RoomConfigError
+	if errors.As(err, &configErr) {
+		msg, _ := protocol.NewMessage(protocol.MsgError, protocol.ErrorData{
+			Message: err.Error(),
+			Code:    configErr.ErrorCode(),
+			Details: configErr.Errors,
+		})
+		return msg
+	}
+
+	var coded codedError
+	if errors.As(err, &coded) {
+		msg, _ := protocol.NewCodedErrorMessage(coded.ErrorCode(), err.Error())
+		return msg
+	}
+
+	msg, _ := protocol.NewErrorMessage(err.Error())
+	return msg
+}
+
+// checkCreateRoom 检查服务器房间总数和玩家创建的房间数是否已达上限
+func (s *Server) checkCreateRoom(playerID string) error {
+	s.mu.RLock()
+	rooms := make([]*Room, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.mu.RUnlock()
+
+	if s.config.MaxRooms > 0 && len(rooms) >= s.config.MaxRooms {
+		s.metrics.limitHit(protocol.ErrorCodeTooManyRooms)
+		return limitError(protocol.ErrorCodeTooManyRooms, "server room limit reached, try again later")
+	}
+
+	if s.config.MaxRoomsPerPlayer <= 0 {
+		return nil
+	}
+
+	owned := 0
+	for _, room := range rooms {
+		room.mu.RLock()
+		if room.OwnerID == playerID && room.State != RoomStateFinished {
+			owned++
+		}
+		room.mu.RUnlock()
+	}
+
+	if owned >= s.config.MaxRoomsPerPlayer {
+		s.metrics.limitHit(protocol.ErrorCodePlayerRoomLimit)
+		return limitError(protocol.ErrorCodePlayerRoomLimit, "you already own the maximum number of rooms")
+	}
+	return nil
+}
+
+// acquireConn 登记一个来自 addr 的连接，同一 IP 的连接数已达上限时返回错误
+func (s *Server) acquireConn(addr net.Addr) (release func(), err error) {
+	ip := remoteIP(addr)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.MaxConnsPerIP > 0 && s.connsByIP[ip] >= s.config.MaxConnsPerIP {
+		s.metrics.limitHit(protocol.ErrorCodeTooManyConnections)
+		return nil, limitError(protocol.ErrorCodeTooManyConnections, "too many connections from your address")
+	}
+
+	s.connsByIP[ip]++
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.connsByIP[ip]--; s.connsByIP[ip] <= 0 {
+			delete(s.connsByIP, ip)
+		}
+	}, nil
+}
+
+// remoteIP 连接地址中的 IP，不含端口
+func remoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
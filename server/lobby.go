@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/subtle"
+	"sort"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// ErrWrongRoomPassword 加入房间的密码不正确
+var ErrWrongRoomPassword = errors.New("wrong room password")
+
+// checkPassword 校验加入密码，房间没有设密码时总是通过
+func (r *Room) checkPassword(password string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.password == "" {
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(password), []byte(r.password)) != 1 {
+		return ErrWrongRoomPassword
+	}
+	return nil
+}
+
+// Listing 生成房间在房间列表中的条目，私密房间返回 false
+func (r *Room) Listing() (protocol.RoomListing, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.private {
+		return protocol.RoomListing{}, false
+	}
+
+	wolves, specials, villagers := campBalance(r.Roles)
+
+	return protocol.RoomListing{
+		RoomID:      r.ID,
+		Name:        r.Name,
+		State:       string(r.State),
+		Players:     len(r.Players),
+		Capacity:    r.seats(),
+		HasPassword: r.password != "",
+		Ranked:      r.ranked,
+		Anonymous:   r.anonymous,
+		Speed:       r.speed,
+		Wolves:      wolves,
+		Specials:    specials,
+		Villagers:   villagers,
+	}, true
+}
+
+// RoomListings 房间列表，不含私密房间；等待中的房间排在前面
+func (s *Server) RoomListings() []protocol.RoomListing {
+	s.mu.RLock()
+	rooms := make([]*Room, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.mu.RUnlock()
+
+	listings := make([]protocol.RoomListing, 0, len(rooms))
+	for _, room := range rooms {
+		if listing, ok := room.Listing(); ok {
+			listings = append(listings, listing)
+		}
+	}
+
+	sort.Slice(listings, func(i, j int) bool {
+		waitingI := listings[i].State == string(RoomStateWaiting)
+		waitingJ := listings[j].State == string(RoomStateWaiting)
+		if waitingI != waitingJ {
+			return waitingI
+		}
+		return listings[i].RoomID < listings[j].RoomID
+	})
+
+	return listings
+}
+
+// handleListRooms 处理查询房间列表
+func (h *MessageHandler) handleListRooms(playerID string) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	respMsg, _ := protocol.NewMessage(protocol.MsgRoomList, protocol.RoomListData{
+		Rooms: h.server.RoomListings(),
+	})
+
+	return player.SendMessage(respMsg.ReplyTo(h.request))
+}
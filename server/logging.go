@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/Zereker/werewolf"
+	"github.com/google/uuid"
+)
+
+// logPosition 房间最近一次对外广播的回合和阶段，随日志输出
+type logPosition struct {
+	round int
+	phase werewolf.PhaseType
+}
+
+// roomLogHandler 为房间日志附加房间ID、回合和阶段
+//
+// 回合和阶段在输出时读取，不需要持有房间锁，持锁期间也可以写日志。
+type roomLogHandler struct {
+	slog.Handler
+	room *Room
+}
+
+// Handle 实现 slog.Handler
+func (h *roomLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.AddAttrs(slog.String("roomID", h.room.ID))
+	if pos := h.room.position.Load(); pos != nil {
+		record.AddAttrs(slog.Int("round", pos.round), slog.String("phase", string(pos.phase)))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs 实现 slog.Handler
+func (h *roomLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &roomLogHandler{Handler: h.Handler.WithAttrs(attrs), room: h.room}
+}
+
+// WithGroup 实现 slog.Handler
+func (h *roomLogHandler) WithGroup(name string) slog.Handler {
+	return &roomLogHandler{Handler: h.Handler.WithGroup(name), room: h.room}
+}
+
+// newRoomLogger 创建房间日志
+func newRoomLogger(base *slog.Logger, room *Room) *slog.Logger {
+	return slog.New(&roomLogHandler{Handler: base.Handler(), room: room})
+}
+
+// setLogPosition 记录房间当前的回合和阶段，之后的日志都带上
+func (r *Room) setLogPosition(round int, phase werewolf.PhaseType) {
+	r.position.Store(&logPosition{round: round, phase: phase})
+}
+
+// playerLogger 房间内某个玩家的日志
+func (r *Room) playerLogger(playerID string) *slog.Logger {
+	return r.logger.With("playerID", playerID)
+}
+
+// log 玩家的日志，在房间中时带上房间ID
+func (p *Player) log() *slog.Logger {
+	logger := p.logger
+	if logger == nil {
+		logger = slog.Default().With("playerID", p.ID)
+	}
+	if p.RoomID != "" {
+		logger = logger.With("roomID", p.RoomID)
+	}
+	return logger
+}
+
+// logSendError 记录发送失败的消息，连接已断开时这很常见，只在调试级别输出
+func (p *Player) logSendError(msg socket.Message, err error) error {
+	if err != nil {
+		msgType := protocol.MessageType("")
+		if m, ok := msg.(*protocol.Message); ok {
+			msgType = m.Type
+		}
+		p.log().Debug("send message failed", "type", msgType, "error", err)
+	}
+	return err
+}
+
+// newRequestID 为每条入站消息生成请求ID，处理同一条消息的多行日志据此关联
+func newRequestID() string {
+	return uuid.New().String()[:8]
+}
+
+// forRequest 绑定到一条入站消息的处理器，日志带上请求ID、玩家ID和消息类型
+func (h *MessageHandler) forRequest(ctx context.Context, requestID, playerID string, msg *protocol.Message) *MessageHandler {
+	return &MessageHandler{
+		server: h.server,
+		logger: h.logger.With("requestID", requestID, "playerID", playerID, "type", msg.Type),
+		ctx:    ctx,
+		routes: h.routes,
+
+		request: msg,
+	}
+}
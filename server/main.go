@@ -1,48 +1,218 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
-	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/Zereker/socket"
+	"github.com/pkg/errors"
 )
 
 func main() {
+	if err := dispatch(os.Args[1:]); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// runServe 启动游戏服务器，直到收到 SIGINT/SIGTERM
+func runServe(args []string) error {
 	// 解析命令行参数
-	addr := flag.String("addr", "127.0.0.1:8888", "server address")
-	flag.Parse()
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8888", "server address")
+	flags := registerConfigFlags(fs, "info")
+	fs.Parse(args)
+
+	config, err := flags.config()
+	if err != nil {
+		return errors.Wrap(err, "invalid flag")
+	}
+	if config.WorkerAddr == "" {
+		config.WorkerAddr = *addr
+	}
 
 	// 创建日志
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	logger, err := flags.logger()
+	if err != nil {
+		return err
+	}
+
+	// 打开账号存储
+	store, err := flags.openStore()
+	if err != nil {
+		return errors.Wrap(err, "open account store")
+	}
+	defer store.Close()
 
 	// 创建服务器
-	server := NewServer(logger)
+	server := NewServer(config, NewAccountService(store), logger)
+
+	// 监控指标要在恢复房间之前启用，恢复的房间才会上报
+	if config.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", server.EnableMetrics().Handler())
+		metricsServer := &http.Server{Addr: config.MetricsAddr, Handler: mux}
+		go func() {
+			logger.Info("metrics endpoint started", "addr", config.MetricsAddr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("metrics endpoint error", "error", err)
+			}
+		}()
+		defer metricsServer.Close()
+	}
+
+	// 链路追踪，只有配置了 OTLP 地址才导出
+	if config.TraceEndpoint != "" {
+		shutdownTracing, err := SetupTracing(config.TraceEndpoint, logger)
+		if err != nil {
+			return errors.Wrap(err, "setup tracing")
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Warn("flush traces failed", "error", err)
+			}
+		}()
+	}
+
+	// 健康检查要在恢复房间之前启动，恢复期间 /readyz 返回未就绪
+	if config.HealthAddr != "" {
+		healthServer := &http.Server{
+			Addr:    config.HealthAddr,
+			Handler: NewHealthHandler(server),
+		}
+		go func() {
+			logger.Info("health endpoint started", "addr", config.HealthAddr)
+			if err := healthServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("health endpoint error", "error", err)
+			}
+		}()
+		defer healthServer.Close()
+	}
+
+	// 恢复上次关闭或崩溃前保存的房间
+	if err := server.RestoreRooms(); err != nil {
+		return errors.Wrap(err, "restore rooms")
+	}
 
 	// 解析地址
 	tcpAddr, err := net.ResolveTCPAddr("tcp", *addr)
 	if err != nil {
-		log.Fatalf("resolve address error: %v", err)
+		return errors.Wrap(err, "resolve address")
 	}
 
-	// 创建 TCP 服务器
-	tcpServer, err := socket.New(tcpAddr)
+	// 创建 TCP 监听
+	listener, err := net.ListenTCP("tcp", tcpAddr)
 	if err != nil {
-		log.Fatalf("create server error: %v", err)
+		return errors.Wrap(err, "listen")
 	}
 
 	logger.Info("server started", "addr", *addr)
 	logger.Info("waiting for players to connect...")
 
-	// 启动服务器（阻塞）
-	tcpServer.Serve(server)
+	// 收到 SIGINT/SIGTERM 时优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 回收已结束的房间
+	go server.RunRoomGC(ctx)
+
+	// 定期保存进行中的房间，崩溃后重启可以续局
+	go server.RunSnapshots(ctx)
+
+	// 匿名使用统计，只有配置了上报地址才启用
+	go server.RunTelemetry(ctx)
+
+	// 管理接口
+	if config.AdminAddr != "" {
+		adminServer := &http.Server{
+			Addr:    config.AdminAddr,
+			Handler: NewAdminHandler(server, config.AdminToken),
+		}
+		go func() {
+			logger.Info("admin API started", "addr", config.AdminAddr)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("admin API error", "error", err)
+			}
+		}()
+		defer adminServer.Close()
+	}
+
+	// 只读观看接口，网页看板和直播叠加层通过 SSE 接收对局进度
+	if config.ViewerAddr != "" {
+		viewerServer := &http.Server{
+			Addr:    config.ViewerAddr,
+			Handler: NewViewerHandler(server),
+		}
+		go func() {
+			logger.Info("viewer endpoint started", "addr", config.ViewerAddr)
+			if err := viewerServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("viewer endpoint error", "error", err)
+			}
+		}()
+		defer viewerServer.Close()
+	}
+
+	// gRPC 接口，供程序化客户端使用，与 TCP 共用同一个服务器
+	if config.GRPCAddr != "" {
+		grpcListener, err := net.Listen("tcp", config.GRPCAddr)
+		if err != nil {
+			return errors.Wrap(err, "listen grpc")
+		}
+		grpcServer := server.NewGRPCServer()
+		go func() {
+			logger.Info("gRPC API started", "addr", config.GRPCAddr)
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				logger.Error("gRPC API error", "error", err)
+			}
+		}()
+		defer grpcServer.Stop()
+	}
+
+	go func() {
+		<-ctx.Done()
+		logger.Info("shutting down server...")
+		if err := server.Shutdown(); err != nil {
+			logger.Error("shutdown error", "error", err)
+		}
+	}()
+
+	// 启动服务器（阻塞直到关闭）
+	if err := server.Serve(listener); err != nil {
+		return errors.Wrap(err, "serve")
+	}
+
+	logger.Info("server stopped")
+
+	return nil
 }
 
-// Handle 实现 socket.Handler 接口
-func (s *Server) Handle(conn *net.TCPConn) {
-	s.HandleConnection(conn)
+// runMigrate 打开账号存储并执行未应用的迁移，不启动服务器
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags := registerConfigFlags(fs, "info")
+	fs.Parse(args)
+
+	logger, err := flags.logger()
+	if err != nil {
+		return err
+	}
+
+	store, err := flags.openStore()
+	if err != nil {
+		return errors.Wrap(err, "migrate account store")
+	}
+	defer store.Close()
+
+	logger.Info("account store is up to date",
+		"backend", *flags.accountStore,
+		"path", *flags.accountPath)
+
+	return nil
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics 服务器监控指标
+//
+// 所有方法对 nil 接收者安全，未启用 /metrics 时房间和处理器无需判断。
+type Metrics struct {
+	registry *prometheus.Registry
+
+	messages      *prometheus.CounterVec
+	broadcast     prometheus.Histogram
+	phaseDuration *prometheus.HistogramVec
+	limitHits     *prometheus.CounterVec
+	sendOverflows *prometheus.CounterVec
+}
+
+// NewMetrics 创建并注册监控指标
+func NewMetrics(server *Server) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "werewolf_messages_processed_total",
+			Help: "Client messages processed, by message type.",
+		}, []string{"type"}),
+		broadcast: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "werewolf_broadcast_duration_seconds",
+			Help:    "Time to deliver one broadcast to every player in a room.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}),
+		phaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "werewolf_phase_duration_seconds",
+			Help:    "How long game phases last, across all rooms.",
+			Buckets: []float64{5, 15, 30, 60, 120, 300, 600},
+		}, []string{"phase"}),
+		limitHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "werewolf_limit_rejections_total",
+			Help: "Requests refused because a server capacity limit was reached, by error code.",
+		}, []string{"code"}),
+		sendOverflows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "werewolf_send_queue_overflows_total",
+			Help: "Messages that did not fit in a client's send queue, by overflow policy.",
+		}, []string{"policy"}),
+	}
+
+	m.registry.MustRegister(
+		m.messages,
+		m.broadcast,
+		m.phaseDuration,
+		m.limitHits,
+		m.sendOverflows,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "werewolf_connected_players",
+			Help: "Players currently logged in.",
+		}, func() float64 {
+			return float64(server.PlayerCount())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "werewolf_open_rooms",
+			Help: "Rooms currently open.",
+		}, func() float64 {
+			open, _ := server.RoomCounts()
+			return float64(open)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "werewolf_games_in_progress",
+			Help: "Rooms with a game playing or paused.",
+		}, func() float64 {
+			_, playing := server.RoomCounts()
+			return float64(playing)
+		}),
+	)
+
+	return m
+}
+
+// Handler 返回 /metrics 的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// messageProcessed 记录一条已处理的客户端消息
+func (m *Metrics) messageProcessed(msgType protocol.MessageType) {
+	if m == nil {
+		return
+	}
+	m.messages.WithLabelValues(string(msgType)).Inc()
+}
+
+// observeBroadcast 记录一次广播耗时
+func (m *Metrics) observeBroadcast(start time.Time) {
+	if m == nil {
+		return
+	}
+	m.broadcast.Observe(time.Since(start).Seconds())
+}
+
+// observePhase 记录一个阶段的持续时间
+func (m *Metrics) observePhase(phase werewolf.PhaseType, d time.Duration) {
+	if m == nil || phase == "" {
+		return
+	}
+	m.phaseDuration.WithLabelValues(string(phase)).Observe(d.Seconds())
+}
+
+// limitHit 记录一次因容量上限被拒绝的请求
+func (m *Metrics) limitHit(code string) {
+	if m == nil {
+		return
+	}
+	m.limitHits.WithLabelValues(code).Inc()
+}
+
+// sendOverflow 记录一条放不进发送队列的消息
+func (m *Metrics) sendOverflow(policy OverflowPolicy) {
+	if m == nil {
+		return
+	}
+	m.sendOverflows.WithLabelValues(string(policy)).Inc()
+}
+
+// PlayerCount 在线玩家数
+func (s *Server) PlayerCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.players)
+}
+
+// RoomCounts 返回房间总数和对局进行中的房间数
+func (s *Server) RoomCounts() (open, playing int) {
+	s.mu.RLock()
+	rooms := make([]*Room, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.mu.RUnlock()
+
+	for _, room := range rooms {
+		room.mu.RLock()
+		if room.State.inGame() {
+			playing++
+		}
+		room.mu.RUnlock()
+	}
+
+	return len(rooms), playing
+}
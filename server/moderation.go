@@ -0,0 +1,268 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	chatHistorySize   = 50  // 房间保留的最近发言条数
+	reportExcerptSize = 20  // 举报附带的最近发言条数
+	maxReportReason   = 500 // 举报理由的最大长度（字符）
+)
+
+// mutedSet 玩家屏蔽的发言者，服务器不再把这些人的发言转发给该玩家
+type mutedSet struct {
+	mu  sync.RWMutex
+	ids map[string]bool
+}
+
+// set 屏蔽或取消屏蔽
+func (m *mutedSet) set(playerID string, muted bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !muted {
+		delete(m.ids, playerID)
+		return
+	}
+	if m.ids == nil {
+		m.ids = make(map[string]bool)
+	}
+	m.ids[playerID] = true
+}
+
+// has 是否屏蔽了该玩家
+func (m *mutedSet) has(playerID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.ids[playerID]
+}
+
+// ChatLine 房间里的一条发言
+type ChatLine struct {
+	PlayerID string               `json:"playerID"`
+	Username string               `json:"username"`
+	Type     protocol.MessageType `json:"type"` // SPEECH 或 SHERIFF_SPEECH
+	Content  string               `json:"content"`
+	Time     time.Time            `json:"time"`
+}
+
+// chatHistory 房间最近的发言，供举报时截取上下文
+type chatHistory struct {
+	mu    sync.Mutex
+	lines []ChatLine
+}
+
+// record 记录一条发言，超出容量时丢弃最早的
+func (h *chatHistory) record(line ChatLine) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lines = append(h.lines, line)
+	if len(h.lines) > chatHistorySize {
+		h.lines = h.lines[len(h.lines)-chatHistorySize:]
+	}
+}
+
+// recent 最近的 n 条发言
+func (h *chatHistory) recent(n int) []ChatLine {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	start := max(len(h.lines)-n, 0)
+	return append([]ChatLine(nil), h.lines[start:]...)
+}
+
+// chatSpeaker 发言类消息（发言、竞选发言、私聊）的发言人和内容，其他消息返回空
+func chatSpeaker(msg *protocol.Message) (speaker, content string) {
+	switch msg.Type {
+	case protocol.MsgSpeech:
+		var data protocol.SpeechData
+		if msg.UnmarshalData(&data) == nil {
+			return data.PlayerID, data.Content
+		}
+	case protocol.MsgSheriffSpeech:
+		var data protocol.SheriffSpeechData
+		if msg.UnmarshalData(&data) == nil {
+			return data.PlayerID, data.Content
+		}
+	case protocol.MsgWhisper:
+		var data protocol.WhisperData
+		if msg.UnmarshalData(&data) == nil {
+			return data.FromID, data.Content
+		}
+	}
+	return "", ""
+}
+
+// recordChat 记下广播的发言，返回发言人，不是发言时返回空
+func (r *Room) recordChat(msg *protocol.Message) string {
+	speaker, content := chatSpeaker(msg)
+	if speaker == "" {
+		return ""
+	}
+
+	r.chat.record(ChatLine{
+		PlayerID: speaker,
+		Username: r.PlayerInfo(speaker).Username,
+		Type:     msg.Type,
+		Content:  content,
+		Time:     protocol.Now(),
+	})
+	return speaker
+}
+
+// Report 玩家提交的举报
+type Report struct {
+	ID         string     `json:"id"`
+	RoomID     string     `json:"roomID"`
+	ReporterID string     `json:"reporterID"`
+	Reporter   string     `json:"reporter"`
+	TargetID   string     `json:"targetID"`
+	Target     string     `json:"target"`
+	Reason     string     `json:"reason"`
+	Excerpt    []ChatLine `json:"excerpt"` // 举报时房间最近的发言
+	Time       time.Time  `json:"time"`
+}
+
+// reportLog 待管理员核查的举报，只保存在内存中
+type reportLog struct {
+	mu      sync.Mutex
+	reports []Report
+}
+
+// add 追加一条举报；同一局里对同一名玩家只能举报一次
+func (l *reportLog) add(report Report) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, existing := range l.reports {
+		if existing.RoomID == report.RoomID && existing.ReporterID == report.ReporterID && existing.TargetID == report.TargetID {
+			return errors.New("you have already reported this player in this room")
+		}
+	}
+
+	l.reports = append(l.reports, report)
+	return nil
+}
+
+// list 所有举报，roomID 非空时只返回该房间的
+func (l *reportLog) list(roomID string) []Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := []Report{}
+	for _, report := range l.reports {
+		if roomID == "" || report.RoomID == roomID {
+			result = append(result, report)
+		}
+	}
+	return result
+}
+
+// roomTarget 解析屏蔽、举报或私聊的对象：同房间的其他玩家
+func (h *MessageHandler) roomTarget(playerID, targetID string, targetSeat int) (*Player, *Room, string, error) {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return nil, nil, "", errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return nil, nil, "", errors.New("not in a room")
+	}
+
+	targetID, err := room.resolveTarget(targetID, targetSeat)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if targetID == "" || targetID == playerID {
+		return nil, nil, "", errors.New("target must be another player in the room")
+	}
+
+	room.mu.RLock()
+	_, inRoom := room.Players[targetID]
+	room.mu.RUnlock()
+	if !inRoom {
+		return nil, nil, "", errors.New("player not in room")
+	}
+
+	return player, room, targetID, nil
+}
+
+// handleMutePlayer 处理屏蔽玩家：之后不再把对方的发言和竞选发言转发给自己
+func (h *MessageHandler) handleMutePlayer(playerID string, data *protocol.MutePlayerData) error {
+	player, room, targetID, err := h.roomTarget(playerID, data.PlayerID, data.TargetSeat)
+	if err != nil {
+		return err
+	}
+
+	player.muted.set(targetID, data.Muted)
+
+	target := room.playerLabel(targetID)
+	result := "已取消屏蔽 " + target
+	if data.Muted {
+		result = "已屏蔽 " + target + " 的发言"
+	}
+
+	resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
+		Success: true,
+		Message: result,
+		Data:    map[string]interface{}{"playerID": targetID, "muted": data.Muted},
+	})
+	return player.SendMessage(resultMsg.ReplyTo(h.request))
+}
+
+// handleReportPlayer 处理举报：记下理由和房间最近的发言，供管理员通过 /admin/reports 核查
+func (h *MessageHandler) handleReportPlayer(playerID string, data *protocol.ReportPlayerData) error {
+	reason := strings.TrimSpace(data.Reason)
+	if reason == "" {
+		return errors.New("report reason is required")
+	}
+	if len([]rune(reason)) > maxReportReason {
+		return errors.Errorf("report reason is too long (max %d characters)", maxReportReason)
+	}
+
+	player, room, targetID, err := h.roomTarget(playerID, data.PlayerID, data.TargetSeat)
+	if err != nil {
+		return err
+	}
+
+	report := Report{
+		ID:         uuid.New().String(),
+		RoomID:     room.ID,
+		ReporterID: playerID,
+		Reporter:   player.Username,
+		TargetID:   targetID,
+		Target:     room.PlayerInfo(targetID).Username,
+		Reason:     reason,
+		Excerpt:    room.chat.recent(reportExcerptSize),
+		Time:       protocol.Now(),
+	}
+	if err := h.server.reports.add(report); err != nil {
+		return err
+	}
+
+	h.logger.Info("player reported", "roomID", room.ID, "targetID", targetID, "reportID", report.ID)
+
+	resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
+		Success: true,
+		Message: "举报已提交，管理员会尽快核查",
+	})
+	return player.SendMessage(resultMsg.ReplyTo(h.request))
+}
+
+// listReports 查询举报
+//
+//	GET /admin/reports?room=<房间ID>
+func (h *AdminHandler) listReports(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, h.server.reports.list(req.URL.Query().Get("room")))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// nightOutbox 夜里暂存的私有动作结果：玩家ID -> 按产生顺序排列的消息，由 r.mu 保护
+//
+// 夜里的动作结果如果立即送达，观察连接流量的人能从时间上推断谁在什么时候行动（比如预言家何时查验）。
+// 因此夜里只立即回复确认收到的回执（ACTION_ACCEPTED、ACTION_PENDING），动作结果先暂存，
+// 天亮时与 NIGHT_RESULT 一起按玩家一次发出；对局在夜里结束时随之发出。
+type nightOutbox map[string][]*protocol.Message
+
+// sendPrivateResult 发送一名玩家的动作结果，夜里暂存到夜晚结束
+func (r *Room) sendPrivateResult(player *Player, msg *protocol.Message) {
+	r.mu.Lock()
+	if r.lastPhase == werewolf.PhaseNight && r.State.inGame() {
+		if r.nightHeld == nil {
+			r.nightHeld = make(nightOutbox)
+		}
+		r.nightHeld[player.ID] = append(r.nightHeld[player.ID], msg)
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+
+	player.SendMessage(msg)
+}
+
+// releaseNightResults 发出暂存的动作结果，已离开房间的玩家的结果丢弃（需持有锁）
+func (r *Room) releaseNightResults() {
+	held := r.nightHeld
+	r.nightHeld = nil
+
+	for playerID, msgs := range held {
+		player, ok := r.Players[playerID]
+		if !ok {
+			continue
+		}
+		for _, msg := range msgs {
+			player.SendMessage(msg)
+		}
+	}
+}
+
+// sendNightResults 天亮时把前一晚的行动结果私下发给各角色
+//
+// 预言家收到查验目标的阵营，女巫收到用药情况和剩余药水，守卫收到守护目标，
+// 狼人收到击杀是否成功。只统计被引擎接受的动作，夜里没有行动的角色不会收到。
+// 夜里暂存的动作结果先于夜晚结果一起发出，每名玩家在同一时刻收到这一晚的全部结果。
+func (r *Room) sendNightResults(round int) {
+	state := r.Engine().GetState()
+
+	roles := make(map[string]werewolf.RoleType, len(state.Players))
+	alive := make(map[string]bool, len(state.Players))
+	for _, ps := range state.Players {
+		roles[ps.ID] = ps.Role
+		alive[ps.ID] = ps.IsAlive
+	}
+
+	r.mu.Lock()
+	results := make(map[string]*protocol.NightResultData)
+	result := func(playerID string) *protocol.NightResultData {
+		if results[playerID] == nil {
+			results[playerID] = &protocol.NightResultData{Round: round, Role: roles[playerID]}
+		}
+		return results[playerID]
+	}
+
+	victim, saver := "", ""
+	antidoteUsed, poisonUsed := false, false
+	for _, action := range r.actions {
+		if action.Round > round {
+			break
+		}
+
+		switch action.ActionType {
+		case protocol.ActionAntidote:
+			antidoteUsed = true
+		case protocol.ActionPoison:
+			poisonUsed = true
+		}
+
+		if action.Round != round {
+			continue
+		}
+
+		switch action.ActionType {
+		case protocol.ActionCheck:
+			result(action.PlayerID).Check = &protocol.SeerCheckResult{
+				TargetID: action.TargetID,
+				Camp:     roleCamp(roles[action.TargetID]),
+			}
+		case protocol.ActionProtect:
+			result(action.PlayerID).Protect = action.TargetID
+		case protocol.ActionKill:
+			victim = action.TargetID
+		case protocol.ActionAntidote, protocol.ActionPoison:
+			potions := result(action.PlayerID).Potions
+			if potions == nil {
+				potions = &protocol.WitchPotionResult{}
+				results[action.PlayerID].Potions = potions
+			}
+			if action.ActionType == protocol.ActionPoison {
+				potions.PoisonedID = action.TargetID
+			} else {
+				saver = action.PlayerID
+			}
+		}
+	}
+
+	for playerID, data := range results {
+		if data.Potions == nil {
+			continue
+		}
+		if playerID == saver {
+			data.Potions.SavedID = victim
+		}
+		data.Potions.AntidoteLeft = !antidoteUsed
+		data.Potions.PoisonLeft = !poisonUsed
+	}
+
+	// 狼人共享击杀结果
+	if victim != "" {
+		for _, ps := range state.Players {
+			if ps.Role == werewolf.RoleTypeWerewolf {
+				result(ps.ID).Kill = &protocol.WolfKillResult{
+					TargetID:  victim,
+					Succeeded: !alive[victim],
+				}
+			}
+		}
+	}
+
+	r.releaseNightResults()
+	for playerID, data := range results {
+		if player, ok := r.Players[playerID]; ok {
+			msg, _ := protocol.NewMessage(protocol.MsgNightResult, data)
+			player.SendMessage(msg)
+		}
+	}
+	r.mu.Unlock()
+}
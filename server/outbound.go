@@ -0,0 +1,24 @@
+package main
+
+import "github.com/Zereker/game/protocol"
+
+// outboundMiddleware 广播和私聊发出前处理消息的中间件，返回 nil 表示拦下不发
+//
+// 中间件在建房时注册、按注册顺序执行，拿到的是上一个中间件的结果；
+// 需要改写内容时返回新消息，不能修改传入的消息，其他接收者可能已经拿到它。
+type outboundMiddleware func(msg *protocol.Message) *protocol.Message
+
+// useOutbound 注册出站中间件，需在房间开始广播前调用
+func (r *Room) useOutbound(mw outboundMiddleware) {
+	r.outbound = append(r.outbound, mw)
+}
+
+// filterOutbound 依次经过出站中间件，被拦下时返回 nil
+func (r *Room) filterOutbound(msg *protocol.Message) *protocol.Message {
+	for _, mw := range r.outbound {
+		if msg = mw(msg); msg == nil {
+			return nil
+		}
+	}
+	return msg
+}
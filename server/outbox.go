@@ -0,0 +1,138 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/pkg/errors"
+)
+
+const (
+	outboxSize      = 256 // 为重发保留的最近消息数
+	recentRequestsN = 64  // 用于去重的最近请求ID数
+)
+
+// outbox 玩家的出站序号、重发缓冲和请求去重记录
+type outbox struct {
+	mu   sync.Mutex
+	seq  uint64
+	sent []*protocol.Message // 环形缓冲，下标为 seq % outboxSize
+
+	requests    []string // 环形缓冲，最近处理过的请求ID
+	requestSet  map[string]bool
+	requestNext int
+}
+
+// send 分配序号、记录并发送消息
+//
+// 广播时同一个消息对象会发给多名玩家，这里复制一份再写入序号。
+// 持锁入队，保证同一玩家收到的消息序号有序。
+func (o *outbox) send(msg socket.Message, write func(socket.Message) error) error {
+	m, ok := msg.(*protocol.Message)
+	if !ok {
+		return write(msg)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.sent == nil {
+		o.sent = make([]*protocol.Message, outboxSize)
+	}
+
+	stamped := *m
+	o.seq++
+	stamped.Seq = o.seq
+	o.sent[o.seq%outboxSize] = &stamped
+
+	return write(&stamped)
+}
+
+// reset 清空序号、重发缓冲和请求记录，重连后的新连接从序号 1 重新开始
+func (o *outbox) reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.seq = 0
+	o.sent = nil
+	o.requests = nil
+	o.requestSet = nil
+	o.requestNext = 0
+}
+
+// bytes 重发缓冲中保留的消息字节数
+func (o *outbox) bytes() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	n := 0
+	for _, m := range o.sent {
+		if m != nil {
+			n += len(m.Data)
+		}
+	}
+	return n
+}
+
+// resend 按原序号重发区间内的消息，区间已超出缓冲时返回错误
+func (o *outbox) resend(from, to uint64, write func(socket.Message) error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if from == 0 || from > to || to > o.seq {
+		return errors.Errorf("invalid resend range: %d-%d", from, to)
+	}
+
+	if o.seq-from >= outboxSize {
+		return errors.Errorf("messages before seq %d are no longer available", o.seq-outboxSize+1)
+	}
+
+	for seq := from; seq <= to; seq++ {
+		if err := write(o.sent[seq%outboxSize]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seen 记录请求ID，已处理过时返回 true；没有ID的请求不去重
+func (o *outbox) seen(requestID string) bool {
+	if requestID == "" {
+		return false
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.requestSet[requestID] {
+		return true
+	}
+
+	if o.requests == nil {
+		o.requests = make([]string, recentRequestsN)
+		o.requestSet = make(map[string]bool)
+	}
+
+	if old := o.requests[o.requestNext]; old != "" {
+		delete(o.requestSet, old)
+	}
+	o.requests[o.requestNext] = requestID
+	o.requestSet[requestID] = true
+	o.requestNext = (o.requestNext + 1) % recentRequestsN
+
+	return false
+}
+
+// handleResend 处理客户端的重发请求
+func (h *MessageHandler) handleResend(playerID string, data *protocol.ResendData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	h.logger.Info("resend requested", "from", data.FromSeq, "to", data.ToSeq)
+
+	return player.Resend(data.FromSeq, data.ToSeq)
+}
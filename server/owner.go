@@ -0,0 +1,155 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// Owner 返回房主ID
+func (r *Room) Owner() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.OwnerID
+}
+
+// TransferOwner 把房主转让给房间内的另一名玩家
+func (r *Room) TransferOwner(playerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	player, exists := r.Players[playerID]
+	if !exists {
+		return errors.New("player not in room")
+	}
+
+	if player.IsBot {
+		return errors.New("cannot transfer ownership to a bot")
+	}
+
+	r.OwnerID = playerID
+
+	r.logger.Info("room owner changed", "ownerID", playerID)
+
+	return nil
+}
+
+// evict 把玩家移出房间并退回大厅
+func (r *Room) evict(player *Player) {
+	r.RemovePlayer(player.ID)
+	player.RoomID = ""
+	player.IsReady = false
+}
+
+// ownedRoom 获取玩家所在房间，并校验其为房主
+func (h *MessageHandler) ownedRoom(playerID string) (*Room, error) {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return nil, errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return nil, errors.New("player not in room")
+	}
+
+	if room.Owner() != playerID {
+		return nil, errors.New("only the room owner can do this")
+	}
+
+	return room, nil
+}
+
+// handleKickPlayer 处理房主踢人（仅等待中的房间）
+func (h *MessageHandler) handleKickPlayer(playerID string, data *protocol.TargetPlayerData) error {
+	room, err := h.ownedRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	if !room.State.lobby() {
+		return errors.New("cannot kick players after the game has started")
+	}
+
+	if data.PlayerID == playerID {
+		return errors.New("cannot kick yourself")
+	}
+
+	target := h.server.GetPlayer(data.PlayerID)
+	if target == nil {
+		// 机器人不在服务器的玩家表中
+		target = room.botPlayer(data.PlayerID)
+	}
+	if target == nil || target.RoomID != room.ID {
+		return errors.New("player not in room")
+	}
+
+	room.evict(target)
+
+	kickedMsg, _ := protocol.NewMessage(protocol.MsgKicked, protocol.KickedData{
+		RoomID: room.ID,
+	})
+	target.SendMessage(kickedMsg)
+
+	leftMsg, _ := protocol.NewMessage(protocol.MsgPlayerLeft, protocol.PlayerLeftData{
+		PlayerID: target.ID,
+	})
+	room.BroadcastMessage(leftMsg)
+
+	h.logger.Info("player kicked", "roomID", room.ID, "targetID", target.ID)
+
+	return nil
+}
+
+// handleTransferOwner 处理转让房主
+func (h *MessageHandler) handleTransferOwner(playerID string, data *protocol.TargetPlayerData) error {
+	room, err := h.ownedRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	if err := room.TransferOwner(data.PlayerID); err != nil {
+		return err
+	}
+
+	ownerMsg, _ := protocol.NewMessage(protocol.MsgOwnerChanged, protocol.OwnerChangedData{
+		OwnerID: data.PlayerID,
+	})
+	room.BroadcastMessage(ownerMsg)
+
+	return nil
+}
+
+// handleCloseRoom 处理关闭房间：通知并请出所有玩家（仅等待中的房间）
+func (h *MessageHandler) handleCloseRoom(playerID string) error {
+	room, err := h.ownedRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	if !room.State.lobby() {
+		return errors.New("cannot close room after the game has started")
+	}
+
+	closedMsg, _ := protocol.NewMessage(protocol.MsgRoomClosed, protocol.RoomClosedData{
+		RoomID: room.ID,
+	})
+	room.BroadcastMessage(closedMsg)
+
+	room.mu.RLock()
+	players := make([]*Player, 0, len(room.Players))
+	for _, player := range room.Players {
+		players = append(players, player)
+	}
+	room.mu.RUnlock()
+
+	for _, player := range players {
+		room.evict(player)
+	}
+
+	h.server.RemoveRoom(room.ID)
+
+	h.logger.Info("room closed", "roomID", room.ID, "by", playerID)
+
+	return nil
+}
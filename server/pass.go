@@ -0,0 +1,128 @@
+package main
+
+import (
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// Pass 玩家明确放弃本阶段的行动：放逐投票弃权，预言家不查验，守卫不守护，狼人不选刀口，轮到发言时跳过
+//
+// 放弃作为空目标的动作交给引擎，和其他人的动作一起由引擎决定何时结算；引擎不接受空目标
+// （没有实现 engine.Abstainer）时只能跳过发言，其他放弃被拒绝，玩家照常行动或等计时结束。
+func (r *Room) Pass(playerID string) error {
+	r.mu.RLock()
+	roomState := r.State
+	r.mu.RUnlock()
+
+	if roomState == RoomStatePaused {
+		return rejected(RejectWrongPhase, errors.New("game is paused"))
+	}
+	if roomState.ended() {
+		return rejected(RejectWrongPhase, errors.New("game has ended"))
+	}
+	if r.removed(playerID) {
+		return rejected(RejectOther, errors.New("player is out of the game"))
+	}
+
+	state := r.Engine().GetState()
+	var ps werewolf.PlayerState
+	for _, p := range state.Players {
+		if p.ID == playerID {
+			ps = p
+		}
+	}
+	if !ps.IsAlive {
+		return rejected(RejectOther, errors.New("player is not alive"))
+	}
+
+	var actionType werewolf.ActionType
+	switch {
+	case state.Phase == werewolf.PhaseVote:
+		actionType = protocol.ActionVote
+	case state.Phase == werewolf.PhaseDay:
+		actionType = protocol.ActionSpeak
+	case state.Phase == werewolf.PhaseNight && ps.Role == werewolf.RoleTypeSeer:
+		actionType = protocol.ActionCheck
+	case state.Phase == werewolf.PhaseNight && ps.Role == werewolf.RoleTypeGuard:
+		actionType = protocol.ActionProtect
+	case state.Phase == werewolf.PhaseNight && ps.Role == werewolf.RoleTypeWerewolf:
+		actionType = protocol.ActionKill
+	default:
+		return rejected(RejectWrongPhase, errors.New("nothing to pass in current phase"))
+	}
+
+	// 跳过发言不带目标；其他放弃要交空目标，需要引擎支持
+	if actionType != protocol.ActionSpeak && !engine.CanAbstain(r.Engine()) {
+		return rejected(RejectOther, errors.New("passing is not supported by the rules engine"))
+	}
+	if state.Phase == werewolf.PhaseNight && r.thiefPending() {
+		return rejected(RejectWrongPhase, errors.New("waiting for the thief to take a card"))
+	}
+	if state.Phase != werewolf.PhaseNight && r.dayCut(state.Round) {
+		return rejected(RejectWrongPhase, errors.New("the day ended with a self-destruct"))
+	}
+	if actionType == protocol.ActionSpeak {
+		if err := r.checkSpeakingTurn(playerID); err != nil {
+			return rejected(RejectRule, err)
+		}
+	}
+	if r.hasActed(playerID, actionType, state.Round) {
+		return rejected(RejectRule, errors.Errorf("already submitted %s this round", actionType))
+	}
+
+	r.markActive(playerID)
+
+	if !r.forceAction(playerID, actionType, "", nil, state.Round) {
+		return rejected(RejectOther, errors.Errorf("cannot pass %s now", actionType))
+	}
+	r.playerLogger(playerID).Info("player passed", "actionType", actionType)
+
+	switch actionType {
+	case protocol.ActionSpeak:
+		r.advanceSpeaker(playerID)
+	case protocol.ActionVote:
+		r.announceVote(playerID, "", state.Round)
+	}
+
+	return nil
+}
+
+// handlePass 处理玩家放弃本阶段的行动，与提交动作一样交给房间事件循环结算
+func (h *MessageHandler) handlePass(playerID string) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+
+	if room.Engine() == nil {
+		return errors.New("game not started")
+	}
+
+	msg := h.request
+	if err := room.submit(func() {
+		result := protocol.ActionResultData{Success: true, Message: "已放弃本阶段的行动"}
+		if err := room.Pass(playerID); err != nil {
+			room.recordRejection(playerID, err)
+			result = protocol.ActionResultData{Success: false, Message: err.Error()}
+		}
+
+		resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, result)
+		player.SendMessage(resultMsg.ReplyTo(msg))
+		room.SendGameState()
+	}); err != nil {
+		if errors.Is(err, ErrRoomBusy) {
+			room.rejections.record(playerID, RejectRateLimited)
+		}
+		return err
+	}
+
+	acceptedMsg, _ := protocol.NewMessage(protocol.MsgActionAccepted, nil)
+	return player.SendMessage(acceptedMsg.ReplyTo(h.request))
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// pauseVoteTimeout 一轮暂停或继续的表态从第一人表态起的有效期，到期仍未生效则作废
+const pauseVoteTimeout = time.Minute
+
+// pauseVote 玩家发起的暂停，由 r.mu 保护
+//
+// 对局中任何仍在场的真人都可以请求暂停（比如有人掉线时），仍在场且在线的真人过半同意后对局暂停：
+// 各步的计时（发言、女巫、狼王开枪、盗贼选牌、警长竞选）停下并记住剩余时长，动作一律拒绝。
+// 暂停后发起人一人即可继续，其他人需要过半同意；继续时各步按剩余时长接着计时。
+// 一轮表态在生效、到期或房间状态改变时结束，之后的表态重新计票。
+type pauseVote struct {
+	requester string          // 请求暂停的玩家，暂停期间保留，用于发起人一人即可继续
+	votes     map[string]bool // 未暂停时是同意暂停的玩家，暂停中是同意继续的玩家
+	timer     *time.Timer     // 本轮表态的有效期，见 pauseVoteTimeout
+	paused    bool            // 对局是否因玩家请求而暂停
+	suspended []suspendedTimer
+}
+
+// settle 结束当前一轮表态，清空票数；还没暂停时发起人也一并清除
+func (p *pauseVote) settle() {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.timer = nil
+	p.votes = nil
+	if !p.paused {
+		p.requester = ""
+	}
+}
+
+// suspendedTimer 暂停时停下的计时器，继续时按剩余时长重新计时并顺延截止时间
+type suspendedTimer struct {
+	timer     *time.Timer
+	deadline  *time.Time
+	remaining time.Duration
+}
+
+// handleRequestPause 处理暂停或继续的表态
+func (h *MessageHandler) handleRequestPause(playerID string, data *protocol.RequestPauseData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return errors.New("player not in room")
+	}
+
+	if room.Engine() == nil {
+		return errors.New("game not started")
+	}
+
+	h.logger.Info("pause vote", "roomID", room.ID, "resume", data.Resume)
+
+	return room.requestPause(playerID, data.Resume)
+}
+
+// requestPause 记下表态，达到生效人数时暂停或继续对局，否则广播最新的表态情况
+func (r *Room) requestPause(playerID string, resume bool) error {
+	r.mu.Lock()
+	player, exists := r.Players[playerID]
+	p := &r.pauses
+	switch {
+	case !exists || player.IsBot || r.abandoned[playerID]:
+		r.mu.Unlock()
+		return errors.New("player not in game")
+	case !resume && r.State != RoomStatePlaying:
+		r.mu.Unlock()
+		return errors.New("game is not in progress")
+	case resume && r.State != RoomStatePaused:
+		r.mu.Unlock()
+		return errors.New("game is not paused")
+	case resume && !p.paused:
+		r.mu.Unlock()
+		return errors.New("game was not paused by players")
+	}
+
+	if p.votes == nil {
+		p.votes = make(map[string]bool)
+		if !resume {
+			p.requester = playerID
+		}
+		r.expirePauseVote()
+	}
+	p.votes[playerID] = true
+
+	agreed, needed := r.pauseVotes()
+	apply := len(agreed) >= needed || (resume && playerID == p.requester)
+
+	var msg *protocol.Message
+	switch {
+	case apply && !resume:
+		// 进入暂停时结束这轮表态（见 roomStateHooks），先标记为玩家暂停以保留发起人
+		p.paused = true
+		r.transition(RoomStatePaused)
+		r.suspendTimers()
+		msg, _ = protocol.NewMessage(protocol.MsgGamePaused, protocol.GamePausedData{
+			Reason:   protocol.ReasonPlayerRequest,
+			Message:  fmt.Sprintf("%s 请求暂停，%d 名玩家同意，发起人或过半玩家同意后继续", r.seatLabel(p.requester), len(agreed)),
+			PlayerID: p.requester,
+		})
+	case apply:
+		r.transition(RoomStatePlaying)
+		r.resumeTimers()
+		r.pauses = pauseVote{}
+		msg, _ = protocol.NewMessage(protocol.MsgGameResumed, protocol.GameResumedData{
+			Reason:   protocol.ReasonPlayerRequest,
+			PlayerID: playerID,
+		})
+	default:
+		msg, _ = protocol.NewMessage(protocol.MsgPauseVote, protocol.PauseVoteData{
+			Resume: resume,
+			Agreed: agreed,
+			Needed: needed,
+		})
+	}
+	r.mu.Unlock()
+
+	r.BroadcastMessage(msg)
+
+	if !apply {
+		return nil
+	}
+
+	if resume {
+		r.logger.Info("game resumed by players", "playerID", playerID)
+	} else {
+		r.logger.Info("game paused by players", "requester", playerID, "agreed", len(agreed))
+	}
+
+	if resume {
+		r.resumed()
+	} else {
+		r.SendGameState()
+		r.pushAllowedSkills()
+	}
+
+	return nil
+}
+
+// resumed 对局继续后补发状态和可用技能；暂停期间机器人的动作被拒绝，重新让它们行动
+func (r *Room) resumed() {
+	r.SendGameState()
+	r.pushAllowedSkills()
+
+	if !r.thiefPending() {
+		state := r.Engine().GetState()
+		r.driveBots(state.Phase, state.Round)
+	}
+}
+
+// expirePauseVote 为刚开始的一轮表态计时，到期仍未生效则作废并告知房间里的人（需持有锁）
+func (r *Room) expirePauseVote() {
+	var timer *time.Timer
+	timer = time.AfterFunc(pauseVoteTimeout, func() {
+		r.mu.Lock()
+		p := &r.pauses
+		if p.timer != timer || !r.State.inGame() {
+			r.mu.Unlock()
+			return
+		}
+
+		resume := p.paused
+		p.settle()
+		_, needed := r.pauseVotes()
+		r.mu.Unlock()
+
+		r.logger.Info("pause vote expired", "resume", resume)
+
+		msg, _ := protocol.NewMessage(protocol.MsgPauseVote, protocol.PauseVoteData{
+			Resume: resume,
+			Agreed: []string{},
+			Needed: needed,
+		})
+		r.BroadcastMessage(msg)
+	})
+	r.pauses.timer = timer
+}
+
+// pauseVotes 按座位顺序列出已表态的玩家，以及生效需要的人数（需持有锁）
+//
+// 只有仍在场且在线的真人算数，掉线的玩家无法表态，不能拖住其他人。
+func (r *Room) pauseVotes() (agreed []string, needed int) {
+	agreed = []string{}
+	voters := 0
+	for _, playerID := range r.order {
+		player := r.Players[playerID]
+		if player.IsBot || r.abandoned[playerID] || !player.connected() {
+			continue
+		}
+
+		voters++
+		if r.pauses.votes[playerID] {
+			agreed = append(agreed, playerID)
+		}
+	}
+	return agreed, voters/2 + 1
+}
+
+// suspendTimers 停下正在计时的各步，记下剩余时长（需持有锁）
+func (r *Room) suspendTimers() {
+	suspend := func(timer *time.Timer, deadline *time.Time) {
+		if timer == nil || deadline.IsZero() || !timer.Stop() {
+			return
+		}
+		r.pauses.suspended = append(r.pauses.suspended, suspendedTimer{
+			timer:     timer,
+			deadline:  deadline,
+			remaining: time.Until(*deadline),
+		})
+	}
+
+	suspend(r.speaking.timer, &r.speaking.deadline)
+	suspend(r.witch.timer, &r.witch.deadline)
+	suspend(r.special.timer, &r.special.deadline)
+	suspend(r.special.thiefTimer, &r.special.thiefDeadline)
+	suspend(r.sheriff.timer, &r.sheriff.deadline)
+}
+
+// resumeTimers 停下的计时按剩余时长接着计时，截止时间顺延暂停的时长（需持有锁）
+//
+// 计时器的回调和纪元都没变，暂停期间被取代的计时器照旧因纪元过期而不起作用。
+func (r *Room) resumeTimers() {
+	for _, s := range r.pauses.suspended {
+		*s.deadline = time.Now().Add(s.remaining)
+		s.timer.Reset(s.remaining)
+	}
+	r.pauses.suspended = nil
+}
+
+// isPaused 对局是否暂停
+func (r *Room) isPaused() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.State == RoomStatePaused
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Zereker/game/protocol"
+)
+
+func TestPauseVoteSettled(t *testing.T) {
+	tests := []struct {
+		name   string
+		settle func(tt *testTable, room *Room) // 在一名玩家表态暂停之后结束这轮表态
+	}{
+		{
+			name: "room paused for another reason",
+			settle: func(tt *testTable, room *Room) {
+				room.pause(protocol.ReasonEngineError, "engine error")
+				room.mu.Lock()
+				room.transition(RoomStatePlaying)
+				room.mu.Unlock()
+			},
+		},
+		{
+			name: "game paused and resumed by players",
+			settle: func(tt *testTable, room *Room) {
+				for _, player := range tt.players[1:3] {
+					tt.must(player, protocol.MsgRequestPause, protocol.RequestPauseData{})
+				}
+				if !room.isPaused() {
+					tt.t.Fatal("game not paused by a majority")
+				}
+				tt.must(tt.players[0], protocol.MsgRequestPause, protocol.RequestPauseData{Resume: true})
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			room := tt.start(fiveSeats...)
+
+			tt.must(tt.players[0], protocol.MsgRequestPause, protocol.RequestPauseData{})
+			tc.settle(tt, room)
+
+			room.mu.RLock()
+			votes, requester, timer := len(room.pauses.votes), room.pauses.requester, room.pauses.timer
+			room.mu.RUnlock()
+			if votes != 0 || requester != "" || timer != nil {
+				t.Fatalf("pause vote left over: votes = %d, requester = %q, timer set = %v", votes, requester, timer != nil)
+			}
+
+			// 新一轮表态从零计票，一人同意不会暂停
+			tt.must(tt.players[3], protocol.MsgRequestPause, protocol.RequestPauseData{})
+			if room.isPaused() {
+				t.Error("game paused by a single vote in a new round")
+			}
+			var data protocol.PauseVoteData
+			tt.waitFor(tt.players[3], protocol.MsgPauseVote).UnmarshalData(&data)
+			if len(data.Agreed) != 1 {
+				t.Errorf("agreed = %v in a new round, want only the new voter", data.Agreed)
+			}
+		})
+	}
+}
@@ -0,0 +1,597 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// RoomSnapshot 房间持久化快照
+//
+// 引擎内部状态无法直接导出，快照记录开局时的角色分配和之后被接受的动作，
+// 恢复时重新开局并重放动作；若重新开局的角色分配与快照不一致则无法续局。
+type RoomSnapshot struct {
+	ID           string                       `json:"id"`
+	Name         string                       `json:"name"`
+	Roles        []werewolf.RoleType          `json:"roles"`
+	State        RoomState                    `json:"state"`
+	OwnerID      string                       `json:"ownerID"`
+	Players      []PlayerSnapshot             `json:"players"` // 按加入顺序
+	Assignments  map[string]werewolf.RoleType `json:"assignments,omitempty"`
+	Phase        werewolf.PhaseType           `json:"phase,omitempty"` // 保存时引擎的阶段和回合，重放后用来核对
+	Round        int                          `json:"round,omitempty"`
+	Actions      []ActionRecord               `json:"actions,omitempty"`
+	Events       []protocol.GameEventRecord   `json:"events,omitempty"`
+	Webhook      string                       `json:"summaryWebhook,omitempty"`
+	GuardRules   *protocol.GuardRules         `json:"guardRules,omitempty"`
+	Rules        *protocol.GameRules          `json:"rules,omitempty"`
+	Sheriff      *SheriffSnapshot             `json:"sheriff,omitempty"`
+	Special      *SpecialSnapshot             `json:"special,omitempty"`
+	Speaking     *SpeakingSnapshot            `json:"speaking,omitempty"`
+	Password     string                       `json:"password,omitempty"`
+	Private      bool                         `json:"private,omitempty"`
+	Ranked       bool                         `json:"ranked,omitempty"`
+	Anonymous    bool                         `json:"anonymous,omitempty"`
+	ReadyTimeout time.Duration                `json:"readyTimeout,omitempty"`
+	Speed        string                       `json:"speed,omitempty"`
+	Unfiltered   bool                         `json:"unfiltered,omitempty"`
+	Spectate     *protocol.SpectateSettings   `json:"spectate,omitempty"`
+	SavedAt      time.Time                    `json:"savedAt"`
+}
+
+// SheriffSnapshot 警长竞选结果快照，进行中的竞选和警徽移交不保存
+type SheriffSnapshot struct {
+	ID string `json:"id,omitempty"` // 为空表示没有警长
+}
+
+// SpecialSnapshot 房间实现的角色及其状态快照，进行中的狼王开枪提示不保存
+type SpecialSnapshot struct {
+	Roles    map[string]werewolf.RoleType `json:"roles,omitempty"`
+	Slain    []string                     `json:"slain,omitempty"`
+	Revealed []string                     `json:"revealed,omitempty"`
+	Dueled   bool                         `json:"dueled,omitempty"`
+	Lovers   []string                     `json:"lovers,omitempty"`
+	Extras   []werewolf.RoleType          `json:"extras,omitempty"` // 盗贼还没选的底牌，恢复后重新计时
+}
+
+// SpeakingSnapshot 白天进行中的发言顺序，恢复后当前发言者重新计时
+type SpeakingSnapshot struct {
+	Order     []string `json:"order"`
+	Turn      int      `json:"turn"`
+	Clockwise bool     `json:"clockwise"`
+}
+
+// PlayerSnapshot 房间内玩家快照
+type PlayerSnapshot struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	IsReady   bool   `json:"isReady"`
+	IsGuest   bool   `json:"isGuest"`
+	Abandoned bool   `json:"abandoned,omitempty"`
+	IsBot     bool   `json:"isBot,omitempty"`
+}
+
+// Snapshot 生成房间快照
+func (r *Room) Snapshot() RoomSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	guardRules, rules, spectate := r.guardRules, r.rules, r.spectate
+	snapshot := RoomSnapshot{
+		ID:           r.ID,
+		Name:         r.Name,
+		Roles:        r.Roles,
+		State:        r.State,
+		OwnerID:      r.OwnerID,
+		Players:      make([]PlayerSnapshot, 0, len(r.order)),
+		Actions:      append([]ActionRecord(nil), r.actions...),
+		Events:       append([]protocol.GameEventRecord(nil), r.events...),
+		Webhook:      r.summaryWebhook,
+		GuardRules:   &guardRules,
+		Rules:        &rules,
+		Password:     r.password,
+		Private:      r.private,
+		Ranked:       r.ranked,
+		Anonymous:    r.anonymous,
+		ReadyTimeout: r.readyTimeout,
+		Speed:        r.speed,
+		Unfiltered:   r.unfilteredChat,
+		Spectate:     &spectate,
+		SavedAt:      protocol.Now(),
+	}
+
+	// 等待重连的暂停不是对局本身的状态，再次重启后照样等待；
+	// 玩家发起的暂停停下的计时无法保存，重启后按进行中的对局恢复
+	if len(r.awaiting) > 0 || r.pauses.paused {
+		snapshot.State = RoomStatePlaying
+	}
+
+	if r.sheriff.stage != "" && !r.sheriff.running() {
+		snapshot.Sheriff = &SheriffSnapshot{ID: r.sheriff.id}
+	}
+
+	for _, playerID := range r.order {
+		player := r.Players[playerID]
+		snapshot.Players = append(snapshot.Players, PlayerSnapshot{
+			ID:        player.ID,
+			Username:  player.Username,
+			IsReady:   player.IsReady,
+			IsGuest:   player.IsGuest,
+			Abandoned: r.abandoned[playerID],
+			IsBot:     player.IsBot,
+		})
+	}
+
+	if r.Engine() != nil {
+		state := r.Engine().GetState()
+		snapshot.Phase, snapshot.Round = state.Phase, state.Round
+		snapshot.Assignments = make(map[string]werewolf.RoleType)
+		for _, ps := range state.Players {
+			snapshot.Assignments[ps.ID] = ps.Role
+		}
+
+		if r.speaking.active() {
+			snapshot.Speaking = &SpeakingSnapshot{
+				Order:     slices.Clone(r.speaking.order),
+				Turn:      r.speaking.turn,
+				Clockwise: r.speaking.clockwise,
+			}
+		}
+
+		special := &SpecialSnapshot{
+			Roles:  maps.Clone(r.special.roles),
+			Dueled: r.special.dueled,
+			Extras: slices.Clone(r.special.extras),
+		}
+		if r.special.lovers[0] != "" {
+			special.Lovers = []string{r.special.lovers[0], r.special.lovers[1]}
+		}
+		for _, playerID := range r.order {
+			if r.special.slain[playerID] {
+				special.Slain = append(special.Slain, playerID)
+			}
+			if r.special.revealed[playerID] {
+				special.Revealed = append(special.Revealed, playerID)
+			}
+		}
+		snapshot.Special = special
+	}
+
+	return snapshot
+}
+
+// restore 根据快照恢复房间，玩家以离线状态占位，等待重新登录
+func (r *Room) restore(snapshot RoomSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ID = snapshot.ID
+	r.Name = snapshot.Name
+	r.Roles = snapshot.Roles
+	r.OwnerID = snapshot.OwnerID
+	r.password = snapshot.Password
+	r.private = snapshot.Private
+	r.ranked = snapshot.Ranked
+	r.anonymous = snapshot.Anonymous
+	r.readyTimeout = snapshot.ReadyTimeout
+	r.speed = normalizeSpeed(snapshot.Speed)
+	r.unfilteredChat = snapshot.Unfiltered
+	if snapshot.Spectate != nil {
+		r.spectate = *snapshot.Spectate
+	}
+	if snapshot.Webhook != "" {
+		r.summaryWebhook = snapshot.Webhook
+	}
+	if snapshot.GuardRules != nil {
+		r.guardRules = *snapshot.GuardRules
+	}
+	if snapshot.Rules != nil {
+		r.rules = normalizeRules(*snapshot.Rules)
+	}
+	if snapshot.Sheriff != nil {
+		r.sheriff = sheriffState{stage: protocol.SheriffStageDone, id: snapshot.Sheriff.ID}
+	}
+
+	for _, ps := range snapshot.Players {
+		r.Players[ps.ID] = &Player{
+			ID:       ps.ID,
+			Username: ps.Username,
+			RoomID:   r.ID,
+			IsReady:  ps.IsReady,
+			IsGuest:  ps.IsGuest,
+			IsBot:    ps.IsBot,
+		}
+		r.order = append(r.order, ps.ID)
+		if ps.IsBot {
+			r.bots[ps.ID] = newBot()
+		}
+		if ps.Abandoned {
+			r.abandoned[ps.ID] = true
+		}
+	}
+
+	if !snapshot.State.inGame() {
+		return nil
+	}
+
+	// 按快照的分配依座位顺序给出角色：盗贼换过牌时基础角色与房间配置不同
+	roles := make([]werewolf.RoleType, 0, len(r.order))
+	for _, playerID := range r.order {
+		roles = append(roles, snapshot.Assignments[playerID])
+	}
+
+	// 重放期间引擎重新发出的事件崩溃前已经处理过（广播、事件日志、机器人和提示），全部丢弃；
+	// 恢复后由 resumeRestored 按当前状态补发提示、驱动机器人
+	r.replaying.Store(true)
+	defer r.replaying.Store(false)
+
+	r.transition(RoomStateStarting)
+	if err := r.startEngine(roles); err != nil {
+		return r.resetToLobby(err)
+	}
+
+	for _, ps := range r.Engine().GetState().Players {
+		if snapshot.Assignments[ps.ID] != ps.Role {
+			return r.resetToLobby(errors.New("role assignment differs from snapshot"))
+		}
+	}
+
+	for _, action := range snapshot.Actions {
+		if action.Held {
+			// 警长加权计票时玩家的票只记在房间，交给引擎的是随后 Settled 的记录
+			r.actions = append(r.actions, action)
+			continue
+		}
+		if err := callEngine("replay action", func() error {
+			return r.Engine().PerformAction(action.PlayerID, action.ActionType, action.TargetID, action.Data)
+		}); err != nil {
+			return r.resetToLobby(errors.Wrap(err, "replay action"))
+		}
+		r.actions = append(r.actions, action)
+	}
+	if flusher, ok := r.Engine().(engine.Flusher); ok {
+		flusher.Flush()
+	}
+
+	// 阶段开始的事件都已丢弃，从当前阶段接着记，夜晚子阶段切换时不再重复广播
+	state := r.Engine().GetState()
+	r.lastPhase, r.lastRound = publicPhase(state.Phase), state.Round
+	r.phaseStarted = time.Now()
+	r.setLogPosition(state.Round, r.lastPhase)
+
+	// 放逐投票中有警长时继续由房间加权计票，已结算的回合不再接受投票
+	if state.Phase == werewolf.PhaseVote && r.sheriff.stage == protocol.SheriffStageDone && engine.CanAbstain(r.Engine()) {
+		r.sheriff.voter = r.sheriff.id
+		for _, action := range r.actions {
+			if action.Settled && action.Round == state.Round {
+				r.sheriff.settled = state.Round
+			}
+		}
+	}
+
+	// 快照可能恰好在引擎接受动作、房间还没记下时生成，此时重放停在前一步，由玩家重新提交
+	if snapshot.Phase != "" && (state.Phase != snapshot.Phase || state.Round != snapshot.Round) {
+		r.logger.Warn("replayed game differs from snapshot",
+			"phase", state.Phase,
+			"round", state.Round,
+			"snapshotPhase", snapshot.Phase,
+			"snapshotRound", snapshot.Round)
+	}
+
+	// 重放时的事件没有处理，事件日志以快照为准
+	r.events = append([]protocol.GameEventRecord(nil), snapshot.Events...)
+
+	// 重新开局时随机选出的特殊角色以快照为准；决斗和开枪不经过引擎，直接恢复结果
+	if snapshot.Special != nil {
+		r.special.roles = maps.Clone(snapshot.Special.Roles)
+		if r.special.roles == nil {
+			r.special.roles = make(map[string]werewolf.RoleType)
+		}
+		for _, playerID := range snapshot.Special.Slain {
+			r.special.slain[playerID] = true
+		}
+		for _, playerID := range snapshot.Special.Revealed {
+			r.special.revealed[playerID] = true
+		}
+		r.special.dueled = snapshot.Special.Dueled
+		if len(snapshot.Special.Lovers) == 2 {
+			r.special.lovers = [2]string{snapshot.Special.Lovers[0], snapshot.Special.Lovers[1]}
+		}
+		if len(snapshot.Special.Extras) > 0 {
+			r.startThief(snapshot.Special.Extras)
+		}
+	}
+
+	if snapshot.Speaking != nil {
+		r.speaking.order = slices.Clone(snapshot.Speaking.Order)
+		r.speaking.turn = snapshot.Speaking.Turn
+		r.speaking.clockwise = snapshot.Speaking.Clockwise
+	}
+
+	r.transition(RoomStatePlaying)
+	if snapshot.State == RoomStatePaused {
+		r.transition(RoomStatePaused)
+		return nil
+	}
+
+	// 进行中的对局等所有真人玩家重新连上后再继续，之前按暂停处理
+	for _, playerID := range r.order {
+		if !r.Players[playerID].IsBot && !r.abandoned[playerID] {
+			r.awaiting[playerID] = nil
+		}
+	}
+	if len(r.awaiting) > 0 {
+		r.transition(RoomStatePaused)
+		if r.special.thiefTimer != nil {
+			r.special.thiefTimer.Stop()
+			r.special.thiefTimer = nil
+		}
+	}
+
+	return nil
+}
+
+// resetToLobby 无法续局时让房间回到等待状态，保留玩家名单
+func (r *Room) resetToLobby(cause error) error {
+	r.setEngine(nil)
+	r.actions = nil
+	r.events = nil
+	r.nightDeaths = nil
+	r.nightHeld = nil
+	r.stateSync.reset()
+	r.abandoned = make(map[string]bool)
+	r.special = specialRoles{}
+	r.transition(RoomStateWaiting)
+
+	for _, player := range r.Players {
+		player.IsReady = false
+	}
+
+	return errors.Wrap(cause, "game cannot be resumed, room reset to lobby")
+}
+
+// SaveRooms 把所有未结束的房间写入快照存储，删除已结束或已移除房间的旧快照，返回保存的房间数
+//
+// 单个房间保存失败时记录日志并继续，返回遇到的第一个错误。
+func (s *Server) SaveRooms() (int, error) {
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	s.mu.RLock()
+	rooms := make([]*Room, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.mu.RUnlock()
+
+	var firstErr error
+	keep := make(map[string]bool, len(rooms))
+	for _, room := range rooms {
+		snapshot := room.Snapshot()
+		if snapshot.State.ended() || len(snapshot.Players) == 0 {
+			continue
+		}
+
+		if err := s.snapshots.save(snapshot); err != nil {
+			s.logger.Warn("save room snapshot failed", "roomID", snapshot.ID, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		keep[snapshot.ID] = true
+		s.saved[snapshot.ID] = true
+	}
+
+	for roomID := range s.saved {
+		if keep[roomID] {
+			continue
+		}
+		if err := s.snapshots.remove(roomID); err != nil {
+			s.logger.Warn("remove room snapshot failed", "roomID", roomID, "error", err)
+			continue
+		}
+		delete(s.saved, roomID)
+	}
+
+	return len(keep), firstErr
+}
+
+// RunSnapshots 按 SnapshotInterval 定期保存房间，进程崩溃后重启时从最近一次快照续局，直到 ctx 结束
+func (s *Server) RunSnapshots(ctx context.Context) {
+	if s.snapshots == nil || s.config.SnapshotInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			saved, err := s.SaveRooms()
+			if err != nil {
+				s.logger.Warn("periodic snapshot incomplete", "saved", saved, "error", err)
+				continue
+			}
+			s.logger.Debug("rooms snapshotted", "count", saved)
+		}
+	}
+}
+
+// RestoreRooms 从快照存储恢复房间
+//
+// 快照留在存储中，由之后的保存覆盖或删除，恢复后、下一次保存前再次崩溃也不会丢失房间。
+// 进行中的对局暂停，等待玩家重新登录，见 rejoinRestored。
+func (s *Server) RestoreRooms() error {
+	if s.snapshots == nil {
+		return nil
+	}
+
+	snapshots, err := s.snapshots.load()
+	if err != nil {
+		return errors.Wrap(err, "load room snapshots")
+	}
+
+	for _, snapshot := range snapshots {
+		room := s.newRoom(snapshot.Name, snapshot.Roles)
+		if err := room.restore(snapshot); err != nil {
+			s.logger.Warn("room restored without game", "roomID", snapshot.ID, "error", err)
+		}
+
+		s.mu.Lock()
+		s.rooms[room.ID] = room
+		s.mu.Unlock()
+
+		s.snapshotMu.Lock()
+		s.saved[room.ID] = true
+		s.snapshotMu.Unlock()
+
+		s.awaitRestored(room)
+		s.publishRoom(room.ID)
+
+		s.logger.Info("room restored",
+			"roomID", room.ID,
+			"state", room.State,
+			"players", len(snapshot.Players),
+			"awaiting", room.awaitingCount())
+	}
+
+	return nil
+}
+
+// awaitRestored 给重启后还没连上的玩家计时，ReconnectGrace 内仍未登录的按离开房间处理
+func (s *Server) awaitRestored(room *Room) {
+	if s.config.ReconnectGrace <= 0 {
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	for playerID := range room.awaiting {
+		room.awaiting[playerID] = time.AfterFunc(s.config.ReconnectGrace, func() {
+			s.expireRestored(room, playerID)
+		})
+	}
+}
+
+// expireRestored 玩家重启后一直没有回来，座位按弃局处理；这是最后一名等待的玩家时对局继续
+func (s *Server) expireRestored(room *Room, playerID string) {
+	room.mu.Lock()
+	_, waiting := room.awaiting[playerID]
+	delete(room.awaiting, playerID)
+	remaining := len(room.awaiting)
+	placeholder := room.Players[playerID]
+	room.mu.Unlock()
+
+	if !waiting || placeholder == nil {
+		return
+	}
+
+	s.logger.Info("restored player did not return", "playerID", playerID, "roomID", room.ID)
+	s.leaveRoom(placeholder)
+
+	if remaining == 0 {
+		room.resumeRestored()
+	}
+}
+
+// rejoinRestored 重启前在对局中的玩家重新登录：还有人没回来时告知对局暂停，全员到齐后继续
+func (s *Server) rejoinRestored(room *Room, player *Player) {
+	room.mu.Lock()
+	timer, waiting := room.awaiting[player.ID]
+	if timer != nil {
+		timer.Stop()
+	}
+	delete(room.awaiting, player.ID)
+	remaining := len(room.awaiting)
+	room.mu.Unlock()
+
+	if !waiting {
+		return
+	}
+
+	if remaining == 0 {
+		room.resumeRestored()
+		return
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgGamePaused, protocol.GamePausedData{
+		Reason:  protocol.ReasonAwaitingReconnect,
+		Message: fmt.Sprintf("服务器已重启，等待其余 %d 名玩家重新连接", remaining),
+	})
+	player.SendMessage(msg)
+	room.announce(room.playerLabel(player.ID) + " 已重新连接")
+}
+
+// awaitingCount 重启后还没重新连上的玩家数
+func (r *Room) awaitingCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.awaiting)
+}
+
+// resumeRestored 重启后所有玩家都已回来（或放弃等待），继续暂停的对局
+//
+// 崩溃前的计时无法接上，当前发言者和盗贼选牌重新计时。
+func (r *Room) resumeRestored() {
+	r.mu.Lock()
+	if r.State != RoomStatePaused || r.Engine() == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.transition(RoomStatePlaying)
+
+	var msgs []speakerMessage
+	bot := ""
+	if r.speaking.active() {
+		msgs, bot = r.announceSpeaker()
+	}
+	if thiefID := r.thiefOf(); thiefID != "" && len(r.special.extras) > 0 {
+		r.armThiefTimer(thiefID)
+	}
+	r.mu.Unlock()
+
+	r.logger.Info("restored game resumed")
+	r.announce("所有玩家已重新连接，对局继续")
+
+	r.SendGameState()
+	r.sendSpeakerMessages(msgs)
+	r.botSpeakTurn(bot)
+	r.pushAllowedSkills()
+
+	if !r.thiefPending() {
+		state := r.Engine().GetState()
+		r.driveBots(state.Phase, state.Round)
+	}
+}
+
+// findRestoredPlayer 查找恢复房间中等待重新登录的玩家占位
+func (s *Server) findRestoredPlayer(playerID string) (*Room, *Player) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, room := range s.rooms {
+		room.mu.RLock()
+		player, exists := room.Players[playerID]
+		abandoned := room.abandoned[playerID]
+		room.mu.RUnlock()
+
+		if exists && !player.connected() && !abandoned {
+			return room, player
+		}
+	}
+
+	return nil, nil
+}
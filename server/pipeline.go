@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+const (
+	messageBurst  = 40               // messageWindow 内每名玩家最多发送的消息数
+	messageWindow = 10 * time.Second // 消息限速的时间窗口
+)
+
+// handlerFunc 处理一条已登录玩家发来的消息，h 绑定到这条消息，见 forRequest
+type handlerFunc func(h *MessageHandler, playerID string, msg *protocol.Message) error
+
+// middleware 包装消息处理，用于日志、鉴权、限速、校验和统计
+//
+// 中间件可以在调用 next 前后做处理，不调用 next 即拒绝该消息。
+type middleware func(next handlerFunc) handlerFunc
+
+// handle 为一种消息注册处理器，外面依次套上公共中间件和 extra
+//
+// 公共中间件从外到内为日志、panic 恢复、鉴权、限速、校验和统计，新增的消息类型注册后自动具备这些处理；
+// extra 在统计之后、处理器之前执行，用于只有部分消息需要的检查。
+func (h *MessageHandler) handle(msgType protocol.MessageType, handler handlerFunc, extra ...middleware) {
+	middlewares := []middleware{
+		logMessages,
+		recoverPanics,
+		requirePlayer,
+		limitMessages,
+		validateMessage,
+		countMessages,
+	}
+	middlewares = append(middlewares, extra...)
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	h.routes[msgType] = handler
+}
+
+// RegisterHandler 为一种消息注册处理器，消息数据按 T 解析后交给 fn，解析失败时不调用 fn
+//
+// 处理器在编译期就确定了数据类型，不必在每个处理函数里重复解析；需要回复时用 h.request 做 ReplyTo。
+func RegisterHandler[T any](h *MessageHandler, msgType protocol.MessageType, fn func(h *MessageHandler, playerID string, data *T) error, extra ...middleware) {
+	h.handle(msgType, func(h *MessageHandler, playerID string, msg *protocol.Message) error {
+		var data T
+		if err := msg.UnmarshalData(&data); err != nil {
+			return errors.Wrapf(err, "invalid data for %s", msgType)
+		}
+		return fn(h, playerID, &data)
+	}, extra...)
+}
+
+// logMessages 记录每条消息，处理出错时记录错误
+func logMessages(next handlerFunc) handlerFunc {
+	return func(h *MessageHandler, playerID string, msg *protocol.Message) error {
+		h.logger.Info("handle message")
+
+		err := next(h, playerID, msg)
+		if err != nil {
+			h.logger.Error("handle message error", "error", err)
+		}
+		return err
+	}
+}
+
+// recoverPanics 处理器 panic 时记录堆栈并转换为错误，一条畸形消息不会拖垮整个服务器
+func recoverPanics(next handlerFunc) handlerFunc {
+	return func(h *MessageHandler, playerID string, msg *protocol.Message) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				h.logger.Error("handler panic", "panic", rec, "stack", string(debug.Stack()))
+				err = errors.New("internal error")
+			}
+		}()
+
+		return next(h, playerID, msg)
+	}
+}
+
+// requirePlayer 只处理仍在线的玩家发来的消息，连接关闭后才送达的消息直接拒绝
+func requirePlayer(next handlerFunc) handlerFunc {
+	return func(h *MessageHandler, playerID string, msg *protocol.Message) error {
+		if h.server.GetPlayer(playerID) == nil {
+			return errors.New("player not found")
+		}
+		return next(h, playerID, msg)
+	}
+}
+
+// limitMessages 每名玩家在时间窗口内的消息数超过上限时拒绝，防止客户端刷消息拖慢房间
+func limitMessages(next handlerFunc) handlerFunc {
+	return func(h *MessageHandler, playerID string, msg *protocol.Message) error {
+		player := h.server.GetPlayer(playerID)
+		if player != nil && !player.messages.allow(time.Now(), messageBurst, messageWindow) {
+			h.server.metrics.limitHit(protocol.ErrorCodeRateLimited)
+			return limitError(protocol.ErrorCodeRateLimited, "too many messages, slow down")
+		}
+		return next(h, playerID, msg)
+	}
+}
+
+// validateMessage 消息数据只能为空或 JSON 对象，格式不对的消息不交给处理器
+func validateMessage(next handlerFunc) handlerFunc {
+	return func(h *MessageHandler, playerID string, msg *protocol.Message) error {
+		data := bytes.TrimSpace(msg.Data)
+		if len(data) > 0 && !bytes.Equal(data, []byte("null")) && data[0] != '{' {
+			return errors.Errorf("invalid data for %s: expected an object", msg.Type)
+		}
+		return next(h, playerID, msg)
+	}
+}
+
+// countMessages 按消息类型统计交给处理器的消息
+func countMessages(next handlerFunc) handlerFunc {
+	return func(h *MessageHandler, playerID string, msg *protocol.Message) error {
+		h.server.metrics.messageProcessed(msg.Type)
+		return next(h, playerID, msg)
+	}
+}
+
+// slidingLimiter 滑动窗口限速，记录窗口内每次请求的时间
+type slidingLimiter struct {
+	mu   sync.Mutex
+	sent []time.Time
+}
+
+// allow 窗口内的请求未达上限时记下这一次并返回 true
+func (l *slidingLimiter) allow(now time.Time, burst int, window time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := l.sent[:0]
+	for _, t := range l.sent {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	l.sent = recent
+
+	if len(l.sent) >= burst {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
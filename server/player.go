@@ -1,6 +1,10 @@
 package main
 
 import (
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/Zereker/game/protocol"
 	"github.com/Zereker/socket"
 	"github.com/google/uuid"
 )
@@ -9,33 +13,113 @@ import (
 type Player struct {
 	ID       string
 	Username string
-	Conn     *socket.Conn
 	RoomID   string
 	IsReady  bool
+	IsGuest  bool // 游客没有账号，ID 每次登录都不同
+	IsBot    bool // 服务器托管的机器人，没有连接
+
+	ProtocolVersion int // 与客户端协商的协议版本，0 表示未握手的旧客户端
+
+	SessionToken string // 会话令牌，断线后凭此在宽限期内重连
+
+	Preferences protocol.Preferences
+	muted       mutedSet       // 屏蔽的玩家，见 moderation.go
+	whispers    slidingLimiter // 私聊限速，见 whisper.go
+	messages    slidingLimiter // 消息限速，见 pipeline.go
+	Rating      int            // 注册玩家的排位积分，游客和机器人为 0
+
+	Profile protocol.Profile // 头像、简介和名字颜色，见 profile.go
+
+	spectating string // 正在观战或等待房主同意观战的房间ID，见 spectate.go
+
+	aliases atomic.Pointer[seatAliases] // 所在匿名对局的化名表，发出的消息中其他玩家的ID换成化名，见 seating.go
+
+	outbox outbox                     // 出站消息序号与重发缓冲
+	link   atomic.Pointer[playerLink] // 当前的连接和发送队列，见 attach
+	logger *slog.Logger               // 带玩家ID的日志，由 Server.AddPlayer 设置，见 logging.go
+}
+
+// playerLink 玩家当前接着的连接和它的发送队列，重连时整体替换，发送时不会读到新旧混搭的一对
+//
+// gRPC 会话只有队列没有连接。
+type playerLink struct {
+	conn  *socket.Conn
+	queue *sendQueue
 }
 
 // NewPlayer 创建新玩家
 func NewPlayer(username string, conn *socket.Conn) *Player {
-	return &Player{
+	p := &Player{
 		ID:       uuid.New().String(),
 		Username: username,
-		Conn:     conn,
 		IsReady:  false,
+		IsGuest:  true,
+
+		SessionToken: uuid.New().String(),
+		Preferences:  protocol.DefaultPreferences(),
 	}
+	p.attach(conn, nil)
+	return p
 }
 
-// SendMessage 发送消息给玩家 (通过channel异步发送)
+// NewAccountPlayer 根据账号创建玩家，玩家ID即账号ID，跨会话保持不变
+func NewAccountPlayer(account *Account, conn *socket.Conn) *Player {
+	p := &Player{
+		ID:       account.ID,
+		Username: account.Username,
+
+		SessionToken: uuid.New().String(),
+		Preferences:  account.Preferences,
+		Profile:      account.Profile,
+		Rating:       ratingOf(account.Stats),
+	}
+	p.attach(conn, nil)
+	return p
+}
+
+// attach 把玩家接到连接和它的发送队列上
+func (p *Player) attach(conn *socket.Conn, queue *sendQueue) {
+	p.link.Store(&playerLink{conn: conn, queue: queue})
+}
+
+// detach 断开玩家与连接的关联，之后发给玩家的消息直接丢弃
+func (p *Player) detach() {
+	p.link.Store(nil)
+}
+
+// queue 当前连接的发送队列，没有接在连接上时为 nil
+func (p *Player) queue() *sendQueue {
+	if link := p.link.Load(); link != nil {
+		return link.queue
+	}
+	return nil
+}
+
+// connected 玩家当前是否接在连接上，TCP 和 gRPC 玩家都有发送队列，机器人和断线的玩家没有
+func (p *Player) connected() bool {
+	return p.queue() != nil
+}
+
+// SendMessage 发送消息给玩家，只放入发送队列，不等待写入连接
 func (p *Player) SendMessage(msg socket.Message) error {
-	if p.Conn == nil {
+	queue := p.queue()
+	if queue == nil {
+		return nil
+	}
+	if aliases := p.aliases.Load(); aliases != nil {
+		msg = aliases.mask(msg, p.ID)
+	}
+	if msg = p.downgrade(msg); msg == nil {
 		return nil
 	}
-	return p.Conn.Write(msg)
+	return p.logSendError(msg, p.outbox.send(msg, queue.enqueue))
 }
 
-// SendMessageDirect 直接同步发送消息 (绕过channel)
-func (p *Player) SendMessageDirect(msg socket.Message) error {
-	if p.Conn == nil {
+// Resend 按原序号重发最近发送过的消息
+func (p *Player) Resend(fromSeq, toSeq uint64) error {
+	queue := p.queue()
+	if queue == nil {
 		return nil
 	}
-	return p.Conn.WriteDirect(msg)
+	return p.outbox.resend(fromSeq, toSeq, queue.enqueue)
 }
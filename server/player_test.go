@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+)
+
+func TestPlayerReconnectWhileSending(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	player := NewPlayer("player", nil)
+	newQueue := func() *sendQueue {
+		return newSendQueue(func(socket.Message) error { return nil }, OverflowDrop, func() {}, nil, logger)
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgPing, nil)
+
+	// 断线重连与广播同时进行，-race 下不应报告数据竞争
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			queue := newQueue()
+			player.attach(nil, queue)
+			player.detach()
+			queue.stop()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			player.SendMessage(msg)
+			player.Resend(1, 1)
+			player.connected()
+		}
+	}()
+	wg.Wait()
+
+	if player.connected() {
+		t.Error("player still connected after detach")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// supportedLanguages 支持的界面语言
+var supportedLanguages = map[string]bool{
+	"zh-CN": true,
+	"en-US": true,
+}
+
+// seatColors 可选的座位颜色（与客户端 ANSI 颜色一致）
+var seatColors = map[string]bool{
+	"red":    true,
+	"green":  true,
+	"yellow": true,
+	"blue":   true,
+	"purple": true,
+	"cyan":   true,
+	"white":  true,
+}
+
+// validatePreferences 校验偏好设置
+func validatePreferences(prefs protocol.Preferences) error {
+	if prefs.Language != "" && !supportedLanguages[prefs.Language] {
+		return errors.Errorf("unsupported language: %s", prefs.Language)
+	}
+
+	if prefs.SeatColor != "" && !seatColors[prefs.SeatColor] {
+		return errors.Errorf("unsupported seat color: %s", prefs.SeatColor)
+	}
+
+	if _, err := protocol.LoadLocation(prefs.TimeZone); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// handleGetPreferences 处理查询偏好设置
+func (h *MessageHandler) handleGetPreferences(playerID string) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	return h.sendPreferences(player)
+}
+
+// handleSetPreferences 处理保存偏好设置，游客的偏好只在本次会话有效
+func (h *MessageHandler) handleSetPreferences(playerID string, data *protocol.PreferencesData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	if player.IsGuest {
+		if err := validatePreferences(data.Preferences); err != nil {
+			return err
+		}
+	} else if err := h.server.accounts.SavePreferences(player.ID, data.Preferences); err != nil {
+		return err
+	}
+
+	player.Preferences = data.Preferences
+
+	return h.sendPreferences(player)
+}
+
+// sendPreferences 把当前偏好发送给玩家
+func (h *MessageHandler) sendPreferences(player *Player) error {
+	msg, _ := protocol.NewMessage(protocol.MsgPreferences, protocol.PreferencesData{
+		Preferences: player.Preferences,
+	})
+
+	return player.SendMessage(msg)
+}
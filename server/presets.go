@@ -0,0 +1,96 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// rolePresets 建房可选的角色预设，按列表顺序展示
+var rolePresets = []protocol.RolePreset{
+	{
+		Name:        "newbie6",
+		Title:       "新手6人局",
+		Description: "2狼 2民 预言家 女巫，角色少、节奏快，适合第一次玩",
+		Roles: []werewolf.RoleType{
+			werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf,
+			werewolf.RoleTypeVillager, werewolf.RoleTypeVillager,
+			werewolf.RoleTypeSeer, werewolf.RoleTypeWitch,
+		},
+	},
+	{
+		Name:        "standard9",
+		Title:       "标准9人局",
+		Description: "3狼 3民 预言家 女巫 猎人",
+		Roles: []werewolf.RoleType{
+			werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf,
+			werewolf.RoleTypeVillager, werewolf.RoleTypeVillager, werewolf.RoleTypeVillager,
+			werewolf.RoleTypeSeer, werewolf.RoleTypeWitch, werewolf.RoleTypeHunter,
+		},
+	},
+	{
+		Name:        "standard12",
+		Title:       "预女猎白12人局",
+		Description: "4狼 4民 预言家 女巫 猎人 白痴",
+		Roles: []werewolf.RoleType{
+			werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf,
+			werewolf.RoleTypeVillager, werewolf.RoleTypeVillager, werewolf.RoleTypeVillager, werewolf.RoleTypeVillager,
+			werewolf.RoleTypeSeer, werewolf.RoleTypeWitch, werewolf.RoleTypeHunter, protocol.RoleTypeIdiot,
+		},
+	},
+	{
+		Name:        "wolfking12",
+		Title:       "狼王守卫12人局",
+		Description: "狼王 3狼 4民 预言家 女巫 猎人 守卫",
+		Roles: []werewolf.RoleType{
+			protocol.RoleTypeWolfKing, werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf, werewolf.RoleTypeWerewolf,
+			werewolf.RoleTypeVillager, werewolf.RoleTypeVillager, werewolf.RoleTypeVillager, werewolf.RoleTypeVillager,
+			werewolf.RoleTypeSeer, werewolf.RoleTypeWitch, werewolf.RoleTypeHunter, werewolf.RoleTypeGuard,
+		},
+	},
+}
+
+// findPreset 按名称查找角色预设
+func findPreset(name string) (protocol.RolePreset, bool) {
+	for _, preset := range rolePresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return protocol.RolePreset{}, false
+}
+
+// configRoles 房间配置实际使用的角色：预设、手动列出的角色或默认6人局
+func configRoles(config protocol.CreateRoomData) ([]werewolf.RoleType, error) {
+	if config.Preset != "" {
+		if len(config.Roles) > 0 {
+			return nil, errors.New("preset and roles cannot both be set")
+		}
+		preset, ok := findPreset(config.Preset)
+		if !ok {
+			return nil, errors.Errorf("unknown preset: %s", config.Preset)
+		}
+		return slices.Clone(preset.Roles), nil
+	}
+
+	if len(config.Roles) == 0 {
+		return defaultRoles(), nil
+	}
+	return config.Roles, nil
+}
+
+// handleListPresets 处理查询角色预设
+func (h *MessageHandler) handleListPresets(playerID string) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	respMsg, _ := protocol.NewMessage(protocol.MsgPresetList, protocol.PresetListData{
+		Presets: rolePresets,
+	})
+
+	return player.SendMessage(respMsg.ReplyTo(h.request))
+}
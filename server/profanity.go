@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// FilterPolicy 发言命中词表时的处理方式
+type FilterPolicy string
+
+const (
+	FilterMask  FilterPolicy = "mask"  // 命中的词换成星号后照常发出
+	FilterBlock FilterPolicy = "block" // 整条发言不发出，私下告知发言人
+)
+
+// ParseFilterPolicy 解析不当发言的处理方式
+func ParseFilterPolicy(s string) (FilterPolicy, error) {
+	switch policy := FilterPolicy(s); policy {
+	case FilterMask, FilterBlock:
+		return policy, nil
+	default:
+		return "", errors.Errorf("unknown profanity policy: %s", s)
+	}
+}
+
+// loadWordList 读取词表文件：每行一个词，空行和 # 开头的行忽略
+func loadWordList(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read profanity word list")
+	}
+
+	var words []string
+	for _, line := range strings.Split(string(content), "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words, nil
+}
+
+// wordFilter 按词表过滤发言，配置见 Config.ProfanityWords
+type wordFilter struct {
+	words     [][]rune // 小写的词
+	policy    FilterPolicy
+	muteAfter int // 房间里累计违规多少次后禁言，0 表示不禁言
+	kickAfter int // 累计违规多少次后移出房间，0 表示不移出
+}
+
+// newWordFilter 配置了词表时返回过滤器，否则返回 nil
+func newWordFilter(config Config) *wordFilter {
+	if len(config.ProfanityWords) == 0 {
+		return nil
+	}
+
+	f := &wordFilter{
+		policy:    config.ProfanityPolicy,
+		muteAfter: config.ProfanityMute,
+		kickAfter: config.ProfanityKick,
+	}
+	for _, word := range config.ProfanityWords {
+		f.words = append(f.words, []rune(strings.ToLower(word)))
+	}
+	return f
+}
+
+// clean 把命中词表的部分换成星号，不区分大小写，返回处理后的内容和是否命中
+func (f *wordFilter) clean(content string) (string, bool) {
+	runes := []rune(content)
+	lower := []rune(strings.ToLower(content))
+	if len(lower) != len(runes) {
+		// 个别字符转小写后长度变化，退回按原文匹配
+		lower = slices.Clone(runes)
+	}
+
+	hit := false
+	for _, word := range f.words {
+		for i := 0; i+len(word) <= len(lower); i++ {
+			if !slices.Equal(lower[i:i+len(word)], word) {
+				continue
+			}
+			for j := i; j < i+len(word); j++ {
+				runes[j] = '*'
+			}
+			hit = true
+		}
+	}
+	return string(runes), hit
+}
+
+// chatViolations 房间里每名玩家不当发言的次数，房间存在期间一直累计
+type chatViolations struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// add 记一次违规，返回累计次数
+func (v *chatViolations) add(playerID string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.counts == nil {
+		v.counts = make(map[string]int)
+	}
+	v.counts[playerID]++
+	return v.counts[playerID]
+}
+
+// count 累计违规次数
+func (v *chatViolations) count(playerID string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.counts[playerID]
+}
+
+// profanityMiddleware 发言、竞选发言和私聊发出前按词表过滤的出站中间件
+//
+// 命中词表时按策略打码或整条拦下，并给发言人记一次违规；累计达到禁言线后该玩家的发言一律不再发出，
+// 达到移出线时把玩家移出房间，对局中按弃局处理。房主建房时关闭了过滤的房间不处理。
+func (s *Server) profanityMiddleware(room *Room) outboundMiddleware {
+	f := s.profanity
+
+	return func(msg *protocol.Message) *protocol.Message {
+		speaker, content := chatSpeaker(msg)
+		if speaker == "" || !room.chatFiltered() {
+			return msg
+		}
+
+		cleaned, hit := f.clean(content)
+		count := room.violations.count(speaker)
+		if hit {
+			count = room.violations.add(speaker)
+			room.playerLogger(speaker).Info("profanity filtered", "violations", count)
+		}
+
+		switch {
+		case hit && f.kickAfter > 0 && count >= f.kickAfter:
+			room.spawn(func() { s.kickForProfanity(room, speaker) })
+			return nil
+		case f.muteAfter > 0 && count >= f.muteAfter:
+			room.announcePrivate(speaker, fmt.Sprintf("你已有 %d 次不当发言，被禁言，发言不会发出", count))
+			return nil
+		case !hit:
+			return msg
+		case f.policy == FilterBlock:
+			room.announcePrivate(speaker, "发言含有不当词语，没有发出")
+			return nil
+		}
+
+		return withChatContent(msg, cleaned)
+	}
+}
+
+// withChatContent 换掉发言类消息的内容，生成新消息
+func withChatContent(msg *protocol.Message, content string) *protocol.Message {
+	var data map[string]interface{}
+	if err := msg.UnmarshalData(&data); err != nil {
+		return msg
+	}
+	data["content"] = content
+
+	cleaned, err := protocol.NewMessage(msg.Type, data)
+	if err != nil {
+		return msg
+	}
+	return cleaned
+}
+
+// kickForProfanity 不当发言累计达到移出线的玩家被移出房间
+func (s *Server) kickForProfanity(room *Room, playerID string) {
+	player := s.GetPlayer(playerID)
+	if player == nil || player.RoomID != room.ID {
+		return
+	}
+
+	kickedMsg, _ := protocol.NewMessage(protocol.MsgKicked, protocol.KickedData{
+		RoomID: room.ID,
+		Reason: protocol.ReasonProfanity,
+	})
+	player.SendMessage(kickedMsg)
+
+	s.leaveRoom(player)
+
+	s.logger.Info("player removed for profanity", "roomID", room.ID, "playerID", playerID)
+}
+
+// chatFiltered 房间是否过滤发言
+func (r *Room) chatFiltered() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return !r.unfilteredChat
+}
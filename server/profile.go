@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// 玩家资料的长度限制，按字符计
+const (
+	maxAvatarLength = 16 // 足够放下带修饰符的组合表情或头像编号
+	maxBioLength    = 80
+)
+
+// validateProfile 检查资料各字段的长度和字符，返回去掉首尾空白后的资料
+//
+// 头像不能含空白，简介不能含控制字符，名字颜色只能是座位颜色之一，避免一个人的资料打乱别人的玩家列表。
+func validateProfile(profile protocol.Profile) (protocol.Profile, error) {
+	profile.Avatar = strings.TrimSpace(profile.Avatar)
+	profile.Bio = strings.TrimSpace(profile.Bio)
+	profile.Color = strings.ToLower(strings.TrimSpace(profile.Color))
+
+	if n := utf8.RuneCountInString(profile.Avatar); n > maxAvatarLength {
+		return protocol.Profile{}, errors.Errorf("avatar must be at most %d characters", maxAvatarLength)
+	}
+	if strings.IndexFunc(profile.Avatar, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return protocol.Profile{}, errors.New("avatar must not contain spaces")
+	}
+
+	if n := utf8.RuneCountInString(profile.Bio); n > maxBioLength {
+		return protocol.Profile{}, errors.Errorf("bio must be at most %d characters", maxBioLength)
+	}
+	if strings.IndexFunc(profile.Bio, unicode.IsControl) >= 0 {
+		return protocol.Profile{}, errors.New("bio must not contain control characters")
+	}
+
+	if profile.Color != "" && !seatColors[profile.Color] {
+		return protocol.Profile{}, errors.Errorf("unsupported name color: %s", profile.Color)
+	}
+
+	return profile, nil
+}
+
+// SaveProfile 校验并保存账号资料，返回实际保存的资料
+func (s *AccountService) SaveProfile(accountID string, profile protocol.Profile) (protocol.Profile, error) {
+	profile, err := validateProfile(profile)
+	if err != nil {
+		return protocol.Profile{}, err
+	}
+	return profile, s.store.SaveProfile(accountID, profile)
+}
+
+// updateProfile 更新玩家资料；注册玩家的资料随账号保存，游客的资料只在本次会话有效
+func (s *Server) updateProfile(player *Player, profile protocol.Profile) error {
+	var err error
+	if player.IsGuest {
+		profile, err = validateProfile(profile)
+	} else {
+		profile, err = s.accounts.SaveProfile(player.ID, profile)
+	}
+	if err != nil {
+		return err
+	}
+
+	player.Profile = profile
+	return nil
+}
+
+// publicProfile 玩家列表中展示的资料，没有设置任何字段时为 nil
+func (p *Player) publicProfile() *protocol.Profile {
+	if p.Profile == (protocol.Profile{}) {
+		return nil
+	}
+
+	profile := p.Profile
+	return &profile
+}
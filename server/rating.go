@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// ratingK 一局排位积分变化的基准（ELO 的 K 值）
+const ratingK = 32
+
+// roleRatingWeight 各角色积分变化的倍数：神职对胜负影响更大，输赢时的积分变化也更大
+var roleRatingWeight = map[werewolf.RoleType]float64{
+	werewolf.RoleTypeVillager: 0.8,
+	werewolf.RoleTypeWerewolf: 1.0,
+	werewolf.RoleTypeSeer:     1.2,
+	werewolf.RoleTypeWitch:    1.2,
+	werewolf.RoleTypeGuard:    1.2,
+	protocol.RoleTypeIdiot:    1.0,
+	protocol.RoleTypeKnight:   1.2,
+	protocol.RoleTypeWolfKing: 1.2,
+	protocol.RoleTypeCupid:    1.0,
+	protocol.RoleTypeThief:    1.0,
+}
+
+var (
+	// ErrRankedGuest 游客不能参加排位
+	ErrRankedGuest = errors.New("ranked rooms are for registered players only")
+	// ErrRankedBots 排位房间不能加入机器人
+	ErrRankedBots = errors.New("bots cannot join ranked rooms")
+)
+
+// ratingOf 账号当前的排位积分，没打过排位时为初始积分
+func ratingOf(stats protocol.AccountStats) int {
+	if stats.RatedGames == 0 {
+		return protocol.InitialRating
+	}
+	return stats.Rating
+}
+
+// ratedPlayer 参与积分结算的玩家
+type ratedPlayer struct {
+	id     string
+	role   werewolf.RoleType
+	rating int
+}
+
+// rateGame 按阵营结算排位积分，返回每名玩家的积分变化
+//
+// 以双方阵营的平均积分计算期望胜率，胜方按 (1-期望) 加分、负方按期望扣分，
+// 再乘以角色倍数。一方没有参与结算的玩家时不调整积分。
+func rateGame(players []ratedPlayer, winner werewolf.Camp) map[string]int {
+	total := make(map[werewolf.Camp]int)
+	count := make(map[werewolf.Camp]int)
+	for _, p := range players {
+		camp := roleCamp(p.role)
+		total[camp] += p.rating
+		count[camp]++
+	}
+
+	if count[werewolf.CampGood] == 0 || count[werewolf.CampEvil] == 0 {
+		return nil
+	}
+
+	average := func(camp werewolf.Camp) float64 {
+		return float64(total[camp]) / float64(count[camp])
+	}
+
+	changes := make(map[string]int, len(players))
+	for _, p := range players {
+		camp, opponent := roleCamp(p.role), werewolf.CampEvil
+		if camp == werewolf.CampEvil {
+			opponent = werewolf.CampGood
+		}
+
+		expected := 1 / (1 + math.Pow(10, (average(opponent)-average(camp))/400))
+		score := 0.0
+		if camp == winner {
+			score = 1
+		}
+
+		weight, ok := roleRatingWeight[p.role]
+		if !ok {
+			weight = 1
+		}
+
+		changes[p.id] = int(math.Round(ratingK * weight * (score - expected)))
+	}
+
+	return changes
+}
+
+// checkRanked 排位房间只接受注册玩家（需持有锁）
+func (r *Room) checkRanked(player *Player) error {
+	if !r.ranked {
+		return nil
+	}
+	if player.IsBot {
+		return ErrRankedBots
+	}
+	if player.IsGuest {
+		return ErrRankedGuest
+	}
+	return nil
+}
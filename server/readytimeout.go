@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+)
+
+const (
+	maxReadyTimeout  = 30 * time.Minute // 房间可设置的最长准备时限
+	readyWarningLead = 30 * time.Second // 移出前多久提醒未准备的玩家，时限较短时在一半时提醒
+)
+
+// readyTimer 满员的房间里多数人已准备时，给未准备的玩家的时限
+//
+// 房间设置了准备时限（CreateRoomData.ReadyTimeout）才会计时：房间满员、至少有一名真人已准备、
+// 未准备的玩家不到一半时开始计时，快到时间时私下提醒，到时间仍未准备的玩家被移出房间，空出座位。
+// 条件不再满足（有人离开、更多人取消准备或全员准备开局）时计时取消。
+type readyTimer struct {
+	deadline time.Time // 未准备的玩家被移出的时间，没有计时时为零值
+	timer    *time.Timer
+	epoch    int // 每次开始或取消计时加一，过期的计时器据此失效
+}
+
+// active 是否正在计时
+func (t *readyTimer) active() bool {
+	return !t.deadline.IsZero()
+}
+
+// idlePlayers 正拖住开局的未准备玩家，不满足计时条件时返回 nil（需持有锁）
+func (r *Room) idlePlayers() []string {
+	if r.readyTimeout <= 0 || r.State != RoomStateWaiting || len(r.Players) != r.seats() {
+		return nil
+	}
+
+	var idle []string
+	readyHumans := 0
+	for _, playerID := range r.order {
+		player := r.Players[playerID]
+		switch {
+		case !player.IsReady:
+			idle = append(idle, playerID)
+		case !player.IsBot:
+			readyHumans++
+		}
+	}
+
+	if readyHumans == 0 || len(idle)*2 >= len(r.Players) {
+		return nil
+	}
+	return idle
+}
+
+// readyCountdown 距离移出未准备玩家的秒数，没有计时时为 0（需持有锁）
+func (r *Room) readyCountdown() int {
+	return secondsUntil(r.ready.deadline)
+}
+
+// updateReadyTimer 准备状态或玩家名单变化后开始或取消计时；开始计时时向房间广播每名未准备玩家的时限
+func (r *Room) updateReadyTimer() {
+	r.mu.Lock()
+	idle := r.idlePlayers()
+	if len(idle) == 0 {
+		r.stopReadyTimer()
+		r.mu.Unlock()
+		return
+	}
+	if r.ready.active() {
+		r.mu.Unlock()
+		return
+	}
+
+	r.ready.epoch++
+	epoch := r.ready.epoch
+	r.ready.deadline = time.Now().Add(r.readyTimeout)
+
+	lead := readyWarningLead
+	if lead > r.readyTimeout/2 {
+		lead = r.readyTimeout / 2
+	}
+	r.ready.timer = time.AfterFunc(r.readyTimeout-lead, func() { r.warnIdle(epoch) })
+	seconds := r.readyCountdown()
+	r.mu.Unlock()
+
+	r.logger.Info("ready timeout started", "idle", len(idle), "timeout", r.readyTimeout)
+
+	for _, playerID := range idle {
+		msg, _ := protocol.NewMessage(protocol.MsgPlayerReady, protocol.PlayerReadyData{
+			PlayerID:     playerID,
+			ReadyTimeout: seconds,
+		})
+		r.BroadcastMessage(msg)
+	}
+}
+
+// stopReadyTimer 取消计时（需持有锁）
+func (r *Room) stopReadyTimer() {
+	if !r.ready.active() {
+		return
+	}
+
+	if r.ready.timer != nil {
+		r.ready.timer.Stop()
+	}
+	r.ready = readyTimer{epoch: r.ready.epoch + 1}
+}
+
+// warnIdle 快到时间时私下提醒未准备的玩家，到时间后再检查一次
+func (r *Room) warnIdle(epoch int) {
+	r.mu.Lock()
+	if epoch != r.ready.epoch {
+		r.mu.Unlock()
+		return
+	}
+
+	idle := r.idlePlayers()
+	seconds := r.readyCountdown()
+	r.ready.timer = time.AfterFunc(time.Until(r.ready.deadline), func() { r.expireIdle(epoch) })
+	r.mu.Unlock()
+
+	for _, playerID := range idle {
+		r.announcePrivate(playerID, fmt.Sprintf("其他玩家都在等你，%d 秒内不准备将被移出房间", seconds))
+	}
+}
+
+// expireIdle 时间到，仍未准备的玩家被移出房间
+func (r *Room) expireIdle(epoch int) {
+	r.mu.Lock()
+	if epoch != r.ready.epoch {
+		r.mu.Unlock()
+		return
+	}
+
+	idle := r.idlePlayers()
+	players := make([]*Player, 0, len(idle))
+	for _, playerID := range idle {
+		players = append(players, r.Players[playerID])
+	}
+	r.ready = readyTimer{epoch: r.ready.epoch + 1}
+	oldOwner := r.OwnerID
+	r.mu.Unlock()
+
+	for _, player := range players {
+		r.evict(player)
+
+		kickedMsg, _ := protocol.NewMessage(protocol.MsgKicked, protocol.KickedData{
+			RoomID: r.ID,
+			Reason: protocol.ReasonReadyTimeout,
+		})
+		player.SendMessage(kickedMsg)
+
+		leftMsg, _ := protocol.NewMessage(protocol.MsgPlayerLeft, protocol.PlayerLeftData{
+			PlayerID: player.ID,
+		})
+		r.BroadcastMessage(leftMsg)
+
+		r.logger.Info("player removed for not getting ready", "playerID", player.ID)
+	}
+
+	r.mu.Lock()
+	if _, exists := r.Players[r.OwnerID]; !exists {
+		r.OwnerID = r.nextOwner()
+	}
+	owner := r.OwnerID
+	r.mu.Unlock()
+
+	if owner != oldOwner && owner != "" {
+		ownerMsg, _ := protocol.NewMessage(protocol.MsgOwnerChanged, protocol.OwnerChangedData{
+			OwnerID: owner,
+		})
+		r.BroadcastMessage(ownerMsg)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// redisTimeout 一次 Redis 操作（连接和全部命令）的时限
+const redisTimeout = 5 * time.Second
+
+// redisClient 最小的 Redis 客户端，供快照存储和房间路由表使用
+//
+// 地址为 host:port 或 redis://[:password@]host:port[/db]。这些操作频率很低，
+// 每次操作单独建立连接，只用到字符串、集合和过期命令。
+type redisClient struct {
+	addr string
+}
+
+// String 返回去掉密码的地址，用于日志
+func (r *redisClient) String() string {
+	if u, err := url.Parse(r.addr); err == nil && u.Host != "" {
+		return u.Redacted()
+	}
+	return r.addr
+}
+
+// session 建立连接，按地址认证和选库后执行 fn
+func (r *redisClient) session(fn func(c *redisConn) error) error {
+	addr, password, db := r.addr, "", 0
+	if u, err := url.Parse(r.addr); err == nil && u.Scheme == "redis" {
+		addr = u.Host
+		if p, ok := u.User.Password(); ok {
+			password = p
+		}
+		if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+			if db, err = strconv.Atoi(path); err != nil {
+				return errors.Errorf("invalid redis database: %s", path)
+			}
+		}
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, redisTimeout)
+	if err != nil {
+		return errors.Wrap(err, "connect redis")
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	c := &redisConn{rw: bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))}
+	if password != "" {
+		if _, err := c.do("AUTH", password); err != nil {
+			return err
+		}
+	}
+	if db != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(db)); err != nil {
+			return err
+		}
+	}
+
+	return fn(c)
+}
+
+// redisConn 一条 Redis 连接，按 RESP 协议收发命令
+type redisConn struct {
+	rw *bufio.ReadWriter
+}
+
+// do 发送一条命令并读取回复：状态和批量字符串为 string，整数为 int64，数组为 []interface{}，空值为 nil
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	fmt.Fprintf(c.rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.rw.Flush(); err != nil {
+		return nil, errors.Wrap(err, "redis write")
+	}
+
+	reply, err := c.read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "redis %s", args[0])
+	}
+	return reply, nil
+}
+
+// read 读取一条回复
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.rw.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.rw, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, 0, n)
+		for range n {
+			item, err := c.read()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, errors.Errorf("unexpected reply: %q", line)
+	}
+}
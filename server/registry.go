@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 路由表的键：房间和会话令牌分别对应所在工作节点的地址
+const (
+	registryRoomPrefix    = "werewolf:route:room:"
+	registrySessionPrefix = "werewolf:route:session:"
+)
+
+// registrySessionTTL 会话路由的保留时长，足够覆盖断线重连和服务器重启
+const registrySessionTTL = 24 * time.Hour
+
+// roomRegistry 多进程部署时的房间路由表，记录每个房间和会话在哪个工作节点上
+//
+// 工作节点创建、恢复和移除房间时更新路由表，网关按路由表把玩家转发到房间所在的节点，见 gateway.go。
+type roomRegistry struct {
+	redisClient
+}
+
+// newRoomRegistry 配置了路由表地址时返回路由表，否则返回 nil
+func newRoomRegistry(addr string) *roomRegistry {
+	if addr == "" {
+		return nil
+	}
+	return &roomRegistry{redisClient{addr: addr}}
+}
+
+// set 写入一条路由，ttl 为 0 表示不过期
+func (r *roomRegistry) set(key, worker string, ttl time.Duration) error {
+	return r.session(func(c *redisConn) error {
+		args := []string{"SET", key, worker}
+		if ttl > 0 {
+			args = append(args, "EX", strconv.Itoa(int(ttl.Seconds())))
+		}
+		_, err := c.do(args...)
+		return err
+	})
+}
+
+// lookup 查询一条路由，没有时返回空
+func (r *roomRegistry) lookup(key string) (string, error) {
+	var worker string
+	err := r.session(func(c *redisConn) error {
+		reply, err := c.do("GET", key)
+		worker, _ = reply.(string)
+		return err
+	})
+	return worker, err
+}
+
+// claimRoom 登记房间在 worker 节点上
+func (r *roomRegistry) claimRoom(roomID, worker string) error {
+	return r.set(registryRoomPrefix+roomID, worker, 0)
+}
+
+// releaseRoom 房间移除后删除路由
+func (r *roomRegistry) releaseRoom(roomID string) error {
+	return r.session(func(c *redisConn) error {
+		_, err := c.do("DEL", registryRoomPrefix+roomID)
+		return err
+	})
+}
+
+// roomWorker 房间所在的工作节点
+func (r *roomRegistry) roomWorker(roomID string) (string, error) {
+	worker, err := r.lookup(registryRoomPrefix + roomID)
+	return worker, errors.Wrap(err, "lookup room route")
+}
+
+// claimSession 登记会话令牌在 worker 节点上，断线重连时网关据此找回原来的节点
+func (r *roomRegistry) claimSession(token, worker string) error {
+	return r.set(registrySessionPrefix+token, worker, registrySessionTTL)
+}
+
+// sessionWorker 会话令牌所在的工作节点
+func (r *roomRegistry) sessionWorker(token string) (string, error) {
+	worker, err := r.lookup(registrySessionPrefix + token)
+	return worker, errors.Wrap(err, "lookup session route")
+}
+
+// publishRoom 工作节点上有了新房间（创建或恢复），在后台登记路由
+func (s *Server) publishRoom(roomID string) {
+	if s.registry == nil {
+		return
+	}
+
+	go func() {
+		if err := s.registry.claimRoom(roomID, s.config.WorkerAddr); err != nil {
+			s.logger.Warn("register room route failed", "roomID", roomID, "error", err)
+		}
+	}()
+}
+
+// unpublishRoom 房间移除后在后台删除路由
+func (s *Server) unpublishRoom(roomID string) {
+	if s.registry == nil {
+		return
+	}
+
+	go func() {
+		if err := s.registry.releaseRoom(roomID); err != nil {
+			s.logger.Warn("remove room route failed", "roomID", roomID, "error", err)
+		}
+	}()
+}
+
+// publishSession 玩家登录后在后台登记会话所在的节点
+func (s *Server) publishSession(token string) {
+	if s.registry == nil || token == "" {
+		return
+	}
+
+	go func() {
+		if err := s.registry.claimSession(token, s.config.WorkerAddr); err != nil {
+			s.logger.Warn("register session route failed", "error", err)
+		}
+	}()
+}
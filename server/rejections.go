@@ -0,0 +1,136 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RejectionReason 玩家动作被拒绝的原因分类
+type RejectionReason string
+
+const (
+	RejectWrongPhase    RejectionReason = "wrong_phase"    // 当前阶段不允许该动作，或对局已暂停、结束
+	RejectInvalidTarget RejectionReason = "invalid_target" // 座位号或目标不合法
+	RejectInvalidAction RejectionReason = "invalid_action" // 动作类型未知或字段不合法
+	RejectRateLimited   RejectionReason = "rate_limited"   // 房间动作队列已满
+	RejectRule          RejectionReason = "rule"           // 本局规则禁止，如守卫连守、女巫自救、没轮到发言
+	RejectOther         RejectionReason = "other"          // 引擎按角色规则拒绝等其他情况
+)
+
+// RejectedError 带分类的动作拒绝，Error 保持原始错误信息
+type RejectedError struct {
+	Reason RejectionReason
+	Err    error
+}
+
+// Error 实现 error 接口
+func (e *RejectedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
+
+// rejected 为动作拒绝标注原因
+func rejected(reason RejectionReason, err error) error {
+	return &RejectedError{Reason: reason, Err: err}
+}
+
+// PlayerRejections 一名玩家本局被拒绝的动作数
+type PlayerRejections struct {
+	PlayerID string                  `json:"playerID"`
+	Username string                  `json:"username"`
+	Total    int                     `json:"total"`
+	ByReason map[RejectionReason]int `json:"byReason"`
+}
+
+// rejectionStats 本局各玩家被拒绝的动作计数，用于区分不熟悉规则的新手和刻意试探
+type rejectionStats struct {
+	mu     sync.Mutex
+	counts map[string]map[RejectionReason]int // playerID -> 原因 -> 次数
+}
+
+// record 记录一次拒绝
+func (s *rejectionStats) record(playerID string, reason RejectionReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts == nil {
+		s.counts = make(map[string]map[RejectionReason]int)
+	}
+	if s.counts[playerID] == nil {
+		s.counts[playerID] = make(map[RejectionReason]int)
+	}
+	s.counts[playerID][reason]++
+}
+
+// reset 新开一局时清空
+func (s *rejectionStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts = nil
+}
+
+// get 返回玩家的计数副本
+func (s *rejectionStats) get(playerID string) map[RejectionReason]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts[playerID] == nil {
+		return nil
+	}
+
+	counts := make(map[RejectionReason]int, len(s.counts[playerID]))
+	for reason, n := range s.counts[playerID] {
+		counts[reason] = n
+	}
+	return counts
+}
+
+// recordRejection 统计玩家被拒绝的动作，引擎内部错误不是玩家造成的，不计入
+func (r *Room) recordRejection(playerID string, err error) {
+	var engineErr *EngineError
+	if err == nil || errors.As(err, &engineErr) {
+		return
+	}
+
+	reason := RejectOther
+	var rejectedErr *RejectedError
+	if errors.As(err, &rejectedErr) {
+		reason = rejectedErr.Reason
+	}
+
+	r.rejections.record(playerID, reason)
+}
+
+// Rejections 本局有动作被拒绝的玩家，按座位顺序排列
+func (r *Room) Rejections() []PlayerRejections {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []PlayerRejections
+	for _, playerID := range r.order {
+		counts := r.rejections.get(playerID)
+		if len(counts) == 0 {
+			continue
+		}
+
+		entry := PlayerRejections{
+			PlayerID: playerID,
+			ByReason: counts,
+		}
+		if player := r.Players[playerID]; player != nil {
+			entry.Username = player.Username
+		}
+		for _, n := range counts {
+			entry.Total += n
+		}
+		result = append(result, entry)
+	}
+
+	return result
+}
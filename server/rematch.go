@@ -0,0 +1,195 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// handleRematch 处理对局结束后的再来一局表态
+func (h *MessageHandler) handleRematch(playerID string, data *protocol.RematchData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return errors.New("player not in room")
+	}
+
+	if err := room.voteRematch(playerID, data.OptIn); err != nil {
+		return err
+	}
+
+	h.logger.Info("rematch vote", "roomID", room.ID, "optIn", data.OptIn)
+
+	return room.progressRematch()
+}
+
+// voteRematch 记下玩家的表态，只有已结束房间里仍在场的真人玩家可以表态
+func (r *Room) voteRematch(playerID string, optIn bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.State != RoomStateFinished {
+		return errors.New("game has not ended")
+	}
+
+	player, exists := r.Players[playerID]
+	if !exists || player.IsBot || r.abandoned[playerID] {
+		return errors.New("player not in room")
+	}
+
+	if r.rematch == nil {
+		r.rematch = make(map[string]bool)
+	}
+	if optIn {
+		r.rematch[playerID] = true
+	} else {
+		delete(r.rematch, playerID)
+	}
+
+	return nil
+}
+
+// progressRematch 广播最新的表态；仍在场的真人玩家都同意后把房间重置为等待状态，
+// 同意的玩家视为已准备，满员时直接进入开局倒计时。表态中途有人离开时也调用，
+// 剩下的人都已同意就不必再等
+func (r *Room) progressRematch() error {
+	r.mu.Lock()
+	if r.State != RoomStateFinished {
+		r.mu.Unlock()
+		return nil
+	}
+
+	optedIn, needed := r.rematchVotes()
+	reset := needed > 0 && len(optedIn) == needed
+	if reset {
+		r.resetForRematch()
+	}
+
+	status := protocol.RematchStatusData{
+		OptedIn: optedIn,
+		Needed:  needed,
+		Reset:   reset,
+	}
+	r.mu.Unlock()
+
+	if reset {
+		status.Players = r.GetPlayerList()
+		r.logger.Info("room reset for rematch", "players", len(status.Players))
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgRematchStatus, status)
+	r.BroadcastMessage(msg)
+
+	if reset {
+		r.updateReadyTimer()
+		if _, err := r.tryStart(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rematchPending 已结束的房间里是否有人表态过再来一局
+func (r *Room) rematchPending() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.State == RoomStateFinished && len(r.rematch) > 0
+}
+
+// rematchVotes 按座位顺序列出已同意的真人玩家，以及需要同意的真人玩家数（需持有锁）
+func (r *Room) rematchVotes() (optedIn []string, needed int) {
+	optedIn = []string{}
+	for _, playerID := range r.order {
+		player := r.Players[playerID]
+		if player.IsBot || r.abandoned[playerID] {
+			continue
+		}
+
+		needed++
+		if r.rematch[playerID] {
+			optedIn = append(optedIn, playerID)
+		}
+	}
+	return optedIn, needed
+}
+
+// resetForRematch 房间回到等待状态，保留仍在场的玩家和角色配置（需持有锁）
+//
+// 对局中离开的玩家的占位座位被移除，空出的座位可以由新玩家或机器人补上；
+// 角色重新洗牌，上一局遗留的计时器全部失效。
+func (r *Room) resetForRematch() {
+	order := r.order[:0]
+	for _, playerID := range r.order {
+		if r.abandoned[playerID] {
+			delete(r.Players, playerID)
+			continue
+		}
+		order = append(order, playerID)
+	}
+	r.order = order
+
+	for playerID, player := range r.Players {
+		player.IsReady = player.IsBot || r.rematch[playerID]
+	}
+	r.rematch = nil
+
+	if r.sheriff.timer != nil {
+		r.sheriff.timer.Stop()
+	}
+	r.sheriff = sheriffState{epoch: r.sheriff.epoch + 1}
+	if r.speaking.timer != nil {
+		r.speaking.timer.Stop()
+	}
+	r.speaking = speakingOrder{epoch: r.speaking.epoch + 1}
+	if r.witch.timer != nil {
+		r.witch.timer.Stop()
+	}
+	r.witch = witchFlow{epoch: r.witch.epoch + 1}
+	if r.special.timer != nil {
+		r.special.timer.Stop()
+	}
+	if r.special.thiefTimer != nil {
+		r.special.thiefTimer.Stop()
+	}
+	r.special = specialRoles{epoch: r.special.epoch + 1}
+	for _, timer := range r.awaiting {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	r.awaiting = make(map[string]*time.Timer)
+
+	r.setEngine(nil)
+	r.actions = nil
+	r.events = nil
+	r.nightDeaths = nil
+	r.nightHeld = nil
+	r.deferred = nil
+	r.abandoned = make(map[string]bool)
+	r.explosion = explosion{}
+	r.submissions = submissions{}
+	r.wolfVotes = wolfVotes{}
+	r.stateSync.reset()
+	r.activity.reset()
+	r.afk = afkTracker{}
+	r.pauses = pauseVote{}
+	r.lastPhase, r.lastRound, r.lastDied = "", 0, ""
+	r.phaseStarted = time.Time{}
+	r.winner = werewolf.CampNone
+
+	// 同样的角色重新洗牌，座位不变，每个人大概率拿到和上一局不同的身份
+	roles := append([]werewolf.RoleType(nil), r.Roles...)
+	rand.Shuffle(len(roles), func(i, j int) { roles[i], roles[j] = roles[j], roles[i] })
+	r.Roles = roles
+
+	r.transition(RoomStateWaiting)
+}
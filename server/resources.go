@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// RoomResources 房间占用的资源，供管理接口展示和房间回收排序
+type RoomResources struct {
+	Goroutines      int64 `json:"goroutines"`      // 房间启动且仍在运行的 goroutine（夜晚播报、机器人等）
+	Players         int   `json:"players"`         // 座位数（含机器人和离线占位）
+	ReplayActions   int   `json:"replayActions"`   // 用于重启重放的动作数
+	ReplayBytes     int   `json:"replayBytes"`     // 重放缓冲的编码后大小
+	DeferredActions int   `json:"deferredActions"` // 等待天亮执行的动作数
+	EventLogBytes   int   `json:"eventLogBytes"`   // 本局事件日志的编码后大小
+	OutboxBytes     int   `json:"outboxBytes"`     // 房间内玩家重发缓冲保留的消息字节数
+}
+
+// Footprint 估算的内存占用（字节），用于比较房间大小
+func (u RoomResources) Footprint() int {
+	return u.ReplayBytes + u.EventLogBytes + u.OutboxBytes
+}
+
+// spawn 启动属于房间的 goroutine 并计数
+func (r *Room) spawn(fn func()) {
+	r.goroutines.Add(1)
+	go func() {
+		defer r.goroutines.Add(-1)
+		fn()
+	}()
+}
+
+// Resources 统计房间当前的资源占用
+func (r *Room) Resources() RoomResources {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	usage := RoomResources{
+		Goroutines:      r.goroutines.Load(),
+		Players:         len(r.Players),
+		ReplayActions:   len(r.actions),
+		DeferredActions: len(r.deferred),
+	}
+
+	if data, err := json.Marshal(r.actions); err == nil {
+		usage.ReplayBytes = len(data)
+	}
+	if data, err := json.Marshal(r.events); err == nil {
+		usage.EventLogBytes = len(data)
+	}
+
+	for _, player := range r.Players {
+		usage.OutboxBytes += player.outbox.bytes()
+	}
+
+	return usage
+}
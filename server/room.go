@@ -1,35 +1,134 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Zereker/game/engine"
 	"github.com/Zereker/game/protocol"
 	"github.com/Zereker/werewolf"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// RoomState 房间状态
-type RoomState string
-
-const (
-	RoomStateWaiting  RoomState = "WAITING"
-	RoomStatePlaying  RoomState = "PLAYING"
-	RoomStateFinished RoomState = "FINISHED"
-)
+// ErrAlreadyStarted 房间已经开局，并发的开局请求中只有一个生效，其余得到这个错误
+var ErrAlreadyStarted = errors.New("game already started")
 
 // Room 游戏房间
 type Room struct {
 	ID      string
 	Name    string
 	Players map[string]*Player // playerID -> Player
-	Engine  *werewolf.Engine
 	State   RoomState
 	Roles   []werewolf.RoleType
+	OwnerID string // 房主，默认为第一个加入的玩家（创建者）
 	mu      sync.RWMutex
 	logger  *slog.Logger
+
+	eng atomic.Pointer[engine.GameEngine] // 当前对局的规则引擎，见 Engine
+
+	order   []string                   // 玩家加入顺序，也是加入引擎的顺序
+	actions []ActionRecord             // 已被引擎接受的动作，用于重启后重放
+	events  []protocol.GameEventRecord // 本局事件日志，见 gamelog.go
+
+	lastPhase   werewolf.PhaseType // 最近一次对外广播的阶段
+	lastRound   int
+	nightDeaths []string        // 当晚出局的玩家，天亮时按死讯公布方式播报，见 dawn.go
+	deferred    []ActionRecord  // 夜间提交、等到白天执行的动作
+	nightHeld   nightOutbox     // 夜里暂存的私有动作结果，见 nightresult.go
+	abandoned   map[string]bool // 对局中离开的玩家，保留座位视为出局
+	bots        map[string]*bot // 机器人玩家的策略状态
+
+	goroutines atomic.Int64                // 房间启动的仍在运行的 goroutine 数
+	position   atomic.Pointer[logPosition] // 日志中的回合和阶段，见 logging.go
+
+	activity  activityTracker // 白天正在输入的玩家
+	stateSync stateSync       // 最近广播的对局状态，用于增量更新，见 statesync.go
+	viewers   viewerHub       // 只读观看者，见 viewer.go
+
+	metrics      *Metrics
+	telemetry    *Telemetry
+	phaseStarted time.Time // 当前对外阶段的开始时间
+
+	accounts *AccountService // 记录注册玩家的战绩，模拟对局时为 nil
+
+	errorPolicy ErrorPolicy
+	alerter     Alerter
+	newEngine   engine.Factory // 开局时创建规则引擎，默认 werewolf 引擎
+
+	webhook        *WebhookClient
+	summaryWebhook string // 对局战报投递地址（房间覆盖优先于服务器默认）
+	replayBaseURL  string
+
+	password string // 加入密码，为空表示不设密码
+	private  bool   // 私密房间不出现在房间列表中
+	ranked   bool   // 排位房间只接受注册玩家，对局结束后调整积分，见 rating.go
+
+	anonymous bool         // 匿名座位：开局时随机排座，对局中只以座位号称呼，见 seating.go
+	aliases   *seatAliases // 匿名对局进行中发出的消息用来代替玩家ID的化名，见 seating.go
+
+	spectate   protocol.SpectateSettings // 观战设置：是否允许、人数上限、是否需要房主同意
+	spectators spectatorList             // 观战的玩家和等待房主同意的请求，见 spectate.go
+
+	unfilteredChat bool                 // 房主建房时关闭了不当发言过滤，见 profanity.go
+	violations     chatViolations       // 玩家在房间里不当发言的次数
+	outbound       []outboundMiddleware // 广播和私聊发出前的中间件，见 outbound.go
+
+	guardRules protocol.GuardRules
+	rules      protocol.GameRules
+	winner     werewolf.Camp // 对局结束后的胜方
+	sheriff    sheriffState  // 警长竞选和警徽，见 sheriff.go
+	speaking   speakingOrder // 白天的发言顺序
+	lastDied   string        // 最近出局的玩家，决定无警长时从谁开始发言
+
+	speakTimeout time.Duration  // 白天轮流发言时每人的时限
+	speed        string         // 对局节奏，各步时限按倍率缩放，见 speed.go
+	afkMisses    int            // 连续错过多少次限时行动视为挂机，0 表示不检测
+	afk          afkTracker     // 连续错过限时行动的玩家，见 afk.go
+	pauses       pauseVote      // 玩家发起的暂停和继续表态，见 pause.go
+	readyTimeout time.Duration  // 满员后给未准备玩家的时限，0 表示不限，见 readytimeout.go
+	ready        readyTimer     // 进行中的准备计时
+	startDelay   time.Duration  // 全员准备后到开局的倒计时
+	countdown    startCountdown // 进行中的开局倒计时，见 countdown.go
+	witch        witchFlow      // 女巫分步用药，见 witch.go
+	wolfVotes    wolfVotes      // 狼人当晚的刀口选择，见 wolfvote.go
+	submissions  submissions    // 当前阶段登记的选择，见 submissions.go
+	special      specialRoles   // 白痴、骑士和狼王，见 specialroles.go
+	explosion    explosion      // 狼人自爆打断的白天，见 selfdestruct.go
+
+	rematch map[string]bool // 对局结束后同意再来一局的真人玩家，见 rematch.go
+
+	// replaying 重启后向引擎重放快照动作期间为 true，重放产生的事件不再处理，见 persist.go
+	replaying atomic.Bool
+	// awaiting 服务器重启后还没重新连上的玩家 -> 放弃等待的计时器，不为空时对局暂停，见 persist.go
+	awaiting map[string]*time.Timer
+
+	rejections rejectionStats // 本局玩家被拒绝的动作
+	auditLog   auditLog       // 玩家提交的每个动作，见 audit.go
+	chat       chatHistory    // 最近的发言，举报时附上，见 moderation.go
+
+	tasks     chan func() // 房间事件队列，见 submit
+	closed    chan struct{}
+	loopOnce  sync.Once
+	closeOnce sync.Once
+}
+
+// ActionRecord 一次被引擎接受的玩家动作
+//
+// 警长加权计票的放逐投票例外：玩家的票只记在房间（Held），结算后房间替每人交给引擎的票记为 Settled，见 sheriff.go。
+type ActionRecord struct {
+	PlayerID   string                 `json:"playerID"`
+	ActionType werewolf.ActionType    `json:"actionType"`
+	TargetID   string                 `json:"targetID,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Round      int                    `json:"round,omitempty"` // 提交时的回合
+	Held       bool                   `json:"held,omitempty"`  // 只记在房间，没有交给引擎
+	Settled    bool                   `json:"settled,omitempty"`
 }
 
 // NewRoom 创建新房间
@@ -39,12 +138,51 @@ func NewRoom(name string, roles []werewolf.RoleType, logger *slog.Logger) *Room
 		Name:    name,
 		Players: make(map[string]*Player),
 		State:   RoomStateWaiting,
-		Roles:   roles,
-		logger:  logger,
+
+		abandoned: make(map[string]bool),
+		awaiting:  make(map[string]*time.Timer),
+		bots:      make(map[string]*bot),
+		Roles:     roles,
+		logger:    logger,
+
+		errorPolicy: ErrorPolicyPause,
+		alerter:     &logAlerter{logger: logger},
+		newEngine:   engine.NewWerewolf,
+
+		guardRules: protocol.DefaultGuardRules(),
+		rules:      protocol.DefaultGameRules(),
+
+		speakTimeout: DefaultConfig().SpeakTimeout,
+		speed:        protocol.SpeedNormal,
+		afkMisses:    DefaultConfig().AFKMisses,
+		startDelay:   DefaultConfig().StartCountdown,
+
+		tasks:  make(chan func(), roomQueueSize),
+		closed: make(chan struct{}),
 	}
+	room.logger = newRoomLogger(logger, room)
 	return room
 }
 
+// Engine 当前对局的规则引擎，没有开局时为 nil
+//
+// 盗贼换牌和引擎出错后的恢复会整体替换引擎，读取不需要持有锁。
+func (r *Room) Engine() engine.GameEngine {
+	if eng := r.eng.Load(); eng != nil {
+		return *eng
+	}
+	return nil
+}
+
+// setEngine 替换规则引擎，nil 表示没有进行中的对局
+func (r *Room) setEngine(eng engine.GameEngine) {
+	if eng == nil {
+		r.eng.Store(nil)
+		return
+	}
+	r.eng.Store(&eng)
+}
+
 // AddPlayer 添加玩家到房间
 func (r *Room) AddPlayer(player *Player) error {
 	r.mu.Lock()
@@ -54,17 +192,29 @@ func (r *Room) AddPlayer(player *Player) error {
 		return errors.New("room is not in waiting state")
 	}
 
-	if len(r.Players) >= len(r.Roles) {
+	if len(r.Players) >= r.seats() {
 		return errors.New("room is full")
 	}
 
+	if err := r.checkRanked(player); err != nil {
+		return err
+	}
+
+	if r.usernameTaken(player.Username, player.ID) {
+		return errUsernameTaken(player.Username)
+	}
+
 	r.Players[player.ID] = player
+	r.order = append(r.order, player.ID)
 	player.RoomID = r.ID
 
+	if r.OwnerID == "" {
+		r.OwnerID = player.ID
+	}
+
 	r.logger.Info("player joined room",
 		"playerID", player.ID,
-		"username", player.Username,
-		"roomID", r.ID)
+		"username", player.Username)
 
 	return nil
 }
@@ -72,13 +222,26 @@ func (r *Room) AddPlayer(player *Player) error {
 // RemovePlayer 从房间移除玩家
 func (r *Room) RemovePlayer(playerID string) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 
 	delete(r.Players, playerID)
+	delete(r.bots, playerID)
+	for i, id := range r.order {
+		if id == playerID {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
 
 	r.logger.Info("player left room",
-		"playerID", playerID,
-		"roomID", r.ID)
+		"playerID", playerID)
+
+	cancelled := r.stopCountdown("有玩家离开房间")
+	r.stopReadyTimer()
+	r.mu.Unlock()
+
+	if cancelled != nil {
+		r.BroadcastMessage(cancelled)
+	}
 }
 
 // SetPlayerReady 设置玩家准备状态
@@ -100,12 +263,9 @@ func (r *Room) SetPlayerReady(playerID string, isReady bool) error {
 	return nil
 }
 
-// CanStart 检查是否可以开始游戏
-func (r *Room) CanStart() bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	if len(r.Players) != len(r.Roles) {
+// canStart 座位坐满且所有人都已准备（需持有锁）
+func (r *Room) canStart() bool {
+	if len(r.Players) != r.seats() {
 		return false
 	}
 
@@ -118,45 +278,62 @@ func (r *Room) CanStart() bool {
 	return true
 }
 
-// Start 开始游戏
+// Result 返回对局是否已结束及胜方
+func (r *Room) Result() (winner werewolf.Camp, finished bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.winner, r.State == RoomStateFinished
+}
+
+// Start 不等准备直接开始游戏，用于模拟等不经过准备流程的场合；房间已开局时返回 ErrAlreadyStarted
 func (r *Room) Start() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.State != RoomStateWaiting {
-		return errors.New("room is not in waiting state")
+	if !r.State.lobby() {
+		return ErrAlreadyStarted
 	}
 
-	if len(r.Players) != len(r.Roles) {
-		return errors.Errorf("need %d players, got %d", len(r.Roles), len(r.Players))
+	if len(r.Players) != r.seats() {
+		return errors.Errorf("need %d players, got %d", r.seats(), len(r.Players))
 	}
 
-	// 创建游戏引擎
-	config := werewolf.Config{
-		Roles:           r.Roles,
-		EnableLastWords: false,
-	}
-
-	r.Engine = werewolf.NewEngine(config)
+	return r.begin()
+}
 
-	// 添加玩家到引擎
-	for playerID := range r.Players {
-		if err := r.Engine.AddPlayer(playerID); err != nil {
-			return errors.Wrap(err, "add player to engine")
+// begin 发牌并启动对局，开局只经过这里，调用方已确认房间还没开局（需持有锁）
+func (r *Room) begin() error {
+	if r.State == RoomStateWaiting {
+		if err := r.transition(RoomStateStarting); err != nil {
+			return err
 		}
 	}
 
-	// 订阅游戏事件
-	r.subscribeEvents()
+	// 直接开局（如模拟）时丢弃尚未结束的倒计时
+	if r.countdown.active() {
+		r.countdown.timer.Stop()
+		r.countdown.timer = nil
+		r.countdown.epoch++
+	}
 
-	// 启动游戏
-	if err := r.Engine.Start(); err != nil {
-		return errors.Wrap(err, "start engine")
+	if r.anonymous {
+		r.shuffleSeats()
 	}
 
-	r.State = RoomStatePlaying
+	inPlay, extras := dealExtras(r.Roles)
+	if err := r.startEngine(inPlay); err != nil {
+		r.transition(RoomStateWaiting)
+		return err
+	}
+	r.startThief(extras)
 
-	r.logger.Info("game started", "roomID", r.ID)
+	if err := r.transition(RoomStatePlaying); err != nil {
+		return err
+	}
+	r.telemetry.gameStarted(len(r.Players), r.telemetryFeatures())
+
+	r.logger.Info("game started")
 
 	// 通知所有玩家游戏开始（每个玩家看到自己的角色）
 	r.notifyGameStarted()
@@ -164,69 +341,399 @@ func (r *Room) Start() error {
 	return nil
 }
 
-// subscribeEvents 订阅游戏引擎事件
-func (r *Room) subscribeEvents() {
+// startEngine 按发给玩家的角色创建并启动游戏引擎，调用方需持有写锁
+func (r *Room) startEngine(roles []werewolf.RoleType) error {
+	eng, err := r.newGameEngine(engineRoles(roles))
+	if err != nil {
+		return err
+	}
+
+	r.setEngine(eng)
+	r.explosion = explosion{}
+	r.actions = nil
+	r.submissions = submissions{}
+	r.events = nil
+	r.nightDeaths = nil
+	r.nightHeld = nil
+	r.rejections.reset()
+	r.stateSync.reset()
+
+	// 启动游戏
+	if err := callEngine("start", r.Engine().Start); err != nil {
+		return errors.Wrap(err, "start engine")
+	}
+
+	r.assignSpecialRoles(roles)
+
+	return nil
+}
+
+// newGameEngine 按引擎角色创建引擎，按加入顺序加入玩家并订阅事件，还没有开始（需持有锁）
+func (r *Room) newGameEngine(roles []werewolf.RoleType) (engine.GameEngine, error) {
+	eng := r.newEngine(werewolf.Config{
+		Roles:           roles,
+		EnableLastWords: r.rules.LastWords,
+	})
+
+	// 按加入顺序添加玩家到引擎
+	for _, playerID := range r.order {
+		if err := callEngine("add player", func() error {
+			return eng.AddPlayer(playerID)
+		}); err != nil {
+			return nil, errors.Wrap(err, "add player to engine")
+		}
+	}
+
+	// 订阅游戏事件
+	r.subscribeEvents(eng)
+
+	return eng, nil
+}
+
+// PerformAction 执行玩家动作，引擎内部错误按房间的错误策略处理
+func (r *Room) PerformAction(playerID string, actionType werewolf.ActionType, targetID string, data map[string]interface{}) error {
+	return r.PerformActionContext(context.Background(), playerID, actionType, targetID, data)
+}
+
+// PerformActionContext 同 PerformAction，引擎调用的 span 挂在 ctx 的链路下
+func (r *Room) PerformActionContext(ctx context.Context, playerID string, actionType werewolf.ActionType, targetID string, data map[string]interface{}) error {
+	r.mu.RLock()
+	state := r.State
+	r.mu.RUnlock()
+
+	if state == RoomStatePaused {
+		return rejected(RejectWrongPhase, errors.New("game is paused"))
+	}
+
+	if state.ended() {
+		return rejected(RejectWrongPhase, errors.New("game has ended"))
+	}
+
+	if r.isAbandoned(playerID) {
+		return errors.New("player has left the game")
+	}
+
+	if r.isSlain(playerID) {
+		return rejected(RejectOther, errors.New("player is out of the game"))
+	}
+
+	if targetID != "" && r.isSlain(targetID) {
+		return rejected(RejectInvalidTarget, errors.New("target is out of the game"))
+	}
+
+	// 自己动手了就不再算挂机
+	r.markActive(playerID)
+
+	if actionType != protocol.ActionSteal && r.thiefPending() {
+		return rejected(RejectWrongPhase, errors.New("waiting for the thief to take a card"))
+	}
+
+	round := r.Engine().GetState().Round
+	choice := targetID
+
+	// 自爆之后当天的发言、决斗和投票都已结束
+	if r.dayCut(round) && (actionType == protocol.ActionSpeak || actionType == protocol.ActionVote || actionType == protocol.ActionDuel) {
+		return rejected(RejectWrongPhase, errors.New("the day ended with a self-destruct"))
+	}
+
+	switch actionType {
+	case protocol.ActionDuel:
+		return r.knightDuel(playerID, targetID)
+	case protocol.ActionLink:
+		return r.linkLovers(playerID, targetID, secondTarget(data))
+	case protocol.ActionSteal:
+		return r.steal(playerID, thiefCard(data))
+	case protocol.ActionSelfDestruct:
+		return r.selfDestruct(playerID)
+	case protocol.ActionKill:
+		targetID = r.wolfKillTarget(playerID, choice, round)
+	case protocol.ActionProtect:
+		if err := r.checkGuardRules(playerID, targetID, round); err != nil {
+			return rejected(RejectRule, err)
+		}
+	case protocol.ActionAntidote:
+		if err := r.checkWitchRules(playerID, round); err != nil {
+			return rejected(RejectRule, err)
+		}
+	case protocol.ActionSpeak:
+		if err := r.checkSpeakingTurn(playerID); err != nil {
+			return rejected(RejectRule, err)
+		}
+	}
+
+	op := func() (err error) {
+		_, span := r.startRoomSpan(ctx, "Engine.PerformAction",
+			attribute.String("player.id", playerID),
+			attribute.String("action.type", string(actionType)),
+			attribute.Int("round", round))
+		defer func() { endSpan(span, err) }()
+
+		return r.Engine().PerformAction(playerID, actionType, targetID, data)
+	}
+
+	// 警长加权计票时放逐投票先只记在房间，全部投完后统一交给引擎
+	held := actionType == protocol.ActionVote && r.holdsVotes()
+	if held {
+		op = func() error { return r.checkHeldVote(playerID, targetID, round) }
+	}
+
+	err := callEngine("perform action", op)
+
+	var engineErr *EngineError
+	if errors.As(err, &engineErr) {
+		err = r.handleEngineError(engineErr)
+	}
+
+	if err == nil {
+		r.mu.Lock()
+		r.actions = append(r.actions, ActionRecord{
+			PlayerID:   playerID,
+			ActionType: actionType,
+			TargetID:   targetID,
+			Data:       data,
+			Round:      round,
+			Held:       held,
+		})
+		r.mu.Unlock()
+
+		if actionType == protocol.ActionSpeak {
+			r.broadcastSpeech(playerID, data)
+			r.advanceSpeaker(playerID)
+		}
+		if actionType == protocol.ActionKill {
+			r.recordWolfVote(playerID, choice, round)
+			r.followPack(targetID, round)
+		}
+		if actionType == protocol.ActionVote {
+			r.announceVote(playerID, choice, round)
+		}
+		if held {
+			r.settleHeldVotes(round)
+		}
+		r.registerSubmission(playerID, actionType, choice, round)
+	}
+
+	return err
+}
+
+// subscribeEvents 订阅游戏引擎事件，只处理房间当前使用的引擎发出的事件（盗贼换牌会替换引擎）；
+// 重启后重放快照时引擎重新发出的事件已经处理过，不再处理
+func (r *Room) subscribeEvents(eng engine.GameEngine) {
+	current := func() bool { return r.Engine() == eng && !r.replaying.Load() }
+
 	// 阶段变化
-	r.Engine.Subscribe(werewolf.EventPhaseStarted, func(e werewolf.Event) {
-		r.handlePhaseStarted(e)
+	eng.Subscribe(werewolf.EventPhaseStarted, func(e werewolf.Event) {
+		if current() {
+			r.handlePhaseStarted(e)
+		}
 	})
 
 	// 玩家死亡
-	r.Engine.Subscribe(werewolf.EventPlayerDied, func(e werewolf.Event) {
-		r.handlePlayerDied(e)
+	eng.Subscribe(werewolf.EventPlayerDied, func(e werewolf.Event) {
+		if current() {
+			r.handlePlayerDied(e)
+		}
 	})
 
 	// 游戏结束
-	r.Engine.Subscribe(werewolf.EventGameEnded, func(e werewolf.Event) {
-		r.handleGameEnded(e)
+	eng.Subscribe(werewolf.EventGameEnded, func(e werewolf.Event) {
+		if current() {
+			r.handleGameEnded(e)
+		}
 	})
 }
 
 // handlePhaseStarted 处理阶段开始事件
 func (r *Room) handlePhaseStarted(e werewolf.Event) {
 	data := e.Data.(map[string]interface{})
-	phase := data["phase"].(werewolf.PhaseType)
+	phase := publicPhase(data["phase"].(werewolf.PhaseType))
 
-	state := r.Engine.GetState()
+	state := r.Engine().GetState()
 
-	// 广播阶段变化
-	msg, _ := protocol.NewMessage(protocol.MsgPhaseChanged, protocol.PhaseChangedData{
-		Phase: phase,
-		Round: state.Round,
-	})
+	_, span := r.startRoomSpan(context.Background(), "Room.PhaseStarted",
+		attribute.String("phase", string(phase)),
+		attribute.Int("round", state.Round))
+	defer span.End()
 
-	r.BroadcastMessage(msg)
+	// 夜晚子阶段之间切换时不重复广播，避免泄露谁在行动
+	r.mu.Lock()
+	entered := phase != r.lastPhase || state.Round != r.lastRound
+	previous, previousRound, previousStarted := r.lastPhase, r.lastRound, r.phaseStarted
+	r.lastPhase, r.lastRound = phase, state.Round
+	if entered {
+		r.phaseStarted = time.Now()
+	}
+	r.mu.Unlock()
+	r.setLogPosition(state.Round, phase)
+	span.SetAttributes(attribute.Bool("entered", entered))
+
+	if entered && !previousStarted.IsZero() {
+		r.metrics.observePhase(previous, time.Since(previousStarted))
+	}
+
+	if entered {
+		// 广播阶段变化
+		msg, _ := protocol.NewMessage(protocol.MsgPhaseChanged, protocol.PhaseChangedData{
+			Phase: phase,
+			Round: state.Round,
+		})
+
+		r.BroadcastMessage(msg)
+		r.logEvent(werewolf.EventPhaseStarted, fmt.Sprintf("第%d轮 %s", state.Round, phase), map[string]interface{}{"phase": phase, "round": state.Round})
+
+		r.activity.reset()
+
+		if previous == werewolf.PhaseVote && !r.dayCut(previousRound) {
+			r.revealVotes(previousRound)
+			r.announceVoteTally(previousRound)
+		}
+		if phase != werewolf.PhaseDay {
+			r.stopSpeakingOrder()
+		}
+		if phase != werewolf.PhaseNight {
+			r.stopWitchFlow()
+		}
+
+		switch phase {
+		case werewolf.PhaseNight:
+			r.spawn(func() { r.announceNight(state.Round) })
+		case werewolf.PhaseDay:
+			if previous == werewolf.PhaseNight {
+				r.announceDawn(previousRound)
+				r.sendNightResults(previousRound)
+			}
+			r.startDay()
+		case werewolf.PhaseVote:
+			r.dropHeldSpeeches()
+			if r.dayCut(state.Round) {
+				r.spawn(func() { r.skipExileVote(state.Round) })
+			} else {
+				r.startVoteRound()
+			}
+		}
+	}
 
 	// 发送游戏状态
 	r.SendGameState()
+
+	// 推送每个玩家在新阶段的可用技能
+	r.pushAllowedSkills()
+
+	// 机器人按各自策略行动；警长竞选和按顺序发言时由房间安排机器人发言
+	if !r.dayHeld() && !r.thiefPending() {
+		r.driveBots(data["phase"].(werewolf.PhaseType), state.Round)
+	}
+
+	// 挂机和已离开的玩家由房间替他跳过
+	if entered {
+		r.spawn(func() { r.passForAbsent(phase, state.Round) })
+	}
 }
 
 // handlePlayerDied 处理玩家死亡事件
 func (r *Room) handlePlayerDied(e werewolf.Event) {
 	data := e.Data.(map[string]interface{})
 	playerID := data["playerID"].(string)
-	reason := data["reason"].(string)
 
-	msg, _ := protocol.NewMessage(protocol.MsgGameEvent, protocol.GameEventData{
+	if r.revealIdiot(playerID) {
+		return
+	}
+
+	// 引擎补上房间已经结算过的出局（如自爆的狼人被代投放逐），不再重复公布
+	if r.isSlain(playerID) {
+		return
+	}
+
+	r.mu.Lock()
+	night, round := r.lastPhase == werewolf.PhaseNight, r.lastRound
+	if night {
+		r.nightDeaths = append(r.nightDeaths, playerID)
+	}
+	r.mu.Unlock()
+
+	policy := protocol.RevealCause
+	if night {
+		policy = r.revealPolicy(round)
+	}
+
+	// 不公布死讯时也不从出局玩家旁边开始发言，免得发言顺序透露谁出局
+	if policy != protocol.RevealNone {
+		r.mu.Lock()
+		r.lastDied = playerID
+		r.mu.Unlock()
+	}
+
+	eventData := protocol.GameEventData{
 		EventType: werewolf.EventPlayerDied,
-		Message:   fmt.Sprintf("玩家 %s 死亡: %s", playerID, reason),
+		Event:     protocol.EventKeyPlayerDied,
+		Params:    map[string]string{"victim": playerID, "cause": r.deathCause(playerID)},
 		Data:      data,
-	})
+	}
+	r.logEvent(eventData.EventType, eventText(eventData), eventData.Data)
+	if policy == protocol.RevealDeathOnly {
+		eventData.Params = map[string]string{"victim": playerID}
+		eventData.Data = map[string]interface{}{"playerID": playerID}
+	}
 
-	r.BroadcastMessage(msg)
+	// 不公布死讯时只通过 GAME_STATE 的存活名单体现
+	if policy != protocol.RevealNone {
+		msg, _ := protocol.NewMessage(protocol.MsgGameEvent, eventData)
+		r.BroadcastMessage(msg)
+	}
+
+	r.sheriffDied(playerID)
+	r.startWolfKingShot(playerID)
+	r.loverSuicide(playerID)
+}
+
+// deathCause 引擎结算的出局原因：投票阶段出局是放逐，夜里出局是被毒或被刀
+func (r *Room) deathCause(playerID string) string {
+	if r.poisoned(playerID) {
+		return protocol.CausePoison
+	}
+
+	r.mu.RLock()
+	phase := r.lastPhase
+	r.mu.RUnlock()
+
+	switch phase {
+	case werewolf.PhaseVote:
+		return protocol.CauseExile
+	case werewolf.PhaseNight:
+		return protocol.CauseWolfKill
+	default:
+		return protocol.CauseOther
+	}
 }
 
 // handleGameEnded 处理游戏结束事件
 func (r *Room) handleGameEnded(e werewolf.Event) {
 	r.mu.Lock()
-	r.State = RoomStateFinished
+	if r.State.ended() {
+		// 已因玩家离开由房间判定结束
+		r.mu.Unlock()
+		return
+	}
+	r.transition(RoomStateFinished)
 	r.mu.Unlock()
 
+	// 放逐出局直接决出胜负时，不再有阶段切换来公布这一轮的投票
+	r.revealFinalVotes()
+
 	data := e.Data.(map[string]interface{})
 	winner := data["winner"].(werewolf.Camp)
 
-	state := r.Engine.GetState()
-	players := r.convertPlayersInfo(state.Players, true)
+	// 引擎不知道恋人，只剩分属两个阵营的恋人时改判恋人获胜
+	if r.loversWin() {
+		winner = protocol.CampLovers
+	}
+
+	r.mu.Lock()
+	r.winner = winner
+	r.mu.Unlock()
+
+	state := r.Engine().GetState()
+	players := r.playersInfo(state.Players, true)
 
 	msg, _ := protocol.NewMessage(protocol.MsgGameEnded, protocol.GameEndedData{
 		Winner:  winner,
@@ -234,93 +741,217 @@ func (r *Room) handleGameEnded(e werewolf.Event) {
 	})
 
 	r.BroadcastMessage(msg)
+	r.logEvent(werewolf.EventGameEnded, fmt.Sprintf("游戏结束，获胜阵营: %s", winner), data)
+	ratings := r.recordStats(winner, players)
+	r.broadcastGameSummary(winner, "", players, ratings)
+	r.sendSummary(winner, "", players)
 
-	r.logger.Info("game ended", "roomID", r.ID, "winner", winner)
+	r.logger.Info("game ended", "winner", winner)
 }
 
 // notifyGameStarted 通知所有玩家游戏开始
 func (r *Room) notifyGameStarted() {
-	state := r.Engine.GetState()
-
 	for playerID, player := range r.Players {
-		// 找到该玩家的角色
-		var roleType werewolf.RoleType
-		var camp werewolf.Camp
+		player.SendMessage(r.gameStartedMessage(playerID))
+	}
+}
 
+// gameStartedMessage 生成游戏开始消息（包含该玩家的角色信息）（需持有锁）
+func (r *Room) gameStartedMessage(playerID string) *protocol.Message {
+	state := r.Engine().GetState()
+	info := r.roleInfo(playerID, state)
+
+	rules := r.rules
+
+	// 还没选牌的盗贼看到两张底牌
+	var extras []werewolf.RoleType
+	if info.RoleType == protocol.RoleTypeThief {
+		extras = r.special.extras
+	}
+
+	players := r.convertPlayersInfo(state.Players, false)
+	msg, _ := protocol.NewMessage(protocol.MsgGameStarted, protocol.GameStartedData{
+		RoleType:   info.RoleType,
+		Camp:       info.Camp,
+		Players:    players,
+		Teammates:  info.Teammates,
+		GuardRules: info.GuardRules,
+		Rules:      &rules,
+		ExtraRoles: extras,
+	})
+
+	return msg
+}
+
+// roleInfo 玩家的角色、阵营和只有该角色能看到的信息（需持有锁）
+func (r *Room) roleInfo(playerID string, state *werewolf.GameState) protocol.RoleInfoData {
+	var info protocol.RoleInfoData
+
+	// 找到该玩家的角色
+	var engineRole werewolf.RoleType
+	for _, ps := range state.Players {
+		if ps.ID == playerID {
+			engineRole, info.RoleType = ps.Role, r.roleOf(ps)
+			info.Camp = roleCamp(info.RoleType)
+			break
+		}
+	}
+
+	// 狼人（包括狼王）互相知道同伴
+	if engineRole == werewolf.RoleTypeWerewolf {
 		for _, ps := range state.Players {
-			if ps.ID == playerID {
-				roleType = ps.Role
-				// 根据角色类型判断阵营
-				switch roleType {
-				case werewolf.RoleTypeWerewolf:
-					camp = werewolf.CampEvil
-				case werewolf.RoleTypeSeer, werewolf.RoleTypeWitch, werewolf.RoleTypeGuard,
-					werewolf.RoleTypeHunter, werewolf.RoleTypeVillager:
-					camp = werewolf.CampGood
-				default:
-					camp = werewolf.CampNone
-				}
-				break
+			if ps.Role == werewolf.RoleTypeWerewolf && ps.ID != playerID {
+				info.Teammates = append(info.Teammates, ps.ID)
 			}
 		}
+	}
 
-		// 发送游戏开始消息（包含该玩家的角色信息）
-		players := r.convertPlayersInfo(state.Players, false)
-		msg, _ := protocol.NewMessage(protocol.MsgGameStarted, protocol.GameStartedData{
-			RoleType: roleType,
-			Camp:     camp,
-			Players:  players,
-		})
-
-		player.SendMessageDirect(msg)
+	// 守卫需要知道本局的守护规则
+	if info.RoleType == werewolf.RoleTypeGuard {
+		guard := r.guardRules
+		info.GuardRules = &guard
 	}
+
+	return info
 }
 
-// SendGameState 发送游戏状态给所有玩家
-func (r *Room) SendGameState() {
-	state := r.Engine.GetState()
-	players := r.convertPlayersInfo(state.Players, false)
+// roleCamp 根据角色类型判断阵营
+func roleCamp(roleType werewolf.RoleType) werewolf.Camp {
+	switch roleType {
+	case werewolf.RoleTypeWerewolf, protocol.RoleTypeWolfKing:
+		return werewolf.CampEvil
+	case werewolf.RoleTypeSeer, werewolf.RoleTypeWitch, werewolf.RoleTypeGuard,
+		werewolf.RoleTypeHunter, werewolf.RoleTypeVillager, protocol.RoleTypeIdiot, protocol.RoleTypeKnight,
+		protocol.RoleTypeCupid, protocol.RoleTypeThief:
+		return werewolf.CampGood
+	default:
+		return werewolf.CampNone
+	}
+}
 
-	msg, _ := protocol.NewMessage(protocol.MsgGameState, protocol.GameStateData{
-		Phase:        state.Phase,
-		Round:        state.Round,
-		Players:      players,
-		AlivePlayers: state.AlivePlayers,
-		IsEnded:      state.IsEnded,
+// resumePlayer 玩家重新登录后补发房间和游戏状态
+func (r *Room) resumePlayer(player *Player) {
+	joinedMsg, _ := protocol.NewMessage(protocol.MsgRoomJoined, protocol.RoomJoinedData{
+		RoomID:  r.ID,
+		OwnerID: r.Owner(),
+		Players: r.GetPlayerList(),
 	})
+	player.SendMessage(joinedMsg)
 
-	r.BroadcastMessage(msg)
+	r.mu.RLock()
+	player.SendMessage(r.roomStateMessage(""))
+	r.mu.RUnlock()
+
+	if r.Engine() == nil {
+		return
+	}
+
+	r.mu.RLock()
+	startedMsg := r.gameStartedMessage(player.ID)
+	r.mu.RUnlock()
+	player.SendMessage(startedMsg)
+
+	// 优先补发其他玩家手里的那一版状态，之后的增量才能接上
+	if !r.sendFullState(player, nil) {
+		state := r.Engine().GetState()
+		r.mu.RLock()
+		view := r.playerView(player.ID, state)
+		r.mu.RUnlock()
+
+		stateMsg, _ := protocol.NewMessage(protocol.MsgGameState, withView(protocol.GameStateData{
+			Phase:        publicPhase(state.Phase),
+			Round:        state.Round,
+			Players:      r.playersInfo(state.Players, false),
+			AlivePlayers: r.activePlayers(state.AlivePlayers),
+			IsEnded:      state.IsEnded,
+		}, view))
+		player.SendMessage(stateMsg)
+	}
+
+	skillsMsg, _ := protocol.NewMessage(protocol.MsgAllowedSkills, r.AllowedSkills(player.ID))
+	player.SendMessage(skillsMsg)
 }
 
 // BroadcastMessage 广播消息给房间内所有玩家
 func (r *Room) BroadcastMessage(msg *protocol.Message) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	defer r.metrics.observeBroadcast(time.Now())
+
+	_, span := r.startRoomSpan(context.Background(), "Room.Broadcast",
+		attribute.String("message.type", string(msg.Type)))
+	defer span.End()
 
+	// 只在复制玩家列表时持锁，入队不阻塞，慢客户端不影响其他玩家
+	r.mu.RLock()
+	players := make([]*Player, 0, len(r.Players))
 	for _, player := range r.Players {
-		player.SendMessageDirect(msg)
+		players = append(players, player)
+	}
+	r.mu.RUnlock()
+
+	// 被出站中间件拦下的消息不广播
+	if msg = r.filterOutbound(msg); msg == nil {
+		return
+	}
+
+	// 发言不转发给屏蔽了发言人的玩家
+	speaker := r.recordChat(msg)
+
+	span.SetAttributes(attribute.Int("recipients", len(players)))
+	for _, player := range players {
+		if speaker != "" && player.muted.has(speaker) {
+			continue
+		}
+		player.SendMessage(msg)
 	}
+
+	r.viewers.publish(msg)
 }
 
-// convertPlayersInfo 转换玩家信息（控制是否包含角色信息）
+// playersInfo 加锁转换玩家信息，用于不持锁时生成玩家列表
+func (r *Room) playersInfo(players []werewolf.PlayerState, includeRole bool) []protocol.PlayerInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.convertPlayersInfo(players, includeRole)
+}
+
+// convertPlayersInfo 转换玩家信息（控制是否包含角色信息），按座位顺序排列（需持有锁）
 func (r *Room) convertPlayersInfo(players []werewolf.PlayerState, includeRole bool) []protocol.PlayerInfo {
+	states := make(map[string]werewolf.PlayerState, len(players))
+	for _, ps := range players {
+		states[ps.ID] = ps
+	}
+
 	result := make([]protocol.PlayerInfo, 0, len(players))
 
-	for _, ps := range players {
-		player, exists := r.Players[ps.ID]
-		if !exists {
+	for i, playerID := range r.order {
+		ps, inGame := states[playerID]
+		player, exists := r.Players[playerID]
+		if !inGame || !exists {
 			continue
 		}
 
 		info := protocol.PlayerInfo{
 			ID:       ps.ID,
+			Seat:     i + 1,
 			Username: player.Username,
-			IsAlive:  ps.IsAlive,
+			IsAlive:  (ps.IsAlive || r.special.revealed[ps.ID]) && !r.abandoned[ps.ID] && !r.special.slain[ps.ID],
 			IsReady:  player.IsReady,
+			IsBot:    player.IsBot,
+			IsOwner:  ps.ID == r.OwnerID,
+
+			IsSheriff:   r.isSheriff(ps.ID),
+			IsConnected: player.IsBot || player.connected(),
+			IsAFK:       r.afk.flagged[ps.ID],
+			Profile:     player.publicProfile(),
+		}
+		if r.namesHidden() {
+			info = anonymize(info)
 		}
 
-		if includeRole {
-			info.RoleType = ps.Role
+		// 翻牌的白痴身份公开
+		if includeRole || r.special.revealed[ps.ID] {
+			info.RoleType = r.roleOf(ps)
 		}
 
 		result = append(result, info)
@@ -329,20 +960,36 @@ func (r *Room) convertPlayersInfo(players []werewolf.PlayerState, includeRole bo
 	return result
 }
 
-// GetPlayerList 获取房间内玩家列表
+// GetPlayerList 获取房间内玩家列表，按座位顺序排列
 func (r *Room) GetPlayerList() []protocol.PlayerInfo {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	result := make([]protocol.PlayerInfo, 0, len(r.Players))
-	for _, player := range r.Players {
-		result = append(result, protocol.PlayerInfo{
-			ID:       player.ID,
-			Username: player.Username,
-			IsReady:  player.IsReady,
-			IsAlive:  true,
-		})
+	for i, playerID := range r.order {
+		info := lobbyPlayerInfo(i+1, r.Players[playerID], playerID == r.OwnerID)
+		if r.namesHidden() {
+			info = anonymize(info)
+		}
+		result = append(result, info)
 	}
 
 	return result
 }
+
+// lobbyPlayerInfo 等待阶段展示的玩家信息
+func lobbyPlayerInfo(seat int, player *Player, isOwner bool) protocol.PlayerInfo {
+	return protocol.PlayerInfo{
+		ID:       player.ID,
+		Seat:     seat,
+		Username: player.Username,
+		IsReady:  player.IsReady,
+		IsAlive:  true,
+		IsBot:    player.IsBot,
+		IsOwner:  isOwner,
+		Rating:   player.Rating,
+
+		IsConnected: player.IsBot || player.connected(),
+		Profile:     player.publicProfile(),
+	}
+}
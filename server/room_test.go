@@ -0,0 +1,433 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// fiveSeats 一狼、预言家、守卫和两名村民，模拟引擎按座位顺序发牌
+var fiveSeats = []werewolf.RoleType{
+	werewolf.RoleTypeWerewolf,
+	werewolf.RoleTypeSeer,
+	werewolf.RoleTypeGuard,
+	werewolf.RoleTypeVillager,
+	werewolf.RoleTypeVillager,
+}
+
+func TestJoinRoom(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string // 建房时设置的密码
+		join     func(tt *testTable, room *Room) protocol.JoinRoomData
+		joiner   int // 加入的玩家下标，此前的玩家都已在房间里
+		wantErr  bool
+	}{
+		{
+			name:   "open seat",
+			join:   func(tt *testTable, room *Room) protocol.JoinRoomData { return protocol.JoinRoomData{RoomID: room.ID} },
+			joiner: 1,
+		},
+		{
+			name: "unknown room",
+			join: func(tt *testTable, room *Room) protocol.JoinRoomData {
+				return protocol.JoinRoomData{RoomID: "no-such-room"}
+			},
+			joiner:  1,
+			wantErr: true,
+		},
+		{
+			name:    "room is full",
+			join:    func(tt *testTable, room *Room) protocol.JoinRoomData { return protocol.JoinRoomData{RoomID: room.ID} },
+			joiner:  len(fiveSeats),
+			wantErr: true,
+		},
+		{
+			name:     "wrong password",
+			password: "secret",
+			join: func(tt *testTable, room *Room) protocol.JoinRoomData {
+				return protocol.JoinRoomData{RoomID: room.ID, Password: "guess"}
+			},
+			joiner:  1,
+			wantErr: true,
+		},
+		{
+			name:     "right password",
+			password: "secret",
+			join: func(tt *testTable, room *Room) protocol.JoinRoomData {
+				return protocol.JoinRoomData{RoomID: room.ID, Password: "secret"}
+			},
+			joiner: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats)+1)
+			owner := tt.players[0]
+			tt.must(owner, protocol.MsgCreateRoom, protocol.CreateRoomData{RoomName: "测试房间", Roles: fiveSeats, Password: tc.password})
+			room := tt.server.GetRoom(owner.RoomID)
+			for _, player := range tt.players[1:tc.joiner] {
+				tt.must(player, protocol.MsgJoinRoom, protocol.JoinRoomData{RoomID: room.ID, Password: tc.password})
+			}
+
+			joiner := tt.players[tc.joiner]
+			err := tt.send(joiner, protocol.MsgJoinRoom, tc.join(tt, room))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("join error = %v, want error %v", err, tc.wantErr)
+			}
+
+			room.mu.RLock()
+			_, seated := room.Players[joiner.ID]
+			room.mu.RUnlock()
+			if seated == tc.wantErr {
+				t.Errorf("seated = %v, want %v", seated, !tc.wantErr)
+			}
+			if !tc.wantErr {
+				tt.waitFor(joiner, protocol.MsgRoomJoined)
+				tt.waitFor(owner, protocol.MsgPlayerJoined)
+			}
+		})
+	}
+}
+
+func TestStartGame(t *testing.T) {
+	tests := []struct {
+		name      string
+		ready     int // 准备的玩家数
+		wantState RoomState
+	}{
+		{name: "everyone ready", ready: len(fiveSeats), wantState: RoomStatePlaying},
+		{name: "one player not ready", ready: len(fiveSeats) - 1, wantState: RoomStateWaiting},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			room := tt.seat(fiveSeats...)
+			for _, player := range tt.players[:tc.ready] {
+				tt.must(player, protocol.MsgReady, protocol.ReadyData{})
+			}
+
+			tt.eventually(func() bool {
+				room.mu.RLock()
+				defer room.mu.RUnlock()
+				return room.State == tc.wantState
+			}, "room state %s", tc.wantState)
+
+			if tc.wantState != RoomStatePlaying {
+				if len(tt.received(tt.players[0], protocol.MsgGameStarted)) > 0 {
+					t.Error("game started without everyone ready")
+				}
+				return
+			}
+
+			tt.waitPhase(room, werewolf.PhaseNight, 1)
+			for _, ps := range room.Engine().GetState().Players {
+				var started protocol.GameStartedData
+				tt.waitFor(tt.server.GetPlayer(ps.ID), protocol.MsgGameStarted).UnmarshalData(&started)
+				if started.RoleType != ps.Role {
+					t.Errorf("player %s told role %s, engine has %s", ps.ID, started.RoleType, ps.Role)
+				}
+			}
+		})
+	}
+}
+
+func TestNightActions(t *testing.T) {
+	type step struct {
+		actor  werewolf.RoleType
+		action werewolf.ActionType
+		target werewolf.RoleType
+		wantOK bool
+	}
+
+	tests := []struct {
+		name     string
+		steps    []step
+		wantDead []werewolf.RoleType
+	}{
+		{
+			name: "wolf kills an unprotected villager",
+			steps: []step{
+				{werewolf.RoleTypeSeer, protocol.ActionCheck, werewolf.RoleTypeWerewolf, true},
+				{werewolf.RoleTypeGuard, protocol.ActionProtect, werewolf.RoleTypeSeer, true},
+				{werewolf.RoleTypeWerewolf, protocol.ActionKill, werewolf.RoleTypeVillager, true},
+			},
+			wantDead: []werewolf.RoleType{werewolf.RoleTypeVillager},
+		},
+		{
+			name: "guard saves the wolves' target",
+			steps: []step{
+				{werewolf.RoleTypeSeer, protocol.ActionCheck, werewolf.RoleTypeVillager, true},
+				{werewolf.RoleTypeGuard, protocol.ActionProtect, werewolf.RoleTypeVillager, true},
+				{werewolf.RoleTypeWerewolf, protocol.ActionKill, werewolf.RoleTypeVillager, true},
+			},
+		},
+		{
+			name: "villager cannot kill",
+			steps: []step{
+				{werewolf.RoleTypeVillager, protocol.ActionKill, werewolf.RoleTypeSeer, false},
+				{werewolf.RoleTypeSeer, protocol.ActionCheck, werewolf.RoleTypeWerewolf, true},
+				{werewolf.RoleTypeGuard, protocol.ActionProtect, werewolf.RoleTypeGuard, true},
+				{werewolf.RoleTypeWerewolf, protocol.ActionKill, werewolf.RoleTypeSeer, true},
+			},
+			wantDead: []werewolf.RoleType{werewolf.RoleTypeSeer},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			room := tt.start(fiveSeats...)
+
+			for _, s := range tc.steps {
+				result := tt.act(room, tt.role(room, s.actor, 0), s.action, tt.role(room, s.target, 0))
+				if result.Success != s.wantOK {
+					t.Fatalf("%s %s %s: success = %v (%s), want %v", s.actor, s.action, s.target, result.Success, result.Message, s.wantOK)
+				}
+			}
+
+			tt.waitPhase(room, werewolf.PhaseDay, 1)
+
+			dead := make(map[werewolf.RoleType]bool)
+			for _, ps := range room.Engine().GetState().Players {
+				if !ps.IsAlive {
+					dead[ps.Role] = true
+				}
+			}
+			if len(dead) != len(tc.wantDead) {
+				t.Errorf("dead roles = %v, want %v", dead, tc.wantDead)
+			}
+			for _, role := range tc.wantDead {
+				if !dead[role] {
+					t.Errorf("%s survived the night", role)
+				}
+			}
+		})
+	}
+}
+
+func TestSpeakAndVote(t *testing.T) {
+	tests := []struct {
+		name       string
+		votes      map[werewolf.RoleType]werewolf.RoleType // 投票人 -> 目标，两名村民中只剩第二名
+		wantWinner werewolf.Camp                           // 为空表示对局继续
+	}{
+		{
+			name: "majority exiles the wolf",
+			votes: map[werewolf.RoleType]werewolf.RoleType{
+				werewolf.RoleTypeWerewolf: werewolf.RoleTypeSeer,
+				werewolf.RoleTypeSeer:     werewolf.RoleTypeWerewolf,
+				werewolf.RoleTypeGuard:    werewolf.RoleTypeWerewolf,
+				werewolf.RoleTypeVillager: werewolf.RoleTypeWerewolf,
+			},
+			wantWinner: werewolf.CampGood,
+		},
+		{
+			name: "tie exiles nobody",
+			votes: map[werewolf.RoleType]werewolf.RoleType{
+				werewolf.RoleTypeWerewolf: werewolf.RoleTypeSeer,
+				werewolf.RoleTypeSeer:     werewolf.RoleTypeWerewolf,
+				werewolf.RoleTypeGuard:    werewolf.RoleTypeWerewolf,
+				werewolf.RoleTypeVillager: werewolf.RoleTypeSeer,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			room := tt.start(fiveSeats...)
+
+			tt.act(room, tt.role(room, werewolf.RoleTypeSeer, 0), protocol.ActionCheck, tt.role(room, werewolf.RoleTypeWerewolf, 0))
+			tt.act(room, tt.role(room, werewolf.RoleTypeGuard, 0), protocol.ActionProtect, tt.role(room, werewolf.RoleTypeSeer, 0))
+			tt.act(room, tt.role(room, werewolf.RoleTypeWerewolf, 0), protocol.ActionKill, tt.role(room, werewolf.RoleTypeVillager, 0))
+			tt.waitPhase(room, werewolf.PhaseDay, 1)
+
+			tt.speakAll(room)
+			tt.waitPhase(room, werewolf.PhaseVote, 1)
+			if len(tt.received(tt.players[0], protocol.MsgSpeech)) == 0 {
+				t.Error("speeches were not broadcast")
+			}
+
+			for voter, target := range tc.votes {
+				n := 0
+				if voter == werewolf.RoleTypeVillager {
+					n = 1
+				}
+				if result := tt.act(room, tt.role(room, voter, n), protocol.ActionVote, tt.role(room, target, 0)); !result.Success {
+					t.Fatalf("%s vote rejected: %s", voter, result.Message)
+				}
+			}
+
+			if tc.wantWinner == "" {
+				tt.waitPhase(room, werewolf.PhaseNight, 2)
+				if alive := len(room.Engine().GetState().AlivePlayers); alive != 4 {
+					t.Errorf("alive players = %d after a tie, want 4", alive)
+				}
+				return
+			}
+
+			var ended protocol.GameEndedData
+			tt.waitFor(tt.players[0], protocol.MsgGameEnded).UnmarshalData(&ended)
+			if ended.Winner != tc.wantWinner {
+				t.Errorf("winner = %s, want %s", ended.Winner, tc.wantWinner)
+			}
+		})
+	}
+}
+
+func TestPass(t *testing.T) {
+	tests := []struct {
+		name      string
+		phase     werewolf.PhaseType // 在这个阶段全员放弃
+		wantPhase werewolf.PhaseType
+		wantRound int
+	}{
+		{name: "everyone passes the night", phase: werewolf.PhaseNight, wantPhase: werewolf.PhaseDay, wantRound: 1},
+		{name: "everyone skips their speech", phase: werewolf.PhaseDay, wantPhase: werewolf.PhaseVote, wantRound: 1},
+		{name: "everyone abstains", phase: werewolf.PhaseVote, wantPhase: werewolf.PhaseNight, wantRound: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			room := tt.start(fiveSeats...)
+
+			if tc.phase != werewolf.PhaseNight {
+				tt.passAll(room)
+				tt.waitPhase(room, werewolf.PhaseDay, 1)
+			}
+			if tc.phase == werewolf.PhaseVote {
+				tt.speakAll(room)
+				tt.waitPhase(room, werewolf.PhaseVote, 1)
+			}
+
+			tt.passAll(room)
+			tt.waitPhase(room, tc.wantPhase, tc.wantRound)
+			if alive := len(room.Engine().GetState().AlivePlayers); alive != len(fiveSeats) {
+				t.Errorf("alive players = %d after everyone passed, want %d", alive, len(fiveSeats))
+			}
+		})
+	}
+
+	t.Run("villager has nothing to pass at night", func(t *testing.T) {
+		tt := newTestTable(t, len(fiveSeats))
+		room := tt.start(fiveSeats...)
+
+		if result := tt.pass(tt.role(room, werewolf.RoleTypeVillager, 0)); result.Success {
+			t.Error("villager pass at night accepted")
+		}
+		if result := tt.pass(tt.role(room, werewolf.RoleTypeSeer, 0)); !result.Success {
+			t.Errorf("seer pass rejected: %s", result.Message)
+		}
+		if result := tt.pass(tt.role(room, werewolf.RoleTypeSeer, 0)); result.Success {
+			t.Error("second seer pass accepted")
+		}
+	})
+}
+
+// strictEngine 不接受空目标的引擎，像没有实现 engine.Abstainer 的真实规则引擎
+type strictEngine struct {
+	engine.GameEngine
+}
+
+// PerformAction 实现 GameEngine 接口，除发言和解药外没有目标的动作被拒绝
+func (e strictEngine) PerformAction(playerID string, actionType werewolf.ActionType, targetID string, data map[string]interface{}) error {
+	if targetID == "" && actionType != protocol.ActionSpeak && actionType != protocol.ActionAntidote {
+		return errors.New("target is required")
+	}
+	return e.GameEngine.PerformAction(playerID, actionType, targetID, data)
+}
+
+func TestPassWithoutAbstain(t *testing.T) {
+	tt := newTestTable(t, len(fiveSeats))
+	tt.server.UseEngine(func(config werewolf.Config) engine.GameEngine {
+		return strictEngine{engine.NewMock(config)}
+	})
+	room := tt.start(fiveSeats...)
+
+	// 夜里的放弃要交空目标，引擎不支持时被拒绝，阶段照旧等所有人行动
+	seer := tt.role(room, werewolf.RoleTypeSeer, 0)
+	if result := tt.pass(seer); result.Success {
+		t.Fatal("seer pass accepted by an engine without abstentions")
+	}
+	tt.act(room, seer, protocol.ActionCheck, tt.role(room, werewolf.RoleTypeWerewolf, 0))
+	tt.act(room, tt.role(room, werewolf.RoleTypeGuard, 0), protocol.ActionProtect, seer)
+	tt.act(room, tt.role(room, werewolf.RoleTypeWerewolf, 0), protocol.ActionKill, tt.role(room, werewolf.RoleTypeVillager, 0))
+	tt.waitPhase(room, werewolf.PhaseDay, 1)
+
+	// 跳过发言不带目标，照样可以
+	tt.passAll(room)
+	tt.waitPhase(room, werewolf.PhaseVote, 1)
+	if result := tt.pass(seer); result.Success {
+		t.Error("abstention accepted by an engine without abstentions")
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	tests := []struct {
+		name       string
+		inGame     bool
+		wantSeated bool
+	}{
+		{name: "in game keeps the seat", inGame: true, wantSeated: true},
+		{name: "in lobby leaves the room", inGame: false, wantSeated: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			var room *Room
+			if tc.inGame {
+				room = tt.start(fiveSeats...)
+			} else {
+				room = tt.seat(fiveSeats...)
+			}
+
+			player, other := tt.players[1], tt.players[2]
+			tt.server.RemovePlayer(player.ID)
+
+			room.mu.RLock()
+			_, seated := room.Players[player.ID]
+			room.mu.RUnlock()
+			if seated != tc.wantSeated {
+				t.Fatalf("seated after disconnect = %v, want %v", seated, tc.wantSeated)
+			}
+			if !tc.wantSeated {
+				return
+			}
+
+			var lost protocol.PlayerConnectionData
+			tt.waitFor(other, protocol.MsgPlayerConnection).UnmarshalData(&lost)
+			if lost.PlayerID != player.ID || lost.IsConnected {
+				t.Errorf("connection update = %+v, want %s disconnected", lost, player.ID)
+			}
+
+			login, _ := protocol.NewMessage(protocol.MsgLogin, protocol.LoginData{SessionToken: player.SessionToken})
+			resumed, err := tt.server.login(login, nil, tt.connect(player), protocol.ProtocolVersion)
+			if err != nil {
+				t.Fatalf("reconnect: %v", err)
+			}
+			if resumed != player || resumed.RoomID != room.ID {
+				t.Fatalf("reconnected to a new player or room")
+			}
+
+			var state protocol.GameStateData
+			tt.waitFor(player, protocol.MsgGameState).UnmarshalData(&state)
+			if state.Phase != werewolf.PhaseNight || state.Round != 1 {
+				t.Errorf("resumed state = %s round %d, want night round 1", state.Phase, state.Round)
+			}
+			tt.eventually(func() bool {
+				var back protocol.PlayerConnectionData
+				updates := tt.received(other, protocol.MsgPlayerConnection)
+				updates[len(updates)-1].UnmarshalData(&back)
+				return back.PlayerID == player.ID && back.IsConnected
+			}, "reconnection broadcast")
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// defaultRoles 未指定角色时使用的默认6人局配置
+func defaultRoles() []werewolf.RoleType {
+	return []werewolf.RoleType{
+		werewolf.RoleTypeWerewolf,
+		werewolf.RoleTypeWerewolf,
+		werewolf.RoleTypeVillager,
+		werewolf.RoleTypeVillager,
+		werewolf.RoleTypeSeer,
+		werewolf.RoleTypeWitch,
+	}
+}
+
+// knownRoles 服务器支持的角色
+var knownRoles = map[werewolf.RoleType]bool{
+	werewolf.RoleTypeWerewolf: true,
+	werewolf.RoleTypeVillager: true,
+	werewolf.RoleTypeSeer:     true,
+	werewolf.RoleTypeWitch:    true,
+	werewolf.RoleTypeGuard:    true,
+	werewolf.RoleTypeHunter:   true,
+	protocol.RoleTypeIdiot:    true,
+	protocol.RoleTypeKnight:   true,
+	protocol.RoleTypeWolfKing: true,
+	protocol.RoleTypeCupid:    true,
+	protocol.RoleTypeThief:    true,
+}
+
+// uniqueRoles 每局最多一个的神职
+var uniqueRoles = []werewolf.RoleType{
+	werewolf.RoleTypeSeer,
+	werewolf.RoleTypeWitch,
+	werewolf.RoleTypeGuard,
+	werewolf.RoleTypeHunter,
+	protocol.RoleTypeIdiot,
+	protocol.RoleTypeKnight,
+	protocol.RoleTypeWolfKing,
+	protocol.RoleTypeCupid,
+	protocol.RoleTypeThief,
+}
+
+const (
+	minRoomPlayers = 4
+	maxRoomPlayers = 18
+)
+
+// RoomConfigError 房间配置不符合规则，Errors 逐条列出原因，随错误消息的 details 发给客户端
+type RoomConfigError struct {
+	Errors []string
+}
+
+// Error 实现 error 接口
+func (e *RoomConfigError) Error() string {
+	return "invalid room config: " + strings.Join(e.Errors, "; ")
+}
+
+// ErrorCode 实现 codedError
+func (e *RoomConfigError) ErrorCode() string {
+	return protocol.ErrorCodeInvalidRoomConfig
+}
+
+// validateRoomConfig 按服务器规则检查房间配置，maxSeats 为服务器允许的最多座位数（Config.MaxRoomSize）
+//
+// errors 非空时不能建房；warnings 只是提示，不影响建房。
+// 创建房间与 VALIDATE_ROOM_CONFIG 预检共用这一套规则。
+func validateRoomConfig(config protocol.CreateRoomData, maxSeats int) (errs, warnings []string) {
+	roles, err := configRoles(config)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	if maxSeats <= 0 || maxSeats > maxRoomPlayers {
+		maxSeats = maxRoomPlayers
+	}
+
+	// 有盗贼时两张底牌不发给玩家，房间人数按座位计算
+	if seats := seatCount(roles); seats < minRoomPlayers || seats > maxSeats {
+		errs = append(errs, fmt.Sprintf("room size must be between %d and %d players, got %d",
+			minRoomPlayers, maxSeats, seats))
+	}
+
+	counts := make(map[werewolf.RoleType]int)
+	for _, role := range roles {
+		if !knownRoles[role] {
+			errs = append(errs, fmt.Sprintf("unknown role: %s", role))
+			continue
+		}
+		counts[role]++
+	}
+
+	wolves := counts[werewolf.RoleTypeWerewolf] + counts[protocol.RoleTypeWolfKing]
+	good := len(roles) - wolves
+	switch {
+	case wolves == 0:
+		errs = append(errs, "at least one werewolf is required")
+	case good == 0:
+		errs = append(errs, "a room cannot be all werewolves")
+	case wolves >= good:
+		errs = append(errs, "werewolves must be fewer than the other players")
+	case wolves*3 > len(roles):
+		warnings = append(warnings, "more than a third of the players are werewolves")
+	case wolves*5 < len(roles):
+		warnings = append(warnings, "fewer than a fifth of the players are werewolves")
+	}
+
+	if villagers := counts[werewolf.RoleTypeVillager]; villagers > 0 && villagers < wolves {
+		warnings = append(warnings, "fewer villagers than werewolves: the wolves can win quickly by killing every villager")
+	}
+
+	for _, role := range uniqueRoles {
+		if counts[role] > 1 {
+			errs = append(errs, fmt.Sprintf("only one %s is allowed", role))
+		}
+	}
+
+	// 两张底牌可能都是狼人，盗贼必须拿走其中一张，场上至少还剩一名狼人
+	if counts[protocol.RoleTypeThief] > 0 && wolves < 2 {
+		errs = append(errs, "the thief needs at least two werewolves: one may be left out as an extra card")
+	}
+
+	if counts[werewolf.RoleTypeSeer] == 0 {
+		warnings = append(warnings, "no seer: the good camp has no way to check identities")
+	}
+
+	if counts[werewolf.RoleTypeVillager] == 0 {
+		warnings = append(warnings, "no villagers: every good player has a special role")
+	}
+
+	if config.Rules != nil {
+		ruleErrs, ruleWarnings := validateRules(*config.Rules, counts)
+		errs = append(errs, ruleErrs...)
+		warnings = append(warnings, ruleWarnings...)
+	}
+
+	if config.GuardRules != nil && counts[werewolf.RoleTypeGuard] == 0 {
+		warnings = append(warnings, "guard rules are set but there is no guard")
+	}
+
+	if config.SummaryWebhook != "" {
+		if err := validateWebhookURL(config.SummaryWebhook); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if timeout := time.Duration(config.ReadyTimeout) * time.Second; timeout < 0 || timeout > maxReadyTimeout {
+		errs = append(errs, fmt.Sprintf("ready timeout must be between 0 and %d seconds", int(maxReadyTimeout.Seconds())))
+	}
+
+	if _, ok := speedFactors[normalizeSpeed(config.Speed)]; !ok {
+		errs = append(errs, fmt.Sprintf("unknown game speed: %s", config.Speed))
+	}
+
+	if config.Spectate != nil && config.Spectate.Max < 0 {
+		errs = append(errs, "spectator limit cannot be negative")
+	}
+
+	return errs, warnings
+}
+
+// handleValidateRoomConfig 预检房间配置，只返回检查结果，不创建房间
+func (h *MessageHandler) handleValidateRoomConfig(playerID string, data *protocol.CreateRoomData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	errs, warnings := validateRoomConfig(*data, h.server.config.MaxRoomSize)
+
+	respMsg, _ := protocol.NewMessage(protocol.MsgRoomConfigValidation, protocol.RoomConfigValidationData{
+		Valid:    len(errs) == 0,
+		Errors:   errs,
+		Warnings: warnings,
+	})
+
+	return player.SendMessage(respMsg.ReplyTo(h.request))
+}
+
+// campBalance 角色配置的阵营配比：狼人（含狼王）、神职和平民各有几张牌，有盗贼时包括两张底牌
+func campBalance(roles []werewolf.RoleType) (wolves, specials, villagers int) {
+	for _, role := range roles {
+		switch role {
+		case werewolf.RoleTypeWerewolf, protocol.RoleTypeWolfKing:
+			wolves++
+		case werewolf.RoleTypeVillager:
+			villagers++
+		default:
+			specials++
+		}
+	}
+	return wolves, specials, villagers
+}
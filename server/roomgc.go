@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// roomGCInterval 房间回收的检查间隔
+const roomGCInterval = time.Minute
+
+// RunRoomGC 定期回收房间，直到 ctx 结束
+func (s *Server) RunRoomGC(ctx context.Context) {
+	ticker := time.NewTicker(roomGCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.collectRooms()
+		}
+	}
+}
+
+// collectRooms 回收已结束且没有真人在线的房间；
+// 堆内存超过 RoomMemoryLimit 时，再按资源占用从大到小回收其余已结束的房间。
+func (s *Server) collectRooms() {
+	var finished []RoomStatus
+	for _, status := range s.RoomStatuses() {
+		if status.State != RoomStateFinished {
+			continue
+		}
+
+		room := s.GetRoom(status.ID)
+		if room == nil {
+			continue
+		}
+
+		if room.connectedHumans() == 0 {
+			s.evictRoom(room, "finished and empty", status.Resources)
+			continue
+		}
+
+		finished = append(finished, status)
+	}
+
+	if s.config.RoomMemoryLimit <= 0 {
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	if mem.HeapAlloc <= uint64(s.config.RoomMemoryLimit) {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].Resources.Footprint() > finished[j].Resources.Footprint()
+	})
+
+	for _, status := range finished {
+		room := s.GetRoom(status.ID)
+		if room == nil {
+			continue
+		}
+
+		s.evictRoom(room, "memory pressure", status.Resources)
+
+		runtime.ReadMemStats(&mem)
+		if mem.HeapAlloc <= uint64(s.config.RoomMemoryLimit) {
+			return
+		}
+	}
+}
+
+// evictRoom 回收房间，仍在房间中的玩家回到大厅
+func (s *Server) evictRoom(room *Room, reason string, usage RoomResources) {
+	room.mu.RLock()
+	players := make([]*Player, 0, len(room.Players))
+	for _, player := range room.Players {
+		players = append(players, player)
+	}
+	room.mu.RUnlock()
+
+	for _, player := range players {
+		player.RoomID = ""
+		player.IsReady = false
+		player.aliases.Store(nil)
+	}
+
+	s.RemoveRoom(room.ID)
+
+	s.logger.Info("room evicted",
+		"roomID", room.ID,
+		"reason", reason,
+		"footprint", usage.Footprint(),
+		"goroutines", usage.Goroutines)
+}
+
+// connectedHumans 仍在线的真人玩家数
+func (r *Room) connectedHumans() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, player := range r.Players {
+		if !player.IsBot && player.connected() {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// RoomState 房间状态
+//
+// 房间只能按 roomTransitions 列出的方向切换状态，所有切换都经过 transition：
+// 由它检查切换是否允许、执行进入新状态时的处理，并通知房间里的人。
+type RoomState string
+
+const (
+	RoomStateWaiting  RoomState = "WAITING"  // 等待玩家加入和准备
+	RoomStateStarting RoomState = "STARTING" // 全员准备后的开局倒计时
+	RoomStatePlaying  RoomState = "PLAYING"
+	RoomStatePaused   RoomState = "PAUSED"
+	RoomStateFinished RoomState = "FINISHED" // 对局结束，可以再来一局
+	RoomStateArchived RoomState = "ARCHIVED" // 房间已移除，状态不再变化
+)
+
+// roomTransitions 每个状态可以切换到的状态
+//
+// 开局倒计时被取消或开局失败时从 STARTING 回到 WAITING；再来一局时从 FINISHED 回到 WAITING；
+// 房间在任何状态下都可能被移除。
+var roomTransitions = map[RoomState][]RoomState{
+	RoomStateWaiting:  {RoomStateStarting, RoomStateArchived},
+	RoomStateStarting: {RoomStateWaiting, RoomStatePlaying, RoomStateArchived},
+	RoomStatePlaying:  {RoomStatePaused, RoomStateFinished, RoomStateArchived},
+	RoomStatePaused:   {RoomStatePlaying, RoomStateFinished, RoomStateArchived},
+	RoomStateFinished: {RoomStateWaiting, RoomStateArchived},
+}
+
+// roomStateHooks 进入某个状态时执行的处理，from 为切换前的状态（需持有锁）
+var roomStateHooks = map[RoomState]func(r *Room, from RoomState){
+	RoomStatePlaying: func(r *Room, from RoomState) {
+		// 开局后不再为未准备的玩家计时；暂停后继续不受影响
+		if from == RoomStateStarting {
+			r.stopReadyTimer()
+		}
+		// 不论因何继续，暂停期间的继续表态都已作废
+		r.pauses.settle()
+	},
+	RoomStatePaused: func(r *Room, from RoomState) {
+		// 玩家表态生效、引擎出错或等待重连都会暂停，之前的暂停表态都已作废
+		r.pauses.settle()
+	},
+	RoomStateFinished: func(r *Room, from RoomState) {
+		// 引擎判定结束和房间判定结束都经过这里，每局只统计一次
+		r.telemetry.gameFinished()
+		// 对局在夜里结束时不再等天亮
+		r.releaseNightResults()
+		r.pauses.settle()
+	},
+}
+
+// lobby 还没开局，玩家可以进出和准备，房主可以移出玩家
+func (s RoomState) lobby() bool {
+	return s == RoomStateWaiting || s == RoomStateStarting
+}
+
+// inGame 对局进行中，包括暂停
+func (s RoomState) inGame() bool {
+	return s == RoomStatePlaying || s == RoomStatePaused
+}
+
+// ended 对局已结束或房间已移除，计时器的回调据此放弃
+func (s RoomState) ended() bool {
+	return s == RoomStateFinished || s == RoomStateArchived
+}
+
+// canTransition 房间状态能否从 from 切换到 to
+func canTransition(from, to RoomState) bool {
+	return slices.Contains(roomTransitions[from], to)
+}
+
+// transition 切换房间状态，执行进入新状态的处理并通知房间里的玩家和观众（需持有锁）
+//
+// 不允许的切换不改变状态，返回错误。通知直接发给各玩家而不经过 BroadcastMessage，
+// 因此持锁时也能调用，并且排在同一次持锁内随后发出的消息（如 GAME_STARTED）之前。
+func (r *Room) transition(to RoomState) error {
+	from := r.State
+	if !canTransition(from, to) {
+		r.logger.Error("invalid room state transition", "from", from, "to", to)
+		return errors.Errorf("invalid room state transition: %s -> %s", from, to)
+	}
+
+	r.State = to
+	if hook := roomStateHooks[to]; hook != nil {
+		hook(r, from)
+	}
+	r.syncAliases()
+
+	r.logger.Info("room state changed", "from", from, "to", to)
+
+	msg := r.roomStateMessage(from)
+	for _, player := range r.Players {
+		player.SendMessage(msg)
+	}
+	r.viewers.publish(msg)
+
+	return nil
+}
+
+// roomStateMessage 房间状态消息，from 为空时只是告知当前状态，用于重连（需持有锁）
+func (r *Room) roomStateMessage(from RoomState) *protocol.Message {
+	msg, _ := protocol.NewMessage(protocol.MsgRoomStateChanged, protocol.RoomStateChangedData{
+		RoomID: r.ID,
+		From:   string(from),
+		State:  string(r.State),
+	})
+	return msg
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// normalizeRules 补全规则中未填写的选项
+func normalizeRules(rules protocol.GameRules) protocol.GameRules {
+	defaults := protocol.DefaultGameRules()
+	if rules.WitchSelfSave == "" {
+		rules.WitchSelfSave = defaults.WitchSelfSave
+	}
+	if rules.FirstNightReveal == "" {
+		rules.FirstNightReveal = defaults.FirstNightReveal
+	}
+	if rules.WolfKill == "" {
+		rules.WolfKill = defaults.WolfKill
+	}
+	return rules
+}
+
+// validateRules 检查对局规则，counts 为各角色人数
+//
+// 引擎只支持遗言开关，女巫自救、首夜死讯、警长竞选和狼人自爆由房间在引擎之外处理；
+// 其余规则需要引擎或房间流程支持，暂时拒绝开启。
+func validateRules(rules protocol.GameRules, counts map[werewolf.RoleType]int) (errs, warnings []string) {
+	rules = normalizeRules(rules)
+
+	switch rules.WitchSelfSave {
+	case protocol.WitchSelfSaveAlways, protocol.WitchSelfSaveFirstNight, protocol.WitchSelfSaveNever:
+	default:
+		errs = append(errs, fmt.Sprintf("unknown witch self-save rule: %s", rules.WitchSelfSave))
+	}
+
+	switch rules.FirstNightReveal {
+	case protocol.RevealCause, protocol.RevealDeathOnly, protocol.RevealNone:
+	default:
+		errs = append(errs, fmt.Sprintf("unknown first night reveal policy: %s", rules.FirstNightReveal))
+	}
+
+	switch rules.WolfKill {
+	case protocol.WolfKillLast, protocol.WolfKillMajority:
+	default:
+		errs = append(errs, fmt.Sprintf("unknown wolf kill rule: %s", rules.WolfKill))
+	}
+
+	if rules.GuardAntidoteKills {
+		errs = append(errs, "guard and antidote on the same player is not supported yet")
+	}
+
+	if rules.WitchSelfSave != protocol.DefaultGameRules().WitchSelfSave && counts[werewolf.RoleTypeWitch] == 0 {
+		warnings = append(warnings, "witch self-save rule is set but there is no witch")
+	}
+
+	return errs, warnings
+}
+
+// checkWitchRules 按本局女巫自救规则检查解药，女巫不是今晚的刀口时不受限制
+func (r *Room) checkWitchRules(playerID string, round int) error {
+	if r.nightVictim(round) != playerID {
+		return nil
+	}
+
+	r.mu.RLock()
+	rule := r.rules.WitchSelfSave
+	r.mu.RUnlock()
+
+	switch {
+	case rule == protocol.WitchSelfSaveNever:
+		return errors.New("witch cannot save themselves")
+	case rule == protocol.WitchSelfSaveFirstNight && round > 1:
+		return errors.New("witch can only save themselves on the first night")
+	}
+
+	return nil
+}
+
+// nightVictim 指定回合狼人最后选定的击杀目标，没有时返回空
+func (r *Room) nightVictim(round int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	victim := ""
+	for _, action := range r.actions {
+		if action.ActionType == protocol.ActionKill && action.Round == round {
+			victim = action.TargetID
+		}
+	}
+	return victim
+}
+
+// revealPolicy 指定回合夜里出局的公布方式：首夜按规则，之后总是公布死因
+func (r *Room) revealPolicy(round int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if round == 1 {
+		return r.rules.FirstNightReveal
+	}
+	return protocol.RevealCause
+}
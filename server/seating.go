@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// PlayerAtSeat 返回座位上的玩家ID（座位号从1开始，即加入顺序）
+func (r *Room) PlayerAtSeat(seat int) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if seat < 1 || seat > len(r.order) {
+		return "", false
+	}
+
+	return r.order[seat-1], true
+}
+
+// resolveTarget 按座位表解析动作目标，同时给出座位号和ID时两者必须一致
+func (r *Room) resolveTarget(targetID string, targetSeat int) (string, error) {
+	if targetSeat == 0 {
+		return targetID, nil
+	}
+
+	seatPlayerID, ok := r.PlayerAtSeat(targetSeat)
+	if !ok {
+		return "", errors.Errorf("invalid seat: %d", targetSeat)
+	}
+
+	if targetID != "" && targetID != seatPlayerID {
+		return "", errors.Errorf("target %s is not at seat %d", targetID, targetSeat)
+	}
+
+	return seatPlayerID, nil
+}
+
+// PlayerInfo 返回房间内某个玩家带座位号的信息
+func (r *Room) PlayerInfo(playerID string) protocol.PlayerInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i, id := range r.order {
+		if id == playerID {
+			return lobbyPlayerInfo(i+1, r.Players[id], id == r.OwnerID)
+		}
+	}
+
+	return protocol.PlayerInfo{ID: playerID}
+}
+
+// sortBySeat 按座位顺序排列玩家（需持有锁）
+func (r *Room) sortBySeat(playerIDs []string) []string {
+	sort.SliceStable(playerIDs, func(i, j int) bool {
+		return slices.Index(r.order, playerIDs[i]) < slices.Index(r.order, playerIDs[j])
+	})
+	return playerIDs
+}
+
+// seatLabel 玩家的座位号和名字，用于主持词（需持有锁）
+func (r *Room) seatLabel(playerID string) string {
+	i := slices.Index(r.order, playerID)
+	if i < 0 {
+		return playerID
+	}
+
+	if player := r.Players[playerID]; player != nil && !r.namesHidden() {
+		return fmt.Sprintf("%d号 %s", i+1, player.Username)
+	}
+	return fmt.Sprintf("%d号", i+1)
+}
+
+// playerLabel 加锁取得玩家的座位号和名字，用于不持锁时的提示
+func (r *Room) playerLabel(playerID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.seatLabel(playerID)
+}
+
+// namesHidden 匿名座位的对局是否正在进行，此时玩家只以座位号称呼（需持有锁）
+func (r *Room) namesHidden() bool {
+	return r.anonymous && r.State.inGame()
+}
+
+// shuffleSeats 匿名座位开局时随机排座，座位号不再对应加入顺序，避免按进房先后认出谁是谁（需持有锁）
+//
+// 打乱后的顺序沿用到下一局开局前，房主移交等按座位顺序的处理随之改变。
+func (r *Room) shuffleSeats() {
+	rand.Shuffle(len(r.order), func(i, j int) { r.order[i], r.order[j] = r.order[j], r.order[i] })
+}
+
+// anonymize 去掉玩家信息中能认出是谁的字段，只留座位号和对局状态
+func anonymize(info protocol.PlayerInfo) protocol.PlayerInfo {
+	info.Username = ""
+	info.Rating = 0
+	info.Profile = nil
+	return info
+}
+
+// seatAliases 匿名对局中玩家ID与化名的对照，每局开局时重新生成
+//
+// 大厅里玩家ID与名字一同显示过，对局中照旧发出真实ID就能认出座位上是谁。匿名对局进行中，
+// 发给玩家和观众的消息里其他玩家的ID都换成化名，收到的以玩家为目标的消息再把化名换回真实ID；
+// 玩家自己的ID不换，客户端照常认得自己。
+type seatAliases struct {
+	alias map[string]string // 玩家ID -> 化名
+	id    map[string]string // 化名 -> 玩家ID
+}
+
+// newSeatAliases 给每名玩家生成一个与玩家ID格式相同的化名
+func newSeatAliases(playerIDs []string) *seatAliases {
+	a := &seatAliases{
+		alias: make(map[string]string, len(playerIDs)),
+		id:    make(map[string]string, len(playerIDs)),
+	}
+	for _, playerID := range playerIDs {
+		alias := uuid.New().String()
+		a.alias[playerID] = alias
+		a.id[alias] = playerID
+	}
+	return a
+}
+
+// of 玩家的化名，没有化名表或不是对局中的玩家时原样返回
+func (a *seatAliases) of(playerID string) string {
+	if a == nil || a.alias[playerID] == "" {
+		return playerID
+	}
+	return a.alias[playerID]
+}
+
+// mask 把发给 self 的消息中其他玩家的ID换成化名，返回新消息，不修改广播共用的原消息
+//
+// 玩家ID是 UUID，只替换整个 JSON 字符串与ID相同的值和键，不会误改发言内容。
+func (a *seatAliases) mask(msg socket.Message, self string) socket.Message {
+	m, ok := msg.(*protocol.Message)
+	if !ok {
+		return msg
+	}
+
+	data := m.Data
+	for playerID, alias := range a.alias {
+		if playerID != self {
+			data = bytes.ReplaceAll(data, quoted(playerID), quoted(alias))
+		}
+	}
+
+	masked := *m
+	masked.Data = data
+	return &masked
+}
+
+// unmask 把收到的消息中的化名换回真实ID
+func (a *seatAliases) unmask(m *protocol.Message) *protocol.Message {
+	data := m.Data
+	for alias, playerID := range a.id {
+		data = bytes.ReplaceAll(data, quoted(alias), quoted(playerID))
+	}
+
+	unmasked := *m
+	unmasked.Data = data
+	return &unmasked
+}
+
+// quoted 玩家ID在 JSON 中的写法
+func quoted(playerID string) []byte {
+	return []byte(`"` + playerID + `"`)
+}
+
+// syncAliases 房间状态变化后按是否隐藏名字生成或作废化名表，交给房间里的玩家和观众（需持有锁）
+func (r *Room) syncAliases() {
+	switch hidden := r.namesHidden(); {
+	case hidden && r.aliases == nil:
+		r.aliases = newSeatAliases(r.order)
+	case !hidden:
+		r.aliases = nil
+	}
+
+	for _, player := range r.Players {
+		player.aliases.Store(r.aliases)
+	}
+	r.viewers.setAliases(r.aliases)
+}
+
+// unmaskSeats 匿名对局中把消息里其他玩家的化名换回真实ID，用于以房间内玩家为目标的消息
+//
+// 私聊、查看资料等不限于房间的消息不换，化名无法用来查出座位上是谁。
+func unmaskSeats(next handlerFunc) handlerFunc {
+	return func(h *MessageHandler, playerID string, msg *protocol.Message) error {
+		if player := h.server.GetPlayer(playerID); player != nil {
+			if aliases := player.aliases.Load(); aliases != nil {
+				msg = aliases.unmask(msg)
+			}
+		}
+		return next(h, playerID, msg)
+	}
+}
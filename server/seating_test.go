@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/google/uuid"
+)
+
+func TestSeatAliases(t *testing.T) {
+	self, other := uuid.New().String(), uuid.New().String()
+	aliases := newSeatAliases([]string{self, other})
+
+	tests := []struct {
+		name       string
+		msgType    protocol.MessageType
+		data       interface{}
+		wantMasked []string // 打上化名后仍应出现的内容
+	}{
+		{
+			name:       "action target",
+			msgType:    protocol.MsgPerformAction,
+			data:       protocol.PerformActionData{ActionType: protocol.ActionCheck, TargetID: other},
+			wantMasked: []string{aliases.of(other)},
+		},
+		{
+			name:       "own ID is kept",
+			msgType:    protocol.MsgSheriffState,
+			data:       protocol.SheriffStateData{Candidates: []string{self, other}, SheriffID: self},
+			wantMasked: []string{self, aliases.of(other)},
+		},
+		{
+			name:       "map keys",
+			msgType:    protocol.MsgSheriffState,
+			data:       protocol.SheriffStateData{Tally: map[string]float64{other: 2}},
+			wantMasked: []string{aliases.of(other)},
+		},
+		{
+			// 只替换整个 JSON 字符串，发言里提到的ID原样保留
+			name:       "speech mentioning an ID",
+			msgType:    protocol.MsgSheriffSpeech,
+			data:       protocol.SheriffSpeechData{PlayerID: other, Content: "查验 " + other},
+			wantMasked: []string{aliases.of(other), "查验 " + other},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := protocol.NewMessage(tc.msgType, tc.data)
+			if err != nil {
+				t.Fatalf("new message: %v", err)
+			}
+			original := append([]byte(nil), msg.Data...)
+
+			masked := aliases.mask(msg, self).(*protocol.Message)
+			if bytes.Contains(masked.Data, quoted(other)) {
+				t.Errorf("masked data still has the real ID: %s", masked.Data)
+			}
+			for _, want := range tc.wantMasked {
+				if !bytes.Contains(masked.Data, []byte(want)) {
+					t.Errorf("masked data %s is missing %q", masked.Data, want)
+				}
+			}
+			if !bytes.Equal(msg.Data, original) {
+				t.Error("mask modified the shared message")
+			}
+
+			if unmasked := aliases.unmask(masked); !bytes.Equal(unmasked.Data, original) {
+				t.Errorf("round trip = %s, want %s", unmasked.Data, original)
+			}
+		})
+	}
+}
+
+func TestAnonymousGame(t *testing.T) {
+	tt := newTestTable(t, len(fiveSeats))
+	viewers := httptest.NewServer(NewViewerHandler(tt.server))
+	defer viewers.Close()
+
+	room := tt.startWith(protocol.CreateRoomData{
+		RoomName:  "测试房间",
+		Roles:     fiveSeats,
+		Rules:     &protocol.GameRules{Sheriff: true},
+		Anonymous: true,
+	})
+	published, unsubscribe := room.viewers.subscribe()
+	defer unsubscribe()
+
+	room.mu.RLock()
+	aliases := room.aliases
+	room.mu.RUnlock()
+	if aliases == nil {
+		t.Fatal("anonymous game started without aliases")
+	}
+
+	wolf, seer, guard := tt.role(room, werewolf.RoleTypeWerewolf, 0), tt.role(room, werewolf.RoleTypeSeer, 0), tt.role(room, werewolf.RoleTypeGuard, 0)
+	villager := tt.role(room, werewolf.RoleTypeVillager, 0)
+
+	// 玩家只知道化名，以化名为目标的动作按真实ID交给引擎
+	tt.must(seer, protocol.MsgPerformAction, protocol.PerformActionData{ActionType: protocol.ActionCheck, TargetID: aliases.of(wolf.ID)})
+	tt.eventually(func() bool { return room.hasActed(seer.ID, protocol.ActionCheck, 1) }, "seer check")
+	room.mu.RLock()
+	checked := room.actions[len(room.actions)-1].TargetID
+	room.mu.RUnlock()
+	if checked != wolf.ID {
+		t.Fatalf("check target = %q, want the wolf's real ID", checked)
+	}
+
+	tt.act(room, guard, protocol.ActionProtect, villager)
+	tt.act(room, wolf, protocol.ActionKill, villager)
+	tt.waitPhase(room, werewolf.PhaseDay, 1)
+
+	// 警长竞选的投票同样以化名提交
+	tt.eventually(func() bool {
+		stage, _ := sheriffOf(room)
+		return stage == protocol.SheriffStageSignup
+	}, "sheriff signup")
+	for _, player := range tt.players {
+		tt.must(player, protocol.MsgSheriffRun, protocol.SheriffRunData{Run: player == seer || player == guard})
+	}
+	for {
+		room.mu.RLock()
+		s := room.sheriff
+		room.mu.RUnlock()
+		if s.stage != protocol.SheriffStageSpeech {
+			break
+		}
+		tt.must(tt.server.GetPlayer(s.candidates[s.speaker]), protocol.MsgSheriffSpeech, protocol.SheriffSpeechData{Content: "请投我"})
+	}
+	for _, player := range tt.players {
+		if player != seer && player != guard {
+			tt.must(player, protocol.MsgSheriffVote, protocol.SheriffTargetData{TargetID: aliases.of(seer.ID)})
+		}
+	}
+	if _, sheriffID := sheriffOf(room); sheriffID != seer.ID {
+		t.Fatalf("sheriff = %q, want the seer", sheriffID)
+	}
+	tt.waitFor(villager, protocol.MsgSpeakingOrder)
+
+	// 开局后发给每名玩家的消息里都没有其他玩家的真实ID和名字
+	for _, player := range tt.players {
+		tt.mu.Lock()
+		inbox := tt.inbox[player.ID]
+		tt.mu.Unlock()
+
+		started := false
+		for _, msg := range inbox {
+			started = started || msg.Type == protocol.MsgGameStarted
+			if !started {
+				continue
+			}
+			for _, other := range tt.players {
+				if other != player && leaks(msg.Data, other) {
+					t.Errorf("%s sent to %s leaks %s: %s", msg.Type, player.Username, other.Username, msg.Data)
+				}
+			}
+		}
+		if !started {
+			t.Errorf("%s did not receive %s", player.Username, protocol.MsgGameStarted)
+		}
+	}
+
+	// 观众收到的广播和快照也只有化名
+	for len(published) > 0 {
+		event := <-published
+		data, _ := json.Marshal(event.data)
+		for _, player := range tt.players {
+			if leaks(data, player) {
+				t.Errorf("viewer %s leaks %s: %s", event.name, player.Username, data)
+			}
+		}
+	}
+
+	snapshot := readSnapshot(t, viewers.URL+"/rooms/"+room.ID+"/events")
+	if !strings.Contains(snapshot, aliases.of(seer.ID)) {
+		t.Errorf("viewer snapshot has no aliases: %s", snapshot)
+	}
+	for _, player := range tt.players {
+		if leaks([]byte(snapshot), player) {
+			t.Errorf("viewer snapshot leaks %s: %s", player.Username, snapshot)
+		}
+	}
+}
+
+// leaks 数据中是否出现玩家的真实ID或名字
+func leaks(data []byte, player *Player) bool {
+	return bytes.Contains(data, []byte(player.ID)) || bytes.Contains(data, []byte(player.Username))
+}
+
+// readSnapshot 订阅观看接口，返回第一条 SNAPSHOT 事件的数据
+func readSnapshot(t *testing.T, url string) string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() != "event: SNAPSHOT" || !scanner.Scan() {
+			continue
+		}
+		return strings.TrimPrefix(scanner.Text(), "data: ")
+	}
+
+	t.Fatalf("no snapshot from %s: %v", url, scanner.Err())
+	return ""
+}
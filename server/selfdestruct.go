@@ -0,0 +1,149 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// explosion 打断白天的狼人自爆，由 r.mu 保护
+type explosion struct {
+	round int    // 自爆所在的回合，没有自爆时为 0
+	wolf  string // 自爆的狼人
+}
+
+// canSelfDestruct 本局允许自爆时，白天还没有人自爆的狼人（包括狼王）可以自爆
+func (r *Room) canSelfDestruct(round int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.rules.WolfSelfDestruct && r.explosion.round != round
+}
+
+// dayCut 本回合的白天是否已被自爆打断，之后的发言和投票由房间代为结束
+func (r *Room) dayCut(round int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.explosion.round != 0 && r.explosion.round == round
+}
+
+// selfDestruct 狼人白天自爆：公开身份并出局，跳过剩余发言和当天的放逐投票，直接进入黑夜
+//
+// 引擎没有结束阶段的接口，房间代所有存活玩家提交发言和投票（都投给自爆的狼人）让引擎推进，
+// 引擎放逐自爆者后与房间的出局状态一致。代交的动作计入重放记录，自爆本身不经过引擎。
+func (r *Room) selfDestruct(wolfID string) error {
+	state := r.Engine().GetState()
+	if publicPhase(state.Phase) != werewolf.PhaseDay {
+		return rejected(RejectWrongPhase, errors.New("self-destruct is only allowed during the day"))
+	}
+	if r.electionRunning() {
+		return rejected(RejectRule, errors.New("cannot self-destruct during the sheriff election"))
+	}
+
+	var role werewolf.RoleType
+	for _, ps := range state.Players {
+		if ps.ID == wolfID {
+			role = ps.Role
+		}
+	}
+	if role != werewolf.RoleTypeWerewolf || !r.isAlive(wolfID) {
+		return rejected(RejectOther, errors.New("only an alive werewolf can self-destruct"))
+	}
+
+	r.mu.Lock()
+	if !r.rules.WolfSelfDestruct {
+		r.mu.Unlock()
+		return rejected(RejectRule, errors.New("self-destruct is not enabled in this room"))
+	}
+	if r.explosion.round == state.Round {
+		r.mu.Unlock()
+		return rejected(RejectRule, errors.New("the day has already ended"))
+	}
+	r.explosion = explosion{round: state.Round, wolf: wolfID}
+	r.mu.Unlock()
+
+	r.stopSpeakingOrder()
+
+	r.slay(wolfID, protocol.GameEventData{
+		EventType: protocol.EventWolfSelfDestruct,
+		Event:     protocol.EventKeyWolfSelfDestruct,
+		Params:    map[string]string{"player": wolfID},
+		Data:      map[string]interface{}{"playerID": wolfID},
+	})
+
+	if _, finished := r.Result(); finished {
+		return nil
+	}
+
+	r.forceActions(protocol.ActionSpeak, state.Round, func(string) (string, map[string]interface{}) {
+		return "", map[string]interface{}{"content": ""}
+	})
+
+	return nil
+}
+
+// skipExileVote 自爆当天进入投票时代所有存活玩家投给自爆的狼人，让引擎直接进入黑夜
+func (r *Room) skipExileVote(round int) {
+	r.mu.RLock()
+	wolfID := r.explosion.wolf
+	r.mu.RUnlock()
+
+	alive := r.Engine().GetState().AlivePlayers
+	r.forceActions(protocol.ActionVote, round, func(playerID string) (string, map[string]interface{}) {
+		if playerID != wolfID {
+			return wolfID, nil
+		}
+		// 自爆者自己投给任意另一名玩家，不影响放逐结果
+		for _, id := range alive {
+			if id != wolfID {
+				return id, nil
+			}
+		}
+		return "", nil
+	})
+}
+
+// forceActions 代引擎中每名存活玩家提交动作，引擎拒绝的（如已经提交过）忽略
+func (r *Room) forceActions(actionType werewolf.ActionType, round int, choose func(playerID string) (string, map[string]interface{})) {
+	for _, playerID := range r.Engine().GetState().AlivePlayers {
+		targetID, data := choose(playerID)
+		r.forceAction(playerID, actionType, targetID, data, round)
+	}
+}
+
+// forceAction 代玩家直接向引擎提交一个动作并记入动作记录，返回引擎是否接受
+func (r *Room) forceAction(playerID string, actionType werewolf.ActionType, targetID string, data map[string]interface{}, round int) bool {
+	perform := func() error {
+		return r.Engine().PerformAction(playerID, actionType, targetID, data)
+	}
+
+	held := actionType == protocol.ActionVote && r.holdsVotes()
+	if held {
+		perform = func() error { return r.checkHeldVote(playerID, targetID, round) }
+	}
+
+	if err := callEngine("forced action", perform); err != nil {
+		r.playerLogger(playerID).Debug("forced action rejected",
+			"actionType", actionType,
+			"error", err)
+		return false
+	}
+
+	r.mu.Lock()
+	r.actions = append(r.actions, ActionRecord{
+		PlayerID:   playerID,
+		ActionType: actionType,
+		TargetID:   targetID,
+		Data:       data,
+		Round:      round,
+		Held:       held,
+	})
+	r.mu.Unlock()
+
+	if held {
+		r.settleHeldVotes(round)
+	}
+
+	return true
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Zereker/socket"
+	"github.com/pkg/errors"
+)
+
+// sendQueueSize 每条连接排队待发的消息数上限
+//
+// 不超过重发缓冲的大小，丢弃的消息仍能通过重发找回。
+const sendQueueSize = outboxSize
+
+// shutdownFlushTimeout 关闭服务器时等待各发送队列写完的最长时间
+const shutdownFlushTimeout = 2 * time.Second
+
+var (
+	errSendQueueFull   = errors.New("send queue full")
+	errSendQueueClosed = errors.New("connection closed")
+)
+
+// OverflowPolicy 发送队列满时的处理策略
+type OverflowPolicy string
+
+const (
+	OverflowDrop       OverflowPolicy = "drop"       // 丢弃新消息，客户端发现序号缺口后请求重发
+	OverflowDisconnect OverflowPolicy = "disconnect" // 断开连接，对局中按断线重连处理
+)
+
+// ParseOverflowPolicy 解析发送队列溢出策略
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(s); policy {
+	case OverflowDrop, OverflowDisconnect:
+		return policy, nil
+	default:
+		return "", errors.Errorf("unknown send overflow policy: %s", s)
+	}
+}
+
+// sendQueue 一条连接的出站消息队列
+//
+// 入队不阻塞，由单独的写协程按顺序写入连接。客户端读得慢时只有它自己的队列堆积，
+// 不会拖住广播它的房间；队列满时按溢出策略丢弃消息或断开连接。
+type sendQueue struct {
+	messages chan socket.Message
+	write    func(socket.Message) error
+	policy   OverflowPolicy
+	// disconnect 断开连接，disconnect 策略下队列第一次溢出时调用
+	disconnect func()
+	metrics    *Metrics
+	logger     *slog.Logger
+
+	pending atomic.Int64 // 已入队、尚未写完的消息数
+	dropped atomic.Int64
+	done    chan struct{}
+	stop    func()
+}
+
+// newSendQueue 创建发送队列并启动写协程
+func newSendQueue(write func(socket.Message) error, policy OverflowPolicy, disconnect func(), metrics *Metrics, logger *slog.Logger) *sendQueue {
+	q := &sendQueue{
+		messages:   make(chan socket.Message, sendQueueSize),
+		write:      write,
+		policy:     policy,
+		disconnect: disconnect,
+		metrics:    metrics,
+		logger:     logger,
+		done:       make(chan struct{}),
+	}
+	q.stop = sync.OnceFunc(func() { close(q.done) })
+
+	go q.run()
+
+	return q
+}
+
+// run 写协程，把队列中的消息依次写入连接，直到 stop 被调用
+func (q *sendQueue) run() {
+	for {
+		select {
+		case <-q.done:
+			return
+		case msg := <-q.messages:
+			if err := q.write(msg); err != nil {
+				q.logger.Debug("write message failed", "error", err)
+			}
+			q.pending.Add(-1)
+		}
+	}
+}
+
+// enqueue 消息入队，不阻塞；队列已满时按溢出策略处理并返回错误
+func (q *sendQueue) enqueue(msg socket.Message) error {
+	select {
+	case <-q.done:
+		return errSendQueueClosed
+	default:
+	}
+
+	q.pending.Add(1)
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		q.pending.Add(-1)
+	}
+
+	dropped := q.dropped.Add(1)
+	q.metrics.sendOverflow(q.policy)
+
+	if q.policy == OverflowDisconnect {
+		if dropped == 1 {
+			q.logger.Warn("send queue full, disconnecting slow client")
+			q.disconnect()
+		}
+		return errSendQueueFull
+	}
+
+	// 只在开始丢消息时提醒一次，之后的丢弃只在调试级别输出
+	if dropped == 1 {
+		q.logger.Warn("send queue full, dropping messages")
+	} else {
+		q.logger.Debug("send queue full, message dropped", "dropped", dropped)
+	}
+	return errSendQueueFull
+}
+
+// flush 等待队列中的消息写完，最多等到 deadline
+func (q *sendQueue) flush(deadline time.Time) {
+	for q.pending.Load() > 0 && time.Now().Before(deadline) {
+		select {
+		case <-q.done:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -8,27 +8,67 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Zereker/game/engine"
 	"github.com/Zereker/game/protocol"
 	"github.com/Zereker/socket"
 	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
 )
 
 // Server 游戏服务器
 type Server struct {
-	rooms      map[string]*Room    // roomID -> Room
-	players    map[string]*Player  // playerID -> Player
-	connID     int64              // 连接ID计数器
-	mu         sync.RWMutex
-	handler    *MessageHandler
+	rooms    map[string]*Room   // roomID -> Room
+	players  map[string]*Player // playerID -> Player
+	sessions map[string]string  // sessionToken -> playerID
+	// connsByIP 每个 IP 当前的连接数，见 acquireConn
+	connsByIP map[string]int
+	// graceTimers 断线等待重连的玩家 playerID -> 宽限期计时器
+	graceTimers map[string]*time.Timer
+	connID      int64 // 连接ID计数器
+	mu          sync.RWMutex
+	handler     *MessageHandler
+	accounts    *AccountService
+	config      Config
+	webhook     *WebhookClient
+	alerter     Alerter
+	listener    net.Listener
+	closing     atomic.Bool
+	serving     atomic.Bool    // Serve 已开始接受连接，见 health.go
+	reports     reportLog      // 玩家举报，见 moderation.go
+	newEngine   engine.Factory // 新房间使用的规则引擎，为 nil 时用房间默认的 werewolf 引擎
+	metrics     *Metrics       // 未启用 /metrics 时为 nil
+	telemetry   *Telemetry     // 未配置上报地址时为 nil
+	snapshots   snapshotStore  // 房间快照存储，未配置时为 nil，见 persist.go
+	registry    *roomRegistry  // 多进程部署时的房间路由表，单机运行时为 nil，见 registry.go
+	profanity   *wordFilter    // 不当发言过滤，没有配置词表时为 nil，见 profanity.go
+	// saved 存储中有快照的房间，保存时删除已结束或已移除房间的快照；snapshotMu 让保存依次进行
+	saved      map[string]bool
+	snapshotMu sync.Mutex
 	logger     *slog.Logger
 }
 
 // NewServer 创建新服务器
-func NewServer(logger *slog.Logger) *Server {
+func NewServer(config Config, accounts *AccountService, logger *slog.Logger) *Server {
+	webhook := NewWebhookClient(logger)
+
 	server := &Server{
-		rooms:   make(map[string]*Room),
-		players: make(map[string]*Player),
-		logger:  logger,
+		rooms:    make(map[string]*Room),
+		players:  make(map[string]*Player),
+		sessions: make(map[string]string),
+		accounts: accounts,
+		config:   config,
+		webhook:  webhook,
+		alerter:  NewAlerter(config.AlertWebhook, webhook, logger),
+		logger:   logger,
+
+		newEngine:   config.Engine,
+		graceTimers: make(map[string]*time.Timer),
+		connsByIP:   make(map[string]int),
+		telemetry:   NewTelemetry(config.TelemetryURL, webhook, logger),
+		snapshots:   newSnapshotStore(config),
+		registry:    newRoomRegistry(config.RegistryRedis),
+		profanity:   newWordFilter(config),
+		saved:       make(map[string]bool),
 	}
 
 	server.handler = NewMessageHandler(server, logger)
@@ -36,14 +76,54 @@ func NewServer(logger *slog.Logger) *Server {
 	return server
 }
 
-// CreateRoom 创建房间
-func (s *Server) CreateRoom(name string, roles []werewolf.RoleType) (*Room, error) {
+// UseEngine 换用其他规则引擎，需在创建房间前调用
+func (s *Server) UseEngine(factory engine.Factory) {
+	s.newEngine = factory
+}
+
+// EnableMetrics 启用监控指标，需在创建房间前调用
+func (s *Server) EnableMetrics() *Metrics {
+	s.metrics = NewMetrics(s)
+	return s.metrics
+}
+
+// newRoom 创建按服务器配置初始化的房间（不注册）
+func (s *Server) newRoom(name string, roles []werewolf.RoleType) *Room {
 	room := NewRoom(name, roles, s.logger)
+	room.errorPolicy = s.config.EngineErrorPolicy
+	room.alerter = s.alerter
+	room.webhook = s.webhook
+	room.summaryWebhook = s.config.SummaryWebhook
+	room.replayBaseURL = s.config.ReplayBaseURL
+	room.metrics = s.metrics
+	room.speakTimeout = s.config.SpeakTimeout
+	room.afkMisses = s.config.AFKMisses
+	room.startDelay = s.config.StartCountdown
+	room.viewers.delay = s.config.ViewerDelay
+	room.telemetry = s.telemetry
+	room.accounts = s.accounts
+	if s.newEngine != nil {
+		room.newEngine = s.newEngine
+	}
+	if s.profanity != nil {
+		room.useOutbound(s.profanityMiddleware(room))
+	}
+	return room
+}
+
+// CreateRoom 创建房间，options 在房间登记、其他玩家能找到并加入之前按顺序应用
+func (s *Server) CreateRoom(name string, roles []werewolf.RoleType, options ...func(r *Room)) (*Room, error) {
+	room := s.newRoom(name, roles)
+	for _, option := range options {
+		option(room)
+	}
 
 	s.mu.Lock()
 	s.rooms[room.ID] = room
 	s.mu.Unlock()
 
+	s.publishRoom(room.ID)
+
 	s.logger.Info("room created",
 		"roomID", room.ID,
 		"name", name,
@@ -60,6 +140,21 @@ func (s *Server) GetRoom(roomID string) *Room {
 	return s.rooms[roomID]
 }
 
+// RemoveRoom 移除房间
+func (s *Server) RemoveRoom(roomID string) {
+	s.mu.Lock()
+	room := s.rooms[roomID]
+	delete(s.rooms, roomID)
+	s.mu.Unlock()
+
+	if room != nil {
+		room.Close()
+		s.unpublishRoom(roomID)
+	}
+
+	s.logger.Info("room removed", "roomID", roomID)
+}
+
 // GetPlayer 获取玩家
 func (s *Server) GetPlayer(playerID string) *Player {
 	s.mu.RLock()
@@ -68,43 +163,233 @@ func (s *Server) GetPlayer(playerID string) *Player {
 	return s.players[playerID]
 }
 
-// AddPlayer 添加玩家
-func (s *Server) AddPlayer(player *Player) {
+// AddPlayer 添加玩家，同一账号不允许同时登录两次
+func (s *Server) AddPlayer(player *Player) error {
 	s.mu.Lock()
+	if _, exists := s.players[player.ID]; exists {
+		s.mu.Unlock()
+		return errors.New("account already logged in")
+	}
+	if s.usernameTaken(player.Username, player.ID) {
+		s.mu.Unlock()
+		return errUsernameTaken(player.Username)
+	}
+	if s.config.MaxPlayers > 0 && len(s.players) >= s.config.MaxPlayers {
+		s.mu.Unlock()
+		s.metrics.limitHit(protocol.ErrorCodeServerFull)
+		return limitError(protocol.ErrorCodeServerFull, "server is full, try again later")
+	}
 	s.players[player.ID] = player
+	s.sessions[player.SessionToken] = player.ID
+	player.logger = s.logger.With("playerID", player.ID)
 	s.mu.Unlock()
 
-	s.logger.Info("player added", "playerID", player.ID)
+	s.logger.Info("player added", "playerID", player.ID, "guest", player.IsGuest)
+	return nil
 }
 
-// RemovePlayer 移除玩家
-func (s *Server) RemovePlayer(playerID string) {
-	s.mu.Lock()
-	player, exists := s.players[playerID]
-	if !exists {
-		s.mu.Unlock()
-		return
+// authenticate 处理登录/注册消息，返回认证通过的玩家
+func (s *Server) authenticate(msg *protocol.Message) (*Player, error) {
+	if msg.Type == protocol.MsgRegister {
+		var data protocol.RegisterData
+		if err := msg.UnmarshalData(&data); err != nil {
+			return nil, err
+		}
+
+		if _, err := s.checkUsername(data.Username, ""); err != nil {
+			return nil, err
+		}
+
+		account, err := s.accounts.Register(data.Username, data.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		s.logger.Info("account registered", "accountID", account.ID, "username", account.Username)
+		return NewAccountPlayer(account, nil), nil
 	}
 
-	// 从房间中移除
-	if player.RoomID != "" {
-		if room := s.rooms[player.RoomID]; room != nil {
-			room.RemovePlayer(playerID)
+	var data protocol.LoginData
+	if err := msg.UnmarshalData(&data); err != nil {
+		return nil, err
+	}
 
-			// 通知房间内其他玩家
-			leftMsg, _ := protocol.NewMessage(protocol.MsgPlayerLeft, protocol.PlayerLeftData{
-				PlayerID: playerID,
-			})
-			room.BroadcastMessage(leftMsg)
+	// 携带会话令牌的是断线重连
+	if data.SessionToken != "" {
+		return s.sessionPlayer(data.SessionToken)
+	}
+
+	// 未提供密码时以游客身份登录，但不能冒用已注册的用户名
+	var player *Player
+	if data.Password == "" {
+		username, err := s.checkGuestUsername(data.Username, "")
+		if err != nil {
+			return nil, err
 		}
+		player = NewPlayer(username, nil)
+	} else {
+		account, err := s.accounts.Authenticate(data.Username, data.Password)
+		if err != nil {
+			return nil, err
+		}
+		player = NewAccountPlayer(account, nil)
+	}
+
+	// 登录时带上的资料覆盖账号保存的资料
+	if data.Profile != nil {
+		if err := s.updateProfile(player, *data.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	return player, nil
+}
+
+// RemovePlayer 移除断线的玩家，对局中的玩家先保留座位等待重连
+func (s *Server) RemovePlayer(playerID string) {
+	player := s.GetPlayer(playerID)
+	if player == nil || s.suspendPlayer(player) {
+		return
 	}
 
+	s.mu.Lock()
 	delete(s.players, playerID)
+	delete(s.sessions, player.SessionToken)
 	s.mu.Unlock()
 
+	s.stopSpectating(player)
+
+	// 断线等同于离开房间，对局中按弃局处理
+	if player.RoomID != "" {
+		s.leaveRoom(player)
+	}
+
 	s.logger.Info("player removed", "playerID", playerID)
 }
 
+// Serve 在监听器上接受连接，直到 Shutdown 被调用；只支持 TCP 监听器
+func (s *Server) Serve(ln net.Listener) error {
+	s.listener = ln
+	s.serving.Store(true)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.closing.Load() {
+				return nil
+			}
+			return err
+		}
+
+		// socket 库只支持 TCP 连接，unix socket 等其他监听器的连接无法服务
+		tcpConn, ok := conn.(*net.TCPConn)
+		if !ok {
+			s.logger.Error("unsupported connection type, only TCP is supported", "addr", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		go s.HandleConnection(tcpConn)
+	}
+}
+
+// Shutdown 停止接受连接，通知所有玩家，并把未结束的房间保存到快照存储
+func (s *Server) Shutdown() error {
+	s.closing.Store(true)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	shutdownMsg, _ := protocol.NewMessage(protocol.MsgServerShutdown, protocol.ServerShutdownData{
+		Message:   "服务器正在关闭",
+		CanResume: s.snapshots != nil,
+	})
+
+	s.mu.RLock()
+	players := make([]*Player, 0, len(s.players))
+	for _, player := range s.players {
+		player.SendMessage(shutdownMsg)
+		players = append(players, player)
+	}
+	s.mu.RUnlock()
+
+	// 等待关闭通知写出，避免进程退出时还留在发送队列中
+	deadline := time.Now().Add(shutdownFlushTimeout)
+	for _, player := range players {
+		if queue := player.queue(); queue != nil {
+			queue.flush(deadline)
+		}
+	}
+
+	if s.snapshots == nil {
+		return nil
+	}
+
+	saved, err := s.SaveRooms()
+	s.logger.Info("rooms saved", "store", s.snapshots, "count", saved)
+	return err
+}
+
+// login 认证登录/注册消息，把玩家接到连接和发送队列上并回复 LOGIN_SUCCESS
+//
+// 宽限期内重连或服务器重启前在房间中的玩家会接回原来的座位。TCP 连接和 gRPC 会话共用，
+// gRPC 会话没有 socket 连接，conn 为 nil。
+func (s *Server) login(msg *protocol.Message, conn *socket.Conn, queue *sendQueue, protocolVersion int) (*Player, error) {
+	authed, err := s.authenticate(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	player := authed
+	var resumeRoom *Room
+	reconnected := false
+	if suspended := s.resumeSession(player.ID, conn, queue); suspended != nil {
+		// 宽限期内重连，接回原来的座位
+		player, resumeRoom = suspended, s.GetRoom(suspended.RoomID)
+		reconnected = true
+		player.ProtocolVersion = protocolVersion
+	} else {
+		// 服务器重启前在房间中的玩家，接管恢复出来的占位
+		if room, placeholder := s.findRestoredPlayer(player.ID); room != nil {
+			resumeRoom, player = room, placeholder
+		}
+
+		// 认证时还没有连接，此时才接上
+		player.attach(conn, queue)
+		player.ProtocolVersion = protocolVersion
+		if err := s.AddPlayer(player); err != nil {
+			return nil, err
+		}
+	}
+
+	// 接回的座位沿用这次登录的资料，登录时可能刚更新过
+	player.Profile = authed.Profile
+
+	// 发送登录成功消息
+	respMsg, _ := protocol.NewMessage(protocol.MsgLoginSuccess, protocol.LoginSuccessData{
+		PlayerID: player.ID,
+		Username: player.Username,
+		IsGuest:  player.IsGuest,
+
+		SessionToken: player.SessionToken,
+
+		Profile: player.publicProfile(),
+	})
+	player.SendMessage(respMsg.ReplyTo(msg))
+	s.publishSession(player.SessionToken)
+
+	if resumeRoom != nil {
+		s.logger.Info("player resumed room", "playerID", player.ID, "roomID", resumeRoom.ID)
+		resumeRoom.resumePlayer(player)
+		resumeRoom.broadcastConnection(player.ID, true, 0)
+		if !reconnected {
+			s.rejoinRestored(resumeRoom, player)
+		}
+	}
+
+	return player, nil
+}
+
 // HandleConnection 处理客户端连接
 func (s *Server) HandleConnection(conn *net.TCPConn) {
 	connID := atomic.AddInt64(&s.connID, 1)
@@ -116,6 +401,14 @@ func (s *Server) HandleConnection(conn *net.TCPConn) {
 	// 创建临时玩家（等待登录）
 	tempPlayerID := ""
 	var socketConn *socket.Conn
+	var queue *sendQueue
+
+	// 登录前没有收到 HELLO 的是旧客户端，按兼容模式服务
+	protocolVersion := legacyProtocolVersion
+	greeted := false
+
+	var hb heartbeat
+	hb.touch()
 
 	// 配置连接选项
 	codecOption := socket.CustomCodecOption(protocol.NewCodec())
@@ -129,54 +422,79 @@ func (s *Server) HandleConnection(conn *net.TCPConn) {
 
 	onMessageOption := socket.OnMessageOption(func(m socket.Message) error {
 		msg := m.(*protocol.Message)
+		hb.touch()
+
+		// 心跳不经过序号和去重，直接回复
+		if msg.Type == protocol.MsgPing {
+			hb.enabled.Store(true)
+			return socketConn.Write(pong(msg))
+		}
 
-		// 如果是登录消息，创建玩家
-		if msg.Type == protocol.MsgLogin {
-			var loginData protocol.LoginData
-			if err := msg.UnmarshalData(&loginData); err != nil {
+		// 握手：协商协议版本
+		if msg.Type == protocol.MsgHello && tempPlayerID == "" {
+			var data protocol.HelloData
+			if err := msg.UnmarshalData(&data); err != nil {
 				return err
 			}
 
-			// 创建玩家（先不设置Conn，因为socketConn还未初始化）
-			player := NewPlayer(loginData.Username, nil)
-			tempPlayerID = player.ID
+			protocolVersion = negotiateVersion(data.ProtocolVersion)
+			greeted = true
 
-			// 在添加到服务器后，立即更新Conn（此时socketConn已经有值了）
-			player.Conn = socketConn
-			s.AddPlayer(player)
+			s.logger.Info("client hello",
+				"connID", connID,
+				"client", data.Client,
+				"clientVersion", data.ProtocolVersion,
+				"protocolVersion", protocolVersion)
 
-			// 发送登录成功消息
-			respMsg, _ := protocol.NewMessage(protocol.MsgLoginSuccess, protocol.LoginSuccessData{
-				PlayerID: player.ID,
+			helloMsg, _ := protocol.NewMessage(protocol.MsgHello, protocol.HelloData{
+				ProtocolVersion: protocolVersion,
 			})
+			return socketConn.Write(helloMsg.ReplyTo(msg))
+		}
+
+		// 如果是登录/注册消息，认证并创建玩家
+		if (msg.Type == protocol.MsgLogin || msg.Type == protocol.MsgRegister) && tempPlayerID == "" {
+			player, err := s.login(msg, socketConn, queue, protocolVersion)
+			if err != nil {
+				s.logger.Warn("login failed", "connID", connID, "type", msg.Type, "error", err)
+				return socketConn.Write(errorMessage(err).ReplyTo(msg))
+			}
+
+			tempPlayerID = player.ID
+
+			if !greeted {
+				s.logger.Warn("client did not send hello, serving legacy protocol",
+					"connID", connID,
+					"playerID", player.ID)
+			}
 
-			return socketConn.Write(respMsg)
+			return nil
 		}
 
 		// 处理其他消息
 		if tempPlayerID == "" {
 			errMsg, _ := protocol.NewErrorMessage("please login first")
-			socketConn.Write(errMsg)
+			socketConn.Write(errMsg.ReplyTo(msg))
 			return nil
 		}
 
-		// 委托给消息处理器
-		if err := s.handler.HandleMessage(tempPlayerID, msg); err != nil {
-			s.logger.Error("handle message error",
+		// 客户端重试的请求只处理一次，响应可通过重发找回
+		if player := s.GetPlayer(tempPlayerID); player != nil && player.outbox.seen(msg.ID) {
+			s.logger.Info("duplicate request ignored",
 				"playerID", tempPlayerID,
 				"type", msg.Type,
-				"error", err)
+				"messageID", msg.ID)
+			return nil
+		}
 
+		// 委托给消息处理器，出错时由处理器记录日志
+		if err := s.handler.HandleMessage(tempPlayerID, msg); err != nil {
 			// 发送错误消息
-			errMsg, _ := protocol.NewErrorMessage(err.Error())
 			if player := s.GetPlayer(tempPlayerID); player != nil {
-				player.SendMessage(errMsg)
+				player.SendMessage(errorMessage(err).ReplyTo(msg))
 			}
 		}
 
-		// 给 writeLoop 一点时间发送响应消息
-		time.Sleep(500 * time.Millisecond)
-
 		return nil
 	})
 
@@ -189,8 +507,37 @@ func (s *Server) HandleConnection(conn *net.TCPConn) {
 		return
 	}
 
+	// 同一 IP 的连接数超过上限时告知原因后断开
+	release, err := s.acquireConn(conn.RemoteAddr())
+	if err != nil {
+		s.logger.Warn("connection refused", "connID", connID, "addr", conn.RemoteAddr(), "error", err)
+		socketConn.WriteDirect(errorMessage(err))
+		conn.Close()
+		return
+	}
+	defer release()
+
+	// 登录后发给玩家的消息经发送队列写出，读得慢的客户端不会拖住房间广播
+	queue = newSendQueue(socketConn.WriteDirect, s.config.SendOverflow, func() { conn.Close() }, s.metrics,
+		s.logger.With("connID", connID))
+	defer queue.stop()
+
+	// 心跳超时的连接直接断开，之后按断线处理（对局中进入重连宽限期）
+	done := make(chan struct{})
+	if s.config.HeartbeatTimeout > 0 {
+		go hb.watch(s.config.HeartbeatTimeout, done, func() {
+			s.logger.Warn("heartbeat timeout, closing connection",
+				"connID", connID,
+				"playerID", tempPlayerID,
+				"idle", hb.idle())
+			conn.Close()
+		})
+	}
+
 	// 运行连接（阻塞直到连接关闭）
-	if err := socketConn.Run(context.Background()); err != nil {
+	err = socketConn.Run(context.Background())
+	close(done)
+	if err != nil {
 		s.logger.Error("connection run error", "error", err)
 	}
 
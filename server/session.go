@@ -0,0 +1,108 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/pkg/errors"
+)
+
+// sessionPlayer 根据会话令牌找到等待重连的玩家
+func (s *Server) sessionPlayer(token string) (*Player, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	playerID, exists := s.sessions[token]
+	if !exists {
+		return nil, errors.New("session expired")
+	}
+
+	player := s.players[playerID]
+	if player == nil {
+		return nil, errors.New("session expired")
+	}
+
+	return player, nil
+}
+
+// suspendPlayer 对局中断线的玩家保留座位，等待在宽限期内重连
+//
+// 玩家仍留在服务器和房间的玩家表中，只是不再关联连接；宽限期结束仍未重连时按离开房间处理。
+// 返回 false 表示不满足保留条件，调用方应直接移除玩家。
+func (s *Server) suspendPlayer(player *Player) bool {
+	if s.config.ReconnectGrace <= 0 || player.RoomID == "" {
+		return false
+	}
+
+	room := s.GetRoom(player.RoomID)
+	if room == nil || !room.State.inGame() || room.isAbandoned(player.ID) {
+		return false
+	}
+
+	s.mu.Lock()
+	player.detach()
+	s.graceTimers[player.ID] = time.AfterFunc(s.config.ReconnectGrace, func() {
+		s.expireSession(player.ID)
+	})
+	s.mu.Unlock()
+
+	room.broadcastConnection(player.ID, false, s.config.ReconnectGrace)
+
+	s.logger.Info("player disconnected, waiting for reconnect",
+		"playerID", player.ID,
+		"roomID", room.ID,
+		"grace", s.config.ReconnectGrace)
+
+	return true
+}
+
+// broadcastConnection 告诉房间里的人某名玩家断线或重连，grace 为断线时等待重连的宽限期
+func (r *Room) broadcastConnection(playerID string, connected bool, grace time.Duration) {
+	msg, _ := protocol.NewMessage(protocol.MsgPlayerConnection, protocol.PlayerConnectionData{
+		PlayerID:     playerID,
+		IsConnected:  connected,
+		GraceSeconds: int(grace.Seconds()),
+	})
+	r.BroadcastMessage(msg)
+}
+
+// resumeSession 把新连接接到等待重连的玩家上，玩家不在等待中时返回 nil
+func (s *Server) resumeSession(playerID string, conn *socket.Conn, queue *sendQueue) *Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timer, waiting := s.graceTimers[playerID]
+	if !waiting {
+		return nil
+	}
+	timer.Stop()
+	delete(s.graceTimers, playerID)
+
+	player := s.players[playerID]
+	player.outbox.reset()
+	player.attach(conn, queue)
+
+	return player
+}
+
+// expireSession 宽限期结束仍未重连，按离开房间处理
+func (s *Server) expireSession(playerID string) {
+	s.mu.Lock()
+	if _, waiting := s.graceTimers[playerID]; !waiting {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.graceTimers, playerID)
+
+	player := s.players[playerID]
+	delete(s.players, playerID)
+	delete(s.sessions, player.SessionToken)
+	s.mu.Unlock()
+
+	s.logger.Info("reconnect grace expired", "playerID", playerID, "roomID", player.RoomID)
+
+	if player.RoomID != "" {
+		s.leaveRoom(player)
+	}
+}
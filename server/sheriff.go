@@ -0,0 +1,780 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+const (
+	sheriffSignupTimeout = 20 * time.Second // 报名上警
+	sheriffSpeechTimeout = 60 * time.Second // 每名候选人的竞选发言
+	sheriffVoteTimeout   = 30 * time.Second // 警下投票
+	sheriffBadgeTimeout  = 30 * time.Second // 警长出局后移交警徽
+)
+
+// sheriffState 警长竞选进度和警徽归属，由 r.mu 保护
+//
+// 竞选在首个白天开始时进行，期间白天讨论暂停；引擎不知道警长的存在，
+// 竞选和警徽都由房间维护，警长对发言顺序的影响见 speakingOrder。
+type sheriffState struct {
+	stage      string
+	id         string             // 当前警长
+	candidates []string           // 仍在竞选的玩家，按座位顺序
+	declared   map[string]bool    // 报名阶段的表态，true 为上警；上过警的玩家不能投票
+	speaker    int                // 正在发言的候选人下标
+	votes      map[string]string  // 投票人 -> 候选人
+	tally      map[string]float64 // 最近一次竞选计票，随下一条状态消息发出
+	voter      string             // 本轮放逐投票开始时的警长
+	settled    int                // 最近一次由房间加权结算的放逐投票回合
+	timer      *time.Timer
+	deadline   time.Time // 当前阶段的截止时间
+	epoch      int       // 每次设置计时器加一，过期的计时器据此失效
+}
+
+// running 竞选是否正在进行
+func (s *sheriffState) running() bool {
+	switch s.stage {
+	case protocol.SheriffStageSignup, protocol.SheriffStageSpeech, protocol.SheriffStageVote:
+		return true
+	}
+	return false
+}
+
+// alivePlayers 仍在对局中的存活玩家
+func (r *Room) alivePlayers() []string {
+	return r.activePlayers(r.Engine().GetState().AlivePlayers)
+}
+
+// electionRunning 警长竞选是否正在进行
+func (r *Room) electionRunning() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sheriff.running()
+}
+
+// dayHeld 白天讨论是否由竞选或发言顺序接管，此时由房间安排机器人发言
+func (r *Room) dayHeld() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sheriff.running() || r.speaking.active()
+}
+
+// startDay 进入白天：开启警长规则时首个白天先竞选，否则直接按顺序发言
+func (r *Room) startDay() {
+	r.mu.RLock()
+	election := r.rules.Sheriff && r.sheriff.stage == ""
+	r.mu.RUnlock()
+
+	if election {
+		r.startSheriffElection()
+		return
+	}
+
+	r.startSpeakingOrder()
+}
+
+// beginDay 竞选结束后开始白天讨论
+func (r *Room) beginDay() {
+	r.SendGameState()
+	r.pushAllowedSkills()
+
+	if !r.startSpeakingOrder() {
+		state := r.Engine().GetState()
+		r.driveBots(state.Phase, state.Round)
+	}
+}
+
+// startSheriffElection 开始报名上警
+func (r *Room) startSheriffElection() {
+	r.mu.Lock()
+	r.sheriff = sheriffState{
+		stage:    protocol.SheriffStageSignup,
+		declared: make(map[string]bool),
+	}
+	r.setSheriffTimer(sheriffSignupTimeout)
+	msg := r.sheriffMessage(fmt.Sprintf("警长竞选开始，请在 %d 秒内决定是否上警", int(r.scaled(sheriffSignupTimeout).Seconds())))
+	r.mu.Unlock()
+
+	r.BroadcastMessage(msg)
+}
+
+// declareSheriff 报名阶段上警或放弃，发言阶段的候选人可以退水
+func (r *Room) declareSheriff(playerID string, run bool) error {
+	alive := r.alivePlayers()
+
+	r.mu.Lock()
+	s := &r.sheriff
+	var msgs []*protocol.Message
+
+	switch s.stage {
+	case protocol.SheriffStageSignup:
+		if !slices.Contains(alive, playerID) {
+			r.mu.Unlock()
+			return errors.New("only alive players can run for sheriff")
+		}
+
+		s.declared[playerID] = run
+		s.candidates = slices.DeleteFunc(s.candidates, func(id string) bool { return id == playerID })
+		if run {
+			s.candidates = r.sortBySeat(append(s.candidates, playerID))
+			msgs = append(msgs, r.sheriffMessage(fmt.Sprintf("%s 上警", r.seatLabel(playerID))))
+		}
+
+		if r.everyoneDeclared(alive) {
+			msgs = append(msgs, r.endSheriffSignup()...)
+		}
+	case protocol.SheriffStageSpeech:
+		if run {
+			r.mu.Unlock()
+			return errors.New("sheriff signup is closed")
+		}
+
+		i := slices.Index(s.candidates, playerID)
+		if i < 0 {
+			r.mu.Unlock()
+			return errors.New("you are not a sheriff candidate")
+		}
+
+		s.candidates = slices.Delete(s.candidates, i, i+1)
+		if i < s.speaker {
+			s.speaker--
+		}
+
+		msgs = append(msgs, r.sheriffMessage(fmt.Sprintf("%s 退水", r.seatLabel(playerID))))
+		msgs = append(msgs, r.continueSheriffSpeeches(alive)...)
+	default:
+		r.mu.Unlock()
+		return errors.New("sheriff signup is closed")
+	}
+
+	finished := s.stage == protocol.SheriffStageDone
+	r.mu.Unlock()
+
+	r.finishSheriffStep(msgs, finished)
+	return nil
+}
+
+// sheriffSpeech 轮到的候选人发表竞选发言
+func (r *Room) sheriffSpeech(playerID, content string) error {
+	if strings.TrimSpace(content) == "" {
+		return errors.New("speech is empty")
+	}
+
+	alive := r.alivePlayers()
+
+	r.mu.Lock()
+	s := &r.sheriff
+	if s.stage != protocol.SheriffStageSpeech || s.speaker >= len(s.candidates) || s.candidates[s.speaker] != playerID {
+		r.mu.Unlock()
+		return errors.New("it is not your turn to give a sheriff speech")
+	}
+
+	speech, _ := protocol.NewMessage(protocol.MsgSheriffSpeech, protocol.SheriffSpeechData{
+		PlayerID: playerID,
+		Content:  content,
+	})
+	s.speaker++
+	msgs := append([]*protocol.Message{speech}, r.continueSheriffSpeeches(alive)...)
+
+	finished := s.stage == protocol.SheriffStageDone
+	r.mu.Unlock()
+
+	r.finishSheriffStep(msgs, finished)
+	return nil
+}
+
+// sheriffVote 警下玩家投票，全部投完后立即计票
+func (r *Room) sheriffVote(playerID, targetID string) error {
+	alive := r.alivePlayers()
+
+	r.mu.Lock()
+	s := &r.sheriff
+	switch {
+	case s.stage != protocol.SheriffStageVote:
+		r.mu.Unlock()
+		return errors.New("sheriff voting is not open")
+	case !slices.Contains(alive, playerID) || s.declared[playerID]:
+		r.mu.Unlock()
+		return errors.New("only alive players who did not run can vote for sheriff")
+	case !slices.Contains(s.candidates, targetID):
+		r.mu.Unlock()
+		return errors.New("target is not a sheriff candidate")
+	}
+
+	if _, voted := s.votes[playerID]; voted {
+		r.mu.Unlock()
+		return errors.New("already voted for sheriff")
+	}
+	s.votes[playerID] = targetID
+
+	var msgs []*protocol.Message
+	if r.sheriffVotesComplete(alive) {
+		msgs = r.countSheriffVotes()
+	}
+
+	finished := s.stage == protocol.SheriffStageDone
+	r.mu.Unlock()
+
+	r.finishSheriffStep(msgs, finished)
+	return nil
+}
+
+// sheriffTimeout 当前阶段超时：报名截止、跳过发言者、按已投的票计票或撕毁警徽
+func (r *Room) sheriffTimeout(epoch int) {
+	alive := r.alivePlayers()
+
+	r.mu.Lock()
+	s := &r.sheriff
+	if epoch != s.epoch || r.State.ended() {
+		r.mu.Unlock()
+		return
+	}
+
+	wasRunning := s.running()
+
+	var msgs []*protocol.Message
+	switch s.stage {
+	case protocol.SheriffStageSignup:
+		msgs = r.endSheriffSignup()
+	case protocol.SheriffStageSpeech:
+		s.speaker++
+		msgs = r.continueSheriffSpeeches(alive)
+	case protocol.SheriffStageVote:
+		msgs = r.countSheriffVotes()
+	case protocol.SheriffStageBadge:
+		msgs = r.passBadge("", fmt.Sprintf("警长 %s 没有移交警徽，警徽流失", r.seatLabel(s.id)))
+	}
+
+	finished := wasRunning && s.stage == protocol.SheriffStageDone
+	r.mu.Unlock()
+
+	r.finishSheriffStep(msgs, finished)
+	if !wasRunning {
+		r.SendGameState()
+	}
+}
+
+// finishSheriffStep 广播竞选进度，竞选刚结束时开始白天讨论
+func (r *Room) finishSheriffStep(msgs []*protocol.Message, finished bool) {
+	for _, msg := range msgs {
+		r.BroadcastMessage(msg)
+	}
+
+	if finished {
+		r.beginDay()
+	}
+}
+
+// endSheriffSignup 报名截止，没有或只有一名候选人时直接出结果（需持有锁）
+func (r *Room) endSheriffSignup() []*protocol.Message {
+	s := &r.sheriff
+
+	switch len(s.candidates) {
+	case 0:
+		return r.finishElection("", "无人上警，本局没有警长")
+	case 1:
+		return r.finishElection(s.candidates[0], fmt.Sprintf("只有 %s 上警，自动当选警长", r.seatLabel(s.candidates[0])))
+	}
+
+	s.stage = protocol.SheriffStageSpeech
+	s.speaker = 0
+	r.setSheriffTimer(sheriffSpeechTimeout)
+
+	return []*protocol.Message{r.sheriffMessage(fmt.Sprintf("上警玩家依次发言，请 %s 发言", r.seatLabel(s.candidates[0])))}
+}
+
+// continueSheriffSpeeches 请下一名候选人发言，都发言完后开始投票（需持有锁）
+func (r *Room) continueSheriffSpeeches(alive []string) []*protocol.Message {
+	s := &r.sheriff
+
+	switch {
+	case len(s.candidates) == 0:
+		return r.finishElection("", "候选人全部退水，本局没有警长")
+	case len(s.candidates) == 1:
+		return r.finishElection(s.candidates[0], fmt.Sprintf("其他候选人已退水，%s 当选警长", r.seatLabel(s.candidates[0])))
+	case s.speaker >= len(s.candidates):
+		return r.startSheriffVote(alive)
+	}
+
+	r.setSheriffTimer(sheriffSpeechTimeout)
+
+	return []*protocol.Message{r.sheriffMessage(fmt.Sprintf("请 %s 发言", r.seatLabel(s.candidates[s.speaker])))}
+}
+
+// startSheriffVote 开始警下投票，机器人随机投给一名候选人（需持有锁）
+func (r *Room) startSheriffVote(alive []string) []*protocol.Message {
+	s := &r.sheriff
+	s.stage = protocol.SheriffStageVote
+	s.votes = make(map[string]string)
+
+	for _, playerID := range alive {
+		if r.bots[playerID] != nil && !s.declared[playerID] {
+			s.votes[playerID] = s.candidates[rand.Intn(len(s.candidates))]
+		}
+	}
+
+	if r.sheriffVotesComplete(alive) {
+		return r.countSheriffVotes()
+	}
+
+	r.setSheriffTimer(sheriffVoteTimeout)
+
+	return []*protocol.Message{r.sheriffMessage("竞选发言结束，请警下玩家投票")}
+}
+
+// countSheriffVotes 计票，得票最多者当选，平票时警徽流失（需持有锁）
+func (r *Room) countSheriffVotes() []*protocol.Message {
+	s := &r.sheriff
+
+	tally := make(map[string]float64, len(s.candidates))
+	for _, candidate := range s.candidates {
+		tally[candidate] = 0
+	}
+	for _, candidate := range s.votes {
+		tally[candidate]++
+	}
+	s.tally = tally
+
+	winner, top, tie := "", -1.0, false
+	for _, candidate := range s.candidates {
+		switch {
+		case tally[candidate] > top:
+			winner, top, tie = candidate, tally[candidate], false
+		case tally[candidate] == top:
+			tie = true
+		}
+	}
+
+	if tie {
+		return r.finishElection("", "竞选投票平票，警徽流失")
+	}
+
+	return r.finishElection(winner, fmt.Sprintf("%s 以 %g 票当选警长", r.seatLabel(winner), top))
+}
+
+// finishElection 结束竞选（需持有锁）
+func (r *Room) finishElection(sheriffID, message string) []*protocol.Message {
+	s := &r.sheriff
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	s.stage = protocol.SheriffStageDone
+	s.id = sheriffID
+	msg := r.sheriffMessage(message)
+	s.tally = nil
+
+	r.logger.Info("sheriff elected", "sheriffID", sheriffID)
+
+	return []*protocol.Message{msg}
+}
+
+// everyoneDeclared 所有存活的真人玩家都已表态（需持有锁）
+func (r *Room) everyoneDeclared(alive []string) bool {
+	for _, playerID := range alive {
+		if _, ok := r.sheriff.declared[playerID]; !ok && r.bots[playerID] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// sheriffVotesComplete 所有有投票权的玩家都已投票（需持有锁）
+func (r *Room) sheriffVotesComplete(alive []string) bool {
+	s := &r.sheriff
+	for _, playerID := range alive {
+		if s.declared[playerID] {
+			continue
+		}
+		if _, voted := s.votes[playerID]; !voted {
+			return false
+		}
+	}
+	return true
+}
+
+// sheriffDied 警长出局后等待其移交警徽，机器人警长随机移交给一名存活玩家
+func (r *Room) sheriffDied(playerID string) {
+	r.mu.Lock()
+	s := &r.sheriff
+	if s.id == "" || s.id != playerID {
+		r.mu.Unlock()
+		return
+	}
+
+	s.stage = protocol.SheriffStageBadge
+	r.setSheriffTimer(sheriffBadgeTimeout)
+	msg := r.sheriffMessage(fmt.Sprintf("警长 %s 出局，请移交或撕毁警徽", r.seatLabel(playerID)))
+	isBot := r.bots[playerID] != nil
+	r.mu.Unlock()
+
+	r.BroadcastMessage(msg)
+
+	if isBot {
+		r.spawn(func() {
+			time.Sleep(botMinDelay)
+
+			alive := r.alivePlayers()
+			if len(alive) == 0 {
+				return
+			}
+			if err := r.PassBadge(playerID, alive[rand.Intn(len(alive))]); err != nil {
+				r.playerLogger(playerID).Debug("bot badge pass rejected", "error", err)
+			}
+		})
+	}
+}
+
+// PassBadge 出局的警长把警徽移交给存活玩家，targetID 为空时撕毁警徽
+func (r *Room) PassBadge(playerID, targetID string) error {
+	alive := r.alivePlayers()
+
+	r.mu.Lock()
+	s := &r.sheriff
+	if s.stage != protocol.SheriffStageBadge || s.id != playerID {
+		r.mu.Unlock()
+		return errors.New("you have no sheriff badge to pass")
+	}
+	if targetID != "" && !slices.Contains(alive, targetID) {
+		r.mu.Unlock()
+		return errors.New("the badge can only go to an alive player")
+	}
+
+	message := fmt.Sprintf("警长 %s 撕毁了警徽", r.seatLabel(playerID))
+	if targetID != "" {
+		message = fmt.Sprintf("警长 %s 把警徽移交给 %s", r.seatLabel(playerID), r.seatLabel(targetID))
+	}
+	msgs := r.passBadge(targetID, message)
+	r.mu.Unlock()
+
+	r.finishSheriffStep(msgs, false)
+	r.SendGameState()
+
+	return nil
+}
+
+// passBadge 警徽交给新警长或流失（需持有锁）
+func (r *Room) passBadge(targetID, message string) []*protocol.Message {
+	s := &r.sheriff
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	s.stage = protocol.SheriffStageDone
+	s.id = targetID
+
+	r.logger.Info("sheriff badge passed", "sheriffID", targetID)
+
+	return []*protocol.Message{r.sheriffMessage(message)}
+}
+
+// setSheriffTimer 为当前阶段设置超时，按房间的对局节奏缩放（需持有锁）
+func (r *Room) setSheriffTimer(d time.Duration) {
+	d = r.scaled(d)
+	s := &r.sheriff
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	s.epoch++
+	epoch := s.epoch
+	s.timer = time.AfterFunc(d, func() { r.sheriffTimeout(epoch) })
+	s.deadline = time.Now().Add(d)
+}
+
+// sheriffMessage 生成竞选状态消息（需持有锁）
+func (r *Room) sheriffMessage(message string) *protocol.Message {
+	s := &r.sheriff
+
+	data := protocol.SheriffStateData{
+		Stage:      s.stage,
+		Candidates: append([]string(nil), s.candidates...),
+		SheriffID:  s.id,
+		Tally:      s.tally,
+		Message:    message,
+	}
+	if s.stage == protocol.SheriffStageSpeech && s.speaker < len(s.candidates) {
+		data.Speaker = s.candidates[s.speaker]
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgSheriffState, data)
+	return msg
+}
+
+// startVoteRound 放逐投票开始时记下警长，计票时警长的一票按 SheriffVoteWeight 计
+//
+// 加权结算要替出局者和平票时的所有人交弃权票，引擎不接受空目标时不加权，警长的票按一票交给引擎。
+func (r *Room) startVoteRound() {
+	weighted := engine.CanAbstain(r.Engine())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sheriff.voter = ""
+	if r.sheriff.stage == protocol.SheriffStageDone {
+		if !weighted {
+			r.logger.Warn("rules engine does not accept abstentions, sheriff vote counts as one")
+			return
+		}
+		r.sheriff.voter = r.sheriff.id
+	}
+}
+
+// announceVoteTally 放逐投票结束后公布计票，警长的一票计 1.5 票，与 settleHeldVotes 的结算一致
+func (r *Room) announceVoteTally(round int) {
+	r.mu.RLock()
+	if !r.rules.Sheriff {
+		r.mu.RUnlock()
+		return
+	}
+
+	tally := weightedTally(r.ballots(round), r.sheriff.voter)
+
+	targets := make([]string, 0, len(tally))
+	for target := range tally {
+		targets = append(targets, target)
+	}
+	targets = r.sortBySeat(targets)
+	sort.SliceStable(targets, func(i, j int) bool { return tally[targets[i]] > tally[targets[j]] })
+
+	parts := make([]string, 0, len(targets))
+	for _, target := range targets {
+		parts = append(parts, fmt.Sprintf("%s %g 票", r.seatLabel(target), tally[target]))
+	}
+	r.mu.RUnlock()
+
+	if len(parts) == 0 {
+		return
+	}
+
+	r.announce("放逐投票结果（警长一票计 1.5 票）：" + strings.Join(parts, "，"))
+}
+
+// holdsVotes 当前的放逐投票是否由房间按警长加权计票
+//
+// 引擎按一人一票结算，计不了半票。本轮开始时有警长的放逐投票，玩家的票先只记在房间，
+// 全部存活玩家投完后由 settleHeldVotes 按加权票数决定放逐谁，再替每人向引擎交票，引擎据此放逐同一人。
+func (r *Room) holdsVotes() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sheriff.voter != "" && r.Engine().GetState().Phase == werewolf.PhaseVote
+}
+
+// checkHeldVote 只记在房间的票同样按引擎的规则检查：投票人和目标都要存活，空目标为弃权
+func (r *Room) checkHeldVote(playerID, targetID string, round int) error {
+	alive := r.Engine().GetState().AlivePlayers
+
+	r.mu.RLock()
+	settled := r.sheriff.settled == round
+	r.mu.RUnlock()
+
+	switch {
+	case settled:
+		return errors.New("exile voting has closed")
+	case !slices.Contains(alive, playerID):
+		return errors.New("player is not alive")
+	case targetID != "" && !slices.Contains(alive, targetID):
+		return errors.New("target is not alive")
+	}
+	return nil
+}
+
+// settleHeldVotes 存活玩家都投完后按加权票数决定放逐谁，替每人向引擎交上同一张票
+//
+// 最高票唯一时其余人都投给他、他本人弃权；平票或全部弃权时所有人弃权，无人出局。
+// 交给引擎的票记为 Settled，玩家本人的票仍以 Held 的记录为准。
+func (r *Room) settleHeldVotes(round int) {
+	alive := r.Engine().GetState().AlivePlayers
+
+	r.mu.Lock()
+	ballots := r.ballots(round)
+	for _, playerID := range alive {
+		if _, voted := ballots[playerID]; !voted {
+			r.mu.Unlock()
+			return
+		}
+	}
+	if r.sheriff.settled == round {
+		r.mu.Unlock()
+		return
+	}
+	r.sheriff.settled = round
+	exiled := topVoted(weightedTally(ballots, r.sheriff.voter))
+	r.mu.Unlock()
+
+	r.logger.Info("exile vote settled", "round", round, "exiled", exiled)
+
+	for _, playerID := range alive {
+		targetID := exiled
+		if playerID == exiled {
+			targetID = ""
+		}
+
+		if err := callEngine("settled vote", func() error {
+			return r.Engine().PerformAction(playerID, protocol.ActionVote, targetID, nil)
+		}); err != nil {
+			r.playerLogger(playerID).Error("settled vote rejected", "error", err)
+			continue
+		}
+
+		r.mu.Lock()
+		r.actions = append(r.actions, ActionRecord{
+			PlayerID:   playerID,
+			ActionType: protocol.ActionVote,
+			TargetID:   targetID,
+			Round:      round,
+			Settled:    true,
+		})
+		r.mu.Unlock()
+	}
+}
+
+// weightedTally 放逐投票的票数，sheriffID 的一票按 SheriffVoteWeight 计，弃权不计
+func weightedTally(ballots map[string]string, sheriffID string) map[string]float64 {
+	tally := make(map[string]float64)
+	for voter, target := range ballots {
+		if target == "" {
+			continue
+		}
+		if voter == sheriffID {
+			tally[target] += protocol.SheriffVoteWeight
+		} else {
+			tally[target]++
+		}
+	}
+	return tally
+}
+
+// topVoted 票数唯一最高的玩家，平票或没有人得票时为空
+func topVoted(tally map[string]float64) string {
+	top, tie := "", false
+	for target, votes := range tally {
+		switch {
+		case top == "" || votes > tally[top]:
+			top, tie = target, false
+		case votes == tally[top]:
+			tie = true
+		}
+	}
+
+	if tie {
+		return ""
+	}
+	return top
+}
+
+// sheriffRoom 查找玩家所在的已开局且开启了警长规则的房间
+func (h *MessageHandler) sheriffRoom(playerID string) (*Room, error) {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return nil, errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return nil, errors.New("player not in room")
+	}
+
+	if room.Engine() == nil {
+		return nil, errors.New("game not started")
+	}
+
+	if room.isPaused() {
+		return nil, errors.New("game is paused")
+	}
+
+	room.mu.RLock()
+	enabled := room.rules.Sheriff
+	room.mu.RUnlock()
+
+	if !enabled {
+		return nil, errors.New("sheriff is not enabled in this room")
+	}
+
+	return room, nil
+}
+
+// handleSheriffRun 处理上警或退水
+func (h *MessageHandler) handleSheriffRun(playerID string, data *protocol.SheriffRunData) error {
+	room, err := h.sheriffRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	return room.declareSheriff(playerID, data.Run)
+}
+
+// handleSheriffSpeech 处理竞选发言
+func (h *MessageHandler) handleSheriffSpeech(playerID string, data *protocol.SheriffSpeechData) error {
+	room, err := h.sheriffRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	return room.sheriffSpeech(playerID, data.Content)
+}
+
+// handleSheriffVote 处理警长竞选投票，票在计票时统一公布，投票人只收到确认
+func (h *MessageHandler) handleSheriffVote(playerID string, data *protocol.SheriffTargetData) error {
+	room, err := h.sheriffRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	targetID, err := room.resolveTarget(data.TargetID, data.TargetSeat)
+	if err != nil {
+		return err
+	}
+
+	if err := room.sheriffVote(playerID, targetID); err != nil {
+		return err
+	}
+
+	resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, protocol.ActionResultData{
+		Success: true,
+		Message: "已投票，等待计票",
+	})
+	return h.server.GetPlayer(playerID).SendMessage(resultMsg.ReplyTo(h.request))
+}
+
+// handleSheriffPass 处理出局警长移交警徽
+func (h *MessageHandler) handleSheriffPass(playerID string, data *protocol.SheriffTargetData) error {
+	room, err := h.sheriffRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	targetID, err := room.resolveTarget(data.TargetID, data.TargetSeat)
+	if err != nil {
+		return err
+	}
+
+	return room.PassBadge(playerID, targetID)
+}
+
+// handleSpeakingOrder 处理警长选择发言方向
+func (h *MessageHandler) handleSpeakingOrder(playerID string, data *protocol.SpeakingOrderData) error {
+	room, err := h.sheriffRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	return room.SetSpeakingDirection(playerID, data.Clockwise)
+}
+
+// isSheriff 玩家是否是当前警长（需持有锁）
+func (r *Room) isSheriff(playerID string) bool {
+	return r.sheriff.id != "" && r.sheriff.id == playerID
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// noVote 投票表里表示弃权
+const noVote = -1
+
+// startSheriffGame 开启警长规则开局，第一夜守卫守住刀口、无人出局，等到竞选报名开始
+func (tt *testTable) startSheriffGame() *Room {
+	tt.t.Helper()
+
+	room := tt.startWith(protocol.CreateRoomData{
+		RoomName: "测试房间",
+		Roles:    fiveSeats,
+		Rules:    &protocol.GameRules{Sheriff: true},
+	})
+
+	wolf, seer, guard, villager := tt.players[0], tt.players[1], tt.players[2], tt.players[4]
+	tt.act(room, seer, protocol.ActionCheck, wolf)
+	tt.act(room, guard, protocol.ActionProtect, villager)
+	tt.act(room, wolf, protocol.ActionKill, villager)
+	tt.waitPhase(room, werewolf.PhaseDay, 1)
+	tt.eventually(func() bool {
+		stage, _ := sheriffOf(room)
+		return stage == protocol.SheriffStageSignup
+	}, "sheriff signup")
+
+	return room
+}
+
+// elect 按座位下标报名上警、依次发言并投票，votes 为投票人 -> 候选人
+func (tt *testTable) elect(room *Room, runners []int, votes map[int]int) {
+	tt.t.Helper()
+
+	for i, player := range tt.players {
+		tt.must(player, protocol.MsgSheriffRun, protocol.SheriffRunData{Run: slices.Contains(runners, i)})
+	}
+
+	for {
+		room.mu.RLock()
+		s := room.sheriff
+		room.mu.RUnlock()
+		if s.stage != protocol.SheriffStageSpeech {
+			break
+		}
+		tt.must(tt.server.GetPlayer(s.candidates[s.speaker]), protocol.MsgSheriffSpeech, protocol.SheriffSpeechData{Content: "请投我"})
+	}
+
+	for voter, candidate := range votes {
+		tt.must(tt.players[voter], protocol.MsgSheriffVote, protocol.SheriffTargetData{TargetID: tt.players[candidate].ID})
+	}
+}
+
+// sheriffOf 竞选阶段和当前警长
+func sheriffOf(room *Room) (string, string) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	return room.sheriff.stage, room.sheriff.id
+}
+
+// expireSheriffStep 让竞选的当前阶段立即超时
+func expireSheriffStep(room *Room) {
+	room.mu.RLock()
+	epoch := room.sheriff.epoch
+	room.mu.RUnlock()
+
+	room.sheriffTimeout(epoch)
+}
+
+func TestSheriffElection(t *testing.T) {
+	tests := []struct {
+		name    string
+		runners []int
+		votes   map[int]int
+		expire  bool // 投票截止时仍有人没投
+		want    int  // 当选者的座位下标，noVote 表示没有警长
+	}{
+		{name: "no candidates", want: noVote},
+		{name: "single candidate", runners: []int{2}, want: 2},
+		{name: "unique winner", runners: []int{0, 1}, votes: map[int]int{2: 1, 3: 1, 4: 0}, want: 1},
+		{name: "tied vote", runners: []int{0, 1, 2}, votes: map[int]int{3: 0, 4: 1}, want: noVote},
+		{name: "no votes cast", runners: []int{0, 1}, expire: true, want: noVote},
+		{name: "votes counted at the deadline", runners: []int{0, 1}, votes: map[int]int{2: 0}, expire: true, want: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			room := tt.startSheriffGame()
+
+			tt.elect(room, tc.runners, tc.votes)
+			if tc.expire {
+				if stage, _ := sheriffOf(room); stage != protocol.SheriffStageVote {
+					t.Fatalf("stage = %s before the deadline, want %s", stage, protocol.SheriffStageVote)
+				}
+				expireSheriffStep(room)
+			}
+
+			want := ""
+			if tc.want != noVote {
+				want = tt.players[tc.want].ID
+			}
+			stage, sheriffID := sheriffOf(room)
+			if stage != protocol.SheriffStageDone || sheriffID != want {
+				t.Fatalf("stage = %s, sheriff = %q, want %s with sheriff %q", stage, sheriffID, protocol.SheriffStageDone, want)
+			}
+
+			// 结果广播给所有人，竞选结束后开始白天发言
+			var data protocol.SheriffStateData
+			tt.eventually(func() bool {
+				msgs := tt.received(tt.players[4], protocol.MsgSheriffState)
+				if len(msgs) == 0 {
+					return false
+				}
+				msgs[len(msgs)-1].UnmarshalData(&data)
+				return data.Stage == protocol.SheriffStageDone
+			}, "election result")
+			if data.SheriffID != want {
+				t.Errorf("announced sheriff = %q, want %q", data.SheriffID, want)
+			}
+			if speaker, _ := room.currentSpeaker(); speaker == "" {
+				t.Error("day speeches did not start after the election")
+			}
+		})
+	}
+}
+
+func TestSheriffBadge(t *testing.T) {
+	tests := []struct {
+		name string
+		pass *protocol.SheriffTargetData // 为空时等到移交超时
+		want int
+	}{
+		{name: "passed", pass: &protocol.SheriffTargetData{TargetSeat: 5}, want: 4},
+		{name: "torn up", pass: &protocol.SheriffTargetData{}, want: noVote},
+		{name: "not passed in time", want: noVote},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			room := tt.startSheriffGame()
+			sheriff := tt.players[3]
+			tt.elect(room, []int{3}, nil)
+
+			// 警长被放逐出局，等待移交警徽
+			tt.speakAll(room)
+			tt.waitPhase(room, werewolf.PhaseVote, 1)
+			for _, player := range tt.players {
+				target := sheriff
+				if player == sheriff {
+					target = tt.players[0]
+				}
+				tt.act(room, player, protocol.ActionVote, target)
+			}
+			tt.eventually(func() bool {
+				stage, _ := sheriffOf(room)
+				return stage == protocol.SheriffStageBadge
+			}, "badge handover")
+
+			// 存活玩家才能接过警徽
+			if err := tt.send(sheriff, protocol.MsgSheriffPass, protocol.SheriffTargetData{TargetID: sheriff.ID}); err == nil {
+				t.Error("badge passed to the dead sheriff")
+			}
+
+			if tc.pass != nil {
+				tt.must(sheriff, protocol.MsgSheriffPass, *tc.pass)
+			} else {
+				expireSheriffStep(room)
+			}
+
+			want := ""
+			if tc.want != noVote {
+				want = tt.players[tc.want].ID
+			}
+			stage, sheriffID := sheriffOf(room)
+			if stage != protocol.SheriffStageDone || sheriffID != want {
+				t.Fatalf("stage = %s, sheriff = %q, want %s with sheriff %q", stage, sheriffID, protocol.SheriffStageDone, want)
+			}
+			if err := tt.send(sheriff, protocol.MsgSheriffPass, protocol.SheriffTargetData{}); err == nil {
+				t.Error("badge handed over twice")
+			}
+		})
+	}
+}
+
+func TestSheriffExileVote(t *testing.T) {
+	// 4 号为警长，一票计 1.5 票
+	tests := []struct {
+		name   string
+		votes  map[int]int
+		strict bool // 引擎不接受弃权，警长的票按一票计
+		want   int  // 被放逐的座位下标，noVote 表示无人出局
+	}{
+		{name: "sheriff breaks a tie", votes: map[int]int{3: 1, 0: 1, 1: 4, 2: 4, 4: 0}, want: 1},
+		{name: "unique exile", votes: map[int]int{0: 1, 2: 1, 4: 1, 1: 4, 3: 4}, want: 1},
+		{name: "weighted tie", votes: map[int]int{3: 2, 0: 1, 4: 1, 1: 4, 2: 4}, want: noVote},
+		{name: "everyone abstains", votes: map[int]int{0: noVote, 1: noVote, 2: noVote, 3: noVote, 4: noVote}, want: noVote},
+		{name: "sheriff counts as one without abstentions", votes: map[int]int{3: 1, 0: 1, 1: 4, 2: 4, 4: 0}, strict: true, want: noVote},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTestTable(t, len(fiveSeats))
+			if tc.strict {
+				tt.server.UseEngine(func(config werewolf.Config) engine.GameEngine {
+					return strictEngine{engine.NewMock(config)}
+				})
+			}
+			room := tt.startSheriffGame()
+			tt.elect(room, []int{3}, nil)
+
+			tt.speakAll(room)
+			tt.waitPhase(room, werewolf.PhaseVote, 1)
+			for voter, target := range tc.votes {
+				var targetPlayer *Player
+				if target != noVote {
+					targetPlayer = tt.players[target]
+				}
+				if result := tt.act(room, tt.players[voter], protocol.ActionVote, targetPlayer); !result.Success {
+					t.Fatalf("vote from seat %d rejected: %s", voter+1, result.Message)
+				}
+			}
+			tt.waitPhase(room, werewolf.PhaseNight, 2)
+
+			alive := room.Engine().GetState().AlivePlayers
+			for i, player := range tt.players {
+				if exiled := !slices.Contains(alive, player.ID); exiled != (i == tc.want) {
+					t.Errorf("seat %d exiled = %v, want %v", i+1, exiled, i == tc.want)
+				}
+			}
+		})
+	}
+}
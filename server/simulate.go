@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// simulatePollInterval 检查模拟对局是否结束的间隔
+const simulatePollInterval = 500 * time.Millisecond
+
+// runSimulate 在进程内让机器人满员对局，统计各阵营胜率，用于检查规则和机器人策略
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	games := fs.Int("games", 10, "number of games to play")
+	roles := fs.String("roles", "", "comma separated roles (default: the standard 6 player setup)")
+	timeout := fs.Duration("timeout", 10*time.Minute, "give up on a single game after this long")
+	flags := registerConfigFlags(fs, "warn")
+	fs.Parse(args)
+
+	config, err := flags.config()
+	if err != nil {
+		return errors.Wrap(err, "invalid flag")
+	}
+
+	logger, err := flags.logger()
+	if err != nil {
+		return err
+	}
+
+	roomConfig := protocol.CreateRoomData{RoomName: "simulate"}
+	if *roles != "" {
+		for _, role := range strings.Split(*roles, ",") {
+			roomConfig.Roles = append(roomConfig.Roles, werewolf.RoleType(strings.TrimSpace(role)))
+		}
+	}
+	if errs, _ := validateRoomConfig(roomConfig, config.MaxRoomSize); len(errs) > 0 {
+		return errors.Errorf("invalid roles: %s", strings.Join(errs, "; "))
+	}
+	if len(roomConfig.Roles) == 0 {
+		roomConfig.Roles = defaultRoles()
+	}
+
+	// 模拟不需要账号、持久化、战报和使用统计
+	config.StateDir = ""
+	config.SnapshotRedis = ""
+	config.RegistryRedis = ""
+	config.SummaryWebhook = ""
+	config.TelemetryURL = ""
+	server := NewServer(config, nil, logger)
+
+	wins := make(map[werewolf.Camp]int)
+	for i := 1; i <= *games; i++ {
+		started := time.Now()
+
+		winner, err := server.simulateGame(roomConfig.Roles, *timeout)
+		if err != nil {
+			return errors.Wrapf(err, "game %d", i)
+		}
+		wins[winner]++
+
+		fmt.Printf("game %d: winner=%s duration=%s\n", i, winner, time.Since(started).Round(time.Second))
+	}
+
+	fmt.Printf("\n%d games: good=%d evil=%d draw=%d\n",
+		*games, wins[werewolf.CampGood], wins[werewolf.CampEvil], wins[werewolf.CampNone])
+
+	return nil
+}
+
+// simulateGame 建一个只有机器人的房间并等待对局结束
+func (s *Server) simulateGame(roles []werewolf.RoleType, timeout time.Duration) (werewolf.Camp, error) {
+	room, err := s.CreateRoom("simulate", roles)
+	if err != nil {
+		return werewolf.CampNone, err
+	}
+	defer s.RemoveRoom(room.ID)
+
+	if _, err := room.AddBots(0); err != nil {
+		return werewolf.CampNone, err
+	}
+
+	if err := room.Start(); err != nil {
+		return werewolf.CampNone, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if winner, finished := room.Result(); finished {
+			return winner, nil
+		}
+		time.Sleep(simulatePollInterval)
+	}
+
+	return werewolf.CampNone, errors.Errorf("game did not finish within %s", timeout)
+}
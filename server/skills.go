@@ -0,0 +1,138 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// AllowedSkills 计算玩家此刻可用的技能及合法目标
+//
+// 这是客户端提示和机器人决策的唯一依据：按顺序发言时只有轮到的人有发言技能，
+// 有时限的一步（轮到发言、盗贼选牌）带上剩余秒数；对局暂停时没有可用的技能。
+func (r *Room) AllowedSkills(playerID string) protocol.AllowedSkillsData {
+	state := r.Engine().GetState()
+
+	data := protocol.AllowedSkillsData{
+		Phase:  publicPhase(state.Phase),
+		Round:  state.Round,
+		Skills: []protocol.SkillInfo{},
+	}
+
+	var me *werewolf.PlayerState
+	for i := range state.Players {
+		if state.Players[i].ID == playerID {
+			me = &state.Players[i]
+			break
+		}
+	}
+
+	if me == nil || !me.IsAlive || state.IsEnded || r.isSlain(playerID) || r.isPaused() {
+		return data
+	}
+
+	// 仍在场的存活玩家，others 不含自己
+	var alive, others []string
+	for _, ps := range state.Players {
+		if ps.IsAlive {
+			alive = append(alive, ps.ID)
+		}
+	}
+	alive = r.activePlayers(alive)
+	for _, id := range alive {
+		if id != playerID {
+			others = append(others, id)
+		}
+	}
+
+	targeted := func(actionType werewolf.ActionType, targets []string) protocol.SkillInfo {
+		return protocol.SkillInfo{ActionType: actionType, NeedsTarget: true, Targets: targets}
+	}
+
+	switch publicPhase(state.Phase) {
+	case werewolf.PhaseNight:
+		switch me.Role {
+		case werewolf.RoleTypeWerewolf:
+			data.Skills = append(data.Skills, targeted(protocol.ActionKill, alive))
+		case werewolf.RoleTypeSeer:
+			data.Skills = append(data.Skills, targeted(protocol.ActionCheck, others))
+		case werewolf.RoleTypeWitch:
+			data.Skills = append(data.Skills, r.witchSkills(playerID, others, state.Round)...)
+		case werewolf.RoleTypeGuard:
+			data.Skills = append(data.Skills, targeted(protocol.ActionProtect, r.guardTargets(playerID, alive, state.Round)))
+		}
+		if r.canLink(playerID, state.Phase, state.Round) {
+			data.Skills = append(data.Skills, targeted(protocol.ActionLink, alive))
+		}
+		if r.canSteal(playerID) {
+			data.Skills = append(data.Skills, protocol.SkillInfo{ActionType: protocol.ActionSteal})
+			data.TimeoutSeconds = r.thiefCountdown()
+		}
+	case werewolf.PhaseDay:
+		// 警长竞选期间暂停讨论，有狼人自爆后当天不再有动作
+		if !r.electionRunning() && !r.dayCut(state.Round) {
+			speaker, remaining := r.currentSpeaker()
+			switch speaker {
+			case "":
+				data.Skills = append(data.Skills, protocol.SkillInfo{ActionType: protocol.ActionSpeak})
+			case playerID:
+				data.Skills = append(data.Skills, protocol.SkillInfo{ActionType: protocol.ActionSpeak})
+				data.TimeoutSeconds = remaining
+			}
+			if r.canDuel(playerID) {
+				data.Skills = append(data.Skills, targeted(protocol.ActionDuel, others))
+			}
+			if me.Role == werewolf.RoleTypeWerewolf && r.canSelfDestruct(state.Round) {
+				data.Skills = append(data.Skills, protocol.SkillInfo{ActionType: protocol.ActionSelfDestruct})
+			}
+		}
+	case werewolf.PhaseVote:
+		if !r.dayCut(state.Round) {
+			data.Skills = append(data.Skills, targeted(protocol.ActionVote, others))
+		}
+	}
+
+	return data
+}
+
+// SendAllowedSkills 把当前可用技能发送给指定玩家
+func (r *Room) SendAllowedSkills(playerID string) {
+	r.mu.RLock()
+	player, exists := r.Players[playerID]
+	r.mu.RUnlock()
+
+	if !exists || r.Engine() == nil {
+		return
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgAllowedSkills, r.AllowedSkills(playerID))
+	player.SendMessage(msg)
+}
+
+// pushAllowedSkills 阶段变化或轮到的发言者变化时向每个玩家推送可用技能
+func (r *Room) pushAllowedSkills() {
+	r.mu.RLock()
+	playerIDs := make([]string, 0, len(r.Players))
+	for playerID := range r.Players {
+		playerIDs = append(playerIDs, playerID)
+	}
+	r.mu.RUnlock()
+
+	for _, playerID := range playerIDs {
+		r.SendAllowedSkills(playerID)
+	}
+}
+
+// secondsUntil 距离 deadline 的秒数，零值或已过期时为 0
+func secondsUntil(deadline time.Time) int {
+	if deadline.IsZero() {
+		return 0
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(remaining.Round(time.Second).Seconds())
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// snapshotStore 房间快照的存储，服务器关闭时和对局进行中定期写入，重启时读回
+type snapshotStore interface {
+	save(snapshot RoomSnapshot) error
+	remove(roomID string) error
+	load() ([]RoomSnapshot, error)
+	String() string
+}
+
+// newSnapshotStore 按配置选择快照存储：配置了 Redis 时用 Redis，否则用快照目录，都没有时返回 nil
+func newSnapshotStore(config Config) snapshotStore {
+	switch {
+	case config.SnapshotRedis != "":
+		return &redisSnapshotStore{redisClient{addr: config.SnapshotRedis}}
+	case config.StateDir != "":
+		return &fileSnapshotStore{dir: config.StateDir}
+	default:
+		return nil
+	}
+}
+
+// fileSnapshotStore 每个房间一个 JSON 文件
+type fileSnapshotStore struct {
+	dir string
+}
+
+// String 返回快照目录，用于日志
+func (f *fileSnapshotStore) String() string {
+	return f.dir
+}
+
+// save 先写临时文件再改名，进程在写入途中崩溃也不会留下半个快照
+func (f *fileSnapshotStore) save(snapshot RoomSnapshot) error {
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return errors.Wrap(err, "create state dir")
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode room snapshot")
+	}
+
+	path := filepath.Join(f.dir, snapshot.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.Wrap(err, "write room snapshot")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "write room snapshot")
+}
+
+// remove 删除房间的快照，快照不存在时不报错
+func (f *fileSnapshotStore) remove(roomID string) error {
+	err := os.Remove(filepath.Join(f.dir, roomID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove room snapshot")
+	}
+	return nil
+}
+
+// load 读取目录中所有快照，无法解析的文件跳过
+func (f *fileSnapshotStore) load() ([]RoomSnapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(f.dir, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "list room snapshots")
+	}
+
+	snapshots := make([]RoomSnapshot, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "read room snapshot")
+		}
+
+		var snapshot RoomSnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+// redisKeyPrefix Redis 中房间快照的键前缀，redisRoomsKey 集合记录所有有快照的房间
+const (
+	redisKeyPrefix = "werewolf:room:"
+	redisRoomsKey  = "werewolf:rooms"
+)
+
+// redisSnapshotStore 快照存在 Redis 中，多台服务器共用时崩溃的房间可以在另一台上恢复
+type redisSnapshotStore struct {
+	redisClient
+}
+
+// save 写入房间快照并记入房间集合
+func (r *redisSnapshotStore) save(snapshot RoomSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return errors.Wrap(err, "encode room snapshot")
+	}
+
+	return r.session(func(c *redisConn) error {
+		if _, err := c.do("SET", redisKeyPrefix+snapshot.ID, string(data)); err != nil {
+			return err
+		}
+		_, err := c.do("SADD", redisRoomsKey, snapshot.ID)
+		return err
+	})
+}
+
+// remove 删除房间快照
+func (r *redisSnapshotStore) remove(roomID string) error {
+	return r.session(func(c *redisConn) error {
+		if _, err := c.do("DEL", redisKeyPrefix+roomID); err != nil {
+			return err
+		}
+		_, err := c.do("SREM", redisRoomsKey, roomID)
+		return err
+	})
+}
+
+// load 读取集合中所有房间的快照，已不存在或无法解析的跳过
+func (r *redisSnapshotStore) load() ([]RoomSnapshot, error) {
+	var snapshots []RoomSnapshot
+
+	err := r.session(func(c *redisConn) error {
+		reply, err := c.do("SMEMBERS", redisRoomsKey)
+		if err != nil {
+			return err
+		}
+		ids, _ := reply.([]interface{})
+
+		for _, id := range ids {
+			roomID, _ := id.(string)
+			reply, err := c.do("GET", redisKeyPrefix+roomID)
+			if err != nil {
+				return err
+			}
+			data, ok := reply.(string)
+			if !ok {
+				continue
+			}
+
+			var snapshot RoomSnapshot
+			if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+				continue
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return nil
+	})
+
+	return snapshots, err
+}
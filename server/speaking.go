@@ -0,0 +1,283 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// speakingOrder 白天的发言顺序，由 r.mu 保护
+//
+// 有存活的警长时从警长的下一位开始、警长最后发言，方向由警长选择；
+// 否则从最近出局玩家的下一位开始按座位号递增发言，没有人出局时从1号开始。
+type speakingOrder struct {
+	order     []string
+	turn      int
+	clockwise bool
+	timer     *time.Timer
+	deadline  time.Time // 当前发言者的时限，不限时为零值
+	epoch     int
+}
+
+// active 是否还有人没按顺序发言
+func (o *speakingOrder) active() bool {
+	return o.turn < len(o.order)
+}
+
+// startSpeakingOrder 排定当天的发言顺序并请第一位发言，返回是否有人需要发言
+func (r *Room) startSpeakingOrder() bool {
+	alive := r.alivePlayers()
+
+	r.mu.Lock()
+	r.speaking.clockwise = true
+	r.arrangeSpeakers(alive)
+	if !r.speaking.active() {
+		r.mu.Unlock()
+		return false
+	}
+	msgs, bot := r.announceSpeaker()
+	r.mu.Unlock()
+
+	r.sendSpeakerMessages(msgs)
+	r.botSpeakTurn(bot)
+
+	return true
+}
+
+// SetSpeakingDirection 警长在第一个人发言前选择发言方向
+func (r *Room) SetSpeakingDirection(playerID string, clockwise bool) error {
+	alive := r.alivePlayers()
+
+	r.mu.Lock()
+	switch {
+	case !r.isSheriff(playerID) || !slices.Contains(alive, playerID):
+		r.mu.Unlock()
+		return errors.New("only the sheriff can choose the speaking order")
+	case !r.speaking.active():
+		r.mu.Unlock()
+		return errors.New("there is no speaking order right now")
+	case r.speaking.turn > 0:
+		r.mu.Unlock()
+		return errors.New("speaking has already started")
+	}
+
+	r.speaking.clockwise = clockwise
+	r.arrangeSpeakers(alive)
+	msgs, bot := r.announceSpeaker()
+	r.mu.Unlock()
+
+	r.sendSpeakerMessages(msgs)
+	r.botSpeakTurn(bot)
+
+	return nil
+}
+
+// currentSpeaker 按顺序发言时轮到的玩家及其剩余秒数，没有发言顺序时返回空
+func (r *Room) currentSpeaker() (string, int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	o := &r.speaking
+	if !o.active() {
+		return "", 0
+	}
+	return o.order[o.turn], secondsUntil(o.deadline)
+}
+
+// checkSpeakingTurn 竞选期间不能讨论，有发言顺序时只有轮到的玩家可以发言
+func (r *Room) checkSpeakingTurn(playerID string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.sheriff.running() {
+		return errors.New("sheriff election in progress")
+	}
+
+	o := &r.speaking
+	if o.active() && o.order[o.turn] != playerID {
+		return errors.Errorf("not your turn to speak, waiting for %s", r.seatLabel(o.order[o.turn]))
+	}
+
+	return nil
+}
+
+// advanceSpeaker 轮到的玩家发言后请下一位发言
+func (r *Room) advanceSpeaker(playerID string) {
+	r.mu.Lock()
+	o := &r.speaking
+	if !o.active() || o.order[o.turn] != playerID {
+		r.mu.Unlock()
+		return
+	}
+
+	o.turn++
+	msgs, bot := r.announceSpeaker()
+	r.mu.Unlock()
+
+	r.sendSpeakerMessages(msgs)
+	r.botSpeakTurn(bot)
+}
+
+// speakingTimeout 轮到的玩家超时未发言，跳过
+func (r *Room) speakingTimeout(epoch int) {
+	r.mu.Lock()
+	o := &r.speaking
+	if epoch != o.epoch || !o.active() || r.State.ended() {
+		r.mu.Unlock()
+		return
+	}
+
+	skipped := o.order[o.turn]
+	o.turn++
+	msgs, bot := r.announceSpeaker()
+	r.mu.Unlock()
+
+	r.playerLogger(skipped).Info("speaker timed out")
+	r.missedAction(skipped)
+
+	r.sendSpeakerMessages(msgs)
+	r.botSpeakTurn(bot)
+}
+
+// stopSpeakingOrder 离开白天时清除发言顺序
+func (r *Room) stopSpeakingOrder() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.speaking.timer != nil {
+		r.speaking.timer.Stop()
+	}
+	r.speaking = speakingOrder{epoch: r.speaking.epoch + 1}
+}
+
+// arrangeSpeakers 从锚点的下一位按方向排到锚点，只保留存活玩家（需持有锁）
+//
+// 锚点是存活的警长（因此警长最后发言）或最近出局的玩家，都没有时从1号开始。
+func (r *Room) arrangeSpeakers(alive []string) {
+	o := &r.speaking
+	o.order = nil
+	o.turn = 0
+
+	n := len(r.order)
+	if n == 0 {
+		return
+	}
+
+	start := n - 1
+	switch {
+	case r.sheriff.id != "" && slices.Contains(alive, r.sheriff.id):
+		start = slices.Index(r.order, r.sheriff.id)
+	case r.lastDied != "":
+		start = slices.Index(r.order, r.lastDied)
+	}
+
+	for step := 1; step <= n; step++ {
+		i := (start + step) % n
+		if !o.clockwise {
+			i = (start - step + n) % n
+		}
+		if slices.Contains(alive, r.order[i]) {
+			o.order = append(o.order, r.order[i])
+		}
+	}
+}
+
+// speakerMessage 发给一名玩家的消息
+type speakerMessage struct {
+	player *Player // 为空时广播
+	msg    *protocol.Message
+}
+
+// announceSpeaker 为当前发言者计时，生成发言顺序广播和给发言者的提醒；
+// 轮到机器人时返回其ID（需持有锁）
+func (r *Room) announceSpeaker() ([]speakerMessage, string) {
+	o := &r.speaking
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	o.epoch++
+
+	data := protocol.SpeakingOrderData{
+		Clockwise: o.clockwise,
+		Order:     append([]string(nil), o.order...),
+	}
+
+	var msgs []speakerMessage
+	bot := ""
+	if o.active() {
+		data.Current = o.order[o.turn]
+
+		// 挂机的玩家很快跳过
+		timeout := r.scaled(r.speakTimeout)
+		if r.afk.flagged[data.Current] {
+			timeout = afkPassDelay
+		}
+
+		o.deadline = time.Time{}
+		if timeout > 0 {
+			epoch := o.epoch
+			o.timer = time.AfterFunc(timeout, func() { r.speakingTimeout(epoch) })
+			o.deadline = time.Now().Add(timeout)
+		}
+
+		if r.bots[data.Current] != nil {
+			bot = data.Current
+		} else if player := r.Players[data.Current]; player != nil {
+			turnMsg, _ := protocol.NewMessage(protocol.MsgYourTurnToSpeak, protocol.YourTurnToSpeakData{
+				TimeoutSeconds: int(timeout.Seconds()),
+			})
+			msgs = append(msgs, speakerMessage{player: player, msg: turnMsg})
+		}
+	}
+
+	orderMsg, _ := protocol.NewMessage(protocol.MsgSpeakingOrder, data)
+	msgs = append([]speakerMessage{{msg: orderMsg}}, msgs...)
+
+	return msgs, bot
+}
+
+// sendSpeakerMessages 发出 announceSpeaker 生成的消息（不能持有锁）
+//
+// 轮到的人变了，发言技能随之从上一位移到下一位，重新推送可用技能；轮到的人夜里留了发言时随即发出。
+func (r *Room) sendSpeakerMessages(msgs []speakerMessage) {
+	for _, m := range msgs {
+		if m.player == nil {
+			r.BroadcastMessage(m.msg)
+		} else {
+			m.player.SendMessage(m.msg)
+		}
+	}
+
+	r.pushAllowedSkills()
+	r.releaseHeldSpeech()
+}
+
+// broadcastSpeech 把被接受的发言广播给所有玩家
+func (r *Room) broadcastSpeech(playerID string, data map[string]interface{}) {
+	content, _ := data["content"].(string)
+
+	msg, _ := protocol.NewMessage(protocol.MsgSpeech, protocol.SpeechData{
+		PlayerID: playerID,
+		Content:  content,
+	})
+	r.BroadcastMessage(msg)
+}
+
+// botSpeakTurn 轮到机器人时让它发言
+func (r *Room) botSpeakTurn(playerID string) {
+	if playerID == "" {
+		return
+	}
+
+	r.spawn(func() {
+		time.Sleep(botMinDelay)
+
+		if err := r.PerformAction(playerID, protocol.ActionSpeak, "", map[string]interface{}{"content": botSpeech}); err != nil {
+			r.playerLogger(playerID).Debug("bot speech rejected", "error", err)
+			return
+		}
+		r.SendGameState()
+	})
+}
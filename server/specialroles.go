@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// wolfKingShotTimeout 狼王决定是否开枪的时限，超时视为不开枪
+const wolfKingShotTimeout = 15 * time.Second
+
+// shotPromptPrefix 狼王开枪提示的编号前缀，用于和女巫的提示区分
+const shotPromptPrefix = "shoot-"
+
+// baseRoles 房间实现的角色交给引擎时使用的基础角色
+var baseRoles = map[werewolf.RoleType]werewolf.RoleType{
+	protocol.RoleTypeIdiot:    werewolf.RoleTypeVillager,
+	protocol.RoleTypeKnight:   werewolf.RoleTypeVillager,
+	protocol.RoleTypeWolfKing: werewolf.RoleTypeWerewolf,
+	protocol.RoleTypeCupid:    werewolf.RoleTypeVillager,
+	protocol.RoleTypeThief:    werewolf.RoleTypeVillager,
+}
+
+// specialRoles 引擎之外由房间实现的角色及其状态，由 r.mu 保护
+//
+// 引擎只知道这些玩家的基础角色，夜里照常行动；白痴翻牌、骑士决斗和狼王开枪由房间结算。
+// 被决斗或开枪带走的玩家在引擎里仍然存活，房间把他们当作出局处理（与弃局的玩家相同）；
+// 翻牌的白痴在引擎里已经出局，房间让他留在场上并计入胜负，但不能再投票、发言或成为目标。
+type specialRoles struct {
+	roles    map[string]werewolf.RoleType // 玩家 -> 房间实现的角色
+	slain    map[string]bool              // 被决斗或开枪带走的玩家
+	revealed map[string]bool              // 已翻牌的白痴
+	dueled   bool                         // 骑士已经决斗过
+	shooter  string                       // 正在决定是否开枪的狼王
+	lovers   [2]string                    // 丘比特连接的恋人，还没连接时为空，见 cupid.go
+	extras   []werewolf.RoleType          // 盗贼还没选的两张底牌，选牌后清空，见 thief.go
+	timer    *time.Timer
+	deadline time.Time // 开枪提示的截止时间
+	epoch    int       // 每发出或结束一次开枪提示加一，过期的计时器和回答据此失效
+
+	thiefTimer    *time.Timer // 盗贼选牌计时
+	thiefDeadline time.Time   // 盗贼选牌的截止时间
+}
+
+// promptID 当前开枪提示的编号
+func (s *specialRoles) promptID() string {
+	return fmt.Sprintf("%s%d", shotPromptPrefix, s.epoch)
+}
+
+// engineRoles 把房间配置中的角色换成引擎认识的基础角色
+func engineRoles(roles []werewolf.RoleType) []werewolf.RoleType {
+	result := make([]werewolf.RoleType, 0, len(roles))
+	for _, role := range roles {
+		if base, ok := baseRoles[role]; ok {
+			role = base
+		}
+		result = append(result, role)
+	}
+	return result
+}
+
+// assignSpecialRoles 引擎分配好基础角色后，从对应基础角色的玩家中随机选出房间实现的角色（需持有锁）
+func (r *Room) assignSpecialRoles(roles []werewolf.RoleType) {
+	if r.special.timer != nil {
+		r.special.timer.Stop()
+	}
+	if r.special.thiefTimer != nil {
+		r.special.thiefTimer.Stop()
+	}
+	r.special = specialRoles{
+		roles:    make(map[string]werewolf.RoleType),
+		slain:    make(map[string]bool),
+		revealed: make(map[string]bool),
+		epoch:    r.special.epoch + 1,
+	}
+
+	pools := make(map[werewolf.RoleType][]string)
+	for _, ps := range r.Engine().GetState().Players {
+		pools[ps.Role] = append(pools[ps.Role], ps.ID)
+	}
+	for _, pool := range pools {
+		rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	}
+
+	for _, role := range roles {
+		base, ok := baseRoles[role]
+		if !ok || len(pools[base]) == 0 {
+			continue
+		}
+		r.special.roles[pools[base][0]] = role
+		pools[base] = pools[base][1:]
+	}
+}
+
+// roleOf 玩家对外的角色，房间实现的角色优先于引擎的基础角色（需持有锁）
+func (r *Room) roleOf(ps werewolf.PlayerState) werewolf.RoleType {
+	if role, ok := r.special.roles[ps.ID]; ok {
+		return role
+	}
+	return ps.Role
+}
+
+// specialRole 玩家在房间实现的角色，没有时返回空
+func (r *Room) specialRole(playerID string) werewolf.RoleType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.special.roles[playerID]
+}
+
+// isSlain 玩家是否已被决斗或开枪带走
+func (r *Room) isSlain(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.special.slain[playerID]
+}
+
+// revealIdiot 白痴被放逐时翻牌免死，返回是否已按翻牌处理
+//
+// 引擎已经判定白痴出局，房间不再广播这次死亡，改为公开他的身份。
+func (r *Room) revealIdiot(playerID string) bool {
+	r.mu.Lock()
+	if r.special.roles[playerID] != protocol.RoleTypeIdiot || r.special.revealed[playerID] || r.lastPhase != werewolf.PhaseVote {
+		r.mu.Unlock()
+		return false
+	}
+	r.special.revealed[playerID] = true
+	r.mu.Unlock()
+
+	event := protocol.GameEventData{
+		EventType: protocol.EventIdiotRevealed,
+		Event:     protocol.EventKeyIdiotRevealed,
+		Params:    map[string]string{"player": playerID},
+		Data:      map[string]interface{}{"playerID": playerID},
+	}
+
+	r.logEvent(event.EventType, eventText(event), event.Data)
+	msg, _ := protocol.NewMessage(protocol.MsgGameEvent, event)
+	r.BroadcastMessage(msg)
+
+	return true
+}
+
+// canDuel 玩家是否是还没决斗过的骑士
+func (r *Room) canDuel(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.special.roles[playerID] == protocol.RoleTypeKnight && !r.special.dueled
+}
+
+// knightDuel 骑士白天翻牌与一名玩家决斗：对方是狼人则对方出局，否则骑士出局
+//
+// 每局只能决斗一次。决斗由房间结算，不经过引擎，也不计入重放的动作记录。
+func (r *Room) knightDuel(knightID, targetID string) error {
+	if r.specialRole(knightID) != protocol.RoleTypeKnight {
+		return rejected(RejectOther, errors.New("only the knight can duel"))
+	}
+	if publicPhase(r.Engine().GetState().Phase) != werewolf.PhaseDay {
+		return rejected(RejectWrongPhase, errors.New("duels are only allowed during the day"))
+	}
+	if r.electionRunning() {
+		return rejected(RejectRule, errors.New("cannot duel during the sheriff election"))
+	}
+	if targetID == "" || targetID == knightID || !r.isAlive(targetID) {
+		return rejected(RejectInvalidTarget, errors.New("duel target must be another alive player"))
+	}
+
+	var targetRole werewolf.RoleType
+	for _, ps := range r.Engine().GetState().Players {
+		if ps.ID == targetID {
+			targetRole = ps.Role
+		}
+	}
+
+	r.mu.Lock()
+	if r.special.dueled {
+		r.mu.Unlock()
+		return rejected(RejectRule, errors.New("the knight has already dueled"))
+	}
+	r.special.dueled = true
+	r.mu.Unlock()
+
+	loser := knightID
+	if roleCamp(targetRole) == werewolf.CampEvil {
+		loser = targetID
+	}
+
+	r.slay(loser, protocol.GameEventData{
+		EventType: protocol.EventKnightDuel,
+		Event:     protocol.EventKeyKnightDuel,
+		Params:    map[string]string{"knight": knightID, "target": targetID, "loser": loser},
+		Data: map[string]interface{}{
+			"knightID": knightID,
+			"targetID": targetID,
+			"playerID": loser,
+		},
+	})
+
+	return nil
+}
+
+// slay 房间结算的出局：广播后重新判定胜负，被带走的是狼王时接着开枪
+func (r *Room) slay(playerID string, event protocol.GameEventData) {
+	r.mu.Lock()
+	r.special.slain[playerID] = true
+	r.lastDied = playerID
+	r.mu.Unlock()
+
+	r.logEvent(event.EventType, eventText(event), event.Data)
+	msg, _ := protocol.NewMessage(protocol.MsgGameEvent, event)
+	r.BroadcastMessage(msg)
+
+	r.sheriffDied(playerID)
+	r.SendGameState()
+	r.pushAllowedSkills()
+	r.checkVictory(protocol.ReasonRoleSkill)
+
+	r.startWolfKingShot(playerID)
+	r.loverSuicide(playerID)
+
+	// 引擎仍把被带走的玩家当作存活，由房间替他交上之后每一阶段的动作
+	r.spawn(func() { r.passForRemoved(playerID) })
+}
+
+// startWolfKingShot 狼王出局后决定是否开枪，被毒杀或对局已结束时不能开枪
+//
+// 机器人扮演的狼王直接随机带走一名非狼人玩家。
+func (r *Room) startWolfKingShot(playerID string) {
+	if r.specialRole(playerID) != protocol.RoleTypeWolfKing {
+		return
+	}
+	if _, finished := r.Result(); finished {
+		return
+	}
+
+	if r.poisoned(playerID) {
+		r.announcePrivate(playerID, "你被毒杀，不能开枪")
+		return
+	}
+
+	targets := r.shotTargets(playerID)
+	if len(targets) == 0 {
+		return
+	}
+
+	r.mu.RLock()
+	_, isBot := r.bots[playerID]
+	r.mu.RUnlock()
+
+	if isBot {
+		wolves := make(map[string]bool)
+		for _, ps := range r.Engine().GetState().Players {
+			if ps.Role == werewolf.RoleTypeWerewolf {
+				wolves[ps.ID] = true
+			}
+		}
+
+		var candidates []string
+		for _, id := range targets {
+			if !wolves[id] {
+				candidates = append(candidates, id)
+			}
+		}
+		if len(candidates) > 0 {
+			r.shoot(playerID, candidates[rand.Intn(len(candidates))])
+		}
+		return
+	}
+
+	r.promptShot(playerID)
+}
+
+// poisoned 玩家是否在本轮被女巫毒杀
+func (r *Room) poisoned(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, action := range r.actions {
+		if action.ActionType == protocol.ActionPoison && action.TargetID == playerID && action.Round == r.lastRound {
+			return true
+		}
+	}
+	return false
+}
+
+// shotTargets 狼王可以带走的玩家
+func (r *Room) shotTargets(playerID string) []string {
+	var targets []string
+	for _, id := range r.alivePlayers() {
+		if id != playerID {
+			targets = append(targets, id)
+		}
+	}
+	return targets
+}
+
+// promptShot 向狼王发出开枪提示并开始计时
+func (r *Room) promptShot(playerID string) {
+	timeout := r.scaled(wolfKingShotTimeout)
+	data := protocol.SkillPromptData{
+		Step:           protocol.SkillStepShoot,
+		Message:        "你出局了，是否开枪带走一名玩家？shoot <编号> / shoot pass",
+		Targets:        r.shotTargets(playerID),
+		TimeoutSeconds: int(timeout.Seconds()),
+	}
+
+	r.mu.Lock()
+	s := &r.special
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.shooter = playerID
+	s.epoch++
+	epoch := s.epoch
+	s.timer = time.AfterFunc(timeout, func() { r.shotTimeout(epoch) })
+	s.deadline = time.Now().Add(timeout)
+	data.PromptID = s.promptID()
+	player := r.Players[playerID]
+	r.mu.Unlock()
+
+	if player != nil {
+		msg, _ := protocol.NewMessage(protocol.MsgSkillPrompt, data)
+		player.SendMessage(msg)
+	}
+}
+
+// shotRespond 处理狼王对开枪提示的回答，目标不合法时重新发出提示
+func (r *Room) shotRespond(playerID, promptID string, accept bool, targetID string) (string, error) {
+	r.mu.Lock()
+	s := &r.special
+	if s.timer == nil || s.shooter != playerID || s.promptID() != promptID {
+		r.mu.Unlock()
+		return "", ErrStalePrompt
+	}
+	s.timer.Stop()
+	s.timer = nil
+	s.shooter = ""
+	s.epoch++
+	r.mu.Unlock()
+
+	if !accept {
+		return "不开枪", nil
+	}
+
+	if targetID == "" || targetID == playerID || !r.isAlive(targetID) {
+		r.promptShot(playerID)
+		return "", errors.New("shot target must be another alive player")
+	}
+
+	r.shoot(playerID, targetID)
+	return "已开枪", nil
+}
+
+// shoot 狼王开枪带走目标
+func (r *Room) shoot(playerID, targetID string) {
+	if _, finished := r.Result(); finished {
+		return
+	}
+
+	r.slay(targetID, protocol.GameEventData{
+		EventType: protocol.EventWolfKingShot,
+		Event:     protocol.EventKeyWolfKingShot,
+		Params:    map[string]string{"shooter": playerID, "victim": targetID},
+		Data: map[string]interface{}{
+			"shooterID": playerID,
+			"playerID":  targetID,
+		},
+	})
+}
+
+// shotTimeout 狼王在时限内没有回答，视为不开枪
+func (r *Room) shotTimeout(epoch int) {
+	r.mu.Lock()
+	s := &r.special
+	if epoch != s.epoch || s.timer == nil {
+		r.mu.Unlock()
+		return
+	}
+	s.timer = nil
+	s.epoch++
+	playerID := s.shooter
+	s.shooter = ""
+	r.mu.Unlock()
+
+	r.announcePrivate(playerID, "超时未选择，视为不开枪")
+	r.missedAction(playerID)
+}
+
+// isShotPrompt 回答的是否是狼王开枪提示
+func isShotPrompt(promptID string) bool {
+	return strings.HasPrefix(promptID, shotPromptPrefix)
+}
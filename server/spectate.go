@@ -0,0 +1,247 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// spectatorList 已登录玩家的观战，由 r.mu 保护
+//
+// 观战的玩家不占座位，与只读观看接口的观众一样订阅 r.viewers，收到相同的公开广播（包括观看延迟），
+// 因此观战人数上限同时计入两者。房间要求房主同意时，请求先记在 pending 里，房主答复后才开始观战。
+type spectatorList struct {
+	watching map[string]func()  // 正在观战的玩家 -> 停止观战
+	pending  map[string]*Player // 等待房主同意的玩家
+}
+
+// handleSpectate 处理观战请求，房间要求房主同意时转给房主
+func (h *MessageHandler) handleSpectate(playerID string, data *protocol.SpectateData) error {
+	player := h.server.GetPlayer(playerID)
+	if player.RoomID != "" {
+		return errors.New("leave your room before spectating")
+	}
+
+	room := h.server.GetRoom(data.RoomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+
+	if err := room.checkPassword(data.Password); err != nil {
+		return err
+	}
+
+	// 同一时间只观战一个房间
+	h.server.stopSpectating(player)
+
+	approved, err := room.admitSpectator(player)
+	if err != nil {
+		return err
+	}
+	player.spectating = room.ID
+
+	if approved {
+		h.logger.Info("spectator joined", "roomID", room.ID)
+		room.startSpectating(player)
+		return nil
+	}
+
+	h.logger.Info("spectate request pending", "roomID", room.ID)
+
+	resultMsg, _ := protocol.NewMessage(protocol.MsgSpectateResult, protocol.SpectateResultData{
+		RoomID: room.ID,
+		Status: protocol.SpectatePending,
+	})
+	if err := player.SendMessage(resultMsg.ReplyTo(h.request)); err != nil {
+		return err
+	}
+
+	requestMsg, _ := protocol.NewMessage(protocol.MsgSpectateRequest, protocol.SpectateRequestData{
+		RoomID:   room.ID,
+		PlayerID: player.ID,
+		Username: player.Username,
+	})
+	if owner := h.server.GetPlayer(room.Owner()); owner != nil {
+		owner.SendMessage(requestMsg)
+	}
+
+	return nil
+}
+
+// handleSpectateApprove 处理房主对观战请求的答复
+func (h *MessageHandler) handleSpectateApprove(playerID string, data *protocol.SpectateApproveData) error {
+	room, err := h.ownedRoom(playerID)
+	if err != nil {
+		return err
+	}
+
+	spectator, err := room.takePending(data.PlayerID, data.Approve)
+	if err != nil {
+		return err
+	}
+
+	if data.Approve {
+		h.logger.Info("spectate request approved", "roomID", room.ID, "spectatorID", spectator.ID)
+		room.startSpectating(spectator)
+		return nil
+	}
+
+	h.logger.Info("spectate request denied", "roomID", room.ID, "spectatorID", spectator.ID)
+	spectator.spectating = ""
+
+	deniedMsg, _ := protocol.NewMessage(protocol.MsgSpectateResult, protocol.SpectateResultData{
+		RoomID: room.ID,
+		Status: protocol.SpectateDenied,
+	})
+	return spectator.SendMessage(deniedMsg)
+}
+
+// admitSpectator 按房间的观战设置检查玩家能否观战，需要房主同意时记为待处理并返回 false
+func (r *Room) admitSpectator(player *Player) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.spectate.Disabled {
+		return false, errors.New("spectating is disabled in this room")
+	}
+	if r.spectatorsFull() {
+		return false, limitError(protocol.ErrorCodeSpectatorsFull, "room has no spectator seats left")
+	}
+
+	if !r.spectate.Approval || player.ID == r.OwnerID {
+		return true, nil
+	}
+
+	if r.spectators.pending == nil {
+		r.spectators.pending = make(map[string]*Player)
+	}
+	r.spectators.pending[player.ID] = player
+	return false, nil
+}
+
+// takePending 取出待处理的观战请求；同意时观战人数已满则保留请求，房主可以稍后再同意
+func (r *Room) takePending(playerID string, approve bool) (*Player, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	player, ok := r.spectators.pending[playerID]
+	if !ok {
+		return nil, errors.New("no pending spectate request from this player")
+	}
+	if approve && r.spectatorsFull() {
+		return nil, limitError(protocol.ErrorCodeSpectatorsFull, "room has no spectator seats left")
+	}
+
+	delete(r.spectators.pending, playerID)
+	return player, nil
+}
+
+// spectatorsFull 观战人数是否已达上限，只读观看的观众和观战的玩家一起计算（需持有锁）
+func (r *Room) spectatorsFull() bool {
+	return r.spectate.Max > 0 && r.viewers.count() >= r.spectate.Max
+}
+
+// startSpectating 玩家开始观战：先发房间快照，之后转发房间的公开广播，直到停止观战或房间关闭
+func (r *Room) startSpectating(player *Player) {
+	// 先订阅再生成快照，快照之后的广播不会漏掉
+	events, unsubscribe := r.viewers.subscribe()
+	stop := make(chan struct{})
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			unsubscribe()
+			close(stop)
+		})
+	}
+
+	r.mu.Lock()
+	if r.spectators.watching == nil {
+		r.spectators.watching = make(map[string]func())
+	}
+	r.spectators.watching[player.ID] = cancel
+	r.mu.Unlock()
+
+	// 延迟观看时快照同样推迟，与之后的广播保持先后顺序
+	if r.viewers.delayed() {
+		r.viewers.sendSnapshot(events, r.viewerSnapshot())
+	} else {
+		player.SendMessage(spectateResult(r.viewerSnapshot()))
+	}
+
+	r.spawn(func() {
+		for {
+			select {
+			case event := <-events:
+				switch data := event.data.(type) {
+				case *protocol.Message:
+					player.SendMessage(data)
+				case ViewerSnapshot:
+					player.SendMessage(spectateResult(data))
+				}
+			case <-stop:
+				return
+			case <-r.closed:
+				cancel()
+				return
+			}
+		}
+	})
+}
+
+// removeSpectator 停止玩家的观战，撤回还没答复的请求
+func (r *Room) removeSpectator(playerID string) {
+	r.mu.Lock()
+	cancel := r.spectators.watching[playerID]
+	delete(r.spectators.watching, playerID)
+	delete(r.spectators.pending, playerID)
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// stopSpectating 玩家离开正在观战或等待同意的房间；加入房间、离开和断线时调用
+func (s *Server) stopSpectating(player *Player) {
+	roomID := player.spectating
+	if roomID == "" {
+		return
+	}
+	player.spectating = ""
+
+	if room := s.GetRoom(roomID); room != nil {
+		room.removeSpectator(player.ID)
+	}
+}
+
+// spectateResult 把房间快照转成开始观战的结果消息
+func spectateResult(snapshot ViewerSnapshot) *protocol.Message {
+	msg, _ := protocol.NewMessage(protocol.MsgSpectateResult, protocol.SpectateResultData{
+		RoomID:       snapshot.RoomID,
+		Status:       protocol.SpectateWatching,
+		Name:         snapshot.Name,
+		Players:      snapshot.Players,
+		Phase:        snapshot.Phase,
+		Round:        snapshot.Round,
+		AlivePlayers: snapshot.AlivePlayers,
+	})
+	return msg
+}
+
+// admitViewer 检查只读观看连接能否进入，拒绝时返回 HTTP 状态码
+//
+// 匿名观众无法由房主同意，要求房主同意或不允许观战的房间与设有密码的房间一样不对外公开。
+func (r *Room) admitViewer() (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.password != "" || r.spectate.Disabled || r.spectate.Approval {
+		return http.StatusNotFound, errors.New("room not found")
+	}
+	if r.spectatorsFull() {
+		return http.StatusServiceUnavailable, errors.New("room has no spectator seats left")
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// speedFactors 各对局节奏的时限倍率
+//
+// 发言、女巫用药、狼王开枪、盗贼选牌、警长竞选各步和开局倒计时都按倍率缩放；
+// 挂机玩家的跳过延迟和机器人的思考时间不受影响。
+var speedFactors = map[string]float64{
+	protocol.SpeedBlitz:   0.5,
+	protocol.SpeedNormal:  1,
+	protocol.SpeedRelaxed: 2,
+}
+
+// normalizeSpeed 未指定的对局节奏按 normal 处理
+func normalizeSpeed(speed string) string {
+	if speed == "" {
+		return protocol.SpeedNormal
+	}
+	return speed
+}
+
+// scaled 按房间的对局节奏缩放时限，房间的节奏建房后不再改变
+func (r *Room) scaled(d time.Duration) time.Duration {
+	factor, ok := speedFactors[r.speed]
+	if !ok {
+		return d
+	}
+	return time.Duration(float64(d) * factor)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// stateDeltaVersion 开始接收 GAME_STATE_DELTA 的协议版本，更早的客户端每次都收到完整状态
+const stateDeltaVersion = 3
+
+// stateSync 最近一次广播的对局状态及其版本，用于计算增量和响应补发请求
+type stateSync struct {
+	mu      sync.Mutex
+	version uint64                  // 单调递增，跨对局不清零
+	last    *protocol.GameStateData // 为 nil 时下一次广播完整状态
+}
+
+// update 记录新状态，返回带版本的完整状态和相对上一版的增量；
+// 没有上一版或玩家名单变化时增量为 nil，状态没有变化时 changed 为 false
+func (s *stateSync) update(state protocol.GameStateData) (full protocol.GameStateData, delta *protocol.GameStateDeltaData, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last != nil {
+		state.Version = s.last.Version
+		if stateEqual(*s.last, state) {
+			return state, nil, false
+		}
+	}
+
+	s.version++
+	state.Version = s.version
+	if s.last != nil {
+		delta = diffState(*s.last, state)
+	}
+	s.last = &state
+
+	return state, delta, true
+}
+
+// current 最近一次广播的完整状态
+func (s *stateSync) current() (protocol.GameStateData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last == nil {
+		return protocol.GameStateData{}, false
+	}
+	return *s.last, true
+}
+
+// reset 新对局或回到大厅时调用，下一次广播完整状态
+func (s *stateSync) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.last = nil
+}
+
+// stateEqual 两个状态除版本外是否相同
+func stateEqual(a, b protocol.GameStateData) bool {
+	return a.Phase == b.Phase &&
+		a.Round == b.Round &&
+		a.IsEnded == b.IsEnded &&
+		slices.Equal(a.AlivePlayers, b.AlivePlayers) &&
+		slices.Equal(a.Players, b.Players)
+}
+
+// diffState 计算增量，玩家名单（加入或离开）变化时返回 nil，改发完整状态
+func diffState(prev, next protocol.GameStateData) *protocol.GameStateDeltaData {
+	if len(prev.Players) != len(next.Players) {
+		return nil
+	}
+
+	delta := &protocol.GameStateDeltaData{
+		Version:     next.Version,
+		BaseVersion: prev.Version,
+		IsEnded:     next.IsEnded,
+	}
+	if next.Phase != prev.Phase {
+		delta.Phase = next.Phase
+	}
+	if next.Round != prev.Round {
+		delta.Round = next.Round
+	}
+	if !slices.Equal(next.AlivePlayers, prev.AlivePlayers) {
+		delta.AlivePlayers = next.AlivePlayers
+	}
+
+	for i, info := range next.Players {
+		before := prev.Players[i]
+		if before.ID != info.ID {
+			return nil
+		}
+		if before == info {
+			continue
+		}
+
+		delta.Players = append(delta.Players, info)
+		if before.IsAlive && !info.IsAlive {
+			delta.Died = append(delta.Died, info.ID)
+		}
+		if before.IsReady != info.IsReady {
+			if delta.Ready == nil {
+				delta.Ready = make(map[string]bool)
+			}
+			delta.Ready[info.ID] = info.IsReady
+		}
+	}
+
+	return delta
+}
+
+// SendGameState 发送游戏状态给所有玩家
+//
+// 支持增量的客户端只收到与上一版相比的变化，旧客户端和只读观看者收到完整状态；状态没有变化时不发送。
+// 每名玩家的消息带上自己的私有视图（见 views.go），视图相同的玩家共用一条消息。
+func (r *Room) SendGameState() {
+	state := r.Engine().GetState()
+
+	full, delta, changed := r.stateSync.update(protocol.GameStateData{
+		Phase:        publicPhase(state.Phase),
+		Round:        state.Round,
+		Players:      r.playersInfo(state.Players, false),
+		AlivePlayers: state.AlivePlayers,
+		IsEnded:      state.IsEnded,
+	})
+	if !changed {
+		return
+	}
+
+	r.mu.RLock()
+	players := make([]*Player, 0, len(r.Players))
+	views := make(map[string]stateView, len(r.Players))
+	for _, player := range r.Players {
+		players = append(players, player)
+		views[player.ID] = r.playerView(player.ID, state)
+	}
+	r.mu.RUnlock()
+
+	fulls := newViewCache(func(view stateView) *protocol.Message {
+		msg, _ := protocol.NewMessage(protocol.MsgGameState, withView(full, view))
+		return msg
+	})
+	deltas := newViewCache(func(view stateView) *protocol.Message {
+		data := *delta
+		data.Teammates, data.KnownCamps, data.Lover = view.teammates, view.knownCamps, view.lover
+		msg, _ := protocol.NewMessage(protocol.MsgGameStateDelta, data)
+		return msg
+	})
+
+	for _, player := range players {
+		if delta != nil && player.ProtocolVersion >= stateDeltaVersion {
+			player.SendMessage(deltas.message(views[player.ID]))
+		} else {
+			player.SendMessage(fulls.message(views[player.ID]))
+		}
+	}
+
+	r.viewers.publish(fulls.message(stateView{}))
+}
+
+// sendFullState 把最近一次广播的完整状态发给一名玩家，用于重连和补发请求
+func (r *Room) sendFullState(player *Player, req *protocol.Message) bool {
+	full, ok := r.stateSync.current()
+	if !ok {
+		return false
+	}
+
+	state := r.Engine().GetState()
+	r.mu.RLock()
+	view := r.playerView(player.ID, state)
+	r.mu.RUnlock()
+
+	msg, _ := protocol.NewMessage(protocol.MsgGameState, withView(full, view))
+	if req != nil {
+		msg.ReplyTo(req)
+	}
+	player.SendMessage(msg)
+	return true
+}
+
+// handleResyncState 客户端发现状态版本不连续，补发完整状态
+func (h *MessageHandler) handleResyncState(playerID string) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+
+	if room.Engine() == nil || !room.sendFullState(player, h.request) {
+		return errors.New("game not started")
+	}
+
+	return nil
+}
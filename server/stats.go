@@ -0,0 +1,240 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultLeaderboardSize 排行榜默认条数
+	defaultLeaderboardSize = 10
+	// maxLeaderboardSize 排行榜最多条数
+	maxLeaderboardSize = 50
+)
+
+// GameRecord 一名玩家在一局中的结果
+type GameRecord struct {
+	Role     werewolf.RoleType
+	Camp     werewolf.Camp
+	Won      bool
+	Survived bool
+
+	Rated       bool // 排位对局
+	RatingDelta int
+}
+
+// apply 把本局结果累加到战绩上，返回新的战绩，不修改传入的统计
+func (g GameRecord) apply(stats protocol.AccountStats) protocol.AccountStats {
+	winsByCamp := make(map[werewolf.Camp]int, len(stats.WinsByCamp)+1)
+	for camp, n := range stats.WinsByCamp {
+		winsByCamp[camp] = n
+	}
+	roles := make(map[werewolf.RoleType]int, len(stats.Roles)+1)
+	for role, n := range stats.Roles {
+		roles[role] = n
+	}
+
+	stats.GamesPlayed++
+	roles[g.Role]++
+	if g.Won {
+		stats.Wins++
+		winsByCamp[g.Camp]++
+	}
+	if g.Survived {
+		stats.Survived++
+	}
+	if g.Rated {
+		stats.Rating = ratingOf(stats) + g.RatingDelta
+		stats.RatedGames++
+	}
+
+	stats.WinsByCamp, stats.Roles = winsByCamp, roles
+	return stats
+}
+
+// RecordGame 累加一局结果到账号战绩
+func (s *AccountService) RecordGame(accountID string, game GameRecord) error {
+	return s.store.RecordGame(accountID, game)
+}
+
+// Stats 查询账号战绩
+func (s *AccountService) Stats(username string) (protocol.AccountStats, error) {
+	account, err := s.store.GetByUsername(strings.TrimSpace(username))
+	if err != nil {
+		return protocol.AccountStats{}, err
+	}
+
+	stats := account.Stats
+	stats.Username = account.Username
+	return stats, nil
+}
+
+// Leaderboard 排行榜前 limit 名
+//
+// 按胜场排列时胜场相同再按胜率、局数，没有打过对局的账号不上榜；
+// 按排位积分排列时积分相同再按排位局数，没有打过排位的账号不上榜。
+func (s *AccountService) Leaderboard(by string, limit int) ([]protocol.AccountStats, error) {
+	if limit <= 0 {
+		limit = defaultLeaderboardSize
+	}
+	if limit > maxLeaderboardSize {
+		limit = maxLeaderboardSize
+	}
+
+	all, err := s.store.ListStats()
+	if err != nil {
+		return nil, err
+	}
+
+	byRating := by == protocol.LeaderboardByRating
+
+	entries := make([]protocol.AccountStats, 0, len(all))
+	for _, stats := range all {
+		if (byRating && stats.RatedGames > 0) || (!byRating && stats.GamesPlayed > 0) {
+			entries = append(entries, stats)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if byRating {
+			if a.Rating != b.Rating {
+				return a.Rating > b.Rating
+			}
+			if a.RatedGames != b.RatedGames {
+				return a.RatedGames > b.RatedGames
+			}
+			return a.Username < b.Username
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		// 比较胜率 a.Wins/a.GamesPlayed 与 b.Wins/b.GamesPlayed，交叉相乘避免浮点
+		if a.Wins*b.GamesPlayed != b.Wins*a.GamesPlayed {
+			return a.Wins*b.GamesPlayed > b.Wins*a.GamesPlayed
+		}
+		if a.GamesPlayed != b.GamesPlayed {
+			return a.GamesPlayed > b.GamesPlayed
+		}
+		return a.Username < b.Username
+	})
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// recordStats 对局正常结束后把结果记入各注册玩家的战绩，机器人和游客不统计
+//
+// 排位房间同时结算积分，返回各玩家的积分变化；非排位房间返回 nil。
+func (r *Room) recordStats(winner werewolf.Camp, players []protocol.PlayerInfo) map[string]int {
+	if r.accounts == nil || winner == werewolf.CampNone {
+		return nil
+	}
+
+	r.mu.RLock()
+	ranked := r.ranked
+	var rated []ratedPlayer
+	for _, p := range players {
+		player, ok := r.Players[p.ID]
+		if !ok || player.IsGuest || player.IsBot {
+			continue
+		}
+
+		// 从快照恢复、还没重新登录的玩家没有加载积分
+		rating := player.Rating
+		if rating == 0 {
+			rating = protocol.InitialRating
+		}
+		rated = append(rated, ratedPlayer{id: p.ID, role: p.RoleType, rating: rating})
+	}
+	r.mu.RUnlock()
+
+	var ratings map[string]int
+	// 恋人获胜时两大阵营都算输，不调整排位积分
+	if ranked && winner != protocol.CampLovers {
+		ratings = rateGame(rated, winner)
+	}
+
+	alive := make(map[string]bool, len(players))
+	for _, p := range players {
+		alive[p.ID] = p.IsAlive
+	}
+
+	for _, p := range rated {
+		camp := roleCamp(p.role)
+		record := GameRecord{
+			Role:        p.role,
+			Camp:        camp,
+			Won:         camp == winner || (winner == protocol.CampLovers && r.onLoversTeam(p.id)),
+			Survived:    alive[p.id],
+			Rated:       ratings != nil,
+			RatingDelta: ratings[p.id],
+		}
+		if err := r.accounts.RecordGame(p.id, record); err != nil {
+			r.logger.Warn("failed to record stats", "accountID", p.id, "error", err)
+		}
+	}
+
+	if ratings != nil {
+		r.mu.Lock()
+		for _, p := range rated {
+			if player, ok := r.Players[p.id]; ok {
+				player.Rating = p.rating + ratings[p.id]
+			}
+		}
+		r.mu.Unlock()
+	}
+
+	return ratings
+}
+
+// handleGetStats 处理查询战绩，不填用户名时查询自己
+func (h *MessageHandler) handleGetStats(playerID string, data *protocol.GetStatsData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	username := data.Username
+	if username == "" {
+		if player.IsGuest {
+			return errors.New("guests have no stats, register to track them")
+		}
+		username = player.Username
+	}
+
+	stats, err := h.server.accounts.Stats(username)
+	if err != nil {
+		return err
+	}
+
+	statsMsg, _ := protocol.NewMessage(protocol.MsgStats, protocol.StatsData{Stats: stats})
+	return player.SendMessage(statsMsg.ReplyTo(h.request))
+}
+
+// handleGetLeaderboard 处理查询排行榜
+func (h *MessageHandler) handleGetLeaderboard(playerID string, data *protocol.GetLeaderboardData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	by := data.By
+	if by != protocol.LeaderboardByRating {
+		by = protocol.LeaderboardByWins
+	}
+
+	entries, err := h.server.accounts.Leaderboard(by, data.Limit)
+	if err != nil {
+		return err
+	}
+
+	boardMsg, _ := protocol.NewMessage(protocol.MsgLeaderboard, protocol.LeaderboardData{By: by, Entries: entries})
+	return player.SendMessage(boardMsg.ReplyTo(h.request))
+}
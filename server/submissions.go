@@ -0,0 +1,92 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// changeableActions 阶段结束前可以改选的动作，引擎以阶段结束时最后一次提交为准
+var changeableActions = map[werewolf.ActionType]bool{
+	protocol.ActionKill:    true,
+	protocol.ActionCheck:   true,
+	protocol.ActionProtect: true,
+	protocol.ActionPoison:  true,
+	protocol.ActionVote:    true,
+}
+
+// submissions 当前阶段每名玩家最近一次被接受的选择，由 r.mu 保护
+//
+// 换了阶段或回合后旧记录自动作废，不需要在阶段变化时清理。
+type submissions struct {
+	round   int
+	phase   werewolf.PhaseType
+	choices map[string]string // "玩家ID/动作" -> 目标
+}
+
+// register 记下一次选择，返回同一阶段内之前的选择
+func (s *submissions) register(round int, phase werewolf.PhaseType, playerID string, actionType werewolf.ActionType, targetID string) (previous string, changed bool) {
+	if s.round != round || s.phase != phase || s.choices == nil {
+		*s = submissions{round: round, phase: phase, choices: make(map[string]string)}
+	}
+
+	key := playerID + "/" + string(actionType)
+	previous, changed = s.choices[key]
+	s.choices[key] = targetID
+	return previous, changed
+}
+
+// registerSubmission 动作被引擎接受后登记为当前选择，并告知提交者阶段结束前仍可改选
+func (r *Room) registerSubmission(playerID string, actionType werewolf.ActionType, targetID string, round int) {
+	if !changeableActions[actionType] {
+		return
+	}
+
+	r.mu.Lock()
+	phase := r.lastPhase
+	previous, changed := r.submissions.register(round, phase, playerID, actionType, targetID)
+	player := r.Players[playerID]
+	r.mu.Unlock()
+
+	if player == nil {
+		return
+	}
+
+	data := protocol.ActionPendingData{
+		Round:      round,
+		Phase:      phase,
+		ActionType: actionType,
+		TargetID:   targetID,
+		TargetSeat: r.PlayerInfo(targetID).Seat,
+	}
+	if changed && previous != targetID {
+		data.PreviousTargetID = previous
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgActionPending, data)
+	player.SendMessage(msg)
+}
+
+// finalActions 每名玩家每回合每种可改选动作只保留最后一次提交，统计和复盘按最终选择计算
+func finalActions(actions []ActionRecord) []ActionRecord {
+	type key struct {
+		round      int
+		playerID   string
+		actionType werewolf.ActionType
+		settled    bool
+	}
+
+	last := make(map[key]int, len(actions))
+	for i, action := range actions {
+		if changeableActions[action.ActionType] {
+			last[key{action.Round, action.PlayerID, action.ActionType, action.Settled}] = i
+		}
+	}
+
+	result := make([]ActionRecord, 0, len(actions))
+	for i, action := range actions {
+		if !changeableActions[action.ActionType] || last[key{action.Round, action.PlayerID, action.ActionType, action.Settled}] == i {
+			result = append(result, action)
+		}
+	}
+	return result
+}
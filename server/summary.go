@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// GameSummary 对局结束后投递给房主配置地址的战报
+type GameSummary struct {
+	RoomID    string                `json:"roomID"`
+	RoomName  string                `json:"roomName"`
+	Winner    werewolf.Camp         `json:"winner"`
+	Reason    string                `json:"reason,omitempty"`
+	Round     int                   `json:"round"`
+	Players   []protocol.PlayerInfo `json:"players"`
+	ReplayURL string                `json:"replayURL,omitempty"`
+	EndedAt   time.Time             `json:"endedAt"`
+
+	Rejections []PlayerRejections `json:"rejections,omitempty"` // 各玩家被拒绝的动作统计
+}
+
+// validateWebhookURL 校验房间级战报地址，只接受 http/https
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return errors.Wrap(err, "invalid summary webhook")
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("summary webhook must be an http(s) URL: %s", raw)
+	}
+
+	return nil
+}
+
+// replayURL 拼出对局回放链接，未配置回放地址时为空
+func (r *Room) replayURL() string {
+	if r.replayBaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(r.replayBaseURL, "/") + "/" + url.PathEscape(r.ID)
+}
+
+// sendSummary 对局结束后异步投递战报，房间和服务器都没有配置地址时跳过
+func (r *Room) sendSummary(winner werewolf.Camp, reason string, players []protocol.PlayerInfo) {
+	if r.summaryWebhook == "" || r.webhook == nil {
+		return
+	}
+
+	summary := GameSummary{
+		RoomID:    r.ID,
+		RoomName:  r.Name,
+		Winner:    winner,
+		Reason:    reason,
+		Round:     r.lastRound,
+		Players:   players,
+		ReplayURL: r.replayURL(),
+		EndedAt:   protocol.Now(),
+
+		Rejections: r.Rejections(),
+	}
+
+	r.webhook.PostAsync(r.summaryWebhook, summary)
+
+	r.logger.Info("game summary queued", "url", r.summaryWebhook)
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Zereker/game/engine"
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/socket"
+	"github.com/Zereker/werewolf"
+)
+
+// waitTimeout 等待异步消息和引擎事件的最长时间
+const waitTimeout = 2 * time.Second
+
+// testTable 使用模拟引擎的服务器和一桌已登录的玩家，玩家收到的消息记在收件箱里
+type testTable struct {
+	t       *testing.T
+	server  *Server
+	handler *MessageHandler
+	players []*Player
+
+	mu    sync.Mutex
+	inbox map[string][]*protocol.Message
+}
+
+// newTestTable 创建服务器并登录 n 名玩家，开局倒计时为 0
+func newTestTable(t *testing.T, n int) *testTable {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := DefaultConfig()
+	config.Engine = engine.NewMock
+	config.StartCountdown = 0
+
+	tt := &testTable{
+		t:     t,
+		inbox: make(map[string][]*protocol.Message),
+	}
+	tt.server = NewServer(config, nil, logger)
+	tt.handler = NewMessageHandler(tt.server, logger)
+
+	for i := 0; i < n; i++ {
+		player := NewPlayer(string(rune('a'+i))+"-player", nil)
+		tt.connect(player)
+		if err := tt.server.AddPlayer(player); err != nil {
+			t.Fatalf("add player: %v", err)
+		}
+		tt.players = append(tt.players, player)
+	}
+
+	return tt
+}
+
+// connect 给玩家接上把消息记进收件箱的发送队列
+func (tt *testTable) connect(player *Player) *sendQueue {
+	playerID := player.ID
+	queue := newSendQueue(func(msg socket.Message) error {
+		if m, ok := msg.(*protocol.Message); ok {
+			tt.mu.Lock()
+			tt.inbox[playerID] = append(tt.inbox[playerID], m)
+			tt.mu.Unlock()
+		}
+		return nil
+	}, OverflowDrop, func() {}, nil, tt.server.logger)
+
+	player.ProtocolVersion = protocol.ProtocolVersion
+	player.attach(nil, queue)
+	return queue
+}
+
+// send 以玩家身份发送一条消息，返回处理器的错误
+func (tt *testTable) send(player *Player, msgType protocol.MessageType, data interface{}) error {
+	msg, err := protocol.NewMessage(msgType, data)
+	if err != nil {
+		tt.t.Fatalf("new message: %v", err)
+	}
+	return tt.handler.HandleMessage(player.ID, msg)
+}
+
+// must 同 send，出错时测试失败
+func (tt *testTable) must(player *Player, msgType protocol.MessageType, data interface{}) {
+	tt.t.Helper()
+
+	if err := tt.send(player, msgType, data); err != nil {
+		tt.t.Fatalf("%s from %s: %v", msgType, player.Username, err)
+	}
+}
+
+// seat 创建房间并让其余玩家依次加入，返回房间
+func (tt *testTable) seat(roles ...werewolf.RoleType) *Room {
+	tt.t.Helper()
+
+	return tt.seatWith(protocol.CreateRoomData{RoomName: "测试房间", Roles: roles})
+}
+
+// seatWith 同 seat，按给定的设置创建房间
+func (tt *testTable) seatWith(data protocol.CreateRoomData) *Room {
+	tt.t.Helper()
+
+	owner := tt.players[0]
+	tt.must(owner, protocol.MsgCreateRoom, data)
+	for _, player := range tt.players[1:len(data.Roles)] {
+		tt.must(player, protocol.MsgJoinRoom, protocol.JoinRoomData{RoomID: owner.RoomID})
+	}
+
+	return tt.server.GetRoom(owner.RoomID)
+}
+
+// start 坐满房间、全员准备并等到第一夜开始
+func (tt *testTable) start(roles ...werewolf.RoleType) *Room {
+	tt.t.Helper()
+
+	return tt.startWith(protocol.CreateRoomData{RoomName: "测试房间", Roles: roles})
+}
+
+// startWith 同 start，按给定的设置创建房间
+func (tt *testTable) startWith(data protocol.CreateRoomData) *Room {
+	tt.t.Helper()
+
+	room := tt.seatWith(data)
+	for _, player := range tt.players[:len(data.Roles)] {
+		tt.must(player, protocol.MsgReady, protocol.ReadyData{})
+	}
+	tt.waitPhase(room, werewolf.PhaseNight, 1)
+
+	return room
+}
+
+// role 按引擎的分配找到扮演某个角色的第 n 名玩家（从 0 开始）
+func (tt *testTable) role(room *Room, role werewolf.RoleType, n int) *Player {
+	tt.t.Helper()
+
+	for _, ps := range room.Engine().GetState().Players {
+		if ps.Role != role {
+			continue
+		}
+		if n == 0 {
+			return tt.server.GetPlayer(ps.ID)
+		}
+		n--
+	}
+
+	tt.t.Fatalf("no %s in the game", role)
+	return nil
+}
+
+// act 提交一个以玩家为目标的动作，等到引擎接受或房间告知被拒绝
+//
+// 夜里被接受的动作结果要到天亮才发出，以房间记下的动作为准。
+func (tt *testTable) act(room *Room, player *Player, actionType werewolf.ActionType, target *Player) protocol.ActionResultData {
+	tt.t.Helper()
+
+	data := protocol.PerformActionData{ActionType: actionType}
+	if target != nil {
+		data.TargetID = target.ID
+	}
+
+	accepted := func() int {
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+
+		n := 0
+		for _, action := range room.actions {
+			if action.PlayerID == player.ID && action.ActionType == actionType {
+				n++
+			}
+		}
+		return n
+	}
+
+	before, results := accepted(), len(tt.received(player, protocol.MsgActionResult))
+	if err := tt.send(player, protocol.MsgPerformAction, data); err != nil {
+		return protocol.ActionResultData{Message: err.Error()}
+	}
+
+	var result protocol.ActionResultData
+	tt.eventually(func() bool {
+		if accepted() > before {
+			result.Success = true
+			return true
+		}
+		if msgs := tt.received(player, protocol.MsgActionResult); len(msgs) > results {
+			msgs[len(msgs)-1].UnmarshalData(&result)
+			return true
+		}
+		return false
+	}, "result of %s from %s", actionType, player.Username)
+
+	return result
+}
+
+// speakAll 让存活玩家按发言顺序依次发言，直到进入投票
+func (tt *testTable) speakAll(room *Room) {
+	tt.t.Helper()
+
+	tt.eventually(func() bool {
+		state := room.Engine().GetState()
+		if state.Phase != werewolf.PhaseDay {
+			return true
+		}
+		// 不是发言人的提交被拒绝，轮到谁就是谁的发言生效
+		for _, playerID := range state.AlivePlayers {
+			tt.send(tt.server.GetPlayer(playerID), protocol.MsgPerformAction, protocol.PerformActionData{
+				ActionType: protocol.ActionSpeak,
+				Data:       map[string]interface{}{"content": "我是好人"},
+			})
+		}
+		return false
+	}, "everyone to speak")
+}
+
+// pass 玩家放弃本阶段的行动，返回房间给出的结果
+func (tt *testTable) pass(player *Player) protocol.ActionResultData {
+	tt.t.Helper()
+
+	results := len(tt.received(player, protocol.MsgActionResult))
+	if err := tt.send(player, protocol.MsgPass, nil); err != nil {
+		return protocol.ActionResultData{Message: err.Error()}
+	}
+
+	var result protocol.ActionResultData
+	tt.eventually(func() bool {
+		return len(tt.received(player, protocol.MsgActionResult)) > results
+	}, "result of pass from %s", player.Username)
+	msgs := tt.received(player, protocol.MsgActionResult)
+	msgs[len(msgs)-1].UnmarshalData(&result)
+
+	return result
+}
+
+// passAll 让存活玩家都放弃行动，直到引擎离开当前阶段
+func (tt *testTable) passAll(room *Room) {
+	tt.t.Helper()
+
+	start := room.Engine().GetState()
+	phase, round := start.Phase, start.Round
+	tt.eventually(func() bool {
+		state := room.Engine().GetState()
+		if state.Phase != phase || state.Round != round {
+			return true
+		}
+		// 白天只有轮到的发言人能跳过，其他人的放弃被拒绝
+		for _, playerID := range state.AlivePlayers {
+			tt.send(tt.server.GetPlayer(playerID), protocol.MsgPass, nil)
+		}
+		return false
+	}, "everyone to pass in phase %s", phase)
+}
+
+// received 玩家收到的某种消息
+func (tt *testTable) received(player *Player, msgType protocol.MessageType) []*protocol.Message {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	var msgs []*protocol.Message
+	for _, msg := range tt.inbox[player.ID] {
+		if msg.Type == msgType {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// waitFor 等到玩家收到某种消息，返回最后一条
+func (tt *testTable) waitFor(player *Player, msgType protocol.MessageType) *protocol.Message {
+	tt.t.Helper()
+
+	var msg *protocol.Message
+	tt.eventually(func() bool {
+		msgs := tt.received(player, msgType)
+		if len(msgs) == 0 {
+			return false
+		}
+		msg = msgs[len(msgs)-1]
+		return true
+	}, "%s for %s", msgType, player.Username)
+
+	return msg
+}
+
+// waitPhase 等到引擎进入某一轮的某个阶段
+func (tt *testTable) waitPhase(room *Room, phase werewolf.PhaseType, round int) {
+	tt.t.Helper()
+
+	tt.eventually(func() bool {
+		state := room.Engine().GetState()
+		return state.Phase == phase && state.Round == round
+	}, "phase %s of round %d", phase, round)
+}
+
+// eventually 轮询直到 cond 成立，超时则测试失败
+func (tt *testTable) eventually(cond func() bool, format string, args ...interface{}) {
+	tt.t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			tt.t.Fatalf("timed out waiting for "+format, args...)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
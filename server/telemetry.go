@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+)
+
+// Telemetry 匿名使用统计，只有配置了上报地址才启用
+//
+// 只上报聚合数量（开局数、平均人数、各功能的使用次数），不含玩家、账号、房间的任何标识，
+// 帮助维护者决定功能的优先级。所有方法对 nil 接收者安全，未启用时房间无需判断。
+type Telemetry struct {
+	url     string
+	webhook *WebhookClient
+	logger  *slog.Logger
+
+	mu            sync.Mutex
+	since         time.Time
+	gamesStarted  int
+	gamesFinished int
+	seats         int            // 已开局对局的座位数之和
+	features      map[string]int // 功能 -> 使用该功能的对局数
+}
+
+// TelemetryReport 一个上报周期的统计
+type TelemetryReport struct {
+	PeriodStart     time.Time      `json:"periodStart"`
+	PeriodEnd       time.Time      `json:"periodEnd"`
+	GamesStarted    int            `json:"gamesStarted"`
+	GamesFinished   int            `json:"gamesFinished"`
+	AverageRoomSize float64        `json:"averageRoomSize"`
+	Features        map[string]int `json:"features,omitempty"`
+}
+
+// NewTelemetry 创建使用统计，url 为空时返回 nil（不启用）
+func NewTelemetry(url string, webhook *WebhookClient, logger *slog.Logger) *Telemetry {
+	if url == "" {
+		return nil
+	}
+
+	return &Telemetry{
+		url:      url,
+		webhook:  webhook,
+		logger:   logger,
+		since:    protocol.Now(),
+		features: make(map[string]int),
+	}
+}
+
+// gameStarted 记录一局开局
+func (t *Telemetry) gameStarted(seats int, features []string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.gamesStarted++
+	t.seats += seats
+	for _, feature := range features {
+		t.features[feature]++
+	}
+}
+
+// gameFinished 记录一局结束
+func (t *Telemetry) gameFinished() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.gamesFinished++
+}
+
+// collect 生成本周期的统计并开始新的周期
+func (t *Telemetry) collect() TelemetryReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := protocol.Now()
+	report := TelemetryReport{
+		PeriodStart:   t.since,
+		PeriodEnd:     now,
+		GamesStarted:  t.gamesStarted,
+		GamesFinished: t.gamesFinished,
+		Features:      t.features,
+	}
+	if t.gamesStarted > 0 {
+		report.AverageRoomSize = float64(t.seats) / float64(t.gamesStarted)
+	}
+
+	t.since = now
+	t.gamesStarted, t.gamesFinished, t.seats = 0, 0, 0
+	t.features = make(map[string]int)
+
+	return report
+}
+
+// flush 上报本周期的统计，没有任何对局时跳过
+func (t *Telemetry) flush() {
+	report := t.collect()
+	if report.GamesStarted == 0 && report.GamesFinished == 0 {
+		return
+	}
+
+	if err := t.webhook.Post(t.url, report); err != nil {
+		t.logger.Warn("telemetry report failed", "error", err)
+		return
+	}
+
+	t.logger.Debug("telemetry reported", "gamesStarted", report.GamesStarted)
+}
+
+// RunTelemetry 按间隔上报使用统计，直到 ctx 结束；结束时上报最后一个周期
+func (s *Server) RunTelemetry(ctx context.Context) {
+	if s.telemetry == nil || s.config.TelemetryInterval <= 0 {
+		return
+	}
+
+	s.logger.Info("anonymous telemetry enabled", "url", s.config.TelemetryURL, "interval", s.config.TelemetryInterval)
+
+	ticker := time.NewTicker(s.config.TelemetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.telemetry.flush()
+			return
+		case <-ticker.C:
+			s.telemetry.flush()
+		}
+	}
+}
+
+// telemetryFeatures 本局用到的可选功能，只记录功能名（需持有锁）
+func (r *Room) telemetryFeatures() []string {
+	var features []string
+
+	if r.rules.Sheriff {
+		features = append(features, "sheriff")
+	}
+	if r.rules.LastWords {
+		features = append(features, "last_words")
+	}
+	if r.rules.WitchSelfSave != protocol.DefaultGameRules().WitchSelfSave {
+		features = append(features, "witch_self_save_"+r.rules.WitchSelfSave)
+	}
+	if r.rules.FirstNightReveal != protocol.RevealCause {
+		features = append(features, "first_night_"+r.rules.FirstNightReveal)
+	}
+	if r.rules.HiddenVotes {
+		features = append(features, "hidden_votes")
+	}
+	if r.rules.WolfKill == protocol.WolfKillMajority {
+		features = append(features, "wolf_kill_majority")
+	}
+	if r.guardRules != protocol.DefaultGuardRules() {
+		features = append(features, "custom_guard_rules")
+	}
+	if r.anonymous {
+		features = append(features, "anonymous_seats")
+	}
+	if len(r.bots) > 0 {
+		features = append(features, "bots")
+	}
+	if r.summaryWebhook != "" {
+		features = append(features, "summary_webhook")
+	}
+
+	return features
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// thiefExtraRoles 有盗贼时多出的底牌数，这些角色不发给玩家
+const thiefExtraRoles = 2
+
+// thiefTimeout 盗贼选牌的时限，超时随机拿一张
+const thiefTimeout = 30 * time.Second
+
+// thiefCardKey 动作数据中盗贼所拿底牌编号的键，见 PerformActionData.Card
+const thiefCardKey = "card"
+
+// seatCount 房间的座位数：有盗贼时两张底牌不发给玩家
+func seatCount(roles []werewolf.RoleType) int {
+	if slices.Contains(roles, protocol.RoleTypeThief) {
+		return len(roles) - thiefExtraRoles
+	}
+	return len(roles)
+}
+
+// seats 房间的座位数（需持有锁）
+func (r *Room) seats() int {
+	return seatCount(r.Roles)
+}
+
+// dealExtras 有盗贼时从盗贼以外的角色中随机抽出两张底牌，返回发给玩家的角色和底牌
+func dealExtras(roles []werewolf.RoleType) (inPlay, extras []werewolf.RoleType) {
+	var candidates []int
+	for i, role := range roles {
+		if role != protocol.RoleTypeThief {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == len(roles) || len(candidates) < thiefExtraRoles {
+		return roles, nil
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	drawn := make(map[int]bool, thiefExtraRoles)
+	for _, i := range candidates[:thiefExtraRoles] {
+		drawn[i] = true
+	}
+
+	for i, role := range roles {
+		if drawn[i] {
+			extras = append(extras, role)
+		} else {
+			inPlay = append(inPlay, role)
+		}
+	}
+	return inPlay, extras
+}
+
+// thiefCard 动作数据中盗贼所拿的底牌编号，没有时返回 0
+func thiefCard(data map[string]interface{}) int {
+	switch card := data[thiefCardKey].(type) {
+	case int:
+		return card
+	case float64:
+		return int(card)
+	default:
+		return 0
+	}
+}
+
+// thiefOf 还没选牌的盗贼，没有时返回空（需持有锁）
+func (r *Room) thiefOf() string {
+	for playerID, role := range r.special.roles {
+		if role == protocol.RoleTypeThief {
+			return playerID
+		}
+	}
+	return ""
+}
+
+// startThief 开局后把底牌交给盗贼并开始计时（需持有锁）
+func (r *Room) startThief(extras []werewolf.RoleType) {
+	thiefID := r.thiefOf()
+	if thiefID == "" || len(extras) == 0 {
+		return
+	}
+
+	r.special.extras = extras
+	r.armThiefTimer(thiefID)
+}
+
+// armThiefTimer 盗贼选牌计时，机器人扮演的盗贼稍等片刻后随机选（需持有锁）
+func (r *Room) armThiefTimer(thiefID string) {
+	delay := r.scaled(thiefTimeout)
+	if _, isBot := r.bots[thiefID]; isBot {
+		delay = botMinDelay
+	}
+
+	if r.special.thiefTimer != nil {
+		r.special.thiefTimer.Stop()
+	}
+	r.special.thiefTimer = time.AfterFunc(delay, func() { r.thiefTimeout(thiefID) })
+	r.special.thiefDeadline = time.Now().Add(delay)
+}
+
+// thiefCountdown 盗贼选牌剩余的秒数
+func (r *Room) thiefCountdown() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return secondsUntil(r.special.thiefDeadline)
+}
+
+// thiefPending 盗贼是否还没选牌，选牌之前其他玩家不能行动
+func (r *Room) thiefPending() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.special.extras) > 0
+}
+
+// canSteal 玩家是否是还没选牌的盗贼
+func (r *Room) canSteal(playerID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.special.roles[playerID] == protocol.RoleTypeThief && len(r.special.extras) > 0
+}
+
+// thiefTimeout 盗贼在时限内没有选牌，随机拿一张
+func (r *Room) thiefTimeout(thiefID string) {
+	if err := r.steal(thiefID, rand.Intn(thiefExtraRoles)+1); err != nil {
+		return
+	}
+
+	r.mu.RLock()
+	_, isBot := r.bots[thiefID]
+	r.mu.RUnlock()
+
+	if !isBot {
+		r.announcePrivate(thiefID, "超时未选择，已随机拿了一张底牌")
+		r.missedAction(thiefID)
+	}
+}
+
+// steal 盗贼拿第 card 张底牌（从1开始）换掉自己的身份，另一张底牌弃掉
+//
+// 换牌由房间结算，不计入重放的动作记录；换好后重新下发角色信息，并让等待中的机器人开始行动。
+func (r *Room) steal(thiefID string, card int) error {
+	if r.specialRole(thiefID) != protocol.RoleTypeThief {
+		return rejected(RejectOther, errors.New("only the thief can take a card"))
+	}
+
+	r.mu.Lock()
+	extras := r.special.extras
+	if len(extras) == 0 {
+		r.mu.Unlock()
+		return rejected(RejectRule, errors.New("the thief has already taken a card"))
+	}
+	if card < 1 || card > len(extras) {
+		r.mu.Unlock()
+		return rejected(RejectInvalidTarget, errors.Errorf("card must be between 1 and %d", len(extras)))
+	}
+	if r.special.thiefTimer != nil {
+		r.special.thiefTimer.Stop()
+		r.special.thiefTimer = nil
+	}
+	r.special.extras = nil
+
+	chosen := extras[card-1]
+	role := r.swapRole(thiefID, chosen)
+	r.mu.Unlock()
+
+	r.logEvent(protocol.EventThiefSwapped, "盗贼换牌", map[string]interface{}{
+		"playerID": thiefID,
+		"role":     role,
+		"extras":   extras,
+	})
+
+	if role != chosen {
+		r.announcePrivate(thiefID, "无法换成这张底牌，你将以平民身份继续游戏")
+	}
+
+	r.sendRoleInfo(thiefID)
+	r.SendGameState()
+	r.pushAllowedSkills()
+
+	state := r.Engine().GetState()
+	r.driveBots(state.Phase, state.Round)
+
+	return nil
+}
+
+// swapRole 让盗贼成为所选的角色，返回实际换成的角色（需持有写锁）
+//
+// 基础角色是平民的（平民、白痴、骑士、丘比特）只需记下房间实现的角色；
+// 其余角色要由引擎结算，需要重新发牌，失败时盗贼以平民身份继续。
+func (r *Room) swapRole(thiefID string, role werewolf.RoleType) werewolf.RoleType {
+	base := role
+	if b, ok := baseRoles[role]; ok {
+		base = b
+	}
+
+	if base != werewolf.RoleTypeVillager {
+		if err := r.redeal(thiefID, base); err != nil {
+			r.playerLogger(thiefID).Warn("thief swap failed, keeping villager",
+				"role", role,
+				"error", err)
+			role = werewolf.RoleTypeVillager
+		}
+	}
+
+	if _, special := baseRoles[role]; special {
+		r.special.roles[thiefID] = role
+	} else {
+		delete(r.special.roles, thiefID)
+	}
+	return role
+}
+
+// redeal 按当前分配重新开局，只把盗贼的基础角色换掉（需持有写锁）
+//
+// 引擎不支持开局后修改角色，只能新建引擎并按座位顺序给出角色；引擎不按座位顺序发牌时分配会对不上，
+// 此时放弃换牌，继续使用原来的引擎。盗贼选牌之前其他玩家不能行动，新引擎不需要重放动作；
+// 被替换的引擎之后发出的事件不再处理（见 subscribeEvents）。
+func (r *Room) redeal(thiefID string, base werewolf.RoleType) error {
+	want := make(map[string]werewolf.RoleType, len(r.order))
+	for _, ps := range r.Engine().GetState().Players {
+		want[ps.ID] = ps.Role
+	}
+	want[thiefID] = base
+
+	roles := make([]werewolf.RoleType, 0, len(r.order))
+	for _, playerID := range r.order {
+		roles = append(roles, want[playerID])
+	}
+
+	eng, err := r.newGameEngine(roles)
+	if err != nil {
+		return err
+	}
+	if err := callEngine("start", eng.Start); err != nil {
+		return errors.Wrap(err, "start engine")
+	}
+
+	for _, ps := range eng.GetState().Players {
+		if want[ps.ID] != ps.Role {
+			return errors.New("engine did not deal roles in seat order")
+		}
+	}
+
+	r.setEngine(eng)
+	return nil
+}
+
+// sendRoleInfo 盗贼换牌后重新下发角色信息：发给盗贼，换成狼人时也发给其他狼人
+func (r *Room) sendRoleInfo(thiefID string) {
+	state := r.Engine().GetState()
+
+	wolf := false
+	for _, ps := range state.Players {
+		if ps.ID == thiefID && ps.Role == werewolf.RoleTypeWerewolf {
+			wolf = true
+		}
+	}
+
+	r.mu.RLock()
+	infos := make(map[*Player]protocol.RoleInfoData)
+	for _, ps := range state.Players {
+		if ps.ID != thiefID && (!wolf || ps.Role != werewolf.RoleTypeWerewolf) {
+			continue
+		}
+		if player := r.Players[ps.ID]; player != nil {
+			infos[player] = r.roleInfo(ps.ID, state)
+		}
+	}
+	r.mu.RUnlock()
+
+	for player, info := range infos {
+		msg, _ := protocol.NewMessage(protocol.MsgRoleInfo, info)
+		player.SendMessage(msg)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 服务器链路追踪的 instrumentation 名称
+const tracerName = "github.com/Zereker/game/server"
+
+// tracer 服务器的链路追踪
+//
+// 未配置 OTLP 地址时全局 TracerProvider 是空实现，创建和结束 span 几乎没有开销，调用方无需判断。
+var tracer = otel.Tracer(tracerName)
+
+// SetupTracing 配置 OTLP/HTTP 导出链路追踪，endpoint 形如 http://localhost:4318
+//
+// 返回的函数在退出前调用，把缓冲中的 span 发送出去。
+func SetupTracing(endpoint string, logger *slog.Logger) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, errors.Wrap(err, "create otlp exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", "werewolf-server"),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "create trace resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("tracing error", "error", err)
+	}))
+
+	logger.Info("tracing enabled", "endpoint", endpoint)
+
+	return provider.Shutdown, nil
+}
+
+// endSpan 结束 span，出错时记录错误
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startMessageSpan 为一条入站消息创建 span
+func startMessageSpan(requestID, playerID string, msg *protocol.Message) (context.Context, trace.Span) {
+	return tracer.Start(context.Background(), "HandleMessage "+string(msg.Type),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("message.type", string(msg.Type)),
+			attribute.String("request.id", requestID),
+			attribute.String("player.id", playerID),
+		))
+}
+
+// startRoomSpan 为房间内的一步操作创建 span，带上房间ID
+func (r *Room) startRoomSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(append(attrs, attribute.String("room.id", r.ID))...))
+}
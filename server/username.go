@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+// 用户名长度限制，按字符计
+const (
+	minUsernameLength = 2
+	maxUsernameLength = 16
+)
+
+// UsernameError 用户名不可用，Code 随错误消息发给客户端，提示玩家换一个名字
+type UsernameError struct {
+	Code string
+	Err  error
+}
+
+// Error 实现 error 接口
+func (e *UsernameError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *UsernameError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode 实现 codedError
+func (e *UsernameError) ErrorCode() string {
+	return e.Code
+}
+
+// validateUsername 检查用户名长度和字符，返回去掉首尾空白后的用户名
+//
+// 允许中英文字母、数字、下划线和短横线，中间不能有空格，避免白天发言时认错人。
+func validateUsername(username string) (string, error) {
+	username = strings.TrimSpace(username)
+
+	n := utf8.RuneCountInString(username)
+	if n < minUsernameLength || n > maxUsernameLength {
+		return "", &UsernameError{
+			Code: protocol.ErrorCodeInvalidUsername,
+			Err:  errors.Errorf("username must be %d to %d characters", minUsernameLength, maxUsernameLength),
+		}
+	}
+
+	for _, r := range username {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return "", &UsernameError{
+				Code: protocol.ErrorCodeInvalidUsername,
+				Err:  errors.Errorf("username contains invalid character %q", r),
+			}
+		}
+	}
+
+	return username, nil
+}
+
+// errUsernameTaken 用户名已被其他玩家使用
+func errUsernameTaken(username string) error {
+	return &UsernameError{
+		Code: protocol.ErrorCodeUsernameTaken,
+		Err:  errors.Errorf("username %s is already in use", username),
+	}
+}
+
+// checkUsername 检查用户名是否合法且没有其他在线玩家在用，返回去掉首尾空白后的用户名
+//
+// exceptID 为改名的玩家自己，新登录时为空。
+func (s *Server) checkUsername(username, exceptID string) (string, error) {
+	username, err := validateUsername(username)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.usernameTaken(username, exceptID) {
+		return "", errUsernameTaken(username)
+	}
+	return username, nil
+}
+
+// checkGuestUsername 在 checkUsername 的基础上不允许游客使用已注册的用户名
+func (s *Server) checkGuestUsername(username, exceptID string) (string, error) {
+	username, err := s.checkUsername(username, exceptID)
+	if err != nil {
+		return "", err
+	}
+
+	registered, err := s.accounts.IsRegistered(username)
+	if err != nil {
+		return "", err
+	}
+	if registered {
+		return "", errors.New("username is registered, password required")
+	}
+	return username, nil
+}
+
+// usernameTaken 房间中是否已有其他玩家使用这个用户名，不区分大小写（需持有锁）
+func (r *Room) usernameTaken(username, exceptID string) bool {
+	for id, player := range r.Players {
+		if id != exceptID && strings.EqualFold(player.Username, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// usernameTaken 是否有其他在线玩家使用这个用户名，等待重连的玩家也算在线（需持有锁）
+func (s *Server) usernameTaken(username, exceptID string) bool {
+	for id, player := range s.players {
+		if id != exceptID && !player.IsBot && strings.EqualFold(player.Username, username) {
+			return true
+		}
+	}
+	return false
+}
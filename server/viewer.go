@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// viewerBufferSize 每个观看连接缓冲的消息数，看得慢的观众丢消息而不影响对局
+const viewerBufferSize = 64
+
+// viewerKeepAlive 没有消息时发送 SSE 注释的间隔，避免代理断开空闲连接
+const viewerKeepAlive = 15 * time.Second
+
+// viewerHub 房间的只读观看者，收到与每个座位相同的公开广播
+//
+// 设置了延迟时广播先进入房间的延迟队列，到时间后才转给观看者，
+// 避免观众把对局进展实时告诉场上的玩家。
+type viewerHub struct {
+	mu    sync.Mutex
+	subs  map[chan viewerEvent]time.Time // 观看者及其订阅时间
+	delay time.Duration                  // 转给观看者之前的延迟，0 表示实时转发
+	queue []delayedEvent                 // 等待转发的事件，按到期时间排列
+	timer *time.Timer                    // 队首事件到期时转发
+
+	aliases *seatAliases // 匿名对局进行中转发的广播把玩家ID换成化名，见 seating.go
+}
+
+// viewerEvent 推给观看者的一条 SSE 事件
+type viewerEvent struct {
+	name string
+	data interface{}
+}
+
+// delayedEvent 延迟队列中的事件，to 为空时转给发布时已订阅的所有观看者
+type delayedEvent struct {
+	at    time.Time
+	event viewerEvent
+	to    chan viewerEvent
+}
+
+// subscribe 开始接收房间广播，返回的函数取消订阅
+func (h *viewerHub) subscribe() (chan viewerEvent, func()) {
+	ch := make(chan viewerEvent, viewerBufferSize)
+
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan viewerEvent]time.Time)
+	}
+	h.subs[ch] = time.Now()
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// count 当前的观看者数
+func (h *viewerHub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
+
+// delayed 观看者是否延迟收到广播
+func (h *viewerHub) delayed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.delay > 0
+}
+
+// publish 把广播转给所有观看者，不阻塞，缓冲满的观看者丢弃这条消息
+func (h *viewerHub) publish(msg *protocol.Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	event := viewerEvent{name: string(msg.Type), data: msg}
+	if h.aliases != nil {
+		event.data = h.aliases.mask(msg, "")
+	}
+
+	if h.delay <= 0 {
+		for ch := range h.subs {
+			offer(ch, event)
+		}
+		return
+	}
+
+	h.queue = append(h.queue, delayedEvent{at: time.Now().Add(h.delay), event: event})
+	h.schedule()
+}
+
+// setAliases 匿名对局开始或结束时更换化名表，nil 表示转发真实ID
+func (h *viewerHub) setAliases(aliases *seatAliases) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.aliases = aliases
+}
+
+// sendSnapshot 延迟时把观看者订阅时的快照排进队列，排在订阅之后的广播前面
+func (h *viewerHub) sendSnapshot(ch chan viewerEvent, snapshot ViewerSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	since, ok := h.subs[ch]
+	if !ok {
+		return
+	}
+
+	at := since.Add(h.delay)
+	i := slices.IndexFunc(h.queue, func(e delayedEvent) bool { return !e.at.Before(at) })
+	if i < 0 {
+		i = len(h.queue)
+	}
+	h.queue = slices.Insert(h.queue, i, delayedEvent{
+		at:    at,
+		event: viewerEvent{name: "SNAPSHOT", data: snapshot},
+		to:    ch,
+	})
+	h.schedule()
+}
+
+// schedule 队列不空且没有计时时，按队首的到期时间计时（需持有锁）
+func (h *viewerHub) schedule() {
+	if h.timer != nil || len(h.queue) == 0 {
+		return
+	}
+	h.timer = time.AfterFunc(time.Until(h.queue[0].at), h.flush)
+}
+
+// flush 转发所有已到期的事件，观看者断开后发给它的事件直接丢弃
+func (h *viewerHub) flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	n := 0
+	for _, e := range h.queue {
+		if e.at.After(now) {
+			break
+		}
+		n++
+
+		if e.to != nil {
+			if _, ok := h.subs[e.to]; ok {
+				offer(e.to, e.event)
+			}
+			continue
+		}
+
+		// 只转给广播发布时已经订阅的观看者，之前的进展已经包含在它们的快照里
+		published := e.at.Add(-h.delay)
+		for ch, since := range h.subs {
+			if !published.Before(since) {
+				offer(ch, e.event)
+			}
+		}
+	}
+
+	h.queue = slices.Delete(h.queue, 0, n)
+	h.timer = nil
+	h.schedule()
+}
+
+// offer 不阻塞地把事件交给观看者，缓冲满时丢弃
+func offer(ch chan viewerEvent, event viewerEvent) {
+	select {
+	case ch <- event:
+	default:
+	}
+}
+
+// ViewerSnapshot 观看连接建立时的房间快照，不含任何玩家的角色
+type ViewerSnapshot struct {
+	RoomID       string                `json:"roomID"`
+	Name         string                `json:"name"`
+	State        RoomState             `json:"state"`
+	Players      []protocol.PlayerInfo `json:"players"`
+	Phase        werewolf.PhaseType    `json:"phase,omitempty"`
+	Round        int                   `json:"round,omitempty"`
+	AlivePlayers []string              `json:"alivePlayers,omitempty"`
+}
+
+// viewerSnapshot 生成房间快照，夜晚的子阶段同样只显示为夜晚
+func (r *Room) viewerSnapshot() ViewerSnapshot {
+	r.mu.RLock()
+	snapshot := ViewerSnapshot{
+		RoomID: r.ID,
+		Name:   r.Name,
+		State:  r.State,
+	}
+	engine := r.Engine()
+	aliases := r.aliases
+	r.mu.RUnlock()
+
+	if engine == nil {
+		snapshot.Players = r.GetPlayerList()
+		return snapshot
+	}
+
+	state := engine.GetState()
+	snapshot.Players = r.playersInfo(state.Players, false)
+	snapshot.Phase = publicPhase(state.Phase)
+	snapshot.Round = state.Round
+	snapshot.AlivePlayers = r.activePlayers(state.AlivePlayers)
+
+	// 匿名对局中观众同样只看到化名
+	for i := range snapshot.Players {
+		snapshot.Players[i].ID = aliases.of(snapshot.Players[i].ID)
+	}
+	for i, playerID := range snapshot.AlivePlayers {
+		snapshot.AlivePlayers[i] = aliases.of(playerID)
+	}
+
+	return snapshot
+}
+
+// ViewerHandler 只读观看 HTTP 接口，供网页看板和直播叠加层展示对局进度
+//
+//	GET /rooms/{id}/events  以 Server-Sent Events 推送房间快照和之后的公开广播
+//
+// 只转发房间对所有座位的广播，观众看到的不会多于任何一名玩家看到的公开信息；
+// 角色只在对局结束时随 GAME_ENDED 公开。服务器设置了观看延迟时，快照和广播都推迟同样的时长。
+type ViewerHandler struct {
+	server *Server
+	mux    *http.ServeMux
+}
+
+// NewViewerHandler 创建只读观看接口
+func NewViewerHandler(server *Server) *ViewerHandler {
+	h := &ViewerHandler{
+		server: server,
+		mux:    http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /rooms/{id}/events", h.streamRoom)
+
+	return h
+}
+
+// ServeHTTP 实现 http.Handler 接口，允许任意来源的网页读取
+func (h *ViewerHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	h.mux.ServeHTTP(w, req)
+}
+
+// streamRoom 推送房间快照，之后逐条推送房间广播，直到客户端断开或房间关闭
+func (h *ViewerHandler) streamRoom(w http.ResponseWriter, req *http.Request) {
+	room := h.server.GetRoom(req.PathValue("id"))
+	if room == nil {
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	if status, err := room.admitViewer(); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// 先订阅再生成快照，快照之后的广播不会漏掉
+	messages, unsubscribe := room.viewers.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// 延迟观看时快照同样推迟，与之后的广播保持先后顺序
+	if room.viewers.delayed() {
+		room.viewers.sendSnapshot(messages, room.viewerSnapshot())
+	} else {
+		if err := writeEvent(w, "SNAPSHOT", room.viewerSnapshot()); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+
+	keepAlive := time.NewTicker(viewerKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event := <-messages:
+			if err := writeEvent(w, event.name, event.data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-room.closed:
+			return
+		case <-req.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent 写出一条 SSE 事件，数据为 JSON
+func writeEvent(w http.ResponseWriter, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
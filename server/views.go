@@ -0,0 +1,113 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// stateView 玩家在对局状态中能看到的私有信息
+type stateView struct {
+	teammates  []string
+	knownCamps map[string]werewolf.Camp
+	lover      string
+}
+
+// key 视图的缓存键，私有信息相同的玩家共用一条消息；没有私有信息时为空
+func (v stateView) key() string {
+	if len(v.teammates) == 0 && len(v.knownCamps) == 0 && v.lover == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(v.lover + "|" + strings.Join(v.teammates, ","))
+
+	ids := make([]string, 0, len(v.knownCamps))
+	for id := range v.knownCamps {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		b.WriteString("|" + id + "=" + string(v.knownCamps[id]))
+	}
+
+	return b.String()
+}
+
+// playerView 玩家的私有视图：狼人（包括狼王）看到同伴，预言家看到已经天亮公布过的查验结果，
+// 恋人看到对方（需持有读锁）
+func (r *Room) playerView(playerID string, state *werewolf.GameState) stateView {
+	view := stateView{lover: r.loverOf(playerID)}
+
+	roles := make(map[string]werewolf.PlayerState, len(state.Players))
+	for _, ps := range state.Players {
+		roles[ps.ID] = ps
+	}
+
+	self, ok := roles[playerID]
+	if !ok {
+		return view
+	}
+
+	switch self.Role {
+	case werewolf.RoleTypeWerewolf:
+		for _, ps := range state.Players {
+			if ps.Role == werewolf.RoleTypeWerewolf && ps.ID != playerID {
+				view.teammates = append(view.teammates, ps.ID)
+			}
+		}
+	case werewolf.RoleTypeSeer:
+		for _, action := range r.actions {
+			// 当晚的查验结果天亮才公布
+			if action.PlayerID != playerID || action.ActionType != protocol.ActionCheck {
+				continue
+			}
+			if action.Round == state.Round && state.Phase == werewolf.PhaseNight {
+				continue
+			}
+
+			if view.knownCamps == nil {
+				view.knownCamps = make(map[string]werewolf.Camp)
+			}
+			view.knownCamps[action.TargetID] = roleCamp(r.roleOf(roles[action.TargetID]))
+		}
+	}
+
+	return view
+}
+
+// viewCache 一次广播中按视图缓存的消息，每种视图只序列化一次
+type viewCache struct {
+	messages map[string]*protocol.Message
+	build    func(view stateView) *protocol.Message
+}
+
+// newViewCache 创建视图缓存，build 根据视图生成消息
+func newViewCache(build func(view stateView) *protocol.Message) *viewCache {
+	return &viewCache{
+		messages: make(map[string]*protocol.Message),
+		build:    build,
+	}
+}
+
+// message 视图对应的消息
+func (c *viewCache) message(view stateView) *protocol.Message {
+	key := view.key()
+	if msg, ok := c.messages[key]; ok {
+		return msg
+	}
+
+	msg := c.build(view)
+	c.messages[key] = msg
+	return msg
+}
+
+// withView 把私有视图填进完整状态
+func withView(state protocol.GameStateData, view stateView) protocol.GameStateData {
+	state.Teammates = view.teammates
+	state.KnownCamps = view.knownCamps
+	state.Lover = view.lover
+	return state
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// announcementInterval 夜晚播报每一步之间的间隔
+const announcementInterval = 2 * time.Second
+
+// nightStep 夜晚播报的一步：公开的主持词和只发给该角色的行动提示
+type nightStep struct {
+	role       werewolf.RoleType
+	open       string
+	close      string
+	private    string
+	firstNight bool // 只在第一夜播报
+}
+
+// nightScript 夜晚播报顺序
+//
+// 无论本局是否配置了该角色、该角色是否存活，都会完整播报所有步骤，
+// 这样公开信息不会泄露场上有哪些角色、谁正在行动。
+var nightScript = []nightStep{
+	{protocol.RoleTypeThief, "盗贼请睁眼", "盗贼请闭眼", "请从两张底牌中选择一张: steal 1 / steal 2", true},
+	{protocol.RoleTypeCupid, "丘比特请睁眼", "丘比特请闭眼", "请选择两名玩家成为恋人: link <编号> <编号>", true},
+	{werewolf.RoleTypeGuard, "守卫请睁眼", "守卫请闭眼", "请选择今晚要守护的玩家: protect <编号>", false},
+	{werewolf.RoleTypeWerewolf, "狼人请睁眼", "狼人请闭眼", "请与队友商议今晚的击杀目标: kill <编号>", false},
+	{werewolf.RoleTypeWitch, "女巫请睁眼", "女巫请闭眼", "请按提示决定是否使用解药和毒药，也可以直接 antidote / poison <编号>", false},
+	{werewolf.RoleTypeSeer, "预言家请睁眼", "预言家请闭眼", "请选择今晚要查验的玩家: check <编号>", false},
+}
+
+// publicPhase 对外公开的阶段：夜晚的子阶段一律显示为夜晚，避免泄露谁在行动
+func publicPhase(phase werewolf.PhaseType) werewolf.PhaseType {
+	if strings.HasPrefix(string(phase), string(werewolf.PhaseNight)) {
+		return werewolf.PhaseNight
+	}
+	return phase
+}
+
+// announceNight 按夜晚播报顺序公开主持词，并私下提示对应角色
+func (r *Room) announceNight(round int) {
+	r.announce("天黑请闭眼")
+
+	for _, step := range nightScript {
+		if !r.stillNight(round) {
+			return
+		}
+		if step.firstNight && round != 1 {
+			continue
+		}
+
+		time.Sleep(announcementInterval)
+		r.announce(step.open)
+		r.promptRole(step.role, step.private)
+		if step.role == werewolf.RoleTypeWitch {
+			r.startWitchFlow(round)
+		}
+
+		time.Sleep(announcementInterval)
+		r.announce(step.close)
+	}
+}
+
+// stillNight 检查是否仍处于指定回合的夜晚
+func (r *Room) stillNight(round int) bool {
+	if r.Engine() == nil {
+		return false
+	}
+
+	state := r.Engine().GetState()
+	return !state.IsEnded && state.Round == round && publicPhase(state.Phase) == werewolf.PhaseNight
+}
+
+// announce 向所有玩家广播主持词
+func (r *Room) announce(message string) {
+	msg, _ := protocol.NewMessage(protocol.MsgAnnouncement, protocol.AnnouncementData{
+		Message: message,
+	})
+
+	r.BroadcastMessage(msg)
+}
+
+// announcePrivate 只向一名玩家发送私密提示
+func (r *Room) announcePrivate(playerID, message string) {
+	r.mu.RLock()
+	player := r.Players[playerID]
+	r.mu.RUnlock()
+
+	if player == nil {
+		return
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgAnnouncement, protocol.AnnouncementData{
+		Message: message,
+		Private: true,
+	})
+	player.SendMessage(msg)
+}
+
+// promptRole 只向存活的指定角色发送行动提示
+func (r *Room) promptRole(role werewolf.RoleType, message string) {
+	msg, _ := protocol.NewMessage(protocol.MsgAnnouncement, protocol.AnnouncementData{
+		Message: message,
+		Private: true,
+	})
+
+	for _, ps := range r.Engine().GetState().Players {
+		r.mu.RLock()
+		player, exists := r.Players[ps.ID]
+		special := r.special.roles[ps.ID]
+		r.mu.RUnlock()
+
+		// 房间实现的角色按对外角色匹配，狼王仍按基础角色收到狼人的提示
+		if (ps.Role != role && special != role) || !ps.IsAlive {
+			continue
+		}
+
+		if exists {
+			player.SendMessage(msg)
+		}
+	}
+}
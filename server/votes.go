@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// announceVote 放逐投票中有人投票或改票时通知房间
+//
+// 隐藏投票的房间只公布谁已投票，改票不再通知，投票结束时由 revealVotes 同时公布每一票，
+// 避免后投的人看着别人的票跟票。
+func (r *Room) announceVote(playerID, targetID string, round int) {
+	r.mu.RLock()
+	hidden := r.rules.HiddenVotes
+	votes := 0
+	for _, action := range r.actions {
+		if action.ballot() && action.Round == round && action.PlayerID == playerID {
+			votes++
+		}
+	}
+	r.mu.RUnlock()
+
+	changed := votes > 1
+	if hidden && changed {
+		return
+	}
+
+	data := protocol.VoteCastData{
+		Round:   round,
+		VoterID: playerID,
+		Changed: changed,
+		Hidden:  hidden,
+	}
+	if !hidden {
+		data.TargetID = targetID
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgVoteCast, data)
+	r.BroadcastMessage(msg)
+}
+
+// revealVotes 放逐投票结束时同时公布每一票，同一玩家多次投票时以最后一票为准
+func (r *Room) revealVotes(round int) {
+	r.mu.RLock()
+	ballots := r.ballots(round)
+	r.mu.RUnlock()
+
+	if len(ballots) == 0 {
+		return
+	}
+
+	msg, _ := protocol.NewMessage(protocol.MsgVoteResult, protocol.VoteResultData{
+		Round:   round,
+		Ballots: ballots,
+	})
+	r.BroadcastMessage(msg)
+}
+
+// revealFinalVotes 对局在放逐投票后直接结束时，结束前公布这一轮的投票
+func (r *Room) revealFinalVotes() {
+	r.mu.RLock()
+	phase, round := r.lastPhase, r.lastRound
+	r.mu.RUnlock()
+
+	if phase == werewolf.PhaseVote && !r.dayCut(round) {
+		r.revealVotes(round)
+	}
+}
+
+// ballots 本轮每名玩家的放逐投票，同一玩家多次投票时以最后一票为准，空目标为弃权（需持有锁）
+func (r *Room) ballots(round int) map[string]string {
+	ballots := make(map[string]string)
+	for _, action := range r.actions {
+		if action.ballot() && action.Round == round {
+			ballots[action.PlayerID] = action.TargetID
+		}
+	}
+	return ballots
+}
+
+// ballot 是否是玩家本人的放逐投票；警长加权计票后房间替玩家交给引擎的票不算
+func (a ActionRecord) ballot() bool {
+	return a.ActionType == protocol.ActionVote && !a.Settled
+}
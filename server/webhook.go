@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// WebhookClient 向外部地址投递 JSON 事件
+type WebhookClient struct {
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewWebhookClient 创建 webhook 客户端
+func NewWebhookClient(logger *slog.Logger) *WebhookClient {
+	return &WebhookClient{
+		client: &http.Client{Timeout: webhookTimeout},
+		logger: logger,
+	}
+}
+
+// Post 同步投递 payload
+func (w *WebhookClient) Post(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshal webhook payload")
+	}
+
+	resp, err := w.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "post webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// PostAsync 在后台投递 payload，失败只记录日志
+func (w *WebhookClient) PostAsync(url string, payload interface{}) {
+	go func() {
+		if err := w.Post(url, payload); err != nil {
+			w.logger.Error("webhook delivery failed", "url", url, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxWhisperLength = 200              // 私聊内容的最大长度（字符）
+	whisperBurst     = 5                // whisperWindow 内最多发送的私聊条数
+	whisperWindow    = 10 * time.Second // 私聊限速的时间窗口
+)
+
+// handleWhisper 处理大厅私聊：转发给同房间的目标玩家，并回给发送者作为确认
+//
+// 对局进行中（包括暂停）不能私聊，避免场外交流；对方屏蔽了发送者时照常回复确认，但不转发。
+func (h *MessageHandler) handleWhisper(playerID string, data *protocol.WhisperData) error {
+	content := strings.TrimSpace(data.Content)
+	if content == "" {
+		return errors.New("whisper is empty")
+	}
+	if len([]rune(content)) > maxWhisperLength {
+		return errors.Errorf("whisper is too long (max %d characters)", maxWhisperLength)
+	}
+
+	player, room, targetID, err := h.roomTarget(playerID, data.PlayerID, data.TargetSeat)
+	if err != nil {
+		return err
+	}
+
+	room.mu.RLock()
+	state := room.State
+	target := room.Players[targetID]
+	room.mu.RUnlock()
+
+	if state.inGame() {
+		return errors.New("whispers are disabled during the game")
+	}
+
+	if !player.whispers.allow(time.Now(), whisperBurst, whisperWindow) {
+		return limitError(protocol.ErrorCodeRateLimited, "too many whispers, slow down")
+	}
+
+	whisper, _ := protocol.NewMessage(protocol.MsgWhisper, protocol.WhisperData{
+		PlayerID: targetID,
+		FromID:   playerID,
+		Content:  content,
+	})
+
+	// 私聊同样经过房间的出站中间件，被拦下时发送者会收到说明
+	if whisper = room.filterOutbound(whisper); whisper == nil {
+		return nil
+	}
+
+	if target != nil && !target.muted.has(playerID) {
+		target.SendMessage(whisper)
+	}
+
+	return player.SendMessage(whisper.ReplyTo(h.request))
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+	"github.com/pkg/errors"
+)
+
+// witchStepTimeout 女巫每一步的时限，超时视为不使用
+const witchStepTimeout = 15 * time.Second
+
+// ErrStalePrompt 回答的不是当前这一步
+var ErrStalePrompt = errors.New("prompt has expired")
+
+// witchFlow 女巫夜里分步用药：先决定是否救今晚的刀口，再决定毒谁，由 r.mu 保护
+type witchFlow struct {
+	playerID string
+	round    int
+	step     string
+	timer    *time.Timer
+	deadline time.Time // 当前这一步的截止时间
+	epoch    int       // 每发出或结束一步加一，过期的计时器和回答据此失效
+}
+
+// active 是否正在等待女巫回答
+func (w *witchFlow) active() bool {
+	return w.timer != nil
+}
+
+// promptID 当前这一步的编号
+func (w *witchFlow) promptID() string {
+	return fmt.Sprintf("witch-%d-%d", w.round, w.epoch)
+}
+
+// startWitchFlow 轮到女巫时开始分步用药，女巫出局、由机器人扮演或两瓶药都已用完时不开始
+//
+// 今晚没有刀口或按规则不能自救时跳过解药这一步。直接发送 antidote、poison 动作仍然有效。
+func (r *Room) startWitchFlow(round int) {
+	witchID := r.aliveWitch()
+	if witchID == "" {
+		return
+	}
+
+	// 轮到女巫时今晚的刀口已经定下，重新推送可用技能，解药才有目标
+	r.SendAllowedSkills(witchID)
+
+	antidoteLeft, poisonLeft := r.potionsLeft()
+	victim := r.nightVictim(round)
+
+	switch {
+	case antidoteLeft && victim != "" && r.checkWitchRules(witchID, round) == nil:
+		r.promptWitch(witchID, round, protocol.SkillStepAntidote)
+	case poisonLeft:
+		r.promptWitch(witchID, round, protocol.SkillStepPoison)
+	}
+}
+
+// aliveWitch 存活且由玩家扮演的女巫，没有时返回空
+func (r *Room) aliveWitch() string {
+	for _, ps := range r.Engine().GetState().Players {
+		if ps.Role != werewolf.RoleTypeWitch || !ps.IsAlive {
+			continue
+		}
+
+		r.mu.RLock()
+		_, exists := r.Players[ps.ID]
+		_, isBot := r.bots[ps.ID]
+		r.mu.RUnlock()
+
+		if exists && !isBot {
+			return ps.ID
+		}
+	}
+	return ""
+}
+
+// potionsLeft 本局女巫还剩哪些药
+func (r *Room) potionsLeft() (antidote, poison bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	antidote, poison = true, true
+	for _, action := range r.actions {
+		switch action.ActionType {
+		case protocol.ActionAntidote:
+			antidote = false
+		case protocol.ActionPoison:
+			poison = false
+		}
+	}
+	return antidote, poison
+}
+
+// witchSkills 女巫还能用的药：解药的目标是今晚的刀口，按自救规则不能救时不列出；毒药的目标是其他存活玩家
+func (r *Room) witchSkills(witchID string, others []string, round int) []protocol.SkillInfo {
+	antidoteLeft, poisonLeft := r.potionsLeft()
+
+	var skills []protocol.SkillInfo
+	if victim := r.nightVictim(round); antidoteLeft && victim != "" && r.checkWitchRules(witchID, round) == nil {
+		skills = append(skills, protocol.SkillInfo{ActionType: protocol.ActionAntidote, Targets: []string{victim}})
+	}
+	if poisonLeft {
+		skills = append(skills, protocol.SkillInfo{ActionType: protocol.ActionPoison, NeedsTarget: true, Targets: others})
+	}
+	return skills
+}
+
+// promptWitch 向女巫发出一步提示并开始计时，已经不是该回合的夜晚时不发
+func (r *Room) promptWitch(witchID string, round int, step string) {
+	if !r.stillNight(round) {
+		return
+	}
+
+	timeout := r.scaled(witchStepTimeout)
+	data := protocol.SkillPromptData{
+		Step:           step,
+		TimeoutSeconds: int(timeout.Seconds()),
+	}
+
+	switch step {
+	case protocol.SkillStepAntidote:
+		data.Victim = r.nightVictim(round)
+		data.Message = fmt.Sprintf("今晚 %s 被刀，是否使用解药？witch yes / witch no", r.playerLabel(data.Victim))
+	case protocol.SkillStepPoison:
+		for _, id := range r.alivePlayers() {
+			if id != witchID {
+				data.Targets = append(data.Targets, id)
+			}
+		}
+		data.Message = "是否使用毒药？witch <编号> / witch pass"
+	}
+
+	r.mu.Lock()
+	w := &r.witch
+	if w.active() {
+		w.timer.Stop()
+	}
+	w.playerID, w.round, w.step = witchID, round, step
+	w.epoch++
+	epoch := w.epoch
+	w.timer = time.AfterFunc(timeout, func() { r.witchTimeout(epoch) })
+	w.deadline = time.Now().Add(timeout)
+	data.PromptID = w.promptID()
+	player := r.Players[witchID]
+	r.mu.Unlock()
+
+	if player != nil {
+		msg, _ := protocol.NewMessage(protocol.MsgSkillPrompt, data)
+		player.SendMessage(msg)
+	}
+}
+
+// witchRespond 处理女巫对当前一步的回答，返回给女巫的结果说明
+//
+// 解药这一步无论救不救都接着问毒药；毒药这一步用药或放弃后结束。
+// 用药被拒绝时重新发出同一步，女巫可以换个目标再试。
+func (r *Room) witchRespond(playerID, promptID string, accept bool, targetID string) (string, error) {
+	r.mu.Lock()
+	w := &r.witch
+	if !w.active() || w.playerID != playerID || w.promptID() != promptID {
+		r.mu.Unlock()
+		return "", ErrStalePrompt
+	}
+	step, round := w.step, w.round
+	w.timer.Stop()
+	w.timer = nil
+	w.epoch++
+	r.mu.Unlock()
+
+	switch step {
+	case protocol.SkillStepAntidote:
+		message := "不使用解药"
+		if accept {
+			if err := r.PerformAction(playerID, protocol.ActionAntidote, "", nil); err != nil {
+				r.promptWitch(playerID, round, step)
+				return "", err
+			}
+			message = "已使用解药"
+		}
+		if _, poisonLeft := r.potionsLeft(); poisonLeft {
+			r.promptWitch(playerID, round, protocol.SkillStepPoison)
+		}
+		return message, nil
+
+	case protocol.SkillStepPoison:
+		if !accept {
+			return "不使用毒药", nil
+		}
+		if targetID == "" {
+			r.promptWitch(playerID, round, step)
+			return "", errors.New("poison needs a target")
+		}
+		if err := r.PerformAction(playerID, protocol.ActionPoison, targetID, nil); err != nil {
+			r.promptWitch(playerID, round, step)
+			return "", err
+		}
+		return "已使用毒药", nil
+	}
+
+	return "", errors.Errorf("unknown witch step: %s", step)
+}
+
+// witchTimeout 女巫在时限内没有回答，视为不使用这一步的药
+func (r *Room) witchTimeout(epoch int) {
+	r.mu.Lock()
+	w := &r.witch
+	if epoch != w.epoch || !w.active() {
+		r.mu.Unlock()
+		return
+	}
+	w.timer = nil
+	w.epoch++
+	playerID, round, step := w.playerID, w.round, w.step
+	player := r.Players[playerID]
+	r.mu.Unlock()
+
+	r.missedAction(playerID)
+
+	message := "超时未选择，视为不使用毒药"
+	if step == protocol.SkillStepAntidote {
+		message = "超时未选择，视为不使用解药"
+	}
+
+	if player != nil {
+		msg, _ := protocol.NewMessage(protocol.MsgAnnouncement, protocol.AnnouncementData{
+			Message: message,
+			Private: true,
+		})
+		player.SendMessage(msg)
+	}
+
+	if step == protocol.SkillStepAntidote {
+		if _, poisonLeft := r.potionsLeft(); poisonLeft {
+			r.promptWitch(playerID, round, protocol.SkillStepPoison)
+		}
+	}
+}
+
+// stopWitchFlow 夜晚结束时丢弃还没回答的一步
+func (r *Room) stopWitchFlow() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.witch.active() {
+		r.witch.timer.Stop()
+	}
+	r.witch = witchFlow{epoch: r.witch.epoch + 1}
+}
+
+// handleSkillResponse 处理女巫分步用药和狼王开枪提示的回答，在房间事件循环中结算
+func (h *MessageHandler) handleSkillResponse(playerID string, data *protocol.SkillResponseData) error {
+	player := h.server.GetPlayer(playerID)
+	if player == nil {
+		return errors.New("player not found")
+	}
+
+	room := h.server.GetRoom(player.RoomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+
+	if room.Engine() == nil {
+		return errors.New("game not started")
+	}
+
+	// 暂停期间不回答提示，回答会开始下一步的计时
+	if room.isPaused() {
+		return errors.New("game is paused")
+	}
+
+	targetID := ""
+	if data.Accept {
+		resolved, err := room.resolveTarget(data.TargetID, data.TargetSeat)
+		if err != nil {
+			return err
+		}
+		targetID = resolved
+	}
+
+	return room.submit(func() {
+		room.markActive(playerID)
+
+		respond := room.witchRespond
+		if isShotPrompt(data.PromptID) {
+			respond = room.shotRespond
+		}
+
+		message, err := respond(playerID, data.PromptID, data.Accept, targetID)
+
+		result := protocol.ActionResultData{Success: err == nil, Message: message}
+		if err != nil {
+			room.recordRejection(playerID, err)
+			result.Message = err.Error()
+			resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, result)
+			player.SendMessage(resultMsg.ReplyTo(h.request))
+			return
+		}
+
+		resultMsg, _ := protocol.NewMessage(protocol.MsgActionResult, result)
+		room.sendPrivateResult(player, resultMsg.ReplyTo(h.request))
+		room.SendGameState()
+	})
+}
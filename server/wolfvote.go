@@ -0,0 +1,112 @@
+package main
+
+import (
+	"github.com/Zereker/game/protocol"
+	"github.com/Zereker/werewolf"
+)
+
+// wolfVotes 狼人当晚的刀口选择，由 r.mu 保护
+//
+// 引擎以最后一次击杀为准；多数决时房间把每次击杀的目标换成当前多数的目标再交给引擎，
+// 这样天亮前最后一次被接受的击杀总是狼队的共同决定。
+type wolfVotes struct {
+	round   int
+	choices map[string]wolfChoice // 狼人ID -> 选择
+	seq     int
+}
+
+// wolfChoice 一名狼人的选择，seq 越大越晚提交
+type wolfChoice struct {
+	target string
+	seq    int
+}
+
+// withChoice 返回加上一名狼人新选择后的各狼人选择，不修改原记录
+func (v *wolfVotes) withChoice(round int, wolfID, target string) map[string]wolfChoice {
+	choices := make(map[string]wolfChoice, len(v.choices)+1)
+	if v.round == round {
+		for id, choice := range v.choices {
+			choices[id] = choice
+		}
+	}
+	choices[wolfID] = wolfChoice{target: target, seq: v.seq + 1}
+	return choices
+}
+
+// decideWolfKill 按规则从狼人的选择中决定刀口
+//
+// last 取最后提交的目标；majority 取票数最多的目标，平票时取其中最近被选的目标。
+func decideWolfKill(rule string, choices map[string]wolfChoice) string {
+	votes := make(map[string]int)
+	latest := make(map[string]int)
+	lastTarget, lastSeq := "", 0
+
+	for _, choice := range choices {
+		votes[choice.target]++
+		if choice.seq > latest[choice.target] {
+			latest[choice.target] = choice.seq
+		}
+		if choice.seq > lastSeq {
+			lastTarget, lastSeq = choice.target, choice.seq
+		}
+	}
+
+	if rule != protocol.WolfKillMajority {
+		return lastTarget
+	}
+
+	target := ""
+	for candidate, count := range votes {
+		if target == "" || count > votes[target] ||
+			(count == votes[target] && latest[candidate] > latest[target]) {
+			target = candidate
+		}
+	}
+	return target
+}
+
+// wolfKillTarget 一名狼人选择 target 后按本局规则应交给引擎的击杀目标
+func (r *Room) wolfKillTarget(wolfID, target string, round int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return decideWolfKill(r.rules.WolfKill, r.wolfVotes.withChoice(round, wolfID, target))
+}
+
+// recordWolfVote 击杀被引擎接受后记下狼人的选择，并把当前的选择和刀口发给存活的狼人
+func (r *Room) recordWolfVote(wolfID, target string, round int) {
+	state := r.Engine().GetState()
+
+	r.mu.Lock()
+	v := &r.wolfVotes
+	v.choices = v.withChoice(round, wolfID, target)
+	v.round = round
+	v.seq++
+
+	data := protocol.WolfVoteUpdateData{
+		Round:    round,
+		PlayerID: wolfID,
+		Votes:    make(map[string]string, len(v.choices)),
+		Target:   decideWolfKill(r.rules.WolfKill, v.choices),
+		Rule:     r.rules.WolfKill,
+	}
+	for id, choice := range v.choices {
+		data.Votes[id] = choice.target
+	}
+
+	var wolves []*Player
+	for _, ps := range state.Players {
+		if ps.Role != werewolf.RoleTypeWerewolf || !ps.IsAlive {
+			continue
+		}
+		if player, ok := r.Players[ps.ID]; ok {
+			wolves = append(wolves, player)
+		}
+	}
+	r.mu.Unlock()
+
+	msg, _ := protocol.NewMessage(protocol.MsgWolfVoteUpdate, data)
+	for _, player := range wolves {
+		player.SendMessage(msg)
+	}
+}